@@ -1,10 +1,9 @@
-//go:build js && wasm
-
 package assets
 
 import "embed"
 
-// TexturesFS embeds the default watercolor texture PNGs for js/wasm builds.
+// TexturesFS embeds the default watercolor texture PNGs so that binaries
+// (native and js/wasm) can render without an on-disk assets directory.
 //
 // NOTE: go:embed patterns must not use ".." and must be relative to this file.
 // Keeping the embed source here (repo-root assets/) allows us to embed assets
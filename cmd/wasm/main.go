@@ -191,25 +191,24 @@ func watercolorOverpassQueryForTile(this js.Value, args []js.Value) interface{}
 	}
 }
 
-// watercolorRenderTileFromOverpassJSON renders a PNG tile (base64) from Overpass JSON.
-// Args: requestJson (GenerateTileRequest), overpassJson (string)
-func watercolorRenderTileFromOverpassJSON(this js.Value, args []js.Value) interface{} {
-	start := time.Now()
+// renderTileFromOverpassArgs renders the tile of a requestJson (GenerateTileRequest) from
+// overpassJson (string), the arguments shared by the render exports.
+func renderTileFromOverpassArgs(args []js.Value) (*image.NRGBA, error) {
 	if len(args) < 2 {
-		return map[string]any{"error": "missing arguments"}
+		return nil, fmt.Errorf("missing arguments")
 	}
 
 	var req GenerateTileRequest
 	if err := json.Unmarshal([]byte(args[0].String()), &req); err != nil {
-		return map[string]any{"error": fmt.Sprintf("failed to parse request: %v", err)}
+		return nil, fmt.Errorf("failed to parse request: %w", err)
 	}
 	overpassJSON := args[1].String()
 	if strings.TrimSpace(overpassJSON) == "" {
-		return map[string]any{"error": "empty Overpass JSON"}
+		return nil, fmt.Errorf("empty Overpass JSON")
 	}
 
 	if err := ensureTexturesLoaded(); err != nil {
-		return map[string]any{"error": fmt.Sprintf("failed to load textures: %v", err)}
+		return nil, fmt.Errorf("failed to load textures: %w", err)
 	}
 
 	tileSize := tileSizeForRequest(req)
@@ -233,7 +232,7 @@ func watercolorRenderTileFromOverpassJSON(this js.Value, args []js.Value) interf
 
 	result, err := datasource.UnmarshalOverpassJSON([]byte(overpassJSON))
 	if err != nil {
-		return map[string]any{"error": fmt.Sprintf("failed to parse Overpass JSON: %v", err)}
+		return nil, fmt.Errorf("failed to parse Overpass JSON: %w", err)
 	}
 	features := datasource.ExtractFeaturesFromOverpassResult(result)
 
@@ -261,7 +260,7 @@ func watercolorRenderTileFromOverpassJSON(this js.Value, args []js.Value) interf
 	if waterImg != nil {
 		waterPainted, err := watercolor.PaintLayer(waterImg, geojson.LayerWater, params)
 		if err != nil {
-			return map[string]any{"error": fmt.Sprintf("failed to paint water: %v", err)}
+			return nil, fmt.Errorf("failed to paint water: %w", err)
 		}
 		painted[geojson.LayerWater] = waterPainted
 	}
@@ -276,26 +275,26 @@ func watercolorRenderTileFromOverpassJSON(this js.Value, args []js.Value) interf
 		return finalMask, nil
 	}()
 	if err != nil {
-		return map[string]any{"error": fmt.Sprintf("failed to process non-land mask: %v", err)}
+		return nil, fmt.Errorf("failed to process non-land mask: %w", err)
 	}
 
 	paintedLand, err := watercolor.PaintLayerFromFinalMask(landMask, geojson.LayerLand, params)
 	if err != nil {
-		return map[string]any{"error": fmt.Sprintf("failed to paint land: %v", err)}
+		return nil, fmt.Errorf("failed to paint land: %w", err)
 	}
 	painted[geojson.LayerLand] = paintedLand
 
 	if roadsImg != nil {
 		roadsPainted, err := watercolor.PaintLayer(roadsImg, geojson.LayerRoads, params)
 		if err != nil {
-			return map[string]any{"error": fmt.Sprintf("failed to paint roads: %v", err)}
+			return nil, fmt.Errorf("failed to paint roads: %w", err)
 		}
 		painted[geojson.LayerRoads] = roadsPainted
 	}
 	if highwaysImg != nil {
 		highwaysPainted, err := watercolor.PaintLayer(highwaysImg, geojson.LayerHighways, params)
 		if err != nil {
-			return map[string]any{"error": fmt.Sprintf("failed to paint highways: %v", err)}
+			return nil, fmt.Errorf("failed to paint highways: %w", err)
 		}
 		painted[geojson.LayerHighways] = highwaysPainted
 	}
@@ -303,28 +302,28 @@ func watercolorRenderTileFromOverpassJSON(this js.Value, args []js.Value) interf
 		parksMask := mask.MinMask(mask.ExtractAlphaMask(parksImg), landMask)
 		parksPainted, err := watercolor.PaintLayerFromMask(parksMask, geojson.LayerParks, params)
 		if err != nil {
-			return map[string]any{"error": fmt.Sprintf("failed to paint parks: %v", err)}
+			return nil, fmt.Errorf("failed to paint parks: %w", err)
 		}
 		painted[geojson.LayerParks] = parksPainted
 	}
-	if civicImg := raw[geojson.LayerCivic]; civicImg != nil {
-		civicMask := mask.MinMask(mask.ExtractAlphaMask(civicImg), landMask)
-		civicPainted, err := watercolor.PaintLayerFromMask(civicMask, geojson.LayerCivic, params)
+	if urbanImg := raw[geojson.LayerUrban]; urbanImg != nil {
+		urbanMask := mask.MinMask(mask.ExtractAlphaMask(urbanImg), landMask)
+		urbanPainted, err := watercolor.PaintLayerFromMask(urbanMask, geojson.LayerUrban, params)
 		if err != nil {
-			return map[string]any{"error": fmt.Sprintf("failed to paint civic: %v", err)}
+			return nil, fmt.Errorf("failed to paint urban: %w", err)
 		}
-		painted[geojson.LayerCivic] = civicPainted
+		painted[geojson.LayerUrban] = urbanPainted
 	}
 
 	base := texture.TileTexture(embeddedTextures[geojson.LayerPaper], params.TileSize, params.OffsetX, params.OffsetY)
 	composited, err := composite.CompositeLayersOverBase(
 		base,
 		painted,
-		[]geojson.LayerType{geojson.LayerWater, geojson.LayerLand, geojson.LayerParks, geojson.LayerCivic, geojson.LayerRoads, geojson.LayerHighways},
+		params.Order(),
 		params.TileSize,
 	)
 	if err != nil {
-		return map[string]any{"error": fmt.Sprintf("failed to composite layers: %v", err)}
+		return nil, fmt.Errorf("failed to composite layers: %w", err)
 	}
 
 	final := composited
@@ -332,6 +331,17 @@ func watercolorRenderTileFromOverpassJSON(this js.Value, args []js.Value) interf
 		cropRect := image.Rect(padPx, padPx, padPx+tileSize, padPx+tileSize)
 		final = cropNRGBA(composited, cropRect)
 	}
+	return final, nil
+}
+
+// watercolorRenderTileFromOverpassJSON renders a PNG tile (base64) from Overpass JSON.
+// Args: requestJson (GenerateTileRequest), overpassJson (string)
+func watercolorRenderTileFromOverpassJSON(this js.Value, args []js.Value) interface{} {
+	start := time.Now()
+	final, err := renderTileFromOverpassArgs(args)
+	if err != nil {
+		return map[string]any{"error": err.Error()}
+	}
 
 	var buf bytes.Buffer
 	enc := png.Encoder{CompressionLevel: png.DefaultCompression}
@@ -346,6 +356,36 @@ func watercolorRenderTileFromOverpassJSON(this js.Value, args []js.Value) interf
 	}
 }
 
+// watercolorRenderTileRGBAFromOverpassJSON renders a tile like
+// watercolorRenderTileFromOverpassJSON, but returns its raw pixels for
+// ctx.putImageData(new ImageData(pixels, width, height), 0, 0), skipping the PNG encode
+// here and the image decode in the browser.
+// Args: requestJson (GenerateTileRequest), overpassJson (string)
+// Returns: {pixels: Uint8ClampedArray (non-premultiplied RGBA), width, height, ms}
+func watercolorRenderTileRGBAFromOverpassJSON(this js.Value, args []js.Value) interface{} {
+	start := time.Now()
+	final, err := renderTileFromOverpassArgs(args)
+	if err != nil {
+		return map[string]any{"error": err.Error()}
+	}
+
+	// Copy into a JS-owned array: a view over the WASM memory would be detached as soon
+	// as the Go heap grows, and its bytes reused once final is collected
+	w, h := final.Rect.Dx(), final.Rect.Dy()
+	pixels := js.Global().Get("Uint8ClampedArray").New(4 * w * h)
+	for y := 0; y < h; y++ {
+		row := final.Pix[final.PixOffset(final.Rect.Min.X, final.Rect.Min.Y+y):][:4*w]
+		js.CopyBytesToJS(pixels.Call("subarray", 4*w*y, 4*w*(y+1)), row)
+	}
+
+	return map[string]any{
+		"pixels": pixels,
+		"width":  w,
+		"height": h,
+		"ms":     time.Since(start).Milliseconds(),
+	}
+}
+
 func cropNRGBA(src image.Image, rect image.Rectangle) *image.NRGBA {
 	if src == nil {
 		return nil
@@ -378,6 +418,7 @@ func main() {
 	js.Global().Set("watercolorGenerateTile", js.FuncOf(generateTile))
 	js.Global().Set("watercolorOverpassQueryForTile", js.FuncOf(watercolorOverpassQueryForTile))
 	js.Global().Set("watercolorRenderTileFromOverpassJSON", js.FuncOf(watercolorRenderTileFromOverpassJSON))
+	js.Global().Set("watercolorRenderTileRGBAFromOverpassJSON", js.FuncOf(watercolorRenderTileRGBAFromOverpassJSON))
 	js.Global().Set("watercolorGetConcurrency", js.FuncOf(getConcurrency))
 	js.Global().Set("watercolorInit", js.FuncOf(initGame))
 
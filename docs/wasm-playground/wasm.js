@@ -84,25 +84,26 @@ class WaterColorMapPlayground {
     const self = this;
     const WaterColorGridLayer = L.GridLayer.extend({
       createTile(coords, done) {
-        const img = document.createElement("img");
-        img.alt = "";
-        img.setAttribute("role", "presentation");
-
         const dpr = window.devicePixelRatio || 1;
         const is2x = dpr >= 2;
         const z = coords.z;
         const x = coords.x;
         const y = coords.y;
 
+        // Tiles are canvases so WASM-rendered pixels can be drawn without a PNG round trip
+        const canvas = document.createElement("canvas");
+        canvas.width = canvas.height = is2x ? 512 : 256;
+        canvas.setAttribute("role", "presentation");
+
         self
-          .loadTileToImg({ z, x, y, is2x, img })
-          .then(() => done(null, img))
+          .loadTileToCanvas({ z, x, y, is2x, canvas })
+          .then(() => done(null, canvas))
           .catch((err) => {
             console.warn("tile load failed", err);
-            done(err, img);
+            done(err, canvas);
           });
 
-        return img;
+        return canvas;
       },
     });
 
@@ -163,7 +164,7 @@ class WaterColorMapPlayground {
     }
   }
 
-  async loadTileToImg({ z, x, y, is2x, img }) {
+  async loadTileToCanvas({ z, x, y, is2x, canvas }) {
     // Step 1: Try to fetch from static pre-generated tiles
     // Only for standard resolution tiles (is2x=false) and supported zoom levels (13-14)
     if (!is2x && z >= 13 && z <= 14) {
@@ -173,7 +174,7 @@ class WaterColorMapPlayground {
         const response = await fetch(staticTileUrl);
         if (response.ok) {
           const blob = await response.blob();
-          img.src = URL.createObjectURL(blob);
+          await this.drawImageURL(canvas, URL.createObjectURL(blob));
           this.updateStatus(`Loaded static tile z${z} ${x}/${y}`);
           return;
         }
@@ -186,12 +187,12 @@ class WaterColorMapPlayground {
 
     // Step 2: Fall back to existing WASM pipeline
     if (typeof watercolorOverpassQueryForTile !== "function") {
-      img.src = this.makePlaceholderDataUrl("WASM not ready");
+      await this.drawImageURL(canvas, this.makePlaceholderDataUrl("WASM not ready"));
       this.updateStatus("WASM not ready");
       return;
     }
     if (typeof watercolorRenderTileFromOverpassJSON !== "function") {
-      img.src = this.makePlaceholderDataUrl("WASM API missing");
+      await this.drawImageURL(canvas, this.makePlaceholderDataUrl("WASM API missing"));
       this.updateStatus("WASM API missing");
       return;
     }
@@ -199,7 +200,7 @@ class WaterColorMapPlayground {
     const req = { zoom: z, x, y, hidpi: is2x };
     const q = watercolorOverpassQueryForTile(JSON.stringify(req));
     if (!q || !q.query) {
-      img.src = this.makePlaceholderDataUrl("Query error");
+      await this.drawImageURL(canvas, this.makePlaceholderDataUrl("Query error"));
       return;
     }
 
@@ -211,7 +212,7 @@ class WaterColorMapPlayground {
       this.updateStatus(`Fetching z${z} ${x}/${y} from Overpass...`);
       overpassJSON = await this.fetchOverpassJSON(q.query);
     } catch (err) {
-      img.src = this.makePlaceholderDataUrl("Overpass error");
+      await this.drawImageURL(canvas, this.makePlaceholderDataUrl("Overpass error"));
       this.updateStatus(`Overpass error: ${err.message}`);
       return;
     } finally {
@@ -222,31 +223,68 @@ class WaterColorMapPlayground {
     await this.renderSemaphore.acquire();
     try {
       this.updateStatus(`Rendering z${z} ${x}/${y}...`);
-      const rendered = watercolorRenderTileFromOverpassJSON(
-        JSON.stringify(req),
-        overpassJSON,
-      );
+      // Raw pixels skip the PNG encode in WASM and the decode here; older WASM builds
+      // only have the PNG export
+      const raw = typeof watercolorRenderTileRGBAFromOverpassJSON === "function";
+      const render = raw
+        ? watercolorRenderTileRGBAFromOverpassJSON
+        : watercolorRenderTileFromOverpassJSON;
+      const rendered = render(JSON.stringify(req), overpassJSON);
 
-      if (!rendered || !rendered.pngBase64) {
+      if (!rendered || (raw ? !rendered.pixels : !rendered.pngBase64)) {
         throw new Error(
           rendered && rendered.error ? rendered.error : "render failed",
         );
       }
 
-      img.src = `data:${rendered.mime || "image/png"};base64,${
-        rendered.pngBase64
-      }`;
+      // Time from the WASM result to pixels on the canvas, to compare both paths
+      const drawStart = performance.now();
+      if (raw) {
+        const data = new ImageData(
+          rendered.pixels,
+          rendered.width,
+          rendered.height,
+        );
+        canvas.getContext("2d").putImageData(data, 0, 0);
+      } else {
+        await this.drawImageURL(
+          canvas,
+          `data:${rendered.mime || "image/png"};base64,${rendered.pngBase64}`,
+        );
+      }
+      const drawMs = performance.now() - drawStart;
+
       if (typeof rendered.ms === "number") {
-        this.updateStatus(`Rendered z${z} ${x}/${y} in ${rendered.ms}ms`);
+        this.updateStatus(
+          `Rendered z${z} ${x}/${y} in ${rendered.ms}ms, drawn in ${drawMs.toFixed(
+            1,
+          )}ms (${raw ? "raw RGBA" : "PNG decode"})`,
+        );
       }
     } catch (err) {
-      img.src = this.makePlaceholderDataUrl("Render error");
+      await this.drawImageURL(canvas, this.makePlaceholderDataUrl("Render error"));
       this.updateStatus(`Render error: ${err.message}`);
     } finally {
       this.renderSemaphore.release();
     }
   }
 
+  // drawImageURL loads an image URL (blob, data or SVG) and draws it scaled over the canvas.
+  async drawImageURL(canvas, url) {
+    const img = new Image();
+    img.src = url;
+    try {
+      await img.decode();
+      canvas
+        .getContext("2d")
+        .drawImage(img, 0, 0, canvas.width, canvas.height);
+    } finally {
+      if (url.startsWith("blob:")) {
+        URL.revokeObjectURL(url);
+      }
+    }
+  }
+
   makePlaceholderDataUrl(message) {
     const svg = `<?xml version="1.0" encoding="UTF-8"?>
 <svg xmlns="http://www.w3.org/2000/svg" width="256" height="256">
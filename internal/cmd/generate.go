@@ -2,20 +2,35 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"iter"
+	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"regexp"
 	"runtime"
+	"slices"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"syscall"
+	"time"
 
 	"github.com/MeKo-Tech/watercolormap/internal/datasource"
+	"github.com/MeKo-Tech/watercolormap/internal/geojson"
+	"github.com/MeKo-Tech/watercolormap/internal/mask"
 	"github.com/MeKo-Tech/watercolormap/internal/mbtiles"
 	"github.com/MeKo-Tech/watercolormap/internal/pipeline"
+	"github.com/MeKo-Tech/watercolormap/internal/server"
 	"github.com/MeKo-Tech/watercolormap/internal/tile"
+	"github.com/MeKo-Tech/watercolormap/internal/types"
+	"github.com/MeKo-Tech/watercolormap/internal/watercolor"
 	"github.com/MeKo-Tech/watercolormap/internal/worker"
+	orbgeojson "github.com/paulmach/orb/geojson"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -34,27 +49,46 @@ func init() {
 	generateCmd.Flags().IntP("zoom", "z", 13, "Zoom level (for single tile mode)")
 	generateCmd.Flags().IntP("x", "x", 0, "X tile coordinate (for single tile mode)")
 	generateCmd.Flags().IntP("y", "y", 0, "Y tile coordinate (for single tile mode)")
+	generateCmd.Flags().Bool("fetch-only", false, "Fetch the tile's OSM data without rendering it (single tile mode; combine with --dump-json)")
+	generateCmd.Flags().String("dump-json", "", "Write the fetched features of the tile as GeoJSON to this file (single tile mode)")
 
 	// Batch generation flags
 	generateCmd.Flags().String("bbox", "", "Bounding box: minLon,minLat,maxLon,maxLat (e.g., \"9.7,52.3,9.9,52.4\")")
 	generateCmd.Flags().Int("zoom-min", 0, "Minimum zoom level for batch generation")
 	generateCmd.Flags().Int("zoom-max", 0, "Maximum zoom level for batch generation")
 	generateCmd.Flags().IntP("workers", "w", 0, "Number of parallel workers (default: number of CPUs)")
+	generateCmd.Flags().String("concurrency-per-zoom", "", "Per-zoom worker counts as zoom:workers pairs, each applying up to the next listed zoom (e.g., \"5:1,10:4,14:8\"; lower zooms use --workers)")
 	generateCmd.Flags().Bool("progress", true, "Show progress bar during batch generation")
+	generateCmd.Flags().String("progress-http", "", "Stream batch progress as Server-Sent Events from GET /progress on this address (e.g. :9090; empty = off)")
 	generateCmd.Flags().Bool("allow-failures", false, "Continue generation even if some tiles fail (useful for CI/CD with API rate limits)")
+	generateCmd.Flags().Int("max-tiles", 0, "Stop after this many base tiles (metatile blocks with --metatile) and skip the rest, e.g. for CI smoke tests; 0 = no limit")
+	generateCmd.Flags().Duration("max-duration", 0, "Stop after this long, cancel the remaining tiles and report them as skipped (e.g., \"5m\"); 0 = no limit")
+	generateCmd.Flags().String("metatile", "", "Render NxN blocks of tiles in one pass during batch generation (e.g., \"4x4\")")
+	generateCmd.Flags().Bool("pyramid-from-base", false, "Render only --zoom-max and build the lower zooms by downsampling their children (much faster; parents show the shrunk base-zoom texture and detail instead of a native render; folder format only)")
+	generateCmd.Flags().Int("noise-cache", 4096, "Keep this many 64x64 slabs of the noise field (4 KiB each) in memory for reuse by neighboring tiles during batch generation; 0 = off")
+	generateCmd.Flags().Bool("fetch-per-column", false, "Fetch OSM data once per --zoom-min tile at --zoom-max detail and render all zooms below it from that data (cuts Overpass queries for deep pyramids of small areas)")
 
 	// Common flags
 	generateCmd.Flags().Bool("force", false, "Force regeneration even if tile exists")
+	generateCmd.Flags().Bool("rerender-changed", false, "Fetch the data of existing tiles and render them again only if it differs from the data recorded in their metadata sidecar (implies --emit-metadata)")
+	generateCmd.Flags().Duration("max-age", 0, "Render existing tiles written longer ago than this again, e.g. 720h (0 = keep existing tiles)")
 	generateCmd.Flags().Int("tile-size", 256, "Tile size in pixels (typically 256 or 512 for Hi-DPI)")
 	generateCmd.Flags().Bool("hidpi", false, "Also generate a 2x (@2x) tile alongside the base tile")
 	generateCmd.Flags().String("png-compression", "default", "PNG compression (default, speed, best, none)")
 	generateCmd.Flags().Int64("seed", 1337, "Deterministic seed for noise/texture alignment")
-	generateCmd.Flags().Bool("keep-layers", false, "Keep intermediate rendered layer PNGs for debugging")
+	generateCmd.Flags().String("noise-seed-mode", "global", "Noise seeding: global (seamless, continuous field) or per-tile (no large-scale banding, small seams)")
+	generateCmd.Flags().Bool("seed-from-coords", false, "Derive the noise seed from --seed and the z8 parent tile: distinct but reproducible regions, seamless within each z8 tile (seams along z8 boundaries)")
+	generateCmd.Flags().Bool("keep-layers", false, "Keep intermediate rendered layer PNGs for debugging (identical layers are hard-linked)")
+	generateCmd.Flags().Bool("debug-stages", false, "Write the intermediate pipeline stages of each tile to <output-dir>/debug-stages/{z}/{x}/{y}/ (not captured for metatile renders)")
+	generateCmd.Flags().Bool("verbose-timing", false, "Log per-stage durations (fetch, render, masks, paint, composite, encode) for each tile")
+	generateCmd.Flags().Bool("emit-metadata", false, "Write a JSON sidecar next to each tile with its seed, feature counts, fetch and render durations, data source, OSM timestamp and params hash (folder format, not with --metatile)")
+	generateCmd.Flags().Int("empty-tile-tolerance", 0, "Store batch tiles without content beyond this per-channel tolerance (solid land, open ocean) once per background color and share them, and write tiles without any features (open sea, empty land) as their zoom's first such tile without rendering; 0 = off, e.g. 24")
 
 	// Output format flags
 	generateCmd.Flags().String("format", "folder", "Output format: folder or mbtiles")
 	generateCmd.Flags().String("output-file", "", "Output file path for MBTiles format (e.g., tiles.mbtiles)")
-	generateCmd.Flags().String("folder-structure", "flat", "Folder structure for folder format: flat (z{z}_x{x}_y{y}.png) or nested ({z}/{x}/{y}.png)")
+	generateCmd.Flags().Bool("tms", false, "Use TMS rows (y grows northward) for -y and output file names instead of XYZ")
+	generateCmd.Flags().String("folder-structure", "flat", "Folder structure for folder format: flat (z{z}_x{x}_y{y}.png), nested ({z}/{x}/{y}.png) or hashed ({ab}/{cd}/z{z}_x{x}_y{y}.png, sharded by a hash of the coordinates)")
 
 	bindFlags := []struct {
 		key  string
@@ -63,21 +97,40 @@ func init() {
 		{"generate.zoom", "zoom"},
 		{"generate.x", "x"},
 		{"generate.y", "y"},
+		{"generate.fetch_only", "fetch-only"},
+		{"generate.dump_json", "dump-json"},
 		{"generate.bbox", "bbox"},
 		{"generate.zoom_min", "zoom-min"},
 		{"generate.zoom_max", "zoom-max"},
 		{"generate.workers", "workers"},
+		{"generate.concurrency_per_zoom", "concurrency-per-zoom"},
 		{"generate.progress", "progress"},
+		{"generate.progress_http", "progress-http"},
 		{"generate.allow_failures", "allow-failures"},
+		{"generate.max_tiles", "max-tiles"},
+		{"generate.max_duration", "max-duration"},
+		{"generate.metatile", "metatile"},
+		{"generate.fetch_per_column", "fetch-per-column"},
+		{"generate.noise_cache", "noise-cache"},
+		{"generate.pyramid_from_base", "pyramid-from-base"},
 		{"generate.force", "force"},
+		{"generate.rerender_changed", "rerender-changed"},
+		{"generate.max_age", "max-age"},
 		{"generate.tile_size", "tile-size"},
 		{"generate.hidpi", "hidpi"},
 		{"generate.png_compression", "png-compression"},
 		{"generate.seed", "seed"},
+		{"generate.noise_seed_mode", "noise-seed-mode"},
+		{"generate.seed_from_coords", "seed-from-coords"},
 		{"generate.keep_layers", "keep-layers"},
+		{"generate.verbose_timing", "verbose-timing"},
+		{"generate.empty_tile_tolerance", "empty-tile-tolerance"},
+		{"generate.emit_metadata", "emit-metadata"},
+		{"generate.debug_stages", "debug-stages"},
 		{"generate.format", "format"},
 		{"generate.output_file", "output-file"},
 		{"generate.folder_structure", "folder-structure"},
+		{"generate.tms", "tms"},
 	}
 
 	for _, bf := range bindFlags {
@@ -104,10 +157,14 @@ func runGenerate(cmd *cobra.Command, args []string) error {
 	hidpi := viper.GetBool("generate.hidpi")
 	pngCompression := viper.GetString("generate.png_compression")
 	seed := viper.GetInt64("generate.seed")
+	noiseSeedMode := viper.GetString("generate.noise_seed_mode")
+	seedFromCoords := viper.GetBool("generate.seed_from_coords")
 	keepLayers := viper.GetBool("generate.keep_layers")
+	logTiming := viper.GetBool("generate.verbose_timing")
 	format := viper.GetString("generate.format")
 	outputFile := viper.GetString("generate.output_file")
 	folderStructure := viper.GetString("generate.folder_structure")
+	tms := viper.GetBool("generate.tms")
 
 	if logger == nil {
 		initLogging()
@@ -119,8 +176,18 @@ func runGenerate(cmd *cobra.Command, args []string) error {
 	}
 
 	// Validate folder structure
-	if folderStructure != "flat" && folderStructure != "nested" {
-		return fmt.Errorf("invalid folder-structure %q: must be 'flat' or 'nested'", folderStructure)
+	if folderStructure != "flat" && folderStructure != "nested" && folderStructure != "hashed" {
+		return fmt.Errorf("invalid folder-structure %q: must be 'flat', 'nested' or 'hashed'", folderStructure)
+	}
+
+	if err := validateNoiseSeedMode(noiseSeedMode); err != nil {
+		return err
+	}
+	if seedFromCoords {
+		if noiseSeedMode == watercolor.NoiseSeedPerTile {
+			return fmt.Errorf("--seed-from-coords cannot be combined with --noise-seed-mode=%s", watercolor.NoiseSeedPerTile)
+		}
+		noiseSeedMode = watercolor.NoiseSeedRegion
 	}
 
 	// Validate MBTiles requirements
@@ -133,17 +200,55 @@ func runGenerate(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	// Expand a templated output directory like tiles/{seed}/{date} into this run's folder
+	if strings.ContainsAny(outputDir, "{}") {
+		zFrom, zTo := zoom, zoom
+		if bbox != "" {
+			zFrom, zTo = zoomMin, zoomMax
+		}
+		expanded, err := expandOutputDir(outputDir, outputDirVars(seed, format, zFrom, zTo, time.Now()))
+		if err != nil {
+			return fmt.Errorf("invalid output-dir: %w", err)
+		}
+		if err := os.MkdirAll(expanded, 0o755); err != nil {
+			return fmt.Errorf("failed to create output directory: %w", err)
+		}
+		logger.Info("expanded output directory", "template", outputDir, "output_dir", expanded)
+		outputDir = expanded
+	}
+	stagesDir := debugStagesDir(outputDir, viper.GetBool("generate.debug_stages"))
+
 	allowFailures := viper.GetBool("generate.allow_failures")
 
+	metatile, err := parseMetatile(viper.GetString("generate.metatile"))
+	if err != nil {
+		return fmt.Errorf("invalid metatile: %w", err)
+	}
+
+	workersPerZoom, err := zoomWorkersFromConfig()
+	if err != nil {
+		return fmt.Errorf("invalid concurrency-per-zoom: %w", err)
+	}
+
 	// Determine mode: batch (bbox provided) or single tile
 	if bbox != "" {
-		return runBatchGenerate(bbox, zoomMin, zoomMax, workers, showProgress, force, outputDir, dataSourceName, tileSize, hidpi, pngCompression, seed, keepLayers, format, outputFile, folderStructure, allowFailures)
+		if viper.GetBool("generate.fetch_only") || viper.GetString("generate.dump_json") != "" {
+			return fmt.Errorf("--fetch-only and --dump-json are only supported for single tiles")
+		}
+		if stagesDir != "" && metatile > 1 {
+			logger.Warn("--debug-stages does not capture metatile renders; use --metatile 1x1 to capture stages", "metatile", metatile)
+		}
+		return runBatchGenerate(bbox, zoomMin, zoomMax, workers, workersPerZoom, showProgress, force, outputDir, dataSourceName, tileSize, hidpi, pngCompression, seed, keepLayers, format, outputFile, folderStructure, noiseSeedMode, allowFailures, metatile, logTiming, stagesDir, tms, viper.GetBool("generate.fetch_per_column"))
+	}
+
+	if metatile > 1 {
+		logger.Warn("--metatile is only used for batch generation; ignoring", "metatile", metatile)
 	}
 
-	return runSingleGenerate(zoom, x, y, force, outputDir, dataSourceName, tileSize, hidpi, pngCompression, seed, keepLayers, folderStructure)
+	return runSingleGenerate(zoom, x, y, force, outputDir, dataSourceName, tileSize, hidpi, pngCompression, seed, keepLayers, folderStructure, noiseSeedMode, logTiming, stagesDir, tms)
 }
 
-func runSingleGenerate(zoom, x, y int, force bool, outputDir, dataSourceName string, tileSize int, hidpi bool, pngCompression string, seed int64, keepLayers bool, folderStructure string) error {
+func runSingleGenerate(zoom, x, y int, force bool, outputDir, dataSourceName string, tileSize int, hidpi bool, pngCompression string, seed int64, keepLayers bool, folderStructure, noiseSeedMode string, logTiming bool, debugStagesDir string, tms bool) error {
 	coords := tile.NewCoords(uint32(zoom), uint32(x), uint32(y))
 
 	logger.Info("Starting tile generation",
@@ -155,33 +260,81 @@ func runSingleGenerate(zoom, x, y int, force bool, outputDir, dataSourceName str
 		"hidpi", hidpi,
 		"png_compression", pngCompression,
 		"seed", seed,
+		"noise_seed_mode", noiseSeedMode,
 		"keep_layers", keepLayers,
 	)
 
 	if zoom < 0 || x < 0 || y < 0 {
 		return fmt.Errorf("invalid coordinates: zoom/x/y must be non-negative")
 	}
+	if tms {
+		// -y is a TMS row; the pipeline renders XYZ and flips it back for the file name
+		if !coords.InRange() {
+			return fmt.Errorf("invalid coordinates: %s is outside zoom %d", coords.String(), zoom)
+		}
+		coords = coords.FlipYForTMS()
+	}
 
 	var ds pipeline.DataSource
 	switch dataSourceName {
 	case "overpass":
-		ds = datasource.NewOverpassDataSource("")
+		classification, err := loadClassification()
+		if err != nil {
+			return err
+		}
+		ds = datasource.NewOverpassDataSource("").
+			WithClassification(classification).
+			WithMaxDataSize(maxDataSizeBytes())
 	default:
 		return fmt.Errorf("unsupported data source: %s", dataSourceName)
 	}
 
+	opts, err := generatorOptions()
+	if err != nil {
+		return err
+	}
+	opts.PNGCompression = pngCompression
+	opts.FolderStructure = folderStructure
+	opts.NoiseSeedMode = noiseSeedMode
+	opts.LogTiming = logTiming
+	opts.DebugStagesDir = debugStagesDir
+	opts.TMS = tms
+	opts.EmitMetadata = viper.GetBool("generate.emit_metadata")
+	opts.RerenderChanged = viper.GetBool("generate.rerender_changed")
+	opts.MaxTileAge = viper.GetDuration("generate.max_age")
+	opts.PaintWorkers = runtime.NumCPU() // a single tile leaves the other cores idle
+
 	stylesDir := filepath.Join("assets", "styles")
 	texturesDir := filepath.Join("assets", "textures")
 
-	gen, err := pipeline.NewGenerator(ds, stylesDir, texturesDir, outputDir, tileSize, seed, keepLayers, logger, pipeline.GeneratorOptions{
-		PNGCompression:  pngCompression,
-		FolderStructure: folderStructure,
-	})
+	gen, err := pipeline.NewGenerator(ds, stylesDir, texturesDir, outputDir, tileSize, seed, keepLayers, logger, opts)
 	if err != nil {
 		return fmt.Errorf("failed to init generator: %w", err)
 	}
 
-	path, layersDir, err := gen.Generate(context.Background(), coords, force, "", nil)
+	// Optionally fetch separately to dump the data or stop before rendering
+	var prefetched *types.TileData
+	fetchOnly := viper.GetBool("generate.fetch_only")
+	dumpJSON := viper.GetString("generate.dump_json")
+	if fetchOnly || dumpJSON != "" {
+		data, err := gen.FetchOnly(context.Background(), coords)
+		if err != nil {
+			return fmt.Errorf("failed to fetch tile data: %w", err)
+		}
+		logger.Info("Fetched tile data", "coords", coords.String(), "features", geojson.LayerSummary(data.Features))
+		if dumpJSON != "" {
+			if err := writeTileDataJSON(dumpJSON, data); err != nil {
+				return err
+			}
+			logger.Info("Wrote tile data", "path", dumpJSON)
+		}
+		if fetchOnly {
+			return nil
+		}
+		prefetched = data
+	}
+
+	path, layersDir, err := gen.GenerateWithData(context.Background(), coords, force, "", nil, prefetched)
 	if err != nil {
 		return fmt.Errorf("failed to generate tile: %w", err)
 	}
@@ -193,10 +346,9 @@ func runSingleGenerate(zoom, x, y int, force bool, outputDir, dataSourceName str
 	logger.Info("Tile generated", logFields...)
 
 	if hidpi {
-		gen2x, err := pipeline.NewGenerator(ds, stylesDir, texturesDir, outputDir, tileSize*2, seed, keepLayers, logger, pipeline.GeneratorOptions{
-			PNGCompression:  pngCompression,
-			FolderStructure: folderStructure,
-		})
+		opts2x := opts
+		opts2x.PixelRatio = 2
+		gen2x, err := pipeline.NewGenerator(ds, stylesDir, texturesDir, outputDir, tileSize*2, seed, keepLayers, logger, opts2x)
 		if err != nil {
 			return fmt.Errorf("failed to init hidpi generator: %w", err)
 		}
@@ -210,7 +362,7 @@ func runSingleGenerate(zoom, x, y int, force bool, outputDir, dataSourceName str
 	return nil
 }
 
-func runBatchGenerate(bboxStr string, zoomMin, zoomMax, workers int, showProgress, force bool, outputDir, dataSourceName string, tileSize int, hidpi bool, pngCompression string, seed int64, keepLayers bool, format, outputFile, folderStructure string, allowFailures bool) error {
+func runBatchGenerate(bboxStr string, zoomMin, zoomMax, workers int, workersPerZoom map[uint32]int, showProgress, force bool, outputDir, dataSourceName string, tileSize int, hidpi bool, pngCompression string, seed int64, keepLayers bool, format, outputFile, folderStructure, noiseSeedMode string, allowFailures bool, metatile int, logTiming bool, debugStagesDir string, tms, fetchPerColumn bool) error {
 	// Parse bounding box
 	bbox, err := parseBBox(bboxStr)
 	if err != nil {
@@ -224,15 +376,60 @@ func runBatchGenerate(bboxStr string, zoomMin, zoomMax, workers int, showProgres
 	if zoomMin > zoomMax {
 		return fmt.Errorf("--zoom-min (%d) must be <= --zoom-max (%d)", zoomMin, zoomMax)
 	}
+	if fetchPerColumn && metatile > 1 {
+		return fmt.Errorf("--fetch-per-column cannot be combined with --metatile")
+	}
+	emptyTileTolerance := viper.GetInt("generate.empty_tile_tolerance")
+	if emptyTileTolerance < 0 || emptyTileTolerance > 255 {
+		return fmt.Errorf("--empty-tile-tolerance must be between 0 and 255, got %d", emptyTileTolerance)
+	}
+	maxTiles := viper.GetInt("generate.max_tiles")
+	if maxTiles < 0 {
+		return fmt.Errorf("--max-tiles must not be negative, got %d", maxTiles)
+	}
+	maxDuration := viper.GetDuration("generate.max_duration")
+	if maxDuration < 0 {
+		return fmt.Errorf("--max-duration must not be negative, got %s", maxDuration)
+	}
+	noiseCacheSlabs := viper.GetInt("generate.noise_cache")
+	if noiseCacheSlabs < 0 {
+		return fmt.Errorf("--noise-cache must not be negative, got %d", noiseCacheSlabs)
+	}
+	pyramidFromBase := viper.GetBool("generate.pyramid_from_base")
+	if pyramidFromBase && format != "folder" {
+		return fmt.Errorf("--pyramid-from-base requires --format=folder")
+	}
 
 	// Default workers to CPU count
 	if workers <= 0 {
 		workers = runtime.NumCPU()
 	}
 
-	// Calculate tiles
-	tiles := tile.TilesInBBox(bbox, zoomMin, zoomMax)
-	totalTiles := len(tiles)
+	// Calculate tiles. Plain runs stream them into the worker pool; pyramid and metatile runs
+	// plan over the whole list. batchTiles holds one entry per block in metatile mode.
+	var batchTiles iter.Seq[tile.Coords]
+	var blockTiles map[tile.Coords]int // requested tiles per block in metatile mode
+	var tileCount, batchCount int
+	var derivedLevels [][]tile.Coords
+	var derivedCount int
+	if pyramidFromBase || metatile > 1 {
+		tiles := tile.TilesInBBox(bbox, zoomMin, zoomMax)
+		if pyramidFromBase {
+			tiles, derivedLevels = pyramidPlan(tiles, zoomMax)
+			for _, level := range derivedLevels {
+				derivedCount += len(level)
+			}
+		}
+		var origins []tile.Coords
+		origins, blockTiles = metatileOrigins(tiles, metatile)
+		batchTiles = slices.Values(origins)
+		tileCount, batchCount = len(tiles), len(origins)
+	} else {
+		batchTiles = tile.TilesInBBoxIter(bbox, zoomMin, zoomMax)
+		tileCount = tile.TileCount(bbox, zoomMin, zoomMax)
+		batchCount = tileCount
+	}
+	totalTiles := tileCount + derivedCount
 
 	// If hidpi, we'll generate 2x the tiles
 	if hidpi {
@@ -242,22 +439,55 @@ func runBatchGenerate(bboxStr string, zoomMin, zoomMax, workers int, showProgres
 	logger.Info("Starting batch tile generation",
 		"bbox", bboxStr,
 		"zoom_range", fmt.Sprintf("%d-%d", zoomMin, zoomMax),
-		"tiles", len(tiles),
+		"tiles", tileCount,
 		"total_with_hidpi", totalTiles,
 		"workers", workers,
+		"workers_per_zoom", workersPerZoom,
 		"output_dir", outputDir,
 		"format", format,
+		"metatile", metatile,
+		"fetch_per_column", fetchPerColumn,
+		"pyramid_from_base", pyramidFromBase,
+		"derived_tiles", derivedCount,
 	)
 
 	// Setup data source
 	var ds pipeline.DataSource
 	switch dataSourceName {
 	case "overpass":
-		ds = datasource.NewOverpassDataSource("")
+		classification, err := loadClassification()
+		if err != nil {
+			return err
+		}
+		ds = datasource.NewOverpassDataSource("").
+			WithClassification(classification).
+			WithMaxDataSize(maxDataSizeBytes())
 	default:
 		return fmt.Errorf("unsupported data source: %s", dataSourceName)
 	}
 
+	// Optionally serve every zoom of a column from a single fetch at the deepest zoom
+	var columns *datasource.ColumnDataSource
+	if fetchPerColumn {
+		bounded, ok := ds.(datasource.BoundedDataSource)
+		if !ok {
+			return fmt.Errorf("--fetch-per-column is not supported by data source %s", dataSourceName)
+		}
+		columns = datasource.NewColumnDataSource(bounded, zoomMin, zoomMax)
+		ds = columns
+	}
+
+	opts, err := generatorOptions()
+	if err != nil {
+		return err
+	}
+
+	// Shared by the base and @2x generators; their noise scales differ, so slabs don't collide
+	var noiseCache *mask.NoiseCache
+	if noiseCacheSlabs > 0 {
+		noiseCache = mask.NewNoiseCache(noiseCacheSlabs)
+	}
+
 	stylesDir := filepath.Join("assets", "styles")
 	texturesDir := filepath.Join("assets", "textures")
 
@@ -312,14 +542,28 @@ func runBatchGenerate(bboxStr string, zoomMin, zoomMax, workers int, showProgres
 		tileWriter = mbtilesWriter
 	}
 
-	gen, err := pipeline.NewGenerator(ds, stylesDir, texturesDir, outputDir, tileSize, seed, keepLayers, logger, pipeline.GeneratorOptions{
-		PNGCompression:  pngCompression,
-		TileWriter:      tileWriter,
-		FolderStructure: folderStructure,
-	})
+	opts.PNGCompression = pngCompression
+	opts.TileWriter = tileWriter
+	opts.FolderStructure = folderStructure
+	opts.NoiseSeedMode = noiseSeedMode
+	opts.NoiseCache = noiseCache
+	opts.LogTiming = logTiming
+	opts.DebugStagesDir = debugStagesDir
+	opts.TMS = tms
+	opts.EmitMetadata = viper.GetBool("generate.emit_metadata")
+	opts.RerenderChanged = viper.GetBool("generate.rerender_changed")
+	opts.MaxTileAge = viper.GetDuration("generate.max_age")
+	opts.EmptyTileTolerance = uint8(emptyTileTolerance)
+	gen, err := pipeline.NewGenerator(ds, stylesDir, texturesDir, outputDir, tileSize, seed, keepLayers, logger, opts)
 	if err != nil {
 		return fmt.Errorf("failed to init generator: %w", err)
 	}
+	if columns != nil {
+		// Fetch the padded area of each column so the padded tiles below it fall inside
+		columns.ColumnBounds = func(c types.TileCoordinate) types.BoundingBox {
+			return gen.CalculateFetchBounds(tile.NewCoords(uint32(c.Zoom), uint32(c.X), uint32(c.Y)))
+		}
+	}
 
 	// Setup context with signal handling
 	ctx, cancel := context.WithCancel(context.Background())
@@ -333,41 +577,70 @@ func runBatchGenerate(bboxStr string, zoomMin, zoomMax, workers int, showProgres
 		cancel()
 	}()
 
-	// Build task list for base tiles
-	tasks := make([]worker.Task, 0, len(tiles))
-	for _, coords := range tiles {
-		tasks = append(tasks, worker.Task{
-			Coords: coords,
-			Force:  force,
-			Suffix: "",
+	// A time budget cancels like an interrupt, but the cancelled tiles count as skipped
+	var budgetExpired atomic.Bool
+	if maxDuration > 0 {
+		timer := time.AfterFunc(maxDuration, func() {
+			budgetExpired.Store(true)
+			logger.Warn("Reached --max-duration, cancelling the remaining tiles", "max_duration", maxDuration)
+			cancel()
 		})
+		defer timer.Stop()
+	}
+
+	// Base tile tasks (one task per block in metatile mode)
+	var budgetSkipped int
+	if maxTiles > 0 && batchCount > maxTiles {
+		budgetSkipped = batchCount - maxTiles
+		if hidpi {
+			budgetSkipped *= 2
+		}
+		batchTiles = firstTiles(batchTiles, maxTiles)
+		batchCount = maxTiles
+		logger.Info("Limiting the run to --max-tiles", "max_tiles", maxTiles, "skipped", budgetSkipped)
 	}
+	var budgetCompleted int
+	tasks := batchTasks(batchTiles, blockTiles, force, "")
+	progressTotal := batchProgressTotal(batchTiles, blockTiles, batchCount)
+
+	stream, stopProgressServer, err := startProgressServer(viper.GetString("generate.progress_http"))
+	if err != nil {
+		return err
+	}
+	defer stopProgressServer()
 
 	// Setup progress tracking
-	progress := worker.NewProgress(len(tasks), showProgress)
+	progress := worker.NewProgress(progressTotal, showProgress)
 
 	// Create worker pool
 	pool := worker.New(worker.Config{
-		Workers:    workers,
-		Generator:  gen,
-		OnProgress: progress.Callback(),
+		Workers:        workers,
+		WorkersPerZoom: workersPerZoom,
+		Generator:      batchGenerator(gen, metatile),
+		OnEvent:        stream.Track("base", progressTotal, progress.Handle),
 	})
 
 	// Run base tiles
-	logger.Info("Generating base tiles", "count", len(tasks))
-	results := pool.Run(ctx, tasks)
+	logger.Info("Generating base tiles", "count", batchCount)
+	var outcome batchOutcome
+	pool.RunSeq(ctx, tasks, progressTotal, func(r worker.Result) {
+		outcome.add(r, budgetExpired.Load())
+	})
+	outcome.finish(batchCount, budgetExpired.Load())
 	progress.Done()
 
 	// Check for failures
-	var failedCount int
-	for _, r := range results {
-		if r.Err != nil {
-			failedCount++
-			logger.Error("Tile generation failed", "coords", r.Task.Coords.String(), "suffix", r.Task.Suffix, "error", r.Err)
-		}
+	for _, r := range outcome.failed {
+		logger.Error("Tile generation failed", "coords", r.Task.Coords.String(), "suffix", r.Task.Suffix, "error", r.Err)
 	}
+	failedCount := len(outcome.failed)
+	budgetCompleted += outcome.completed
+	budgetSkipped += outcome.skipped
 
 	logger.Info(progress.Summary())
+	if emptyTileTolerance > 0 {
+		logger.Info("Empty tiles (no content besides the background)", "count", gen.EmptyTiles(), "featureless_unrendered", gen.FeaturelessTiles(), "tolerance", emptyTileTolerance)
+	}
 
 	if failedCount > 0 {
 		if allowFailures {
@@ -377,9 +650,20 @@ func runBatchGenerate(bboxStr string, zoomMin, zoomMax, workers int, showProgres
 		}
 	}
 
+	if len(derivedLevels) > 0 {
+		outcome := buildPyramid(ctx, gen, derivedLevels, force, "", workers, showProgress, stream, &budgetExpired)
+		budgetCompleted += outcome.completed
+		budgetSkipped += outcome.skipped
+		if err := reportDerivedFailures(outcome.failed, allowFailures); err != nil {
+			return err
+		}
+	}
+
 	// Generate HiDPI tiles if requested
-	if hidpi {
-		logger.Info("Generating HiDPI tiles", "count", len(tiles))
+	if hidpi && budgetExpired.Load() {
+		budgetSkipped += batchCount + derivedCount
+	} else if hidpi {
+		logger.Info("Generating HiDPI tiles", "count", batchCount)
 
 		// Create HiDPI generator with appropriate writer
 		var hidpiWriter pipeline.TileWriter
@@ -387,47 +671,43 @@ func runBatchGenerate(bboxStr string, zoomMin, zoomMax, workers int, showProgres
 			hidpiWriter = mbtilesWriterHiDPI
 		}
 
-		genHiDPI, err := pipeline.NewGenerator(ds, stylesDir, texturesDir, outputDir, tileSize*2, seed, keepLayers, logger, pipeline.GeneratorOptions{
-			PNGCompression:  pngCompression,
-			TileWriter:      hidpiWriter,
-			FolderStructure: folderStructure,
-		})
+		optsHiDPI := opts
+		optsHiDPI.TileWriter = hidpiWriter
+		optsHiDPI.PixelRatio = 2
+		genHiDPI, err := pipeline.NewGenerator(ds, stylesDir, texturesDir, outputDir, tileSize*2, seed, keepLayers, logger, optsHiDPI)
 		if err != nil {
 			return fmt.Errorf("failed to init HiDPI generator: %w", err)
 		}
 
-		// Build HiDPI task list
-		hidpiTasks := make([]worker.Task, 0, len(tiles))
-		for _, coords := range tiles {
-			hidpiTasks = append(hidpiTasks, worker.Task{
-				Coords: coords,
-				Force:  force,
-				Suffix: "@2x",
-			})
-		}
+		// HiDPI tasks walk the same tiles again
+		hidpiTasks := batchTasks(batchTiles, blockTiles, force, "@2x")
 
 		// Setup progress tracking for HiDPI
-		progressHiDPI := worker.NewProgress(len(hidpiTasks), showProgress)
+		progressHiDPI := worker.NewProgress(progressTotal, showProgress)
 
 		// Create worker pool for HiDPI
 		poolHiDPI := worker.New(worker.Config{
-			Workers:    workers,
-			Generator:  genHiDPI,
-			OnProgress: progressHiDPI.Callback(),
+			Workers:        workers,
+			WorkersPerZoom: workersPerZoom,
+			Generator:      batchGenerator(genHiDPI, metatile),
+			OnEvent:        stream.Track("@2x", progressTotal, progressHiDPI.Handle),
 		})
 
 		// Run HiDPI tiles
-		resultsHiDPI := poolHiDPI.Run(ctx, hidpiTasks)
+		var outcomeHiDPI batchOutcome
+		poolHiDPI.RunSeq(ctx, hidpiTasks, progressTotal, func(r worker.Result) {
+			outcomeHiDPI.add(r, budgetExpired.Load())
+		})
+		outcomeHiDPI.finish(batchCount, budgetExpired.Load())
 		progressHiDPI.Done()
 
 		// Check for failures
-		var hidpiFailedCount int
-		for _, r := range resultsHiDPI {
-			if r.Err != nil {
-				hidpiFailedCount++
-				logger.Error("HiDPI tile generation failed", "coords", r.Task.Coords.String(), "error", r.Err)
-			}
+		for _, r := range outcomeHiDPI.failed {
+			logger.Error("HiDPI tile generation failed", "coords", r.Task.Coords.String(), "error", r.Err)
 		}
+		hidpiFailedCount := len(outcomeHiDPI.failed)
+		budgetCompleted += outcomeHiDPI.completed
+		budgetSkipped += outcomeHiDPI.skipped
 
 		logger.Info(progressHiDPI.Summary())
 
@@ -438,6 +718,15 @@ func runBatchGenerate(bboxStr string, zoomMin, zoomMax, workers int, showProgres
 				return fmt.Errorf("%d HiDPI tiles failed to generate", hidpiFailedCount)
 			}
 		}
+
+		if len(derivedLevels) > 0 {
+			outcome := buildPyramid(ctx, genHiDPI, derivedLevels, force, "@2x", workers, showProgress, stream, &budgetExpired)
+			budgetCompleted += outcome.completed
+			budgetSkipped += outcome.skipped
+			if err := reportDerivedFailures(outcome.failed, allowFailures); err != nil {
+				return err
+			}
+		}
 	}
 
 	// Flush MBTiles writers if used
@@ -454,9 +743,377 @@ func runBatchGenerate(bboxStr string, zoomMin, zoomMax, workers int, showProgres
 		logger.Info("MBTiles generation complete", "base", outputFile)
 	}
 
+	if budgetSkipped > 0 {
+		logger.Info("Stopped early at the --max-tiles/--max-duration budget", "completed", budgetCompleted, "skipped", budgetSkipped)
+	}
+
 	return nil
 }
 
+// batchOutcome summarizes the results of one worker pool run. Results are added as they
+// arrive, so a run never holds more than its failed results.
+type batchOutcome struct {
+	failed    []worker.Result
+	completed int
+	skipped   int // Tasks cancelled by --max-duration or never started
+	results   int // Results added so far
+}
+
+// add sorts one result into completed, failed or skipped. Once the time budget has expired,
+// cancelled tiles are skipped rather than failed; an interrupt still fails them. The budget
+// flag is set before the run is cancelled, so it is current for every cancelled result.
+func (out *batchOutcome) add(r worker.Result, budgetExpired bool) {
+	out.results++
+	switch {
+	case r.Err == nil:
+		out.completed++
+	case budgetExpired && (errors.Is(r.Err, context.Canceled) || errors.Is(r.Err, context.DeadlineExceeded)):
+		out.skipped++
+	default:
+		out.failed = append(out.failed, r)
+	}
+}
+
+// finish counts the tasks of the run that never produced a result as skipped once the time
+// budget has expired.
+func (out *batchOutcome) finish(tasks int, budgetExpired bool) {
+	if budgetExpired {
+		out.skipped += tasks - out.results
+	}
+}
+
+// summarizeResults sorts the results of a pool run over tasks into completed, failed and
+// skipped tiles (see batchOutcome.add).
+func summarizeResults(results []worker.Result, tasks int, budgetExpired bool) batchOutcome {
+	var out batchOutcome
+	for _, r := range results {
+		out.add(r, budgetExpired)
+	}
+	out.finish(tasks, budgetExpired)
+	return out
+}
+
+// pyramidPlan splits the tiles of a --pyramid-from-base run into the tiles to render and the
+// tiles to build from their children, grouped by zoom from zoomMax-1 up. A tile is built from
+// its children when all four are part of the run; tiles at zoomMax and tiles at the edges of
+// the bbox, where some children fall outside it, are rendered.
+func pyramidPlan(tiles []tile.Coords, zoomMax int) (render []tile.Coords, derived [][]tile.Coords) {
+	inRun := make(map[tile.Coords]bool, len(tiles))
+	for _, c := range tiles {
+		inRun[c] = true
+	}
+	byZoom := make(map[uint32][]tile.Coords)
+	for _, c := range tiles {
+		if int(c.Z) < zoomMax &&
+			inRun[tile.NewCoords(c.Z+1, 2*c.X, 2*c.Y)] && inRun[tile.NewCoords(c.Z+1, 2*c.X+1, 2*c.Y)] &&
+			inRun[tile.NewCoords(c.Z+1, 2*c.X, 2*c.Y+1)] && inRun[tile.NewCoords(c.Z+1, 2*c.X+1, 2*c.Y+1)] {
+			byZoom[c.Z] = append(byZoom[c.Z], c)
+			continue
+		}
+		render = append(render, c)
+	}
+	for z := zoomMax - 1; z >= 0; z-- {
+		if level := byZoom[uint32(z)]; len(level) > 0 {
+			derived = append(derived, level)
+		}
+	}
+	return render, derived
+}
+
+// buildPyramid builds the tiles of each level from their children (see
+// pipeline.PyramidGenerator), one level after the other so the children are complete.
+func buildPyramid(ctx context.Context, gen *pipeline.Generator, levels [][]tile.Coords, force bool, suffix string, workers int, showProgress bool, stream *server.ProgressStream, budgetExpired *atomic.Bool) batchOutcome {
+	var total batchOutcome
+	pyramid := pipeline.NewPyramidGenerator(gen)
+	for _, level := range levels {
+		tasks := make([]worker.Task, 0, len(level))
+		for _, coords := range level {
+			tasks = append(tasks, worker.Task{Coords: coords, Force: force, Suffix: suffix})
+		}
+
+		logger.Info("Building tiles from their children", "zoom", level[0].Z, "suffix", suffix, "count", len(tasks))
+		progress := worker.NewProgress(len(tasks), showProgress)
+		pool := worker.New(worker.Config{
+			Workers:   workers,
+			Generator: pyramid,
+			OnEvent:   stream.Track(fmt.Sprintf("pyramid z%d%s", level[0].Z, suffix), len(tasks), progress.Handle),
+		})
+		results := pool.Run(ctx, tasks)
+		progress.Done()
+		logger.Info(progress.Summary())
+
+		outcome := summarizeResults(results, len(tasks), budgetExpired.Load())
+		total.failed = append(total.failed, outcome.failed...)
+		total.completed += outcome.completed
+		total.skipped += outcome.skipped
+	}
+	return total
+}
+
+// startProgressServer serves the progress of a batch run as Server-Sent Events on addr (see
+// server.ProgressStream) until the returned stop func is called, which ends open streams with
+// a final event and shuts the server down. An empty addr serves nothing and returns a nil
+// stream, which records nothing.
+func startProgressServer(addr string) (*server.ProgressStream, func(), error) {
+	if addr == "" {
+		return nil, func() {}, nil
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to listen on --progress-http address: %w", err)
+	}
+
+	stream := server.NewProgressStream()
+	mux := http.NewServeMux()
+	var anyOrigin *server.CORS // Allows every origin, so any page can follow the batch
+	mux.Handle("/progress", anyOrigin.Middleware(stream.Handler()))
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("Progress server failed", "error", err)
+		}
+	}()
+	logger.Info("Streaming batch progress", "url", "http://"+ln.Addr().String()+"/progress")
+
+	stop := func() {
+		stream.Finish()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := srv.Shutdown(ctx); err != nil {
+			logger.Warn("Progress server did not shut down cleanly", "error", err)
+		}
+	}
+	return stream, stop, nil
+}
+
+// reportDerivedFailures logs the tiles buildPyramid failed to build and returns an error
+// unless failures are allowed.
+func reportDerivedFailures(failed []worker.Result, allowFailures bool) error {
+	for _, r := range failed {
+		logger.Error("Building tile from its children failed", "coords", r.Task.Coords.String(), "suffix", r.Task.Suffix, "error", r.Err)
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	if !allowFailures {
+		return fmt.Errorf("%d tiles failed to build from their children", len(failed))
+	}
+	logger.Warn("Some tiles failed to build from their children, but continuing due to --allow-failures flag", "failed_count", len(failed))
+	return nil
+}
+
+// writeTileDataJSON writes the features of data as an indented GeoJSON FeatureCollection whose
+// bbox is the fetched area (see geojson.CollectionToGeoJSON).
+func writeTileDataJSON(path string, data *types.TileData) error {
+	fc, err := geojson.CollectionToGeoJSON(data.Features)
+	if err != nil {
+		return fmt.Errorf("failed to convert tile data: %w", err)
+	}
+	b := data.Bounds
+	fc.BBox = orbgeojson.BBox{b.MinLon, b.MinLat, b.MaxLon, b.MaxLat}
+
+	out, err := json.MarshalIndent(fc, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal tile data: %w", err)
+	}
+	if err := os.WriteFile(path, out, 0o644); err != nil {
+		return fmt.Errorf("failed to write tile data: %w", err)
+	}
+	return nil
+}
+
+// outputDirPlaceholder matches a {name} placeholder in an --output-dir template.
+var outputDirPlaceholder = regexp.MustCompile(`\{[^{}]*\}`)
+
+// outputDirVars returns the --output-dir template variables of a run: {seed}, {date}
+// (YYYYMMDD), {z-range} ("12" for one zoom, "10-14" for a range) and {format}.
+func outputDirVars(seed int64, format string, zoomMin, zoomMax int, now time.Time) map[string]string {
+	zRange := strconv.Itoa(zoomMin)
+	if zoomMax != zoomMin {
+		zRange = fmt.Sprintf("%d-%d", zoomMin, zoomMax)
+	}
+	return map[string]string{
+		"seed":    strconv.FormatInt(seed, 10),
+		"date":    now.Format("20060102"),
+		"z-range": zRange,
+		"format":  format,
+	}
+}
+
+// expandOutputDir replaces the {name} placeholders of an --output-dir template with vars.
+// Paths without placeholders are returned unchanged; unknown placeholders and stray braces
+// are an error.
+func expandOutputDir(tmpl string, vars map[string]string) (string, error) {
+	var unknown string
+	dir := outputDirPlaceholder.ReplaceAllStringFunc(tmpl, func(m string) string {
+		v, ok := vars[m[1:len(m)-1]]
+		if !ok {
+			if unknown == "" {
+				unknown = m
+			}
+			return m
+		}
+		return v
+	})
+	if unknown != "" {
+		return "", fmt.Errorf("unknown placeholder %s (supported: {seed}, {date}, {z-range}, {format})", unknown)
+	}
+	if strings.ContainsAny(dir, "{}") {
+		return "", fmt.Errorf("unbalanced braces in %q", tmpl)
+	}
+	if strings.TrimSpace(dir) == "" {
+		return "", fmt.Errorf("%q expands to an empty path", tmpl)
+	}
+	return dir, nil
+}
+
+// parseMetatile parses a metatile size "NxN" (or just "N") into N.
+// An empty string means metatiling is disabled and returns 1.
+func parseMetatile(s string) (int, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return 1, nil
+	}
+
+	parts := strings.Split(s, "x")
+	if len(parts) > 2 {
+		return 0, fmt.Errorf("expected NxN, got %q", s)
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q: %w", parts[0], err)
+	}
+	if len(parts) == 2 {
+		m, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return 0, fmt.Errorf("invalid size %q: %w", parts[1], err)
+		}
+		if m != n {
+			return 0, fmt.Errorf("metatiles must be square, got %dx%d", n, m)
+		}
+	}
+	if n < 1 {
+		return 0, fmt.Errorf("size must be at least 1, got %d", n)
+	}
+
+	return n, nil
+}
+
+// batchTasks turns tiles into generation tasks with the given force flag and suffix.
+func batchTasks(tiles iter.Seq[tile.Coords], blockTiles map[tile.Coords]int, force bool, suffix string) iter.Seq[worker.Task] {
+	return func(yield func(worker.Task) bool) {
+		for coords := range tiles {
+			if !yield(worker.Task{Coords: coords, Force: force, Suffix: suffix, Tiles: blockTiles[coords]}) {
+				return
+			}
+		}
+	}
+}
+
+// firstTiles yields the first n tiles of tiles.
+func firstTiles(tiles iter.Seq[tile.Coords], n int) iter.Seq[tile.Coords] {
+	return func(yield func(tile.Coords) bool) {
+		if n <= 0 {
+			return
+		}
+		i := 0
+		for coords := range tiles {
+			if !yield(coords) {
+				return
+			}
+			if i++; i == n {
+				return
+			}
+		}
+	}
+}
+
+// batchProgressTotal returns the number of tiles the blocks of tiles cover, or count, the
+// number of tiles, when blockTiles is nil.
+func batchProgressTotal(tiles iter.Seq[tile.Coords], blockTiles map[tile.Coords]int, count int) int {
+	if blockTiles == nil {
+		return count
+	}
+	total := 0
+	for origin := range tiles {
+		total += blockTiles[origin]
+	}
+	return total
+}
+
+// metatileOrigins collapses tiles into the unique origins of their n×n blocks and counts the
+// tiles of each block, so progress can be reported in tiles. For n <= 1 the tiles are
+// returned unchanged with a nil count.
+func metatileOrigins(tiles []tile.Coords, n int) ([]tile.Coords, map[tile.Coords]int) {
+	if n <= 1 {
+		return tiles, nil
+	}
+
+	counts := make(map[tile.Coords]int, len(tiles)/(n*n)+1)
+	origins := make([]tile.Coords, 0, len(tiles)/(n*n)+1)
+	for _, coords := range tiles {
+		origin := pipeline.MetatileOrigin(coords, n)
+		if _, ok := counts[origin]; !ok {
+			origins = append(origins, origin)
+		}
+		counts[origin]++
+	}
+	return origins, counts
+}
+
+// zoomWorkersFromConfig reads generate.concurrency_per_zoom, which is either a
+// "zoom:workers,..." string (flag) or a zoom → workers map (config file).
+func zoomWorkersFromConfig() (map[uint32]int, error) {
+	const key = "generate.concurrency_per_zoom"
+	if m := viper.GetStringMapString(key); len(m) > 0 {
+		pairs := make([]string, 0, len(m))
+		for zoom, workers := range m {
+			pairs = append(pairs, zoom+":"+workers)
+		}
+		return parseZoomWorkers(strings.Join(pairs, ","))
+	}
+	return parseZoomWorkers(viper.GetString(key))
+}
+
+// parseZoomWorkers parses per-zoom worker counts like "5:1,10:4,14:8".
+// It returns nil for an empty string.
+func parseZoomWorkers(s string) (map[uint32]int, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+
+	result := make(map[uint32]int)
+	for _, pair := range strings.Split(s, ",") {
+		zoomStr, workersStr, ok := strings.Cut(pair, ":")
+		if !ok {
+			return nil, fmt.Errorf("expected zoom:workers, got %q", strings.TrimSpace(pair))
+		}
+		zoom, err := strconv.ParseUint(strings.TrimSpace(zoomStr), 10, 32)
+		if err != nil || zoom > 30 {
+			return nil, fmt.Errorf("invalid zoom %q", strings.TrimSpace(zoomStr))
+		}
+		workers, err := strconv.Atoi(strings.TrimSpace(workersStr))
+		if err != nil || workers < 1 {
+			return nil, fmt.Errorf("invalid worker count %q for zoom %d", strings.TrimSpace(workersStr), zoom)
+		}
+		if _, dup := result[uint32(zoom)]; dup {
+			return nil, fmt.Errorf("duplicate zoom %d", zoom)
+		}
+		result[uint32(zoom)] = workers
+	}
+	return result, nil
+}
+
+// batchGenerator returns the worker generator for batch mode, wrapping gen for metatiles when n > 1.
+func batchGenerator(gen *pipeline.Generator, n int) worker.Generator {
+	if n > 1 {
+		return pipeline.NewMetatileGenerator(gen, n)
+	}
+	return gen
+}
+
 // parseBBox parses a bounding box string "minLon,minLat,maxLon,maxLat" into [4]float64.
 func parseBBox(s string) ([4]float64, error) {
 	parts := strings.Split(s, ",")
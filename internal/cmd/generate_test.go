@@ -1,7 +1,24 @@
 package cmd
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
 	"testing"
+	"time"
+
+	"github.com/MeKo-Tech/watercolormap/internal/geojson"
+	"github.com/MeKo-Tech/watercolormap/internal/renderer"
+	"github.com/MeKo-Tech/watercolormap/internal/tile"
+	"github.com/MeKo-Tech/watercolormap/internal/types"
+	"github.com/MeKo-Tech/watercolormap/internal/worker"
+	"github.com/paulmach/orb"
+	orbgeojson "github.com/paulmach/orb/geojson"
+	"github.com/spf13/viper"
 )
 
 func TestParseBBox(t *testing.T) {
@@ -80,3 +97,281 @@ func TestParseBBox(t *testing.T) {
 		})
 	}
 }
+
+func TestParseMetatile(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    int
+		wantErr bool
+	}{
+		{name: "empty disables", input: "", want: 1},
+		{name: "square", input: "4x4", want: 4},
+		{name: "uppercase separator", input: "2X2", want: 2},
+		{name: "single number", input: "3", want: 3},
+		{name: "non-square", input: "2x4", wantErr: true},
+		{name: "zero", input: "0x0", wantErr: true},
+		{name: "invalid number", input: "axa", wantErr: true},
+		{name: "too many parts", input: "2x2x2", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseMetatile(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseMetatile(%q) expected error, got nil", tt.input)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("parseMetatile(%q) unexpected error: %v", tt.input, err)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("parseMetatile(%q) = %d, want %d", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseZoomWorkers(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    map[uint32]int
+		wantErr bool
+	}{
+		{name: "empty", input: "", want: nil},
+		{name: "pairs", input: "5:1, 10:4,14:8", want: map[uint32]int{5: 1, 10: 4, 14: 8}},
+		{name: "missing colon", input: "5=1", wantErr: true},
+		{name: "zero workers", input: "5:0", wantErr: true},
+		{name: "invalid zoom", input: "z5:1", wantErr: true},
+		{name: "duplicate zoom", input: "5:1,5:2", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseZoomWorkers(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseZoomWorkers(%q) expected error, got nil", tt.input)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseZoomWorkers(%q) unexpected error: %v", tt.input, err)
+			}
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("parseZoomWorkers(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestZoomWorkersFromConfig(t *testing.T) {
+	defer viper.Set("generate.concurrency_per_zoom", "")
+
+	viper.Set("generate.concurrency_per_zoom", "5:1,12:6")
+	got, err := zoomWorkersFromConfig()
+	if err != nil || !maps.Equal(got, map[uint32]int{5: 1, 12: 6}) {
+		t.Errorf("string form: got %v, %v", got, err)
+	}
+
+	// Config files give a map (YAML keys decode as strings)
+	viper.Set("generate.concurrency_per_zoom", map[string]any{"5": 1, "10": 4})
+	got, err = zoomWorkersFromConfig()
+	if err != nil || !maps.Equal(got, map[uint32]int{5: 1, 10: 4}) {
+		t.Errorf("map form: got %v, %v", got, err)
+	}
+}
+
+func TestExpandOutputDir(t *testing.T) {
+	vars := outputDirVars(42, "folder", 10, 14, time.Date(2024, 3, 9, 12, 0, 0, 0, time.UTC))
+
+	tests := []struct {
+		name    string
+		tmpl    string
+		want    string
+		wantErr bool
+	}{
+		{name: "plain path", tmpl: "./tiles", want: "./tiles"},
+		{name: "seed and date", tmpl: "tiles/{seed}/{date}", want: "tiles/42/20240309"},
+		{name: "z-range and format", tmpl: "out/{format}-z{z-range}", want: "out/folder-z10-14"},
+		{name: "repeated placeholder", tmpl: "{seed}/{seed}", want: "42/42"},
+		{name: "unknown placeholder", tmpl: "tiles/{zoom}", wantErr: true},
+		{name: "unbalanced brace", tmpl: "tiles/{seed", wantErr: true},
+		{name: "empty placeholder", tmpl: "tiles/{}", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := expandOutputDir(tt.tmpl, vars)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expandOutputDir(%q) error = %v, wantErr %v", tt.tmpl, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("expandOutputDir(%q) = %q, want %q", tt.tmpl, got, tt.want)
+			}
+		})
+	}
+
+	t.Run("single zoom", func(t *testing.T) {
+		got, err := expandOutputDir("z{z-range}", outputDirVars(1, "folder", 12, 12, time.Now()))
+		if err != nil || got != "z12" {
+			t.Errorf("expandOutputDir = %q, %v, want z12", got, err)
+		}
+	})
+}
+
+func TestSummarizeResults(t *testing.T) {
+	results := []worker.Result{
+		{},
+		{},
+		{Err: errors.New("overpass: 429 Too Many Requests")},
+		{Err: context.Canceled},
+		{Err: fmt.Errorf("failed to fetch data: %w", context.Canceled)},
+	}
+
+	tests := []struct {
+		name          string
+		tasks         int
+		budgetExpired bool
+		wantCompleted int
+		wantFailed    int
+		wantSkipped   int
+	}{
+		{name: "interrupt", tasks: 5, wantCompleted: 2, wantFailed: 3},
+		{name: "budget expired", tasks: 5, budgetExpired: true, wantCompleted: 2, wantFailed: 1, wantSkipped: 2},
+		{name: "budget expired before tasks started", tasks: 8, budgetExpired: true, wantCompleted: 2, wantFailed: 1, wantSkipped: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := summarizeResults(results, tt.tasks, tt.budgetExpired)
+			if got.completed != tt.wantCompleted || len(got.failed) != tt.wantFailed || got.skipped != tt.wantSkipped {
+				t.Errorf("completed/failed/skipped = %d/%d/%d, want %d/%d/%d",
+					got.completed, len(got.failed), got.skipped, tt.wantCompleted, tt.wantFailed, tt.wantSkipped)
+			}
+		})
+	}
+}
+
+func TestFirstTiles(t *testing.T) {
+	bbox := [4]float64{9.7, 52.3, 9.8, 52.4}
+	all := tile.TilesInBBox(bbox, 10, 12)
+	for _, n := range []int{0, 1, 3, len(all), len(all) + 5} {
+		got := slices.Collect(firstTiles(tile.TilesInBBoxIter(bbox, 10, 12), n))
+		want := all[:min(n, len(all))]
+		if !slices.Equal(got, want) {
+			t.Errorf("firstTiles(%d) = %v, want %v", n, got, want)
+		}
+	}
+}
+
+func TestPyramidPlan(t *testing.T) {
+	// inset returns the bbox of a tile shrunk slightly so that it covers no neighbors
+	inset := func(c tile.Coords) [4]float64 {
+		b := c.Bounds()
+		dx, dy := (b[2]-b[0])/100, (b[3]-b[1])/100
+		return [4]float64{b[0] + dx, b[1] + dy, b[2] - dx, b[3] - dy}
+	}
+
+	tests := []struct {
+		name       string
+		bbox       [4]float64
+		wantRender int
+		wantLevels []int // Derived tiles per level, deepest first
+	}{
+		// All children of the z10 tile are in the run: only z12 is rendered
+		{name: "aligned", bbox: inset(tile.NewCoords(10, 539, 336)), wantRender: 16, wantLevels: []int{4, 1}},
+		// Only a quarter of the z10 tile: z10 lacks three children and is rendered
+		{name: "edge", bbox: inset(tile.NewCoords(11, 1078, 672)), wantRender: 4 + 1, wantLevels: []int{1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			render, derived := pyramidPlan(tile.TilesInBBox(tt.bbox, 10, 12), 12)
+			if len(render) != tt.wantRender {
+				t.Errorf("rendered %d tiles, want %d", len(render), tt.wantRender)
+			}
+			var levels []int
+			for i, level := range derived {
+				levels = append(levels, len(level))
+				for _, c := range level {
+					if want := uint32(11 - i); c.Z != want {
+						t.Errorf("level %d has tile %s, want zoom %d", i, c.String(), want)
+					}
+				}
+			}
+			if !slices.Equal(levels, tt.wantLevels) {
+				t.Errorf("derived levels = %v, want %v", levels, tt.wantLevels)
+			}
+		})
+	}
+}
+
+func TestWriteTileDataJSON(t *testing.T) {
+	data := &types.TileData{
+		Bounds: types.BoundingBox{MinLon: 9.7, MinLat: 52.3, MaxLon: 9.8, MaxLat: 52.4},
+		Features: types.FeatureCollection{
+			Water: []types.Feature{{ID: "way/1", Type: types.FeatureTypeWater, Geometry: orb.Polygon{{{9.71, 52.31}, {9.72, 52.31}, {9.72, 52.32}, {9.71, 52.31}}}}},
+			Roads: []types.Feature{{ID: "way/2", Type: types.FeatureTypeRoad, Geometry: orb.LineString{{9.7, 52.3}, {9.8, 52.4}}, Name: "Hauptstraße"}},
+		},
+	}
+
+	path := filepath.Join(t.TempDir(), "tile.json")
+	if err := writeTileDataJSON(path, data); err != nil {
+		t.Fatalf("writeTileDataJSON failed: %v", err)
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fc, err := orbgeojson.UnmarshalFeatureCollection(raw)
+	if err != nil {
+		t.Fatalf("dump is not GeoJSON: %v", err)
+	}
+
+	if want := (orbgeojson.BBox{9.7, 52.3, 9.8, 52.4}); !slices.Equal(fc.BBox, want) {
+		t.Errorf("bbox = %v, want %v", fc.BBox, want)
+	}
+	if len(fc.Features) != 2 {
+		t.Fatalf("got %d features, want 2", len(fc.Features))
+	}
+	for i, want := range []string{"water", "roads"} {
+		if got := fc.Features[i].Properties["collection"]; got != want {
+			t.Errorf("feature %d collection = %v, want %s", i, got, want)
+		}
+	}
+	if got := fc.Features[1].Properties["name"]; got != "Hauptstraße" {
+		t.Errorf("road name = %v", got)
+	}
+}
+
+func TestLoadLayerOverrides(t *testing.T) {
+	defer viper.Set("line_width_scale", nil)
+
+	viper.Set("line_width_scale", nil)
+	if got, err := loadLayerOverrides(); got != nil || err != nil {
+		t.Errorf("unset: got %v, %v; want nil", got, err)
+	}
+
+	// Config files give a map with numeric values
+	viper.Set("line_width_scale", map[string]any{"roads": 1.5, "highways": "0.8"})
+	got, err := loadLayerOverrides()
+	want := map[geojson.LayerType]renderer.LayerRenderOverride{
+		geojson.LayerRoads:    {LineWidthScale: 1.5},
+		geojson.LayerHighways: {LineWidthScale: 0.8},
+	}
+	if err != nil || !maps.Equal(got, want) {
+		t.Errorf("got %v, %v; want %v", got, err, want)
+	}
+
+	for _, invalid := range []map[string]any{{"parks": 2}, {"roads": 0}, {"roads": "wide"}} {
+		viper.Set("line_width_scale", invalid)
+		if _, err := loadLayerOverrides(); err == nil {
+			t.Errorf("%v: expected an error", invalid)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"fmt"
+	"image/png"
+	"os"
+	"path/filepath"
+
+	"github.com/MeKo-Tech/watercolormap/internal/pipeline"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var legendCmd = &cobra.Command{
+	Use:   "legend",
+	Short: "Render a legend of the layer swatches",
+	Long: `Render a legend: a swatch of every painted layer with its name beside it, painted
+with the same textures, tints and edges as real tiles over the paper. The active
+styling applies, so --params and --style show the legend of a custom theme.
+
+Example:
+  watercolormap legend --out legend.png --params theme.yaml`,
+	RunE: runLegend,
+}
+
+func init() {
+	rootCmd.AddCommand(legendCmd)
+
+	legendCmd.Flags().StringP("out", "o", "legend.png", "Output PNG file")
+	legendCmd.Flags().Int("patch-size", 96, "Swatch size in pixels")
+	legendCmd.Flags().Int("columns", 2, "Number of swatch columns")
+	legendCmd.Flags().Int64("seed", 1337, "Deterministic seed for the swatch noise")
+
+	bindFlags := []struct {
+		key  string
+		flag string
+	}{
+		{"legend.out", "out"},
+		{"legend.patch_size", "patch-size"},
+		{"legend.columns", "columns"},
+		{"legend.seed", "seed"},
+	}
+
+	for _, bf := range bindFlags {
+		if err := viper.BindPFlag(bf.key, legendCmd.Flags().Lookup(bf.flag)); err != nil {
+			panic(fmt.Sprintf("failed to bind flag %s: %v", bf.flag, err))
+		}
+	}
+}
+
+func runLegend(cmd *cobra.Command, args []string) error {
+	outFile := viper.GetString("legend.out")
+	patchSize := viper.GetInt("legend.patch_size")
+	columns := viper.GetInt("legend.columns")
+	seed := viper.GetInt64("legend.seed")
+
+	if logger == nil {
+		initLogging()
+	}
+
+	if patchSize <= 0 {
+		return fmt.Errorf("--patch-size must be positive, got %d", patchSize)
+	}
+	if columns <= 0 {
+		return fmt.Errorf("--columns must be positive, got %d", columns)
+	}
+
+	opts, err := generatorOptions()
+	if err != nil {
+		return err
+	}
+
+	texturesDir := filepath.Join("assets", "textures")
+	gen, err := pipeline.NewGenerator(nil, "", texturesDir, filepath.Dir(outFile), patchSize, seed, false, logger, opts)
+	if err != nil {
+		return fmt.Errorf("failed to init generator: %w", err)
+	}
+
+	img, err := gen.Legend(patchSize, columns)
+	if err != nil {
+		return fmt.Errorf("failed to render legend: %w", err)
+	}
+	f, err := os.Create(outFile)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close() // nolint:errcheck
+		return fmt.Errorf("failed to encode legend: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write legend: %w", err)
+	}
+
+	logger.Info("Legend written", "path", outFile, "width", img.Bounds().Dx(), "height", img.Bounds().Dy())
+	return nil
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/MeKo-Tech/watercolormap/internal/mbtiles"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var mbtilesMergeCmd = &cobra.Command{
+	Use:   "mbtiles-merge --out combined.mbtiles in1.mbtiles in2.mbtiles ...",
+	Short: "Merge several MBTiles files into one",
+	Long: `Merge several MBTiles files (e.g. from distributed generation runs) into a new one.
+
+Tiles present in more than one input are taken from the input listed last, or the first
+with --conflict=first. The zoom range, bounds and center of the output are recomputed
+from the merged inputs; other metadata is taken from the first input.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runMBTilesMerge,
+}
+
+func init() {
+	rootCmd.AddCommand(mbtilesMergeCmd)
+
+	mbtilesMergeCmd.Flags().String("out", "", "Output MBTiles file path (required, must not exist)")
+	mbtilesMergeCmd.Flags().String("conflict", string(mbtiles.ConflictLastWins), "Which input wins on duplicate tiles (last, first)")
+
+	bindFlags := []struct {
+		key  string
+		flag string
+	}{
+		{"mbtiles_merge.out", "out"},
+		{"mbtiles_merge.conflict", "conflict"},
+	}
+
+	for _, bf := range bindFlags {
+		if err := viper.BindPFlag(bf.key, mbtilesMergeCmd.Flags().Lookup(bf.flag)); err != nil {
+			panic(fmt.Sprintf("failed to bind flag %s: %v", bf.flag, err))
+		}
+	}
+}
+
+func runMBTilesMerge(cmd *cobra.Command, args []string) error {
+	out := viper.GetString("mbtiles_merge.out")
+	conflict := mbtiles.ConflictPolicy(viper.GetString("mbtiles_merge.conflict"))
+
+	if logger == nil {
+		initLogging()
+	}
+
+	if out == "" {
+		return fmt.Errorf("--out is required")
+	}
+
+	logger.Info("Merging MBTiles", "inputs", args, "output", out, "conflict", conflict)
+
+	stats, err := mbtiles.Merge(out, args, conflict)
+	if err != nil {
+		return fmt.Errorf("failed to merge MBTiles: %w", err)
+	}
+
+	logger.Info("Merge complete",
+		"output", out,
+		"tiles", stats.Tiles,
+		"conflicts", stats.Conflicts,
+		"min_zoom", stats.Metadata.MinZoom,
+		"max_zoom", stats.Metadata.MaxZoom,
+		"bounds", stats.Metadata.Bounds,
+	)
+	return nil
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"math"
+	"os"
+	"runtime/debug"
+	"strconv"
+	"strings"
+
+	"github.com/MeKo-Tech/watercolormap/internal/pipeline"
+	"github.com/MeKo-Tech/watercolormap/internal/watercolor"
+)
+
+// availableMemory returns the memory in bytes renders may use: the Go memory limit
+// (GOMEMLIMIT) when set, otherwise the smaller of the available system memory and the
+// cgroup limit of the container. It returns 0 when none of them is known.
+func availableMemory() int64 {
+	if limit := debug.SetMemoryLimit(-1); limit < math.MaxInt64 {
+		return limit
+	}
+
+	var avail int64
+	if data, err := os.ReadFile("/proc/meminfo"); err == nil {
+		avail = parseMemAvailable(data)
+	}
+	if data, err := os.ReadFile("/sys/fs/cgroup/memory.max"); err == nil {
+		// "max" when unlimited
+		if limit, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64); err == nil && (avail == 0 || limit < avail) {
+			avail = limit
+		}
+	}
+	return avail
+}
+
+// parseMemAvailable returns the MemAvailable entry of /proc/meminfo in bytes, or 0.
+func parseMemAvailable(meminfo []byte) int64 {
+	sc := bufio.NewScanner(bytes.NewReader(meminfo))
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) < 2 || fields[0] != "MemAvailable:" {
+			continue
+		}
+		kb, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return 0
+		}
+		return kb * 1024
+	}
+	return 0
+}
+
+// autoConcurrency caps maxConc to the number of @2x renders of baseTileSize tiles, the
+// largest the server does, that fit into the available memory (see
+// pipeline.EstimateRenderMemory). maxConc is returned unchanged when the memory is unknown.
+func autoConcurrency(maxConc, baseTileSize int, seed int64, params *watercolor.Params) int {
+	avail := availableMemory()
+	if avail <= 0 {
+		logger.Warn("Available memory unknown; not capping concurrent generations", "max_concurrent_generations", maxConc)
+		return maxConc
+	}
+
+	tileSize := 2 * baseTileSize
+	p := watercolor.DefaultParams(tileSize, seed, nil)
+	if params != nil {
+		p = *params
+	}
+	padPx := min(watercolor.RequiredPaddingPx(p), tileSize)
+	perRender := pipeline.EstimateRenderMemory(tileSize, padPx, pipeline.RenderLayers)
+
+	fit := int(max(avail/perRender, 1))
+	if fit < maxConc {
+		logger.Info("Capping concurrent generations to available memory",
+			"max_concurrent_generations", fit,
+			"requested", maxConc,
+			"available_mb", avail>>20,
+			"per_render_mb", perRender>>20)
+		return fit
+	}
+	return maxConc
+}
@@ -0,0 +1,13 @@
+package cmd
+
+import "testing"
+
+func TestParseMemAvailable(t *testing.T) {
+	meminfo := []byte("MemTotal:       16303428 kB\nMemFree:         1234567 kB\nMemAvailable:    8151714 kB\nBuffers:          123456 kB\n")
+	if got, want := parseMemAvailable(meminfo), int64(8151714*1024); got != want {
+		t.Errorf("parseMemAvailable = %d, want %d", got, want)
+	}
+	if got := parseMemAvailable([]byte("MemTotal: 16303428 kB\n")); got != 0 {
+		t.Errorf("parseMemAvailable without MemAvailable = %d, want 0", got)
+	}
+}
@@ -4,8 +4,16 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 
+	"github.com/MeKo-Tech/watercolormap/internal/composite"
+	"github.com/MeKo-Tech/watercolormap/internal/datasource"
+	"github.com/MeKo-Tech/watercolormap/internal/geojson"
+	"github.com/MeKo-Tech/watercolormap/internal/pipeline"
+	"github.com/MeKo-Tech/watercolormap/internal/renderer"
+	"github.com/MeKo-Tech/watercolormap/internal/watercolor"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -39,9 +47,27 @@ func init() {
 
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is ./config.yaml)")
 	rootCmd.PersistentFlags().String("data-source", "overpass", "Data source for OSM data (overpass, protomaps)")
-	rootCmd.PersistentFlags().String("output-dir", "./tiles", "Output directory for generated tiles")
+	rootCmd.PersistentFlags().String("output-dir", "./tiles", "Output directory for generated tiles; generate expands {seed}, {date}, {z-range} and {format}, e.g. tiles/{seed}/{date}")
 	rootCmd.PersistentFlags().Bool("verbose", false, "Enable verbose logging")
 	rootCmd.PersistentFlags().String("log-level", "info", "Log level (debug, info, warn, error)")
+	rootCmd.PersistentFlags().String("classification", "", "YAML file mapping OSM tags to layers (default: built-in mapping)")
+	rootCmd.PersistentFlags().String("params", "", "YAML/TOML file overriding the default watercolor styling (default: built-in styles)")
+	rootCmd.PersistentFlags().Float64("tone-brightness", 0, "Brightness added to finished tiles (-1.0 to 1.0; 0 = unchanged)")
+	rootCmd.PersistentFlags().Float64("tone-contrast", 0, "Contrast adjustment of finished tiles (-1.0 to 1.0; 0 = unchanged)")
+	rootCmd.PersistentFlags().Float64("tone-saturation", 0, "Saturation adjustment of finished tiles (-1.0 = grayscale; 0 = unchanged)")
+	rootCmd.PersistentFlags().Float64("tone-gamma", 1, "Gamma correction of finished tiles (>1 lightens midtones; 1 = unchanged)")
+	rootCmd.PersistentFlags().Float64("dither", 0, "Blue-noise dither strength in 8-bit levels applied to finished tiles against banding (e.g. 2; 0 = off)")
+	rootCmd.PersistentFlags().Float64("vignette", 0, "Darkening of areas dense with roads and buildings, 0 to 1 (e.g. 0.15; 0 = off)")
+	rootCmd.PersistentFlags().Float64("land-tint", 0, "Green glaze of land around parks and forests, 0 to 1 (e.g. 0.3; 0 = off)")
+	rootCmd.PersistentFlags().Bool("bridges", false, "Keep roads crossing water (bridges, causeways) free of the water wash, like roads on land")
+	rootCmd.PersistentFlags().Bool("shared-edges", false, "Darken the boundary where two painted layers meet (a park along a road) once, on the upper layer, instead of on both sides")
+	rootCmd.PersistentFlags().Int("palette-colors", 0, "Write PNG tiles as 8-bit paletted images of at most this many colors (2-256), best with --style flat (0 = true color)")
+	rootCmd.PersistentFlags().String("style", watercolor.StyleWatercolor, "Look preset: watercolor, or flat for crisp solid fills without blur, noise or texture")
+	rootCmd.PersistentFlags().Int("noise-downscale", 1, "Sample the watercolor noise every N pixels and interpolate in between; 2 or 4 renders faster and looks the same at the default noise scale (1 = full resolution)")
+	rootCmd.PersistentFlags().Int("mapnik-buffer", renderer.DefaultBufferPx, "Margin in pixels around each render in which Mapnik still draws features, so road casings aren't clipped at tile edges")
+	rootCmd.PersistentFlags().StringToString("line-width-scale", nil, "Scale the Mapnik stroke widths of layers at render time, e.g. roads=1.5,highways=0.8 (default: as styled)")
+	rootCmd.PersistentFlags().Bool("skip-failed-layers", false, "Render tiles without layers whose Mapnik render failed (logged) instead of failing the tile; the land layer is always required")
+	rootCmd.PersistentFlags().Int64("max-data-size-mb", 0, "Fail tiles whose Overpass response or estimated OSM data size exceeds this many MB instead of rendering them; oversized responses are abandoned mid-download (0 = unlimited)")
 
 	if err := viper.BindPFlag("data-source", rootCmd.PersistentFlags().Lookup("data-source")); err != nil {
 		panic(fmt.Sprintf("failed to bind flag: %v", err))
@@ -55,6 +81,161 @@ func init() {
 	if err := viper.BindPFlag("log-level", rootCmd.PersistentFlags().Lookup("log-level")); err != nil {
 		panic(fmt.Sprintf("failed to bind flag: %v", err))
 	}
+	if err := viper.BindPFlag("classification", rootCmd.PersistentFlags().Lookup("classification")); err != nil {
+		panic(fmt.Sprintf("failed to bind flag: %v", err))
+	}
+	if err := viper.BindPFlag("params", rootCmd.PersistentFlags().Lookup("params")); err != nil {
+		panic(fmt.Sprintf("failed to bind flag: %v", err))
+	}
+	for key, name := range map[string]string{
+		"tone.brightness": "tone-brightness",
+		"tone.contrast":   "tone-contrast",
+		"tone.saturation": "tone-saturation",
+		"tone.gamma":      "tone-gamma",
+		"dither":          "dither",
+		"vignette":        "vignette",
+		"land_tint":       "land-tint",
+		"bridges":         "bridges",
+		"shared_edges":    "shared-edges",
+		"palette_colors":  "palette-colors",
+		"style":           "style",
+		"mapnik_buffer":   "mapnik-buffer",
+		"noise_downscale": "noise-downscale",
+	} {
+		if err := viper.BindPFlag(key, rootCmd.PersistentFlags().Lookup(name)); err != nil {
+			panic(fmt.Sprintf("failed to bind flag: %v", err))
+		}
+	}
+	if err := viper.BindPFlag("line_width_scale", rootCmd.PersistentFlags().Lookup("line-width-scale")); err != nil {
+		panic(fmt.Sprintf("failed to bind flag: %v", err))
+	}
+	if err := viper.BindPFlag("skip_failed_layers", rootCmd.PersistentFlags().Lookup("skip-failed-layers")); err != nil {
+		panic(fmt.Sprintf("failed to bind flag: %v", err))
+	}
+	if err := viper.BindPFlag("overpass.max_data_size_mb", rootCmd.PersistentFlags().Lookup("max-data-size-mb")); err != nil {
+		panic(fmt.Sprintf("failed to bind flag: %v", err))
+	}
+}
+
+// loadClassification loads the feature classification configured via --classification.
+// It returns nil (the built-in mapping) when none is configured.
+func loadClassification() (*datasource.Classification, error) {
+	path := viper.GetString("classification")
+	if path == "" {
+		return nil, nil
+	}
+	return datasource.LoadClassification(path)
+}
+
+// loadParams loads the watercolor parameters configured via --params.
+// It returns nil (the built-in styles) when none are configured.
+func loadParams() (*watercolor.Params, error) {
+	path := viper.GetString("params")
+	if path == "" {
+		return nil, nil
+	}
+	params, err := watercolor.LoadParams(path)
+	if err != nil {
+		return nil, err
+	}
+	return &params, nil
+}
+
+// loadTone returns the tone adjustment configured via the --tone-* flags (or the tone
+// section of the config file).
+func loadTone() composite.ToneAdjust {
+	return composite.ToneAdjust{
+		Brightness: viper.GetFloat64("tone.brightness"),
+		Contrast:   viper.GetFloat64("tone.contrast"),
+		Saturation: viper.GetFloat64("tone.saturation"),
+		Gamma:      viper.GetFloat64("tone.gamma"),
+	}
+}
+
+// loadVignette returns the vignette configured via --vignette.
+func loadVignette() composite.Vignette {
+	return composite.Vignette{Strength: viper.GetFloat64("vignette")}
+}
+
+// loadLandTint returns the landcover tint configured via --land-tint.
+func loadLandTint() composite.LandTint {
+	return composite.LandTint{Strength: viper.GetFloat64("land_tint")}
+}
+
+// loadLayerOverrides returns the per-layer render overrides configured via --line-width-scale
+// (or the line_width_scale section of the config file). It returns nil when none are set.
+func loadLayerOverrides() (map[geojson.LayerType]renderer.LayerRenderOverride, error) {
+	scales := viper.GetStringMapString("line_width_scale")
+	if len(scales) == 0 {
+		return nil, nil
+	}
+	overrides := make(map[geojson.LayerType]renderer.LayerRenderOverride, len(scales))
+	for name, value := range scales {
+		layer := geojson.LayerType(name)
+		switch layer {
+		case geojson.LayerRoads, geojson.LayerHighways, geojson.LayerRivers:
+		default:
+			return nil, fmt.Errorf("invalid --line-width-scale layer %q (expected roads, highways or rivers)", name)
+		}
+		scale, err := strconv.ParseFloat(value, 64)
+		if err != nil || scale <= 0 {
+			return nil, fmt.Errorf("invalid --line-width-scale for %s: %q (expected a positive number)", name, value)
+		}
+		overrides[layer] = renderer.LayerRenderOverride{LineWidthScale: scale}
+	}
+	return overrides, nil
+}
+
+// generatorOptions returns the GeneratorOptions configured by the root flags (params file,
+// styling, tone and render settings) that every rendering command shares. Commands set their
+// own fields on the result.
+func generatorOptions() (pipeline.GeneratorOptions, error) {
+	params, err := loadParams()
+	if err != nil {
+		return pipeline.GeneratorOptions{}, err
+	}
+	layerOverrides, err := loadLayerOverrides()
+	if err != nil {
+		return pipeline.GeneratorOptions{}, err
+	}
+	return pipeline.GeneratorOptions{
+		Params:           params,
+		Tone:             loadTone(),
+		Dither:           viper.GetFloat64("dither"),
+		Vignette:         loadVignette(),
+		Bridges:          viper.GetBool("bridges"),
+		SharedEdges:      viper.GetBool("shared_edges"),
+		Paletted:         viper.GetInt("palette_colors") > 0,
+		PaletteColors:    viper.GetInt("palette_colors"),
+		LandTint:         loadLandTint(),
+		Style:            viper.GetString("style"),
+		MapnikBufferPx:   viper.GetInt("mapnik_buffer"),
+		NoiseDownscale:   viper.GetInt("noise_downscale"),
+		LayerOverrides:   layerOverrides,
+		SkipFailedLayers: viper.GetBool("skip_failed_layers"),
+	}, nil
+}
+
+// validateNoiseSeedMode checks a --noise-seed-mode value.
+func validateNoiseSeedMode(mode string) error {
+	if mode != watercolor.NoiseSeedGlobal && mode != watercolor.NoiseSeedPerTile {
+		return fmt.Errorf("invalid noise-seed-mode %q: must be '%s' or '%s'", mode, watercolor.NoiseSeedGlobal, watercolor.NoiseSeedPerTile)
+	}
+	return nil
+}
+
+// debugStagesDir returns where --debug-stages writes intermediate stages for tiles rendered
+// into baseDir, or "" when the flag is off.
+func debugStagesDir(baseDir string, enabled bool) string {
+	if !enabled {
+		return ""
+	}
+	return filepath.Join(baseDir, "debug-stages")
+}
+
+// maxDataSizeBytes returns the tile data size limit configured via --max-data-size-mb in bytes.
+func maxDataSizeBytes() int64 {
+	return viper.GetInt64("overpass.max_data_size_mb") * 1024 * 1024
 }
 
 func initConfig() {
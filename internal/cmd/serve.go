@@ -32,17 +32,40 @@ func init() {
 
 	serveCmd.Flags().Bool("generate-missing", true, "Generate missing tiles on-demand and cache them to disk")
 	serveCmd.Flags().Bool("disable-cache", false, "Always regenerate tiles (still writes to disk)")
+	serveCmd.Flags().Bool("head-triggers-generate", false, "Generate missing tiles for HEAD requests instead of only reporting whether they are cached")
 	serveCmd.Flags().Int("max-concurrent-generations", runtime.NumCPU(), "Max concurrent tile generations (default: number of CPUs)")
+	serveCmd.Flags().Bool("auto-concurrency", false, "Lower --max-concurrent-generations to the number of renders that fit into the available memory (GOMEMLIMIT, cgroup limit or free RAM)")
+	serveCmd.Flags().Int("paint-workers", 4, "Layers painted concurrently within a single tile (1 = sequential)")
+	serveCmd.Flags().Bool("debug-stages", false, "Write the intermediate pipeline stages of each generated tile to <tiles-dir>/debug-stages/{z}/{x}/{y}/")
 	serveCmd.Flags().Duration("generation-timeout", 2*time.Minute, "Timeout per tile generation")
 	serveCmd.Flags().String("cache-control", "no-store", "Cache-Control header for served tiles")
+	serveCmd.Flags().String("folder-structure", "flat", "Layout of --tiles-dir: flat (z{z}_x{x}_y{y}.png) or hashed ({ab}/{cd}/z{z}_x{x}_y{y}.png), as written by generate --folder-structure")
+	serveCmd.Flags().Int("max-data-zoom", 0, "Render tiles deeper than this zoom from their ancestor's data at it instead of fetching their own (e.g. 16, where OSM detail stops growing; 0 = off)")
+	serveCmd.Flags().Bool("debug-endpoints", false, "Serve debugging endpoints: GET /tiles/query?z=&x=&y= returns the Overpass query a tile would run")
+	serveCmd.Flags().Bool("tms", false, "Address tiles with TMS rows (y grows northward) instead of XYZ; cached files are named the same way")
 
 	serveCmd.Flags().Int("tile-size", 256, "Base tile size in pixels (256; @2x requests render 512)")
 	serveCmd.Flags().String("png-compression", "default", "PNG compression (default, speed, best, none)")
 	serveCmd.Flags().Int64("seed", 1337, "Deterministic seed for noise/texture alignment")
-	serveCmd.Flags().Bool("keep-layers", false, "Keep intermediate rendered layer PNGs for debugging")
+	serveCmd.Flags().Bool("seed-overrides", false, "Serve tiles named with a seed (z13_x4317_y2692_s42.png) rendered with that seed, to compare seeds; each seed caches its own tiles")
+	serveCmd.Flags().String("noise-seed-mode", "global", "Noise seeding: global (seamless, continuous field) or per-tile (no large-scale banding, small seams)")
+	serveCmd.Flags().Bool("keep-layers", false, "Keep intermediate rendered layer PNGs for debugging (identical layers are hard-linked)")
 	serveCmd.Flags().Int("overpass-workers", 4, "Number of parallel Overpass API requests (2-4 recommended for public API)")
 	serveCmd.Flags().Int("fetch-workers", 2, "Number of concurrent data fetch workers (separate from rendering)")
 	serveCmd.Flags().Int64("data-size-warning-mb", 10, "Warn when tile data exceeds this size in MB")
+	serveCmd.Flags().Float64("rate-limit-rps", 0, "Max sustained tile requests per second per client IP (0 = unlimited); /healthz and /demo are exempt")
+	serveCmd.Flags().Int("rate-limit-burst", 20, "Tile requests a client may make at once before --rate-limit-rps applies")
+	serveCmd.Flags().Bool("rate-limit-global", false, "Share one rate limit bucket between all clients instead of one per IP")
+	serveCmd.Flags().String("cors-origins", "*", "Comma-separated origins allowed to fetch tiles and status from browsers (e.g. https://maps.example.com), or * for any")
+	serveCmd.Flags().Bool("compress", true, "Gzip/deflate-compress status JSON, SSE and tiles.json responses for clients that accept it")
+	serveCmd.Flags().String("tls-cert", "", "TLS certificate file; serves HTTPS when set together with --tls-key")
+	serveCmd.Flags().String("tls-key", "", "TLS private key file")
+	serveCmd.Flags().String("purge-token", "", "Enable POST /tiles/purge, authenticated with this shared secret in the X-Purge-Token header (empty = disabled)")
+	serveCmd.Flags().String("fallback-url", "", "Upstream XYZ tile URL template ({z}, {x}, {y}; {r} = @2x) to proxy and cache missing tiles from before generating them (empty = off)")
+	serveCmd.Flags().Int("fallback-min-zoom", 0, "Lowest zoom served from --fallback-url")
+	serveCmd.Flags().Int("fallback-max-zoom", 8, "Highest zoom served from --fallback-url")
+	serveCmd.Flags().Duration("fallback-timeout", 10*time.Second, "Timeout per upstream tile fetch; slower fetches fall back to local generation")
+	serveCmd.Flags().Bool("http2", false, "Also serve HTTP/2: negotiated over TLS, or cleartext h2c (prior knowledge) without TLS")
 
 	mustBind := func(key string, name string) {
 		if err := viper.BindPFlag(key, serveCmd.Flags().Lookup(name)); err != nil {
@@ -52,21 +75,44 @@ func init() {
 
 	mustBind("serve.addr", "addr")
 	mustBind("serve.tiles_dir", "tiles-dir")
+	mustBind("serve.folder_structure", "folder-structure")
+	mustBind("serve.max_data_zoom", "max-data-zoom")
+	mustBind("serve.debug_endpoints", "debug-endpoints")
 	mustBind("serve.demo_dir", "demo-dir")
 	mustBind("serve.mbtiles", "mbtiles")
 	mustBind("serve.generate_missing", "generate-missing")
 	mustBind("serve.disable_cache", "disable-cache")
+	mustBind("serve.head_triggers_generate", "head-triggers-generate")
 	mustBind("serve.max_concurrent_generations", "max-concurrent-generations")
+	mustBind("serve.auto_concurrency", "auto-concurrency")
+	mustBind("serve.paint_workers", "paint-workers")
+	mustBind("serve.debug_stages", "debug-stages")
 	mustBind("serve.generation_timeout", "generation-timeout")
 	mustBind("serve.cache_control", "cache-control")
+	mustBind("serve.tms", "tms")
 
 	mustBind("serve.tile_size", "tile-size")
 	mustBind("serve.png_compression", "png-compression")
 	mustBind("serve.seed", "seed")
+	mustBind("serve.noise_seed_mode", "noise-seed-mode")
+	mustBind("serve.seed_overrides", "seed-overrides")
 	mustBind("serve.keep_layers", "keep-layers")
 	mustBind("serve.overpass_workers", "overpass-workers")
 	mustBind("serve.fetch_workers", "fetch-workers")
 	mustBind("serve.data_size_warning_mb", "data-size-warning-mb")
+	mustBind("serve.rate_limit_rps", "rate-limit-rps")
+	mustBind("serve.rate_limit_burst", "rate-limit-burst")
+	mustBind("serve.rate_limit_global", "rate-limit-global")
+	mustBind("serve.cors_origins", "cors-origins")
+	mustBind("serve.compress", "compress")
+	mustBind("serve.tls_cert", "tls-cert")
+	mustBind("serve.tls_key", "tls-key")
+	mustBind("serve.http2", "http2")
+	mustBind("serve.purge_token", "purge-token")
+	mustBind("serve.fallback_url", "fallback-url")
+	mustBind("serve.fallback_min_zoom", "fallback-min-zoom")
+	mustBind("serve.fallback_max_zoom", "fallback-max-zoom")
+	mustBind("serve.fallback_timeout", "fallback-timeout")
 }
 
 func runServe(cmd *cobra.Command, args []string) error {
@@ -90,12 +136,47 @@ func runServe(cmd *cobra.Command, args []string) error {
 	baseTileSize := viper.GetInt("serve.tile_size")
 	pngCompression := viper.GetString("serve.png_compression")
 	seed := viper.GetInt64("serve.seed")
+	noiseSeedMode := viper.GetString("serve.noise_seed_mode")
+	if err := validateNoiseSeedMode(noiseSeedMode); err != nil {
+		return err
+	}
 	keepLayers := viper.GetBool("serve.keep_layers")
 	overpassWorkers := viper.GetInt("serve.overpass_workers")
 	fetchWorkers := viper.GetInt("serve.fetch_workers")
 	dataSizeWarningMB := viper.GetInt64("serve.data_size_warning_mb")
+	compress := viper.GetBool("serve.compress")
+	tlsCert := viper.GetString("serve.tls_cert")
+	tlsKey := viper.GetString("serve.tls_key")
+	if (tlsCert == "") != (tlsKey == "") {
+		return fmt.Errorf("--tls-cert and --tls-key must be set together")
+	}
+	useTLS := tlsCert != ""
+	http2 := viper.GetBool("serve.http2")
+
+	// Text endpoints are optionally compressed; tile images never are.
+	withCompression := func(h http.Handler) http.Handler {
+		if !compress {
+			return h
+		}
+		return server.Compress(h)
+	}
+
+	// Tiles and status are served to browsers on the allowed origins
+	cors, err := server.NewCORS(viper.GetString("serve.cors_origins"))
+	if err != nil {
+		return fmt.Errorf("invalid --cors-origins: %w", err)
+	}
+	withCORS := cors.Middleware
+
+	// Tile requests are optionally rate limited; health checks and the demo UI never are.
+	limiter := server.NewRateLimiter(server.RateLimitConfig{
+		RPS:    viper.GetFloat64("serve.rate_limit_rps"),
+		Burst:  viper.GetInt("serve.rate_limit_burst"),
+		Global: viper.GetBool("serve.rate_limit_global"),
+	})
 
 	mux := http.NewServeMux()
+	// /healthz is the cheap liveness check; /readyz (registered below) is the deep readiness check.
 	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
 		_, _ = w.Write([]byte("ok"))
@@ -118,47 +199,92 @@ func runServe(cmd *cobra.Command, args []string) error {
 		mbHandler, err := server.NewMBTilesHandler(server.MBTilesConfig{
 			MBTilesPath:  mbtilesPath,
 			CacheControl: cacheControl,
+			TMS:          viper.GetBool("serve.tms"),
 		}, logger)
 		if err != nil {
 			return fmt.Errorf("failed to create MBTiles handler: %w", err)
 		}
 		defer mbHandler.Close()
 
-		mux.Handle("/tiles/", withCORS(mbHandler.Handler()))
+		mux.Handle("/tiles/", withCORS(limiter.Middleware(mbHandler.Handler())))
+		tileJSON, err := mbHandler.TileJSON()
+		if err != nil {
+			return fmt.Errorf("failed to read MBTiles metadata: %w", err)
+		}
+		mux.Handle("/tiles.json", withCORS(withCompression(server.TileJSONHandler(tileJSON))))
+		// Nothing is rendered when serving from MBTiles; an open archive is ready.
+		mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			_, _ = w.Write([]byte("ok"))
+		})
 	} else {
 		logger.Info("Using folder-based tile serving with on-demand generation", "tiles_dir", tilesDir)
 		dataSourceName := viper.GetString("data-source")
 		var ds pipeline.DataSource
 		switch dataSourceName {
 		case "overpass":
-			ds = createOverpassDataSource(overpassWorkers, logger)
+			classification, err := loadClassification()
+			if err != nil {
+				return err
+			}
+			ds = createOverpassDataSource(overpassWorkers, classification, logger)
 		default:
 			return fmt.Errorf("unsupported data source: %s", dataSourceName)
 		}
 
+		opts, err := generatorOptions()
+		if err != nil {
+			return err
+		}
+		opts.PNGCompression = pngCompression
+		opts.NoiseSeedMode = noiseSeedMode
+		opts.PaintWorkers = viper.GetInt("serve.paint_workers")
+		opts.DebugStagesDir = debugStagesDir(tilesDir, viper.GetBool("serve.debug_stages"))
+		opts.FolderStructure = viper.GetString("serve.folder_structure")
+		opts.MaxDataZoom = viper.GetInt("serve.max_data_zoom")
+		opts.TMS = viper.GetBool("serve.tms")
+		if viper.GetBool("serve.auto_concurrency") {
+			maxConc = autoConcurrency(maxConc, baseTileSize, seed, opts.Params)
+		}
+
 		od, err := server.NewOnDemandTiles(ds, server.OnDemandTilesConfig{
 			TilesDir:                 tilesDir,
 			StylesDir:                filepath.Join("assets", "styles"),
 			TexturesDir:              filepath.Join("assets", "textures"),
 			BaseTileSize:             baseTileSize,
 			Seed:                     seed,
+			SeedOverrides:            viper.GetBool("serve.seed_overrides"),
 			KeepLayers:               keepLayers,
-			PNGCompression:           pngCompression,
 			GenerateMissing:          generateMissing,
 			DisableCache:             disableCache,
+			HeadTriggersGenerate:     viper.GetBool("serve.head_triggers_generate"),
 			MaxConcurrentGenerations: maxConc,
 			GenerationTimeout:        genTimeout,
+			Generator:                opts,
 			CacheControl:             cacheControl,
 			FetchWorkers:             fetchWorkers,
 			DataSizeWarningMB:        dataSizeWarningMB,
+			MaxDataSizeMB:            viper.GetInt64("overpass.max_data_size_mb"),
+			FallbackURL:              viper.GetString("serve.fallback_url"),
+			FallbackMinZoom:          viper.GetInt("serve.fallback_min_zoom"),
+			FallbackMaxZoom:          viper.GetInt("serve.fallback_max_zoom"),
+			FallbackTimeout:          viper.GetDuration("serve.fallback_timeout"),
 		}, logger)
 		if err != nil {
 			return err
 		}
 
-		mux.Handle("/tiles/status", withCORS(od.StatusHandler()))
-		mux.Handle("/tiles/status/stream", withCORS(od.StatusStreamHandler()))
-		mux.Handle("/tiles/", withCORS(od.Handler()))
+		mux.Handle("/readyz", od.ReadyHandler())
+		mux.Handle("/tiles/status", withCORS(withCompression(od.StatusHandler())))
+		mux.Handle("/tiles/status/stream", withCORS(withCompression(od.StatusStreamHandler())))
+		mux.Handle("/tiles.json", withCORS(withCompression(server.TileJSONHandler(od.TileJSON()))))
+		mux.Handle("/tiles/", withCORS(limiter.Middleware(od.Handler())))
+		if token := viper.GetString("serve.purge_token"); token != "" {
+			mux.Handle("/tiles/purge", od.PurgeHandler(token))
+		}
+		if viper.GetBool("serve.debug_endpoints") {
+			mux.Handle("/tiles/query", withCORS(od.QueryHandler()))
+		}
 	}
 
 	logger.Info("demo server listening",
@@ -170,23 +296,41 @@ func runServe(cmd *cobra.Command, args []string) error {
 		"overpass_workers", overpassWorkers,
 		"fetch_workers", fetchWorkers,
 		"data_size_warning_mb", dataSizeWarningMB,
+		"rate_limit_rps", viper.GetFloat64("serve.rate_limit_rps"),
+		"tls", useTLS,
+		"http2", http2,
 	)
 
 	// Print the URL directly for easy access
-	fmt.Printf("\n  → http://%s/demo/\n\n", addr)
+	scheme := "http"
+	if useTLS {
+		scheme = "https"
+	}
+	fmt.Printf("\n  → %s://%s/demo/\n\n", scheme, addr)
 
-	srv := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+		Protocols:         server.Protocols(http2, useTLS),
+	}
+	if useTLS {
+		return srv.ListenAndServeTLS(tlsCert, tlsKey)
+	}
 	return srv.ListenAndServe()
 }
 
 // createOverpassDataSource creates an Overpass datasource from configuration.
 // Supports both single-server and multi-server (geographic routing) configurations.
-func createOverpassDataSource(overpassWorkers int, logger *slog.Logger) pipeline.DataSource {
+// A nil classification keeps the built-in feature-to-layer mapping.
+func createOverpassDataSource(overpassWorkers int, classification *datasource.Classification, logger *slog.Logger) pipeline.DataSource {
 	// Check for multi-server configuration
 	if viper.IsSet("overpass.servers") {
 		var configs []map[string]interface{}
 		if err := viper.UnmarshalKey("overpass.servers", &configs); err == nil && len(configs) > 0 {
-			return createMultiServerDataSource(configs, logger)
+			mds := createMultiServerDataSource(configs, logger).WithClassification(classification)
+			mds.FailoverOnError = viper.GetBool("overpass.failover_on_error")
+			return mds
 		}
 	}
 
@@ -197,11 +341,11 @@ func createOverpassDataSource(overpassWorkers int, logger *slog.Logger) pipeline
 	}
 
 	logger.Info("Using single Overpass server", "endpoint", endpoint, "workers", overpassWorkers)
-	return datasource.NewOverpassDataSourceWithWorkers(endpoint, overpassWorkers)
+	return datasource.NewOverpassDataSourceWithWorkers(endpoint, overpassWorkers).WithClassification(classification)
 }
 
 // createMultiServerDataSource creates a multi-server routing datasource from config.
-func createMultiServerDataSource(configs []map[string]interface{}, logger *slog.Logger) pipeline.DataSource {
+func createMultiServerDataSource(configs []map[string]interface{}, logger *slog.Logger) *datasource.MultiOverpassDataSource {
 	var serverConfigs []datasource.ServerConfig
 
 	for i, cfg := range configs {
@@ -280,17 +424,3 @@ func getFloat64OrDefault(m map[string]interface{}, key string, defaultVal float6
 	}
 	return defaultVal
 }
-
-func withCORS(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
-
-		if r.Method == http.MethodOptions {
-			w.WriteHeader(http.StatusNoContent)
-			return
-		}
-
-		next.ServeHTTP(w, r)
-	})
-}
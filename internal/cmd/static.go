@@ -0,0 +1,197 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/MeKo-Tech/watercolormap/internal/datasource"
+	"github.com/MeKo-Tech/watercolormap/internal/mask"
+	"github.com/MeKo-Tech/watercolormap/internal/pipeline"
+	"github.com/MeKo-Tech/watercolormap/internal/types"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var staticCmd = &cobra.Command{
+	Use:   "static",
+	Short: "Render a bounding box as a single image",
+	Long: `Render a static map: one PNG of a bounding box at a fixed zoom, stitched from the
+tiles covering it and cropped to the exact bounds. The pixels match the tiles of the
+same zoom, including the noise and texture alignment.
+
+With --size, the bounding box is instead rendered in one pass as an image of exactly that
+many pixels (e.g. a poster). --zoom then selects the styling and data detail, and the
+bounding box is grown around its center to the aspect ratio of the image.
+
+Examples:
+  watercolormap static --bbox 13.70,51.03,13.78,51.07 --zoom 14 --out dresden.png
+  watercolormap static --bbox 13.70,51.03,13.78,51.07 --zoom 14 --size 6000x4000 --out poster.png`,
+	RunE: runStatic,
+}
+
+func init() {
+	rootCmd.AddCommand(staticCmd)
+
+	staticCmd.Flags().String("bbox", "", "Bounding box to render: minLon,minLat,maxLon,maxLat (required)")
+	staticCmd.Flags().IntP("zoom", "z", -1, "Zoom level of the tiles to stitch (required)")
+	staticCmd.Flags().StringP("out", "o", "map.png", "Output PNG file")
+	staticCmd.Flags().Int("tile-size", 256, "Tile size in pixels (typically 256 or 512 for Hi-DPI)")
+	staticCmd.Flags().Int64("seed", 1337, "Deterministic seed for noise/texture alignment")
+	staticCmd.Flags().Bool("world-file", false, "Also write a world file (.pgw, EPSG:3857) next to the image for GIS tools")
+	staticCmd.Flags().String("size", "", "Render the bounding box in one pass as an image of WxH pixels (e.g. 6000x4000) instead of stitching tiles")
+
+	bindFlags := []struct {
+		key  string
+		flag string
+	}{
+		{"static.bbox", "bbox"},
+		{"static.zoom", "zoom"},
+		{"static.out", "out"},
+		{"static.tile_size", "tile-size"},
+		{"static.seed", "seed"},
+		{"static.world_file", "world-file"},
+		{"static.size", "size"},
+	}
+
+	for _, bf := range bindFlags {
+		if err := viper.BindPFlag(bf.key, staticCmd.Flags().Lookup(bf.flag)); err != nil {
+			panic(fmt.Sprintf("failed to bind flag %s: %v", bf.flag, err))
+		}
+	}
+}
+
+func runStatic(cmd *cobra.Command, args []string) error {
+	bboxStr := viper.GetString("static.bbox")
+	zoom := viper.GetInt("static.zoom")
+	outFile := viper.GetString("static.out")
+	tileSize := viper.GetInt("static.tile_size")
+	seed := viper.GetInt64("static.seed")
+	worldFile := viper.GetBool("static.world_file")
+	dataSourceName := viper.GetString("data-source")
+
+	if logger == nil {
+		initLogging()
+	}
+
+	if bboxStr == "" {
+		return fmt.Errorf("--bbox is required")
+	}
+	bbox, err := parseBBox(bboxStr)
+	if err != nil {
+		return fmt.Errorf("invalid bbox: %w", err)
+	}
+	if zoom < 0 {
+		return fmt.Errorf("--zoom is required")
+	}
+	if zoom > 30 {
+		return fmt.Errorf("invalid zoom level %d", zoom)
+	}
+	if tileSize <= 0 {
+		return fmt.Errorf("--tile-size must be positive, got %d", tileSize)
+	}
+	width, height, err := parseImageSize(viper.GetString("static.size"))
+	if err != nil {
+		return fmt.Errorf("invalid --size: %w", err)
+	}
+
+	var ds pipeline.DataSource
+	switch dataSourceName {
+	case "overpass":
+		classification, err := loadClassification()
+		if err != nil {
+			return err
+		}
+		ds = datasource.NewOverpassDataSource("").
+			WithClassification(classification).
+			WithMaxDataSize(maxDataSizeBytes())
+	default:
+		return fmt.Errorf("unsupported data source: %s", dataSourceName)
+	}
+
+	opts, err := generatorOptions()
+	if err != nil {
+		return err
+	}
+	opts.NoiseCache = mask.NewNoiseCache(4096) // Neighboring tiles share their padding
+	opts.PaintWorkers = runtime.NumCPU()       // Tiles render one after another
+
+	stylesDir := filepath.Join("assets", "styles")
+	texturesDir := filepath.Join("assets", "textures")
+
+	gen, err := pipeline.NewGenerator(ds, stylesDir, texturesDir, filepath.Dir(outFile), tileSize, seed, false, logger, opts)
+	if err != nil {
+		return fmt.Errorf("failed to init generator: %w", err)
+	}
+
+	bounds := types.BoundingBox{MinLon: bbox[0], MinLat: bbox[1], MaxLon: bbox[2], MaxLat: bbox[3]}
+	var img *image.NRGBA
+	if width > 0 {
+		img, err = gen.RenderArea(context.Background(), bounds, width, height, zoom)
+	} else {
+		img, err = gen.RenderStatic(context.Background(), bounds, zoom)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to render static map: %w", err)
+	}
+
+	f, err := os.Create(outFile)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close() // nolint:errcheck
+		return fmt.Errorf("failed to encode static map: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write static map: %w", err)
+	}
+
+	logger.Info("Static map written", "path", outFile, "width", img.Bounds().Dx(), "height", img.Bounds().Dy())
+
+	if worldFile {
+		// Named after the image with a "w" world file extension: map.png -> map.pgw
+		wfPath := strings.TrimSuffix(outFile, filepath.Ext(outFile)) + ".pgw"
+		wf := gen.StaticWorldFile(bounds, zoom)
+		if width > 0 {
+			wf = gen.AreaWorldFile(bounds, width, height, zoom)
+		}
+		if err := wf.Write(wfPath); err != nil {
+			return err
+		}
+		logger.Info("World file written", "path", wfPath, "crs", "EPSG:3857")
+	}
+	return nil
+}
+
+// parseImageSize parses an image size "WxH" into its width and height in pixels. An empty
+// string returns 0, 0.
+func parseImageSize(s string) (int, int, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return 0, 0, nil
+	}
+
+	w, h, ok := strings.Cut(s, "x")
+	if !ok {
+		return 0, 0, fmt.Errorf("expected WxH, got %q", s)
+	}
+	width, err := strconv.Atoi(strings.TrimSpace(w))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid width %q: %w", w, err)
+	}
+	height, err := strconv.Atoi(strings.TrimSpace(h))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid height %q: %w", h, err)
+	}
+	if width < 1 || height < 1 {
+		return 0, 0, fmt.Errorf("size must be at least 1x1, got %dx%d", width, height)
+	}
+	return width, height, nil
+}
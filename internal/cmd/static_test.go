@@ -0,0 +1,38 @@
+package cmd
+
+import "testing"
+
+func TestParseImageSize(t *testing.T) {
+	tests := []struct {
+		input      string
+		wantWidth  int
+		wantHeight int
+		wantErr    bool
+	}{
+		{input: "", wantWidth: 0, wantHeight: 0},
+		{input: "6000x4000", wantWidth: 6000, wantHeight: 4000},
+		{input: " 800X600 ", wantWidth: 800, wantHeight: 600},
+		{input: "800", wantErr: true},
+		{input: "0x600", wantErr: true},
+		{input: "ax600", wantErr: true},
+		{input: "800x600x2", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			w, h, err := parseImageSize(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseImageSize(%q) expected error, got nil", tt.input)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseImageSize(%q) unexpected error: %v", tt.input, err)
+			}
+			if w != tt.wantWidth || h != tt.wantHeight {
+				t.Errorf("parseImageSize(%q) = %dx%d, want %dx%d", tt.input, w, h, tt.wantWidth, tt.wantHeight)
+			}
+		})
+	}
+}
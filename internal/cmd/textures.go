@@ -25,6 +25,8 @@ func init() {
 	texturesCmd.Flags().Float64("variation", 1.0, "Global variation multiplier (0..1) applied to defaults")
 	texturesCmd.Flags().Float64("brushness", 1.0, "Brush stroke strength (0..1)")
 	texturesCmd.Flags().Bool("force", false, "Overwrite textures that already exist")
+	texturesCmd.Flags().String("contact-sheet", "", "Optional PNG path for a contact sheet previewing all layer textures")
+	texturesCmd.Flags().Int("thumb-size", 256, "Thumbnail size in pixels for the contact sheet")
 
 	bindFlags := []struct {
 		key  string
@@ -36,6 +38,8 @@ func init() {
 		{"textures.variation", "variation"},
 		{"textures.brushness", "brushness"},
 		{"textures.force", "force"},
+		{"textures.contact_sheet", "contact-sheet"},
+		{"textures.thumb_size", "thumb-size"},
 	}
 
 	for _, bf := range bindFlags {
@@ -56,6 +60,8 @@ func runTextures(cmd *cobra.Command, args []string) error {
 	variation := viper.GetFloat64("textures.variation")
 	brushness := viper.GetFloat64("textures.brushness")
 	force := viper.GetBool("textures.force")
+	contactSheet := viper.GetString("textures.contact_sheet")
+	thumbSize := viper.GetInt("textures.thumb_size")
 
 	if size <= 0 {
 		return fmt.Errorf("size must be positive")
@@ -66,16 +72,39 @@ func runTextures(cmd *cobra.Command, args []string) error {
 	if brushness < 0 || brushness > 1 {
 		return fmt.Errorf("brushness must be within [0,1]")
 	}
+	if contactSheet != "" && thumbSize <= 0 {
+		return fmt.Errorf("thumb-size must be positive")
+	}
 
 	result, err := texture.WriteDefaultTextures(dir, size, seed, variation, brushness, force)
 	if err != nil {
 		return err
 	}
 
+	out := cmd.OutOrStdout()
+	for _, path := range result.Written {
+		fmt.Fprintf(out, "written  %s\n", path)
+	}
+	for _, path := range result.Skipped {
+		fmt.Fprintf(out, "skipped  %s (exists; use --force to overwrite)\n", path)
+	}
+
 	logger.Info("Texture generation complete",
 		"dir", dir,
 		"written", len(result.Written),
 		"skipped", len(result.Skipped),
 	)
+
+	if contactSheet != "" {
+		textures, err := texture.LoadDefaultTextures(dir)
+		if err != nil {
+			return fmt.Errorf("failed to load textures for contact sheet: %w", err)
+		}
+		if err := texture.WriteContactSheet(contactSheet, textures, thumbSize); err != nil {
+			return fmt.Errorf("failed to write contact sheet: %w", err)
+		}
+		fmt.Fprintf(out, "contact sheet  %s\n", contactSheet)
+	}
+
 	return nil
 }
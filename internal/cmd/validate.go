@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/MeKo-Tech/watercolormap/internal/datasource"
+	"github.com/MeKo-Tech/watercolormap/internal/pipeline"
+	"github.com/MeKo-Tech/watercolormap/internal/tile"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var validateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Render a tile and check it against quality thresholds",
+	Long: `Render a single tile without writing it and check basic quality metrics: the number of
+fetched features, the painted fraction, black regions and the water coverage. The report
+is printed to stdout; the command exits non-zero if any threshold is violated, so CI can
+use it as a quality gate.
+
+Example:
+  watercolormap validate -z 13 -x 4317 -y 2692 --min-water 0.05`,
+	RunE: runValidate,
+}
+
+func init() {
+	rootCmd.AddCommand(validateCmd)
+
+	defaults := pipeline.DefaultTileThresholds()
+	validateCmd.Flags().IntP("zoom", "z", 13, "Zoom level of the tile")
+	validateCmd.Flags().IntP("x", "x", 0, "X tile coordinate")
+	validateCmd.Flags().IntP("y", "y", 0, "Y tile coordinate")
+	validateCmd.Flags().Int("tile-size", 256, "Tile size in pixels (typically 256 or 512 for Hi-DPI)")
+	validateCmd.Flags().Int64("seed", 1337, "Deterministic seed for noise/texture alignment")
+	validateCmd.Flags().Int("min-features", defaults.MinFeatures, "Minimum number of fetched features")
+	validateCmd.Flags().Float64("min-painted", defaults.MinOpaqueFraction, "Minimum fraction of non-transparent pixels (0 to 1)")
+	validateCmd.Flags().Int("max-black-blocks", defaults.MaxBlackBlocks, "Maximum number of entirely black 16x16 blocks")
+	validateCmd.Flags().Float64("min-water", defaults.MinWaterFraction, "Minimum fraction of the tile covered by water (0 to 1)")
+	validateCmd.Flags().Float64("max-water", defaults.MaxWaterFraction, "Maximum fraction of the tile covered by water (0 to 1; 0 = no limit)")
+
+	bindFlags := []struct {
+		key  string
+		flag string
+	}{
+		{"validate.zoom", "zoom"},
+		{"validate.x", "x"},
+		{"validate.y", "y"},
+		{"validate.tile_size", "tile-size"},
+		{"validate.seed", "seed"},
+		{"validate.min_features", "min-features"},
+		{"validate.min_painted", "min-painted"},
+		{"validate.max_black_blocks", "max-black-blocks"},
+		{"validate.min_water", "min-water"},
+		{"validate.max_water", "max-water"},
+	}
+
+	for _, bf := range bindFlags {
+		if err := viper.BindPFlag(bf.key, validateCmd.Flags().Lookup(bf.flag)); err != nil {
+			panic(fmt.Sprintf("failed to bind flag %s: %v", bf.flag, err))
+		}
+	}
+}
+
+func runValidate(cmd *cobra.Command, args []string) error {
+	zoom := viper.GetInt("validate.zoom")
+	x := viper.GetInt("validate.x")
+	y := viper.GetInt("validate.y")
+	tileSize := viper.GetInt("validate.tile_size")
+	seed := viper.GetInt64("validate.seed")
+	dataSourceName := viper.GetString("data-source")
+	thresholds := pipeline.TileThresholds{
+		MinFeatures:       viper.GetInt("validate.min_features"),
+		MinOpaqueFraction: viper.GetFloat64("validate.min_painted"),
+		MaxBlackBlocks:    viper.GetInt("validate.max_black_blocks"),
+		MinWaterFraction:  viper.GetFloat64("validate.min_water"),
+		MaxWaterFraction:  viper.GetFloat64("validate.max_water"),
+	}
+
+	if logger == nil {
+		initLogging()
+	}
+
+	if zoom < 0 || zoom > 30 {
+		return fmt.Errorf("invalid zoom level %d", zoom)
+	}
+	coords := tile.NewCoords(uint32(zoom), uint32(x), uint32(y))
+	if x < 0 || y < 0 || !coords.InRange() {
+		return fmt.Errorf("tile %d/%d/%d is outside the world", zoom, x, y)
+	}
+	if tileSize <= 0 {
+		return fmt.Errorf("--tile-size must be positive, got %d", tileSize)
+	}
+
+	var ds pipeline.DataSource
+	switch dataSourceName {
+	case "overpass":
+		classification, err := loadClassification()
+		if err != nil {
+			return err
+		}
+		ds = datasource.NewOverpassDataSource("").
+			WithClassification(classification).
+			WithMaxDataSize(maxDataSizeBytes())
+	default:
+		return fmt.Errorf("unsupported data source: %s", dataSourceName)
+	}
+
+	opts, err := generatorOptions()
+	if err != nil {
+		return err
+	}
+
+	stylesDir := filepath.Join("assets", "styles")
+	texturesDir := filepath.Join("assets", "textures")
+
+	gen, err := pipeline.NewGenerator(ds, stylesDir, texturesDir, viper.GetString("output-dir"), tileSize, seed, false, logger, opts)
+	if err != nil {
+		return fmt.Errorf("failed to init generator: %w", err)
+	}
+
+	report, err := gen.ValidateTile(context.Background(), coords)
+	if err != nil {
+		return fmt.Errorf("failed to render tile %s: %w", coords.String(), err)
+	}
+
+	out := cmd.OutOrStdout()
+	fmt.Fprint(out, report.String())
+	failures := report.Check(thresholds)
+	for _, f := range failures {
+		fmt.Fprintf(out, "FAIL: %s\n", f)
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("tile %s failed %d quality check(s)", coords.String(), len(failures))
+	}
+	fmt.Fprintln(out, "PASS")
+	return nil
+}
@@ -0,0 +1,58 @@
+package composite
+
+import (
+	"image"
+	"image/color"
+)
+
+// ContentBounds returns the tightest rectangle containing every pixel of img that differs from
+// the background by more than backgroundTolerance in any channel (non-premultiplied RGBA). The
+// background is the color of the top-left pixel, which for finished tiles is the paper or the
+// fill that covers the whole tile (solid land, open ocean). The result is empty when img has
+// no content, e.g. for tiles that could be stored once and shared.
+func ContentBounds(img image.Image, backgroundTolerance uint8) image.Rectangle {
+	b := img.Bounds()
+	if b.Empty() {
+		return image.Rectangle{}
+	}
+
+	var at func(x, y int) color.NRGBA
+	if nrgba, ok := img.(*image.NRGBA); ok {
+		at = nrgba.NRGBAAt
+	} else {
+		at = func(x, y int) color.NRGBA {
+			return color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+		}
+	}
+	bg := at(b.Min.X, b.Min.Y)
+
+	tol := int(backgroundTolerance)
+	differs := func(c color.NRGBA) bool {
+		return absDiff(c.R, bg.R) > tol || absDiff(c.G, bg.G) > tol ||
+			absDiff(c.B, bg.B) > tol || absDiff(c.A, bg.A) > tol
+	}
+
+	content := image.Rectangle{}
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if !differs(at(x, y)) {
+				continue
+			}
+			if content.Empty() {
+				content = image.Rect(x, y, x+1, y+1)
+				continue
+			}
+			content.Min.X = min(content.Min.X, x)
+			content.Max.X = max(content.Max.X, x+1)
+			content.Max.Y = y + 1
+		}
+	}
+	return content
+}
+
+func absDiff(a, b uint8) int {
+	if a > b {
+		return int(a - b)
+	}
+	return int(b - a)
+}
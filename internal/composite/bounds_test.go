@@ -0,0 +1,67 @@
+package composite
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestContentBounds(t *testing.T) {
+	paper := color.NRGBA{R: 245, G: 240, B: 230, A: 255}
+	newTile := func() *image.NRGBA {
+		img := image.NewNRGBA(image.Rect(0, 0, 64, 64))
+		for i := 0; i < len(img.Pix); i += 4 {
+			img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = paper.R, paper.G, paper.B, paper.A
+		}
+		return img
+	}
+
+	t.Run("painted square", func(t *testing.T) {
+		img := newTile()
+		square := image.Rect(10, 20, 30, 45)
+		for y := square.Min.Y; y < square.Max.Y; y++ {
+			for x := square.Min.X; x < square.Max.X; x++ {
+				img.SetNRGBA(x, y, color.NRGBA{R: 100, G: 150, B: 200, A: 255})
+			}
+		}
+		if got := ContentBounds(img, 4); got != square {
+			t.Errorf("ContentBounds = %v, want %v", got, square)
+		}
+	})
+
+	t.Run("uniform tile is empty", func(t *testing.T) {
+		if got := ContentBounds(newTile(), 0); !got.Empty() {
+			t.Errorf("ContentBounds = %v, want empty", got)
+		}
+	})
+
+	t.Run("grain within tolerance", func(t *testing.T) {
+		img := newTile()
+		img.SetNRGBA(5, 5, color.NRGBA{R: paper.R - 3, G: paper.G + 2, B: paper.B, A: 255})
+		if got := ContentBounds(img, 3); !got.Empty() {
+			t.Errorf("ContentBounds = %v, want empty within tolerance", got)
+		}
+		if got, want := ContentBounds(img, 2), image.Rect(5, 5, 6, 6); got != want {
+			t.Errorf("ContentBounds = %v, want %v below tolerance", got, want)
+		}
+	})
+
+	t.Run("sub-image and generic image", func(t *testing.T) {
+		img := newTile()
+		img.SetNRGBA(40, 50, color.NRGBA{A: 255})
+		sub := img.SubImage(image.Rect(32, 32, 64, 64))
+		want := image.Rect(40, 50, 41, 51)
+		if got := ContentBounds(sub, 0); got != want {
+			t.Errorf("ContentBounds(sub-image) = %v, want %v", got, want)
+		}
+		rgba := image.NewRGBA(img.Bounds())
+		for y := 0; y < 64; y++ {
+			for x := 0; x < 64; x++ {
+				rgba.Set(x, y, img.At(x, y))
+			}
+		}
+		if got := ContentBounds(rgba, 0); got != want {
+			t.Errorf("ContentBounds(RGBA) = %v, want %v", got, want)
+		}
+	})
+}
@@ -7,19 +7,9 @@ import (
 	"math"
 
 	"github.com/MeKo-Tech/watercolormap/internal/geojson"
+	"github.com/MeKo-Tech/watercolormap/internal/watercolor"
 )
 
-// DefaultOrder defines the bottom-to-top compositing order for watercolor layers.
-var DefaultOrder = []geojson.LayerType{
-	geojson.LayerWater,
-	geojson.LayerLand,
-	geojson.LayerParks,
-	geojson.LayerUrban,     // Civic areas (lighter lavender)
-	geojson.LayerBuildings, // Buildings on top of urban (darker lavender)
-	geojson.LayerRoads,
-	geojson.LayerHighways,
-}
-
 // CompositeLayersOverBase stacks watercolor-painted layers into a single tile over a pre-filled base.
 // This is used to model "paper" showing through cutouts (e.g., roads as transparent holes).
 func CompositeLayersOverBase(
@@ -33,7 +23,7 @@ func CompositeLayersOverBase(
 	}
 
 	if order == nil {
-		order = DefaultOrder
+		order = watercolor.DefaultCompositeOrder
 	}
 
 	expectedBounds := image.Rect(0, 0, tileSize, tileSize)
@@ -50,24 +40,64 @@ func CompositeLayersOverBase(
 		}
 	}
 
+	if err := CompositeLayersInto(dst, layers, order); err != nil {
+		return nil, err
+	}
+
+	return dst, nil
+}
+
+// CompositeLayersInto alpha-blends layers, in order, over the existing contents of dst.
+// This avoids allocation when the caller has already filled a reusable buffer with the base.
+// Each layer must match the bounds of dst.
+func CompositeLayersInto(dst *image.NRGBA, layers map[geojson.LayerType]image.Image, order []geojson.LayerType) error {
+	return CompositeLayersOverPaperInto(dst, layers, order, nil, nil)
+}
+
+// CompositeLayersOverPaperInto is like CompositeLayersInto, but lets the paper texture bleed
+// through painted layers to simulate thin pigment. Each painted pixel of a layer with a bleed
+// factor b (0.0-1.0) is mixed toward the paper color at that pixel by b before blending, so the
+// wash fades toward the paper rather than toward the layers beneath it. paper must match the
+// bounds of dst; with a nil paper or no bleed factors this equals CompositeLayersInto.
+func CompositeLayersOverPaperInto(
+	dst *image.NRGBA,
+	layers map[geojson.LayerType]image.Image,
+	order []geojson.LayerType,
+	paper image.Image,
+	bleed map[geojson.LayerType]float64,
+) error {
+	if dst == nil {
+		return fmt.Errorf("destination image is nil")
+	}
+	if order == nil {
+		order = watercolor.DefaultCompositeOrder
+	}
+	if paper != nil && paper.Bounds() != dst.Bounds() {
+		return fmt.Errorf("paper bounds %v do not match expected %v", paper.Bounds(), dst.Bounds())
+	}
+
 	for _, layer := range order {
 		img := layers[layer]
 		if img == nil {
 			continue
 		}
 
-		if img.Bounds() != expectedBounds {
-			return nil, fmt.Errorf("layer %s bounds %v do not match expected %v", layer, img.Bounds(), expectedBounds)
+		if img.Bounds() != dst.Bounds() {
+			return fmt.Errorf("layer %s bounds %v do not match expected %v", layer, img.Bounds(), dst.Bounds())
 		}
 
+		if b := bleed[layer]; paper != nil && b > 0 {
+			alphaOverPaper(dst, img, paper, math.Min(b, 1))
+			continue
+		}
 		alphaOver(dst, img)
 	}
 
-	return dst, nil
+	return nil
 }
 
 // CompositeLayers stacks watercolor-painted layers into a single tile using alpha blending.
-// Layers are drawn in the provided order (or DefaultOrder when nil). Each layer must match tileSize.
+// Layers are drawn in the provided order (or watercolor.DefaultCompositeOrder when nil). Each layer must match tileSize.
 func CompositeLayers(
 	layers map[geojson.LayerType]image.Image,
 	order []geojson.LayerType,
@@ -78,7 +108,7 @@ func CompositeLayers(
 	}
 
 	if order == nil {
-		order = DefaultOrder
+		order = watercolor.DefaultCompositeOrder
 	}
 
 	expectedBounds := image.Rect(0, 0, tileSize, tileSize)
@@ -101,6 +131,12 @@ func CompositeLayers(
 }
 
 func alphaOver(dst *image.NRGBA, src image.Image) {
+	alphaOverPaper(dst, src, nil, 0)
+}
+
+// alphaOverPaper blends src over dst after mixing each source color toward the paper
+// color at the same pixel by bleed (no mixing when paper is nil).
+func alphaOverPaper(dst *image.NRGBA, src image.Image, paper image.Image, bleed float64) {
 	bounds := dst.Bounds()
 
 	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
@@ -110,6 +146,14 @@ func alphaOver(dst *image.NRGBA, src image.Image) {
 				continue
 			}
 
+			if paper != nil {
+				p := color.NRGBAModel.Convert(paper.At(x, y)).(color.NRGBA)
+				mix := func(srcVal, paperVal uint8) uint8 {
+					return uint8(math.Round(float64(srcVal)*(1-bleed) + float64(paperVal)*bleed))
+				}
+				s.R, s.G, s.B = mix(s.R, p.R), mix(s.G, p.G), mix(s.B, p.B)
+			}
+
 			d := dst.NRGBAAt(x, y)
 
 			sa := float64(s.A) / 255.0
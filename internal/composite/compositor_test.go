@@ -51,11 +51,11 @@ func expectColor(t *testing.T, got color.NRGBA, want color.NRGBA, context string
 func TestCompositeUsesOrderAndTransparency(t *testing.T) {
 	tileSize := 4
 
-	water := image.NewNRGBA(image.Rect(0, 0, tileSize, tileSize))
-	fillRect(water, water.Bounds(), color.NRGBA{B: 255, A: 255})
-
 	land := image.NewNRGBA(image.Rect(0, 0, tileSize, tileSize))
-	fillRect(land, image.Rect(0, 0, tileSize/2, tileSize/2), color.NRGBA{G: 255, A: 255})
+	fillRect(land, land.Bounds(), color.NRGBA{G: 255, A: 255})
+
+	water := image.NewNRGBA(image.Rect(0, 0, tileSize, tileSize))
+	fillRect(water, image.Rect(0, 0, tileSize/2, tileSize/2), color.NRGBA{B: 255, A: 255})
 
 	roads := image.NewNRGBA(image.Rect(0, 0, tileSize, tileSize))
 	for y := 0; y < tileSize; y++ {
@@ -73,15 +73,15 @@ func TestCompositeUsesOrderAndTransparency(t *testing.T) {
 		t.Fatalf("CompositeLayers returned error: %v", err)
 	}
 
-	expectColor(t, out.NRGBAAt(0, 0), color.NRGBA{G: 255, A: 255}, "land should sit above water")
-	expectColor(t, out.NRGBAAt(3, 3), color.NRGBA{B: 255, A: 255}, "water should show where land is transparent")
+	expectColor(t, out.NRGBAAt(0, 0), color.NRGBA{B: 255, A: 255}, "water should sit above land")
+	expectColor(t, out.NRGBAAt(3, 3), color.NRGBA{G: 255, A: 255}, "land should show where water is transparent")
 
 	expectedRoad := blendNRGBA(
 		color.NRGBA{R: 255, A: 128},
-		color.NRGBA{G: 255, A: 255},
+		color.NRGBA{B: 255, A: 255},
 	)
-	expectColor(t, out.NRGBAAt(1, 1), expectedRoad, "road should alpha-blend on top of land")
-	expectColor(t, out.NRGBAAt(0, 1), color.NRGBA{G: 255, A: 255}, "neighbor pixel remains aligned")
+	expectColor(t, out.NRGBAAt(1, 1), expectedRoad, "road should alpha-blend on top of water")
+	expectColor(t, out.NRGBAAt(0, 1), color.NRGBA{B: 255, A: 255}, "neighbor pixel remains aligned")
 }
 
 func TestCompositeValidatesBounds(t *testing.T) {
@@ -94,3 +94,41 @@ func TestCompositeValidatesBounds(t *testing.T) {
 		t.Fatal("expected error for mismatched bounds")
 	}
 }
+
+func TestCompositeOverPaperBleed(t *testing.T) {
+	tileSize := 2
+	paperColor := color.NRGBA{R: 240, G: 230, B: 210, A: 255}
+
+	paper := image.NewNRGBA(image.Rect(0, 0, tileSize, tileSize))
+	fillRect(paper, paper.Bounds(), paperColor)
+
+	land := image.NewNRGBA(image.Rect(0, 0, tileSize, tileSize))
+	fillRect(land, land.Bounds(), color.NRGBA{R: 40, G: 130, B: 10, A: 255})
+	parks := image.NewNRGBA(image.Rect(0, 0, tileSize, tileSize))
+	parks.SetNRGBA(0, 0, color.NRGBA{R: 0, G: 200, B: 0, A: 255})
+
+	layers := map[geojson.LayerType]image.Image{geojson.LayerLand: land, geojson.LayerParks: parks}
+	order := []geojson.LayerType{geojson.LayerLand, geojson.LayerParks}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, tileSize, tileSize))
+	copy(dst.Pix, paper.Pix)
+	bleed := map[geojson.LayerType]float64{geojson.LayerParks: 0.25}
+	if err := CompositeLayersOverPaperInto(dst, layers, order, paper, bleed); err != nil {
+		t.Fatalf("CompositeLayersOverPaperInto returned error: %v", err)
+	}
+
+	// Parks fade toward the paper color, not toward the land underneath
+	expectColor(t, dst.NRGBAAt(0, 0), color.NRGBA{R: 60, G: 208, B: 53, A: 255}, "parks mixed 25% toward paper")
+	expectColor(t, dst.NRGBAAt(1, 1), color.NRGBA{R: 40, G: 130, B: 10, A: 255}, "land without bleed stays unchanged")
+
+	// Without paper the bleed factors are ignored
+	plain := image.NewNRGBA(image.Rect(0, 0, tileSize, tileSize))
+	if err := CompositeLayersOverPaperInto(plain, layers, order, nil, bleed); err != nil {
+		t.Fatalf("CompositeLayersOverPaperInto returned error: %v", err)
+	}
+	expectColor(t, plain.NRGBAAt(0, 0), color.NRGBA{G: 200, A: 255}, "no bleed without paper")
+
+	if err := CompositeLayersOverPaperInto(dst, layers, order, image.NewNRGBA(image.Rect(0, 0, 3, 3)), bleed); err == nil {
+		t.Fatal("expected error for mismatched paper bounds")
+	}
+}
@@ -0,0 +1,167 @@
+package composite
+
+import (
+	"image"
+	"math"
+	"math/rand/v2"
+	"sync"
+)
+
+// blueNoiseSize is the period of the tiling blue-noise texture used by Dither.
+const blueNoiseSize = 64
+
+var (
+	blueNoiseOnce sync.Once
+	blueNoise     []float32 // Zero-mean thresholds in [-0.5, 0.5), blueNoiseSize² row-major
+)
+
+// Dither adds blue noise of the given strength to the RGB channels of img in place, breaking
+// up the 8-bit banding of smooth gradients without a visible pattern. strength is the
+// peak-to-peak noise amplitude in 8-bit levels (2 = ±1 level is usually enough). The noise has
+// zero mean, so the average color is preserved. Alpha and fully transparent pixels are left
+// unchanged; a strength of 0 leaves img unchanged.
+func Dither(img *image.NRGBA, strength float64) {
+	DitherAt(img, strength, 0, 0)
+}
+
+// DitherAt is Dither with the noise texture anchored so that img's top-left pixel sits at
+// global pixel (offsetX, offsetY). Neighboring tiles dithered at their own offsets continue
+// the same pattern without seams.
+func DitherAt(img *image.NRGBA, strength float64, offsetX, offsetY int) {
+	if img == nil || strength <= 0 {
+		return
+	}
+	noise := blueNoiseThresholds()
+
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		row := img.Pix[img.PixOffset(b.Min.X, y):img.PixOffset(b.Max.X, y)]
+		noiseRow := noise[wrap(y-b.Min.Y+offsetY, blueNoiseSize)*blueNoiseSize:]
+		nx := wrap(offsetX, blueNoiseSize)
+		for i := 0; i < len(row); i += 4 {
+			if row[i+3] != 0 {
+				// The same offset for all channels: luminance grain instead of color speckle
+				n := float64(noiseRow[nx]) * strength
+				row[i] = clampChannel(float64(row[i]) + n)
+				row[i+1] = clampChannel(float64(row[i+1]) + n)
+				row[i+2] = clampChannel(float64(row[i+2]) + n)
+			}
+			if nx++; nx == blueNoiseSize {
+				nx = 0
+			}
+		}
+	}
+}
+
+// wrap returns v modulo n in [0, n).
+func wrap(v, n int) int {
+	return ((v % n) + n) % n
+}
+
+// blueNoiseThresholds returns the blue-noise texture, generating it on first use.
+func blueNoiseThresholds() []float32 {
+	blueNoiseOnce.Do(func() {
+		ranks := voidAndCluster(blueNoiseSize, 1.5)
+		n := float64(len(ranks))
+		blueNoise = make([]float32, len(ranks))
+		for i, r := range ranks {
+			blueNoise[i] = float32((float64(r)+0.5)/n - 0.5)
+		}
+	})
+	return blueNoise
+}
+
+// voidAndCluster generates a size×size tiling blue-noise dither array with Ulichney's
+// void-and-cluster method and returns the rank (0 to size²-1) of every pixel. sigma is the
+// width of the Gaussian energy filter; 1.5 gives the classic blue-noise spectrum. The
+// initial pattern is seeded with a fixed seed, so the result is deterministic.
+func voidAndCluster(size int, sigma float64) []int {
+	n := size * size
+
+	// Toroidal Gaussian kernel indexed by the wrapped (dy, dx) offset between two pixels
+	kernel := make([]float64, n)
+	for dy := 0; dy < size; dy++ {
+		for dx := 0; dx < size; dx++ {
+			ty, tx := min(dy, size-dy), min(dx, size-dx)
+			kernel[dy*size+dx] = math.Exp(-float64(tx*tx+ty*ty) / (2 * sigma * sigma))
+		}
+	}
+
+	pattern := make([]bool, n)
+	energy := make([]float64, n)
+	toggle := func(p int, on bool) {
+		pattern[p] = on
+		sign := 1.0
+		if !on {
+			sign = -1
+		}
+		py, px := p/size, p%size
+		for qy := 0; qy < size; qy++ {
+			krow := kernel[wrap(qy-py, size)*size:]
+			erow := energy[qy*size : (qy+1)*size]
+			for qx := range erow {
+				dx := qx - px
+				if dx < 0 {
+					dx += size
+				}
+				erow[qx] += sign * krow[dx]
+			}
+		}
+	}
+	// tightestCluster returns the set pixel with the highest energy, largestVoid the empty
+	// pixel with the lowest
+	tightestCluster := func() int {
+		best := -1
+		for p, on := range pattern {
+			if on && (best < 0 || energy[p] > energy[best]) {
+				best = p
+			}
+		}
+		return best
+	}
+	largestVoid := func() int {
+		best := -1
+		for p, on := range pattern {
+			if !on && (best < 0 || energy[p] < energy[best]) {
+				best = p
+			}
+		}
+		return best
+	}
+
+	// Initial binary pattern: ~10% random pixels, relaxed until the tightest cluster is also
+	// the largest void
+	rng := rand.New(rand.NewPCG(0x6a09e667, 0xbb67ae85))
+	ones := n / 10
+	for _, p := range rng.Perm(n)[:ones] {
+		toggle(p, true)
+	}
+	for {
+		cluster := tightestCluster()
+		toggle(cluster, false)
+		void := largestVoid()
+		toggle(void, true)
+		if void == cluster {
+			break
+		}
+	}
+	prototype := append([]bool(nil), pattern...)
+	prototypeEnergy := append([]float64(nil), energy...)
+
+	ranks := make([]int, n)
+	// Phase 1: rank the initial pattern by removing its tightest clusters
+	for rank := ones - 1; rank >= 0; rank-- {
+		p := tightestCluster()
+		toggle(p, false)
+		ranks[p] = rank
+	}
+	// Phase 2: fill the largest voids of the initial pattern until every pixel is set
+	copy(pattern, prototype)
+	copy(energy, prototypeEnergy)
+	for rank := ones; rank < n; rank++ {
+		p := largestVoid()
+		toggle(p, true)
+		ranks[p] = rank
+	}
+	return ranks
+}
@@ -0,0 +1,109 @@
+package composite
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"slices"
+	"testing"
+)
+
+// gradient returns a horizontal gradient that rises by only a few 8-bit levels across its
+// width, so it shows wide bands.
+func gradient(w, h int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			v := uint8(100 + x*8/w)
+			img.SetNRGBA(x, y, color.NRGBA{R: v, G: v + 40, B: v + 80, A: 255})
+		}
+	}
+	return img
+}
+
+func TestDitherBreaksBanding(t *testing.T) {
+	const w, h = 256, 128
+	orig := gradient(w, h)
+	img := gradient(w, h)
+	Dither(img, 2)
+
+	// Within each column the gradient is constant: any variance there is the dither
+	var variance, sum, origSum float64
+	for x := 0; x < w; x++ {
+		var colSum, colSq float64
+		for y := 0; y < h; y++ {
+			got, want := img.NRGBAAt(x, y), orig.NRGBAAt(x, y)
+			if d := int(got.R) - int(want.R); d < -1 || d > 1 {
+				t.Fatalf("pixel (%d,%d) moved by %d levels, want at most 1", x, y, d)
+			}
+			if got.A != 255 || int(got.G)-int(got.R) != 40 {
+				t.Fatalf("pixel (%d,%d) = %+v: expected the same offset on all channels and alpha kept", x, y, got)
+			}
+			v := float64(got.R)
+			colSum += v
+			colSq += v * v
+			sum += v
+			origSum += float64(want.R)
+		}
+		mean := colSum / h
+		variance += colSq/h - mean*mean
+	}
+	variance /= w
+
+	if variance < 0.1 {
+		t.Errorf("mean per-column variance = %.3f, want the dither to add noise", variance)
+	}
+	if mean, origMean := sum/(w*h), origSum/(w*h); mean-origMean > 0.05 || origMean-mean > 0.05 {
+		t.Errorf("mean = %.3f, want ~%.3f", mean, origMean)
+	}
+}
+
+func TestDitherOffIsIdentity(t *testing.T) {
+	img := gradient(64, 64)
+	Dither(img, 0)
+	if !bytes.Equal(img.Pix, gradient(64, 64).Pix) {
+		t.Error("strength 0 changed the image")
+	}
+}
+
+func TestDitherAtIsSeamless(t *testing.T) {
+	whole := gradient(200, 100)
+	DitherAt(whole, 2, -12, 30)
+
+	// Dither the two halves separately at their own global offsets
+	halves := gradient(200, 100)
+	left := halves.SubImage(image.Rect(0, 0, 90, 100)).(*image.NRGBA)
+	right := halves.SubImage(image.Rect(90, 0, 200, 100)).(*image.NRGBA)
+	DitherAt(left, 2, -12, 30)
+	DitherAt(right, 2, -12+90, 30)
+
+	if !bytes.Equal(whole.Pix, halves.Pix) {
+		t.Error("dithering the halves at their offsets differs from dithering the whole image")
+	}
+}
+
+func TestVoidAndClusterRanks(t *testing.T) {
+	const size = 16
+	ranks := voidAndCluster(size, 1.5)
+	sorted := slices.Sorted(slices.Values(ranks))
+	for i, r := range sorted {
+		if r != i {
+			t.Fatalf("ranks are not a permutation of 0..%d: sorted[%d] = %d", size*size-1, i, r)
+		}
+	}
+
+	// Blue noise spreads the darkest pixels evenly: no two of the first 16 are neighbors
+	for p, r := range ranks {
+		if r >= size {
+			continue
+		}
+		px, py := p%size, p/size
+		for q, rq := range ranks {
+			qx, qy := q%size, q/size
+			dx, dy := min(wrap(px-qx, size), wrap(qx-px, size)), min(wrap(py-qy, size), wrap(qy-py, size))
+			if q != p && rq < size && dx <= 1 && dy <= 1 {
+				t.Errorf("pixels %d and %d of the first %d ranks are adjacent", p, q, size)
+			}
+		}
+	}
+}
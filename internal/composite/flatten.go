@@ -0,0 +1,30 @@
+package composite
+
+import (
+	"image"
+	"image/color"
+)
+
+// Flatten composites img over the solid color bg in place, leaving every pixel opaque, e.g.
+// before encoding to a format without an alpha channel such as JPEG. bg is treated as opaque;
+// its alpha is ignored.
+func Flatten(img *image.NRGBA, bg color.RGBA) {
+	if img == nil {
+		return
+	}
+
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		row := img.Pix[img.PixOffset(b.Min.X, y):img.PixOffset(b.Max.X, y)]
+		for i := 0; i < len(row); i += 4 {
+			a := float64(row[i+3]) / 255
+			if a == 1 {
+				continue
+			}
+			row[i] = clampChannel(float64(row[i])*a + float64(bg.R)*(1-a))
+			row[i+1] = clampChannel(float64(row[i+1])*a + float64(bg.G)*(1-a))
+			row[i+2] = clampChannel(float64(row[i+2])*a + float64(bg.B)*(1-a))
+			row[i+3] = 255
+		}
+	}
+}
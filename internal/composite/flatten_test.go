@@ -0,0 +1,20 @@
+package composite
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestFlatten(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 3, 1))
+	img.SetNRGBA(0, 0, color.NRGBA{R: 0, G: 100, B: 200, A: 128}) // Half transparent
+	img.SetNRGBA(1, 0, color.NRGBA{R: 10, G: 20, B: 30, A: 255})  // Opaque
+	img.SetNRGBA(2, 0, color.NRGBA{R: 10, G: 20, B: 30, A: 0})    // Fully transparent
+
+	Flatten(img, color.RGBA{R: 255, G: 255, B: 255, A: 255})
+
+	expectColor(t, img.NRGBAAt(0, 0), color.NRGBA{R: 127, G: 177, B: 227, A: 255}, "half transparent over white")
+	expectColor(t, img.NRGBAAt(1, 0), color.NRGBA{R: 10, G: 20, B: 30, A: 255}, "opaque pixel")
+	expectColor(t, img.NRGBAAt(2, 0), color.NRGBA{R: 255, G: 255, B: 255, A: 255}, "transparent pixel")
+}
@@ -0,0 +1,57 @@
+package composite
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// DefaultLandTintColor is the sage green glaze LandTint nudges land toward around vegetation.
+var DefaultLandTintColor = color.NRGBA{R: 170, G: 200, B: 140, A: 255}
+
+// LandTint varies the land wash with the surrounding landcover: land near parks and forests
+// takes on a green glaze instead of the same tan everywhere. The zero value is off.
+type LandTint struct {
+	Strength float64     // Glaze strength at full vegetation density, 0 (off) to 1
+	Color    color.NRGBA // Glaze multiplied onto the land (zero = DefaultLandTintColor)
+}
+
+// ApplyLandTint glazes the RGB channels of the painted land layer img in place: each channel
+// is multiplied by the tint color's, mixed in by Strength·density/255. density is the
+// (typically heavily blurred) vegetation coverage of img, with the same bounds; 0 leaves a
+// pixel unchanged. Alpha is preserved.
+func ApplyLandTint(img *image.NRGBA, density *image.Gray, t LandTint) {
+	if img == nil || density == nil || t.Strength <= 0 {
+		return
+	}
+	strength := math.Min(t.Strength, 1)
+	tint := t.Color
+	if tint.A == 0 {
+		tint = DefaultLandTintColor
+	}
+
+	// Per density level and channel, the multiplier in 1/65536 units
+	var scale [256][3]int
+	for d := range scale {
+		mix := strength * float64(d) / 255
+		for c, v := range [3]uint8{tint.R, tint.G, tint.B} {
+			scale[d][c] = int(math.Round((1 - mix + mix*float64(v)/255) * 65536))
+		}
+	}
+
+	b := img.Bounds().Intersect(density.Bounds())
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		row := img.Pix[img.PixOffset(b.Min.X, y):img.PixOffset(b.Max.X, y)]
+		drow := density.Pix[density.PixOffset(b.Min.X, y):density.PixOffset(b.Max.X, y)]
+		for x, d := range drow {
+			if d == 0 {
+				continue
+			}
+			s := &scale[d]
+			i := x * 4
+			row[i] = uint8((int(row[i])*s[0] + 32768) >> 16)
+			row[i+1] = uint8((int(row[i+1])*s[1] + 32768) >> 16)
+			row[i+2] = uint8((int(row[i+2])*s[2] + 32768) >> 16)
+		}
+	}
+}
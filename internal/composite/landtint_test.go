@@ -0,0 +1,43 @@
+package composite
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestApplyLandTint(t *testing.T) {
+	base := color.NRGBA{R: 200, G: 200, B: 200, A: 180}
+	tint := color.NRGBA{R: 102, G: 204, B: 51, A: 255}
+
+	tests := []struct {
+		name    string
+		tint    LandTint
+		density uint8
+		want    color.NRGBA
+	}{
+		{name: "off", tint: LandTint{Color: tint}, density: 255, want: base},
+		{name: "no vegetation", tint: LandTint{Strength: 1, Color: tint}, density: 0, want: base},
+		{name: "full", tint: LandTint{Strength: 1, Color: tint}, density: 255, want: color.NRGBA{R: 80, G: 160, B: 40, A: 180}},
+		{name: "half", tint: LandTint{Strength: 0.5, Color: tint}, density: 255, want: color.NRGBA{R: 140, G: 180, B: 120, A: 180}},
+		{name: "default color", tint: LandTint{Strength: 1}, density: 255, want: color.NRGBA{R: 133, G: 157, B: 110, A: 180}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img := image.NewNRGBA(image.Rect(0, 0, 2, 2))
+			density := image.NewGray(img.Bounds())
+			for y := 0; y < 2; y++ {
+				for x := 0; x < 2; x++ {
+					img.SetNRGBA(x, y, base)
+					density.SetGray(x, y, color.Gray{Y: tt.density})
+				}
+			}
+
+			ApplyLandTint(img, density, tt.tint)
+			if got := img.NRGBAAt(1, 1); got != tt.want {
+				t.Errorf("pixel = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package composite
+
+import (
+	"cmp"
+	"image"
+	"image/color"
+	"slices"
+)
+
+// MaxPaletteColors is the largest palette Quantize builds; PNG palettes hold at most 256 entries.
+const MaxPaletteColors = 256
+
+// Quantize reduces img to an indexed image of at most maxColors colors (0 or more than
+// MaxPaletteColors means MaxPaletteColors), e.g. to write 8-bit paletted PNGs. Images with
+// no more distinct colors than that are converted losslessly, which is what makes flat-style
+// tiles small; otherwise the palette is chosen by median cut over RGBA and every pixel maps
+// to its nearest entry. Watercolor gradients and paper grain band visibly when quantized.
+func Quantize(img *image.NRGBA, maxColors int) *image.Paletted {
+	if maxColors <= 0 || maxColors > MaxPaletteColors {
+		maxColors = MaxPaletteColors
+	}
+
+	b := img.Bounds()
+	counts := make(map[color.NRGBA]int)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		row := img.Pix[img.PixOffset(b.Min.X, y):img.PixOffset(b.Max.X, y)]
+		for i := 0; i < len(row); i += 4 {
+			counts[color.NRGBA{R: row[i], G: row[i+1], B: row[i+2], A: row[i+3]}]++
+		}
+	}
+
+	// Sorted so the palette doesn't depend on map order
+	colors := make([]weightedColor, 0, len(counts))
+	for c, n := range counts {
+		colors = append(colors, weightedColor{c: c, n: n})
+	}
+	slices.SortFunc(colors, func(a, b weightedColor) int { return cmp.Compare(packNRGBA(a.c), packNRGBA(b.c)) })
+
+	palette := medianCut(colors, maxColors)
+	out := image.NewPaletted(b, palette)
+	index := make(map[color.NRGBA]uint8, len(colors))
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		row := img.Pix[img.PixOffset(b.Min.X, y):img.PixOffset(b.Max.X, y)]
+		dst := out.Pix[out.PixOffset(b.Min.X, y):]
+		for i := 0; i < len(row); i += 4 {
+			c := color.NRGBA{R: row[i], G: row[i+1], B: row[i+2], A: row[i+3]}
+			idx, ok := index[c]
+			if !ok {
+				idx = nearestEntry(palette, c)
+				index[c] = idx
+			}
+			dst[i/4] = idx
+		}
+	}
+	return out
+}
+
+// weightedColor is a distinct image color and the number of pixels with it.
+type weightedColor struct {
+	c color.NRGBA
+	n int
+}
+
+// packNRGBA orders colors for a deterministic palette.
+func packNRGBA(c color.NRGBA) uint32 {
+	return uint32(c.R)<<24 | uint32(c.G)<<16 | uint32(c.B)<<8 | uint32(c.A)
+}
+
+// channel returns channel ch (0-3 = R, G, B, A) of c.
+func channel(c color.NRGBA, ch int) uint8 {
+	switch ch {
+	case 0:
+		return c.R
+	case 1:
+		return c.G
+	case 2:
+		return c.B
+	default:
+		return c.A
+	}
+}
+
+// medianCut splits colors into at most n boxes, each time halving the box with the widest
+// channel range at its pixel-weighted median, and returns the weighted mean of every box.
+func medianCut(colors []weightedColor, n int) color.Palette {
+	if len(colors) <= n {
+		palette := make(color.Palette, len(colors))
+		for i, wc := range colors {
+			palette[i] = wc.c
+		}
+		return palette
+	}
+
+	boxes := [][]weightedColor{colors}
+	for len(boxes) < n {
+		best, bestCh, bestRange := -1, 0, 0
+		for i, box := range boxes {
+			if len(box) < 2 {
+				continue
+			}
+			for ch := 0; ch < 4; ch++ {
+				lo, hi := uint8(255), uint8(0)
+				for _, wc := range box {
+					v := channel(wc.c, ch)
+					lo, hi = min(lo, v), max(hi, v)
+				}
+				if r := int(hi) - int(lo); r > bestRange {
+					best, bestCh, bestRange = i, ch, r
+				}
+			}
+		}
+		if best < 0 {
+			break
+		}
+
+		box := boxes[best]
+		slices.SortStableFunc(box, func(a, b weightedColor) int {
+			return int(channel(a.c, bestCh)) - int(channel(b.c, bestCh))
+		})
+		total := 0
+		for _, wc := range box {
+			total += wc.n
+		}
+		split, acc := 1, 0
+		for i, wc := range box[:len(box)-1] {
+			acc += wc.n
+			split = i + 1
+			if 2*acc >= total {
+				break
+			}
+		}
+		boxes[best] = box[:split]
+		boxes = append(boxes, box[split:])
+	}
+
+	palette := make(color.Palette, len(boxes))
+	for i, box := range boxes {
+		var r, g, b, a, total int
+		for _, wc := range box {
+			r += int(wc.c.R) * wc.n
+			g += int(wc.c.G) * wc.n
+			b += int(wc.c.B) * wc.n
+			a += int(wc.c.A) * wc.n
+			total += wc.n
+		}
+		palette[i] = color.NRGBA{
+			R: uint8((r + total/2) / total),
+			G: uint8((g + total/2) / total),
+			B: uint8((b + total/2) / total),
+			A: uint8((a + total/2) / total),
+		}
+	}
+	return palette
+}
+
+// nearestEntry returns the index of the palette entry closest to c in RGBA.
+func nearestEntry(palette color.Palette, c color.NRGBA) uint8 {
+	best, bestDist := 0, -1
+	for i, p := range palette {
+		pc := p.(color.NRGBA)
+		dist := 0
+		for ch := 0; ch < 4; ch++ {
+			d := int(channel(pc, ch)) - int(channel(c, ch))
+			dist += d * d
+		}
+		if bestDist < 0 || dist < bestDist {
+			best, bestDist = i, dist
+		}
+	}
+	return uint8(best)
+}
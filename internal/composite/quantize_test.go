@@ -0,0 +1,58 @@
+package composite
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestQuantize(t *testing.T) {
+	t.Run("few colors are exact", func(t *testing.T) {
+		img := image.NewNRGBA(image.Rect(0, 0, 8, 8))
+		colors := []color.NRGBA{
+			{R: 240, G: 235, B: 220, A: 255},
+			{R: 90, G: 140, B: 200, A: 255},
+			{R: 120, G: 170, B: 90, A: 128},
+			{},
+		}
+		for y := 0; y < 8; y++ {
+			for x := 0; x < 8; x++ {
+				img.SetNRGBA(x, y, colors[(x+y)%len(colors)])
+			}
+		}
+
+		got := Quantize(img, 16)
+		if len(got.Palette) != len(colors) {
+			t.Errorf("palette has %d colors, want %d", len(got.Palette), len(colors))
+		}
+		for y := 0; y < 8; y++ {
+			for x := 0; x < 8; x++ {
+				expectColor(t, got.At(x, y).(color.NRGBA), img.NRGBAAt(x, y), "quantized pixel")
+			}
+		}
+	})
+
+	t.Run("gradient within tolerance", func(t *testing.T) {
+		img := image.NewNRGBA(image.Rect(0, 0, 64, 4))
+		for y := 0; y < 4; y++ {
+			for x := 0; x < 64; x++ {
+				img.SetNRGBA(x, y, color.NRGBA{R: uint8(x * 4), G: 100, B: 255 - uint8(x*4), A: 255})
+			}
+		}
+
+		got := Quantize(img, 16)
+		if len(got.Palette) != 16 {
+			t.Errorf("palette has %d colors, want 16", len(got.Palette))
+		}
+		for x := 0; x < 64; x++ {
+			want := img.NRGBAAt(x, 0)
+			c := got.At(x, 0).(color.NRGBA)
+			if d := int(c.R) - int(want.R); d < -8 || d > 8 {
+				t.Errorf("pixel %d red = %d, want within 8 of %d", x, c.R, want.R)
+			}
+			if c.G != want.G || c.A != want.A {
+				t.Errorf("pixel %d = %v, want green and alpha of %v", x, c, want)
+			}
+		}
+	})
+}
@@ -0,0 +1,84 @@
+package composite
+
+import (
+	"image"
+	"math"
+)
+
+// ToneAdjust is a global color correction applied to a finished tile, e.g. to match a
+// house style. The zero value is the identity: no adjustment.
+type ToneAdjust struct {
+	Brightness float64 // Added to every channel, -1.0 (black) to 1.0 (white); 0 = unchanged
+	Contrast   float64 // Stretches (> 0) or flattens (< 0) values around mid-gray, -1.0 to 1.0; 0 = unchanged
+	Saturation float64 // Scales color away from (> 0) or toward (< 0) gray, -1.0 = grayscale; 0 = unchanged
+	Gamma      float64 // Gamma correction; > 1 lightens midtones, < 1 darkens them; 0 or 1 = unchanged
+}
+
+// IsIdentity reports whether adj leaves images unchanged.
+func (adj ToneAdjust) IsIdentity() bool {
+	return adj.Brightness == 0 && adj.Contrast == 0 && adj.Saturation == 0 &&
+		(adj.Gamma == 0 || adj.Gamma == 1)
+}
+
+// ApplyToneCurve applies adj to the RGB channels of img in place. Channels are adjusted in
+// straight (non-premultiplied) alpha, which is exact for the opaque composite and keeps the
+// color of translucent pixels independent of their coverage; alpha is preserved. Saturation
+// is applied first, then contrast, brightness and gamma, and every step clamps to [0, 255].
+func ApplyToneCurve(img *image.NRGBA, adj ToneAdjust) {
+	if img == nil || adj.IsIdentity() {
+		return
+	}
+
+	// Contrast, brightness and gamma act on each channel independently: use a lookup table
+	lut := toneLUT(adj)
+	saturation := 1 + adj.Saturation
+	if saturation < 0 {
+		saturation = 0
+	}
+
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		row := img.Pix[img.PixOffset(b.Min.X, y):img.PixOffset(b.Max.X, y)]
+		for i := 0; i < len(row); i += 4 {
+			r, g, bl := row[i], row[i+1], row[i+2]
+			if saturation != 1 {
+				luma := 0.299*float64(r) + 0.587*float64(g) + 0.114*float64(bl)
+				r = clampChannel(luma + (float64(r)-luma)*saturation)
+				g = clampChannel(luma + (float64(g)-luma)*saturation)
+				bl = clampChannel(luma + (float64(bl)-luma)*saturation)
+			}
+			row[i] = lut[r]
+			row[i+1] = lut[g]
+			row[i+2] = lut[bl]
+		}
+	}
+}
+
+// toneLUT builds the per-channel contrast → brightness → gamma curve of adj.
+func toneLUT(adj ToneAdjust) [256]uint8 {
+	contrast := 1 + adj.Contrast
+	if contrast < 0 {
+		contrast = 0
+	}
+	gamma := adj.Gamma
+	if gamma <= 0 {
+		gamma = 1
+	}
+
+	var lut [256]uint8
+	for i := range lut {
+		v := float64(i) / 255
+		v = (v-0.5)*contrast + 0.5
+		v += adj.Brightness
+		v = min(max(v, 0), 1)
+		if gamma != 1 {
+			v = math.Pow(v, 1/gamma)
+		}
+		lut[i] = clampChannel(v * 255)
+	}
+	return lut
+}
+
+func clampChannel(v float64) uint8 {
+	return uint8(min(max(math.Round(v), 0), 255))
+}
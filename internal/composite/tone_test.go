@@ -0,0 +1,84 @@
+package composite
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestApplyToneCurve(t *testing.T) {
+	base := color.NRGBA{R: 200, G: 100, B: 50, A: 255}
+
+	tests := []struct {
+		name  string
+		adj   ToneAdjust
+		check func(t *testing.T, got color.NRGBA)
+	}{
+		{"identity", ToneAdjust{}, func(t *testing.T, got color.NRGBA) {
+			expectColor(t, got, base, "zero adjustment")
+		}},
+		{"gamma one is identity", ToneAdjust{Gamma: 1}, func(t *testing.T, got color.NRGBA) {
+			expectColor(t, got, base, "gamma 1")
+		}},
+		{"brightness", ToneAdjust{Brightness: 0.1}, func(t *testing.T, got color.NRGBA) {
+			expectColor(t, got, color.NRGBA{R: 226, G: 126, B: 75, A: 255}, "brightness +0.1")
+		}},
+		{"brightness clamps", ToneAdjust{Brightness: 1}, func(t *testing.T, got color.NRGBA) {
+			expectColor(t, got, color.NRGBA{R: 255, G: 255, B: 255, A: 255}, "brightness +1")
+		}},
+		{"contrast", ToneAdjust{Contrast: 0.5}, func(t *testing.T, got color.NRGBA) {
+			if got.R <= base.R || got.G >= base.G || got.B >= base.B {
+				t.Errorf("expected values to spread from mid-gray, got %+v", got)
+			}
+		}},
+		{"desaturate", ToneAdjust{Saturation: -1}, func(t *testing.T, got color.NRGBA) {
+			if got.R != got.G || got.G != got.B {
+				t.Errorf("expected gray at saturation -1, got %+v", got)
+			}
+		}},
+		{"gamma lightens midtones", ToneAdjust{Gamma: 2}, func(t *testing.T, got color.NRGBA) {
+			if got.R <= base.R || got.G <= base.G || got.B <= base.B {
+				t.Errorf("expected gamma 2 to lighten, got %+v", got)
+			}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img := image.NewNRGBA(image.Rect(0, 0, 1, 1))
+			img.SetNRGBA(0, 0, base)
+			ApplyToneCurve(img, tt.adj)
+			tt.check(t, img.NRGBAAt(0, 0))
+		})
+	}
+}
+
+func TestApplyToneCurvePreservesAlpha(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	img.SetNRGBA(0, 0, color.NRGBA{R: 100, G: 150, B: 200, A: 128})
+	img.SetNRGBA(1, 0, color.NRGBA{})
+
+	ApplyToneCurve(img, ToneAdjust{Brightness: 0.2, Contrast: 0.3, Saturation: 0.5, Gamma: 1.4})
+
+	if a := img.NRGBAAt(0, 0).A; a != 128 {
+		t.Errorf("alpha = %d, want 128", a)
+	}
+	if got := img.NRGBAAt(1, 0); got.A != 0 {
+		t.Errorf("transparent pixel alpha = %d, want 0", got.A)
+	}
+}
+
+func TestApplyToneCurveSubImage(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+	fillRect(img, img.Bounds(), color.NRGBA{R: 100, G: 100, B: 100, A: 255})
+	sub := img.SubImage(image.Rect(1, 1, 3, 3)).(*image.NRGBA)
+
+	ApplyToneCurve(sub, ToneAdjust{Brightness: 0.2})
+
+	if got := img.NRGBAAt(0, 0); got.R != 100 {
+		t.Errorf("pixel outside the sub-image changed: %+v", got)
+	}
+	if got := img.NRGBAAt(2, 2); got.R == 100 {
+		t.Errorf("pixel inside the sub-image unchanged: %+v", got)
+	}
+}
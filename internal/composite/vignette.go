@@ -0,0 +1,45 @@
+package composite
+
+import (
+	"image"
+	"math"
+)
+
+// Vignette darkens a composited tile where painted features are dense, like pigment pooling
+// in the busier parts of a painting: built-up areas get slightly darker while open land stays
+// bright. The zero value is off.
+type Vignette struct {
+	Strength float64 // Darkening at full feature density, 0 (off) to 1 (black)
+}
+
+// ApplyVignette multiplies the RGB channels of img in place by 1 - Strength·density/255.
+// density is the (typically heavily blurred) feature coverage of img, with the same bounds;
+// 0 leaves a pixel unchanged. Alpha is preserved.
+func ApplyVignette(img *image.NRGBA, density *image.Gray, v Vignette) {
+	if img == nil || density == nil || v.Strength <= 0 {
+		return
+	}
+	strength := math.Min(v.Strength, 1)
+
+	// Per density level, the channel multiplier in 1/65536 units
+	var scale [256]int
+	for d := range scale {
+		scale[d] = int(math.Round((1 - strength*float64(d)/255) * 65536))
+	}
+
+	b := img.Bounds().Intersect(density.Bounds())
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		row := img.Pix[img.PixOffset(b.Min.X, y):img.PixOffset(b.Max.X, y)]
+		drow := density.Pix[density.PixOffset(b.Min.X, y):density.PixOffset(b.Max.X, y)]
+		for x, d := range drow {
+			if d == 0 {
+				continue
+			}
+			s := scale[d]
+			i := x * 4
+			row[i] = uint8((int(row[i])*s + 32768) >> 16)
+			row[i+1] = uint8((int(row[i+1])*s + 32768) >> 16)
+			row[i+2] = uint8((int(row[i+2])*s + 32768) >> 16)
+		}
+	}
+}
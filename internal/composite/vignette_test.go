@@ -0,0 +1,42 @@
+package composite
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestApplyVignette(t *testing.T) {
+	base := color.NRGBA{R: 200, G: 180, B: 100, A: 200}
+
+	tests := []struct {
+		name     string
+		strength float64
+		density  uint8
+		want     color.NRGBA
+	}{
+		{name: "off", strength: 0, density: 255, want: base},
+		{name: "open land", strength: 0.5, density: 0, want: base},
+		{name: "dense", strength: 0.5, density: 255, want: color.NRGBA{R: 100, G: 90, B: 50, A: 200}},
+		{name: "half density", strength: 0.5, density: 102, want: color.NRGBA{R: 160, G: 144, B: 80, A: 200}},
+		{name: "strength clamped", strength: 3, density: 255, want: color.NRGBA{A: 200}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img := image.NewNRGBA(image.Rect(0, 0, 2, 2))
+			density := image.NewGray(img.Bounds())
+			for y := 0; y < 2; y++ {
+				for x := 0; x < 2; x++ {
+					img.SetNRGBA(x, y, base)
+					density.SetGray(x, y, color.Gray{Y: tt.density})
+				}
+			}
+
+			ApplyVignette(img, density, Vignette{Strength: tt.strength})
+			if got := img.NRGBAAt(1, 1); got != tt.want {
+				t.Errorf("pixel = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
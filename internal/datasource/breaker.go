@@ -0,0 +1,133 @@
+package datasource
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned by the fetch queue while its circuit breaker is open, i.e. after
+// repeated transient failures (rate limiting, timeouts) and before the cooldown has passed.
+// Callers should fail fast (e.g. HTTP 503) rather than retry.
+var ErrCircuitOpen = errors.New("overpass circuit breaker is open")
+
+// Circuit breaker states as reported in FetchQueueStatus.
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half-open"
+)
+
+// circuitBreaker stops fetching after threshold consecutive transient failures. While open,
+// requests fail immediately; after cooldown a single probe request is let through (half-open)
+// and its outcome either closes the breaker or opens it for another cooldown.
+//
+// Requests still in flight when the breaker opens belong to an earlier generation: their
+// outcomes are ignored, so they can neither close the breaker nor take the probe's place.
+type circuitBreaker struct {
+	mu         sync.Mutex
+	now        func() time.Time
+	state      string
+	openUntil  time.Time
+	threshold  int
+	cooldown   time.Duration
+	failures   int
+	probing    bool
+	generation uint64 // incremented each time the breaker opens
+}
+
+// breakerTicket identifies an allowed request when its outcome is recorded.
+type breakerTicket struct {
+	generation uint64
+	probe      bool
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{
+		now:       time.Now,
+		state:     BreakerClosed,
+		threshold: threshold,
+		cooldown:  cooldown,
+	}
+}
+
+// allow reports whether a request may proceed and returns the ticket to record its outcome
+// with. In the half-open state only one probe request is allowed until its outcome is
+// recorded.
+func (b *circuitBreaker) allow() (breakerTicket, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if b.now().Before(b.openUntil) {
+			return breakerTicket{}, false
+		}
+		b.state = BreakerHalfOpen
+		b.probing = true
+		return breakerTicket{generation: b.generation, probe: true}, true
+	case BreakerHalfOpen:
+		if b.probing {
+			return breakerTicket{}, false
+		}
+		b.probing = true
+		return breakerTicket{generation: b.generation, probe: true}, true
+	default:
+		return breakerTicket{generation: b.generation}, true
+	}
+}
+
+// rejecting reports whether the breaker is open and still cooling down, without claiming
+// the half-open probe. Used to fail fast before a job is queued.
+func (b *circuitBreaker) rejecting() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state == BreakerOpen && b.now().Before(b.openUntil)
+}
+
+// record updates the breaker with the outcome of the request t was issued for. Outcomes that
+// say nothing about the server's health (e.g. the caller cancelled) are recorded as neutral.
+func (b *circuitBreaker) record(t breakerTicket, failed, neutral bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if t.generation != b.generation {
+		// Started before the breaker last opened; the probe decides what happens next
+		return
+	}
+	if t.probe {
+		b.probing = false
+	}
+
+	switch {
+	case neutral:
+		// A cancelled probe leaves the breaker half-open for the next request
+	case failed:
+		b.failures++
+		if t.probe || b.failures >= b.threshold {
+			b.open()
+		}
+	default:
+		b.failures = 0
+		b.state = BreakerClosed
+	}
+}
+
+// open opens the breaker for a cooldown and starts a new generation. Must be called with the
+// lock held.
+func (b *circuitBreaker) open() {
+	b.state = BreakerOpen
+	b.openUntil = b.now().Add(b.cooldown)
+	b.generation++
+}
+
+// status returns the current state and number of consecutive failures.
+func (b *circuitBreaker) status() (string, int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	state := b.state
+	if state == BreakerOpen && !b.now().Before(b.openUntil) {
+		state = BreakerHalfOpen // the next request will probe
+	}
+	return state, b.failures
+}
@@ -0,0 +1,135 @@
+package datasource
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/MeKo-Christian/go-overpass"
+	"github.com/MeKo-Tech/watercolormap/internal/types"
+)
+
+func TestFetchQueueCircuitBreaker(t *testing.T) {
+	stub := &stubQuerier{err: &overpass.ServerError{StatusCode: 429}}
+	fq := NewFetchQueue(&OverpassDataSource{client: stub}, FetchQueueConfig{
+		Workers:          1,
+		BreakerThreshold: 3,
+		BreakerCooldown:  time.Minute,
+	})
+	now := time.Now()
+	fq.breaker.now = func() time.Time { return now }
+	fq.Start()
+	defer fq.Stop()
+
+	tile := types.TileCoordinate{Zoom: 13, X: 4317, Y: 2692}
+	bounds := types.TileToBounds(tile)
+	ctx := context.Background()
+
+	// Trip the breaker with consecutive rate-limit failures
+	for i := 0; i < 3; i++ {
+		result, err := fq.SubmitAndWait(ctx, tile, bounds)
+		if err != nil || result.Error == nil || errors.Is(result.Error, ErrCircuitOpen) {
+			t.Fatalf("attempt %d: expected an Overpass failure, got err=%v result=%v", i, err, result.Error)
+		}
+	}
+	if status := fq.Status(); status.BreakerState != BreakerOpen || status.ConsecutiveFailures != 3 {
+		t.Fatalf("expected open breaker after 3 failures, got %q with %d failures", status.BreakerState, status.ConsecutiveFailures)
+	}
+
+	// While open, requests fail fast without reaching Overpass
+	calls := stub.calls
+	start := time.Now()
+	if _, err := fq.SubmitAndWait(ctx, tile, bounds); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen while open, got %v", err)
+	}
+	if result := fq.FetchSync(ctx, tile, bounds); !errors.Is(result.Error, ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen from FetchSync while open, got %v", result.Error)
+	}
+	if time.Since(start) > time.Second || stub.calls != calls {
+		t.Fatalf("expected fast failure without queries, took %v and made %d queries", time.Since(start), stub.calls-calls)
+	}
+	if got := fq.Status().TotalRejected; got != 2 {
+		t.Errorf("expected 2 rejected fetches, got %d", got)
+	}
+
+	// After the cooldown a failing probe re-opens the breaker immediately
+	now = now.Add(time.Minute)
+	if state := fq.Status().BreakerState; state != BreakerHalfOpen {
+		t.Fatalf("expected half-open after cooldown, got %q", state)
+	}
+	if result := fq.FetchSync(ctx, tile, bounds); result.Error == nil || errors.Is(result.Error, ErrCircuitOpen) {
+		t.Fatalf("expected the probe to reach Overpass and fail, got %v", result.Error)
+	}
+	if state := fq.Status().BreakerState; state != BreakerOpen {
+		t.Fatalf("expected failed probe to re-open the breaker, got %q", state)
+	}
+
+	// A successful probe closes it again
+	now = now.Add(time.Minute)
+	stub.err = nil
+	stub.result = lakeResult()
+	if result := fq.FetchSync(ctx, tile, bounds); result.Error != nil {
+		t.Fatalf("expected successful probe, got %v", result.Error)
+	}
+	if status := fq.Status(); status.BreakerState != BreakerClosed || status.ConsecutiveFailures != 0 {
+		t.Fatalf("expected closed breaker after success, got %q with %d failures", status.BreakerState, status.ConsecutiveFailures)
+	}
+}
+
+func TestCircuitBreakerAllowsSingleProbe(t *testing.T) {
+	b := newCircuitBreaker(1, time.Second)
+	now := time.Now()
+	b.now = func() time.Time { return now }
+
+	ticket, _ := b.allow()
+	b.record(ticket, true, false)
+	if _, ok := b.allow(); ok {
+		t.Fatal("expected open breaker to reject")
+	}
+
+	now = now.Add(time.Second)
+	probe, ok := b.allow()
+	if !ok {
+		t.Fatal("expected a probe after the cooldown")
+	}
+	if _, ok := b.allow(); ok {
+		t.Fatal("expected only one concurrent probe while half-open")
+	}
+
+	// A cancelled probe frees the slot for the next request
+	b.record(probe, false, true)
+	if _, ok := b.allow(); !ok {
+		t.Fatal("expected a new probe after a neutral outcome")
+	}
+}
+
+func TestCircuitBreakerIgnoresCallsFromBeforeOpening(t *testing.T) {
+	b := newCircuitBreaker(1, time.Second)
+	now := time.Now()
+	b.now = func() time.Time { return now }
+
+	failing, _ := b.allow()
+	slow, _ := b.allow() // still in flight when the breaker opens
+	b.record(failing, true, false)
+
+	now = now.Add(time.Second)
+	probe, ok := b.allow()
+	if !ok {
+		t.Fatal("expected a probe after the cooldown")
+	}
+
+	// The old call finishing must neither close the breaker nor free the probe slot
+	b.record(slow, false, false)
+	if state, _ := b.status(); state != BreakerHalfOpen {
+		t.Fatalf("state after stale success = %s, want %s", state, BreakerHalfOpen)
+	}
+	if _, ok := b.allow(); ok {
+		t.Fatal("expected the stale outcome to leave the probe in place")
+	}
+
+	b.record(probe, true, false)
+	if state, _ := b.status(); state != BreakerOpen {
+		t.Errorf("state after failed probe = %s, want %s", state, BreakerOpen)
+	}
+}
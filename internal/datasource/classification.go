@@ -0,0 +1,204 @@
+package datasource
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/MeKo-Tech/watercolormap/internal/geojson"
+	"github.com/MeKo-Tech/watercolormap/internal/types"
+	"go.yaml.in/yaml/v3"
+)
+
+// ClassificationRule routes OSM features carrying a tag to a map layer.
+type ClassificationRule struct {
+	// Key is the OSM tag key, e.g. "natural".
+	Key string `yaml:"key"`
+	// Value is the required tag value; empty or "*" matches any non-empty value.
+	Value string `yaml:"value,omitempty"`
+	// Layer is the layer matching features are rendered in.
+	Layer geojson.LayerType `yaml:"layer"`
+}
+
+// Classification maps OSM tag patterns to layers. Rules are evaluated in order and the
+// first matching rule wins, so more specific rules must come before broader ones.
+type Classification struct {
+	Rules []ClassificationRule `yaml:"rules"`
+}
+
+// classifiableLayers are the layers that features can be routed to. Land is derived
+// from the other layers, highways are split from roads and forests from parks by the
+// renderer, and paper is only a texture.
+var classifiableLayers = map[geojson.LayerType]types.FeatureType{
+	geojson.LayerWater:     types.FeatureTypeWater,
+	geojson.LayerRivers:    types.FeatureTypeWater,
+	geojson.LayerParks:     types.FeatureTypePark,
+	geojson.LayerRoads:     types.FeatureTypeRoad,
+	geojson.LayerBuildings: types.FeatureTypeBuilding,
+	geojson.LayerUrban:     types.FeatureTypeUrban,
+}
+
+// DefaultClassification returns the built-in feature-to-layer mapping.
+func DefaultClassification() *Classification {
+	rules := []ClassificationRule{
+		// Only polygonal water bodies; linear waterways go to rivers.
+		// NOTE: natural=sea and place=sea are NOT area polygons (they're points or don't exist)
+		// Ocean tiles will not render correctly - see PLAN.md section 4.10
+		{Key: "natural", Value: "water", Layer: geojson.LayerWater},
+		{Key: "natural", Value: "coastline", Layer: geojson.LayerWater},
+
+		// Linear waterways: rivers, streams, canals
+		// These are rendered with LineSymbolizer to avoid polygon closing issues
+		{Key: "waterway", Layer: geojson.LayerRivers},
+	}
+	for _, v := range []string{"park", "garden", "playground", "nature_reserve"} {
+		rules = append(rules, ClassificationRule{Key: "leisure", Value: v, Layer: geojson.LayerParks})
+	}
+	for _, v := range []string{"forest", "grass", "meadow", "farmland", "orchard", "vineyard", "allotments"} {
+		rules = append(rules, ClassificationRule{Key: "landuse", Value: v, Layer: geojson.LayerParks})
+	}
+	for _, v := range []string{"wood", "heath", "grassland"} {
+		rules = append(rules, ClassificationRule{Key: "natural", Value: v, Layer: geojson.LayerParks})
+	}
+	rules = append(rules,
+		ClassificationRule{Key: "highway", Layer: geojson.LayerRoads},
+		ClassificationRule{Key: "building", Layer: geojson.LayerBuildings},
+	)
+	// Urban areas include landuse zones (residential/commercial/industrial)
+	// and urban buildings (schools, hospitals, universities)
+	for _, v := range []string{"residential", "commercial", "industrial", "retail"} {
+		rules = append(rules, ClassificationRule{Key: "landuse", Value: v, Layer: geojson.LayerUrban})
+	}
+	for _, v := range []string{"school", "hospital", "university", "library", "town_hall"} {
+		rules = append(rules, ClassificationRule{Key: "amenity", Value: v, Layer: geojson.LayerUrban})
+	}
+
+	return &Classification{Rules: rules}
+}
+
+// LoadClassification reads a classification table from a YAML (or JSON) file:
+//
+//	rules:
+//	  - {key: natural, value: wetland, layer: water}
+//	  - {key: man_made, value: pier, layer: urban}
+//	  - {key: highway, layer: roads}
+//
+// The file replaces the built-in mapping entirely; start from DefaultClassification's
+// rules to make small adjustments.
+func LoadClassification(path string) (*Classification, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read classification file: %w", err)
+	}
+
+	var c Classification
+	if err := yaml.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("failed to parse classification file %s: %w", path, err)
+	}
+	if err := c.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid classification file %s: %w", path, err)
+	}
+	return &c, nil
+}
+
+// Validate checks that every rule has a key and references a known, classifiable layer.
+func (c *Classification) Validate() error {
+	if len(c.Rules) == 0 {
+		return fmt.Errorf("classification has no rules")
+	}
+	for i, rule := range c.Rules {
+		if strings.TrimSpace(rule.Key) == "" {
+			return fmt.Errorf("rule %d: missing tag key", i+1)
+		}
+		if _, ok := classifiableLayers[rule.Layer]; !ok {
+			switch rule.Layer {
+			case geojson.LayerLand, geojson.LayerHighways, geojson.LayerForest, geojson.LayerPaper:
+				return fmt.Errorf("rule %d (%s): layer %q is derived and cannot be assigned directly", i+1, rule.pattern(), rule.Layer)
+			default:
+				return fmt.Errorf("rule %d (%s): unknown layer %q (expected water, rivers, parks, roads, buildings, or urban)",
+					i+1, rule.pattern(), rule.Layer)
+			}
+		}
+	}
+	return nil
+}
+
+// Classify returns the layer of the first rule matching tags.
+func (c *Classification) Classify(tags map[string]string) (geojson.LayerType, bool) {
+	for _, rule := range c.Rules {
+		if rule.matches(tags) {
+			return rule.Layer, true
+		}
+	}
+	return "", false
+}
+
+func (r ClassificationRule) matches(tags map[string]string) bool {
+	v := tags[r.Key]
+	if r.Value == "" || r.Value == "*" {
+		return v != ""
+	}
+	return v == r.Value
+}
+
+// pattern returns the rule as an Overpass-style tag filter, e.g. `"natural"="water"`.
+func (r ClassificationRule) pattern() string {
+	if r.Value == "" || r.Value == "*" {
+		return fmt.Sprintf("%q", r.Key)
+	}
+	return fmt.Sprintf("%q=%q", r.Key, r.Value)
+}
+
+// extraQueryParts returns Overpass query parts for rules whose tags the built-in query
+// does not fetch, so that custom classifications actually receive their features.
+func (c *Classification) extraQueryParts(bbox string) []string {
+	builtin := make(map[ClassificationRule]bool)
+	for _, rule := range DefaultClassification().Rules {
+		builtin[ClassificationRule{Key: rule.Key, Value: rule.Value}] = true
+	}
+
+	var parts []string
+	seen := make(map[string]bool)
+	for _, rule := range c.Rules {
+		key := ClassificationRule{Key: rule.Key, Value: rule.Value}
+		if rule.Value == "*" {
+			key.Value = ""
+		}
+		if builtin[key] || seen[rule.pattern()] {
+			continue
+		}
+		seen[rule.pattern()] = true
+		parts = append(parts,
+			fmt.Sprintf("way[%s](%s);", rule.pattern(), bbox),
+			fmt.Sprintf("relation[%s](%s);", rule.pattern(), bbox),
+		)
+	}
+	return parts
+}
+
+// featureTypeForLayer returns the feature category for a classified layer.
+func featureTypeForLayer(layer geojson.LayerType) types.FeatureType {
+	if ft, ok := classifiableLayers[layer]; ok {
+		return ft
+	}
+	return types.FeatureTypeUnknown
+}
+
+// addToLayer appends feature to the collection slice for layer.
+func addToLayer(features *types.FeatureCollection, layer geojson.LayerType, feature types.Feature) {
+	feature.Type = featureTypeForLayer(layer)
+	switch layer {
+	case geojson.LayerWater:
+		features.Water = append(features.Water, feature)
+	case geojson.LayerRivers:
+		features.Rivers = append(features.Rivers, feature)
+	case geojson.LayerParks:
+		features.Parks = append(features.Parks, feature)
+	case geojson.LayerRoads:
+		features.Roads = append(features.Roads, feature)
+	case geojson.LayerBuildings:
+		features.Buildings = append(features.Buildings, feature)
+	case geojson.LayerUrban:
+		features.Urban = append(features.Urban, feature)
+	}
+}
@@ -0,0 +1,116 @@
+package datasource
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/MeKo-Christian/go-overpass"
+	"github.com/MeKo-Tech/watercolormap/internal/geojson"
+	"github.com/MeKo-Tech/watercolormap/internal/types"
+)
+
+func TestDefaultClassificationMatchesAssetFile(t *testing.T) {
+	c, err := LoadClassification(filepath.Join("..", "..", "assets", "classification.yaml"))
+	if err != nil {
+		t.Fatalf("failed to load shipped classification: %v", err)
+	}
+	if !reflect.DeepEqual(c.Rules, DefaultClassification().Rules) {
+		t.Fatalf("assets/classification.yaml is out of sync with DefaultClassification")
+	}
+}
+
+func TestClassify(t *testing.T) {
+	c := DefaultClassification()
+	tests := []struct {
+		name  string
+		tags  map[string]string
+		want  geojson.LayerType
+		found bool
+	}{
+		{"lake", map[string]string{"natural": "water"}, geojson.LayerWater, true},
+		{"river", map[string]string{"waterway": "river"}, geojson.LayerRivers, true},
+		{"forest", map[string]string{"landuse": "forest"}, geojson.LayerParks, true},
+		{"road", map[string]string{"highway": "residential"}, geojson.LayerRoads, true},
+		{"building wins over amenity", map[string]string{"building": "yes", "amenity": "school"}, geojson.LayerBuildings, true},
+		{"school grounds", map[string]string{"amenity": "school"}, geojson.LayerUrban, true},
+		{"wetland unmapped", map[string]string{"natural": "wetland"}, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := c.Classify(tt.tags)
+			if got != tt.want || ok != tt.found {
+				t.Fatalf("Classify(%v) = %q, %v; want %q, %v", tt.tags, got, ok, tt.want, tt.found)
+			}
+		})
+	}
+}
+
+func TestLoadClassificationValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"unknown layer", "rules:\n  - {key: natural, value: wetland, layer: swamp}\n", `unknown layer "swamp"`},
+		{"derived layer", "rules:\n  - {key: highway, value: motorway, layer: highways}\n", `layer "highways" is derived`},
+		{"missing key", "rules:\n  - {value: wetland, layer: water}\n", "missing tag key"},
+		{"no rules", "rules: []\n", "no rules"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "classification.yaml")
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := LoadClassification(path)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestExtractFeaturesWithCustomClassification(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "classification.yaml")
+	content := "rules:\n" +
+		"  - {key: natural, value: wetland, layer: water}\n" +
+		"  - {key: man_made, value: pier, layer: urban}\n" +
+		"  - {key: highway, layer: roads}\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c, err := LoadClassification(path)
+	if err != nil {
+		t.Fatalf("LoadClassification failed: %v", err)
+	}
+
+	square := []overpass.Point{{Lat: 0, Lon: 0}, {Lat: 0, Lon: 1}, {Lat: 1, Lon: 1}, {Lat: 0, Lon: 0}}
+	newWay := func(id int64, tags map[string]string) *overpass.Way {
+		w := &overpass.Way{Geometry: square}
+		w.ID = id
+		w.Tags = tags
+		return w
+	}
+	result := &overpass.Result{Ways: map[int64]*overpass.Way{
+		1: newWay(1, map[string]string{"natural": "wetland"}),
+		2: newWay(2, map[string]string{"man_made": "pier"}),
+		3: newWay(3, map[string]string{"leisure": "park"}), // not in the custom table
+	}}
+
+	features := ExtractFeaturesWithClassification(result, c)
+	if len(features.Water) != 1 || len(features.Urban) != 1 || len(features.Parks) != 0 {
+		t.Fatalf("unexpected routing: water=%d urban=%d parks=%d", len(features.Water), len(features.Urban), len(features.Parks))
+	}
+
+	ds := NewOverpassDataSource("").WithClassification(c)
+	query := ds.buildTileQuery(types.BoundingBox{MinLat: 52, MinLon: 9, MaxLat: 52.1, MaxLon: 9.1}, 13)
+	if !strings.Contains(query, `way["natural"="wetland"]`) || !strings.Contains(query, `way["man_made"="pier"]`) {
+		t.Errorf("expected custom tags in query:\n%s", query)
+	}
+	if strings.Count(query, `way["highway"](`) != 0 {
+		t.Errorf("built-in tags should not be duplicated in query:\n%s", query)
+	}
+}
@@ -0,0 +1,113 @@
+package datasource
+
+import (
+	"context"
+	"sync"
+
+	"github.com/MeKo-Tech/watercolormap/internal/types"
+)
+
+// BoundedDataSource fetches tile data for an explicit bounding box.
+type BoundedDataSource interface {
+	FetchTileDataWithBounds(context.Context, types.TileCoordinate, types.BoundingBox) (*types.TileData, error)
+}
+
+// ColumnDataSource serves a tile pyramid from one fetch per column: the first request inside
+// a tile at ColumnZoom fetches that tile's whole area at DetailZoom, and every tile of the
+// column, at any zoom, is cut from that data with FilterFeaturesForZoom. For deep pyramids of
+// small areas this replaces one Overpass query per tile with one per column.
+//
+// Fetched columns are kept for the lifetime of the data source, so it is meant for batch runs
+// over small areas. Requests above ColumnZoom, or reaching outside their column's bounds, are
+// passed through to the wrapped data source. It is safe for concurrent use.
+type ColumnDataSource struct {
+	ds         BoundedDataSource
+	columnZoom int
+	detailZoom int
+
+	// ColumnBounds returns the area fetched for a column tile (default: the tile bounds).
+	// Set it to the generator's padded fetch bounds so that the padded requests of all tiles
+	// in the column fall inside.
+	ColumnBounds func(types.TileCoordinate) types.BoundingBox
+
+	mu      sync.Mutex
+	columns map[types.TileCoordinate]*columnData
+}
+
+// columnData is the fetched data of one column; mu serialises the fetch so concurrent
+// requests for the same column wait for it instead of querying again.
+type columnData struct {
+	mu     sync.Mutex
+	data   *types.TileData
+	bounds types.BoundingBox
+}
+
+// NewColumnDataSource wraps ds so that tiles between columnZoom and detailZoom are served
+// from one fetch per column tile at columnZoom, made with detailZoom's feature selection.
+func NewColumnDataSource(ds BoundedDataSource, columnZoom, detailZoom int) *ColumnDataSource {
+	return &ColumnDataSource{
+		ds:         ds,
+		columnZoom: columnZoom,
+		detailZoom: max(detailZoom, columnZoom),
+		columns:    make(map[types.TileCoordinate]*columnData),
+	}
+}
+
+// FetchTileData fetches the features of a tile.
+func (c *ColumnDataSource) FetchTileData(ctx context.Context, tile types.TileCoordinate) (*types.TileData, error) {
+	return c.FetchTileDataWithBounds(ctx, tile, types.TileToBounds(tile))
+}
+
+// FetchTileDataWithBounds returns the features of tile's column that intersect bounds and
+// that a fetch at tile's zoom would have returned.
+func (c *ColumnDataSource) FetchTileDataWithBounds(ctx context.Context, tile types.TileCoordinate, bounds types.BoundingBox) (*types.TileData, error) {
+	if tile.Zoom < c.columnZoom || tile.Zoom > c.detailZoom {
+		return c.ds.FetchTileDataWithBounds(ctx, tile, bounds)
+	}
+
+	shift := tile.Zoom - c.columnZoom
+	column := types.TileCoordinate{Zoom: c.columnZoom, X: tile.X >> shift, Y: tile.Y >> shift}
+	col := c.column(column)
+
+	col.mu.Lock()
+	if col.data == nil {
+		col.bounds = types.TileToBounds(column)
+		if c.ColumnBounds != nil {
+			col.bounds = c.ColumnBounds(column)
+		}
+		detail := types.TileCoordinate{Zoom: c.detailZoom, X: column.X << (c.detailZoom - c.columnZoom), Y: column.Y << (c.detailZoom - c.columnZoom)}
+		data, err := c.ds.FetchTileDataWithBounds(ctx, detail, col.bounds)
+		if err != nil {
+			// Not cached: the next tile of the column retries the fetch
+			col.mu.Unlock()
+			return nil, err
+		}
+		col.data = data
+	}
+	data, colBounds := col.data, col.bounds
+	col.mu.Unlock()
+
+	if !colBounds.ContainsBox(bounds) {
+		return c.ds.FetchTileDataWithBounds(ctx, tile, bounds)
+	}
+
+	return &types.TileData{
+		Coordinate: tile,
+		Bounds:     bounds,
+		Features:   clipFeaturesToBounds(FilterFeaturesForZoom(data.Features, tile.Zoom), bounds),
+		FetchedAt:  data.FetchedAt,
+		Source:     data.Source,
+	}, nil
+}
+
+// column returns the entry of a column tile, creating it on first use.
+func (c *ColumnDataSource) column(tile types.TileCoordinate) *columnData {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	col, ok := c.columns[tile]
+	if !ok {
+		col = &columnData{}
+		c.columns[tile] = col
+	}
+	return col
+}
@@ -0,0 +1,141 @@
+package datasource
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/MeKo-Tech/watercolormap/internal/types"
+	"github.com/paulmach/orb"
+)
+
+// countingSource returns fixed features and records the fetches it served.
+type countingSource struct {
+	mu       sync.Mutex
+	features types.FeatureCollection
+	err      error
+	fetches  []types.TileCoordinate
+}
+
+func (s *countingSource) FetchTileDataWithBounds(ctx context.Context, tile types.TileCoordinate, bounds types.BoundingBox) (*types.TileData, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fetches = append(s.fetches, tile)
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &types.TileData{Coordinate: tile, Bounds: bounds, Features: s.features, Source: "stub"}, nil
+}
+
+func TestColumnDataSourceFetchesOncePerColumn(t *testing.T) {
+	column := types.TileCoordinate{Zoom: 12, X: 2158, Y: 1346}
+	colBounds := types.TileToBounds(column)
+	center := orb.Point{(colBounds.MinLon + colBounds.MaxLon) / 2, (colBounds.MinLat + colBounds.MaxLat) / 2}
+
+	road := tagged("way/1", map[string]interface{}{"highway": "residential"})
+	road.Geometry = orb.LineString{center, {center[0] + 1e-4, center[1]}}
+	building := tagged("way/2", map[string]interface{}{"building": "yes"})
+	building.Geometry = center
+	src := &countingSource{features: types.FeatureCollection{Roads: []types.Feature{road}, Buildings: []types.Feature{building}}}
+
+	ds := NewColumnDataSource(src, 12, 16)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	tiles := []types.TileCoordinate{column}
+	for z := 13; z <= 16; z++ {
+		shift := z - 12
+		tiles = append(tiles, types.TileCoordinate{Zoom: z, X: column.X<<shift + 1<<shift/2, Y: column.Y<<shift + 1<<shift/2})
+	}
+	results := make([]*types.TileData, len(tiles))
+	for i, tile := range tiles {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			data, err := ds.FetchTileData(ctx, tile)
+			if err != nil {
+				t.Errorf("fetch %v: %v", tile, err)
+				return
+			}
+			results[i] = data
+		}()
+	}
+	wg.Wait()
+
+	if len(src.fetches) != 1 || src.fetches[0].Zoom != 16 {
+		t.Fatalf("expected a single z16 fetch, got %v", src.fetches)
+	}
+	for i, data := range results {
+		if data == nil {
+			continue
+		}
+		if data.Coordinate != tiles[i] {
+			t.Errorf("tile %d: coordinate %v, want %v", i, data.Coordinate, tiles[i])
+		}
+		wantBuildings := 0
+		if tiles[i].Zoom >= 16 {
+			wantBuildings = 1
+		}
+		wantRoads := 0
+		if tiles[i].Zoom >= 14 {
+			wantRoads = 1
+		}
+		if len(data.Features.Buildings) != wantBuildings || len(data.Features.Roads) != wantRoads {
+			t.Errorf("z%d: %d buildings, %d roads; want %d, %d", tiles[i].Zoom, len(data.Features.Buildings), len(data.Features.Roads), wantBuildings, wantRoads)
+		}
+	}
+
+	// A z16 tile in the same column but away from the features gets none
+	corner := types.TileCoordinate{Zoom: 16, X: column.X << 4, Y: column.Y << 4}
+	data, err := ds.FetchTileData(ctx, corner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.Features.Count() != 0 || len(src.fetches) != 1 {
+		t.Errorf("expected no features and no new fetch, got %d features, %d fetches", data.Features.Count(), len(src.fetches))
+	}
+}
+
+func TestColumnDataSourcePassThrough(t *testing.T) {
+	src := &countingSource{}
+	ds := NewColumnDataSource(src, 12, 14)
+	ctx := context.Background()
+
+	// Above the column zoom
+	if _, err := ds.FetchTileData(ctx, types.TileCoordinate{Zoom: 11, X: 1079, Y: 673}); err != nil {
+		t.Fatal(err)
+	}
+	// Bounds reaching outside the column
+	tile := types.TileCoordinate{Zoom: 13, X: 4316, Y: 2692}
+	if _, err := ds.FetchTileDataWithBounds(ctx, tile, types.TileToBounds(tile).ExpandByFraction(0.5)); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []types.TileCoordinate{{Zoom: 11, X: 1079, Y: 673}, {Zoom: 14, X: 8632, Y: 5384}, tile}
+	if len(src.fetches) != len(want) {
+		t.Fatalf("fetches = %v, want %v", src.fetches, want)
+	}
+	for i := range want {
+		if src.fetches[i] != want[i] {
+			t.Errorf("fetch %d = %v, want %v", i, src.fetches[i], want[i])
+		}
+	}
+}
+
+func TestColumnDataSourceRetriesFailedFetch(t *testing.T) {
+	src := &countingSource{err: errors.New("timeout")}
+	ds := NewColumnDataSource(src, 10, 12)
+	tile := types.TileCoordinate{Zoom: 11, X: 1000, Y: 600}
+
+	if _, err := ds.FetchTileData(context.Background(), tile); err == nil {
+		t.Fatal("expected the fetch error")
+	}
+	src.err = nil
+	if _, err := ds.FetchTileData(context.Background(), tile); err != nil {
+		t.Fatalf("expected the retry to succeed, got %v", err)
+	}
+	if len(src.fetches) != 2 {
+		t.Errorf("expected a second fetch after the failure, got %d", len(src.fetches))
+	}
+}
@@ -0,0 +1,100 @@
+package datasource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/MeKo-Christian/go-overpass"
+	"github.com/MeKo-Tech/watercolormap/internal/types"
+)
+
+// FetchError describes a failed tile data fetch. Transient reports whether retrying the same
+// request later is likely to succeed (timeouts, rate limiting, overloaded servers, empty
+// responses). StatusCode is the HTTP status returned by the server, or 0 if it never answered.
+// RetryAfter is the delay the server asked for in a Retry-After header of a 429 or 503
+// response, or 0; retries should not come sooner.
+type FetchError struct {
+	Transient  bool
+	StatusCode int
+	RetryAfter time.Duration
+	Err        error
+}
+
+func (e *FetchError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *FetchError) Unwrap() error {
+	return e.Err
+}
+
+// DataSizeError reports tile data whose Overpass response or estimated in-memory size exceeds
+// the configured maximum (see OverpassDataSource.WithMaxDataSize). Rendering such a tile risks
+// running out of memory, and fetching it again returns the same data, so it is never transient.
+type DataSizeError struct {
+	SizeBytes  int64
+	LimitBytes int64
+	Partial    bool // The response was abandoned after SizeBytes; its full size is unknown
+}
+
+func (e *DataSizeError) Error() string {
+	if e.Partial {
+		return fmt.Sprintf("tile data too large: response exceeds limit of %.2f MB",
+			float64(e.LimitBytes)/(1024*1024))
+	}
+	return fmt.Sprintf("tile data too large: %.2f MB exceeds limit of %.2f MB",
+		float64(e.SizeBytes)/(1024*1024), float64(e.LimitBytes)/(1024*1024))
+}
+
+// checkDataSize returns a non-transient FetchError wrapping a DataSizeError when data exceeds
+// limitBytes. A limit of 0 or less disables the check.
+func checkDataSize(data *types.TileData, limitBytes int64) error {
+	if limitBytes <= 0 {
+		return nil
+	}
+	if size := estimateDataSize(data); size > limitBytes {
+		return &FetchError{Err: &DataSizeError{SizeBytes: size, LimitBytes: limitBytes}}
+	}
+	return nil
+}
+
+// newFetchError wraps err in a FetchError, classifying it from the underlying Overpass
+// server error, network error or empty-response sentinel.
+func newFetchError(err error) *FetchError {
+	fe := &FetchError{Err: err}
+
+	var serverErr *overpass.ServerError
+	if errors.As(err, &serverErr) {
+		fe.StatusCode = serverErr.StatusCode
+		fe.Transient = isTransientStatus(serverErr.StatusCode)
+		return fe
+	}
+
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.Canceled):
+		// The caller gave up; retrying on its behalf is pointless
+	case errors.Is(err, ErrEmptyOverpassResponse), errors.Is(err, context.DeadlineExceeded):
+		fe.Transient = true
+	case errors.As(err, &netErr):
+		// Timeouts, refused or reset connections
+		fe.Transient = true
+	}
+	return fe
+}
+
+// isTransientStatus reports whether an HTTP status indicates a temporary server condition.
+func isTransientStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,194 @@
+package datasource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/MeKo-Christian/go-overpass"
+	"github.com/MeKo-Tech/watercolormap/internal/types"
+)
+
+func TestFetchErrorClassification(t *testing.T) {
+	tile := types.TileCoordinate{Zoom: 13, X: 4317, Y: 2692}
+
+	tests := []struct {
+		name          string
+		err           error
+		result        overpass.Result
+		wantTransient bool
+		wantStatus    int
+		wantIs        error
+	}{
+		{
+			name:          "gateway timeout",
+			err:           fmt.Errorf("max retries exceeded: %w", fmt.Errorf("overpass engine error: %w", &overpass.ServerError{StatusCode: 504})),
+			wantTransient: true,
+			wantStatus:    504,
+		},
+		{
+			name:          "rate limited",
+			err:           fmt.Errorf("overpass engine error: %w", &overpass.ServerError{StatusCode: 429}),
+			wantTransient: true,
+			wantStatus:    429,
+		},
+		{
+			name:       "bad query",
+			err:        fmt.Errorf("overpass engine error: %w", &overpass.ServerError{StatusCode: 400}),
+			wantStatus: 400,
+		},
+		{
+			name:          "empty response",
+			result:        overpass.Result{},
+			wantTransient: true,
+			wantIs:        ErrEmptyOverpassResponse,
+		},
+		{
+			name:          "deadline exceeded",
+			err:           context.DeadlineExceeded,
+			wantTransient: true,
+		},
+		{
+			name:   "cancelled",
+			err:    context.Canceled,
+			wantIs: context.Canceled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ds := &OverpassDataSource{client: &stubQuerier{result: tt.result, err: tt.err}}
+
+			_, err := ds.FetchTileData(context.Background(), tile)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+
+			var fetchErr *FetchError
+			if !errors.As(err, &fetchErr) {
+				t.Fatalf("expected a *FetchError, got %T: %v", err, err)
+			}
+			if fetchErr.Transient != tt.wantTransient {
+				t.Errorf("Transient = %v, want %v (error: %v)", fetchErr.Transient, tt.wantTransient, err)
+			}
+			if fetchErr.StatusCode != tt.wantStatus {
+				t.Errorf("StatusCode = %d, want %d", fetchErr.StatusCode, tt.wantStatus)
+			}
+			if tt.wantIs != nil && !errors.Is(err, tt.wantIs) {
+				t.Errorf("expected error chain to contain %v, got %v", tt.wantIs, err)
+			}
+		})
+	}
+}
+
+func TestMaxDataSize(t *testing.T) {
+	tile := types.TileCoordinate{Zoom: 15, X: 17270, Y: 10770}
+
+	tests := []struct {
+		name     string
+		maxBytes int64
+		wantErr  bool
+	}{
+		{name: "unlimited", maxBytes: 0},
+		{name: "within limit", maxBytes: 1024 * 1024},
+		{name: "exceeds limit", maxBytes: 100, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ds := (&OverpassDataSource{client: &stubQuerier{result: lakeResult()}}).WithMaxDataSize(tt.maxBytes)
+
+			data, err := ds.FetchTileData(context.Background(), tile)
+			if !tt.wantErr {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if len(data.Features.Water) != 1 {
+					t.Fatalf("expected 1 water feature, got %d", len(data.Features.Water))
+				}
+				return
+			}
+
+			var sizeErr *DataSizeError
+			if !errors.As(err, &sizeErr) {
+				t.Fatalf("expected a *DataSizeError, got %T: %v", err, err)
+			}
+			if sizeErr.LimitBytes != tt.maxBytes || sizeErr.SizeBytes <= tt.maxBytes {
+				t.Errorf("unexpected sizes in %+v", sizeErr)
+			}
+			var fetchErr *FetchError
+			if !errors.As(err, &fetchErr) || fetchErr.Transient {
+				t.Errorf("expected a non-transient *FetchError, got %v", err)
+			}
+		})
+	}
+
+	t.Run("aborts oversized response", func(t *testing.T) {
+		const limit = 64 * 1024
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Stream without a Content-Length, as Overpass does
+			chunk := []byte(strings.Repeat(" ", 4096))
+			for i := 0; i < 1024; i++ {
+				if _, err := w.Write(chunk); err != nil {
+					return
+				}
+				w.(http.Flusher).Flush()
+			}
+		}))
+		defer srv.Close()
+
+		ds := NewOverpassDataSourceWithConfig(OverpassConfig{
+			Endpoint:   srv.URL,
+			HTTPClient: srv.Client(),
+		}).WithMaxDataSize(limit)
+		_, err := ds.FetchTileData(context.Background(), tile)
+
+		var sizeErr *DataSizeError
+		if !errors.As(err, &sizeErr) || !sizeErr.Partial {
+			t.Fatalf("expected a partial *DataSizeError, got %v", err)
+		}
+		if sizeErr.SizeBytes != limit+1 {
+			t.Errorf("read %d bytes before giving up, want %d", sizeErr.SizeBytes, limit+1)
+		}
+		var fetchErr *FetchError
+		if !errors.As(err, &fetchErr) || fetchErr.Transient {
+			t.Errorf("expected a non-transient *FetchError, got %v", err)
+		}
+	})
+
+	t.Run("rejects declared length", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Length", "1048576")
+			w.Write(make([]byte, 1024*1024)) // nolint:errcheck
+		}))
+		defer srv.Close()
+
+		ds := NewOverpassDataSourceWithConfig(OverpassConfig{
+			Endpoint:   srv.URL,
+			HTTPClient: srv.Client(),
+		}).WithMaxDataSize(1024)
+		_, err := ds.FetchTileData(context.Background(), tile)
+
+		var sizeErr *DataSizeError
+		if !errors.As(err, &sizeErr) || sizeErr.Partial || sizeErr.SizeBytes != 1024*1024 {
+			t.Fatalf("expected a *DataSizeError for the declared 1 MB, got %v", err)
+		}
+	})
+
+	t.Run("multi server", func(t *testing.T) {
+		mds := &MultiOverpassDataSource{servers: []serverInstance{
+			{datasource: &OverpassDataSource{client: &stubQuerier{result: lakeResult()}}, name: "Public"},
+		}}
+		mds.WithMaxDataSize(100)
+
+		_, err := mds.FetchTileData(context.Background(), tile)
+		var sizeErr *DataSizeError
+		if !errors.As(err, &sizeErr) {
+			t.Fatalf("expected a *DataSizeError, got %v", err)
+		}
+	})
+}
@@ -2,6 +2,7 @@ package datasource
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"sync"
@@ -13,6 +14,10 @@ import (
 
 // FetchJob represents a tile fetch request.
 type FetchJob struct {
+	// Context optionally ties the fetch to the submitter's lifetime (e.g. an HTTP request).
+	// When it is cancelled, the in-flight query is abandoned and the worker is freed.
+	// If nil, only the queue's own context applies.
+	Context    context.Context
 	Coordinate types.TileCoordinate
 	Bounds     types.BoundingBox
 	ResultChan chan FetchResult
@@ -39,6 +44,13 @@ type FetchQueueStatus struct {
 	TotalBytes int64 `json:"total_bytes"`
 	// CurrentTiles lists tiles currently being fetched
 	CurrentTiles []string `json:"current_tiles"`
+	// BreakerState is the circuit breaker state: "closed", "open" or "half-open"
+	// (empty when the breaker is disabled)
+	BreakerState string `json:"breaker_state,omitempty"`
+	// ConsecutiveFailures is the number of transient failures since the last success
+	ConsecutiveFailures int `json:"consecutive_failures"`
+	// TotalRejected is the number of fetches failed fast while the breaker was open
+	TotalRejected int64 `json:"total_rejected"`
 }
 
 // FetchQueueConfig configures the fetch queue behavior.
@@ -49,6 +61,13 @@ type FetchQueueConfig struct {
 	QueueSize int
 	// DataSizeWarningThreshold warns when tile data exceeds this size in bytes (default: 10MB)
 	DataSizeWarningThreshold int64
+	// BreakerThreshold is the number of consecutive transient failures (rate limiting,
+	// timeouts) after which the circuit breaker opens and fetches fail fast with
+	// ErrCircuitOpen (default: 5; negative disables the breaker)
+	BreakerThreshold int
+	// BreakerCooldown is how long the breaker stays open before a probe fetch is let
+	// through (default: 30s)
+	BreakerCooldown time.Duration
 	// Logger for fetch operations
 	Logger *slog.Logger
 }
@@ -59,6 +78,8 @@ func DefaultFetchQueueConfig() FetchQueueConfig {
 		Workers:                  2,
 		QueueSize:                100,
 		DataSizeWarningThreshold: 10 * 1024 * 1024, // 10MB
+		BreakerThreshold:         5,
+		BreakerCooldown:          30 * time.Second,
 		Logger:                   slog.Default(),
 	}
 }
@@ -67,6 +88,7 @@ func DefaultFetchQueueConfig() FetchQueueConfig {
 // It queues fetch jobs and processes them with a pool of workers.
 type FetchQueue struct {
 	ds        *OverpassDataSource
+	breaker   *circuitBreaker // nil when disabled
 	jobs      chan FetchJob
 	cfg       FetchQueueConfig
 	ctx       context.Context
@@ -79,6 +101,7 @@ type FetchQueue struct {
 	totalCompleted atomic.Int64
 	totalFailed    atomic.Int64
 	totalBytes     atomic.Int64
+	totalRejected  atomic.Int64
 	currentTiles   sync.Map // map[string]time.Time - tile coord string -> start time
 }
 
@@ -93,17 +116,29 @@ func NewFetchQueue(ds *OverpassDataSource, cfg FetchQueueConfig) *FetchQueue {
 	if cfg.DataSizeWarningThreshold <= 0 {
 		cfg.DataSizeWarningThreshold = 10 * 1024 * 1024
 	}
+	if cfg.BreakerThreshold == 0 {
+		cfg.BreakerThreshold = 5
+	}
+	if cfg.BreakerCooldown <= 0 {
+		cfg.BreakerCooldown = 30 * time.Second
+	}
 	if cfg.Logger == nil {
 		cfg.Logger = slog.Default()
 	}
 
+	var breaker *circuitBreaker
+	if cfg.BreakerThreshold > 0 {
+		breaker = newCircuitBreaker(cfg.BreakerThreshold, cfg.BreakerCooldown)
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	return &FetchQueue{
-		ds:     ds,
-		jobs:   make(chan FetchJob, cfg.QueueSize),
-		cfg:    cfg,
-		ctx:    ctx,
-		cancel: cancel,
+		ds:      ds,
+		breaker: breaker,
+		jobs:    make(chan FetchJob, cfg.QueueSize),
+		cfg:     cfg,
+		ctx:     ctx,
+		cancel:  cancel,
 	}
 }
 
@@ -128,6 +163,10 @@ func (fq *FetchQueue) Stop() {
 // Submit adds a fetch job to the queue and returns immediately.
 // The result will be sent to the job's ResultChan when complete.
 func (fq *FetchQueue) Submit(job FetchJob) error {
+	if fq.breaker != nil && fq.breaker.rejecting() {
+		fq.totalRejected.Add(1)
+		return ErrCircuitOpen
+	}
 	select {
 	case fq.jobs <- job:
 		return nil
@@ -139,9 +178,16 @@ func (fq *FetchQueue) Submit(job FetchJob) error {
 }
 
 // SubmitAndWait submits a fetch job and blocks until the result is available.
+// It returns ErrCircuitOpen without queueing while the circuit breaker is open.
 func (fq *FetchQueue) SubmitAndWait(ctx context.Context, coord types.TileCoordinate, bounds types.BoundingBox) (FetchResult, error) {
+	if fq.breaker != nil && fq.breaker.rejecting() {
+		fq.totalRejected.Add(1)
+		return FetchResult{}, ErrCircuitOpen
+	}
+
 	resultChan := make(chan FetchResult, 1)
 	job := FetchJob{
+		Context:    ctx,
 		Coordinate: coord,
 		Bounds:     bounds,
 		ResultChan: resultChan,
@@ -177,14 +223,19 @@ func (fq *FetchQueue) Status() FetchQueueStatus {
 		return true
 	})
 
-	return FetchQueueStatus{
+	status := FetchQueueStatus{
 		ActiveFetches:  int(fq.activeFetches.Load()),
 		QueuedFetches:  len(fq.jobs),
 		TotalCompleted: fq.totalCompleted.Load(),
 		TotalFailed:    fq.totalFailed.Load(),
 		TotalBytes:     fq.totalBytes.Load(),
+		TotalRejected:  fq.totalRejected.Load(),
 		CurrentTiles:   currentTiles,
 	}
+	if fq.breaker != nil {
+		status.BreakerState, status.ConsecutiveFailures = fq.breaker.status()
+	}
+	return status
 }
 
 func (fq *FetchQueue) worker(id int) {
@@ -202,7 +253,9 @@ func (fq *FetchQueue) worker(id int) {
 				log.Debug("fetch worker channel closed")
 				return
 			}
-			result := fq.doFetch(fq.ctx, job.Coordinate, job.Bounds)
+			ctx, cancel := fq.jobContext(job)
+			result := fq.doFetch(ctx, job.Coordinate, job.Bounds)
+			cancel()
 			if job.ResultChan != nil {
 				select {
 				case job.ResultChan <- result:
@@ -214,9 +267,33 @@ func (fq *FetchQueue) worker(id int) {
 	}
 }
 
+// jobContext returns a context that is cancelled when either the queue stops
+// or the job's own context (if any) is done.
+func (fq *FetchQueue) jobContext(job FetchJob) (context.Context, context.CancelFunc) {
+	if job.Context == nil {
+		return fq.ctx, func() {}
+	}
+	ctx, cancel := context.WithCancel(job.Context)
+	stop := context.AfterFunc(fq.ctx, cancel)
+	return ctx, func() {
+		stop()
+		cancel()
+	}
+}
+
 func (fq *FetchQueue) doFetch(ctx context.Context, coord types.TileCoordinate, bounds types.BoundingBox) FetchResult {
 	tileKey := formatTileCoord(coord)
 
+	// Jobs queued before the breaker opened fail fast as well
+	var ticket breakerTicket
+	if fq.breaker != nil {
+		var ok bool
+		if ticket, ok = fq.breaker.allow(); !ok {
+			fq.totalRejected.Add(1)
+			return FetchResult{Error: ErrCircuitOpen}
+		}
+	}
+
 	// Track fetch start
 	fq.activeFetches.Add(1)
 	fq.currentTiles.Store(tileKey, time.Now())
@@ -235,6 +312,7 @@ func (fq *FetchQueue) doFetch(ctx context.Context, coord types.TileCoordinate, b
 
 	data, err := fq.ds.FetchTileDataWithBounds(ctx, coord, bounds)
 	elapsed := time.Since(start)
+	fq.recordOutcome(ctx, ticket, err, log)
 
 	if err != nil {
 		fq.totalFailed.Add(1)
@@ -277,6 +355,31 @@ func (fq *FetchQueue) doFetch(ctx context.Context, coord types.TileCoordinate, b
 	}
 }
 
+// recordOutcome feeds a fetch result into the circuit breaker. Only transient failures count
+// against the server; a cancelled caller says nothing about its health.
+func (fq *FetchQueue) recordOutcome(ctx context.Context, ticket breakerTicket, err error, log *slog.Logger) {
+	if fq.breaker == nil {
+		return
+	}
+	var fetchErr *FetchError
+	failed := err != nil && errors.As(err, &fetchErr) && fetchErr.Transient
+	neutral := err != nil && ctx.Err() != nil
+
+	before, _ := fq.breaker.status()
+	fq.breaker.record(ticket, failed, neutral)
+	after, failures := fq.breaker.status()
+	switch {
+	case after == before:
+	case after == BreakerOpen:
+		log.Warn("circuit breaker opened after consecutive transient failures",
+			"failures", failures,
+			"cooldown", fq.cfg.BreakerCooldown,
+		)
+	case after == BreakerClosed:
+		log.Info("circuit breaker closed, fetches resumed")
+	}
+}
+
 // estimateDataSize estimates the memory size of tile data.
 // This is an approximation based on the number of features and their complexity.
 func estimateDataSize(data *types.TileData) int64 {
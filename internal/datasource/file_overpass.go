@@ -0,0 +1,64 @@
+package datasource
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/MeKo-Christian/go-overpass"
+	"github.com/MeKo-Tech/watercolormap/internal/types"
+)
+
+// FileOverpassDataSource serves tile data from an Overpass result saved as JSON (the format
+// read by UnmarshalOverpassJSON) instead of querying the API. Each fetch returns the features
+// of the file that intersect the requested bounds and that a fetch at the tile's zoom would
+// have returned, so a checked-in fixture renders like live data but without network access
+// and without changing when OSM does. It is safe for concurrent use.
+type FileOverpassDataSource struct {
+	path           string
+	result         *overpass.Result
+	classification *Classification
+}
+
+// NewFileOverpassDataSource loads the Overpass JSON file at path.
+func NewFileOverpassDataSource(path string) (*FileOverpassDataSource, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read overpass file: %w", err)
+	}
+	result, err := UnmarshalOverpassJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", path, err)
+	}
+	return &FileOverpassDataSource{path: path, result: result}, nil
+}
+
+// WithClassification overrides the built-in feature-to-layer mapping (see LoadClassification).
+// Passing nil restores the default.
+func (ds *FileOverpassDataSource) WithClassification(c *Classification) *FileOverpassDataSource {
+	ds.classification = c
+	return ds
+}
+
+// FetchTileData returns the features of the file within a tile.
+func (ds *FileOverpassDataSource) FetchTileData(ctx context.Context, tile types.TileCoordinate) (*types.TileData, error) {
+	return ds.FetchTileDataWithBounds(ctx, tile, types.TileToBounds(tile))
+}
+
+// FetchTileDataWithBounds returns the features of the file that intersect bounds. FetchedAt
+// is the timestamp recorded in the file, so repeated fetches return identical data.
+func (ds *FileOverpassDataSource) FetchTileDataWithBounds(ctx context.Context, tile types.TileCoordinate, bounds types.BoundingBox) (*types.TileData, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	features := ExtractFeaturesWithClassification(ds.result, ds.classification)
+
+	return &types.TileData{
+		Coordinate: tile,
+		Bounds:     bounds,
+		Features:   clipFeaturesToBounds(FilterFeaturesForZoom(features, tile.Zoom), bounds),
+		FetchedAt:  ds.result.Timestamp,
+		Source:     "file:" + ds.path,
+	}, nil
+}
@@ -0,0 +1,83 @@
+package datasource
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MeKo-Tech/watercolormap/internal/types"
+)
+
+// fixturePath is the Overpass fixture of the offline pipeline golden test.
+var fixturePath = filepath.Join("..", "..", "testdata", "overpass", "z16_x34540_y21540.json")
+
+func TestFileOverpassDataSource(t *testing.T) {
+	ds, err := NewFileOverpassDataSource(fixturePath)
+	if err != nil {
+		t.Fatalf("failed to load fixture: %v", err)
+	}
+	tile := types.TileCoordinate{Zoom: 16, X: 34540, Y: 21540}
+
+	data, err := ds.FetchTileData(context.Background(), tile)
+	if err != nil {
+		t.Fatalf("FetchTileData failed: %v", err)
+	}
+	f := data.Features
+	if len(f.Water) == 0 || len(f.Rivers) == 0 || len(f.Parks) == 0 || len(f.Roads) == 0 || len(f.Buildings) == 0 {
+		t.Errorf("expected every layer in the fixture tile, got water=%d rivers=%d parks=%d roads=%d buildings=%d",
+			len(f.Water), len(f.Rivers), len(f.Parks), len(f.Roads), len(f.Buildings))
+	}
+	if want := ds.result.Timestamp; !data.FetchedAt.Equal(want) || want.IsZero() {
+		t.Errorf("FetchedAt = %v, want the fixture timestamp", data.FetchedAt)
+	}
+
+	again, err := ds.FetchTileData(context.Background(), tile)
+	if err != nil {
+		t.Fatalf("FetchTileData failed: %v", err)
+	}
+	if got, want := again.Features.Count(), data.Features.Count(); got != want {
+		t.Errorf("second fetch returned %d features, want %d", got, want)
+	}
+	if got, want := again.Features.Hash(), data.Features.Hash(); got != want {
+		t.Errorf("second fetch hashes to %s, want %s", got, want)
+	}
+
+	// A tile far from the fixture area is empty
+	empty, err := ds.FetchTileData(context.Background(), types.TileCoordinate{Zoom: 16, X: 0, Y: 0})
+	if err != nil {
+		t.Fatalf("FetchTileData failed: %v", err)
+	}
+	if n := empty.Features.Count(); n != 0 {
+		t.Errorf("tile outside the fixture returned %d features, want 0", n)
+	}
+
+	// At z10 the zoom filter drops buildings and minor roads
+	low, err := ds.FetchTileDataWithBounds(context.Background(), types.TileCoordinate{Zoom: 10, X: 539, Y: 336}, data.Bounds)
+	if err != nil {
+		t.Fatalf("FetchTileDataWithBounds failed: %v", err)
+	}
+	if len(low.Features.Buildings) != 0 || len(low.Features.Roads) >= len(f.Roads) {
+		t.Errorf("z10 fetch kept buildings=%d roads=%d, want the zoom filter applied", len(low.Features.Buildings), len(low.Features.Roads))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ds.FetchTileData(ctx, tile); err == nil {
+		t.Error("expected an error for a cancelled context")
+	}
+}
+
+func TestNewFileOverpassDataSourceErrors(t *testing.T) {
+	dir := t.TempDir()
+	invalid := filepath.Join(dir, "invalid.json")
+	if err := os.WriteFile(invalid, []byte("{not json"), 0o644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	for _, path := range []string{filepath.Join(dir, "missing.json"), invalid} {
+		if _, err := NewFileOverpassDataSource(path); err == nil {
+			t.Errorf("NewFileOverpassDataSource(%s): expected an error", filepath.Base(path))
+		}
+	}
+}
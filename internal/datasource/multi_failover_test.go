@@ -0,0 +1,111 @@
+package datasource
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/MeKo-Christian/go-overpass"
+	"github.com/MeKo-Tech/watercolormap/internal/types"
+)
+
+// stubQuerier returns a fixed result or error and counts calls.
+type stubQuerier struct {
+	result overpass.Result
+	err    error
+	calls  int
+}
+
+func (s *stubQuerier) QueryContext(ctx context.Context, query string) (overpass.Result, error) {
+	s.calls++
+	return s.result, s.err
+}
+
+func lakeResult() overpass.Result {
+	way := &overpass.Way{Geometry: []overpass.Point{{Lat: 52.37, Lon: 9.73}, {Lat: 52.37, Lon: 9.74}, {Lat: 52.38, Lon: 9.74}, {Lat: 52.37, Lon: 9.73}}}
+	way.ID = 1
+	way.Tags = map[string]string{"natural": "water"}
+	return overpass.Result{Ways: map[int64]*overpass.Way{1: way}}
+}
+
+func TestMultiOverpassFailover(t *testing.T) {
+	hanover := types.TileCoordinate{Zoom: 15, X: 17270, Y: 10770}
+	regional := types.TileToBounds(hanover).ExpandByFraction(1)
+
+	newMulti := func(primary, fallback *stubQuerier) *MultiOverpassDataSource {
+		return &MultiOverpassDataSource{servers: []serverInstance{
+			{datasource: &OverpassDataSource{client: primary}, coverage: &regional, name: "Regional"},
+			{datasource: &OverpassDataSource{client: fallback}, name: "Public"},
+		}}
+	}
+
+	t.Run("failing primary falls back", func(t *testing.T) {
+		primary := &stubQuerier{err: errors.New("connection refused")}
+		fallback := &stubQuerier{result: lakeResult()}
+		mds := newMulti(primary, fallback)
+		mds.FailoverOnError = true
+
+		data, err := mds.FetchTileData(context.Background(), hanover)
+		if err != nil {
+			t.Fatalf("expected fallback to succeed, got %v", err)
+		}
+		if primary.calls != 1 || fallback.calls != 1 {
+			t.Fatalf("expected one call per server, got primary=%d fallback=%d", primary.calls, fallback.calls)
+		}
+		if len(data.Features.Water) != 1 {
+			t.Fatalf("expected fallback features, got %d water features", len(data.Features.Water))
+		}
+	})
+
+	t.Run("without failover primary error is returned", func(t *testing.T) {
+		primary := &stubQuerier{err: errors.New("connection refused")}
+		fallback := &stubQuerier{result: lakeResult()}
+
+		_, err := newMulti(primary, fallback).FetchTileData(context.Background(), hanover)
+		if err == nil || !strings.Contains(err.Error(), "[Regional]") {
+			t.Fatalf("expected primary error, got %v", err)
+		}
+		if fallback.calls != 0 {
+			t.Fatalf("fallback should not be queried without failover")
+		}
+	})
+
+	t.Run("all servers failing accumulates errors", func(t *testing.T) {
+		mds := newMulti(&stubQuerier{err: errors.New("primary down")}, &stubQuerier{err: errors.New("rate limited")})
+		mds.FailoverOnError = true
+
+		_, err := mds.FetchTileData(context.Background(), hanover)
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		for _, want := range []string{"[Regional]", "primary down", "[Public]", "rate limited"} {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("error %q missing %q", err, want)
+			}
+		}
+	})
+}
+
+func TestMultiOverpassRoutesByTileCenter(t *testing.T) {
+	hanover := types.TileCoordinate{Zoom: 15, X: 17270, Y: 10770}
+	tb := types.TileToBounds(hanover)
+
+	// The first coverage overlaps the tile's western edge only; the second contains its center
+	edgeOnly := types.BoundingBox{MinLon: tb.MinLon - 1, MinLat: tb.MinLat, MaxLon: tb.MinLon + tb.Width()/4, MaxLat: tb.MaxLat}
+	containing := tb.ExpandByFraction(1)
+
+	west := &stubQuerier{result: lakeResult()}
+	east := &stubQuerier{result: lakeResult()}
+	mds := &MultiOverpassDataSource{servers: []serverInstance{
+		{datasource: &OverpassDataSource{client: west}, coverage: &edgeOnly, name: "West"},
+		{datasource: &OverpassDataSource{client: east}, coverage: &containing, name: "East"},
+	}}
+
+	if _, err := mds.FetchTileData(context.Background(), hanover); err != nil {
+		t.Fatalf("FetchTileData failed: %v", err)
+	}
+	if west.calls != 0 || east.calls != 1 {
+		t.Fatalf("expected routing to the coverage containing the tile center, got west=%d east=%d", west.calls, east.calls)
+	}
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/MeKo-Christian/go-overpass"
 	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/planar"
 )
 
 // TestMultipolygonAssembly tests that multipolygon relations are properly assembled
@@ -192,3 +193,151 @@ func TestWaterwaysExcludedFromWaterFeatures(t *testing.T) {
 		t.Errorf("Expected 0 water features (waterways excluded), got %d", len(features.Water))
 	}
 }
+
+// squareWay builds a closed square way with its lower-left corner at (lat, lon).
+func squareWay(id int64, lat, lon, size float64) *overpass.Way {
+	return &overpass.Way{
+		Meta: overpass.Meta{ID: id},
+		Geometry: []overpass.Point{
+			{Lat: lat, Lon: lon},
+			{Lat: lat, Lon: lon + size},
+			{Lat: lat + size, Lon: lon + size},
+			{Lat: lat + size, Lon: lon},
+			{Lat: lat, Lon: lon},
+		},
+	}
+}
+
+// TestMultipolygonInnerRingsAreHoles verifies that inner rings are attached as holes
+// to the outer ring containing them, including relations with several outer rings.
+func TestMultipolygonInnerRingsAreHoles(t *testing.T) {
+	tests := []struct {
+		name    string
+		members []overpass.RelationMember
+		holes   []orb.Point
+		water   []orb.Point
+	}{
+		{
+			name: "single outer",
+			members: []overpass.RelationMember{
+				{Type: "way", Way: squareWay(1, 52.0, 9.0, 0.1), Role: "outer"},
+				{Type: "way", Way: squareWay(2, 52.04, 9.04, 0.02), Role: "inner"},
+			},
+			holes: []orb.Point{{9.05, 52.05}},
+			water: []orb.Point{{9.01, 52.01}},
+		},
+		{
+			name: "two outers with islands",
+			members: []overpass.RelationMember{
+				{Type: "way", Way: squareWay(1, 52.0, 9.0, 0.1), Role: "outer"},
+				{Type: "way", Way: squareWay(2, 52.0, 9.2, 0.1), Role: "outer"},
+				{Type: "way", Way: squareWay(3, 52.04, 9.24, 0.02), Role: "inner"},
+				{Type: "way", Way: squareWay(4, 52.04, 9.04, 0.02), Role: "inner"},
+			},
+			holes: []orb.Point{{9.05, 52.05}, {9.25, 52.05}},
+			water: []orb.Point{{9.01, 52.01}, {9.21, 52.01}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rel := &overpass.Relation{
+				Meta: overpass.Meta{
+					ID:   3001,
+					Tags: map[string]string{"type": "multipolygon", "natural": "water"},
+				},
+				Members: tt.members,
+			}
+
+			feature := convertMultipolygonRelationToFeature(rel, nil)
+			if feature == nil {
+				t.Fatal("expected a feature, got nil")
+			}
+
+			var mp orb.MultiPolygon
+			switch geom := feature.Geometry.(type) {
+			case orb.Polygon:
+				mp = orb.MultiPolygon{geom}
+			case orb.MultiPolygon:
+				mp = geom
+			default:
+				t.Fatalf("expected Polygon or MultiPolygon, got %T", geom)
+			}
+
+			for _, p := range tt.holes {
+				if planar.MultiPolygonContains(mp, p) {
+					t.Errorf("hole centre %v should not be inside the water polygon", p)
+				}
+			}
+			for _, p := range tt.water {
+				if !planar.MultiPolygonContains(mp, p) {
+					t.Errorf("point %v should be inside the water polygon", p)
+				}
+			}
+
+			for i, poly := range mp {
+				if poly[0].Orientation() != orb.CCW {
+					t.Errorf("polygon %d: outer ring should be counter-clockwise", i)
+				}
+				for j, inner := range poly[1:] {
+					if inner.Orientation() != orb.CW {
+						t.Errorf("polygon %d: inner ring %d should be clockwise", i, j)
+					}
+				}
+			}
+		})
+	}
+}
+
+// TestMultipolygonStitchesSplitRings verifies that rings split across several member
+// ways (in arbitrary direction) are joined into a single closed ring.
+func TestMultipolygonStitchesSplitRings(t *testing.T) {
+	south := &overpass.Way{
+		Meta: overpass.Meta{ID: 1},
+		Geometry: []overpass.Point{
+			{Lat: 52.1, Lon: 9.0},
+			{Lat: 52.0, Lon: 9.0},
+			{Lat: 52.0, Lon: 9.1},
+			{Lat: 52.1, Lon: 9.1},
+		},
+	}
+	// North edge runs in the same direction as the south way ends, so it must be reversed.
+	north := &overpass.Way{
+		Meta: overpass.Meta{ID: 2},
+		Geometry: []overpass.Point{
+			{Lat: 52.1, Lon: 9.0},
+			{Lat: 52.1, Lon: 9.1},
+		},
+	}
+
+	rel := &overpass.Relation{
+		Meta: overpass.Meta{
+			ID:   3002,
+			Tags: map[string]string{"type": "multipolygon", "natural": "water"},
+		},
+		Members: []overpass.RelationMember{
+			{Type: "way", Way: south, Role: "outer"},
+			{Type: "way", Way: north, Role: "outer"},
+			{Type: "way", Way: squareWay(3, 52.04, 9.04, 0.02), Role: "inner"},
+		},
+	}
+
+	feature := convertMultipolygonRelationToFeature(rel, nil)
+	if feature == nil {
+		t.Fatal("expected a feature, got nil")
+	}
+
+	poly, ok := feature.Geometry.(orb.Polygon)
+	if !ok {
+		t.Fatalf("expected Polygon, got %T", feature.Geometry)
+	}
+	if len(poly) != 2 {
+		t.Fatalf("expected outer + inner ring, got %d rings", len(poly))
+	}
+	if len(poly[0]) != 5 || !poly[0].Closed() {
+		t.Errorf("expected closed 5-point outer ring, got %v", poly[0])
+	}
+	if planar.PolygonContains(poly, orb.Point{9.05, 52.05}) {
+		t.Error("hole centre should not be inside the stitched polygon")
+	}
+}
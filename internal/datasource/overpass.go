@@ -2,7 +2,9 @@ package datasource
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
 	"time"
 
@@ -20,8 +22,17 @@ type OverpassConfig struct {
 	RetryConfig *overpass.RetryConfig
 	// HTTPClient allows custom HTTP client (default: http.DefaultClient)
 	HTTPClient *http.Client
+	// MinQueryTimeout is the server-side [timeout:] used for small tiles (default: 60s)
+	MinQueryTimeout time.Duration
+	// MaxQueryTimeout caps the adaptive [timeout:] for huge low-zoom bboxes (default: 180s)
+	MaxQueryTimeout time.Duration
 }
 
+const (
+	defaultMinQueryTimeout = 60 * time.Second
+	defaultMaxQueryTimeout = 180 * time.Second
+)
+
 // DefaultOverpassConfig returns sensible defaults for public Overpass API.
 func DefaultOverpassConfig() OverpassConfig {
 	retryConfig := overpass.DefaultRetryConfig()
@@ -50,11 +61,23 @@ func PrivateInstanceConfig(endpoint string) OverpassConfig {
 	}
 }
 
+// overpassQuerier is the subset of overpass.Client used by OverpassDataSource.
+// It exists so tests can substitute a stub client.
+type overpassQuerier interface {
+	QueryContext(ctx context.Context, query string) (overpass.Result, error)
+}
+
 // OverpassDataSource fetches OSM data from Overpass API
 type OverpassDataSource struct {
-	client           overpass.Client
+	client           overpassQuerier
 	storeRawResponse bool // If true, stores raw Overpass response in TileData (for debugging)
 	clipGeomToBbox   bool // If true, uses "out geom(bbox)" - DO NOT USE (known Overpass API bug)
+	minQueryTimeout  time.Duration
+	maxQueryTimeout  time.Duration
+	classification   *Classification      // nil = DefaultClassification
+	maxDataSize      int64                   // Reject tiles whose estimated data size exceeds this many bytes (0 = unlimited)
+	responseLimit    *responseLimitTransport // Aborts responses larger than maxDataSize (nil with stub clients)
+	retryAfter       *retryAfterTransport    // Retry-After of the latest rate-limited response (nil with stub clients)
 }
 
 // NewOverpassDataSource creates a new Overpass data source with default settings.
@@ -89,6 +112,20 @@ func NewOverpassDataSourceWithConfig(cfg OverpassConfig) *OverpassDataSource {
 	if cfg.HTTPClient == nil {
 		cfg.HTTPClient = http.DefaultClient
 	}
+	if cfg.MinQueryTimeout <= 0 {
+		cfg.MinQueryTimeout = defaultMinQueryTimeout
+	}
+	if cfg.MaxQueryTimeout < cfg.MinQueryTimeout {
+		cfg.MaxQueryTimeout = max(defaultMaxQueryTimeout, cfg.MinQueryTimeout)
+	}
+
+	// Capture Retry-After headers, which the Overpass client doesn't return with its errors,
+	// and cut off responses beyond the data size limit while they download
+	responseLimit := newResponseLimitTransport(cfg.HTTPClient.Transport)
+	retryAfter := newRetryAfterTransport(responseLimit)
+	httpClient := *cfg.HTTPClient
+	httpClient.Transport = retryAfter
+	cfg.HTTPClient = &httpClient
 
 	var client overpass.Client
 	if cfg.RetryConfig != nil {
@@ -109,9 +146,13 @@ func NewOverpassDataSourceWithConfig(cfg OverpassConfig) *OverpassDataSource {
 	}
 
 	return &OverpassDataSource{
-		client:           client,
+		client:           &client,
 		storeRawResponse: false, // Don't store raw response by default (saves memory)
 		clipGeomToBbox:   false, // Don't clip geometry (prevents artifacts from Overpass bug)
+		minQueryTimeout:  cfg.MinQueryTimeout,
+		maxQueryTimeout:  cfg.MaxQueryTimeout,
+		responseLimit:    responseLimit,
+		retryAfter:       retryAfter,
 	}
 }
 
@@ -122,6 +163,24 @@ func (ds *OverpassDataSource) WithRawResponseStorage(enabled bool) *OverpassData
 	return ds
 }
 
+// WithClassification overrides the built-in feature-to-layer mapping (see LoadClassification).
+// Tags referenced by the classification that the built-in query does not fetch are added to
+// the Overpass query. Passing nil restores the default.
+func (ds *OverpassDataSource) WithClassification(c *Classification) *OverpassDataSource {
+	ds.classification = c
+	return ds
+}
+
+// WithMaxDataSize rejects tiles whose Overpass response or estimated in-memory size exceeds
+// maxBytes with a DataSizeError, instead of handing them to the renderer. Responses are
+// abandoned as soon as they pass the limit rather than read in full. 0 (the default) disables
+// the limit.
+func (ds *OverpassDataSource) WithMaxDataSize(maxBytes int64) *OverpassDataSource {
+	ds.maxDataSize = maxBytes
+	ds.responseLimit.setLimit(maxBytes)
+	return ds
+}
+
 // WithGeometryClipping enables clipping geometry to bbox in Overpass query.
 //
 // WARNING: DO NOT USE IN PRODUCTION. This has a known Overpass API bug.
@@ -150,20 +209,24 @@ func (ds *OverpassDataSource) FetchTileDataWithBounds(ctx context.Context, tile
 	// Build Overpass QL query with zoom-based filtering
 	query := ds.buildTileQuery(bounds, tile.Zoom)
 
-	// Execute query (note: this version doesn't support context)
-	result, err := ds.client.Query(query)
+	// Execute query; returns early with ctx.Err() if the context is cancelled
+	result, err := ds.queryContext(ctx, query)
 	if err != nil {
-		return nil, fmt.Errorf("overpass query failed: %w", err)
+		fe := newFetchError(fmt.Errorf("overpass query failed: %w", err))
+		if fe.StatusCode == http.StatusTooManyRequests || fe.StatusCode == http.StatusServiceUnavailable {
+			fe.RetryAfter = ds.retryAfter.remaining()
+		}
+		return nil, fe
 	}
 
 	// Convert to feature collection
-	features := ExtractFeaturesFromOverpassResult(&result)
+	features := ExtractFeaturesWithClassification(&result, ds.classification)
 
 	// Validate that we got expected data based on zoom level.
 	// At zoom 5-13, we should always have roads/highways in any tile over land.
 	// An empty response likely indicates Overpass timeout or incomplete data.
 	if err := validateFeatureResponse(features, tile.Zoom); err != nil {
-		return nil, err
+		return nil, newFetchError(err)
 	}
 
 	tileData := &types.TileData{
@@ -179,9 +242,49 @@ func (ds *OverpassDataSource) FetchTileDataWithBounds(ctx context.Context, tile
 		tileData.OverpassResult = &result
 	}
 
+	if err := checkDataSize(tileData, ds.maxDataSize); err != nil {
+		return nil, err
+	}
+
 	return tileData, nil
 }
 
+// queryContext runs an Overpass query and honors ctx cancellation.
+//
+// The client passes ctx to the HTTP request, but it waits for a free worker slot
+// without watching ctx. The query therefore runs in a goroutine and we return as
+// soon as ctx is done. The in-flight HTTP request is cancelled through ctx, and
+// any late result is discarded.
+func (ds *OverpassDataSource) queryContext(ctx context.Context, query string) (overpass.Result, error) {
+	if err := ctx.Err(); err != nil {
+		return overpass.Result{}, err
+	}
+
+	type queryResult struct {
+		err    error
+		result overpass.Result
+	}
+	done := make(chan queryResult, 1) // buffered so the goroutine never blocks after we return
+
+	go func() {
+		result, err := ds.client.QueryContext(ctx, query)
+		done <- queryResult{result: result, err: err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.result, r.err
+	case <-ctx.Done():
+		return overpass.Result{}, ctx.Err()
+	}
+}
+
+// DebugQuery returns the Overpass QL query FetchTileDataWithBounds runs for bounds at zoom,
+// e.g. to reproduce a fetch in Overpass turbo.
+func (ds *OverpassDataSource) DebugQuery(bounds types.BoundingBox, zoom int) string {
+	return ds.buildTileQuery(bounds, zoom)
+}
+
 // buildTileQuery creates a comprehensive Overpass QL query for tile features.
 // It fetches COMPLETE unclipped geometry for all ways that intersect the bounding box.
 // Features are filtered based on zoom level to reduce data at lower zooms.
@@ -230,8 +333,13 @@ func (ds *OverpassDataSource) buildTileQuery(bounds types.BoundingBox, zoom int)
 	// Buildings and urban (only at higher zooms)
 	queryParts = append(queryParts, ds.buildBuildingsQuery(bbox, zoom)...)
 
+	// Tags only referenced by a custom classification (fetched at all zooms)
+	if ds.classification != nil {
+		queryParts = append(queryParts, ds.classification.extraQueryParts(bbox)...)
+	}
+
 	// Build final query
-	query := "[out:json][timeout:60];\n(\n"
+	query := fmt.Sprintf("[out:json][timeout:%d];\n(\n", ds.computeQueryTimeout(bounds, zoom))
 	for _, part := range queryParts {
 		query += "  " + part + "\n"
 	}
@@ -240,211 +348,85 @@ func (ds *OverpassDataSource) buildTileQuery(bounds types.BoundingBox, zoom int)
 	return query
 }
 
+// queryTimeoutRefArea is the area (in equator-equivalent square degrees) of a z12 tile.
+// Queries up to this size get the minimum timeout.
+var queryTimeoutRefArea = math.Pow(360.0/4096.0, 2)
+
+// computeQueryTimeout returns the Overpass [timeout:] in seconds for a query over bounds.
+// The minimum timeout grows by 25% for every doubling of the bbox area beyond a z12 tile,
+// plus 10% per zoom level below z10 (low-zoom queries fetch complete coastline and forest
+// geometry), and is capped at the configured maximum.
+func (ds *OverpassDataSource) computeQueryTimeout(bounds types.BoundingBox, zoom int) int {
+	minTimeout, maxTimeout := ds.minQueryTimeout, ds.maxQueryTimeout
+	if minTimeout <= 0 {
+		minTimeout = defaultMinQueryTimeout
+	}
+	if maxTimeout < minTimeout {
+		maxTimeout = max(defaultMaxQueryTimeout, minTimeout)
+	}
+
+	// Shrink the longitude span by cos(lat) so equal ground areas get equal timeouts.
+	midLat := (bounds.MinLat + bounds.MaxLat) / 2
+	lonSpan := math.Abs(bounds.MaxLon-bounds.MinLon) * math.Cos(midLat*math.Pi/180)
+	latSpan := math.Abs(bounds.MaxLat - bounds.MinLat)
+	area := lonSpan * latSpan
+
+	factor := 1.0
+	if area > queryTimeoutRefArea {
+		factor += 0.25 * math.Log2(area/queryTimeoutRefArea)
+	}
+	if zoom < 10 {
+		factor *= 1 + 0.1*float64(10-zoom)
+	}
+
+	timeout := time.Duration(float64(minTimeout) * factor)
+	if timeout > maxTimeout {
+		timeout = maxTimeout
+	}
+	return int(math.Ceil(timeout.Seconds()))
+}
+
 // buildWaterQuery returns water-related query parts based on zoom level.
-// Zoom-based filtering:
+// Zoom-based filtering (see waterZoomRules):
 //   - All zooms: Coastlines + large water bodies
 //   - z10-11: + major rivers
 //   - z12-13: + rivers/streams/canals
 //   - z14+: All waterways
 func (ds *OverpassDataSource) buildWaterQuery(bbox string, zoom int) []string {
-	var parts []string
-
-	// Coastlines and water bodies - always include at all zoom levels
-	// NOTE: OSM does NOT include ocean polygons in raw data. Ocean is represented
-	// as "absence of land". This causes ocean tiles to render as land (tan background).
-	// See PLAN.md section 4.10 for ocean rendering solutions (water polygons or synthesis).
-	parts = append(parts,
-		fmt.Sprintf(`way["natural"="water"](%s);`, bbox),
-		fmt.Sprintf(`way["natural"="coastline"](%s);`, bbox),
-		fmt.Sprintf(`relation["natural"="water"](%s);`, bbox),
-	)
-
-	// Rivers and waterways - progressively add detail
-	if zoom >= 10 {
-		if zoom >= 14 {
-			// z14+: All waterways
-			parts = append(parts,
-				fmt.Sprintf(`way["waterway"](%s);`, bbox),
-				fmt.Sprintf(`relation["waterway"](%s);`, bbox),
-			)
-		} else if zoom >= 12 {
-			// z12-13: Rivers, streams, canals (no drains/ditches)
-			parts = append(parts,
-				fmt.Sprintf(`way["waterway"~"river|stream|canal"](%s);`, bbox),
-				fmt.Sprintf(`relation["waterway"~"river|stream|canal"](%s);`, bbox),
-			)
-		} else {
-			// z10-11: Major rivers only
-			parts = append(parts,
-				fmt.Sprintf(`way["waterway"="river"](%s);`, bbox),
-				fmt.Sprintf(`relation["waterway"="river"](%s);`, bbox),
-			)
-		}
-	}
-
-	return parts
+	return zoomRulesQueryParts(waterZoomRules, bbox, zoom)
 }
 
 // buildParksQuery returns parks/green space query parts based on zoom level.
-// Zoom-based filtering:
+// Zoom-based filtering (see parksZoomRules):
 //   - All zooms: Large forests and woods (major geographic features)
 //   - z8-9: + parks
 //   - z10-11: + meadows and grass
 //   - z14-15: + gardens
 //   - z16+: + playgrounds
 func (ds *OverpassDataSource) buildParksQuery(bbox string, zoom int) []string {
-	var parts []string
-
-	// Forests and woods - always include at all zoom levels (major geographic features like water)
-	// Include both ways and relations for complete coverage of large forest areas
-	parts = append(parts,
-		fmt.Sprintf(`way["landuse"="forest"](%s);`, bbox),
-		fmt.Sprintf(`relation["landuse"="forest"](%s);`, bbox),
-		fmt.Sprintf(`way["natural"="wood"](%s);`, bbox),
-		fmt.Sprintf(`relation["natural"="wood"](%s);`, bbox),
-	)
-
-	if zoom >= 8 {
-		// z8+: Add parks, nature reserves, and heath (like Lüneburger Heide)
-		parts = append(parts,
-			fmt.Sprintf(`way["leisure"="park"](%s);`, bbox),
-			fmt.Sprintf(`relation["leisure"="park"](%s);`, bbox),
-			fmt.Sprintf(`way["leisure"="nature_reserve"](%s);`, bbox),
-			fmt.Sprintf(`relation["leisure"="nature_reserve"](%s);`, bbox),
-			fmt.Sprintf(`way["natural"="heath"](%s);`, bbox),
-			fmt.Sprintf(`relation["natural"="heath"](%s);`, bbox),
-		)
-	}
-
-	if zoom >= 10 {
-		// z10+: Add meadows, grass, and farmland
-		parts = append(parts,
-			fmt.Sprintf(`way["landuse"="grass"](%s);`, bbox),
-			fmt.Sprintf(`way["landuse"="meadow"](%s);`, bbox),
-			fmt.Sprintf(`way["landuse"="farmland"](%s);`, bbox),
-			fmt.Sprintf(`way["natural"="grassland"](%s);`, bbox),
-			fmt.Sprintf(`way["natural"="heath"](%s);`, bbox),
-		)
-	}
-
-	if zoom >= 14 {
-		// z14+: Add gardens and orchards
-		parts = append(parts,
-			fmt.Sprintf(`way["leisure"="garden"](%s);`, bbox),
-			fmt.Sprintf(`way["landuse"="orchard"](%s);`, bbox),
-			fmt.Sprintf(`way["landuse"="vineyard"](%s);`, bbox),
-		)
-	}
-
-	if zoom >= 16 {
-		// z16+: Add playgrounds and allotments
-		parts = append(parts,
-			fmt.Sprintf(`way["leisure"="playground"](%s);`, bbox),
-			fmt.Sprintf(`way["landuse"="allotments"](%s);`, bbox),
-		)
-	}
-
-	return parts
+	return zoomRulesQueryParts(parksZoomRules, bbox, zoom)
 }
 
 // buildRoadsQuery returns road query parts based on zoom level.
-// Zoom-based filtering:
+// Zoom-based filtering (see roadsZoomRules):
 //   - z<5: No roads
 //   - z5-7: Motorway only
-//   - z8-9: Motorway + trunk
-//   - z10-11: + primary
+//   - z8-11: Motorway + trunk + primary
 //   - z12-13: + secondary, tertiary
 //   - z14-15: + residential, unclassified
 //   - z16+: All roads
 func (ds *OverpassDataSource) buildRoadsQuery(bbox string, zoom int) []string {
-	if zoom < 5 {
-		// No roads at very low zooms
-		return nil
-	}
-
-	if zoom < 8 {
-		// z5-7: Motorway only for overview
-		return []string{
-			fmt.Sprintf(`way["highway"~"motorway|motorway_link"](%s);`, bbox),
-		}
-	}
-
-	if zoom < 10 {
-		// z8-9: Motorway + trunk + primary for visibility at low zoom
-		return []string{
-			fmt.Sprintf(`way["highway"~"motorway|motorway_link|trunk|trunk_link|primary|primary_link"](%s);`, bbox),
-		}
-	}
-
-	var parts []string
-
-	if zoom >= 16 {
-		// z16+: All roads
-		parts = append(parts, fmt.Sprintf(`way["highway"](%s);`, bbox))
-	} else if zoom >= 14 {
-		// z14-15: Major + residential (no service, track, path, footway, etc.)
-		parts = append(parts,
-			fmt.Sprintf(`way["highway"~"motorway|motorway_link|trunk|trunk_link|primary|primary_link|secondary|secondary_link|tertiary|tertiary_link|residential|unclassified|living_street"](%s);`, bbox),
-		)
-	} else if zoom >= 12 {
-		// z12-13: Major roads + secondary/tertiary
-		parts = append(parts,
-			fmt.Sprintf(`way["highway"~"motorway|motorway_link|trunk|trunk_link|primary|primary_link|secondary|secondary_link|tertiary|tertiary_link"](%s);`, bbox),
-		)
-	} else {
-		// z10-11: Major roads only
-		parts = append(parts,
-			fmt.Sprintf(`way["highway"~"motorway|motorway_link|trunk|trunk_link|primary|primary_link"](%s);`, bbox),
-		)
-	}
-
-	return parts
+	return zoomRulesQueryParts(roadsZoomRules, bbox, zoom)
 }
 
 // buildBuildingsQuery returns building and urban area query parts based on zoom level.
-// Zoom-based filtering:
+// Zoom-based filtering (see buildingsZoomRules):
 //   - z<11: Nothing
 //   - z11-13: Urban landuse areas (residential, commercial, industrial, retail)
 //   - z14-15: Urban areas + urban buildings (schools, hospitals, universities)
 //   - z16+: Urban areas + urban buildings + all individual buildings
 func (ds *OverpassDataSource) buildBuildingsQuery(bbox string, zoom int) []string {
-	if zoom < 11 {
-		// No buildings or urban areas at very low zooms
-		return nil
-	}
-
-	var parts []string
-
-	// Urban landuse areas - from z11+
-	// These show built-up areas to identify towns/cities at medium zooms
-	if zoom >= 11 {
-		parts = append(parts,
-			fmt.Sprintf(`way["landuse"="residential"](%s);`, bbox),
-			fmt.Sprintf(`relation["landuse"="residential"](%s);`, bbox),
-			fmt.Sprintf(`way["landuse"="commercial"](%s);`, bbox),
-			fmt.Sprintf(`relation["landuse"="commercial"](%s);`, bbox),
-			fmt.Sprintf(`way["landuse"="industrial"](%s);`, bbox),
-			fmt.Sprintf(`relation["landuse"="industrial"](%s);`, bbox),
-			fmt.Sprintf(`way["landuse"="retail"](%s);`, bbox),
-			fmt.Sprintf(`relation["landuse"="retail"](%s);`, bbox),
-		)
-	}
-
-	// Civic buildings - from z14+
-	if zoom >= 14 {
-		parts = append(parts,
-			fmt.Sprintf(`way["amenity"="school"](%s);`, bbox),
-			fmt.Sprintf(`way["amenity"="hospital"](%s);`, bbox),
-			fmt.Sprintf(`way["amenity"="university"](%s);`, bbox),
-		)
-	}
-
-	// Individual buildings - from z16+
-	if zoom >= 16 {
-		parts = append(parts, fmt.Sprintf(`way["building"](%s);`, bbox))
-	}
-
-	return parts
+	return zoomRulesQueryParts(buildingsZoomRules, bbox, zoom)
 }
 
 // Close cleans up resources (no-op for current version)
@@ -482,6 +464,11 @@ type ServerConfig struct {
 // It checks tile coordinates against coverage areas and delegates to the appropriate server.
 type MultiOverpassDataSource struct {
 	servers []serverInstance
+
+	// FailoverOnError makes a failed fetch fall through to the next server whose coverage
+	// matches (typically the nil-coverage fallback) instead of failing the tile. Errors from
+	// every attempted server are returned together if all of them fail.
+	FailoverOnError bool
 }
 
 type serverInstance struct {
@@ -550,31 +537,74 @@ func (mds *MultiOverpassDataSource) FetchTileData(ctx context.Context, tile type
 }
 
 // FetchTileDataWithBounds routes the query to the appropriate Overpass server.
+// With FailoverOnError set, subsequent matching servers are tried in order when one fails.
 func (mds *MultiOverpassDataSource) FetchTileDataWithBounds(ctx context.Context, tile types.TileCoordinate, bounds types.BoundingBox) (*types.TileData, error) {
+	var errs []error
+
+	// Route by the center of the requested area (the tile center for single tiles), so a
+	// tile overlapping two coverage areas goes to the one that actually contains it
+	lat, lon := bounds.Center()
+
 	// Find the first server whose coverage contains this tile
 	for _, srv := range mds.servers {
-		if srv.coverage == nil || intersects(bounds, *srv.coverage) {
-			// Found a matching server - delegate to it
-			data, err := srv.datasource.FetchTileDataWithBounds(ctx, tile, bounds)
-			if err != nil {
-				// Include server name in error for debugging
-				return nil, fmt.Errorf("[%s] %w", srv.name, err)
-			}
+		if srv.coverage != nil && !srv.coverage.Contains(lon, lat) {
+			continue
+		}
+
+		// Found a matching server - delegate to it
+		data, err := srv.datasource.FetchTileDataWithBounds(ctx, tile, bounds)
+		if err == nil {
 			return data, nil
 		}
+
+		// Include server name in error for debugging
+		err = fmt.Errorf("[%s] %w", srv.name, err)
+		if !mds.FailoverOnError {
+			return nil, err
+		}
+		errs = append(errs, err)
+
+		// Don't try further servers once the caller has given up
+		if ctx.Err() != nil {
+			return nil, errors.Join(errs...)
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("all matching overpass servers failed for tile %s: %w", tile, errors.Join(errs...))
 	}
 
 	// No server matched (shouldn't happen if you have a nil-coverage fallback)
 	return nil, fmt.Errorf("no overpass server configured for tile %s", tile)
 }
 
-// intersects checks if two bounding boxes overlap.
-// Returns true if they share any geographic area.
-func intersects(a, b types.BoundingBox) bool {
-	// Boxes intersect if they overlap in both longitude and latitude
-	lonOverlap := a.MinLon <= b.MaxLon && a.MaxLon >= b.MinLon
-	latOverlap := a.MinLat <= b.MaxLat && a.MaxLat >= b.MinLat
-	return lonOverlap && latOverlap
+// DebugQuery returns the query of the server FetchTileDataWithBounds routes bounds to first
+// (see OverpassDataSource.DebugQuery), or "" when no server covers them.
+func (mds *MultiOverpassDataSource) DebugQuery(bounds types.BoundingBox, zoom int) string {
+	lat, lon := bounds.Center()
+	for _, srv := range mds.servers {
+		if srv.coverage == nil || srv.coverage.Contains(lon, lat) {
+			return srv.datasource.DebugQuery(bounds, zoom)
+		}
+	}
+	return ""
+}
+
+// WithClassification applies a custom feature-to-layer mapping to every server.
+func (mds *MultiOverpassDataSource) WithClassification(c *Classification) *MultiOverpassDataSource {
+	for _, s := range mds.servers {
+		s.datasource.WithClassification(c)
+	}
+	return mds
+}
+
+// WithMaxDataSize applies a maximum tile data size to every server (see
+// OverpassDataSource.WithMaxDataSize).
+func (mds *MultiOverpassDataSource) WithMaxDataSize(maxBytes int64) *MultiOverpassDataSource {
+	for _, s := range mds.servers {
+		s.datasource.WithMaxDataSize(maxBytes)
+	}
+	return mds
 }
 
 // Close cleans up all underlying datasources.
@@ -0,0 +1,84 @@
+package datasource
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/MeKo-Christian/go-overpass"
+	"github.com/MeKo-Tech/watercolormap/internal/types"
+)
+
+// slowQuerier simulates an Overpass client that ignores cancellation and blocks
+// until released (e.g. waiting for a worker slot on a busy server).
+type slowQuerier struct {
+	release chan struct{}
+}
+
+func (s *slowQuerier) QueryContext(ctx context.Context, query string) (overpass.Result, error) {
+	<-s.release
+	return overpass.Result{}, nil
+}
+
+func TestFetchTileDataHonorsContextCancellation(t *testing.T) {
+	stub := &slowQuerier{release: make(chan struct{})}
+	defer close(stub.release)
+
+	ds := &OverpassDataSource{client: stub}
+	tile := types.TileCoordinate{Zoom: 5, X: 16, Y: 10}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := ds.FetchTileData(ctx, tile)
+	elapsed := time.Since(start)
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+	if elapsed > time.Second {
+		t.Errorf("expected prompt return after cancellation, took %v", elapsed)
+	}
+}
+
+func TestFetchTileDataAlreadyCancelled(t *testing.T) {
+	stub := &slowQuerier{release: make(chan struct{})}
+	defer close(stub.release)
+
+	ds := &OverpassDataSource{client: stub}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := ds.FetchTileData(ctx, types.TileCoordinate{Zoom: 13, X: 4317, Y: 2692})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestFetchQueueFreesWorkerOnCancellation(t *testing.T) {
+	stub := &slowQuerier{release: make(chan struct{})}
+	defer close(stub.release)
+
+	fq := NewFetchQueue(&OverpassDataSource{client: stub}, FetchQueueConfig{Workers: 1})
+	fq.Start()
+	defer fq.Stop()
+
+	tile := types.TileCoordinate{Zoom: 5, X: 16, Y: 10}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if _, err := fq.SubmitAndWait(ctx, tile, types.TileToBounds(tile)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for fq.Status().ActiveFetches > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("fetch worker still busy after the request context was cancelled")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
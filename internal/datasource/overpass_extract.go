@@ -3,10 +3,13 @@ package datasource
 import (
 	"encoding/json"
 	"fmt"
+	"math"
 
 	"github.com/MeKo-Christian/go-overpass"
+	"github.com/MeKo-Tech/watercolormap/internal/geojson"
 	"github.com/MeKo-Tech/watercolormap/internal/types"
 	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/planar"
 )
 
 // UnmarshalOverpassJSON decodes an Overpass API JSON response into an overpass.Result.
@@ -19,13 +22,31 @@ func UnmarshalOverpassJSON(data []byte) (*overpass.Result, error) {
 	return &result, nil
 }
 
-// ExtractFeaturesFromOverpassResult converts an Overpass result to WaterColorMap's FeatureCollection.
-// It mirrors the logic used by OverpassDataSource.
+// ExtractFeaturesFromOverpassResult converts an Overpass result to WaterColorMap's FeatureCollection
+// using the built-in classification. It mirrors the logic used by OverpassDataSource.
 func ExtractFeaturesFromOverpassResult(result *overpass.Result) types.FeatureCollection {
+	return ExtractFeaturesWithClassification(result, nil)
+}
+
+// relationLayers are the layers relations may be routed to; other relations (e.g. route
+// relations tagged highway=*) don't carry usable area geometry.
+var relationLayers = map[geojson.LayerType]bool{
+	geojson.LayerWater:  true,
+	geojson.LayerRivers: true,
+	geojson.LayerParks:  true,
+}
+
+// ExtractFeaturesWithClassification converts an Overpass result to a FeatureCollection,
+// routing each way and relation to the layer of its first matching classification rule.
+// A nil classification uses DefaultClassification.
+func ExtractFeaturesWithClassification(result *overpass.Result, classification *Classification) types.FeatureCollection {
 	var features types.FeatureCollection
 	if result == nil {
 		return features
 	}
+	if classification == nil {
+		classification = DefaultClassification()
+	}
 
 	// Build a set of way IDs that are members of multipolygon relations
 	// Note: We check both embedded Way objects and referenced way IDs
@@ -57,19 +78,8 @@ func ExtractFeaturesFromOverpassResult(result *overpass.Result) types.FeatureCol
 			continue
 		}
 
-		switch {
-		case isWater(way.Tags):
-			features.Water = append(features.Water, *feature)
-		case isRiver(way.Tags):
-			features.Rivers = append(features.Rivers, *feature)
-		case isGreen(way.Tags):
-			features.Parks = append(features.Parks, *feature)
-		case isRoad(way.Tags):
-			features.Roads = append(features.Roads, *feature)
-		case isBuilding(way.Tags):
-			features.Buildings = append(features.Buildings, *feature)
-		case isUrban(way.Tags):
-			features.Urban = append(features.Urban, *feature)
+		if layer, ok := classification.Classify(way.Tags); ok {
+			addToLayer(&features, layer, *feature)
 		}
 	}
 
@@ -88,13 +98,8 @@ func ExtractFeaturesFromOverpassResult(result *overpass.Result) types.FeatureCol
 			continue
 		}
 
-		switch {
-		case isWater(rel.Tags):
-			features.Water = append(features.Water, *feature)
-		case isRiver(rel.Tags):
-			features.Rivers = append(features.Rivers, *feature)
-		case isGreen(rel.Tags):
-			features.Parks = append(features.Parks, *feature)
+		if layer, ok := classification.Classify(rel.Tags); ok && relationLayers[layer] {
+			addToLayer(&features, layer, *feature)
 		}
 	}
 
@@ -125,11 +130,8 @@ func convertWayToFeature(way *overpass.Way) *types.Feature {
 		name = n
 	}
 
-	featureType := categorizeByTags(way.Tags)
-
 	return &types.Feature{
 		ID:         fmt.Sprintf("way/%d", way.ID),
-		Type:       featureType,
 		Geometry:   geometry,
 		Properties: convertTags(way.Tags),
 		Name:       name,
@@ -146,89 +148,83 @@ func convertRelationToFeature(rel *overpass.Relation) *types.Feature {
 		name = n
 	}
 
-	featureType := categorizeByTags(rel.Tags)
-
 	return &types.Feature{
 		ID:         fmt.Sprintf("relation/%d", rel.ID),
-		Type:       featureType,
 		Geometry:   orb.Point{},
 		Properties: convertTags(rel.Tags),
 		Name:       name,
 	}
 }
 
-// convertMultipolygonRelationToFeature assembles a multipolygon relation from its member ways
+// convertMultipolygonRelationToFeature assembles a multipolygon relation from its member ways.
+// Member ways are stitched into closed rings per role, inner rings are attached as holes to
+// the outer ring that contains them, and ring orientation is normalized (outer CCW, inner CW)
+// so the renderer's fill rule cuts the holes out.
 func convertMultipolygonRelationToFeature(rel *overpass.Relation, ways map[int64]*overpass.Way) *types.Feature {
 	if rel == nil {
 		return nil
 	}
 
-	// Separate outer and inner rings
-	var outerRings []orb.Ring
-	var innerRings []orb.Ring
+	// Separate outer and inner member ways
+	var outerLines []orb.LineString
+	var innerLines []orb.LineString
 
 	for _, member := range rel.Members {
 		if member.Type != "way" {
 			continue
 		}
 
-		// Look up the way - try embedded object first, then fall back to map lookup
-		var way *overpass.Way
-		if member.Way != nil {
-			// Embedded way object (from test data)
-			way = member.Way
-		}
-		// Note: Real Overpass API doesn't embed member ways - they must be looked up
-		// However, the go-overpass library doesn't expose the ref ID, so we can't look them up
-		// Member ways that aren't in the result will be skipped
-
+		// Member ways are resolved by the overpass client and shared with result.Ways;
+		// ways whose geometry was not part of the response are skipped.
+		way := member.Way
 		if way == nil || len(way.Geometry) == 0 {
 			continue
 		}
 
-		// Convert way geometry to ring
 		points := make(orb.LineString, len(way.Geometry))
 		for i, point := range way.Geometry {
 			points[i] = orb.Point{point.Lon, point.Lat}
 		}
 
-		// Ensure ring is closed
-		if len(points) > 0 && points[0] != points[len(points)-1] {
-			points = append(points, points[0])
-		}
-
-		ring := orb.Ring(points)
-
 		// Classify as outer or inner based on role
 		if member.Role == "inner" {
-			innerRings = append(innerRings, ring)
+			innerLines = append(innerLines, points)
 		} else {
 			// Default to outer (role can be empty or "outer")
-			outerRings = append(outerRings, ring)
+			outerLines = append(outerLines, points)
 		}
 	}
 
-	// Build geometry
-	var geometry orb.Geometry
+	outerRings := assembleRings(outerLines)
 	if len(outerRings) == 0 {
 		// No outer rings - can't build polygon
 		return nil
 	}
+	innerRings := assembleRings(innerLines)
 
-	if len(outerRings) == 1 {
-		// Single polygon with potential inner rings
-		rings := make([]orb.Ring, 0, 1+len(innerRings))
-		rings = append(rings, outerRings[0])
-		rings = append(rings, innerRings...)
-		geometry = orb.Polygon(rings)
-	} else {
-		// Multiple outer rings - create MultiPolygon
-		polygons := make(orb.MultiPolygon, len(outerRings))
-		for i, outer := range outerRings {
-			polygons[i] = orb.Polygon{outer}
+	polygons := make(orb.MultiPolygon, len(outerRings))
+	for i, outer := range outerRings {
+		orientRing(outer, orb.CCW)
+		polygons[i] = orb.Polygon{outer}
+	}
+
+	for _, inner := range innerRings {
+		idx := containingRing(outerRings, inner)
+		if idx < 0 {
+			if len(outerRings) > 1 {
+				// A hole outside every outer ring has nothing to cut
+				continue
+			}
+			idx = 0
 		}
-		// Note: For simplicity, we're not assigning inner rings to specific outer rings
-		// A more sophisticated implementation would determine which inner rings belong to which outer rings
+		orientRing(inner, orb.CW)
+		polygons[idx] = append(polygons[idx], inner)
+	}
+
+	var geometry orb.Geometry
+	if len(polygons) == 1 {
+		geometry = polygons[0]
+	} else {
 		geometry = polygons
 	}
 
@@ -237,91 +233,92 @@ func convertMultipolygonRelationToFeature(rel *overpass.Relation, ways map[int64
 		name = n
 	}
 
-	featureType := categorizeByTags(rel.Tags)
-
 	return &types.Feature{
 		ID:         fmt.Sprintf("relation/%d", rel.ID),
-		Type:       featureType,
 		Geometry:   geometry,
 		Properties: convertTags(rel.Tags),
 		Name:       name,
 	}
 }
 
-func categorizeByTags(tags map[string]string) types.FeatureType {
-	if isWater(tags) {
-		return types.FeatureTypeWater
-	}
-	if isGreen(tags) {
-		return types.FeatureTypePark
-	}
-	if isRoad(tags) {
-		return types.FeatureTypeRoad
-	}
-	if isBuilding(tags) {
-		return types.FeatureTypeBuilding
-	}
-	if isUrban(tags) {
-		return types.FeatureTypeUrban
-	}
-	return types.FeatureTypeUnknown
-}
+// assembleRings joins member way segments into closed rings. OSM multipolygons
+// commonly split a single ring across several ways, so open segments are chained
+// by matching endpoints (reversing segments where needed). Segments that cannot
+// be chained into a closed ring are closed artificially.
+func assembleRings(lines []orb.LineString) []orb.Ring {
+	var rings []orb.Ring
+	var open []orb.LineString
 
-func isWater(tags map[string]string) bool {
-	// Only include polygonal water bodies, not linear waterways
-	// Waterways are now handled separately in isRiver()
-	// NOTE: natural=sea and place=sea are NOT area polygons (they're points or don't exist)
-	// Ocean tiles will not render correctly - see PLAN.md section 4.10
-	return tags["natural"] == "water" ||
-		tags["natural"] == "coastline"
-}
+	for _, line := range lines {
+		if len(line) == 0 {
+			continue
+		}
+		if len(line) > 2 && line[0] == line[len(line)-1] {
+			rings = append(rings, orb.Ring(line.Clone()))
+			continue
+		}
+		open = append(open, line)
+	}
 
-func isRiver(tags map[string]string) bool {
-	// Linear waterways: rivers, streams, canals
-	// These will be rendered with LineSymbolizer to avoid polygon closing issues
-	return tags["waterway"] != ""
-}
+	for len(open) > 0 {
+		current := open[0].Clone()
+		open = open[1:]
+
+		for current[0] != current[len(current)-1] {
+			joined := false
+			for i, next := range open {
+				end := current[len(current)-1]
+				switch {
+				case next[0] == end:
+					current = append(current, next[1:]...)
+				case next[len(next)-1] == end:
+					reversed := next.Clone()
+					reversed.Reverse()
+					current = append(current, reversed[1:]...)
+				default:
+					continue
+				}
+				open = append(open[:i], open[i+1:]...)
+				joined = true
+				break
+			}
+			if !joined {
+				// Ring is incomplete (e.g. clipped by the query bbox); close it directly
+				current = append(current, current[0])
+			}
+		}
 
-func isGreen(tags map[string]string) bool {
-	return tags["leisure"] == "park" ||
-		tags["leisure"] == "garden" ||
-		tags["leisure"] == "playground" ||
-		tags["leisure"] == "nature_reserve" ||
-		tags["landuse"] == "forest" ||
-		tags["landuse"] == "grass" ||
-		tags["landuse"] == "meadow" ||
-		tags["landuse"] == "farmland" ||
-		tags["landuse"] == "orchard" ||
-		tags["landuse"] == "vineyard" ||
-		tags["landuse"] == "allotments" ||
-		tags["natural"] == "wood" ||
-		tags["natural"] == "heath" ||
-		tags["natural"] == "grassland"
-}
+		if len(current) > 3 {
+			rings = append(rings, orb.Ring(current))
+		}
+	}
 
-func isRoad(tags map[string]string) bool {
-	return tags["highway"] != ""
+	return rings
 }
 
-func isBuilding(tags map[string]string) bool {
-	return tags["building"] != ""
+// orientRing reverses the ring in place if it does not have the given orientation.
+func orientRing(r orb.Ring, o orb.Orientation) {
+	if r.Orientation() != o {
+		r.Reverse()
+	}
 }
 
-func isUrban(tags map[string]string) bool {
-	// Urban areas include landuse zones (residential/commercial/industrial)
-	// and urban buildings (schools, hospitals, universities)
-	landuse := tags["landuse"]
-	amenity := tags["amenity"]
-
-	return landuse == "residential" ||
-		landuse == "commercial" ||
-		landuse == "industrial" ||
-		landuse == "retail" ||
-		amenity == "school" ||
-		amenity == "hospital" ||
-		amenity == "university" ||
-		amenity == "library" ||
-		amenity == "town_hall"
+// containingRing returns the index of the smallest outer ring that contains the
+// inner ring, or -1 if none does.
+func containingRing(outers []orb.Ring, inner orb.Ring) int {
+	best := -1
+	bestArea := 0.0
+	for i, outer := range outers {
+		if !planar.RingContains(outer, inner[0]) {
+			continue
+		}
+		area := math.Abs(planar.Area(outer))
+		if best < 0 || area < bestArea {
+			best = i
+			bestArea = area
+		}
+	}
+	return best
 }
 
 // convertTags converts OSM tags to generic properties map
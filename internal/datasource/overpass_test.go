@@ -2,6 +2,7 @@ package datasource
 
 import (
 	"context"
+	"strings"
 	"testing"
 	"time"
 
@@ -270,3 +271,42 @@ func TestCacheOperations(t *testing.T) {
 
 	t.Log("Cache operations tests passed")
 }
+
+// TestComputeQueryTimeout checks that the adaptive Overpass timeout grows with bbox size
+// and stays within the configured bounds.
+func TestComputeQueryTimeout(t *testing.T) {
+	ds := NewOverpassDataSourceWithConfig(OverpassConfig{})
+
+	hanover := types.TileToBounds(types.TileCoordinate{Zoom: 13, X: 4317, Y: 2692})
+	z10 := types.TileToBounds(types.TileCoordinate{Zoom: 10, X: 539, Y: 336})
+	z5 := types.TileToBounds(types.TileCoordinate{Zoom: 5, X: 16, Y: 10})
+
+	if got := ds.computeQueryTimeout(hanover, 13); got != 60 {
+		t.Errorf("z13 timeout = %d, want the 60s floor", got)
+	}
+
+	mid := ds.computeQueryTimeout(z10, 10)
+	if mid <= 60 || mid >= 180 {
+		t.Errorf("z10 timeout = %d, want between floor and cap", mid)
+	}
+
+	if got := ds.computeQueryTimeout(z5, 5); got != 180 {
+		t.Errorf("z5 timeout = %d, want the 180s cap", got)
+	}
+
+	custom := NewOverpassDataSourceWithConfig(OverpassConfig{
+		MinQueryTimeout: 30 * time.Second,
+		MaxQueryTimeout: 90 * time.Second,
+	})
+	if got := custom.computeQueryTimeout(hanover, 13); got != 30 {
+		t.Errorf("custom z13 timeout = %d, want 30", got)
+	}
+	if got := custom.computeQueryTimeout(z5, 5); got != 90 {
+		t.Errorf("custom z5 timeout = %d, want 90", got)
+	}
+
+	query := ds.buildTileQuery(z5, 5)
+	if !strings.HasPrefix(query, "[out:json][timeout:180];") {
+		t.Errorf("query header not adapted: %q", query[:40])
+	}
+}
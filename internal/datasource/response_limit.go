@@ -0,0 +1,61 @@
+package datasource
+
+import (
+	"io"
+	"net/http"
+	"sync/atomic"
+)
+
+// responseLimitTransport stops reading Overpass responses once they exceed the size limit set
+// with OverpassDataSource.WithMaxDataSize. The Overpass client buffers the whole response
+// before parsing it, so without this an oversized tile would be held in memory in full before
+// its data size could be checked.
+type responseLimitTransport struct {
+	next  http.RoundTripper
+	limit atomic.Int64 // bytes; 0 = unlimited
+}
+
+func newResponseLimitTransport(next http.RoundTripper) *responseLimitTransport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &responseLimitTransport{next: next}
+}
+
+// setLimit sets the limit in bytes for later responses; 0 or less disables it.
+func (t *responseLimitTransport) setLimit(limitBytes int64) {
+	if t != nil {
+		t.limit.Store(max(limitBytes, 0))
+	}
+}
+
+func (t *responseLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	limit := t.limit.Load()
+	if err != nil || limit <= 0 {
+		return resp, err
+	}
+	if resp.ContentLength > limit {
+		resp.Body.Close() // nolint:errcheck
+		return nil, &DataSizeError{SizeBytes: resp.ContentLength, LimitBytes: limit}
+	}
+	resp.Body = &limitedBody{ReadCloser: resp.Body, r: io.LimitReader(resp.Body, limit+1), limit: limit}
+	return resp, nil
+}
+
+// limitedBody fails with a DataSizeError as soon as more than limit bytes have been read.
+type limitedBody struct {
+	io.ReadCloser
+	r     io.Reader // the body limited to limit+1 bytes
+	read  int64
+	limit int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	n, err := b.r.Read(p)
+	b.read += int64(n)
+	if b.read > b.limit {
+		return n, &DataSizeError{SizeBytes: b.read, LimitBytes: b.limit, Partial: true}
+	}
+	return n, err
+}
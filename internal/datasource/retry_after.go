@@ -0,0 +1,70 @@
+package datasource
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// retryAfterTransport records the Retry-After header of rate-limited (429) and unavailable
+// (503) Overpass responses. The Overpass client only returns the status code of failed
+// queries, so this is how the requested delay reaches FetchError.RetryAfter. Overpass rate
+// limits per client rather than per query, so the latest request applies to every query.
+type retryAfterTransport struct {
+	next http.RoundTripper
+	now  func() time.Time
+
+	mu    sync.Mutex
+	until time.Time
+}
+
+func newRetryAfterTransport(next http.RoundTripper) *retryAfterTransport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &retryAfterTransport{next: next, now: time.Now}
+}
+
+func (t *retryAfterTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil || (resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable) {
+		return resp, err
+	}
+	now := t.now()
+	if delay, ok := parseRetryAfter(resp.Header.Get("Retry-After"), now); ok {
+		t.mu.Lock()
+		t.until = now.Add(delay)
+		t.mu.Unlock()
+	}
+	return resp, nil
+}
+
+// remaining returns how much longer the server asked clients to wait, or 0.
+func (t *retryAfterTransport) remaining() time.Duration {
+	if t == nil {
+		return 0
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return max(t.until.Sub(t.now()), 0)
+}
+
+// parseRetryAfter parses a Retry-After header value: a number of seconds or an HTTP date.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(at.Sub(now), 0), true
+	}
+	return 0, false
+}
@@ -0,0 +1,64 @@
+package datasource
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/MeKo-Christian/go-overpass"
+	"github.com/MeKo-Tech/watercolormap/internal/types"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{"120", 2 * time.Minute, true},
+		{" 0 ", 0, true},
+		{"Sun, 01 Jun 2025 12:00:30 GMT", 30 * time.Second, true},
+		{"Sun, 01 Jun 2025 11:00:00 GMT", 0, true}, // In the past
+		{"", 0, false},
+		{"-5", 0, false},
+		{"soon", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseRetryAfter(tt.value, now)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("parseRetryAfter(%q) = %v, %v; want %v, %v", tt.value, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+// TestFetchErrorRetryAfter fetches from a stub Overpass server that rate limits every query
+// and checks that the requested delay reaches the FetchError.
+func TestFetchErrorRetryAfter(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "120")
+		http.Error(w, "rate limited", http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	ds := NewOverpassDataSourceWithConfig(OverpassConfig{
+		Endpoint:    srv.URL,
+		HTTPClient:  srv.Client(),
+		RetryConfig: &overpass.RetryConfig{MaxRetries: 0},
+	})
+	_, err := ds.FetchTileData(context.Background(), types.TileCoordinate{Zoom: 13, X: 4317, Y: 2692})
+
+	var fetchErr *FetchError
+	if !errors.As(err, &fetchErr) {
+		t.Fatalf("expected a *FetchError, got %T: %v", err, err)
+	}
+	if fetchErr.StatusCode != http.StatusTooManyRequests || !fetchErr.Transient {
+		t.Errorf("StatusCode = %d, Transient = %v; want 429, true", fetchErr.StatusCode, fetchErr.Transient)
+	}
+	if fetchErr.RetryAfter <= 110*time.Second || fetchErr.RetryAfter > 120*time.Second {
+		t.Errorf("RetryAfter = %v, want about 2m", fetchErr.RetryAfter)
+	}
+}
@@ -0,0 +1,301 @@
+package datasource
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/MeKo-Tech/watercolormap/internal/geom"
+	"github.com/MeKo-Tech/watercolormap/internal/types"
+	"github.com/paulmach/orb"
+)
+
+// zoomRule is one tag filter of the zoom-dependent Overpass query. The same rules drive the
+// query (buildTileQuery) and the client-side FilterFeaturesForZoom, so data fetched for a
+// higher zoom can be thinned out to exactly what a lower zoom would have fetched.
+type zoomRule struct {
+	minZoom   int    // Lowest zoom the rule applies at
+	maxZoom   int    // Highest zoom the rule applies at (0 = no upper bound)
+	key       string // OSM tag key
+	value     string // Tag value ("" = any value)
+	regex     bool   // value is an Overpass regular expression (unanchored, like ["key"~"value"])
+	relations bool   // Also fetch relations, not only ways
+}
+
+// activeAt reports whether the rule is part of the query at zoom.
+func (r zoomRule) activeAt(zoom int) bool {
+	return zoom >= r.minZoom && (r.maxZoom == 0 || zoom <= r.maxZoom)
+}
+
+// filter returns the Overpass tag filter, e.g. ["waterway"~"river|stream"].
+func (r zoomRule) filter() string {
+	switch {
+	case r.value == "":
+		return fmt.Sprintf("[%q]", r.key)
+	case r.regex:
+		return fmt.Sprintf("[%q~%q]", r.key, r.value)
+	default:
+		return fmt.Sprintf("[%q=%q]", r.key, r.value)
+	}
+}
+
+// matches reports whether the query part of r would have fetched f.
+func (r zoomRule) matches(f types.Feature) bool {
+	if strings.HasPrefix(f.ID, "relation/") && !r.relations {
+		return false
+	}
+	v, ok := f.Properties[r.key].(string)
+	if !ok {
+		return false
+	}
+	switch {
+	case r.value == "":
+		return true
+	case r.regex:
+		return zoomRuleRegexp(r.value).MatchString(v)
+	default:
+		return v == r.value
+	}
+}
+
+var zoomRuleRegexps = map[string]*regexp.Regexp{}
+
+// zoomRuleRegexp returns the compiled value pattern of a regex rule. All patterns are
+// compiled at init, so lookups never write to the map.
+func zoomRuleRegexp(pattern string) *regexp.Regexp {
+	return zoomRuleRegexps[pattern]
+}
+
+func init() {
+	for _, rules := range allZoomRules {
+		for _, r := range rules {
+			if r.regex {
+				zoomRuleRegexps[r.value] = regexp.MustCompile(r.value)
+			}
+		}
+	}
+}
+
+// zoomRulesQueryParts returns the Overpass query parts of the rules active at zoom.
+func zoomRulesQueryParts(rules []zoomRule, bbox string, zoom int) []string {
+	var parts []string
+	for _, r := range rules {
+		if !r.activeAt(zoom) {
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("way%s(%s);", r.filter(), bbox))
+		if r.relations {
+			parts = append(parts, fmt.Sprintf("relation%s(%s);", r.filter(), bbox))
+		}
+	}
+	return parts
+}
+
+// Road classes by the zoom they appear at.
+const (
+	roadsMotorway  = "motorway|motorway_link"
+	roadsMajor     = roadsMotorway + "|trunk|trunk_link|primary|primary_link"
+	roadsSecondary = roadsMajor + "|secondary|secondary_link|tertiary|tertiary_link"
+	roadsMinor     = roadsSecondary + "|residential|unclassified|living_street"
+)
+
+var (
+	// Coastlines and water bodies at all zooms; rivers and waterways progressively from z10.
+	// NOTE: OSM does NOT include ocean polygons in raw data. Ocean is represented
+	// as "absence of land". This causes ocean tiles to render as land (tan background).
+	// See PLAN.md section 4.10 for ocean rendering solutions (water polygons or synthesis).
+	waterZoomRules = []zoomRule{
+		{key: "natural", value: "water", relations: true},
+		{key: "natural", value: "coastline"},
+		{minZoom: 10, maxZoom: 11, key: "waterway", value: "river", relations: true},                           // Major rivers only
+		{minZoom: 12, maxZoom: 13, key: "waterway", value: "river|stream|canal", regex: true, relations: true}, // No drains/ditches
+		{minZoom: 14, key: "waterway", relations: true},                                                        // All waterways
+	}
+
+	// Forests and woods at all zooms (major geographic features like water); smaller green
+	// spaces progressively from z8.
+	parksZoomRules = []zoomRule{
+		{key: "landuse", value: "forest", relations: true},
+		{key: "natural", value: "wood", relations: true},
+		// z8+: parks, nature reserves, and heath (like Lüneburger Heide)
+		{minZoom: 8, key: "leisure", value: "park", relations: true},
+		{minZoom: 8, key: "leisure", value: "nature_reserve", relations: true},
+		{minZoom: 8, key: "natural", value: "heath", relations: true},
+		// z10+: meadows, grass, and farmland
+		{minZoom: 10, key: "landuse", value: "grass"},
+		{minZoom: 10, key: "landuse", value: "meadow"},
+		{minZoom: 10, key: "landuse", value: "farmland"},
+		{minZoom: 10, key: "natural", value: "grassland"},
+		// z14+: gardens and orchards
+		{minZoom: 14, key: "leisure", value: "garden"},
+		{minZoom: 14, key: "landuse", value: "orchard"},
+		{minZoom: 14, key: "landuse", value: "vineyard"},
+		// z16+: playgrounds and allotments
+		{minZoom: 16, key: "leisure", value: "playground"},
+		{minZoom: 16, key: "landuse", value: "allotments"},
+	}
+
+	// No roads below z5; more road classes with every few zoom levels.
+	roadsZoomRules = []zoomRule{
+		{minZoom: 5, maxZoom: 7, key: "highway", value: roadsMotorway, regex: true},    // Overview
+		{minZoom: 8, maxZoom: 11, key: "highway", value: roadsMajor, regex: true},      // Visible at low zoom
+		{minZoom: 12, maxZoom: 13, key: "highway", value: roadsSecondary, regex: true}, // + secondary/tertiary
+		{minZoom: 14, maxZoom: 15, key: "highway", value: roadsMinor, regex: true},     // + residential (no service, track, path, ...)
+		{minZoom: 16, key: "highway"}, // All roads
+	}
+
+	// Urban landuse from z11 to identify towns and cities, civic buildings from z14 and
+	// individual buildings from z16.
+	buildingsZoomRules = []zoomRule{
+		{minZoom: 11, key: "landuse", value: "residential", relations: true},
+		{minZoom: 11, key: "landuse", value: "commercial", relations: true},
+		{minZoom: 11, key: "landuse", value: "industrial", relations: true},
+		{minZoom: 11, key: "landuse", value: "retail", relations: true},
+		{minZoom: 14, key: "amenity", value: "school"},
+		{minZoom: 14, key: "amenity", value: "hospital"},
+		{minZoom: 14, key: "amenity", value: "university"},
+		{minZoom: 16, key: "building"},
+	}
+
+	allZoomRules = [][]zoomRule{waterZoomRules, parksZoomRules, roadsZoomRules, buildingsZoomRules}
+)
+
+// FeatureVisibleAtZoom reports whether the tile query for zoom would have fetched f. Features
+// that match no built-in rule at any zoom (e.g. tags only a custom classification asks for,
+// which are fetched at all zooms) are always visible.
+func FeatureVisibleAtZoom(f types.Feature, zoom int) bool {
+	matchedAny := false
+	for _, rules := range allZoomRules {
+		for _, r := range rules {
+			if !r.matches(f) {
+				continue
+			}
+			if r.activeAt(zoom) {
+				return true
+			}
+			matchedAny = true
+		}
+	}
+	return !matchedAny
+}
+
+// FilterFeaturesForZoom applies the zoom-dependent feature selection of the Overpass query
+// client-side: it returns the features of fc that a fetch at zoom would have returned, so data
+// fetched once at a high zoom can be reused for lower ones. Land polygons are kept as is.
+func FilterFeaturesForZoom(fc types.FeatureCollection, zoom int) types.FeatureCollection {
+	filter := func(features []types.Feature) []types.Feature {
+		var out []types.Feature
+		for _, f := range features {
+			if FeatureVisibleAtZoom(f, zoom) {
+				out = append(out, f)
+			}
+		}
+		return out
+	}
+	return types.FeatureCollection{
+		Water:     filter(fc.Water),
+		Rivers:    filter(fc.Rivers),
+		Parks:     filter(fc.Parks),
+		Roads:     filter(fc.Roads),
+		Buildings: filter(fc.Buildings),
+		Urban:     filter(fc.Urban),
+		Land:      fc.Land,
+	}
+}
+
+// clipMargin is how far beyond the requested bounds clipFeaturesToBounds cuts geometry, as a
+// fraction of their size on each side. Clipped polygons gain edges along the cut, so it sits
+// outside the rendered area and Mapnik's buffer (renderer.DefaultBufferPx, half a 256 px
+// tile), where no outline drawn along the cut can show.
+const clipMargin = 0.5
+
+// clipFeaturesToBounds clips the geometry of the features of fc to bounds grown by
+// clipMargin, dropping features that lie entirely outside. Data fetched for a larger area
+// (a cached column, a whole extract) thus only hands the renderer the geometry near the tile.
+func clipFeaturesToBounds(fc types.FeatureCollection, bounds types.BoundingBox) types.FeatureCollection {
+	clipBox := bounds.ExpandByFraction(clipMargin)
+	box := orb.Bound{Min: orb.Point{clipBox.MinLon, clipBox.MinLat}, Max: orb.Point{clipBox.MaxLon, clipBox.MaxLat}}
+	clip := func(features []types.Feature) []types.Feature {
+		var out []types.Feature
+		for _, f := range features {
+			if f.Geometry == nil {
+				out = append(out, f)
+				continue
+			}
+			bound := f.Geometry.Bound()
+			if !bound.Intersects(box) {
+				continue
+			}
+			if box.Contains(bound.Min) && box.Contains(bound.Max) {
+				out = append(out, f)
+				continue
+			}
+			if g := clipGeometry(f.Geometry, clipBox); g != nil {
+				f.Geometry = g
+				out = append(out, f)
+			}
+		}
+		return out
+	}
+	return types.FeatureCollection{
+		Water:     clip(fc.Water),
+		Rivers:    clip(fc.Rivers),
+		Parks:     clip(fc.Parks),
+		Roads:     clip(fc.Roads),
+		Buildings: clip(fc.Buildings),
+		Urban:     clip(fc.Urban),
+		Land:      clip(fc.Land),
+	}
+}
+
+// clipGeometry clips g to b with the geom helpers, or returns nil when nothing of it lies
+// inside. Lines split by the box become multi-lines; geometry types without a clipper are
+// returned unchanged.
+func clipGeometry(g orb.Geometry, b types.BoundingBox) orb.Geometry {
+	switch g := g.(type) {
+	case orb.Point:
+		if !b.Contains(g[0], g[1]) {
+			return nil
+		}
+		return g
+	case orb.Polygon:
+		if p := geom.ClipPolygon(g, b); p != nil {
+			return p
+		}
+		return nil
+	case orb.MultiPolygon:
+		var out orb.MultiPolygon
+		for _, p := range g {
+			if c := geom.ClipPolygon(p, b); c != nil {
+				out = append(out, c)
+			}
+		}
+		if len(out) == 0 {
+			return nil
+		}
+		return out
+	case orb.LineString:
+		return clippedLines(geom.ClipLineString(g, b))
+	case orb.MultiLineString:
+		var out orb.MultiLineString
+		for _, ls := range g {
+			out = append(out, geom.ClipLineString(ls, b)...)
+		}
+		return clippedLines(out)
+	default:
+		return g
+	}
+}
+
+// clippedLines returns the parts of a clipped line as a single LineString when there is one,
+// a MultiLineString when there are several, and nil when there are none.
+func clippedLines(parts orb.MultiLineString) orb.Geometry {
+	switch len(parts) {
+	case 0:
+		return nil
+	case 1:
+		return parts[0]
+	default:
+		return parts
+	}
+}
@@ -0,0 +1,134 @@
+package datasource
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/MeKo-Tech/watercolormap/internal/types"
+	"github.com/paulmach/orb"
+)
+
+func tagged(id string, tags map[string]interface{}) types.Feature {
+	return types.Feature{ID: id, Properties: tags}
+}
+
+func TestFeatureVisibleAtZoom(t *testing.T) {
+	tests := []struct {
+		name    string
+		feature types.Feature
+		zoom    int
+		want    bool
+	}{
+		{"lake at z3", tagged("way/1", map[string]interface{}{"natural": "water"}), 3, true},
+		{"lake relation at z3", tagged("relation/1", map[string]interface{}{"natural": "water"}), 3, true},
+		{"river below z10", tagged("way/3", map[string]interface{}{"waterway": "river"}), 9, false},
+		{"river at z10", tagged("way/3", map[string]interface{}{"waterway": "river"}), 10, true},
+		{"stream at z11", tagged("way/4", map[string]interface{}{"waterway": "stream"}), 11, false},
+		{"stream at z12", tagged("way/4", map[string]interface{}{"waterway": "stream"}), 12, true},
+		{"ditch at z13", tagged("way/5", map[string]interface{}{"waterway": "ditch"}), 13, false},
+		{"ditch at z14", tagged("way/5", map[string]interface{}{"waterway": "ditch"}), 14, true},
+		{"motorway at z4", tagged("way/6", map[string]interface{}{"highway": "motorway"}), 4, false},
+		{"motorway at z5", tagged("way/6", map[string]interface{}{"highway": "motorway"}), 5, true},
+		{"primary at z7", tagged("way/7", map[string]interface{}{"highway": "primary"}), 7, false},
+		{"primary at z8", tagged("way/7", map[string]interface{}{"highway": "primary"}), 8, true},
+		{"residential at z13", tagged("way/8", map[string]interface{}{"highway": "residential"}), 13, false},
+		{"residential at z14", tagged("way/8", map[string]interface{}{"highway": "residential"}), 14, true},
+		{"footway at z15", tagged("way/9", map[string]interface{}{"highway": "footway"}), 15, false},
+		{"footway at z16", tagged("way/9", map[string]interface{}{"highway": "footway"}), 16, true},
+		{"building at z15", tagged("way/10", map[string]interface{}{"building": "yes"}), 15, false},
+		{"building at z16", tagged("way/10", map[string]interface{}{"building": "yes"}), 16, true},
+		{"custom tag at z3", tagged("way/12", map[string]interface{}{"natural": "wetland"}), 3, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FeatureVisibleAtZoom(tt.feature, tt.zoom); got != tt.want {
+				t.Errorf("FeatureVisibleAtZoom = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestZoomRulesMatchQuery checks that each rule's filter appears in the tile query exactly at
+// the zooms the client-side filter considers it active.
+func TestZoomRulesMatchQuery(t *testing.T) {
+	ds := NewOverpassDataSource("")
+	bounds := types.BoundingBox{MinLat: 52, MinLon: 9, MaxLat: 52.1, MaxLon: 9.1}
+	for zoom := 0; zoom <= 18; zoom++ {
+		query := ds.buildTileQuery(bounds, zoom)
+		for _, rules := range allZoomRules {
+			for _, r := range rules {
+				inQuery := strings.Contains(query, "way"+r.filter()+"(")
+				if inQuery != r.activeAt(zoom) {
+					t.Errorf("z%d: rule %s in query = %v, active = %v", zoom, r.filter(), inQuery, r.activeAt(zoom))
+				}
+			}
+		}
+	}
+}
+
+func TestFilterFeaturesForZoom(t *testing.T) {
+	fc := types.FeatureCollection{
+		Water:     []types.Feature{tagged("way/1", map[string]interface{}{"natural": "water"})},
+		Rivers:    []types.Feature{tagged("way/2", map[string]interface{}{"waterway": "stream"})},
+		Roads:     []types.Feature{tagged("way/3", map[string]interface{}{"highway": "motorway"}), tagged("way/4", map[string]interface{}{"highway": "residential"})},
+		Buildings: []types.Feature{tagged("way/5", map[string]interface{}{"building": "yes"})},
+		Land:      []types.Feature{{ID: "land/1"}},
+	}
+
+	got := FilterFeaturesForZoom(fc, 10)
+	if len(got.Water) != 1 || len(got.Rivers) != 0 || len(got.Roads) != 1 || len(got.Buildings) != 0 || len(got.Land) != 1 {
+		t.Errorf("unexpected z10 features: %+v", got.FeatureCounts())
+	}
+	if got.Roads[0].ID != "way/3" {
+		t.Errorf("expected the motorway to be kept, got %s", got.Roads[0].ID)
+	}
+	if all := FilterFeaturesForZoom(fc, 16); all.Count() != fc.Count() || len(all.Rivers) != 1 {
+		t.Errorf("expected all features at z16, got %+v", all.FeatureCounts())
+	}
+}
+
+func TestClipFeaturesToBounds(t *testing.T) {
+	// Clipped at bounds grown by clipMargin: [-0.5, 1.5] on both axes
+	bounds := types.BoundingBox{MinLon: 0, MinLat: 0, MaxLon: 1, MaxLat: 1}
+	square := func(min, max float64) orb.Polygon {
+		return orb.Polygon{{{min, min}, {max, min}, {max, max}, {min, max}, {min, min}}}
+	}
+
+	fc := types.FeatureCollection{
+		Water: []types.Feature{
+			{ID: "inside", Geometry: square(0.2, 0.8)},
+			{ID: "overlapping", Geometry: square(-10, 10)},
+			{ID: "outside", Geometry: square(5, 6)},
+		},
+		Roads: []types.Feature{
+			// Leaves the clip box and comes back, so it is split in two
+			{ID: "through", Geometry: orb.LineString{{-5, 0.5}, {1, 0.5}, {1, 5}, {0.5, 5}, {0.5, -5}}},
+			{ID: "far", Geometry: orb.LineString{{5, 5}, {6, 6}}},
+		},
+		Land: []types.Feature{{ID: "no geometry"}},
+	}
+
+	got := clipFeaturesToBounds(fc, bounds)
+	if len(got.Water) != 2 || len(got.Roads) != 1 || len(got.Land) != 1 {
+		t.Fatalf("unexpected features after clipping: %+v", got.FeatureCounts())
+	}
+
+	if got.Water[0].Geometry.Bound() != square(0.2, 0.8).Bound() {
+		t.Errorf("feature inside the box changed: %v", got.Water[0].Geometry.Bound())
+	}
+	wantBound := orb.Bound{Min: orb.Point{-0.5, -0.5}, Max: orb.Point{1.5, 1.5}}
+	if b := got.Water[1].Geometry.Bound(); b != wantBound {
+		t.Errorf("overlapping polygon clipped to %v, want %v", b, wantBound)
+	}
+	lines, ok := got.Roads[0].Geometry.(orb.MultiLineString)
+	if !ok || len(lines) != 2 {
+		t.Fatalf("expected the road to be split into 2 parts, got %#v", got.Roads[0].Geometry)
+	}
+	if b := lines.Bound(); !wantBound.Contains(b.Min) || !wantBound.Contains(b.Max) {
+		t.Errorf("road parts reach beyond the clip box: %v", b)
+	}
+	if fc.Water[1].Geometry.Bound().Max[0] != 10 {
+		t.Error("clipping modified the input features")
+	}
+}
@@ -16,6 +16,7 @@ const (
 	LayerRivers    LayerType = "rivers" // Linear waterways (rivers, streams, canals)
 	LayerLand      LayerType = "land"
 	LayerParks     LayerType = "parks"
+	LayerForest    LayerType = "forest"    // Forests and woods; a subset of parks painted over them
 	LayerUrban     LayerType = "urban"     // Urban landuse areas and urban buildings
 	LayerBuildings LayerType = "buildings" // Individual building footprints
 	LayerRoads     LayerType = "roads"
@@ -75,6 +76,36 @@ func ToGeoJSONBytes(features []types.Feature) ([]byte, error) {
 	return data, nil
 }
 
+// CollectionToGeoJSON converts all features of fc to a single GeoJSON FeatureCollection.
+// Each feature carries a "collection" property naming the group it came from (water, rivers,
+// parks, roads, buildings, urban, land), so the grouping survives a round trip.
+func CollectionToGeoJSON(fc types.FeatureCollection) (*geojson.FeatureCollection, error) {
+	out := geojson.NewFeatureCollection()
+	groups := []struct {
+		name     string
+		features []types.Feature
+	}{
+		{"water", fc.Water},
+		{"rivers", fc.Rivers},
+		{"parks", fc.Parks},
+		{"roads", fc.Roads},
+		{"buildings", fc.Buildings},
+		{"urban", fc.Urban},
+		{"land", fc.Land},
+	}
+	for _, group := range groups {
+		gfc, err := ToGeoJSON(group.features)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert %s: %w", group.name, err)
+		}
+		for _, f := range gfc.Features {
+			f.Properties["collection"] = group.name
+			out.Append(f)
+		}
+	}
+	return out, nil
+}
+
 // GetLayerFeatures returns features for a specific layer from FeatureCollection
 func GetLayerFeatures(fc types.FeatureCollection, layer LayerType) []types.Feature {
 	switch layer {
@@ -84,6 +115,16 @@ func GetLayerFeatures(fc types.FeatureCollection, layer LayerType) []types.Featu
 		return fc.Rivers
 	case LayerParks:
 		return fc.Parks
+	case LayerForest:
+		// Forests are derived from the parks feature set, like highways from roads, so the
+		// parks layer still covers all greens when no forest style is configured.
+		out := make([]types.Feature, 0, len(fc.Parks))
+		for _, f := range fc.Parks {
+			if IsForest(f) {
+				out = append(out, f)
+			}
+		}
+		return out
 	case LayerUrban:
 		// Return urban landuse areas and urban buildings (not individual building footprints)
 		return fc.Urban
@@ -111,6 +152,14 @@ func GetLayerFeatures(fc types.FeatureCollection, layer LayerType) []types.Featu
 	}
 }
 
+// IsForest reports whether a green feature is a forest or wood (landuse=forest, natural=wood)
+// rather than a park, meadow or other open green space.
+func IsForest(f types.Feature) bool {
+	landuse, _ := f.Properties["landuse"].(string)
+	natural, _ := f.Properties["natural"].(string)
+	return landuse == "forest" || natural == "wood"
+}
+
 // LayerCount returns the number of features in a layer
 func LayerCount(fc types.FeatureCollection, layer LayerType) int {
 	return len(GetLayerFeatures(fc, layer))
@@ -0,0 +1,195 @@
+// Package geom provides small geometry helpers, such as clipping features to tile bounds and
+// reprojecting input coordinates to WGS84.
+//
+// Coordinates are treated as planar lon/lat pairs (orb.Point{lon, lat}); clipping against a
+// bounding box is exact in both WGS84 and Web Mercator because box edges are axis-aligned.
+package geom
+
+import (
+	"github.com/MeKo-Tech/watercolormap/internal/types"
+	"github.com/paulmach/orb"
+)
+
+// edge identifies one side of a bounding box for Sutherland–Hodgman clipping.
+type edge int
+
+const (
+	edgeWest edge = iota
+	edgeEast
+	edgeSouth
+	edgeNorth
+)
+
+// inside reports whether p lies on the inner side of edge e of b.
+func inside(p orb.Point, e edge, b types.BoundingBox) bool {
+	switch e {
+	case edgeWest:
+		return p[0] >= b.MinLon
+	case edgeEast:
+		return p[0] <= b.MaxLon
+	case edgeSouth:
+		return p[1] >= b.MinLat
+	default:
+		return p[1] <= b.MaxLat
+	}
+}
+
+// intersect returns the point where segment a-b crosses edge e of box.
+// The caller guarantees that a and b lie on opposite sides of the edge.
+func intersect(a, b orb.Point, e edge, box types.BoundingBox) orb.Point {
+	switch e {
+	case edgeWest, edgeEast:
+		x := box.MinLon
+		if e == edgeEast {
+			x = box.MaxLon
+		}
+		t := (x - a[0]) / (b[0] - a[0])
+		return orb.Point{x, a[1] + t*(b[1]-a[1])}
+	default:
+		y := box.MinLat
+		if e == edgeNorth {
+			y = box.MaxLat
+		}
+		t := (y - a[1]) / (b[1] - a[1])
+		return orb.Point{a[0] + t*(b[0]-a[0]), y}
+	}
+}
+
+// ClipRing clips a ring to b using the Sutherland–Hodgman algorithm. The result is closed
+// (first point repeated at the end) and nil when nothing of the ring lies inside b.
+// Parts of a concave ring that leave and re-enter the box are joined along the box edge.
+func ClipRing(r orb.Ring, b types.BoundingBox) orb.Ring {
+	pts := []orb.Point(r)
+	if len(pts) > 1 && pts[0] == pts[len(pts)-1] {
+		pts = pts[:len(pts)-1]
+	}
+
+	for e := edgeWest; e <= edgeNorth && len(pts) > 0; e++ {
+		in := pts
+		pts = make([]orb.Point, 0, len(in)+4)
+		prev := in[len(in)-1]
+		for _, cur := range in {
+			curIn, prevIn := inside(cur, e, b), inside(prev, e, b)
+			switch {
+			case curIn && prevIn:
+				pts = append(pts, cur)
+			case curIn:
+				pts = append(pts, intersect(prev, cur, e, b), cur)
+			case prevIn:
+				pts = append(pts, intersect(prev, cur, e, b))
+			}
+			prev = cur
+		}
+	}
+
+	// Vertices on a box edge produce duplicate points
+	deduped := pts[:0]
+	for i, p := range pts {
+		if i == 0 || p != deduped[len(deduped)-1] {
+			deduped = append(deduped, p)
+		}
+	}
+	for len(deduped) > 1 && deduped[0] == deduped[len(deduped)-1] {
+		deduped = deduped[:len(deduped)-1]
+	}
+
+	if len(deduped) < 3 {
+		return nil
+	}
+	return append(orb.Ring(deduped), deduped[0])
+}
+
+// ClipPolygon clips every ring of p to b. It returns nil when the outer ring lies entirely
+// outside b; holes that fall outside b are dropped.
+func ClipPolygon(p orb.Polygon, b types.BoundingBox) orb.Polygon {
+	if len(p) == 0 {
+		return nil
+	}
+	outer := ClipRing(p[0], b)
+	if outer == nil {
+		return nil
+	}
+
+	clipped := orb.Polygon{outer}
+	for _, hole := range p[1:] {
+		if r := ClipRing(hole, b); r != nil {
+			clipped = append(clipped, r)
+		}
+	}
+	return clipped
+}
+
+// ClipLineString clips ls to b using the Liang–Barsky algorithm. A line that leaves and
+// re-enters the box is split, so the result may hold several parts; it is nil when no part
+// of the line lies inside b.
+func ClipLineString(ls orb.LineString, b types.BoundingBox) orb.MultiLineString {
+	var out orb.MultiLineString
+	var cur orb.LineString
+
+	for i := 1; i < len(ls); i++ {
+		a, c, ok := clipSegment(ls[i-1], ls[i], b)
+		if !ok {
+			if len(cur) > 1 {
+				out = append(out, cur)
+			}
+			cur = nil
+			continue
+		}
+		if len(cur) == 0 || cur[len(cur)-1] != a {
+			if len(cur) > 1 {
+				out = append(out, cur)
+			}
+			cur = orb.LineString{a}
+		}
+		cur = append(cur, c)
+	}
+	if len(cur) > 1 {
+		out = append(out, cur)
+	}
+	return out
+}
+
+// clipSegment returns the part of segment p-q inside b, or ok=false if there is none.
+func clipSegment(p, q orb.Point, b types.BoundingBox) (orb.Point, orb.Point, bool) {
+	dx, dy := q[0]-p[0], q[1]-p[1]
+	t0, t1 := 0.0, 1.0
+
+	// Each (denominator, numerator) pair bounds t for one box edge
+	checks := [4][2]float64{
+		{-dx, p[0] - b.MinLon},
+		{dx, b.MaxLon - p[0]},
+		{-dy, p[1] - b.MinLat},
+		{dy, b.MaxLat - p[1]},
+	}
+	for _, c := range checks {
+		den, num := c[0], c[1]
+		if den == 0 {
+			if num < 0 {
+				return orb.Point{}, orb.Point{}, false // parallel to and outside this edge
+			}
+			continue
+		}
+		t := num / den
+		if den < 0 {
+			if t > t1 {
+				return orb.Point{}, orb.Point{}, false
+			}
+			t0 = max(t0, t)
+		} else {
+			if t < t0 {
+				return orb.Point{}, orb.Point{}, false
+			}
+			t1 = min(t1, t)
+		}
+	}
+
+	start := orb.Point{p[0] + t0*dx, p[1] + t0*dy}
+	end := orb.Point{p[0] + t1*dx, p[1] + t1*dy}
+	if t0 == 0 {
+		start = p
+	}
+	if t1 == 1 {
+		end = q
+	}
+	return start, end, true
+}
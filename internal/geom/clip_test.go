@@ -0,0 +1,105 @@
+package geom
+
+import (
+	"testing"
+
+	"github.com/MeKo-Tech/watercolormap/internal/types"
+	"github.com/paulmach/orb"
+)
+
+var unitBox = types.BoundingBox{MinLon: 0, MinLat: 0, MaxLon: 10, MaxLat: 10}
+
+func TestClipPolygon(t *testing.T) {
+	tests := []struct {
+		name string
+		poly orb.Polygon
+		want orb.Polygon
+	}{
+		{
+			name: "inside unchanged",
+			poly: orb.Polygon{{{2, 2}, {8, 2}, {8, 8}, {2, 8}, {2, 2}}},
+			want: orb.Polygon{{{2, 2}, {8, 2}, {8, 8}, {2, 8}, {2, 2}}},
+		},
+		{
+			name: "overlapping east edge",
+			poly: orb.Polygon{{{5, 2}, {15, 2}, {15, 8}, {5, 8}, {5, 2}}},
+			want: orb.Polygon{{{5, 2}, {10, 2}, {10, 8}, {5, 8}, {5, 2}}},
+		},
+		{
+			name: "covering the box",
+			poly: orb.Polygon{{{-5, -5}, {15, -5}, {15, 15}, {-5, 15}, {-5, -5}}},
+			want: orb.Polygon{{{0, 10}, {0, 0}, {10, 0}, {10, 10}, {0, 10}}},
+		},
+		{
+			name: "outside",
+			poly: orb.Polygon{{{20, 20}, {30, 20}, {30, 30}, {20, 20}}},
+			want: nil,
+		},
+		{
+			name: "hole outside dropped",
+			poly: orb.Polygon{
+				{{-5, 2}, {5, 2}, {5, 8}, {-5, 8}, {-5, 2}},
+				{{-4, 4}, {-2, 4}, {-2, 6}, {-4, 4}},
+			},
+			want: orb.Polygon{{{0, 2}, {5, 2}, {5, 8}, {0, 8}, {0, 2}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ClipPolygon(tt.poly, unitBox)
+			if !got.Equal(tt.want) && !(got == nil && tt.want == nil) {
+				t.Errorf("ClipPolygon() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClipRingTriangleCorner(t *testing.T) {
+	// A triangle poking out of the north-east corner is cut back to the box corner
+	ring := orb.Ring{{6, 6}, {14, 6}, {6, 14}, {6, 6}}
+	got := ClipRing(ring, unitBox)
+
+	want := orb.Ring{{6, 10}, {6, 6}, {10, 6}, {10, 10}, {6, 10}}
+	if !got.Equal(want) {
+		t.Errorf("ClipRing() = %v, want %v", got, want)
+	}
+}
+
+func TestClipLineString(t *testing.T) {
+	tests := []struct {
+		name string
+		line orb.LineString
+		want orb.MultiLineString
+	}{
+		{
+			name: "inside",
+			line: orb.LineString{{1, 1}, {5, 5}, {9, 1}},
+			want: orb.MultiLineString{{{1, 1}, {5, 5}, {9, 1}}},
+		},
+		{
+			name: "crossing",
+			line: orb.LineString{{-5, 5}, {15, 5}},
+			want: orb.MultiLineString{{{0, 5}, {10, 5}}},
+		},
+		{
+			name: "leaving and re-entering",
+			line: orb.LineString{{5, 5}, {15, 5}, {15, 8}, {5, 8}},
+			want: orb.MultiLineString{{{5, 5}, {10, 5}}, {{10, 8}, {5, 8}}},
+		},
+		{
+			name: "outside",
+			line: orb.LineString{{-5, -5}, {-1, 20}},
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ClipLineString(tt.line, unitBox)
+			if !got.Equal(tt.want) && !(got == nil && tt.want == nil) {
+				t.Errorf("ClipLineString() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package geom
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+
+	"github.com/MeKo-Tech/watercolormap/internal/tile"
+	"github.com/MeKo-Tech/watercolormap/internal/types"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/project"
+)
+
+// Reprojector converts coordinates between a source CRS and WGS84 lon/lat (EPSG:4326), the
+// CRS features are extracted and rendered in. Implementations must be safe for concurrent use.
+type Reprojector interface {
+	// ToWGS84 converts a point of the source CRS to {lon, lat}.
+	ToWGS84(p orb.Point) orb.Point
+	// FromWGS84 converts {lon, lat} to the source CRS.
+	FromWGS84(p orb.Point) orb.Point
+}
+
+// maxMercatorLat is the latitude at which Web Mercator reaches the square world extent.
+const maxMercatorLat = 85.05112877980659
+
+// Identity is the Reprojector of data already in WGS84 (EPSG:4326).
+type Identity struct{}
+
+func (Identity) ToWGS84(p orb.Point) orb.Point   { return p }
+func (Identity) FromWGS84(p orb.Point) orb.Point { return p }
+
+// WebMercator is the Reprojector of spherical Web Mercator meters (EPSG:3857).
+type WebMercator struct{}
+
+func (WebMercator) ToWGS84(p orb.Point) orb.Point {
+	lon, lat := tile.MercatorToLonLat(p[0], p[1])
+	return orb.Point{lon, lat}
+}
+
+// FromWGS84 clamps latitudes to the Mercator limit of ±85.0511°, since the poles project to
+// infinity.
+func (WebMercator) FromWGS84(p orb.Point) orb.Point {
+	x, y := tile.LonLatToMercator(p[0], math.Max(-maxMercatorLat, math.Min(maxMercatorLat, p[1])))
+	return orb.Point{x, y}
+}
+
+var (
+	reprojectorsMu sync.RWMutex
+	reprojectors   = map[string]Reprojector{
+		"EPSG:4326":   Identity{},
+		"CRS:84":      Identity{}, // GeoJSON's lon/lat default (OGC:CRS84)
+		"OGC:CRS84":   Identity{},
+		"EPSG:3857":   WebMercator{},
+		"EPSG:900913": WebMercator{}, // Historic aliases of EPSG:3857
+		"EPSG:3785":   WebMercator{},
+		"EPSG:102100": WebMercator{},
+		"EPSG:102113": WebMercator{},
+	}
+)
+
+// RegisterReprojector makes r available to ReprojectorForCRS under crs (e.g. "EPSG:25832"),
+// so callers can plug in transforms for projections without built-in support.
+func RegisterReprojector(crs string, r Reprojector) {
+	reprojectorsMu.Lock()
+	defer reprojectorsMu.Unlock()
+	reprojectors[normalizeCRS(crs)] = r
+}
+
+// ReprojectorForCRS returns the Reprojector of crs, given as "EPSG:3857", a bare EPSG code
+// ("3857") or an OGC URN ("urn:ogc:def:crs:EPSG::3857"). An empty crs means WGS84.
+func ReprojectorForCRS(crs string) (Reprojector, error) {
+	if strings.TrimSpace(crs) == "" {
+		return Identity{}, nil
+	}
+	reprojectorsMu.RLock()
+	r, ok := reprojectors[normalizeCRS(crs)]
+	reprojectorsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unsupported CRS %q (built in: EPSG:4326, EPSG:3857)", crs)
+	}
+	return r, nil
+}
+
+// normalizeCRS returns the canonical "AUTHORITY:CODE" spelling of a CRS identifier.
+func normalizeCRS(crs string) string {
+	s := strings.ToUpper(strings.TrimSpace(crs))
+	if rest, ok := strings.CutPrefix(s, "URN:OGC:DEF:CRS:"); ok {
+		// urn:ogc:def:crs:EPSG::3857 and urn:ogc:def:crs:OGC:1.3:CRS84
+		parts := strings.Split(rest, ":")
+		return parts[0] + ":" + parts[len(parts)-1]
+	}
+	if !strings.Contains(s, ":") {
+		return "EPSG:" + s
+	}
+	return s
+}
+
+// ReprojectGeometry returns a copy of g converted to WGS84 with r; g is left unchanged.
+func ReprojectGeometry(g orb.Geometry, r Reprojector) orb.Geometry {
+	if g == nil {
+		return nil
+	}
+	if _, ok := r.(Identity); ok {
+		return g
+	}
+	return project.Geometry(orb.Clone(g), r.ToWGS84)
+}
+
+// ReprojectFeatures returns fc with the geometries of all features converted to WGS84 with r.
+// The input features are not modified.
+func ReprojectFeatures(fc types.FeatureCollection, r Reprojector) types.FeatureCollection {
+	if _, ok := r.(Identity); ok {
+		return fc
+	}
+	reproject := func(features []types.Feature) []types.Feature {
+		if features == nil {
+			return nil
+		}
+		out := make([]types.Feature, len(features))
+		for i, f := range features {
+			f.Geometry = ReprojectGeometry(f.Geometry, r)
+			out[i] = f
+		}
+		return out
+	}
+	return types.FeatureCollection{
+		Water:     reproject(fc.Water),
+		Rivers:    reproject(fc.Rivers),
+		Parks:     reproject(fc.Parks),
+		Roads:     reproject(fc.Roads),
+		Buildings: reproject(fc.Buildings),
+		Urban:     reproject(fc.Urban),
+		Land:      reproject(fc.Land),
+	}
+}
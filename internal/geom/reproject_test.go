@@ -0,0 +1,105 @@
+package geom
+
+import (
+	"math"
+	"testing"
+
+	"github.com/MeKo-Tech/watercolormap/internal/types"
+	"github.com/paulmach/orb"
+)
+
+// Hannover Hauptbahnhof in WGS84 and Web Mercator
+var (
+	hannoverLonLat   = orb.Point{9.741017, 52.376764}
+	hannoverMercator = orb.Point{1084365.05, 6868538.02}
+)
+
+func near(a, b orb.Point, tol float64) bool {
+	return math.Abs(a[0]-b[0]) <= tol && math.Abs(a[1]-b[1]) <= tol
+}
+
+func TestWebMercatorRoundTrip(t *testing.T) {
+	r, err := ReprojectorForCRS("EPSG:3857")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	merc := r.FromWGS84(hannoverLonLat)
+	if !near(merc, hannoverMercator, 1) {
+		t.Errorf("FromWGS84 = %v, want ~%v", merc, hannoverMercator)
+	}
+	if back := r.ToWGS84(merc); !near(back, hannoverLonLat, 1e-9) {
+		t.Errorf("round trip = %v, want %v", back, hannoverLonLat)
+	}
+
+	// The poles clamp to the square world extent instead of projecting to infinity
+	pole := r.FromWGS84(orb.Point{0, 90})
+	if math.IsInf(pole[1], 0) || math.Abs(pole[1]-20037508.34) > 1 {
+		t.Errorf("north pole = %v, want y clamped to ~20037508", pole)
+	}
+}
+
+func TestReprojectorForCRS(t *testing.T) {
+	tests := []struct {
+		crs     string
+		want    Reprojector
+		wantErr bool
+	}{
+		{crs: "", want: Identity{}},
+		{crs: "EPSG:4326", want: Identity{}},
+		{crs: "urn:ogc:def:crs:OGC:1.3:CRS84", want: Identity{}},
+		{crs: "epsg:3857", want: WebMercator{}},
+		{crs: "3857", want: WebMercator{}},
+		{crs: "urn:ogc:def:crs:EPSG::3857", want: WebMercator{}},
+		{crs: "EPSG:900913", want: WebMercator{}},
+		{crs: "EPSG:27700", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.crs, func(t *testing.T) {
+			got, err := ReprojectorForCRS(tt.crs)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %T, want %T", got, tt.want)
+			}
+		})
+	}
+}
+
+// offsetReprojector shifts coordinates by a fixed amount, like a simple national grid.
+type offsetReprojector struct{ dx, dy float64 }
+
+func (o offsetReprojector) ToWGS84(p orb.Point) orb.Point { return orb.Point{p[0] - o.dx, p[1] - o.dy} }
+func (o offsetReprojector) FromWGS84(p orb.Point) orb.Point {
+	return orb.Point{p[0] + o.dx, p[1] + o.dy}
+}
+
+func TestRegisterReprojector(t *testing.T) {
+	RegisterReprojector("TEST:1", offsetReprojector{dx: 100, dy: 50})
+	r, err := ReprojectorForCRS("test:1")
+	if err != nil {
+		t.Fatalf("registered CRS not found: %v", err)
+	}
+	if got := r.ToWGS84(orb.Point{110, 102}); got != (orb.Point{10, 52}) {
+		t.Errorf("ToWGS84 = %v, want [10 52]", got)
+	}
+}
+
+func TestReprojectFeatures(t *testing.T) {
+	road := orb.LineString{hannoverMercator, {hannoverMercator[0] + 1000, hannoverMercator[1]}}
+	fc := types.FeatureCollection{Roads: []types.Feature{{ID: "way/1", Geometry: road}}}
+
+	got := ReprojectFeatures(fc, WebMercator{})
+	line := got.Roads[0].Geometry.(orb.LineString)
+	if !near(line[0], hannoverLonLat, 1e-5) {
+		t.Errorf("first vertex = %v, want ~%v", line[0], hannoverLonLat)
+	}
+	if road[0] != hannoverMercator {
+		t.Error("input geometry was modified")
+	}
+	if got.Water != nil {
+		t.Errorf("empty group became %v", got.Water)
+	}
+}
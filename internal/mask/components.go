@@ -0,0 +1,104 @@
+package mask
+
+import (
+	"image"
+)
+
+// RemoveSmallComponents returns a copy of m with every 8-connected component of covered
+// (non-zero) pixels smaller than minAreaPx pixels set to 0, e.g. to drop the isolated specks
+// that noise and thresholding leave behind. Soft antialiased edge pixels count toward the
+// area of their component. Components touching the mask border are always kept, since they
+// may continue beyond it: a mask rendered with padding therefore filters identically across
+// tile boundaries. minAreaPx <= 1 returns an unmodified copy.
+func RemoveSmallComponents(m *image.Gray, minAreaPx int) *image.Gray {
+	if m == nil {
+		return nil
+	}
+	b := m.Bounds()
+	w, h := b.Dx(), b.Dy()
+	out := image.NewGray(b)
+	for y := 0; y < h; y++ {
+		copy(out.Pix[y*out.Stride:y*out.Stride+w], m.Pix[m.PixOffset(b.Min.X, b.Min.Y+y):])
+	}
+	if minAreaPx <= 1 || w == 0 || h == 0 {
+		return out
+	}
+
+	// First pass: provisional labels, merging the already labeled W, NW, N and NE neighbors
+	labels := make([]int32, w*h) // 0 = background; labels start at 1
+	parent := []int32{0}
+	find := func(l int32) int32 {
+		for parent[l] != l {
+			parent[l] = parent[parent[l]] // Path halving
+			l = parent[l]
+		}
+		return l
+	}
+	union := func(a, b int32) int32 {
+		ra, rb := find(a), find(b)
+		if ra == rb {
+			return ra
+		}
+		if ra > rb {
+			ra, rb = rb, ra
+		}
+		parent[rb] = ra
+		return ra
+	}
+
+	for y := 0; y < h; y++ {
+		row := out.Pix[y*out.Stride : y*out.Stride+w]
+		for x, v := range row {
+			if v == 0 {
+				continue
+			}
+			var label int32
+			for _, n := range [4][2]int{{-1, 0}, {-1, -1}, {0, -1}, {1, -1}} {
+				nx, ny := x+n[0], y+n[1]
+				if nx < 0 || nx >= w || ny < 0 {
+					continue
+				}
+				if nl := labels[ny*w+nx]; nl != 0 {
+					if label == 0 {
+						label = nl
+					} else {
+						label = union(label, nl)
+					}
+				}
+			}
+			if label == 0 {
+				label = int32(len(parent))
+				parent = append(parent, label)
+			}
+			labels[y*w+x] = label
+		}
+	}
+
+	// Second pass: resolve labels to their roots and measure each component
+	area := make([]int, len(parent))
+	border := make([]bool, len(parent))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			i := y*w + x
+			if labels[i] == 0 {
+				continue
+			}
+			root := find(labels[i])
+			labels[i] = root
+			area[root]++
+			if x == 0 || y == 0 || x == w-1 || y == h-1 {
+				border[root] = true
+			}
+		}
+	}
+
+	for y := 0; y < h; y++ {
+		row := out.Pix[y*out.Stride : y*out.Stride+w]
+		for x := range row {
+			if root := labels[y*w+x]; root != 0 && area[root] < minAreaPx && !border[root] {
+				row[x] = 0
+			}
+		}
+	}
+	return out
+}
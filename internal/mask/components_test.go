@@ -0,0 +1,62 @@
+package mask
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestRemoveSmallComponents(t *testing.T) {
+	m := squareMask(32, 8, 20)           // 144 px blob
+	m.SetGray(20, 20, color.Gray{Y: 90}) // Soft pixel touching the blob diagonally
+	m.SetGray(3, 26, color.Gray{Y: 255}) // Isolated dots
+	m.SetGray(26, 4, color.Gray{Y: 200})
+	m.SetGray(26, 5, color.Gray{Y: 120}) // ... one of them 2 px
+	m.SetGray(0, 12, color.Gray{Y: 255}) // Dot on the border, may continue beyond it
+
+	got := RemoveSmallComponents(m, 4)
+
+	for _, p := range []image.Point{{3, 26}, {26, 4}, {26, 5}} {
+		if v := got.GrayAt(p.X, p.Y).Y; v != 0 {
+			t.Errorf("speck at %v kept with value %d", p, v)
+		}
+	}
+	for _, p := range []image.Point{{8, 8}, {14, 14}, {19, 19}, {20, 20}, {0, 12}} {
+		if v, want := got.GrayAt(p.X, p.Y).Y, m.GrayAt(p.X, p.Y).Y; v != want {
+			t.Errorf("pixel %v = %d, want %d (kept)", p, v, want)
+		}
+	}
+	if m.GrayAt(3, 26).Y != 255 {
+		t.Error("input mask was modified")
+	}
+
+	if off := RemoveSmallComponents(m, 0); !bytes.Equal(off.Pix, m.Pix) {
+		t.Error("minAreaPx 0 should return an unmodified copy")
+	}
+	if all := RemoveSmallComponents(m, 1000); all.GrayAt(14, 14).Y != 0 || all.GrayAt(0, 12).Y != 255 {
+		t.Error("expected interior components below the area to be removed and border ones kept")
+	}
+}
+
+// TestRemoveSmallComponentsMergesLabels checks a U shape whose arms get separate provisional
+// labels until the bottom row joins them.
+func TestRemoveSmallComponentsMergesLabels(t *testing.T) {
+	m := image.NewGray(image.Rect(0, 0, 10, 10))
+	for y := 2; y < 8; y++ {
+		m.SetGray(2, y, color.Gray{Y: 255})
+		m.SetGray(7, y, color.Gray{Y: 255})
+	}
+	for x := 2; x < 8; x++ {
+		m.SetGray(x, 7, color.Gray{Y: 255})
+	}
+
+	// 6 + 6 + 4 pixels: each arm alone is below the limit, the whole U is not
+	got := RemoveSmallComponents(m, 16)
+	if !bytes.Equal(got.Pix, m.Pix) {
+		t.Error("expected the connected U to be kept as one 16 px component")
+	}
+	if got := RemoveSmallComponents(m, 17); got.GrayAt(2, 2).Y != 0 || got.GrayAt(7, 2).Y != 0 {
+		t.Error("expected the U to be removed below 17 px")
+	}
+}
@@ -0,0 +1,158 @@
+package mask
+
+import (
+	"image"
+	"math"
+)
+
+// OrientationField estimates the local direction of the features in m: for every pixel,
+// the angle in radians (x right, y down) of the edges or lines around it, from the structure
+// tensor of the image gradients averaged over a Gaussian window of the given sigma. The
+// window should be at least as wide as the features, so the two opposite edges of a thin
+// line agree on its direction. Flat areas get angle 0, which doesn't matter for blurring
+// them. The result is row-major with one entry per pixel of m.
+func OrientationField(m *image.Gray, sigma float32) []float64 {
+	b := m.Bounds()
+	w, h := b.Dx(), b.Dy()
+	at := func(x, y int) float64 {
+		x = min(max(x, 0), w-1)
+		y = min(max(y, 0), h-1)
+		return float64(m.Pix[y*m.Stride+x])
+	}
+
+	// Structure tensor J = [jxx jxy; jxy jyy] of central-difference gradients
+	jxx := make([]float64, w*h)
+	jxy := make([]float64, w*h)
+	jyy := make([]float64, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			gx := (at(x+1, y) - at(x-1, y)) / 2
+			gy := (at(x, y+1) - at(x, y-1)) / 2
+			i := y*w + x
+			jxx[i], jxy[i], jyy[i] = gx*gx, gx*gy, gy*gy
+		}
+	}
+	kernel := gaussianKernel(float64(sigma))
+	for _, f := range [][]float64{jxx, jxy, jyy} {
+		blurFloatField(f, w, h, kernel)
+	}
+
+	// The dominant gradient direction is 0.5·atan2(2jxy, jxx-jyy); features run across it
+	angles := make([]float64, w*h)
+	for i := range angles {
+		if jxx[i]+jyy[i] < 1e-9 {
+			continue
+		}
+		angles[i] = 0.5*math.Atan2(2*jxy[i], jxx[i]-jyy[i]) + math.Pi/2
+	}
+	return angles
+}
+
+// DirectionalBlur blurs m anisotropically: with sigmaAlong along the local feature direction
+// given by angles (see OrientationField) and with sigmaAcross perpendicular to it. A small
+// sigmaAcross keeps thin lines, such as rivers and roads, from fading below the threshold
+// and breaking apart, while the blur along them still softens their outline. Samples
+// outside m are clamped to its edge. A sigma <= 0 skips that direction.
+func DirectionalBlur(m *image.Gray, angles []float64, sigmaAlong, sigmaAcross float32) *image.Gray {
+	b := m.Bounds()
+	w, h := b.Dx(), b.Dy()
+	src := make([]float64, w*h)
+	for y := 0; y < h; y++ {
+		for x, v := range m.Pix[y*m.Stride : y*m.Stride+w] {
+			src[y*w+x] = float64(v)
+		}
+	}
+
+	// Two 1-D passes in the rotated frame: along the feature, then across it
+	if sigmaAlong > 0 {
+		src = lineBlur(src, w, h, angles, 0, gaussianKernel(float64(sigmaAlong)))
+	}
+	if sigmaAcross > 0 {
+		src = lineBlur(src, w, h, angles, math.Pi/2, gaussianKernel(float64(sigmaAcross)))
+	}
+
+	dst := image.NewGray(b)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			dst.Pix[y*dst.Stride+x] = uint8(math.Round(min(max(src[y*w+x], 0), 255)))
+		}
+	}
+	return dst
+}
+
+// lineBlur convolves src with kernel along the direction angles[i]+rotation of every pixel,
+// sampling bilinearly at unit steps.
+func lineBlur(src []float64, w, h int, angles []float64, rotation float64, kernel []float64) []float64 {
+	radius := len(kernel) / 2
+	sample := func(x, y float64) float64 {
+		x = min(max(x, 0), float64(w-1))
+		y = min(max(y, 0), float64(h-1))
+		x0, y0 := int(x), int(y)
+		x1, y1 := min(x0+1, w-1), min(y0+1, h-1)
+		tx, ty := x-float64(x0), y-float64(y0)
+		top := src[y0*w+x0]*(1-tx) + src[y0*w+x1]*tx
+		bottom := src[y1*w+x0]*(1-tx) + src[y1*w+x1]*tx
+		return top*(1-ty) + bottom*ty
+	}
+
+	dst := make([]float64, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			i := y*w + x
+			dy, dx := math.Sincos(angles[i] + rotation)
+			var sum float64
+			for k, weight := range kernel {
+				t := float64(k - radius)
+				sum += weight * sample(float64(x)+t*dx, float64(y)+t*dy)
+			}
+			dst[i] = sum
+		}
+	}
+	return dst
+}
+
+// gaussianKernel returns a normalized Gaussian kernel with radius ceil(3·sigma).
+func gaussianKernel(sigma float64) []float64 {
+	if sigma <= 0 {
+		return []float64{1}
+	}
+	radius := int(math.Ceil(3 * sigma))
+	kernel := make([]float64, 2*radius+1)
+	var sum float64
+	for i := range kernel {
+		d := float64(i - radius)
+		kernel[i] = math.Exp(-d * d / (2 * sigma * sigma))
+		sum += kernel[i]
+	}
+	for i := range kernel {
+		kernel[i] /= sum
+	}
+	return kernel
+}
+
+// blurFloatField blurs a row-major w×h field in place with a separable kernel, clamping at
+// the edges.
+func blurFloatField(f []float64, w, h int, kernel []float64) {
+	radius := len(kernel) / 2
+	tmp := make([]float64, len(f))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var sum float64
+			for k, weight := range kernel {
+				sx := min(max(x+k-radius, 0), w-1)
+				sum += weight * f[y*w+sx]
+			}
+			tmp[y*w+x] = sum
+		}
+	}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var sum float64
+			for k, weight := range kernel {
+				sy := min(max(y+k-radius, 0), h-1)
+				sum += weight * tmp[sy*w+x]
+			}
+			f[y*w+x] = sum
+		}
+	}
+}
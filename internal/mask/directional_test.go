@@ -0,0 +1,80 @@
+package mask
+
+import (
+	"image"
+	"math"
+	"testing"
+)
+
+// connected reports whether from and to lie in the same 8-connected component of the pixels
+// of m at or above 128.
+func connected(m *image.Gray, from, to image.Point) bool {
+	b := m.Bounds()
+	on := func(p image.Point) bool { return p.In(b) && m.GrayAt(p.X, p.Y).Y >= 128 }
+	if !on(from) || !on(to) {
+		return false
+	}
+	seen := map[image.Point]bool{from: true}
+	queue := []image.Point{from}
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+		if p == to {
+			return true
+		}
+		for dy := -1; dy <= 1; dy++ {
+			for dx := -1; dx <= 1; dx++ {
+				n := image.Pt(p.X+dx, p.Y+dy)
+				if !seen[n] && on(n) {
+					seen[n] = true
+					queue = append(queue, n)
+				}
+			}
+		}
+	}
+	return false
+}
+
+func TestDirectionalBlurKeepsThinLineConnected(t *testing.T) {
+	// A thin diagonal line, about 1.4 px wide across its direction
+	m := image.NewGray(image.Rect(0, 0, 96, 96))
+	for i := 8; i < 88; i++ {
+		m.Pix[i*m.Stride+i] = 255
+		m.Pix[i*m.Stride+i+1] = 255
+	}
+	from, to := image.Pt(20, 20), image.Pt(76, 76)
+
+	const sigma = 3
+	isotropic := ApplyThreshold(BoxBlurSigma(m, sigma), 128)
+	if connected(isotropic, from, to) {
+		t.Fatal("expected the isotropic blur to break the line")
+	}
+
+	angles := OrientationField(m, sigma)
+	if a := math.Mod(angles[50*96+50]+math.Pi, math.Pi); math.Abs(a-math.Pi/4) > 0.1 {
+		t.Errorf("orientation on the line = %.2f rad, want π/4", a)
+	}
+	directional := ApplyThreshold(DirectionalBlur(m, angles, sigma, 0.5), 128)
+	if !connected(directional, from, to) {
+		t.Error("expected the line to stay connected after the directional blur")
+	}
+}
+
+func TestDirectionalBlurSoftensAlongLine(t *testing.T) {
+	// A horizontal line ending in the middle: blurring along it softens the end
+	m := image.NewGray(image.Rect(0, 0, 64, 32))
+	for x := 0; x < 32; x++ {
+		m.Pix[16*m.Stride+x] = 255
+	}
+	blurred := DirectionalBlur(m, OrientationField(m, 2), 4, 0)
+
+	if got := blurred.GrayAt(10, 16).Y; got < 250 {
+		t.Errorf("line interior = %d, want ~255", got)
+	}
+	if got := blurred.GrayAt(10, 14).Y; got != 0 {
+		t.Errorf("across the line = %d, want 0 without sigmaAcross", got)
+	}
+	if got := blurred.GrayAt(33, 16).Y; got == 0 || got == 255 {
+		t.Errorf("line end = %d, want a soft transition", got)
+	}
+}
@@ -71,6 +71,168 @@ func ApplySoftEdgeMaskInto(base *image.NRGBA, mask *image.Gray, strength float64
 	}
 }
 
+// ApplySoftEdgeMaskTinted is like ApplySoftEdgeMask but darkens toward a pigment color
+// instead of black. Each channel is multiplied by a factor that blends from 1 (no change)
+// toward tint/255 as the effect grows, so edges pick up the tint's hue (e.g. deep blue for
+// water) instead of turning muddy gray. A black tint behaves like a plain multiply darkening.
+// The mask uses the same convention: 255 = no change, 0 = maximum effect.
+func ApplySoftEdgeMaskTinted(base *image.NRGBA, mask *image.Gray, strength float64, tint color.NRGBA) *image.NRGBA {
+	if base == nil || mask == nil {
+		return nil
+	}
+
+	bounds := base.Bounds()
+	dst := image.NewNRGBA(bounds)
+	ApplySoftEdgeMaskTintedInto(base, mask, strength, tint, dst)
+	return dst
+}
+
+// ApplySoftEdgeMaskTintedInto applies a tinted soft edge effect into an existing destination buffer.
+// The dst buffer must have the same bounds as base.
+func ApplySoftEdgeMaskTintedInto(base *image.NRGBA, mask *image.Gray, strength float64, tint color.NRGBA, dst *image.NRGBA) {
+	if base == nil || mask == nil || dst == nil {
+		return
+	}
+
+	if strength < 0 {
+		strength = 0
+	}
+	if strength > 1 {
+		strength = 1
+	}
+
+	bounds := base.Bounds()
+
+	// Per-channel darkening headroom: how far the multiply factor drops at full effect.
+	// 65025 = 255*255, matching the fixed-point scale used by ApplySoftEdgeMaskInto.
+	dropR := 255 - int(tint.R)
+	dropG := 255 - int(tint.G)
+	dropB := 255 - int(tint.B)
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			src := base.NRGBAAt(x, y)
+			maskVal := int(mask.GrayAt(x, y).Y)
+
+			// Same quadratic falloff as ApplySoftEdgeMaskInto
+			maskSquared := maskVal * maskVal
+			invMaskSquared := 65025 - maskSquared
+			effectInt := int(float64(invMaskSquared) * strength) // 0..65025
+
+			// factor = 1 - effect*(1 - tint/255), in 1/(65025*255) units
+			fR := 65025*255 - effectInt*dropR
+			fG := 65025*255 - effectInt*dropG
+			fB := 65025*255 - effectInt*dropB
+
+			dst.SetNRGBA(x, y, color.NRGBA{
+				R: uint8(int64(src.R) * int64(fR) / (65025 * 255)),
+				G: uint8(int64(src.G) * int64(fG) / (65025 * 255)),
+				B: uint8(int64(src.B) * int64(fB) / (65025 * 255)),
+				A: src.A, // preserve original alpha
+			})
+		}
+	}
+}
+
+// ApplyOutlineInto paints ink over base where band is set, like a pen stroke over watercolor.
+// The ink coverage at each pixel is strength × band/255 × ink.A/255, composited over base with
+// the "over" operator, so the stroke also shows where base is transparent. dst may be base.
+// All images must share the same bounds.
+func ApplyOutlineInto(base *image.NRGBA, band *image.Gray, ink color.NRGBA, strength float64, dst *image.NRGBA) {
+	if base == nil || band == nil || dst == nil {
+		return
+	}
+
+	if strength < 0 {
+		strength = 0
+	}
+	if strength > 1 {
+		strength = 1
+	}
+	// Ink opacity at full band, in 1/65025 units
+	inkScale := int(strength * float64(ink.A) * 255)
+
+	bounds := base.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			src := base.NRGBAAt(x, y)
+			ia := int(band.GrayAt(x, y).Y) * inkScale / 65025 // 0..255
+			if ia == 0 {
+				dst.SetNRGBA(x, y, src)
+				continue
+			}
+
+			// out = ink over src (non-premultiplied)
+			sa := int(src.A) * (255 - ia) / 255
+			oa := ia + sa
+			dst.SetNRGBA(x, y, color.NRGBA{
+				R: uint8((int(ink.R)*ia + int(src.R)*sa) / oa),
+				G: uint8((int(ink.G)*ia + int(src.G)*sa) / oa),
+				B: uint8((int(ink.B)*ia + int(src.B)*sa) / oa),
+				A: uint8(oa),
+			})
+		}
+	}
+}
+
+// ApplyDepthRampInto glazes base with a color ramp driven by a normalized distance map (as
+// produced by EuclideanDistanceTransform): shallow where dist is 0 (at the shore), deep where
+// dist is 255 (at or beyond the ramp's max distance), linearly interpolated in between. The
+// ramp color is multiplied onto the RGB channels like a transparent glaze, so white leaves a
+// pixel unchanged and the texture grain stays visible. Alpha is preserved. dst may be base.
+// All images must share the same bounds.
+func ApplyDepthRampInto(base *image.NRGBA, dist *image.Gray, shallow, deep color.NRGBA, dst *image.NRGBA) {
+	if base == nil || dist == nil || dst == nil {
+		return
+	}
+
+	// Precompute the ramp once per distance value
+	var ramp [256][3]int
+	for d := range ramp {
+		ramp[d] = [3]int{
+			int(shallow.R) + (int(deep.R)-int(shallow.R))*d/255,
+			int(shallow.G) + (int(deep.G)-int(shallow.G))*d/255,
+			int(shallow.B) + (int(deep.B)-int(shallow.B))*d/255,
+		}
+	}
+
+	bounds := base.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			src := base.NRGBAAt(x, y)
+			c := ramp[dist.GrayAt(x, y).Y]
+			dst.SetNRGBA(x, y, color.NRGBA{
+				R: uint8(int(src.R) * c[0] / 255),
+				G: uint8(int(src.G) * c[1] / 255),
+				B: uint8(int(src.B) * c[2] / 255),
+				A: src.A, // preserve original alpha
+			})
+		}
+	}
+}
+
+// ApplyTintInto glazes base with a uniform tint: each RGB channel is multiplied by tint/255,
+// so white leaves a pixel unchanged and the texture grain stays visible. Alpha is preserved.
+// dst may be base. Both images must share the same bounds.
+func ApplyTintInto(base *image.NRGBA, tint color.NRGBA, dst *image.NRGBA) {
+	if base == nil || dst == nil {
+		return
+	}
+
+	bounds := base.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			src := base.NRGBAAt(x, y)
+			dst.SetNRGBA(x, y, color.NRGBA{
+				R: uint8(int(src.R) * int(tint.R) / 255),
+				G: uint8(int(src.G) * int(tint.G) / 255),
+				B: uint8(int(src.B) * int(tint.B) / 255),
+				A: src.A, // preserve original alpha
+			})
+		}
+	}
+}
+
 // MultiplyRGBByMask multiplies the RGB color values of an image by a grayscale mask.
 // The mask values (0-255) are normalized to (0-1) and multiplied with RGB values.
 // Alpha channel is preserved from the base image.
@@ -209,3 +209,88 @@ func BenchmarkApplySoftEdgeMask(b *testing.B) {
 		ApplySoftEdgeMask(base, mask, 0.8)
 	}
 }
+
+func TestApplySoftEdgeMaskTinted(t *testing.T) {
+	base := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	base.SetNRGBA(0, 0, color.NRGBA{R: 200, G: 200, B: 200, A: 255})
+	base.SetNRGBA(1, 0, color.NRGBA{R: 200, G: 200, B: 200, A: 128})
+
+	m := image.NewGray(image.Rect(0, 0, 2, 1))
+	m.SetGray(0, 0, color.Gray{Y: 0})   // edge: full effect
+	m.SetGray(1, 0, color.Gray{Y: 255}) // center: no change
+
+	tint := color.NRGBA{R: 0, G: 0, B: 255, A: 255}
+	out := ApplySoftEdgeMaskTinted(base, m, 0.5, tint)
+
+	edge := out.NRGBAAt(0, 0)
+	if edge.R >= 200 || edge.G >= 200 {
+		t.Errorf("expected red/green channels to darken at edge, got %+v", edge)
+	}
+	if edge.B != 200 {
+		t.Errorf("expected blue channel to be preserved by a blue tint, got %+v", edge)
+	}
+	if edge.A != 255 {
+		t.Errorf("alpha should be preserved, got %d", edge.A)
+	}
+
+	center := out.NRGBAAt(1, 0)
+	if center != (color.NRGBA{R: 200, G: 200, B: 200, A: 128}) {
+		t.Errorf("expected no change at white mask, got %+v", center)
+	}
+}
+
+func TestApplySoftEdgeMaskTintedBlackMatchesMultiply(t *testing.T) {
+	base := image.NewNRGBA(image.Rect(0, 0, 1, 1))
+	base.SetNRGBA(0, 0, color.NRGBA{R: 200, G: 100, B: 50, A: 255})
+	m := image.NewGray(image.Rect(0, 0, 1, 1)) // all zero: full effect
+
+	out := ApplySoftEdgeMaskTinted(base, m, 0.5, color.NRGBA{A: 255})
+	got := out.NRGBAAt(0, 0)
+	if got.R != 100 || got.G != 50 || got.B != 25 {
+		t.Errorf("expected black tint at strength 0.5 to halve RGB, got %+v", got)
+	}
+}
+
+func TestApplyDepthRampInto(t *testing.T) {
+	base := image.NewNRGBA(image.Rect(0, 0, 3, 1))
+	for x := 0; x < 3; x++ {
+		base.SetNRGBA(x, 0, color.NRGBA{R: 200, G: 200, B: 200, A: 180})
+	}
+	dist := image.NewGray(image.Rect(0, 0, 3, 1))
+	dist.SetGray(0, 0, color.Gray{Y: 0})   // shore
+	dist.SetGray(1, 0, color.Gray{Y: 128}) // halfway
+	dist.SetGray(2, 0, color.Gray{Y: 255}) // deep
+
+	shallow := color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+	deep := color.NRGBA{R: 0, G: 128, B: 255, A: 255}
+	out := image.NewNRGBA(base.Bounds())
+	ApplyDepthRampInto(base, dist, shallow, deep, out)
+
+	if got := out.NRGBAAt(0, 0); got != base.NRGBAAt(0, 0) {
+		t.Errorf("white shallow color should leave the shore unchanged, got %+v", got)
+	}
+	if got := out.NRGBAAt(2, 0); got != (color.NRGBA{R: 0, G: 100, B: 200, A: 180}) {
+		t.Errorf("expected the deep color multiplied in, got %+v", got)
+	}
+	mid := out.NRGBAAt(1, 0)
+	if mid.R <= 0 || mid.R >= 200 || mid.B != 200 || mid.A != 180 {
+		t.Errorf("expected halfway pixel between shallow and deep, got %+v", mid)
+	}
+}
+
+func TestApplyTintInto(t *testing.T) {
+	base := image.NewNRGBA(image.Rect(0, 0, 1, 1))
+	base.SetNRGBA(0, 0, color.NRGBA{R: 200, G: 100, B: 50, A: 180})
+
+	white := image.NewNRGBA(base.Bounds())
+	ApplyTintInto(base, color.NRGBA{R: 255, G: 255, B: 255, A: 255}, white)
+	if got := white.NRGBAAt(0, 0); got != base.NRGBAAt(0, 0) {
+		t.Errorf("white tint should leave the pixel unchanged, got %+v", got)
+	}
+
+	// In place, like the painter uses it
+	ApplyTintInto(base, color.NRGBA{R: 128, G: 255, B: 0, A: 255}, base)
+	if got := base.NRGBAAt(0, 0); got != (color.NRGBA{R: 100, G: 100, B: 0, A: 180}) {
+		t.Errorf("expected the tint multiplied in with alpha kept, got %+v", got)
+	}
+}
@@ -0,0 +1,88 @@
+package mask
+
+import (
+	"image"
+)
+
+// Dilate returns the grayscale dilation of m by a (2*radius+1)² square: each pixel becomes
+// the maximum of its neighbourhood. Pixels outside the mask bounds are ignored, so a mask
+// rendered with padding dilates identically across tile boundaries.
+func Dilate(m *image.Gray, radius int) *image.Gray {
+	return morph(m, radius, func(a, b uint8) bool { return a > b })
+}
+
+// Erode returns the grayscale erosion of m by a (2*radius+1)² square: each pixel becomes
+// the minimum of its neighbourhood (see Dilate).
+func Erode(m *image.Gray, radius int) *image.Gray {
+	return morph(m, radius, func(a, b uint8) bool { return a < b })
+}
+
+// EdgeBand returns a band roughly widthPx wide straddling the edges of m: the morphological
+// gradient (dilation minus erosion). Interior and empty areas are 0; hard edges are 255 across
+// the band and soft (antialiased) edges proportionally less. widthPx <= 0 yields an empty band.
+func EdgeBand(m *image.Gray, widthPx int) *image.Gray {
+	if m == nil {
+		return nil
+	}
+	if widthPx <= 0 {
+		return image.NewGray(m.Bounds())
+	}
+
+	// Split the width between the outer and inner side of the edge
+	dilated := Dilate(m, (widthPx+1)/2)
+	eroded := Erode(m, widthPx/2)
+	for i, v := range dilated.Pix {
+		dilated.Pix[i] = v - eroded.Pix[i]
+	}
+	return dilated
+}
+
+// morph applies a separable square max/min filter; better(a, b) reports whether a should
+// replace b.
+func morph(m *image.Gray, radius int, better func(a, b uint8) bool) *image.Gray {
+	if m == nil {
+		return nil
+	}
+	bounds := m.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+
+	// Work on a compact copy so rows are contiguous regardless of m's stride
+	src := make([]uint8, w*h)
+	for y := 0; y < h; y++ {
+		copy(src[y*w:(y+1)*w], m.Pix[y*m.Stride:y*m.Stride+w])
+	}
+	out := image.NewGray(bounds)
+	if radius <= 0 {
+		copy(out.Pix, src)
+		return out
+	}
+
+	// Horizontal pass
+	tmp := make([]uint8, w*h)
+	for y := 0; y < h; y++ {
+		row := src[y*w : (y+1)*w]
+		for x := 0; x < w; x++ {
+			best := row[x]
+			for k := max(0, x-radius); k <= min(w-1, x+radius); k++ {
+				if better(row[k], best) {
+					best = row[k]
+				}
+			}
+			tmp[y*w+x] = best
+		}
+	}
+
+	// Vertical pass
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			best := tmp[y*w+x]
+			for k := max(0, y-radius); k <= min(h-1, y+radius); k++ {
+				if v := tmp[k*w+x]; better(v, best) {
+					best = v
+				}
+			}
+			out.Pix[y*out.Stride+x] = best
+		}
+	}
+	return out
+}
@@ -0,0 +1,114 @@
+package mask
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func squareMask(size, from, to int) *image.Gray {
+	m := image.NewGray(image.Rect(0, 0, size, size))
+	for y := from; y < to; y++ {
+		for x := from; x < to; x++ {
+			m.SetGray(x, y, color.Gray{Y: 255})
+		}
+	}
+	return m
+}
+
+func TestDilateErode(t *testing.T) {
+	m := squareMask(20, 5, 15)
+
+	d := Dilate(m, 2)
+	if d.GrayAt(3, 10).Y != 255 || d.GrayAt(2, 10).Y != 0 {
+		t.Errorf("dilate by 2 should reach x=3 but not x=2, got %d/%d", d.GrayAt(3, 10).Y, d.GrayAt(2, 10).Y)
+	}
+
+	e := Erode(m, 2)
+	if e.GrayAt(7, 10).Y != 255 || e.GrayAt(6, 10).Y != 0 {
+		t.Errorf("erode by 2 should keep x=7 but clear x=6, got %d/%d", e.GrayAt(7, 10).Y, e.GrayAt(6, 10).Y)
+	}
+
+	if got := Dilate(m, 0); got.GrayAt(4, 10).Y != 0 || got.GrayAt(5, 10).Y != 255 {
+		t.Error("radius 0 should return an unchanged copy")
+	}
+}
+
+func TestEdgeBand(t *testing.T) {
+	tests := []struct {
+		name    string
+		width   int
+		wantSet []int // x positions along y=10 inside the band
+		wantOff []int // x positions outside the band
+	}{
+		{name: "width 2", width: 2, wantSet: []int{4, 5}, wantOff: []int{3, 6, 10}},
+		{name: "width 3", width: 3, wantSet: []int{3, 4, 5}, wantOff: []int{2, 6, 10}},
+		{name: "width 0", width: 0, wantOff: []int{4, 5, 10}},
+	}
+
+	m := squareMask(20, 5, 15)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			band := EdgeBand(m, tt.width)
+			for _, x := range tt.wantSet {
+				if v := band.GrayAt(x, 10).Y; v != 255 {
+					t.Errorf("expected band at x=%d, got %d", x, v)
+				}
+			}
+			for _, x := range tt.wantOff {
+				if v := band.GrayAt(x, 10).Y; v != 0 {
+					t.Errorf("expected no band at x=%d, got %d", x, v)
+				}
+			}
+		})
+	}
+}
+
+// TestEdgeBandIsLocal checks that a band computed on a padded crop matches the band of
+// the full mask once the padding is cropped away, which keeps outlines seamless across tiles.
+func TestEdgeBandIsLocal(t *testing.T) {
+	const width, pad = 3, 4
+	full := squareMask(64, 20, 44)
+	fullBand := EdgeBand(full, width)
+
+	crop := image.Rect(32-pad, 0, 64, 64)
+	sub := image.NewGray(image.Rect(0, 0, crop.Dx(), crop.Dy()))
+	for y := 0; y < crop.Dy(); y++ {
+		for x := 0; x < crop.Dx(); x++ {
+			sub.SetGray(x, y, full.GrayAt(crop.Min.X+x, crop.Min.Y+y))
+		}
+	}
+	subBand := EdgeBand(sub, width)
+
+	for y := 0; y < crop.Dy(); y++ {
+		for x := pad; x < crop.Dx(); x++ {
+			if subBand.GrayAt(x, y) != fullBand.GrayAt(crop.Min.X+x, crop.Min.Y+y) {
+				t.Fatalf("band mismatch at (%d,%d)", crop.Min.X+x, crop.Min.Y+y)
+			}
+		}
+	}
+}
+
+func TestApplyOutlineInto(t *testing.T) {
+	base := image.NewNRGBA(image.Rect(0, 0, 3, 1))
+	base.SetNRGBA(0, 0, color.NRGBA{R: 200, G: 200, B: 200, A: 255})
+	base.SetNRGBA(1, 0, color.NRGBA{R: 200, G: 200, B: 200, A: 255})
+	// (2,0) stays transparent
+
+	band := image.NewGray(image.Rect(0, 0, 3, 1))
+	band.SetGray(1, 0, color.Gray{Y: 255})
+	band.SetGray(2, 0, color.Gray{Y: 255})
+
+	ink := color.NRGBA{R: 0, G: 0, B: 0, A: 255}
+	ApplyOutlineInto(base, band, ink, 0.5, base)
+
+	if got := base.NRGBAAt(0, 0); got != (color.NRGBA{R: 200, G: 200, B: 200, A: 255}) {
+		t.Errorf("pixel outside band changed: %v", got)
+	}
+	if got := base.NRGBAAt(1, 0); got.A != 255 || got.R < 95 || got.R > 105 {
+		t.Errorf("expected half-strength ink over opaque base (~100), got %v", got)
+	}
+	if got := base.NRGBAAt(2, 0); got.R != 0 || got.A < 125 || got.A > 129 {
+		t.Errorf("expected half-opaque ink over transparent base, got %v", got)
+	}
+}
@@ -0,0 +1,151 @@
+package mask
+
+import (
+	"container/list"
+	"image"
+	"sync"
+
+	"github.com/aquilax/go-perlin"
+)
+
+// noiseSlabSize is the edge length in pixels of the slabs a NoiseCache stores. Slabs sit on a
+// global grid, so neighboring tile canvases (tile plus padding) share the slabs of their
+// overlap. Tile sizes and the default padding are multiples of it, so little is sampled
+// outside the canvases.
+const noiseSlabSize = 64
+
+// NoiseCache memoizes slabs of the Perlin noise field so tiles of a batch that overlap the
+// same area don't sample it again. Every noise pixel depends only on the seed, the octaves,
+// the scale and its global position, so the assembled noise is identical to uncached noise.
+// The least recently used slabs are evicted beyond the size limit. A NoiseCache is safe for
+// concurrent use; a nil *NoiseCache generates without caching.
+type NoiseCache struct {
+	mu       sync.Mutex
+	maxSlabs int
+	slabs    map[noiseSlabKey]*list.Element // Values are *noiseSlab
+	lru      *list.List                     // Most recently used at the front
+	perlins  map[perlinKey]*perlin.Perlin   // Generators by seed and octaves; creating one costs as much as sampling a slab
+}
+
+type noiseSlabKey struct {
+	perlinKey
+	scale  float64
+	sx, sy int // Slab grid position: the slab starts at global pixel (sx, sy)×noiseSlabSize
+}
+
+type perlinKey struct {
+	seed    int64
+	octaves PerlinOctaves // withDefaults applied
+}
+
+type noiseSlab struct {
+	key   noiseSlabKey
+	img   *image.Gray
+	ready chan struct{} // Closed once img is set
+}
+
+// NewNoiseCache creates a cache holding up to maxSlabs slabs of 64×64 noise pixels (4 KiB
+// each).
+func NewNoiseCache(maxSlabs int) *NoiseCache {
+	return &NoiseCache{
+		maxSlabs: max(maxSlabs, 1),
+		slabs:    make(map[noiseSlabKey]*list.Element),
+		lru:      list.New(),
+		perlins:  make(map[perlinKey]*perlin.Perlin),
+	}
+}
+
+// GeneratePerlinNoiseDownscaled returns the same noise as the package-level
+// GeneratePerlinNoiseDownscaled, assembled from cached slabs.
+func (c *NoiseCache) GeneratePerlinNoiseDownscaled(
+	width, height int,
+	scale float64,
+	seed int64,
+	octaves PerlinOctaves,
+	offsetX, offsetY int,
+	downscale int,
+) *image.Gray {
+	dst := image.NewGray(image.Rect(0, 0, width, height))
+	c.FillPerlinNoiseDownscaled(dst, scale, seed, octaves, offsetX, offsetY, downscale)
+	return dst
+}
+
+// FillPerlinNoiseDownscaled is GeneratePerlinNoiseDownscaled writing into dst (see the
+// package-level FillPerlinNoiseDownscaled).
+func (c *NoiseCache) FillPerlinNoiseDownscaled(
+	dst *image.Gray,
+	scale float64,
+	seed int64,
+	octaves PerlinOctaves,
+	offsetX, offsetY int,
+	downscale int,
+) {
+	if c == nil {
+		FillPerlinNoiseDownscaled(dst, scale, seed, octaves, offsetX, offsetY, downscale)
+		return
+	}
+	perlinNoiseDownscaled(dst, scale, seed, octaves, offsetX, offsetY, downscale, c.field)
+}
+
+// Len returns the number of cached slabs.
+func (c *NoiseCache) Len() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// field is a noiseFieldFunc copying the requested window out of the slabs it overlaps.
+func (c *NoiseCache) field(dst *image.Gray, scale float64, seed int64, octaves PerlinOctaves, offsetX, offsetY int) {
+	width, height := dst.Rect.Dx(), dst.Rect.Dy()
+	if width <= 0 || height <= 0 {
+		return
+	}
+
+	for sy := floorDiv(offsetY, noiseSlabSize); sy*noiseSlabSize < offsetY+height; sy++ {
+		for sx := floorDiv(offsetX, noiseSlabSize); sx*noiseSlabSize < offsetX+width; sx++ {
+			slab := c.slab(noiseSlabKey{perlinKey: perlinKey{seed, octaves.withDefaults()}, scale: scale, sx: sx, sy: sy})
+
+			// Overlap of the slab and the window in global pixels
+			x0, x1 := max(sx*noiseSlabSize, offsetX), min((sx+1)*noiseSlabSize, offsetX+width)
+			y0, y1 := max(sy*noiseSlabSize, offsetY), min((sy+1)*noiseSlabSize, offsetY+height)
+			for y := y0; y < y1; y++ {
+				src := slab.Pix[(y-sy*noiseSlabSize)*slab.Stride+x0-sx*noiseSlabSize:]
+				copy(dst.Pix[(y-offsetY)*dst.Stride+x0-offsetX:], src[:x1-x0])
+			}
+		}
+	}
+}
+
+// slab returns the slab for key, generating it on a miss. Concurrent requests for a missing
+// slab wait for the first one to generate it.
+func (c *NoiseCache) slab(key noiseSlabKey) *image.Gray {
+	c.mu.Lock()
+	if e, ok := c.slabs[key]; ok {
+		c.lru.MoveToFront(e)
+		s := e.Value.(*noiseSlab)
+		c.mu.Unlock()
+		<-s.ready
+		return s.img
+	}
+	p, ok := c.perlins[key.perlinKey]
+	if !ok {
+		p = newPerlin(key.seed, key.octaves)
+		c.perlins[key.perlinKey] = p
+	}
+	s := &noiseSlab{key: key, ready: make(chan struct{})}
+	c.slabs[key] = c.lru.PushFront(s)
+	for c.lru.Len() > c.maxSlabs {
+		// Evicting a slab still being generated is fine: its waiters hold the entry
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.slabs, oldest.Value.(*noiseSlab).key)
+	}
+	c.mu.Unlock()
+
+	s.img = samplePerlin(p, noiseSlabSize, noiseSlabSize, key.scale, key.sx*noiseSlabSize, key.sy*noiseSlabSize)
+	close(s.ready)
+	return s.img
+}
@@ -0,0 +1,105 @@
+package mask
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// TestNoiseCacheMatchesDirect checks that noise assembled from cached slabs is identical to
+// generating it directly, for windows straddling slab boundaries and negative offsets.
+func TestNoiseCacheMatchesDirect(t *testing.T) {
+	tests := []struct {
+		width, height    int
+		offsetX, offsetY int
+		downscale        int
+	}{
+		{256, 256, 0, 0, 1},
+		{320, 320, 224, 480, 1},
+		{320, 300, -32, -32, 1},
+		{100, 40, 250, 510, 1},
+		{320, 320, 1000, -300, 2},
+		{288, 288, -16, 752, 4},
+	}
+
+	cache := NewNoiseCache(64)
+	for _, tt := range tests {
+		name := fmt.Sprintf("%dx%d@%d,%d/%d", tt.width, tt.height, tt.offsetX, tt.offsetY, tt.downscale)
+		t.Run(name, func(t *testing.T) {
+			want := GeneratePerlinNoiseDownscaled(tt.width, tt.height, 30, 42, PerlinOctaves{}, tt.offsetX, tt.offsetY, tt.downscale)
+			// Twice: once filling the cache, once from it
+			for pass := 0; pass < 2; pass++ {
+				got := cache.GeneratePerlinNoiseDownscaled(tt.width, tt.height, 30, 42, PerlinOctaves{}, tt.offsetX, tt.offsetY, tt.downscale)
+				if got.Bounds() != want.Bounds() || !bytes.Equal(got.Pix, want.Pix) {
+					t.Fatalf("pass %d: cached noise differs from direct generation", pass)
+				}
+			}
+		})
+	}
+
+	// Another seed must not reuse the slabs
+	other := cache.GeneratePerlinNoiseDownscaled(64, 64, 30, 7, PerlinOctaves{}, 0, 0, 1)
+	if !bytes.Equal(other.Pix, GeneratePerlinNoiseWithOffset(64, 64, 30, 7, 0, 0).Pix) {
+		t.Error("cached noise of a second seed differs from direct generation")
+	}
+}
+
+func TestNoiseCacheEviction(t *testing.T) {
+	cache := NewNoiseCache(2)
+	for i := 0; i < 4; i++ {
+		cache.GeneratePerlinNoiseDownscaled(64, 64, 30, 42, PerlinOctaves{}, i*noiseSlabSize, 0, 1)
+	}
+	if n := cache.Len(); n != 2 {
+		t.Errorf("expected 2 slabs after eviction, got %d", n)
+	}
+
+	var nilCache *NoiseCache
+	if got := nilCache.GeneratePerlinNoiseDownscaled(8, 8, 30, 42, PerlinOctaves{}, 0, 0, 1); got.Bounds().Dx() != 8 {
+		t.Errorf("nil cache: got bounds %v", got.Bounds())
+	}
+}
+
+func TestNoiseCacheConcurrent(t *testing.T) {
+	cache := NewNoiseCache(16)
+	want := GeneratePerlinNoiseWithOffset(300, 300, 30, 42, 100, 100)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			got := cache.GeneratePerlinNoiseDownscaled(300, 300, 30, 42, PerlinOctaves{}, 100, 100, 1)
+			if !bytes.Equal(got.Pix, want.Pix) {
+				t.Error("concurrently cached noise differs from direct generation")
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// BenchmarkNoiseTileBlock generates the noise for a 4×4 block of 256px tiles with 64px of
+// padding each, as a batch render does, with and without a cache.
+func BenchmarkNoiseTileBlock(b *testing.B) {
+	const tileSize, pad, block = 256, 64, 4
+	generate := func(cache *NoiseCache) {
+		for ty := 0; ty < block; ty++ {
+			for tx := 0; tx < block; tx++ {
+				cache.GeneratePerlinNoiseDownscaled(tileSize+2*pad, tileSize+2*pad, 30, 42, PerlinOctaves{},
+					tx*tileSize-pad, ty*tileSize-pad, 1)
+			}
+		}
+	}
+
+	b.Run("uncached", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			generate(nil)
+		}
+	})
+	b.Run("cached", func(b *testing.B) {
+		// A fresh cache per block, so every slab is generated once per iteration
+		for i := 0; i < b.N; i++ {
+			generate(NewNoiseCache(1024))
+		}
+	})
+}
@@ -1,6 +1,7 @@
 package mask
 
 import (
+	"fmt"
 	"image"
 	"image/color"
 	"math"
@@ -53,6 +54,22 @@ func NewEmptyMask(bounds image.Rectangle) *image.Gray {
 	return image.NewGray(bounds)
 }
 
+// IsEmpty reports whether every pixel of m is 0. A nil mask is empty.
+func IsEmpty(m *image.Gray) bool {
+	if m == nil {
+		return true
+	}
+	b := m.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for _, v := range m.Pix[m.PixOffset(b.Min.X, y):m.PixOffset(b.Max.X, y)] {
+			if v != 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
 // MaxMask computes a pixel-wise max of two masks (union/or for alpha masks).
 // Masks must have identical bounds.
 func MaxMask(a, b *image.Gray) *image.Gray {
@@ -219,6 +236,32 @@ func GaussianBlur(mask *image.Gray, sigma float32) *image.Gray {
 	return dst
 }
 
+// Default shape of the Perlin noise field (see PerlinOctaves): three octaves, each at half the
+// amplitude and twice the frequency of the previous one.
+const (
+	DefaultNoiseOctaves     = 3
+	DefaultNoisePersistence = 0.5
+)
+
+// PerlinOctaves shapes the fractal sum of the Perlin noise field. More octaves add finer
+// detail on top of the base wobble, fewer give a smoother one; a lower persistence fades the
+// finer octaves out faster. The zero value is the default shape.
+type PerlinOctaves struct {
+	Octaves     int     // Octaves summed (0 = DefaultNoiseOctaves)
+	Persistence float64 // Amplitude of each octave relative to the previous one (0 = DefaultNoisePersistence)
+}
+
+// withDefaults returns o with unset values replaced by the defaults, so equal fields compare equal.
+func (o PerlinOctaves) withDefaults() PerlinOctaves {
+	if o.Octaves <= 0 {
+		o.Octaves = DefaultNoiseOctaves
+	}
+	if o.Persistence <= 0 {
+		o.Persistence = DefaultNoisePersistence
+	}
+	return o
+}
+
 // GeneratePerlinNoise generates a grayscale Perlin noise texture.
 // width, height: dimensions of the output image
 // scale: controls the frequency of the noise (smaller = more detail)
@@ -227,22 +270,38 @@ func GeneratePerlinNoise(width, height int, scale float64, seed int64) *image.Gr
 	return GeneratePerlinNoiseWithOffset(width, height, scale, seed, 0, 0)
 }
 
-// GeneratePerlinNoiseWithOffset generates Perlin noise aligned to a global grid.
-// Offsets allow adjacent tiles to sample the same underlying noise field to avoid seams.
+// GeneratePerlinNoiseWithOffset generates Perlin noise of the default shape aligned to a
+// global grid. Offsets allow adjacent tiles to sample the same underlying noise field to
+// avoid seams.
 func GeneratePerlinNoiseWithOffset(
 	width, height int,
 	scale float64,
 	seed int64,
 	offsetX, offsetY int,
 ) *image.Gray {
+	return samplePerlin(newPerlin(seed, PerlinOctaves{}), width, height, scale, offsetX, offsetY)
+}
+
+// newPerlin creates the Perlin generator sampled for seed and octaves.
+func newPerlin(seed int64, octaves PerlinOctaves) *perlin.Perlin {
+	octaves = octaves.withDefaults()
 	// Create Perlin noise generator with octaves, alpha, and beta parameters
-	// alpha: persistence (how much each octave contributes)
+	// alpha: divisor of each octave's amplitude (the inverse of the persistence)
 	// beta: lacunarity (frequency multiplier between octaves)
 	// n: number of octaves
-	p := perlin.NewPerlin(2.0, 2.0, 3, seed)
+	return perlin.NewPerlin(1/octaves.Persistence, 2.0, int32(octaves.Octaves), seed)
+}
 
+// samplePerlin samples p into a width×height image starting at global pixel (offsetX, offsetY).
+func samplePerlin(p *perlin.Perlin, width, height int, scale float64, offsetX, offsetY int) *image.Gray {
 	noise := image.NewGray(image.Rect(0, 0, width, height))
+	samplePerlinInto(noise, p, scale, offsetX, offsetY)
+	return noise
+}
 
+// samplePerlinInto is samplePerlin writing every pixel of noise instead of a new image.
+func samplePerlinInto(noise *image.Gray, p *perlin.Perlin, scale float64, offsetX, offsetY int) {
+	width, height := noise.Rect.Dx(), noise.Rect.Dy()
 	for y := 0; y < height; y++ {
 		for x := 0; x < width; x++ {
 			// Sample Perlin noise at normalized coordinates
@@ -257,11 +316,9 @@ func GeneratePerlinNoiseWithOffset(
 			normalized := (val + 1.0) / 2.0
 			gray := uint8(math.Max(0, math.Min(255, normalized*255)))
 
-			noise.SetGray(x, y, color.Gray{Y: gray})
+			noise.Pix[y*noise.Stride+x] = gray
 		}
 	}
-
-	return noise
 }
 
 // smoothstep performs smooth Hermite interpolation between 0 and 1.
@@ -277,29 +334,84 @@ func smoothstep(edge0, edge1, x float64) float64 {
 	return t * t * (3 - 2*t)
 }
 
+// Noise falloff curves for NoiseFalloff.Curve.
+const (
+	FalloffSmoothstep = "smoothstep" // Hermite ease-in/out (the default)
+	FalloffLinear     = "linear"     // Straight ramp; thin features pick up noise sooner
+	FalloffPower      = "power"      // t^Exponent; exponents above 1 keep thin features cleaner
+)
+
+// NoiseFalloff selects how adaptive noise ramps up from minDist to maxDist.
+// The zero value is smoothstep.
+type NoiseFalloff struct {
+	Curve    string  // FalloffSmoothstep, FalloffLinear or FalloffPower ("" = smoothstep)
+	Exponent float64 // Exponent of FalloffPower (0 = 2)
+}
+
+// Validate reports an unknown curve or a negative exponent.
+func (f NoiseFalloff) Validate() error {
+	switch f.Curve {
+	case "", FalloffSmoothstep, FalloffLinear, FalloffPower:
+	default:
+		return fmt.Errorf("unknown noise falloff %q (want %s, %s or %s)", f.Curve, FalloffSmoothstep, FalloffLinear, FalloffPower)
+	}
+	if f.Exponent < 0 {
+		return fmt.Errorf("noise falloff exponent must not be negative, got %v", f.Exponent)
+	}
+	return nil
+}
+
+// Weight returns the noise scale (0-1) at distance d: 0 at or below minDist, 1 at or above
+// maxDist and the falloff curve in between.
+func (f NoiseFalloff) Weight(minDist, maxDist, d float64) float64 {
+	switch f.Curve {
+	case FalloffLinear, FalloffPower:
+		if d <= minDist {
+			return 0
+		}
+		if d >= maxDist {
+			return 1
+		}
+		t := (d - minDist) / (maxDist - minDist)
+		if f.Curve == FalloffLinear {
+			return t
+		}
+		exp := f.Exponent
+		if exp == 0 {
+			exp = 2
+		}
+		return math.Pow(t, exp)
+	default:
+		return smoothstep(minDist, maxDist, d)
+	}
+}
+
 // ApplyNoiseToMaskAdaptive overlays Perlin noise onto a blurred mask with distance-based attenuation.
 // For thin structures (low distance values), noise is reduced to prevent fragmentation.
 // distanceMap: euclidean distance transform of the mask (pixel values represent distance in pixels)
 // strength: base noise strength (0.0 = no noise, 1.0 = full noise)
 // minDist: distance below which noise is minimal
 // maxDist: distance above which noise is at full strength
-func ApplyNoiseToMaskAdaptive(maskImg, noise, distanceMap *image.Gray, strength float64, minDist, maxDist float64) *image.Gray {
+// falloff: curve between minDist and maxDist
+func ApplyNoiseToMaskAdaptive(maskImg, noise, distanceMap *image.Gray, strength float64, minDist, maxDist float64, falloff NoiseFalloff) *image.Gray {
 	bounds := maskImg.Bounds()
 	result := image.NewGray(bounds)
 
 	noiseBounds := noise.Bounds()
 
+	// Distances are whole pixels, so the weight of each is computed once
+	var weights [256]float64
+	for d := range weights {
+		weights[d] = falloff.Weight(minDist, maxDist, float64(d))
+	}
+
 	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
 		for x := bounds.Min.X; x < bounds.Max.X; x++ {
 			// Get mask value
 			maskVal := float64(maskImg.GrayAt(x, y).Y)
 
-			// Get distance value (pixel intensity represents distance in pixels)
-			distVal := float64(distanceMap.GrayAt(x, y).Y)
-
-			// Calculate noise scaling based on distance
-			// Use smoothstep for gradual transition
-			noiseScale := smoothstep(minDist, maxDist, distVal)
+			// Scale noise by the distance (pixel intensity represents distance in pixels)
+			noiseScale := weights[distanceMap.GrayAt(x, y).Y]
 
 			// Get noise value
 			nx := (x - bounds.Min.X) % noiseBounds.Dx()
@@ -390,72 +502,112 @@ func ApplyThreshold(mask *image.Gray, threshold uint8) *image.Gray {
 	return result
 }
 
-// ApplyThresholdWithAntialias applies a threshold with smooth antialiased edges.
-// Uses a fixed transition zone with cubic interpolation (smootherstep) for natural-looking edges.
-// The transition zone is 20 gray levels on each side of the threshold value.
-func ApplyThresholdWithAntialias(mask *image.Gray, threshold uint8) *image.Gray {
-	bounds := mask.Bounds()
-	result := image.NewGray(bounds)
-
-	// Transition zone: 20 gray levels on each side of threshold
-	const transitionWidth = 20
-
-	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
-		for x := bounds.Min.X; x < bounds.Max.X; x++ {
-			val := mask.GrayAt(x, y).Y
+// OtsuThreshold picks a threshold for mask with Otsu's method: the gray level that best
+// separates the histogram into two classes (maximum between-class variance). Values at or above
+// the returned threshold are the foreground, matching ApplyThreshold. When several levels
+// separate the classes equally well, e.g. across an empty valley between two peaks, the middle
+// one is returned. ok is false when the mask has fewer than two distinct values and there is
+// nothing to separate.
+func OtsuThreshold(mask *image.Gray) (threshold uint8, ok bool) {
+	hist := Histogram(mask)
+
+	total := 0
+	sumAll := 0.0
+	for v, n := range hist {
+		total += n
+		sumAll += float64(v * n)
+	}
 
-			// Smooth threshold with cubic interpolation
-			lower := int(threshold) - transitionWidth
-			upper := int(threshold) + transitionWidth
-
-			var outVal uint8
-			if int(val) <= lower {
-				outVal = 0
-			} else if int(val) >= upper {
-				outVal = 255
-			} else {
-				// Cubic interpolation: smootherstep (3t² - 2t³)
-				t := float32(int(val)-lower) / float32(2*transitionWidth)
-				smoothT := t * t * (3.0 - 2.0*t)
-				outVal = uint8((smoothT) * 255.0)
-			}
-			result.SetGray(x, y, color.Gray{Y: outVal})
+	// Class 0 is [0, k], class 1 is [k+1, 255]
+	bestVar := 0.0
+	bestLo, bestHi := -1, -1
+	count0 := 0
+	sum0 := 0.0
+	for k := 0; k < 255; k++ {
+		count0 += hist[k]
+		sum0 += float64(k * hist[k])
+		count1 := total - count0
+		if count0 == 0 || count1 == 0 {
+			continue
 		}
+		mean0 := sum0 / float64(count0)
+		mean1 := (sumAll - sum0) / float64(count1)
+		between := float64(count0) * float64(count1) * (mean0 - mean1) * (mean0 - mean1)
+		switch {
+		case between > bestVar*(1+1e-12):
+			bestVar = between
+			bestLo, bestHi = k, k
+		case between >= bestVar*(1-1e-12) && bestLo >= 0:
+			bestHi = k
+		}
+	}
+	if bestLo < 0 {
+		return 0, false
 	}
+	return uint8((bestLo+bestHi)/2 + 1), true
+}
 
-	return result
+// DefaultAntialiasWidth is the default antialiasing transition width in gray levels on each
+// side of the threshold.
+const DefaultAntialiasWidth uint8 = 20
+
+// ApplyThresholdWithAntialias applies a threshold with smooth antialiased edges.
+// Uses a fixed transition zone with cubic interpolation (smootherstep) for natural-looking edges.
+// The transition zone is DefaultAntialiasWidth gray levels on each side of the threshold value.
+func ApplyThresholdWithAntialias(mask *image.Gray, threshold uint8) *image.Gray {
+	return ApplyThresholdWithAntialiasWidth(mask, threshold, DefaultAntialiasWidth)
+}
+
+// ApplyThresholdWithAntialiasWidth applies a threshold with smooth antialiased edges over a
+// transition zone of width gray levels on each side of the threshold value.
+// A width of 0 behaves exactly like ApplyThreshold.
+func ApplyThresholdWithAntialiasWidth(mask *image.Gray, threshold, width uint8) *image.Gray {
+	return applyThresholdSmooth(mask, threshold, width, false)
 }
 
 // ApplyThresholdWithAntialiasAndInvert applies a threshold with smooth antialiased edges.
 // Uses a fixed transition zone with cubic interpolation (smootherstep) for natural-looking edges.
-// The transition zone is 20 gray levels on each side of the threshold value.
+// The transition zone is DefaultAntialiasWidth gray levels on each side of the threshold value.
 func ApplyThresholdWithAntialiasAndInvert(mask *image.Gray, threshold uint8) *image.Gray {
+	return ApplyThresholdWithAntialiasAndInvertWidth(mask, threshold, DefaultAntialiasWidth)
+}
+
+// ApplyThresholdWithAntialiasAndInvertWidth is the inverted variant of
+// ApplyThresholdWithAntialiasWidth. A width of 0 behaves exactly like an inverted ApplyThreshold.
+func ApplyThresholdWithAntialiasAndInvertWidth(mask *image.Gray, threshold, width uint8) *image.Gray {
+	return applyThresholdSmooth(mask, threshold, width, true)
+}
+
+func applyThresholdSmooth(mask *image.Gray, threshold, width uint8, invert bool) *image.Gray {
 	bounds := mask.Bounds()
 	result := image.NewGray(bounds)
 
-	// Transition zone: 20 gray levels on each side of threshold
-	const transitionWidth = 20
+	lower := int(threshold) - int(width)
+	upper := int(threshold) + int(width)
 
 	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
 		for x := bounds.Min.X; x < bounds.Max.X; x++ {
-			val := mask.GrayAt(x, y).Y
+			val := int(mask.GrayAt(x, y).Y)
 
 			// Smooth threshold with cubic interpolation
-			lower := int(threshold) - transitionWidth
-			upper := int(threshold) + transitionWidth
-
-			var outVal uint8
-			if int(val) <= lower {
-				outVal = 255
-			} else if int(val) >= upper {
-				outVal = 0
-			} else {
+			var smoothT float32
+			if width == 0 {
+				// Hard threshold, identical to ApplyThreshold
+				if val >= upper {
+					smoothT = 1
+				}
+			} else if val >= upper {
+				smoothT = 1
+			} else if val > lower {
 				// Cubic interpolation: smootherstep (3t² - 2t³)
-				t := float32(int(val)-lower) / float32(2*transitionWidth)
-				smoothT := t * t * (3.0 - 2.0*t)
-				outVal = uint8((1.0 - smoothT) * 255.0)
+				t := float32(val-lower) / float32(2*int(width))
+				smoothT = t * t * (3.0 - 2.0*t)
+			}
+
+			if invert {
+				smoothT = 1.0 - smoothT
 			}
-			result.SetGray(x, y, color.Gray{Y: outVal})
+			result.SetGray(x, y, color.Gray{Y: uint8(smoothT * 255.0)})
 		}
 	}
 
@@ -588,6 +740,62 @@ func BoxBlur(mask *image.Gray, radius int) *image.Gray {
 	return dst
 }
 
+// BoxBlurIntegral applies the same box blur as BoxBlur using a summed-area table, computing
+// each output pixel from four table lookups in a single pass regardless of radius.
+// Output matches BoxBlur to within one gray level (BoxBlur truncates between its passes).
+//
+// The table is accumulated in uint32. Entries may wrap for very large images, but every
+// window sum is at most 255·(2r+1)², so the modular differences stay exact.
+func BoxBlurIntegral(mask *image.Gray, radius int) *image.Gray {
+	bounds := mask.Bounds()
+	dst := image.NewGray(bounds)
+	if radius < 1 {
+		// No blur needed, return a copy
+		copy(dst.Pix, mask.Pix)
+		return dst
+	}
+
+	width := bounds.Dx()
+	height := bounds.Dy()
+
+	// sat[(y+1)*stride+(x+1)] holds the sum of all pixels in [0,x]×[0,y]
+	stride := width + 1
+	sat := make([]uint32, stride*(height+1))
+	for y := 0; y < height; y++ {
+		row := mask.Pix[y*mask.Stride : y*mask.Stride+width]
+		prev := sat[y*stride : (y+1)*stride]
+		cur := sat[(y+1)*stride : (y+2)*stride]
+		var rowSum uint32
+		for x, v := range row {
+			rowSum += uint32(v)
+			cur[x+1] = prev[x+1] + rowSum
+		}
+	}
+
+	for y := 0; y < height; y++ {
+		y0 := max(y-radius, 0)
+		y1 := min(y+radius+1, height)
+		top := sat[y0*stride:]
+		bottom := sat[y1*stride:]
+		rows := y1 - y0
+		out := dst.Pix[y*dst.Stride : y*dst.Stride+width]
+
+		for x := 0; x < width; x++ {
+			x0 := max(x-radius, 0)
+			x1 := min(x+radius+1, width)
+			sum := bottom[x1] - bottom[x0] - top[x1] + top[x0]
+			out[x] = uint8(sum / uint32(rows*(x1-x0)))
+		}
+	}
+
+	return dst
+}
+
+// integralBlurMinRadius is the radius above which BoxBlurSigma switches to BoxBlurIntegral.
+// Smaller radii (the default mask blurs) keep the sliding-window path so their output stays
+// byte-identical to earlier releases.
+const integralBlurMinRadius = 8
+
 // BoxBlurSigma applies a 3-pass box blur to approximate a Gaussian blur.
 // This is optimized for small sigma values (σ < 5) and provides significant
 // performance improvement over true Gaussian blur while maintaining good quality.
@@ -617,16 +825,29 @@ func BoxBlurSigma(mask *image.Gray, sigma float32) *image.Gray {
 	}
 
 	// Apply box blur 3 times to approximate Gaussian
-	result := BoxBlur(mask, radius)
-	result = BoxBlur(result, radius)
-	result = BoxBlur(result, radius)
+	blur := BoxBlur
+	if radius > integralBlurMinRadius {
+		blur = BoxBlurIntegral
+	}
+	result := blur(mask, radius)
+	result = blur(result, radius)
+	result = blur(result, radius)
 
 	return result
 }
 
+// antialiasGaussianMaxSigma is the sigma below which AntialiasEdges uses a true Gaussian.
+// BoxBlurSigma clamps its box radius to at least 1, so three passes spread an edge over
+// about 7 pixels no matter how small sigma is; for σ < 1 that is far more than the Gaussian
+// it approximates, while the exact kernel is still tiny.
+const antialiasGaussianMaxSigma = 1.0
+
 // AntialiasEdges applies subtle antialiasing to smooth sharp mask edges.
-// This is essentially a light blur to soften transitions.
+// This is essentially a light blur to soften transitions: a true Gaussian for small sigmas,
+// where the box approximation over-blurs, and BoxBlurSigma for larger ones.
 func AntialiasEdges(mask *image.Gray, sigma float32) *image.Gray {
-	// Use BoxBlurSigma for fast antialiasing
+	if sigma > 0 && sigma < antialiasGaussianMaxSigma {
+		return GaussianBlur(mask, sigma)
+	}
 	return BoxBlurSigma(mask, sigma)
 }
@@ -1,8 +1,11 @@
 package mask
 
 import (
+	"bytes"
+	"fmt"
 	"image"
 	"image/color"
+	"math"
 	"testing"
 )
 
@@ -166,6 +169,42 @@ func TestExtractBinaryMask(t *testing.T) {
 }
 
 // TestGaussianBlur tests applying Gaussian blur to soften mask edges
+func TestIsEmpty(t *testing.T) {
+	zero := image.NewGray(image.Rect(0, 0, 8, 8))
+	speck := image.NewGray(image.Rect(0, 0, 8, 8))
+	speck.SetGray(7, 7, color.Gray{Y: 1})
+	// A sub-image whose backing rows are covered outside its bounds
+	sub := image.NewGray(image.Rect(0, 0, 8, 8))
+	for i := range sub.Pix {
+		sub.Pix[i] = 255
+	}
+	for y := 2; y < 6; y++ {
+		for x := 2; x < 6; x++ {
+			sub.SetGray(x, y, color.Gray{})
+		}
+	}
+
+	tests := []struct {
+		name string
+		m    *image.Gray
+		want bool
+	}{
+		{name: "nil", m: nil, want: true},
+		{name: "zero", m: zero, want: true},
+		{name: "one speck", m: speck, want: false},
+		{name: "empty sub-image", m: sub.SubImage(image.Rect(2, 2, 6, 6)).(*image.Gray), want: true},
+		{name: "covered parent", m: sub, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsEmpty(tt.m); got != tt.want {
+				t.Errorf("IsEmpty = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestGaussianBlur(t *testing.T) {
 	// Create a simple binary mask with a sharp edge
 	mask := image.NewGray(image.Rect(0, 0, 10, 10))
@@ -343,6 +382,55 @@ func TestApplyNoiseToMask(t *testing.T) {
 	}
 }
 
+// TestApplyNoiseToMaskAdaptiveFalloff feeds a distance gradient with constant noise and
+// checks that the applied noise follows the selected falloff curve.
+func TestApplyNoiseToMaskAdaptiveFalloff(t *testing.T) {
+	const minDist, maxDist = 2.0, 12.0
+	const noiseOffset, strength = 100.0, 1.0
+
+	// Distance grows by one pixel per column; mask and noise are constant
+	bounds := image.Rect(0, 0, 16, 1)
+	maskImg := image.NewGray(bounds)
+	noise := image.NewGray(bounds)
+	dist := image.NewGray(bounds)
+	for x := 0; x < 16; x++ {
+		maskImg.SetGray(x, 0, color.Gray{Y: 100})
+		noise.SetGray(x, 0, color.Gray{Y: 128 + noiseOffset})
+		dist.SetGray(x, 0, color.Gray{Y: uint8(x)})
+	}
+
+	tests := []struct {
+		name    string
+		falloff NoiseFalloff
+		curve   func(t float64) float64
+	}{
+		{"default is smoothstep", NoiseFalloff{}, func(t float64) float64 { return t * t * (3 - 2*t) }},
+		{"smoothstep", NoiseFalloff{Curve: FalloffSmoothstep}, func(t float64) float64 { return t * t * (3 - 2*t) }},
+		{"linear", NoiseFalloff{Curve: FalloffLinear}, func(t float64) float64 { return t }},
+		{"power default exponent", NoiseFalloff{Curve: FalloffPower}, func(t float64) float64 { return t * t }},
+		{"power 3", NoiseFalloff{Curve: FalloffPower, Exponent: 3}, func(t float64) float64 { return t * t * t }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.falloff.Validate(); err != nil {
+				t.Fatalf("Validate: %v", err)
+			}
+			result := ApplyNoiseToMaskAdaptive(maskImg, noise, dist, strength, minDist, maxDist, tt.falloff)
+			for x := 0; x < 16; x++ {
+				w := math.Min(math.Max((float64(x)-minDist)/(maxDist-minDist), 0), 1)
+				want := uint8(math.Min(100+noiseOffset*strength*tt.curve(w), 255))
+				if got := result.GrayAt(x, 0).Y; got != want {
+					t.Errorf("distance %d: got %d, want %d", x, got, want)
+				}
+			}
+		})
+	}
+
+	if err := (NoiseFalloff{Curve: "cubic"}).Validate(); err == nil {
+		t.Error("expected an error for an unknown curve")
+	}
+}
+
 // TestApplyThreshold tests thresholding to sharpen mask edges
 func TestApplyThreshold(t *testing.T) {
 	// Create a gradient mask (soft edge)
@@ -380,6 +468,69 @@ func TestApplyThreshold(t *testing.T) {
 	}
 }
 
+// bimodalMask returns a mask whose histogram has two triangular peaks of the given half-width
+// centered at lo and hi, with weights wLo and wHi.
+func bimodalMask(lo, hi, halfWidth, wLo, wHi int) *image.Gray {
+	var values []uint8
+	for v := 0; v < 256; v++ {
+		n := wLo*max(0, halfWidth-abs(v-lo)) + wHi*max(0, halfWidth-abs(v-hi))
+		for i := 0; i < n; i++ {
+			values = append(values, uint8(v))
+		}
+	}
+	m := image.NewGray(image.Rect(0, 0, len(values), 1))
+	copy(m.Pix, values)
+	return m
+}
+
+func TestOtsuThreshold(t *testing.T) {
+	tests := []struct {
+		name   string
+		mask   *image.Gray
+		lo, hi uint8 // Acceptable threshold range (inclusive)
+	}{
+		// Peaks span 21-99 and 151-229; every threshold in the empty valley separates them
+		{"separated peaks", bimodalMask(60, 190, 40, 1, 1), 100, 151},
+		{"separated unequal peaks", bimodalMask(60, 190, 40, 5, 1), 100, 151},
+		// Peaks overlap around 125, where the histogram has its minimum
+		{"overlapping peaks", bimodalMask(60, 190, 70, 1, 1), 117, 133},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := OtsuThreshold(tt.mask)
+			if !ok {
+				t.Fatal("expected a threshold for a bimodal histogram")
+			}
+			if got < tt.lo || got > tt.hi {
+				t.Errorf("OtsuThreshold = %d, want within the valley [%d, %d]", got, tt.lo, tt.hi)
+			}
+		})
+	}
+
+	t.Run("separates at the valley", func(t *testing.T) {
+		m := bimodalMask(60, 190, 40, 1, 1)
+		threshold, _ := OtsuThreshold(m)
+		result := ApplyThreshold(m, threshold)
+		for i, v := range m.Pix {
+			want := uint8(0)
+			if v > 125 {
+				want = 255
+			}
+			if result.Pix[i] != want {
+				t.Fatalf("value %d thresholded to %d, want %d", v, result.Pix[i], want)
+			}
+		}
+	})
+
+	t.Run("uniform mask", func(t *testing.T) {
+		m := image.NewGray(image.Rect(0, 0, 4, 4))
+		if _, ok := OtsuThreshold(m); ok {
+			t.Error("expected no threshold for a uniform mask")
+		}
+	})
+}
+
 // TestAntialiasEdges tests applying subtle antialiasing to mask edges
 func TestAntialiasEdges(t *testing.T) {
 	// Create a binary mask with sharp edges
@@ -419,6 +570,43 @@ func TestAntialiasEdges(t *testing.T) {
 	}
 }
 
+// TestAntialiasEdgesSmallSigmaSharpness compares the edge transition of a σ=0.5 antialias
+// against the box approximation, which over-blurs small sigmas.
+func TestAntialiasEdgesSmallSigmaSharpness(t *testing.T) {
+	mask := image.NewGray(image.Rect(0, 0, 32, 4))
+	for y := 0; y < 4; y++ {
+		for x := 16; x < 32; x++ {
+			mask.SetGray(x, y, color.Gray{Y: 255})
+		}
+	}
+
+	// transitionWidth counts the partially covered pixels along the middle row
+	transitionWidth := func(img *image.Gray) int {
+		n := 0
+		for x := 0; x < 32; x++ {
+			if v := img.GrayAt(x, 2).Y; v > 0 && v < 255 {
+				n++
+			}
+		}
+		return n
+	}
+
+	antialiased := AntialiasEdges(mask, 0.5)
+	box := BoxBlurSigma(mask, 0.5)
+	aaWidth, boxWidth := transitionWidth(antialiased), transitionWidth(box)
+	if aaWidth == 0 {
+		t.Fatal("expected σ=0.5 antialiasing to soften the edge")
+	}
+	if aaWidth >= boxWidth {
+		t.Errorf("transition width = %d px, want narrower than the box approximation's %d px", aaWidth, boxWidth)
+	}
+
+	// Larger sigmas keep the fast box path
+	if got, want := AntialiasEdges(mask, 2), BoxBlurSigma(mask, 2); !bytes.Equal(got.Pix, want.Pix) {
+		t.Error("expected σ=2 antialiasing to match BoxBlurSigma")
+	}
+}
+
 // TestApplyThresholdWithAntialias tests the threshold with cubic interpolation antialiasing
 func TestApplyThresholdWithAntialias(t *testing.T) {
 	t.Run("basic_threshold", func(t *testing.T) {
@@ -488,6 +676,61 @@ func TestApplyThresholdWithAntialias(t *testing.T) {
 	})
 }
 
+// TestApplyThresholdWithAntialiasWidth tests the configurable transition width
+func TestApplyThresholdWithAntialiasWidth(t *testing.T) {
+	// One pixel per gray level
+	mask := image.NewGray(image.Rect(0, 0, 256, 1))
+	for x := 0; x < 256; x++ {
+		mask.SetGray(x, 0, color.Gray{Y: uint8(x)})
+	}
+
+	t.Run("zero_width_matches_hard_threshold", func(t *testing.T) {
+		hard := ApplyThreshold(mask, 128)
+		soft := ApplyThresholdWithAntialiasWidth(mask, 128, 0)
+		inverted := ApplyThresholdWithAntialiasAndInvertWidth(mask, 128, 0)
+		for x := 0; x < 256; x++ {
+			if soft.GrayAt(x, 0) != hard.GrayAt(x, 0) {
+				t.Errorf("x=%d: got %d, want %d", x, soft.GrayAt(x, 0).Y, hard.GrayAt(x, 0).Y)
+			}
+			if inverted.GrayAt(x, 0).Y != 255-hard.GrayAt(x, 0).Y {
+				t.Errorf("x=%d inverted: got %d, want %d", x, inverted.GrayAt(x, 0).Y, 255-hard.GrayAt(x, 0).Y)
+			}
+		}
+	})
+
+	t.Run("default_width_matches_legacy", func(t *testing.T) {
+		got := ApplyThresholdWithAntialiasWidth(mask, 128, DefaultAntialiasWidth)
+		want := ApplyThresholdWithAntialias(mask, 128)
+		for x := 0; x < 256; x++ {
+			if got.GrayAt(x, 0) != want.GrayAt(x, 0) {
+				t.Fatalf("x=%d: got %d, want %d", x, got.GrayAt(x, 0).Y, want.GrayAt(x, 0).Y)
+			}
+		}
+	})
+
+	t.Run("width_controls_transition", func(t *testing.T) {
+		prev := 0
+		for _, width := range []uint8{5, 20, 40} {
+			result := ApplyThresholdWithAntialiasWidth(mask, 128, width)
+			partial := 0
+			for x := 0; x < 256; x++ {
+				v := result.GrayAt(x, 0).Y
+				if v == 0 || v == 255 {
+					continue
+				}
+				partial++
+				if x <= 128-int(width) || x >= 128+int(width) {
+					t.Errorf("width=%d: x=%d outside the transition zone is %d", width, x, v)
+				}
+			}
+			if partial <= prev {
+				t.Errorf("width=%d: expected a wider transition than %d pixels, got %d", width, prev, partial)
+			}
+			prev = partial
+		}
+	})
+}
+
 // TestWatercolorPipeline tests the complete watercolor effect pipeline
 func TestWatercolorPipeline(t *testing.T) {
 	// Create a test layer image with a blue feature
@@ -607,6 +850,45 @@ func TestBoxBlurZeroRadius(t *testing.T) {
 	}
 }
 
+// TestBoxBlurIntegralMatchesBoxBlur tests that the summed-area table blur matches the
+// sliding-window implementation to within one gray level
+func TestBoxBlurIntegralMatchesBoxBlur(t *testing.T) {
+	mask := image.NewGray(image.Rect(0, 0, 97, 64))
+	for i := range mask.Pix {
+		mask.Pix[i] = uint8((i*7919 + i/13*31) % 256)
+	}
+
+	for _, radius := range []int{0, 1, 3, 9, 20, 70} {
+		t.Run(fmt.Sprintf("radius_%d", radius), func(t *testing.T) {
+			want := BoxBlur(mask, radius)
+			got := BoxBlurIntegral(mask, radius)
+			if got.Bounds() != want.Bounds() {
+				t.Fatalf("bounds %v != %v", got.Bounds(), want.Bounds())
+			}
+			for i := range want.Pix {
+				if d := absDiffU8(got.Pix[i], want.Pix[i]); d > 1 {
+					t.Fatalf("pixel %d: got %d, want %d (diff %d)", i, got.Pix[i], want.Pix[i], d)
+				}
+			}
+		})
+	}
+}
+
+// TestBoxBlurIntegralLargeImage tests that the uint32 table stays exact on large white images
+func TestBoxBlurIntegralLargeImage(t *testing.T) {
+	mask := image.NewGray(image.Rect(0, 0, 1024, 1024))
+	for i := range mask.Pix {
+		mask.Pix[i] = 255
+	}
+
+	result := BoxBlurIntegral(mask, 12)
+	for _, p := range []image.Point{{0, 0}, {512, 512}, {1023, 1023}} {
+		if v := result.GrayAt(p.X, p.Y).Y; v != 255 {
+			t.Errorf("pixel %v: got %d, want 255", p, v)
+		}
+	}
+}
+
 // TestBoxBlurSigma tests the sigma-to-radius conversion and 3-pass blur
 func TestBoxBlurSigma(t *testing.T) {
 	tests := []struct {
@@ -751,3 +1033,21 @@ func TestBoxBlurVsGaussianQuality(t *testing.T) {
 		t.Error("Box blur should keep corners dark")
 	}
 }
+
+// BenchmarkBoxBlurIntegral compares the sliding-window and summed-area table box blurs
+// on a 1024px metatile-sized mask
+func BenchmarkBoxBlurIntegral(b *testing.B) {
+	mask := createCircleMask(1024, 1024, 512, 512, 400)
+	for _, radius := range []int{4, 8, 12, 24} {
+		b.Run(fmt.Sprintf("SlidingWindow/r%d", radius), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				BoxBlur(mask, radius)
+			}
+		})
+		b.Run(fmt.Sprintf("Integral/r%d", radius), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				BoxBlurIntegral(mask, radius)
+			}
+		})
+	}
+}
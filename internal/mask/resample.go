@@ -0,0 +1,148 @@
+package mask
+
+import (
+	"image"
+	"math"
+)
+
+// ResampleBilinear resizes a grayscale image to w×h using bilinear interpolation. The corner
+// pixels of src and the result are aligned, so src pixel i lands on result pixel
+// i*(w-1)/(sw-1): upscaling samples taken every d pixels to (sw-1)*d+1 pixels puts every
+// sample back on its own pixel and interpolates in between. Returns nil for an empty size.
+func ResampleBilinear(src *image.Gray, w, h int) *image.Gray {
+	if src == nil || w <= 0 || h <= 0 {
+		return nil
+	}
+	b := src.Bounds()
+	sw, sh := b.Dx(), b.Dy()
+	dst := image.NewGray(image.Rect(0, 0, w, h))
+	if sw == 0 || sh == 0 {
+		return dst
+	}
+
+	cols, colT := resampleAxis(sw, w)
+	rows, rowT := resampleAxis(sh, h)
+	base := src.PixOffset(b.Min.X, b.Min.Y)
+
+	for y := 0; y < h; y++ {
+		r0 := base + rows[y]*src.Stride
+		r1 := base + min(rows[y]+1, sh-1)*src.Stride
+		ty := rowT[y]
+
+		for x := 0; x < w; x++ {
+			c0, tx := cols[x], colT[x]
+			c1 := min(c0+1, sw-1)
+			top := float64(src.Pix[r0+c0])*(1-tx) + float64(src.Pix[r0+c1])*tx
+			bottom := float64(src.Pix[r1+c0])*(1-tx) + float64(src.Pix[r1+c1])*tx
+			dst.Pix[y*dst.Stride+x] = uint8(math.Round(top*(1-ty) + bottom*ty))
+		}
+	}
+
+	return dst
+}
+
+// resampleAxis maps each of the n result pixels along an axis to the source pixel before it
+// (of sn) and the fraction towards the next one, aligning the end pixels. Positions are
+// computed in integers, so a given fraction of a source step always yields the same weight.
+func resampleAxis(sn, n int) (idx []int, frac []float64) {
+	idx = make([]int, n)
+	frac = make([]float64, n)
+	if sn == 1 || n == 1 {
+		return idx, frac
+	}
+	num, den := sn-1, n-1
+	for i := range idx {
+		p := i * num
+		idx[i] = p / den
+		frac[i] = float64(p%den) / float64(den)
+	}
+	return idx, frac
+}
+
+// GeneratePerlinNoiseDownscaled generates Perlin noise like GeneratePerlinNoiseWithOffset, in
+// the shape given by octaves, but samples the field only every downscale pixels and bilinearly
+// interpolates in between.
+//
+// The coarse samples sit at global pixel positions that are multiples of downscale, so two
+// tiles with different offsets interpolate between the same samples wherever they overlap
+// and stay seamless. For low-frequency noise (scale well above downscale) the result is
+// visually indistinguishable from full-resolution noise at roughly 1/downscale² of the cost.
+func GeneratePerlinNoiseDownscaled(
+	width, height int,
+	scale float64,
+	seed int64,
+	octaves PerlinOctaves,
+	offsetX, offsetY int,
+	downscale int,
+) *image.Gray {
+	dst := image.NewGray(image.Rect(0, 0, width, height))
+	FillPerlinNoiseDownscaled(dst, scale, seed, octaves, offsetX, offsetY, downscale)
+	return dst
+}
+
+// FillPerlinNoiseDownscaled is GeneratePerlinNoiseDownscaled writing into dst, whose size
+// gives the width and height, instead of a new image. Every pixel of dst is overwritten, so
+// its previous contents don't matter and buffers can be reused across tiles.
+func FillPerlinNoiseDownscaled(
+	dst *image.Gray,
+	scale float64,
+	seed int64,
+	octaves PerlinOctaves,
+	offsetX, offsetY int,
+	downscale int,
+) {
+	perlinNoiseDownscaled(dst, scale, seed, octaves, offsetX, offsetY, downscale, samplePerlinField)
+}
+
+// noiseFieldFunc samples the Perlin field like GeneratePerlinNoiseWithOffset, in the shape
+// given by octaves, into every pixel of dst.
+type noiseFieldFunc func(dst *image.Gray, scale float64, seed int64, octaves PerlinOctaves, offsetX, offsetY int)
+
+// samplePerlinField is the uncached noiseFieldFunc.
+func samplePerlinField(dst *image.Gray, scale float64, seed int64, octaves PerlinOctaves, offsetX, offsetY int) {
+	samplePerlinInto(dst, newPerlin(seed, octaves), scale, offsetX, offsetY)
+}
+
+// perlinNoiseDownscaled implements FillPerlinNoiseDownscaled, sampling the field with field.
+func perlinNoiseDownscaled(
+	dst *image.Gray,
+	scale float64,
+	seed int64,
+	octaves PerlinOctaves,
+	offsetX, offsetY int,
+	downscale int,
+	field noiseFieldFunc,
+) {
+	if downscale <= 1 {
+		field(dst, scale, seed, octaves, offsetX, offsetY)
+		return
+	}
+	width, height := dst.Rect.Dx(), dst.Rect.Dy()
+
+	// Coarse grid cells covering [offset, offset+size], plus one sample past the end
+	startX := floorDiv(offsetX, downscale)
+	startY := floorDiv(offsetY, downscale)
+	endX := floorDiv(offsetX+width-1, downscale) + 1
+	endY := floorDiv(offsetY+height-1, downscale) + 1
+
+	coarse := image.NewGray(image.Rect(0, 0, endX-startX+1, endY-startY+1))
+	field(coarse, scale/float64(downscale), seed, octaves, startX, startY)
+
+	// Upscaling with aligned corners puts coarse sample k back on global pixel k*downscale, so
+	// overlapping tiles interpolate identical values; dst is the window at the offset.
+	up := ResampleBilinear(coarse, (endX-startX)*downscale+1, (endY-startY)*downscale+1)
+	x0 := offsetX - startX*downscale
+	y0 := offsetY - startY*downscale
+	for y := 0; y < height; y++ {
+		copy(dst.Pix[y*dst.Stride:y*dst.Stride+width], up.Pix[(y0+y)*up.Stride+x0:])
+	}
+}
+
+// floorDiv divides rounding toward negative infinity (offsets can be negative due to padding).
+func floorDiv(a, b int) int {
+	q := a / b
+	if (a%b != 0) && ((a < 0) != (b < 0)) {
+		q--
+	}
+	return q
+}
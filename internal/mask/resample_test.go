@@ -0,0 +1,122 @@
+package mask
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestResampleBilinear(t *testing.T) {
+	t.Run("identity size", func(t *testing.T) {
+		src := image.NewGray(image.Rect(0, 0, 3, 2))
+		for i := range src.Pix {
+			src.Pix[i] = uint8(i * 40)
+		}
+		dst := ResampleBilinear(src, 3, 2)
+		for i := range src.Pix {
+			if dst.Pix[i] != src.Pix[i] {
+				t.Fatalf("pixel %d: got %d, want %d", i, dst.Pix[i], src.Pix[i])
+			}
+		}
+	})
+
+	t.Run("upscale interpolates", func(t *testing.T) {
+		src := image.NewGray(image.Rect(0, 0, 2, 1))
+		src.SetGray(0, 0, color.Gray{Y: 0})
+		src.SetGray(1, 0, color.Gray{Y: 200})
+
+		dst := ResampleBilinear(src, 5, 1)
+		want := []uint8{0, 50, 100, 150, 200}
+		for x, w := range want {
+			if got := dst.GrayAt(x, 0).Y; got != w {
+				t.Errorf("x=%d: got %d, want %d", x, got, w)
+			}
+		}
+	})
+
+	t.Run("sub-image", func(t *testing.T) {
+		src := image.NewGray(image.Rect(0, 0, 4, 4))
+		src.SetGray(2, 1, color.Gray{Y: 100})
+		src.SetGray(3, 1, color.Gray{Y: 200})
+
+		dst := ResampleBilinear(src.SubImage(image.Rect(2, 1, 4, 2)).(*image.Gray), 3, 1)
+		want := []uint8{100, 150, 200}
+		for x, w := range want {
+			if got := dst.GrayAt(x, 0).Y; got != w {
+				t.Errorf("x=%d: got %d, want %d", x, got, w)
+			}
+		}
+	})
+
+	t.Run("invalid size", func(t *testing.T) {
+		if ResampleBilinear(image.NewGray(image.Rect(0, 0, 2, 2)), 0, 4) != nil {
+			t.Fatal("expected nil for zero width")
+		}
+	})
+}
+
+func TestGeneratePerlinNoiseDownscaledSeamless(t *testing.T) {
+	const (
+		scale     = 30.0
+		seed      = int64(42)
+		downscale = 4
+	)
+
+	// Two overlapping tiles with offsets that are not multiples of downscale (as with padding)
+	// must agree exactly wherever they cover the same global pixels.
+	a := GeneratePerlinNoiseDownscaled(100, 60, scale, seed, PerlinOctaves{}, -13, -7, downscale)
+	b := GeneratePerlinNoiseDownscaled(100, 60, scale, seed, PerlinOctaves{}, 37, 11, downscale)
+
+	for gy := 11; gy < 53; gy++ {
+		for gx := 37; gx < 87; gx++ {
+			va := a.GrayAt(gx+13, gy+7).Y
+			vb := b.GrayAt(gx-37, gy-11).Y
+			if va != vb {
+				t.Fatalf("global (%d,%d): tile A %d != tile B %d", gx, gy, va, vb)
+			}
+		}
+	}
+}
+
+func TestGeneratePerlinNoiseDownscaledMatchesFullAtSamples(t *testing.T) {
+	full := GeneratePerlinNoiseWithOffset(64, 64, 30.0, 7, -8, 16)
+	coarse := GeneratePerlinNoiseDownscaled(64, 64, 30.0, 7, PerlinOctaves{}, -8, 16, 4)
+
+	// Pixels on the coarse grid are sampled directly, so they match full resolution.
+	for y := 0; y < 64; y += 4 {
+		for x := 0; x < 64; x += 4 {
+			if d := int(full.GrayAt(x, y).Y) - int(coarse.GrayAt(x, y).Y); d < -1 || d > 1 {
+				t.Fatalf("(%d,%d): full %d vs downscaled %d", x, y, full.GrayAt(x, y).Y, coarse.GrayAt(x, y).Y)
+			}
+		}
+	}
+}
+
+func TestPerlinOctaves(t *testing.T) {
+	const scale, seed = 30.0, 42
+	def := GeneratePerlinNoiseWithOffset(64, 64, scale, seed, -8, 16)
+	explicit := GeneratePerlinNoiseDownscaled(64, 64, scale, seed, PerlinOctaves{Octaves: DefaultNoiseOctaves, Persistence: DefaultNoisePersistence}, -8, 16, 1)
+	if !bytes.Equal(def.Pix, explicit.Pix) {
+		t.Error("explicit default octaves differ from the default noise")
+	}
+
+	shapes := []PerlinOctaves{{Octaves: 1}, {Octaves: 6}, {Persistence: 0.8}}
+	for _, octaves := range shapes {
+		a := GeneratePerlinNoiseDownscaled(64, 64, scale, seed, octaves, -8, 16, 1)
+		b := GeneratePerlinNoiseDownscaled(64, 64, scale, seed, octaves, -8, 16, 1)
+		if !bytes.Equal(a.Pix, b.Pix) {
+			t.Errorf("%+v: noise is not deterministic for a seed", octaves)
+		}
+		if bytes.Equal(a.Pix, def.Pix) {
+			t.Errorf("%+v: noise equals the default shape", octaves)
+		}
+
+		// The cache keeps fields of different shapes apart
+		cache := NewNoiseCache(64)
+		cache.GeneratePerlinNoiseDownscaled(64, 64, scale, seed, PerlinOctaves{}, -8, 16, 1)
+		if cached := cache.GeneratePerlinNoiseDownscaled(64, 64, scale, seed, octaves, -8, 16, 1); !bytes.Equal(cached.Pix, a.Pix) {
+			t.Errorf("%+v: cached noise differs from uncached", octaves)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package mask
+
+import (
+	"fmt"
+	"image"
+)
+
+// Histogram counts the pixels of m at each gray level.
+func Histogram(m *image.Gray) [256]int {
+	var hist [256]int
+	bounds := m.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		row := m.Pix[m.PixOffset(bounds.Min.X, y):m.PixOffset(bounds.Max.X, y)]
+		for _, v := range row {
+			hist[v]++
+		}
+	}
+	return hist
+}
+
+// Stats summarizes the gray values of a mask, e.g. to see why a threshold leaves a layer
+// empty: a blurred mask that never exceeds 40 has nothing left at threshold 50.
+type Stats struct {
+	Pixels int
+	Min    uint8
+	Max    uint8
+	Mean   float64
+	Median uint8 // Lower median for an even number of pixels
+}
+
+// ComputeStats returns the Stats of m. An empty mask has zero Stats.
+func ComputeStats(m *image.Gray) Stats {
+	return HistogramStats(Histogram(m))
+}
+
+// HistogramStats returns the Stats of the pixels counted by hist (see Histogram).
+func HistogramStats(hist [256]int) Stats {
+	var s Stats
+	sum := 0
+	for v, n := range hist {
+		s.Pixels += n
+		sum += v * n
+	}
+	if s.Pixels == 0 {
+		return s
+	}
+	s.Mean = float64(sum) / float64(s.Pixels)
+
+	first := true
+	seen := 0
+	for v, n := range hist {
+		if n == 0 {
+			continue
+		}
+		if first {
+			s.Min = uint8(v)
+			first = false
+		}
+		s.Max = uint8(v)
+		if seen < (s.Pixels+1)/2 && seen+n >= (s.Pixels+1)/2 {
+			s.Median = uint8(v)
+		}
+		seen += n
+	}
+	return s
+}
+
+// String formats s on one line, e.g. "min 0 max 212 mean 37.5 median 12".
+func (s Stats) String() string {
+	return fmt.Sprintf("min %d max %d mean %.1f median %d", s.Min, s.Max, s.Mean, s.Median)
+}
@@ -0,0 +1,53 @@
+package mask
+
+import (
+	"image"
+	"testing"
+)
+
+func TestHistogramAndStatsGradient(t *testing.T) {
+	// 256×2 gradient: every gray level twice
+	m := image.NewGray(image.Rect(0, 0, 256, 2))
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 256; x++ {
+			m.Pix[m.PixOffset(x, y)] = uint8(x)
+		}
+	}
+
+	hist := Histogram(m)
+	for v, n := range hist {
+		if n != 2 {
+			t.Fatalf("hist[%d] = %d, want 2", v, n)
+		}
+	}
+
+	want := Stats{Pixels: 512, Min: 0, Max: 255, Mean: 127.5, Median: 127}
+	if got := ComputeStats(m); got != want {
+		t.Errorf("ComputeStats = %+v, want %+v", got, want)
+	}
+
+	// A sub-image only counts its own pixels: levels 40..49
+	sub := m.SubImage(image.Rect(40, 0, 50, 1)).(*image.Gray)
+	want = Stats{Pixels: 10, Min: 40, Max: 49, Mean: 44.5, Median: 44}
+	if got := ComputeStats(sub); got != want {
+		t.Errorf("sub-image ComputeStats = %+v, want %+v", got, want)
+	}
+}
+
+func TestStatsSkewed(t *testing.T) {
+	// Mostly empty with a few bright pixels: the median stays at 0 while the mean rises
+	m := image.NewGray(image.Rect(0, 0, 10, 1))
+	m.Pix[7], m.Pix[8], m.Pix[9] = 200, 220, 240
+
+	want := Stats{Pixels: 10, Min: 0, Max: 240, Mean: 66, Median: 0}
+	if got := ComputeStats(m); got != want {
+		t.Errorf("ComputeStats = %+v, want %+v", got, want)
+	}
+	if got, want := want.String(), "min 0 max 240 mean 66.0 median 0"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+
+	if got := ComputeStats(image.NewGray(image.Rect(0, 0, 0, 0))); got != (Stats{}) {
+		t.Errorf("empty mask stats = %+v, want zero", got)
+	}
+}
@@ -0,0 +1,192 @@
+package mbtiles
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+
+	"github.com/MeKo-Tech/watercolormap/internal/tile"
+)
+
+// ConflictPolicy decides which input wins when several inputs contain the same tile.
+type ConflictPolicy string
+
+const (
+	// ConflictLastWins keeps the tile from the input listed last (the default).
+	ConflictLastWins ConflictPolicy = "last"
+	// ConflictFirstWins keeps the tile from the input listed first.
+	ConflictFirstWins ConflictPolicy = "first"
+)
+
+// MergeStats summarizes a merge.
+type MergeStats struct {
+	Metadata  Metadata // Metadata written to the output
+	Tiles     int      // Distinct tiles in the output
+	Conflicts int      // Tiles present in more than one input
+}
+
+// Merge copies all tiles from inputs into a new MBTiles file at out.
+//
+// Descriptive metadata (name, attribution, ...) is taken from the first input. The zoom range
+// is recomputed from the tiles actually present, bounds are the union of the inputs' bounds
+// (falling back to the extent of an input's tiles when it declares none), and the center is
+// recomputed from the merged bounds. All inputs must share the same tile format. out only
+// appears once the merge has succeeded.
+func Merge(out string, inputs []string, policy ConflictPolicy) (MergeStats, error) {
+	if len(inputs) == 0 {
+		return MergeStats{}, fmt.Errorf("no input files")
+	}
+	switch policy {
+	case "":
+		policy = ConflictLastWins
+	case ConflictLastWins, ConflictFirstWins:
+	default:
+		return MergeStats{}, fmt.Errorf("invalid conflict policy %q: must be %q or %q", policy, ConflictLastWins, ConflictFirstWins)
+	}
+	if _, err := os.Stat(out); err == nil {
+		return MergeStats{}, fmt.Errorf("output file already exists: %s", out)
+	}
+
+	readers := make([]*Reader, 0, len(inputs))
+	defer func() {
+		for _, r := range readers {
+			r.Close() // nolint:errcheck
+		}
+	}()
+
+	var meta Metadata
+	for i, path := range inputs {
+		r, err := OpenReader(path)
+		if err != nil {
+			return MergeStats{}, fmt.Errorf("failed to open %s: %w", path, err)
+		}
+		readers = append(readers, r)
+
+		m, err := r.Metadata()
+		if err != nil {
+			return MergeStats{}, fmt.Errorf("failed to read metadata of %s: %w", path, err)
+		}
+		if i == 0 {
+			meta = m
+		} else if m.Format != "" && meta.Format != "" && m.Format != meta.Format {
+			return MergeStats{}, fmt.Errorf("format mismatch: %s is %q but %s is %q", inputs[0], meta.Format, path, m.Format)
+		}
+	}
+
+	// Build the output next to out and move it into place once complete, so a failed merge
+	// leaves no partial file behind
+	f, err := os.CreateTemp(filepath.Dir(out), "."+filepath.Base(out)+".*.partial")
+	if err != nil {
+		return MergeStats{}, fmt.Errorf("failed to create output: %w", err)
+	}
+	tmp := f.Name()
+	f.Close() // nolint:errcheck
+	complete := false
+	defer func() {
+		if !complete {
+			for _, suffix := range []string{"", "-wal", "-shm", "-journal"} {
+				os.Remove(tmp + suffix) // nolint:errcheck
+			}
+		}
+	}()
+
+	w, err := New(tmp, meta)
+	if err != nil {
+		return MergeStats{}, fmt.Errorf("failed to create output: %w", err)
+	}
+
+	// The writer replaces existing tiles, so visiting inputs in reverse makes the first one win.
+	order := make([]int, len(readers))
+	for i := range order {
+		order[i] = i
+		if policy == ConflictFirstWins {
+			order[i] = len(readers) - 1 - i
+		}
+	}
+
+	stats := MergeStats{}
+	seen := make(map[[3]int]struct{})
+	minZoom, maxZoom := math.MaxInt, -1
+	union := emptyBounds()
+
+	for _, i := range order {
+		declared, err := readers[i].Metadata()
+		if err != nil {
+			w.Close() // nolint:errcheck
+			return MergeStats{}, fmt.Errorf("failed to read metadata of %s: %w", inputs[i], err)
+		}
+		extent := emptyBounds()
+
+		err = readers[i].IterTiles(func(z, x, y int, data []byte) error {
+			key := [3]int{z, x, y}
+			if _, dup := seen[key]; dup {
+				stats.Conflicts++
+			} else {
+				seen[key] = struct{}{}
+			}
+			minZoom = min(minZoom, z)
+			maxZoom = max(maxZoom, z)
+			extent = extent.union(tile.NewCoords(uint32(z), uint32(x), uint32(y)).Bounds())
+			return w.WriteTile(z, x, y, data)
+		})
+		if err != nil {
+			w.Close() // nolint:errcheck
+			return MergeStats{}, fmt.Errorf("failed to copy tiles from %s: %w", inputs[i], err)
+		}
+
+		if declared.Bounds != [4]float64{} {
+			union = union.union(declared.Bounds)
+		} else {
+			union = union.union(extent)
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		w.Close() // nolint:errcheck
+		return MergeStats{}, err
+	}
+
+	stats.Tiles = len(seen)
+	if stats.Tiles > 0 {
+		meta.MinZoom = minZoom
+		meta.MaxZoom = maxZoom
+		meta.Bounds = [4]float64(union)
+		meta.Center = [3]float64{
+			(union[0] + union[2]) / 2,
+			(union[1] + union[3]) / 2,
+			float64((minZoom + maxZoom) / 2),
+		}
+	}
+
+	if err := insertMetadata(w.db, meta); err != nil {
+		w.Close() // nolint:errcheck
+		return MergeStats{}, fmt.Errorf("failed to write merged metadata: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return MergeStats{}, err
+	}
+	if err := os.Rename(tmp, out); err != nil {
+		return MergeStats{}, fmt.Errorf("failed to move output into place: %w", err)
+	}
+	complete = true
+
+	stats.Metadata = meta
+	return stats, nil
+}
+
+// bounds is a minLon,minLat,maxLon,maxLat box used to accumulate unions.
+type bounds [4]float64
+
+func emptyBounds() bounds {
+	return bounds{math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)}
+}
+
+func (b bounds) union(o [4]float64) bounds {
+	return bounds{
+		math.Min(b[0], o[0]),
+		math.Min(b[1], o[1]),
+		math.Max(b[2], o[2]),
+		math.Max(b[3], o[3]),
+	}
+}
@@ -0,0 +1,182 @@
+package mbtiles
+
+import (
+	"database/sql"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTestMBTiles(t *testing.T, path string, meta Metadata, tiles map[[3]int]string) {
+	t.Helper()
+	w, err := New(path, meta)
+	if err != nil {
+		t.Fatalf("Failed to create writer: %v", err)
+	}
+	for k, v := range tiles {
+		if err := w.WriteTile(k[0], k[1], k[2], []byte(v)); err != nil {
+			t.Fatalf("Failed to write tile %v: %v", k, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Failed to close writer: %v", err)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	tmpDir := t.TempDir()
+	in1 := filepath.Join(tmpDir, "west.mbtiles")
+	in2 := filepath.Join(tmpDir, "east.mbtiles")
+
+	writeTestMBTiles(t, in1, Metadata{
+		Name:    "West",
+		Format:  "png",
+		MinZoom: 12,
+		MaxZoom: 13,
+		Bounds:  [4]float64{9.5, 52.0, 9.7, 52.2},
+	}, map[[3]int]string{
+		{12, 2157, 1345}: "west-12",
+		{13, 4316, 2692}: "west-13a",
+		{13, 4317, 2692}: "west-13b",
+	})
+	// No declared bounds: the extent of its tiles is used instead
+	writeTestMBTiles(t, in2, Metadata{
+		Name:    "East",
+		Format:  "png",
+		MinZoom: 13,
+		MaxZoom: 14,
+	}, map[[3]int]string{
+		{13, 4317, 2692}: "east-13b",
+		{14, 8640, 5384}: "east-14",
+	})
+
+	tests := []struct {
+		name     string
+		policy   ConflictPolicy
+		wantTile string
+	}{
+		{name: "last wins", policy: ConflictLastWins, wantTile: "east-13b"},
+		{name: "first wins", policy: ConflictFirstWins, wantTile: "west-13b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := filepath.Join(t.TempDir(), "merged.mbtiles")
+			stats, err := Merge(out, []string{in1, in2}, tt.policy)
+			if err != nil {
+				t.Fatalf("Merge failed: %v", err)
+			}
+			if stats.Tiles != 4 || stats.Conflicts != 1 {
+				t.Errorf("got %d tiles / %d conflicts, want 4 / 1", stats.Tiles, stats.Conflicts)
+			}
+
+			r, err := OpenReader(out)
+			if err != nil {
+				t.Fatalf("Failed to open merged file: %v", err)
+			}
+			defer r.Close()
+
+			data, err := r.ReadTile(13, 4317, 2692)
+			if err != nil {
+				t.Fatalf("Failed to read conflicting tile: %v", err)
+			}
+			if string(data) != tt.wantTile {
+				t.Errorf("conflicting tile = %q, want %q", data, tt.wantTile)
+			}
+
+			count := 0
+			if err := r.IterTiles(func(z, x, y int, data []byte) error {
+				count++
+				return nil
+			}); err != nil {
+				t.Fatalf("IterTiles failed: %v", err)
+			}
+			if count != 4 {
+				t.Errorf("merged file has %d tiles, want 4", count)
+			}
+
+			meta, err := r.Metadata()
+			if err != nil {
+				t.Fatalf("Failed to read metadata: %v", err)
+			}
+			if meta.Name != "West" || meta.Format != "png" {
+				t.Errorf("expected descriptive metadata from the first input, got name=%q format=%q", meta.Name, meta.Format)
+			}
+			if meta.MinZoom != 12 || meta.MaxZoom != 14 {
+				t.Errorf("zoom range = %d-%d, want 12-14", meta.MinZoom, meta.MaxZoom)
+			}
+
+			// East tile z14/8640/5384 spans lon 9.843750..9.865723 and reaches north of the west bounds
+			if meta.Bounds[0] != 9.5 || meta.Bounds[1] != 52.0 {
+				t.Errorf("bounds min = (%.4f, %.4f), want (9.5, 52.0)", meta.Bounds[0], meta.Bounds[1])
+			}
+			if math.Abs(meta.Bounds[2]-9.865723) > 1e-5 {
+				t.Errorf("bounds maxLon = %.6f, want east tile edge 9.865723", meta.Bounds[2])
+			}
+			wantCenter := [3]float64{(meta.Bounds[0] + meta.Bounds[2]) / 2, (meta.Bounds[1] + meta.Bounds[3]) / 2, 13}
+			for i := range wantCenter {
+				if math.Abs(meta.Center[i]-wantCenter[i]) > 1e-5 {
+					t.Errorf("center = %v, want %v", meta.Center, wantCenter)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestMergeRejectsFormatMismatch(t *testing.T) {
+	tmpDir := t.TempDir()
+	in1 := filepath.Join(tmpDir, "a.mbtiles")
+	in2 := filepath.Join(tmpDir, "b.mbtiles")
+	writeTestMBTiles(t, in1, Metadata{Name: "A", Format: "png"}, map[[3]int]string{{1, 0, 0}: "a"})
+	writeTestMBTiles(t, in2, Metadata{Name: "B", Format: "jpg"}, map[[3]int]string{{1, 1, 0}: "b"})
+
+	if _, err := Merge(filepath.Join(tmpDir, "out.mbtiles"), []string{in1, in2}, ConflictLastWins); err == nil {
+		t.Fatal("expected an error for mismatched tile formats")
+	}
+}
+
+func TestMergeRefusesExistingOutput(t *testing.T) {
+	tmpDir := t.TempDir()
+	in := filepath.Join(tmpDir, "a.mbtiles")
+	writeTestMBTiles(t, in, Metadata{Name: "A", Format: "png"}, map[[3]int]string{{1, 0, 0}: "a"})
+
+	if _, err := Merge(in, []string{in}, ConflictLastWins); err == nil {
+		t.Fatal("expected an error when the output already exists")
+	}
+}
+
+func TestMergeRemovesPartialOutput(t *testing.T) {
+	tmpDir := t.TempDir()
+	good := filepath.Join(tmpDir, "a.mbtiles")
+	bad := filepath.Join(tmpDir, "b.mbtiles")
+	writeTestMBTiles(t, good, Metadata{Name: "A", Format: "png"}, map[[3]int]string{{1, 0, 0}: "a"})
+	writeTestMBTiles(t, bad, Metadata{Name: "B", Format: "png"}, map[[3]int]string{{1, 1, 0}: "b"})
+
+	// Corrupt the second input's tile so copying fails after the first input was merged
+	db, err := sql.Open("sqlite", bad)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	_, err = db.Exec("UPDATE images SET tile_data = x'00'")
+	db.Close()
+	if err != nil {
+		t.Fatalf("Failed to corrupt tile: %v", err)
+	}
+
+	out := filepath.Join(tmpDir, "out.mbtiles")
+	if _, err := Merge(out, []string{good, bad}, ConflictLastWins); err == nil {
+		t.Fatal("expected an error for a corrupt input tile")
+	}
+
+	entries, err := os.ReadDir(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if name := e.Name(); name != "a.mbtiles" && name != "b.mbtiles" {
+			t.Errorf("failed merge left %s behind", name)
+		}
+	}
+}
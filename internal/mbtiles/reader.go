@@ -24,9 +24,9 @@ func OpenReader(path string) (*Reader, error) {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
-	// Verify schema exists
+	// Verify schema exists; tiles is a table or a view over deduplicated images
 	var count int
-	err = db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type='table' AND name='tiles'").Scan(&count)
+	err = db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type IN ('table', 'view') AND name='tiles'").Scan(&count)
 	if err != nil {
 		db.Close()
 		return nil, fmt.Errorf("failed to verify schema: %w", err)
@@ -139,6 +139,42 @@ func (r *Reader) Metadata() (Metadata, error) {
 	return meta, nil
 }
 
+// IterTiles calls fn for every tile in the database with ungzipped PNG data, in
+// zoom/column/row order. Coordinates are converted from TMS to XYZ. Iteration stops at the
+// first error returned by fn, which is passed through.
+func (r *Reader) IterTiles(fn func(z, x, y int, data []byte) error) error {
+	rows, err := r.db.Query("SELECT zoom_level, tile_column, tile_row, tile_data FROM tiles ORDER BY zoom_level, tile_column, tile_row")
+	if err != nil {
+		return fmt.Errorf("failed to query tiles: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var z, x, tmsY int
+		var compressedData []byte
+		if err := rows.Scan(&z, &x, &tmsY, &compressedData); err != nil {
+			return fmt.Errorf("failed to scan tile row: %w", err)
+		}
+
+		// Convert TMS to XYZ coordinates
+		y := (1 << z) - 1 - tmsY
+
+		data, err := gzipDecompress(compressedData)
+		if err != nil {
+			return fmt.Errorf("failed to decompress tile %d/%d/%d: %w", z, x, y, err)
+		}
+
+		if err := fn(z, x, y, data); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating tiles: %w", err)
+	}
+	return nil
+}
+
 // Close closes the database connection.
 func (r *Reader) Close() error {
 	if err := r.db.Close(); err != nil {
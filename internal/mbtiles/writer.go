@@ -3,7 +3,9 @@ package mbtiles
 import (
 	"bytes"
 	"compress/gzip"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
 	"fmt"
 	"sync"
 
@@ -24,12 +26,18 @@ type TileEntry struct {
 }
 
 // Writer writes tiles to an MBTiles database.
+//
+// New databases store tile data once per distinct content: a map table points each tile at
+// an entry of the images table, and tiles is a view joining the two, as the MBTiles spec
+// allows. Identical tiles, such as the empty tiles of open ocean, then share a single blob.
+// Databases created with a plain tiles table keep being written that way.
 type Writer struct {
 	db        *sql.DB
 	path      string
 	batch     []TileEntry
 	metadata  Metadata
 	batchSize int
+	flat      bool // tiles is a plain table rather than a view over map and images
 	mu        sync.Mutex
 }
 
@@ -56,7 +64,8 @@ func New(path string, metadata Metadata) (*Writer, error) {
 	}
 
 	// Create schema
-	if err := createSchema(db); err != nil {
+	flat, err := createSchema(db)
+	if err != nil {
 		db.Close()
 		return nil, fmt.Errorf("failed to create schema: %w", err)
 	}
@@ -73,32 +82,63 @@ func New(path string, metadata Metadata) (*Writer, error) {
 		batch:     make([]TileEntry, 0, DefaultBatchSize),
 		batchSize: DefaultBatchSize,
 		metadata:  metadata,
+		flat:      flat,
 	}, nil
 }
 
-// createSchema creates the MBTiles database schema.
-func createSchema(db *sql.DB) error {
+// createSchema creates the MBTiles database schema. It reports whether the database already
+// has a plain tiles table, which is kept instead of the deduplicating map and images tables.
+func createSchema(db *sql.DB) (flat bool, err error) {
+	var kind string
+	err = db.QueryRow("SELECT type FROM sqlite_master WHERE name='tiles'").Scan(&kind)
+	if err != nil && err != sql.ErrNoRows {
+		return false, fmt.Errorf("failed to inspect schema: %w", err)
+	}
+	flat = kind == "table"
+
 	schema := `
 		CREATE TABLE IF NOT EXISTS metadata (
 			name TEXT NOT NULL,
 			value TEXT
 		);
 
-		CREATE TABLE IF NOT EXISTS tiles (
+		CREATE TABLE IF NOT EXISTS map (
 			zoom_level INTEGER NOT NULL,
 			tile_column INTEGER NOT NULL,
 			tile_row INTEGER NOT NULL,
+			tile_id TEXT NOT NULL
+		);
+
+		CREATE UNIQUE INDEX IF NOT EXISTS map_index ON map (zoom_level, tile_column, tile_row);
+
+		CREATE TABLE IF NOT EXISTS images (
+			tile_id TEXT NOT NULL PRIMARY KEY,
 			tile_data BLOB NOT NULL
 		);
 
-		CREATE UNIQUE INDEX IF NOT EXISTS tile_index ON tiles (zoom_level, tile_column, tile_row);
+		CREATE VIEW IF NOT EXISTS tiles AS
+			SELECT map.zoom_level AS zoom_level,
+				map.tile_column AS tile_column,
+				map.tile_row AS tile_row,
+				images.tile_data AS tile_data
+			FROM map JOIN images ON images.tile_id = map.tile_id;
 	`
+	if flat {
+		schema = `
+			CREATE TABLE IF NOT EXISTS metadata (
+				name TEXT NOT NULL,
+				value TEXT
+			);
+
+			CREATE UNIQUE INDEX IF NOT EXISTS tile_index ON tiles (zoom_level, tile_column, tile_row);
+		`
+	}
 
 	if _, err := db.Exec(schema); err != nil {
-		return fmt.Errorf("failed to execute schema: %w", err)
+		return false, fmt.Errorf("failed to execute schema: %w", err)
 	}
 
-	return nil
+	return flat, nil
 }
 
 // insertMetadata inserts metadata into the database.
@@ -145,6 +185,35 @@ func (w *Writer) WriteTile(z, x, y int, pngData []byte) error {
 	return nil
 }
 
+// HasTile reports whether the tile was written, including tiles still buffered in the batch.
+// Coordinates are in XYZ format.
+func (w *Writer) HasTile(z, x, y int) (bool, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, tile := range w.batch {
+		if tile.Z == z && tile.X == x && tile.Y == y {
+			return true, nil
+		}
+	}
+
+	// Convert XYZ to TMS coordinates
+	tmsY := (1 << z) - 1 - y
+
+	var found int
+	err := w.db.QueryRow(
+		"SELECT 1 FROM tiles WHERE zoom_level=? AND tile_column=? AND tile_row=?",
+		z, x, tmsY,
+	).Scan(&found)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to query tile: %w", err)
+	}
+	return true, nil
+}
+
 // Flush writes any buffered tiles to the database.
 func (w *Writer) Flush() error {
 	w.mu.Lock()
@@ -164,13 +233,32 @@ func (w *Writer) flushLocked() error {
 	}
 	defer tx.Rollback() // nolint:errcheck
 
+	if w.flat {
+		err = insertFlat(tx, w.batch)
+	} else {
+		err = insertDeduplicated(tx, w.batch)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	w.batch = w.batch[:0]
+	return nil
+}
+
+// insertFlat writes tiles into a plain tiles table.
+func insertFlat(tx *sql.Tx, tiles []TileEntry) error {
 	stmt, err := tx.Prepare("INSERT OR REPLACE INTO tiles (zoom_level, tile_column, tile_row, tile_data) VALUES (?, ?, ?, ?)")
 	if err != nil {
 		return fmt.Errorf("failed to prepare insert: %w", err)
 	}
 	defer stmt.Close()
 
-	for _, tile := range w.batch {
+	for _, tile := range tiles {
 		// Convert XYZ to TMS coordinates
 		tmsY := (1 << tile.Z) - 1 - tile.Y
 
@@ -184,22 +272,62 @@ func (w *Writer) flushLocked() error {
 			return fmt.Errorf("failed to insert tile %d/%d/%d: %w", tile.Z, tile.X, tile.Y, err)
 		}
 	}
+	return nil
+}
 
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+// insertDeduplicated writes tiles into the map and images tables, keyed by a hash of their
+// data so identical tiles share one image row.
+func insertDeduplicated(tx *sql.Tx, tiles []TileEntry) error {
+	imageStmt, err := tx.Prepare("INSERT OR IGNORE INTO images (tile_id, tile_data) VALUES (?, ?)")
+	if err != nil {
+		return fmt.Errorf("failed to prepare image insert: %w", err)
 	}
+	defer imageStmt.Close()
 
-	w.batch = w.batch[:0]
+	mapStmt, err := tx.Prepare("INSERT OR REPLACE INTO map (zoom_level, tile_column, tile_row, tile_id) VALUES (?, ?, ?, ?)")
+	if err != nil {
+		return fmt.Errorf("failed to prepare insert: %w", err)
+	}
+	defer mapStmt.Close()
+
+	for _, tile := range tiles {
+		// Convert XYZ to TMS coordinates
+		tmsY := (1 << tile.Z) - 1 - tile.Y
+
+		sum := sha256.Sum256(tile.Data)
+		id := hex.EncodeToString(sum[:])
+
+		// Gzip compress the PNG data
+		compressed, err := gzipCompress(tile.Data)
+		if err != nil {
+			return fmt.Errorf("failed to compress tile %d/%d/%d: %w", tile.Z, tile.X, tile.Y, err)
+		}
+
+		if _, err := imageStmt.Exec(id, compressed); err != nil {
+			return fmt.Errorf("failed to insert image of tile %d/%d/%d: %w", tile.Z, tile.X, tile.Y, err)
+		}
+		if _, err := mapStmt.Exec(tile.Z, tile.X, tmsY, id); err != nil {
+			return fmt.Errorf("failed to insert tile %d/%d/%d: %w", tile.Z, tile.X, tile.Y, err)
+		}
+	}
 	return nil
 }
 
-// Close flushes any remaining tiles and closes the database.
+// Close flushes any remaining tiles, drops images no tile refers to any more (left behind by
+// replaced tiles) and closes the database.
 func (w *Writer) Close() error {
 	if err := w.Flush(); err != nil {
 		w.db.Close()
 		return err
 	}
 
+	if !w.flat {
+		if _, err := w.db.Exec("DELETE FROM images WHERE tile_id NOT IN (SELECT tile_id FROM map)"); err != nil {
+			w.db.Close()
+			return fmt.Errorf("failed to drop unused images: %w", err)
+		}
+	}
+
 	if err := w.db.Close(); err != nil {
 		return fmt.Errorf("failed to close database: %w", err)
 	}
@@ -37,7 +37,7 @@ func TestWriter_New(t *testing.T) {
 
 	// Verify schema exists
 	var count int
-	err = w.db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type='table' AND name='tiles'").Scan(&count)
+	err = w.db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type IN ('table', 'view') AND name='tiles'").Scan(&count)
 	if err != nil {
 		t.Fatalf("Failed to query schema: %v", err)
 	}
@@ -196,3 +196,128 @@ func TestWriter_ReplaceExisting(t *testing.T) {
 		t.Errorf("Expected 1 tile (replaced), got %d", count)
 	}
 }
+
+func TestWriter_SharesIdenticalTiles(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.mbtiles")
+
+	w, err := New(dbPath, Metadata{Name: "Test", Format: "png"})
+	if err != nil {
+		t.Fatalf("Failed to create writer: %v", err)
+	}
+
+	ocean := []byte("open ocean")
+	for x := range 3 {
+		if err := w.WriteTile(4, x, 0, ocean); err != nil {
+			t.Fatalf("Failed to write tile: %v", err)
+		}
+	}
+	if err := w.WriteTile(4, 3, 0, []byte("coast")); err != nil {
+		t.Fatalf("Failed to write tile: %v", err)
+	}
+	// Replacing the only coast tile leaves its image unused
+	if err := w.WriteTile(4, 3, 0, ocean); err != nil {
+		t.Fatalf("Failed to write tile: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Failed to close writer: %v", err)
+	}
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	var tiles, images int
+	if err := db.QueryRow("SELECT COUNT(*) FROM tiles").Scan(&tiles); err != nil {
+		t.Fatalf("Failed to count tiles: %v", err)
+	}
+	if err := db.QueryRow("SELECT COUNT(*) FROM images").Scan(&images); err != nil {
+		t.Fatalf("Failed to count images: %v", err)
+	}
+	if tiles != 4 || images != 1 {
+		t.Errorf("got %d tiles sharing %d images, want 4 sharing 1", tiles, images)
+	}
+
+	r, err := OpenReader(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to open reader: %v", err)
+	}
+	defer r.Close()
+	got, err := r.ReadTile(4, 2, 0)
+	if err != nil {
+		t.Fatalf("Failed to read tile: %v", err)
+	}
+	if string(got) != string(ocean) {
+		t.Errorf("ReadTile = %q, want %q", got, ocean)
+	}
+}
+
+func TestWriter_KeepsFlatTilesTable(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "flat.mbtiles")
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	_, err = db.Exec(`CREATE TABLE tiles (zoom_level INTEGER NOT NULL, tile_column INTEGER NOT NULL,
+		tile_row INTEGER NOT NULL, tile_data BLOB NOT NULL)`)
+	db.Close()
+	if err != nil {
+		t.Fatalf("Failed to create flat schema: %v", err)
+	}
+
+	w, err := New(dbPath, Metadata{Name: "Test", Format: "png"})
+	if err != nil {
+		t.Fatalf("Failed to create writer: %v", err)
+	}
+	if err := w.WriteTile(4, 1, 2, []byte("tile")); err != nil {
+		t.Fatalf("Failed to write tile: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Failed to close writer: %v", err)
+	}
+
+	r, err := OpenReader(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to open reader: %v", err)
+	}
+	defer r.Close()
+	got, err := r.ReadTile(4, 1, 2)
+	if err != nil {
+		t.Fatalf("Failed to read tile: %v", err)
+	}
+	if string(got) != "tile" {
+		t.Errorf("ReadTile = %q, want %q", got, "tile")
+	}
+}
+
+func TestWriter_HasTile(t *testing.T) {
+	w, err := New(filepath.Join(t.TempDir(), "test.mbtiles"), Metadata{Name: "Test", Format: "png"})
+	if err != nil {
+		t.Fatalf("Failed to create writer: %v", err)
+	}
+	defer w.Close()
+
+	check := func(z, x, y int, want bool) {
+		t.Helper()
+		got, err := w.HasTile(z, x, y)
+		if err != nil {
+			t.Fatalf("HasTile(%d, %d, %d) failed: %v", z, x, y, err)
+		}
+		if got != want {
+			t.Errorf("HasTile(%d, %d, %d) = %v, want %v", z, x, y, got, want)
+		}
+	}
+
+	check(13, 100, 200, false)
+	if err := w.WriteTile(13, 100, 200, []byte("tile")); err != nil {
+		t.Fatalf("Failed to write tile: %v", err)
+	}
+	check(13, 100, 200, true) // still buffered
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+	check(13, 100, 200, true)
+	check(13, 100, 201, false)
+}
@@ -0,0 +1,172 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"math"
+	"os"
+
+	"github.com/MeKo-Tech/watercolormap/internal/renderer"
+	"github.com/MeKo-Tech/watercolormap/internal/tile"
+	"github.com/MeKo-Tech/watercolormap/internal/types"
+	"github.com/MeKo-Tech/watercolormap/internal/watercolor"
+)
+
+// webMercatorExtent is the width of the Web Mercator world in meters.
+const webMercatorExtent = 2 * math.Pi * 6378137.0
+
+// areaGeometry describes how a geographic area maps onto a rectangular canvas.
+type areaGeometry struct {
+	// mercator holds the Web Mercator bounds (minX, minY, maxX, maxY) covered by the canvas,
+	// grown from the requested bounds to match the canvas aspect ratio.
+	mercator [4]float64
+	// scale is the canvas resolution relative to this generator's tiles at the same zoom
+	// (1 when the area is rendered at tile resolution).
+	scale float64
+	// offsetX and offsetY are the canvas origin in global pixel coordinates at that resolution,
+	// used to align noise and textures with tiles.
+	offsetX int
+	offsetY int
+}
+
+// newAreaGeometry fits bounds to a widthPx×heightPx canvas at the given zoom for tiles of
+// tileSize pixels. The shorter side of the bounds is grown around the center so the area
+// is never stretched.
+func newAreaGeometry(bounds types.BoundingBox, widthPx, heightPx, zoom, tileSize int) areaGeometry {
+	minX, minY := tile.LonLatToMercator(bounds.MinLon, bounds.MinLat)
+	maxX, maxY := tile.LonLatToMercator(bounds.MaxLon, bounds.MaxLat)
+
+	w, h := maxX-minX, maxY-minY
+	aspect := float64(widthPx) / float64(heightPx)
+	if w/h < aspect {
+		grow := (h*aspect - w) / 2
+		minX, maxX = minX-grow, maxX+grow
+	} else {
+		grow := (w/aspect - h) / 2
+		minY, maxY = minY-grow, maxY+grow
+	}
+
+	metersPerPx := webMercatorExtent / (math.Exp2(float64(zoom)) * float64(tileSize))
+	scale := float64(widthPx) * metersPerPx / (maxX - minX)
+
+	// Global pixel coordinates grow east and south from the top-left corner of the world
+	return areaGeometry{
+		mercator: [4]float64{minX, minY, maxX, maxY},
+		scale:    scale,
+		offsetX:  int(math.Round((minX + webMercatorExtent/2) / metersPerPx * scale)),
+		offsetY:  int(math.Round((webMercatorExtent/2 - maxY) / metersPerPx * scale)),
+	}
+}
+
+// RenderArea renders bounds as a single widthPx×heightPx image at the given zoom level, running
+// the full watercolor pipeline without cutting tiles (e.g. for poster exports).
+//
+// If the aspect ratio of bounds differs from widthPx:heightPx, the bounds are grown around their
+// center to fit. zoom selects the zoom-dependent styling and data detail; when the requested
+// size differs from the area's native pixel size at that zoom, noise is scaled with it so the
+// result matches tiles of the same zoom. The data source must support bounded fetches.
+func (g *Generator) RenderArea(ctx context.Context, bounds types.BoundingBox, widthPx, heightPx int, zoom int) (*image.NRGBA, error) {
+	if widthPx <= 0 || heightPx <= 0 {
+		return nil, fmt.Errorf("image size must be positive, got %dx%d", widthPx, heightPx)
+	}
+	if zoom < 0 || zoom > 30 {
+		return nil, fmt.Errorf("invalid zoom level %d", zoom)
+	}
+	if bounds.MinLon >= bounds.MaxLon || bounds.MinLat >= bounds.MaxLat {
+		return nil, fmt.Errorf("invalid bounds %s", bounds.String())
+	}
+	dsb, ok := g.ds.(dataSourceWithBounds)
+	if !ok {
+		return nil, fmt.Errorf("data source does not support bounded fetches required for areas")
+	}
+
+	geom := newAreaGeometry(bounds, widthPx, heightPx, zoom, g.tileSize)
+	params := g.zoomParams(zoom, float64(g.pixelRatio())*geom.scale)
+
+	padPx := watercolor.RequiredPaddingPx(params)
+	if limit := min(widthPx, heightPx); padPx > limit {
+		padPx = limit
+	}
+	params.TileSize = widthPx + 2*padPx
+	params.TileHeight = heightPx + 2*padPx
+	params.OffsetX = geom.offsetX - padPx
+	params.OffsetY = geom.offsetY - padPx
+
+	// Per-tile and region noise seeds are taken from the tile at the center of the area
+	lat, lon := bounds.Center()
+	center := types.TileCoordinate{Zoom: zoom}
+	center.X, center.Y = lonLatToTile(lon, lat, zoom)
+	coords := tile.NewCoords(uint32(zoom), uint32(center.X), uint32(center.Y))
+
+	params.PerlinNoise = watercolor.GenerateNoise(params, zoom, center.X, center.Y)
+
+	// Fetch the padded area
+	padX := (geom.mercator[2] - geom.mercator[0]) * float64(padPx) / float64(widthPx)
+	padY := (geom.mercator[3] - geom.mercator[1]) * float64(padPx) / float64(heightPx)
+	minLon, minLat := tile.MercatorToLonLat(geom.mercator[0]-padX, geom.mercator[1]-padY)
+	maxLon, maxLat := tile.MercatorToLonLat(geom.mercator[2]+padX, geom.mercator[3]+padY)
+	dataBounds := types.BoundingBox{MinLon: minLon, MinLat: minLat, MaxLon: maxLon, MaxLat: maxLat}.Expand(0, 0)
+
+	g.log().Info("Fetching area data", "bounds", dataBounds.String(), "zoom", zoom, "size", fmt.Sprintf("%dx%d", widthPx, heightPx))
+	data, err := dsb.FetchTileDataWithBounds(ctx, center, dataBounds)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch area data: %w", err)
+	}
+
+	layerDir, err := os.MkdirTemp("", "watercolormap-layers-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp layer dir: %w", err)
+	}
+	if g.keepLayers {
+		g.log().Info("Keeping rendered layer PNGs", "coords", coords.String(), "dir", layerDir)
+	} else {
+		defer os.RemoveAll(layerDir) // nolint:errcheck
+	}
+
+	mpRenderer, err := renderer.NewMultiPassRendererSize(g.stylesDir, layerDir, widthPx, heightPx, padPx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create multipass renderer: %w", err)
+	}
+	defer mpRenderer.Close() // nolint:errcheck
+	mpRenderer.SetOverrides(g.options.LayerOverrides)
+	if g.options.MapnikBufferPx > 0 {
+		mpRenderer.SetBufferSize(g.options.MapnikBufferPx)
+	}
+
+	renderResult, err := mpRenderer.RenderBounds(coords, geom.mercator, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render layers: %w", err)
+	}
+	rawLayers, err := g.readLayers(renderResult, coords)
+	if err != nil {
+		return nil, err
+	}
+
+	masks, err := g.tileMasks(rawLayers, params, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build masks: %w", err)
+	}
+	painted, err := paintAllLayers(rawLayers, masks, params, g.textures, g.options.TransparentBackground, g.options.PaintWorkers, workerProcessorContext(ctx), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	composited, err := g.compositeLayers(painted, params, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer metatileBuffers.put(composited)
+
+	// Copy out the unpadded area so the pooled composite can be recycled
+	return cropNRGBA(composited, image.Rect(padPx, padPx, padPx+widthPx, padPx+heightPx)), nil
+}
+
+// lonLatToTile returns the column and row of the tile containing lon/lat at zoom.
+func lonLatToTile(lon, lat float64, zoom int) (int, int) {
+	n := math.Exp2(float64(zoom))
+	x, y := tile.LonLatToMercator(lon, lat)
+	col := int((x + webMercatorExtent/2) / webMercatorExtent * n)
+	row := int((webMercatorExtent/2 - y) / webMercatorExtent * n)
+	return min(max(col, 0), int(n)-1), min(max(row, 0), int(n)-1)
+}
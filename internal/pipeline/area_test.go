@@ -0,0 +1,148 @@
+package pipeline
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/MeKo-Tech/watercolormap/internal/geojson"
+	"github.com/MeKo-Tech/watercolormap/internal/tile"
+	"github.com/MeKo-Tech/watercolormap/internal/types"
+	"github.com/MeKo-Tech/watercolormap/internal/watercolor"
+)
+
+func tileBBox(z, x, y int) types.BoundingBox {
+	b := tile.NewCoords(uint32(z), uint32(x), uint32(y)).Bounds()
+	return types.BoundingBox{MinLon: b[0], MinLat: b[1], MaxLon: b[2], MaxLat: b[3]}
+}
+
+func TestAreaGeometry(t *testing.T) {
+	const tileSize = 256
+	bbox := tileBBox(13, 4317, 2692)
+
+	tests := []struct {
+		name          string
+		width, height int
+		wantScale     float64
+		wantOffsetX   int
+		wantOffsetY   int
+	}{
+		// A single tile at its native size lines up with the tile grid exactly
+		{name: "native tile", width: 256, height: 256, wantScale: 1, wantOffsetX: 4317 * 256, wantOffsetY: 2692 * 256},
+		// Twice the resolution doubles the global pixel coordinates
+		{name: "double resolution", width: 512, height: 512, wantScale: 2, wantOffsetX: 4317 * 512, wantOffsetY: 2692 * 512},
+		// A wide canvas grows the bounds east and west by half a tile each
+		{name: "wide canvas", width: 512, height: 256, wantScale: 1, wantOffsetX: 4317*256 - 128, wantOffsetY: 2692 * 256},
+		// A tall canvas grows the bounds north and south
+		{name: "tall canvas", width: 256, height: 768, wantScale: 1, wantOffsetX: 4317 * 256, wantOffsetY: 2692*256 - 256},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			geom := newAreaGeometry(bbox, tt.width, tt.height, 13, tileSize)
+
+			if math.Abs(geom.scale-tt.wantScale) > 1e-6 {
+				t.Errorf("scale = %v, want %v", geom.scale, tt.wantScale)
+			}
+			if geom.offsetX != tt.wantOffsetX || geom.offsetY != tt.wantOffsetY {
+				t.Errorf("offset = (%d, %d), want (%d, %d)", geom.offsetX, geom.offsetY, tt.wantOffsetX, tt.wantOffsetY)
+			}
+
+			m := geom.mercator
+			gotAspect := (m[2] - m[0]) / (m[3] - m[1])
+			wantAspect := float64(tt.width) / float64(tt.height)
+			if math.Abs(gotAspect-wantAspect) > 1e-9 {
+				t.Errorf("mercator aspect = %v, want %v", gotAspect, wantAspect)
+			}
+
+			orig := tile.NewCoords(13, 4317, 2692).BoundsMercator()
+			if m[0] > orig[0]+1e-6 || m[1] > orig[1]+1e-6 || m[2] < orig[2]-1e-6 || m[3] < orig[3]-1e-6 {
+				t.Errorf("fitted bounds %v do not contain requested bounds %v", m, orig)
+			}
+		})
+	}
+}
+
+func TestLonLatToTile(t *testing.T) {
+	bbox := tileBBox(13, 4317, 2692)
+	lat, lon := bbox.Center()
+	if x, y := lonLatToTile(lon, lat, 13); x != 4317 || y != 2692 {
+		t.Errorf("lonLatToTile = (%d, %d), want (4317, 2692)", x, y)
+	}
+	if x, y := lonLatToTile(180, -89, 2); x != 3 || y != 3 {
+		t.Errorf("expected edge coordinates to be clamped, got (%d, %d)", x, y)
+	}
+}
+
+func TestRenderAreaValidatesInput(t *testing.T) {
+	gen := newCompositeTestGenerator(t, 256, GeneratorOptions{})
+	bbox := tileBBox(13, 4317, 2692)
+
+	if _, err := gen.RenderArea(context.Background(), bbox, 0, 256, 13); err == nil {
+		t.Error("expected an error for a zero width")
+	}
+	if _, err := gen.RenderArea(context.Background(), types.BoundingBox{MinLon: 1, MaxLon: 0, MinLat: 0, MaxLat: 1}, 256, 256, 13); err == nil {
+		t.Error("expected an error for inverted bounds")
+	}
+	// The test generator has no data source, so bounded fetches are unavailable
+	if _, err := gen.RenderArea(context.Background(), bbox, 256, 256, 13); err == nil {
+		t.Error("expected an error for a data source without bounded fetches")
+	}
+}
+
+// TestPaintRectangularCanvas runs the mask, paint and composite stages on a non-square canvas.
+func TestPaintRectangularCanvas(t *testing.T) {
+	const width, height = 300, 180
+	gen := newCompositeTestGenerator(t, 256, GeneratorOptions{})
+
+	params := gen.zoomParams(13, 1)
+	params.TileSize = width
+	params.TileHeight = height
+	params.PerlinNoise = watercolor.GenerateNoise(params, 13, 0, 0)
+
+	// Water on the left half, a park across the bottom
+	water := image.NewNRGBA(image.Rect(0, 0, width, height))
+	parks := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if x < width/2 {
+				water.SetNRGBA(x, y, color.NRGBA{R: 80, G: 140, B: 200, A: 255})
+			} else if y > height/2 {
+				parks.SetNRGBA(x, y, color.NRGBA{R: 120, G: 180, B: 90, A: 255})
+			}
+		}
+	}
+	rawLayers := map[geojson.LayerType]image.Image{geojson.LayerWater: water, geojson.LayerParks: parks}
+
+	masks, err := buildMasks(rawLayers, params, nil)
+	if err != nil {
+		t.Fatalf("buildMasks failed: %v", err)
+	}
+	painted, err := paintAllLayers(rawLayers, masks, params, gen.textures, false, 0, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("paintAllLayers failed: %v", err)
+	}
+	for layer, img := range painted {
+		if img.Bounds() != image.Rect(0, 0, width, height) {
+			t.Errorf("painted %s bounds = %v, want %dx%d", layer, img.Bounds(), width, height)
+		}
+	}
+
+	composited, err := gen.compositeLayers(painted, params, nil)
+	if err != nil {
+		t.Fatalf("compositeLayers failed: %v", err)
+	}
+	defer metatileBuffers.put(composited)
+
+	if composited.Bounds() != image.Rect(0, 0, width, height) {
+		t.Fatalf("composite bounds = %v, want %dx%d", composited.Bounds(), width, height)
+	}
+	// Water dominates the left half and land/park the right, so the two sides must differ
+	left := composited.NRGBAAt(width/4, height/4)
+	right := composited.NRGBAAt(3*width/4, height/4)
+	if left == right {
+		t.Errorf("expected different colors for water and land, both are %v", left)
+	}
+}
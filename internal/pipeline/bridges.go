@@ -0,0 +1,62 @@
+package pipeline
+
+import (
+	"image"
+
+	"github.com/MeKo-Tech/watercolormap/internal/geojson"
+	"github.com/MeKo-Tech/watercolormap/internal/mask"
+	"github.com/MeKo-Tech/watercolormap/internal/watercolor"
+)
+
+// bridgeMinWaterDepthPx is how far inside water (water or rivers) a road pixel must be to
+// count as part of a bridge or causeway. Roads running along a shore only overlap the water's
+// antialiased edge and stay untouched.
+const bridgeMinWaterDepthPx = 2
+
+// tileMasks builds the masks of a render (see buildMasks), carving bridges out of the water
+// masks when GeneratorOptions.Bridges is set and recording the neighbors of each layer when
+// GeneratorOptions.SharedEdges is.
+func (g *Generator) tileMasks(rawLayers map[geojson.LayerType]image.Image, params watercolor.Params, dc *DebugContext) (*maskSet, error) {
+	masks, err := buildMasks(rawLayers, params, dc)
+	if err != nil {
+		return nil, err
+	}
+	if g.options.Bridges {
+		masks.carveBridges(dc)
+	}
+	if g.options.SharedEdges {
+		masks.shareEdges(rawLayers, params, dc)
+	}
+	return masks, nil
+}
+
+// carveBridges finds road and highway pixels lying over water and removes them, widened by a
+// pixel, from the water and rivers masks. Roads already cut through land (see
+// maskSet.nonLandUnion), so this puts bridges on paper like every other road instead of
+// over the water wash, whose edge darkening then outlines the bridge like a shore.
+func (m *maskSet) carveBridges(dc *DebugContext) {
+	water := mask.ApplyThreshold(mask.MaxMasks(m.waterMask, m.riversMask), 128)
+	depth := mask.EuclideanDistanceTransform(water, bridgeMinWaterDepthPx)
+	roads := mask.MaxMasks(m.roadsMask, m.highwaysAlpha)
+
+	bridges := image.NewGray(roads.Bounds())
+	found := false
+	for i, v := range roads.Pix {
+		if v >= 128 && depth.Pix[i] == 255 {
+			bridges.Pix[i] = 255
+			found = true
+		}
+	}
+	if !found {
+		return
+	}
+
+	// Widen by a pixel so the water doesn't show through the road's antialiased edge
+	bridges = mask.Dilate(bridges, 1)
+	dc.Capture("04_bridges", "Road pixels over water, carved out of water and rivers", bridges, 4)
+
+	keep := mask.InvertMask(bridges)
+	m.waterMask = mask.MinMask(m.waterMask, keep)
+	m.riversMask = mask.MinMask(m.riversMask, keep)
+	m.bridgeMask = bridges
+}
@@ -0,0 +1,88 @@
+package pipeline
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/MeKo-Tech/watercolormap/internal/geojson"
+	"github.com/MeKo-Tech/watercolormap/internal/watercolor"
+)
+
+// TestBridgesOverWater paints a road crossing a water band and checks that with Bridges the
+// road, antialiased edges included, stays free of the water wash, while the water beside it
+// and the road on land are unchanged.
+func TestBridgesOverWater(t *testing.T) {
+	composite := func(bridges bool) (*image.NRGBA, *image.NRGBA) {
+		gen := newCompositeTestGenerator(t, 256, GeneratorOptions{Bridges: bridges})
+		params := testParams(gen)
+		params.PerlinNoise = watercolor.GenerateNoise(params, 13, 100, 200)
+
+		// A horizontal water band 80px high and a vertical road 6px wide
+		size := params.TileSize
+		c := size / 2
+		water := image.NewNRGBA(image.Rect(0, 0, size, size))
+		roads := image.NewNRGBA(image.Rect(0, 0, size, size))
+		for y := 0; y < size; y++ {
+			for x := 0; x < size; x++ {
+				if y >= c-40 && y < c+40 {
+					water.SetNRGBA(x, y, color.NRGBA{A: 255})
+				}
+				if x >= c-3 && x < c+3 {
+					roads.SetNRGBA(x, y, color.NRGBA{A: 255})
+				}
+			}
+		}
+		raw := map[geojson.LayerType]image.Image{geojson.LayerWater: water, geojson.LayerRoads: roads}
+
+		masks, err := gen.tileMasks(raw, params, nil)
+		if err != nil {
+			t.Fatalf("tileMasks failed: %v", err)
+		}
+		if (masks.bridgeMask != nil) != bridges {
+			t.Fatalf("bridge mask present = %v, want %v", masks.bridgeMask != nil, bridges)
+		}
+		painted, err := paintAllLayers(raw, masks, params, gen.textures, false, 0, nil, nil, nil)
+		if err != nil {
+			t.Fatalf("paintAllLayers failed: %v", err)
+		}
+		composited, err := gen.compositeLayers(painted, params, nil)
+		if err != nil {
+			t.Fatalf("compositeLayers failed: %v", err)
+		}
+		t.Cleanup(func() { metatileBuffers.put(composited) })
+		return composited, painted[geojson.LayerRoads].(*image.NRGBA)
+	}
+
+	// Water is blue; the road and the paper are near-neutral
+	blueness := func(p color.NRGBA) int { return int(p.B) - int(p.R) }
+
+	plain, _ := composite(false)
+	bridged, roads := composite(true)
+	c := bridged.Bounds().Dx() / 2
+
+	waterTinted := 0
+	for x := c - 8; x <= c+8; x++ {
+		if roads.NRGBAAt(x, c).A == 0 {
+			continue
+		}
+		if b := blueness(plain.NRGBAAt(x, c)); b > 10 {
+			waterTinted++
+		}
+		if b := blueness(bridged.NRGBAAt(x, c)); b > 10 {
+			t.Errorf("x=%d: road over water is tinted by the water (%v)", x, bridged.NRGBAAt(x, c))
+		}
+	}
+	if waterTinted == 0 {
+		t.Error("expected the road's edges to take on the water's color without Bridges")
+	}
+
+	if b := blueness(bridged.NRGBAAt(c+20, c)); b < 50 {
+		t.Errorf("expected water beside the bridge, got %v", bridged.NRGBAAt(c+20, c))
+	}
+	for x := c - 8; x <= c+8; x++ {
+		if plain.NRGBAAt(x, c-80) != bridged.NRGBAAt(x, c-80) {
+			t.Fatalf("x=%d: road on land changed from %v to %v", x, plain.NRGBAAt(x, c-80), bridged.NRGBAAt(x, c-80))
+		}
+	}
+}
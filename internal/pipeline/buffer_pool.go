@@ -0,0 +1,81 @@
+package pipeline
+
+import (
+	"image"
+	"sync"
+)
+
+// nrgbaPool recycles NRGBA buffers, keyed by dimensions, across renders.
+// Under concurrent load every in-flight tile otherwise allocates its own padded
+// metatile composite, which dominates peak memory for @2x tiles.
+type nrgbaPool struct {
+	pools sync.Map // map[image.Point]*sync.Pool
+}
+
+// metatileBuffers holds composite buffers sized to the padded metatile.
+var metatileBuffers nrgbaPool
+
+// get returns a size×size buffer with undefined contents.
+func (p *nrgbaPool) get(size int) *image.NRGBA {
+	return p.getRect(size, size)
+}
+
+// getRect returns a width×height buffer with undefined contents.
+func (p *nrgbaPool) getRect(width, height int) *image.NRGBA {
+	v, _ := p.pools.LoadOrStore(image.Pt(width, height), &sync.Pool{
+		New: func() any { return image.NewNRGBA(image.Rect(0, 0, width, height)) },
+	})
+	return v.(*sync.Pool).Get().(*image.NRGBA)
+}
+
+// put returns a buffer obtained from get. The caller must not use img afterwards,
+// including sub-images of it.
+func (p *nrgbaPool) put(img *image.NRGBA) {
+	if img == nil {
+		return
+	}
+	b := img.Bounds()
+	if b.Min != (image.Point{}) || img.Stride != 4*b.Dx() || len(img.Pix) != img.Stride*b.Dy() {
+		return // Not a pooled buffer
+	}
+	if v, ok := p.pools.Load(b.Size()); ok {
+		v.(*sync.Pool).Put(img)
+	}
+}
+
+// grayPool recycles Gray buffers, keyed by dimensions, like nrgbaPool.
+type grayPool struct {
+	pools sync.Map // map[image.Point]*sync.Pool
+}
+
+// noiseBuffers holds the Perlin noise fields of renders, sized to the padded canvas. Every
+// tile of a batch has a different field, but at one size the buffers can be refilled
+// instead of reallocated (see watercolor.FillNoise).
+var noiseBuffers grayPool
+
+// get returns a width×height buffer with undefined contents.
+func (p *grayPool) get(width, height int) *image.Gray {
+	// Load first: noise buffers are small enough that allocating the pool for LoadOrStore
+	// on every call would show up next to them
+	v, ok := p.pools.Load(image.Pt(width, height))
+	if !ok {
+		v, _ = p.pools.LoadOrStore(image.Pt(width, height), &sync.Pool{
+			New: func() any { return image.NewGray(image.Rect(0, 0, width, height)) },
+		})
+	}
+	return v.(*sync.Pool).Get().(*image.Gray)
+}
+
+// put returns a buffer obtained from get. The caller must not use img afterwards.
+func (p *grayPool) put(img *image.Gray) {
+	if img == nil {
+		return
+	}
+	b := img.Bounds()
+	if b.Min != (image.Point{}) || img.Stride != b.Dx() || len(img.Pix) != img.Stride*b.Dy() {
+		return // Not a pooled buffer
+	}
+	if v, ok := p.pools.Load(b.Size()); ok {
+		v.(*sync.Pool).Put(img)
+	}
+}
@@ -0,0 +1,349 @@
+package pipeline
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"runtime/metrics"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MeKo-Tech/watercolormap/internal/composite"
+	"github.com/MeKo-Tech/watercolormap/internal/geojson"
+	"github.com/MeKo-Tech/watercolormap/internal/mask"
+	"github.com/MeKo-Tech/watercolormap/internal/texture"
+	"github.com/MeKo-Tech/watercolormap/internal/tile"
+	"github.com/MeKo-Tech/watercolormap/internal/watercolor"
+)
+
+const testPadPx = 32
+
+func newCompositeTestGenerator(t testing.TB, tileSize int, opts GeneratorOptions) *Generator {
+	t.Helper()
+	texturesDir := filepath.Join("..", "..", "assets", "textures")
+	gen, err := NewGenerator(nil, "", texturesDir, t.TempDir(), tileSize, 123, false, nil, opts)
+	if err != nil {
+		t.Fatalf("failed to create generator: %v", err)
+	}
+	return gen
+}
+
+// syntheticPainted returns semi-transparent painted layers covering different regions.
+func syntheticPainted(size int) map[geojson.LayerType]image.Image {
+	water := image.NewNRGBA(image.Rect(0, 0, size, size))
+	parks := image.NewNRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			if x < size/2 {
+				water.SetNRGBA(x, y, color.NRGBA{R: 80, G: 140, B: 200, A: uint8(x % 256)})
+			}
+			if y > size/3 {
+				parks.SetNRGBA(x, y, color.NRGBA{R: 120, G: 180, B: 90, A: uint8((y * 3) % 256)})
+			}
+		}
+	}
+	return map[geojson.LayerType]image.Image{geojson.LayerWater: water, geojson.LayerParks: parks}
+}
+
+func testParams(gen *Generator) watercolor.Params {
+	params := watercolor.DefaultParams(gen.tileSize, gen.seed, gen.textures)
+	params.TileSize = gen.tileSize + 2*testPadPx
+	params.OffsetX = 100*gen.tileSize - testPadPx
+	params.OffsetY = 200*gen.tileSize - testPadPx
+	return params
+}
+
+// referenceTile is the pre-pooling composite path: allocate, composite, copy-crop, encode.
+func referenceTile(t testing.TB, gen *Generator, painted map[geojson.LayerType]image.Image, params watercolor.Params) []byte {
+	t.Helper()
+	base := texture.TileTexture(gen.textures[geojson.LayerPaper], params.TileSize, params.OffsetX, params.OffsetY)
+	composited, err := composite.CompositeLayersOverBase(
+		base,
+		painted,
+		params.Order(),
+		params.TileSize,
+	)
+	if err != nil {
+		t.Fatalf("reference composite failed: %v", err)
+	}
+	final := cropNRGBA(composited, image.Rect(testPadPx, testPadPx, testPadPx+gen.tileSize, testPadPx+gen.tileSize))
+
+	var buf bytes.Buffer
+	enc := gen.pngEncoder()
+	if err := enc.Encode(&buf, final); err != nil {
+		t.Fatalf("reference encode failed: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func pooledTile(t testing.TB, gen *Generator, painted map[geojson.LayerType]image.Image, params watercolor.Params, w io.Writer) {
+	t.Helper()
+	composited, err := gen.compositeLayers(painted, params, nil)
+	if err != nil {
+		t.Fatalf("compositeLayers failed: %v", err)
+	}
+	defer metatileBuffers.put(composited)
+
+	enc := gen.pngEncoder()
+	if err := enc.Encode(w, gen.cropTile(composited, testPadPx, 0, 0)); err != nil {
+		t.Fatalf("encode failed: %v", err)
+	}
+}
+
+func TestPooledCompositeMatchesReference(t *testing.T) {
+	gen := newCompositeTestGenerator(t, 256, GeneratorOptions{})
+	params := testParams(gen)
+	painted := syntheticPainted(params.TileSize)
+
+	want := referenceTile(t, gen, painted, params)
+
+	// Run twice so the second render reuses a dirty buffer from the pool.
+	for i := 0; i < 2; i++ {
+		var got bytes.Buffer
+		pooledTile(t, gen, painted, params, &got)
+		if !bytes.Equal(got.Bytes(), want) {
+			t.Fatalf("run %d: pooled output differs from reference (%d vs %d bytes)", i, got.Len(), len(want))
+		}
+	}
+}
+
+type recordingStreamWriter struct {
+	streamed  map[string][]byte
+	buffered  int
+	openCalls int
+	aborted   int
+	failWrite bool // streams fail every write
+}
+
+type recordingStream struct {
+	bytes.Buffer
+	fail    bool
+	onClose func([]byte)
+	onAbort func()
+}
+
+func (s *recordingStream) Write(p []byte) (int, error) {
+	if s.fail {
+		return 0, errors.New("disk full")
+	}
+	return s.Buffer.Write(p)
+}
+
+func (s *recordingStream) Close() error {
+	s.onClose(s.Bytes())
+	return nil
+}
+
+func (s *recordingStream) Abort() error {
+	s.onAbort()
+	return nil
+}
+
+func (w *recordingStreamWriter) WriteTile(z, x, y int, pngData []byte) error {
+	w.buffered++
+	return nil
+}
+
+func (w *recordingStreamWriter) TileStream(z, x, y int) (TileStream, error) {
+	w.openCalls++
+	key := tile.NewCoords(uint32(z), uint32(x), uint32(y)).String()
+	return &recordingStream{
+		fail:    w.failWrite,
+		onClose: func(b []byte) { w.streamed[key] = append([]byte(nil), b...) },
+		onAbort: func() { w.aborted++ },
+	}, nil
+}
+
+func TestWriteTileUsesStreamWriter(t *testing.T) {
+	sw := &recordingStreamWriter{streamed: map[string][]byte{}}
+	gen := newCompositeTestGenerator(t, 64, GeneratorOptions{TileWriter: sw})
+
+	img := image.NewNRGBA(image.Rect(0, 0, 64, 64))
+	coords := tile.NewCoords(3, 1, 2)
+	if err := gen.writeTile(img, coords, ""); err != nil {
+		t.Fatalf("writeTile failed: %v", err)
+	}
+
+	if sw.buffered != 0 || sw.openCalls != 1 {
+		t.Fatalf("expected one streamed write and no buffered writes, got streamed=%d buffered=%d", sw.openCalls, sw.buffered)
+	}
+	decoded, err := png.Decode(bytes.NewReader(sw.streamed[coords.String()]))
+	if err != nil {
+		t.Fatalf("streamed data is not a valid PNG: %v", err)
+	}
+	if decoded.Bounds().Dx() != 64 {
+		t.Fatalf("unexpected streamed tile size %v", decoded.Bounds())
+	}
+}
+
+func TestWriteTileAbortsFailedStream(t *testing.T) {
+	sw := &recordingStreamWriter{streamed: map[string][]byte{}, failWrite: true}
+	gen := newCompositeTestGenerator(t, 64, GeneratorOptions{TileWriter: sw})
+
+	coords := tile.NewCoords(3, 1, 2)
+	if err := gen.writeTile(image.NewNRGBA(image.Rect(0, 0, 64, 64)), coords, ""); err == nil {
+		t.Fatal("expected the failed encode to fail the tile")
+	}
+	if sw.aborted != 1 {
+		t.Errorf("stream aborted %d times, want 1", sw.aborted)
+	}
+	if _, ok := sw.streamed[coords.String()]; ok {
+		t.Error("partial tile was committed")
+	}
+}
+
+func TestWriteTileToCopiesTile(t *testing.T) {
+	gen := newCompositeTestGenerator(t, 64, GeneratorOptions{})
+	path := filepath.Join(t.TempDir(), "tile.png")
+
+	var tee bytes.Buffer
+	if err := gen.writeTileTo(image.NewNRGBA(image.Rect(0, 0, 64, 64)), tile.NewCoords(3, 1, 2), path, &tee, false); err != nil {
+		t.Fatalf("writeTileTo failed: %v", err)
+	}
+	written, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(tee.Bytes(), written) {
+		t.Errorf("copied %d bytes, tile file has %d", tee.Len(), len(written))
+	}
+}
+
+// BenchmarkCompositeAndEncode compares the pooled, streaming composite path with the
+// previous allocate-and-buffer path for @2x tiles under concurrent load. peak-MB is the most
+// memory the process held from the OS while a sub-benchmark ran (see peakMemory).
+func BenchmarkCompositeAndEncode(b *testing.B) {
+	gen := newCompositeTestGenerator(b, 512, GeneratorOptions{PNGCompression: "speed"})
+	params := testParams(gen)
+	painted := syntheticPainted(params.TileSize)
+
+	b.Run("allocating", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetParallelism(4)
+		peak := peakMemory(func() {
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					_ = referenceTile(b, gen, painted, params)
+				}
+			})
+		})
+		b.ReportMetric(float64(peak)/(1<<20), "peak-MB")
+	})
+
+	b.Run("pooled", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetParallelism(4)
+		peak := peakMemory(func() {
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					pooledTile(b, gen, painted, params, io.Discard)
+				}
+			})
+		})
+		b.ReportMetric(float64(peak)/(1<<20), "peak-MB")
+	})
+}
+
+// peakMemory runs run and returns the peak of the memory the Go runtime held from the OS
+// meanwhile, sampled every millisecond. It stands in for the peak RSS, which getrusage only
+// reports for the whole process and so can't be reset between sub-benchmarks.
+func peakMemory(run func()) uint64 {
+	samples := []metrics.Sample{
+		{Name: "/memory/classes/total:bytes"},
+		{Name: "/memory/classes/heap/released:bytes"},
+	}
+	held := func() uint64 {
+		metrics.Read(samples)
+		return samples[0].Value.Uint64() - samples[1].Value.Uint64()
+	}
+
+	// Start from what is actually in use, not from an earlier sub-benchmark's garbage
+	runtime.GC()
+	debug.FreeOSMemory()
+
+	peak := held()
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				peak = max(peak, held())
+			}
+		}
+	}()
+	run()
+	close(done)
+	wg.Wait()
+	return max(peak, held())
+}
+
+func TestPooledNoiseMatchesFresh(t *testing.T) {
+	gen := newCompositeTestGenerator(t, 256, GeneratorOptions{})
+	for _, downscale := range []int{1, 4} {
+		params := testParams(gen)
+		params.NoiseDownscale = downscale
+		params.NoiseCache = mask.NewNoiseCache(64)
+
+		// A recycled buffer holds the previous tile's noise; refilling must overwrite all of it
+		noise := noiseBuffers.get(params.Size())
+		for i := range noise.Pix {
+			noise.Pix[i] = 0xAA
+		}
+		watercolor.FillNoise(noise, params, 9, 100, 200)
+		want := watercolor.GenerateNoise(params, 9, 100, 200)
+		if !bytes.Equal(noise.Pix, want.Pix) {
+			t.Errorf("downscale %d: refilled noise differs from freshly generated noise", downscale)
+		}
+		noiseBuffers.put(noise)
+	}
+}
+
+// BenchmarkTileNoise generates the noise of a row of tiles at one size, as a batch does, with
+// and without recycling the buffers.
+func BenchmarkTileNoise(b *testing.B) {
+	gen := newCompositeTestGenerator(b, 256, GeneratorOptions{})
+	params := testParams(gen)
+	params.NoiseCache = mask.NewNoiseCache(4096)
+
+	tileParams := func(i int) watercolor.Params {
+		p := params
+		p.OffsetX += (i % 64) * gen.tileSize
+		return p
+	}
+	// Warm the slab cache so both variants only assemble the noise
+	for i := 0; i < 64; i++ {
+		_ = watercolor.GenerateNoise(tileParams(i), 9, 100+i, 200)
+	}
+
+	b.Run("allocating", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = watercolor.GenerateNoise(tileParams(i), 9, 100+i%64, 200)
+		}
+	})
+
+	b.Run("pooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			p := tileParams(i)
+			noise := noiseBuffers.get(p.Size())
+			watercolor.FillNoise(noise, p, 9, 100+i%64, 200)
+			noiseBuffers.put(noise)
+		}
+	})
+}
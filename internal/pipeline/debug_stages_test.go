@@ -0,0 +1,101 @@
+package pipeline
+
+import (
+	"image"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MeKo-Tech/watercolormap/internal/tile"
+)
+
+func TestDebugContextWriteStages(t *testing.T) {
+	dc := &DebugContext{}
+	dc.Capture("21_combined_final", "Final tile", image.NewNRGBA(image.Rect(0, 0, 4, 4)), 21)
+	dc.Capture("01_water_alpha", "Water alpha", image.NewAlpha(image.Rect(0, 0, 4, 4)), 1)
+
+	dir := filepath.Join(t.TempDir(), "stages")
+	if err := dc.WriteStages(dir); err != nil {
+		t.Fatalf("WriteStages: %v", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	want := []string{"01_water_alpha.png", "21_combined_final.png"}
+	if len(names) != len(want) || names[0] != want[0] || names[1] != want[1] {
+		t.Errorf("written stages = %v, want %v", names, want)
+	}
+
+	// A nil context writes nothing and does not create the directory
+	var nilDC *DebugContext
+	empty := filepath.Join(t.TempDir(), "none")
+	if err := nilDC.WriteStages(empty); err != nil {
+		t.Fatalf("nil WriteStages: %v", err)
+	}
+	if _, err := os.Stat(empty); !os.IsNotExist(err) {
+		t.Errorf("nil context should not create %s", empty)
+	}
+}
+
+func TestDebugContextWriteStagesStats(t *testing.T) {
+	blurred := image.NewGray(image.Rect(0, 0, 4, 1))
+	copy(blurred.Pix, []uint8{0, 10, 30, 40})
+	dc := &DebugContext{}
+	dc.Capture("02_water_blurred", "Blurred water mask", blurred, 2)
+	dc.Capture("21_combined_final", "Final tile", image.NewNRGBA(image.Rect(0, 0, 4, 4)), 21)
+
+	dir := t.TempDir()
+	if err := dc.WriteStages(dir); err != nil {
+		t.Fatalf("WriteStages: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "stats.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Only the mask stage has stats
+	if got, want := string(data), "02_water_blurred: min 0 max 40 mean 20.0 median 10\n"; got != want {
+		t.Errorf("stats.txt = %q, want %q", got, want)
+	}
+}
+
+func TestDebugStagesDir(t *testing.T) {
+	g := &Generator{options: GeneratorOptions{DebugStagesDir: "debug"}}
+	got := g.debugStagesDir(tile.NewCoords(13, 4297, 2754), "@2x")
+	want := filepath.Join("debug", "13", "4297", "2754@2x")
+	if got != want {
+		t.Errorf("debugStagesDir = %q, want %q", got, want)
+	}
+}
+
+func TestTilePathTMS(t *testing.T) {
+	coords := tile.NewCoords(13, 4317, 2692)
+
+	tests := []struct {
+		name            string
+		folderStructure string
+		suffix          string
+		tms             bool
+		want            string
+	}{
+		{"flat xyz", "flat", "", false, filepath.Join("out", "z13_x4317_y2692.png")},
+		{"flat tms", "flat", "", true, filepath.Join("out", "z13_x4317_y5499.png")},
+		{"nested tms", "nested", "@2x", true, filepath.Join("out", "13", "4317", "5499@2x.png")},
+		// Same locations as in the server's TestServeTileHashed, which reads them back
+		{"hashed xyz", "hashed", "", false, filepath.Join("out", "9a", "8a", "z13_x4317_y2692.png")},
+		{"hashed tms", "hashed", "_s42@2x", true, filepath.Join("out", "a5", "a5", "z13_x4317_y5499_s42@2x.png")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &Generator{outputDir: "out", options: GeneratorOptions{FolderStructure: tt.folderStructure, TMS: tt.tms}}
+			if got, _ := g.tilePath(coords, tt.suffix); got != tt.want {
+				t.Errorf("tilePath = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package pipeline
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/MeKo-Tech/watercolormap/internal/tile"
+	"github.com/MeKo-Tech/watercolormap/internal/types"
+)
+
+// emptyTileStore keeps the first empty tile written for each background color, so later
+// empty tiles of that color are stored as the same bytes instead of a fresh encode. It is
+// safe for concurrent use by the workers of a batch.
+//
+// It also remembers, per zoom, the background of the first empty tile rendered from data
+// without any features, so later featureless tiles of that zoom can reuse it unrendered (see
+// writeFeaturelessTile).
+type emptyTileStore struct {
+	mu          sync.Mutex
+	tiles       map[color.NRGBA]storedTile
+	featureless map[uint32]color.NRGBA
+}
+
+// storedTile is an encoded empty tile and the file it was written to ("" for TileWriter
+// output).
+type storedTile struct {
+	data []byte
+	path string
+}
+
+func (s *emptyTileStore) get(bg color.NRGBA) (storedTile, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.tiles[bg]
+	return t, ok
+}
+
+// put records t for bg unless another worker stored one first.
+func (s *emptyTileStore) put(bg color.NRGBA, t storedTile) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.tiles == nil {
+		s.tiles = make(map[color.NRGBA]storedTile)
+	}
+	if _, ok := s.tiles[bg]; !ok {
+		s.tiles[bg] = t
+	}
+}
+
+// featurelessBackground returns the background of the featureless tile stored for zoom z.
+func (s *emptyTileStore) featurelessBackground(z uint32) (color.NRGBA, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	bg, ok := s.featureless[z]
+	return bg, ok
+}
+
+// putFeatureless records bg as the background of featureless tiles at zoom z. The empty tile
+// of bg must have been stored already.
+func (s *emptyTileStore) putFeatureless(z uint32, bg color.NRGBA) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.featureless == nil {
+		s.featureless = make(map[uint32]color.NRGBA)
+	}
+	if _, ok := s.featureless[z]; !ok {
+		s.featureless[z] = bg
+	}
+}
+
+// featureless reports whether data holds no features at all: no coastline, water or land,
+// so the tile is open sea or empty land and renders as nothing but its background.
+func featureless(data *types.TileData) bool {
+	return data != nil && data.Features.Count() == 0
+}
+
+// writeFeaturelessTile writes the empty tile stored for featureless tiles at the zoom of
+// coords, without rendering, and reports whether one was stored. Callers check that the
+// tile's data is featureless.
+func (g *Generator) writeFeaturelessTile(coords tile.Coords, finalPath string, tee io.Writer) (bool, error) {
+	bg, ok := g.emptyStore.featurelessBackground(coords.Z)
+	if !ok {
+		return false, nil
+	}
+	// The stored tile exists, so writeEmptyTile needs no image
+	if err := g.writeEmptyTile(nil, bg, coords, finalPath, tee); err != nil {
+		return false, err
+	}
+	g.emptyTiles.Add(1)
+	g.featurelessTiles.Add(1)
+	g.log().Debug("Reused featureless tile", "coords", coords.String())
+	return true, nil
+}
+
+// writeEmptyTile writes an empty tile with background bg. The first one of each background is
+// encoded and stored; later ones reuse its bytes, which the MBTiles writer stores as a single
+// shared blob, and in folder output become hard links to its file.
+func (g *Generator) writeEmptyTile(final image.Image, bg color.NRGBA, coords tile.Coords, finalPath string, tee io.Writer) error {
+	z, x, y := int(coords.Z), int(coords.X), int(coords.Y)
+
+	stored, ok := g.emptyStore.get(bg)
+	if !ok {
+		var buf bytes.Buffer
+		if err := g.encodeTile(&buf, final); err != nil {
+			return fmt.Errorf("failed to encode tile: %w", err)
+		}
+		stored = storedTile{data: buf.Bytes()}
+	}
+
+	if tee != nil {
+		if _, err := tee.Write(stored.data); err != nil {
+			return fmt.Errorf("failed to copy tile: %w", err)
+		}
+	}
+
+	if g.options.TileWriter != nil {
+		g.log().Info("Writing empty tile via TileWriter", "coords", coords.String())
+		if err := g.options.TileWriter.WriteTile(z, x, y, stored.data); err != nil {
+			return fmt.Errorf("failed to write tile: %w", err)
+		}
+		if !ok {
+			g.emptyStore.put(bg, stored)
+		}
+		return nil
+	}
+
+	if ok && stored.path != "" && stored.path != finalPath {
+		linked, err := linkOver(stored.path, finalPath)
+		if err != nil {
+			return fmt.Errorf("failed to link empty tile: %w", err)
+		}
+		if linked {
+			g.log().Info("Linked empty tile", "coords", coords.String(), "path", finalPath, "target", stored.path)
+			return nil
+		}
+	}
+
+	// The first tile of its background, or links are unavailable: write a copy. The old file
+	// may be a link shared with other empty tiles, so it is removed rather than truncated.
+	g.log().Info("Writing final tile", "coords", coords.String(), "path", finalPath)
+	if err := os.Remove(finalPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to replace tile file: %w", err)
+	}
+	if err := os.WriteFile(finalPath, stored.data, 0o644); err != nil {
+		return fmt.Errorf("failed to write final tile: %w", err)
+	}
+	if !ok {
+		stored.path = finalPath
+		g.emptyStore.put(bg, stored)
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MeKo-Tech/watercolormap/internal/tile"
+	"github.com/MeKo-Tech/watercolormap/internal/types"
+)
+
+func TestEmptyTileTagging(t *testing.T) {
+	const size = 32
+	uniform := image.NewNRGBA(image.Rect(0, 0, size, size))
+	for i := range uniform.Pix {
+		uniform.Pix[i] = 240
+	}
+	painted := image.NewNRGBA(uniform.Bounds())
+	copy(painted.Pix, uniform.Pix)
+	for y := 8; y < 16; y++ {
+		for x := 8; x < 16; x++ {
+			painted.SetNRGBA(x, y, color.NRGBA{R: 80, G: 140, B: 200, A: 255})
+		}
+	}
+
+	tests := []struct {
+		name      string
+		tolerance uint8
+		want      int64
+	}{
+		{"disabled", 0, 0},
+		{"enabled", 8, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen := newCompositeTestGenerator(t, size, GeneratorOptions{EmptyTileTolerance: tt.tolerance})
+			dir := t.TempDir()
+			for i, img := range []*image.NRGBA{uniform, painted} {
+				coords := tile.NewCoords(5, uint32(i), 0)
+				if err := gen.writeTile(img, coords, filepath.Join(dir, coords.String()+".png")); err != nil {
+					t.Fatalf("writeTile failed: %v", err)
+				}
+			}
+			if got := gen.EmptyTiles(); got != tt.want {
+				t.Errorf("EmptyTiles = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEmptyTilesStoredOnce(t *testing.T) {
+	const size = 32
+	ocean := func(grain uint8) *image.NRGBA {
+		img := image.NewNRGBA(image.Rect(0, 0, size, size))
+		for i := 0; i < len(img.Pix); i += 4 {
+			img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = 80, 140, 200, 255
+		}
+		// Grain within the tolerance, different for every tile
+		img.SetNRGBA(size-1, size-1, color.NRGBA{R: 80 + grain, G: 140, B: 200, A: 255})
+		return img
+	}
+
+	t.Run("folder", func(t *testing.T) {
+		gen := newCompositeTestGenerator(t, size, GeneratorOptions{EmptyTileTolerance: 8})
+		dir := t.TempDir()
+		var paths []string
+		for i := range 3 {
+			coords := tile.NewCoords(5, uint32(i), 0)
+			path := filepath.Join(dir, coords.String()+".png")
+			if err := gen.writeTile(ocean(uint8(i)), coords, path); err != nil {
+				t.Fatalf("writeTile failed: %v", err)
+			}
+			paths = append(paths, path)
+		}
+
+		first, err := os.Stat(paths[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, path := range paths[1:] {
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !os.SameFile(first, info) {
+				t.Errorf("%s is not linked to %s", path, paths[0])
+			}
+		}
+
+		// Re-rendering a linked tile with content must not touch the shared file
+		coords := tile.NewCoords(5, 1, 0)
+		painted := ocean(0)
+		painted.SetNRGBA(4, 4, color.NRGBA{R: 240, G: 240, B: 240, A: 255})
+		if err := gen.writeTile(painted, coords, paths[1]); err != nil {
+			t.Fatalf("writeTile failed: %v", err)
+		}
+		a, _ := os.ReadFile(paths[0])
+		b, _ := os.ReadFile(paths[1])
+		if bytes.Equal(a, b) {
+			t.Error("rewriting a linked tile changed the tile it was linked to")
+		}
+	})
+
+	t.Run("writer", func(t *testing.T) {
+		w := &memTileWriter{tiles: map[[3]int][]byte{}}
+		gen := newCompositeTestGenerator(t, size, GeneratorOptions{EmptyTileTolerance: 8, TileWriter: w})
+		for i := range 3 {
+			if err := gen.writeTile(ocean(uint8(i)), tile.NewCoords(5, uint32(i), 0), ""); err != nil {
+				t.Fatalf("writeTile failed: %v", err)
+			}
+		}
+		for i := 1; i < 3; i++ {
+			if !bytes.Equal(w.tiles[[3]int{5, i, 0}], w.tiles[[3]int{5, 0, 0}]) {
+				t.Errorf("empty tile %d was not stored as the first one's bytes", i)
+			}
+		}
+	})
+}
+
+func TestFeaturelessTilesReused(t *testing.T) {
+	const size = 32
+	ocean := image.NewNRGBA(image.Rect(0, 0, size, size))
+	for i := 0; i < len(ocean.Pix); i += 4 {
+		ocean.Pix[i], ocean.Pix[i+1], ocean.Pix[i+2], ocean.Pix[i+3] = 80, 140, 200, 255
+	}
+	empty := &types.TileData{}
+
+	w := &memTileWriter{tiles: map[[3]int][]byte{}}
+	gen := newCompositeTestGenerator(t, size, GeneratorOptions{EmptyTileTolerance: 8, TileWriter: w})
+
+	// An empty tile with features isn't reused for featureless ones
+	if err := gen.writeTileTo(ocean, tile.NewCoords(5, 0, 0), "", nil, false); err != nil {
+		t.Fatalf("writeTileTo failed: %v", err)
+	}
+	if written, err := gen.writeFeaturelessTile(tile.NewCoords(5, 1, 0), "", nil); err != nil || written {
+		t.Fatalf("writeFeaturelessTile = %v, %v before a featureless tile was stored", written, err)
+	}
+
+	if err := gen.writeTileTo(ocean, tile.NewCoords(5, 2, 0), "", nil, true); err != nil {
+		t.Fatalf("writeTileTo failed: %v", err)
+	}
+
+	// The data source is nil, so anything but the stored tile would fail to render
+	var buf bytes.Buffer
+	if _, err := gen.GenerateTo(context.Background(), tile.NewCoords(5, 3, 0), true, "", &buf, empty); err != nil {
+		t.Fatalf("GenerateTo failed: %v", err)
+	}
+	first := w.tiles[[3]int{5, 2, 0}]
+	if !bytes.Equal(w.tiles[[3]int{5, 3, 0}], first) || !bytes.Equal(buf.Bytes(), first) {
+		t.Error("featureless tile was not written as the stored one")
+	}
+	if got := gen.FeaturelessTiles(); got != 1 {
+		t.Errorf("FeaturelessTiles = %d, want 1", got)
+	}
+	if got := gen.EmptyTiles(); got != 3 {
+		t.Errorf("EmptyTiles = %d, want 3", got)
+	}
+
+	// Other zooms render their own featureless tile first
+	if written, err := gen.writeFeaturelessTile(tile.NewCoords(6, 0, 0), "", nil); err != nil || written {
+		t.Errorf("writeFeaturelessTile = %v, %v at a zoom without a featureless tile", written, err)
+	}
+}
+
+// memTileWriter keeps written tiles in memory.
+type memTileWriter struct {
+	tiles map[[3]int][]byte
+}
+
+func (w *memTileWriter) WriteTile(z, x, y int, pngData []byte) error {
+	w.tiles[[3]int{z, x, y}] = pngData
+	return nil
+}
@@ -0,0 +1,111 @@
+package pipeline
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MeKo-Tech/watercolormap/internal/geojson"
+	"github.com/MeKo-Tech/watercolormap/internal/renderer"
+	"github.com/MeKo-Tech/watercolormap/internal/tile"
+	"github.com/MeKo-Tech/watercolormap/internal/watercolor"
+)
+
+// stubRenderResult stands in for a Mapnik render: land covers the tile, water its left half,
+// and the parks layer failed.
+func stubRenderResult(t *testing.T, size int) *renderer.TileRenderResult {
+	t.Helper()
+	dir := t.TempDir()
+	writeLayer := func(layer geojson.LayerType, covers func(x, y int) bool) *renderer.LayerRenderResult {
+		img := image.NewNRGBA(image.Rect(0, 0, size, size))
+		for y := 0; y < size; y++ {
+			for x := 0; x < size; x++ {
+				if covers(x, y) {
+					img.SetNRGBA(x, y, color.NRGBA{A: 255})
+				}
+			}
+		}
+		path := filepath.Join(dir, string(layer)+".png")
+		f, err := os.Create(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close() // nolint:errcheck
+		if err := png.Encode(f, img); err != nil {
+			t.Fatal(err)
+		}
+		return &renderer.LayerRenderResult{Layer: layer, OutputPath: path}
+	}
+
+	return &renderer.TileRenderResult{
+		Layers: map[geojson.LayerType]*renderer.LayerRenderResult{
+			geojson.LayerLand:  writeLayer(geojson.LayerLand, func(x, y int) bool { return true }),
+			geojson.LayerWater: writeLayer(geojson.LayerWater, func(x, y int) bool { return x < size/2 }),
+			geojson.LayerParks: {Layer: geojson.LayerParks, Error: &renderer.RenderError{Layer: geojson.LayerParks, Err: errors.New("failed to load style")}},
+		},
+	}
+}
+
+func TestSkipFailedLayers(t *testing.T) {
+	coords := tile.NewCoords(13, 100, 200)
+
+	t.Run("disabled", func(t *testing.T) {
+		gen := newCompositeTestGenerator(t, 64, GeneratorOptions{})
+		params := testParams(gen)
+		if _, err := gen.readLayers(stubRenderResult(t, params.TileSize), coords); err == nil {
+			t.Fatal("expected the failed parks layer to fail the tile")
+		}
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		gen := newCompositeTestGenerator(t, 64, GeneratorOptions{SkipFailedLayers: true})
+		params := testParams(gen)
+		params.PerlinNoise = watercolor.GenerateNoise(params, 13, 100, 200)
+
+		raw, err := gen.readLayers(stubRenderResult(t, params.TileSize), coords)
+		if err != nil {
+			t.Fatalf("readLayers failed: %v", err)
+		}
+		if _, ok := raw[geojson.LayerParks]; ok {
+			t.Error("failed parks layer was kept")
+		}
+
+		masks, err := gen.tileMasks(raw, params, nil)
+		if err != nil {
+			t.Fatalf("tileMasks failed: %v", err)
+		}
+		painted, err := paintAllLayers(raw, masks, params, gen.textures, false, 0, nil, nil, nil)
+		if err != nil {
+			t.Fatalf("paintAllLayers failed: %v", err)
+		}
+		for _, layer := range []geojson.LayerType{geojson.LayerLand, geojson.LayerWater} {
+			if painted[layer] == nil {
+				t.Errorf("%s layer missing from the painted tile", layer)
+			}
+		}
+		composited, err := gen.compositeLayers(painted, params, nil)
+		if err != nil {
+			t.Fatalf("compositeLayers failed: %v", err)
+		}
+		defer metatileBuffers.put(composited)
+
+		c := params.TileSize / 2
+		report := analyzeTile(composited, painted, image.Rect(c-16, c-16, c+16, c+16))
+		if report.OpaqueFraction < 1 || report.WaterFraction == 0 {
+			t.Errorf("painted %.2f, water %.2f; want a fully painted tile with water", report.OpaqueFraction, report.WaterFraction)
+		}
+	})
+
+	t.Run("land is required", func(t *testing.T) {
+		gen := newCompositeTestGenerator(t, 64, GeneratorOptions{SkipFailedLayers: true})
+		result := stubRenderResult(t, testParams(gen).TileSize)
+		result.Layers[geojson.LayerLand] = &renderer.LayerRenderResult{Layer: geojson.LayerLand, Error: errors.New("failed to render land layer")}
+		if _, err := gen.readLayers(result, coords); err == nil {
+			t.Fatal("expected a failed land layer to fail the tile")
+		}
+	})
+}
@@ -0,0 +1,55 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+
+	"github.com/MeKo-Tech/watercolormap/internal/tile"
+	"github.com/MeKo-Tech/watercolormap/internal/types"
+)
+
+// boundsRecordingDataSource returns empty tile data and records the requested bounds.
+type boundsRecordingDataSource struct {
+	bounds types.BoundingBox
+	calls  int
+}
+
+func (s *boundsRecordingDataSource) FetchTileData(ctx context.Context, coord types.TileCoordinate) (*types.TileData, error) {
+	return s.FetchTileDataWithBounds(ctx, coord, types.TileToBounds(coord))
+}
+
+func (s *boundsRecordingDataSource) FetchTileDataWithBounds(_ context.Context, coord types.TileCoordinate, bounds types.BoundingBox) (*types.TileData, error) {
+	s.calls++
+	s.bounds = bounds
+	return &types.TileData{Coordinate: coord, Bounds: bounds, Source: "test"}, nil
+}
+
+func TestFetchOnlyFetchesPaddedTile(t *testing.T) {
+	gen := newCompositeTestGenerator(t, 256, GeneratorOptions{})
+	ds := &boundsRecordingDataSource{}
+	gen.ds = ds
+
+	coords := tile.NewCoords(13, 4317, 2692)
+	data, err := gen.FetchOnly(context.Background(), coords)
+	if err != nil {
+		t.Fatalf("FetchOnly failed: %v", err)
+	}
+	if ds.calls != 1 || data.Source != "test" {
+		t.Fatalf("expected one fetch through the data source, got %d calls and %+v", ds.calls, data)
+	}
+
+	tb := types.TileToBounds(types.TileCoordinate{Zoom: 13, X: 4317, Y: 2692})
+	if !(ds.bounds.MinLon < tb.MinLon && ds.bounds.MinLat < tb.MinLat && ds.bounds.MaxLon > tb.MaxLon && ds.bounds.MaxLat > tb.MaxLat) {
+		t.Errorf("fetched bounds %+v do not pad the tile bounds %+v", ds.bounds, tb)
+	}
+	if want := gen.CalculateFetchBounds(coords); ds.bounds != want {
+		t.Errorf("fetched bounds %+v, want CalculateFetchBounds %+v", ds.bounds, want)
+	}
+}
+
+func TestRenderFromDataRequiresData(t *testing.T) {
+	gen := newCompositeTestGenerator(t, 256, GeneratorOptions{})
+	if _, err := gen.RenderFromData(context.Background(), tile.NewCoords(13, 4317, 2692), nil); err == nil {
+		t.Error("expected an error without tile data")
+	}
+}
@@ -0,0 +1,80 @@
+package pipeline
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MeKo-Tech/watercolormap/internal/geojson"
+	"github.com/MeKo-Tech/watercolormap/internal/watercolor"
+)
+
+// TestFlatStyleGolden paints a synthetic tile with a round lake and a park in the flat style
+// and compares it against a golden (set UPDATE_GOLDEN=1 to regenerate).
+func TestFlatStyleGolden(t *testing.T) {
+	goldenPath := filepath.Join("..", "..", "testdata", "golden", "pipeline-flat", "lake_park.png")
+	debugDir := filepath.Join("..", "..", "testdata", "output", "pipeline-flat")
+
+	gen := newCompositeTestGenerator(t, 256, GeneratorOptions{Style: watercolor.StyleFlat})
+	params := gen.baseParams()
+	params.TileSize = gen.tileSize + 2*testPadPx
+	params.OffsetX = 100*gen.tileSize - testPadPx
+	params.OffsetY = 200*gen.tileSize - testPadPx
+	params.PerlinNoise = watercolor.GenerateNoise(params, 13, 100, 200)
+
+	// A round lake with a Mapnik-like antialiased rim, and a park along the bottom
+	size := params.TileSize
+	water := image.NewNRGBA(image.Rect(0, 0, size, size))
+	parks := image.NewNRGBA(water.Bounds())
+	c := float64(size) / 2
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			d := math.Hypot(float64(x)+0.5-c, float64(y)+0.5-c)
+			if cover := math.Min(math.Max(70.5-d, 0), 1); cover > 0 {
+				water.SetNRGBA(x, y, color.NRGBA{B: 255, A: uint8(255 * cover)})
+			}
+			if y >= size*3/4 {
+				parks.SetNRGBA(x, y, color.NRGBA{G: 255, A: 255})
+			}
+		}
+	}
+	raw := map[geojson.LayerType]image.Image{geojson.LayerWater: water, geojson.LayerParks: parks}
+
+	masks, err := gen.tileMasks(raw, params, nil)
+	if err != nil {
+		t.Fatalf("tileMasks failed: %v", err)
+	}
+	painted, err := paintAllLayers(raw, masks, params, gen.textures, false, 0, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("paintAllLayers failed: %v", err)
+	}
+	var buf bytes.Buffer
+	pooledTile(t, gen, painted, params, &buf)
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatalf("failed to decode tile: %v", err)
+	}
+
+	// Solid fills: land, lake and park are each one color, without texture or edge darkening
+	for _, pair := range [][2]image.Point{
+		{{2, 2}, {250, 30}},      // Land
+		{{128, 128}, {128, 180}}, // Lake, center and close to the shore
+		{{10, 240}, {200, 250}},  // Park
+	} {
+		if a, b := img.At(pair[0].X, pair[0].Y), img.At(pair[1].X, pair[1].Y); a != b {
+			t.Errorf("expected a solid fill, got %v at %v and %v at %v", a, pair[0], b, pair[1])
+		}
+	}
+
+	writePNG(t, filepath.Join(debugDir, "lake_park.png"), img)
+	if os.Getenv("UPDATE_GOLDEN") == "1" {
+		writePNG(t, goldenPath, img)
+		return
+	}
+	assertImagesEqual(t, goldenPath, img, "lake_park")
+}
@@ -0,0 +1,80 @@
+package pipeline
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/MeKo-Tech/watercolormap/internal/geojson"
+	"github.com/MeKo-Tech/watercolormap/internal/watercolor"
+)
+
+// TestPaintAllLayersForest checks that forests are painted as their own deeper-green layer
+// when styled and left to the parks layer otherwise.
+func TestPaintAllLayersForest(t *testing.T) {
+	gen := newCompositeTestGenerator(t, 256, GeneratorOptions{})
+
+	rect := func(size, x0, y0, x1, y1 int) *image.NRGBA {
+		img := image.NewNRGBA(image.Rect(0, 0, size, size))
+		for y := y0; y < y1; y++ {
+			for x := x0; x < x1; x++ {
+				img.SetNRGBA(x, y, color.NRGBA{A: 255})
+			}
+		}
+		return img
+	}
+	luminance := func(c color.NRGBA) int { return 299*int(c.R) + 587*int(c.G) + 114*int(c.B) }
+
+	tests := []struct {
+		name       string
+		style      bool
+		wantForest bool
+	}{
+		{name: "styled", style: true, wantForest: true},
+		{name: "no forest style", style: false, wantForest: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := testParams(gen)
+			params.PerlinNoise = watercolor.GenerateNoise(params, 13, 100, 200)
+			if !tt.style {
+				delete(params.Styles, geojson.LayerForest)
+			}
+
+			c := params.TileSize / 2
+			raw := map[geojson.LayerType]image.Image{
+				geojson.LayerParks:  rect(params.TileSize, c-80, c-80, c+80, c+80),
+				geojson.LayerForest: rect(params.TileSize, c-40, c-40, c+40, c+40),
+			}
+			masks, err := buildMasks(raw, params, nil)
+			if err != nil {
+				t.Fatalf("buildMasks failed: %v", err)
+			}
+			painted, err := paintAllLayers(raw, masks, params, gen.textures, false, 0, nil, nil, nil)
+			if err != nil {
+				t.Fatalf("paintAllLayers failed: %v", err)
+			}
+
+			forest, ok := painted[geojson.LayerForest]
+			if ok != tt.wantForest {
+				t.Fatalf("forest painted = %v, want %v", ok, tt.wantForest)
+			}
+			if !tt.wantForest {
+				return
+			}
+
+			parks := painted[geojson.LayerParks].(*image.NRGBA).NRGBAAt(c, c)
+			got := forest.(*image.NRGBA).NRGBAAt(c, c)
+			if got.A == 0 {
+				t.Fatal("expected the forest center to be painted")
+			}
+			if luminance(got) >= luminance(parks) {
+				t.Errorf("forest %+v should be darker than parks %+v", got, parks)
+			}
+			if a := forest.(*image.NRGBA).NRGBAAt(c-70, c-70).A; a != 0 {
+				t.Errorf("expected no forest outside its polygon, got alpha %d", a)
+			}
+		})
+	}
+}
@@ -5,13 +5,20 @@ import (
 	"context"
 	"fmt"
 	"image"
+	"image/color"
+	"image/jpeg"
 	"image/png"
+	"io"
 	"log/slog"
+	"maps"
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/MeKo-Tech/watercolormap/internal/composite"
 	"github.com/MeKo-Tech/watercolormap/internal/geojson"
@@ -68,6 +75,47 @@ func (dc *DebugContext) SortedStages() []StageCapture {
 	return sorted
 }
 
+// WriteStages writes every captured stage to dir as <Name>.png, in ZOrder. The gray-value
+// statistics of the mask stages (see mask.Stats) go to stats.txt, one stage per line.
+func (dc *DebugContext) WriteStages(dir string) error {
+	stages := dc.SortedStages()
+	if len(stages) == 0 {
+		return nil
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create debug stages dir: %w", err)
+	}
+	var stats strings.Builder
+	for _, stage := range stages {
+		if err := writeStage(filepath.Join(dir, stage.Name+".png"), stage.Image); err != nil {
+			return fmt.Errorf("failed to write stage %s: %w", stage.Name, err)
+		}
+		if m, ok := stage.Image.(*image.Gray); ok {
+			fmt.Fprintf(&stats, "%s: %s\n", stage.Name, mask.ComputeStats(m))
+		}
+	}
+	if stats.Len() > 0 {
+		if err := os.WriteFile(filepath.Join(dir, "stats.txt"), []byte(stats.String()), 0o644); err != nil {
+			return fmt.Errorf("failed to write stage stats: %w", err)
+		}
+	}
+	return nil
+}
+
+func writeStage(path string, img image.Image) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	// Debug output: favor speed over size
+	enc := png.Encoder{CompressionLevel: png.BestSpeed}
+	if err := enc.Encode(f, img); err != nil {
+		f.Close() // nolint:errcheck
+		return err
+	}
+	return f.Close()
+}
+
 // GeneratorOptions controls output and encoding behavior.
 type GeneratorOptions struct {
 	// PNGCompression controls PNG encoding. Supported values:
@@ -78,9 +126,191 @@ type GeneratorOptions struct {
 	// If nil, tiles are written to disk in outputDir.
 	TileWriter TileWriter
 
+	// MaxDataZoom over-zooms deeper tiles: they render from the data of their ancestor at
+	// this zoom, cropped to their own bounds, instead of fetching their own tiny area. OSM
+	// detail hardly grows past z16, and the siblings of a tile then share one fetch (see
+	// Generator.DataTile). Noise and textures stay aligned to the rendered tile, so over-zoomed
+	// tiles are as seamless as any others. Applies to single tiles, not metatiles (0 = off).
+	MaxDataZoom int
+
+	// Grid is the tile grid tiles are placed in: the area each tile coordinate fetches and
+	// renders. The zero value uses tile.WebMercator, the standard XYZ grid.
+	Grid tile.Grid
+
 	// FolderStructure controls file naming for folder format. Supported values:
-	// "flat" (z{z}_x{x}_y{y}.png), "nested" ({z}/{x}/{y}.png).
+	// "flat" (z{z}_x{x}_y{y}.png), "nested" ({z}/{x}/{y}.png) and "hashed"
+	// ({ab}/{cd}/z{z}_x{x}_y{y}.png, see tile.Coords.HashedDir).
 	FolderStructure string
+
+	// Textures optionally supplies pre-loaded layer textures (e.g. from
+	// texture.LoadTexturesFromFS or texture.LoadTexturesFromURLs). When set,
+	// texturesDir passed to NewGenerator is ignored. Without Params, every layer the default
+	// styles paint needs a texture; NewGenerator fails otherwise.
+	Textures map[geojson.LayerType]image.Image
+
+	// NoiseSeedMode selects how the Perlin noise field is seeded: "global" (default)
+	// keeps noise continuous across tiles; "per-tile" decorrelates tiles at the cost
+	// of small seams; "region" derives the seed from the z8 parent tile so regions differ
+	// while tiles within a region stay seamless. See watercolor.NoiseSeedGlobal,
+	// watercolor.NoiseSeedPerTile and watercolor.NoiseSeedRegion.
+	NoiseSeedMode string
+
+	// PixelRatio is the device pixel ratio of the rendered tiles (2 for @2x generators created
+	// with twice the base tile size). The noise period is scaled by it so @1x and @2x tiles of
+	// the same area show the same noise field. 0 is treated as 1.
+	PixelRatio int
+
+	// LayerOverrides adjusts the Mapnik styles of individual layers at render time, e.g.
+	// scaling road widths without editing the style XML (see renderer.LayerRenderOverride).
+	// Layers without an entry render as styled.
+	LayerOverrides map[geojson.LayerType]renderer.LayerRenderOverride
+
+	// MapnikBufferPx is the margin in rendered pixels around the padded render in which
+	// Mapnik still queries and draws features, so wide road casings just outside aren't
+	// clipped at the image edge (see renderer.MultiPassRenderer.SetBufferSize). It only
+	// reaches features in the fetched data, which covers the padding. 0 uses
+	// renderer.DefaultBufferPx.
+	MapnikBufferPx int
+
+	// SkipFailedLayers drops layers whose Mapnik render failed (e.g. a broken style) from the
+	// tile and logs them, instead of failing the whole tile. The land layer is required either
+	// way. Off by default: a failed layer fails the tile rather than silently leaving it out
+	// (which earlier versions did).
+	SkipFailedLayers bool
+
+	// SharedEdges darkens the boundary where two painted layers meet (e.g. a park abutting a
+	// road) once instead of on both sides, which stacks into a dark seam: the lower layer (in
+	// compositing order) skips its edge darkening along the layers above it and only the upper
+	// layer's edge remains. Applies to parks, forest, urban, buildings, roads and highways.
+	// Off by default.
+	SharedEdges bool
+
+	// LogTiming logs the duration of each pipeline stage (noise, fetch, render, masks,
+	// per-layer paint, composite, encode) for every tile. Off by default.
+	LogTiming bool
+
+	// TransparentBackground skips the paper base and the land fill so only feature layers
+	// (water, rivers, parks, roads, ...) are painted over a transparent canvas. The land mask is
+	// still computed to constrain parks, urban areas and buildings. Useful for overlay tiles.
+	TransparentBackground bool
+
+	// NoiseDownscale, when >1, generates the noise field at 1/NoiseDownscale resolution and
+	// upscales it bilinearly. 2 or 4 is visually indistinguishable at the default noise scale.
+	NoiseDownscale int
+
+	// NoiseCache, when set, keeps slabs of the noise field in memory so neighboring tiles
+	// reuse the overlapping noise instead of sampling it again (see mask.NoiseCache). The
+	// output is identical; one cache can be shared by several generators.
+	NoiseCache *mask.NoiseCache
+
+	// Params optionally replaces watercolor.DefaultParams as the base styling (e.g. loaded
+	// with watercolor.LoadParams). Style textures are resolved from their TextureFile against
+	// the generator's textures, falling back to files in texturesDir. Tile size and seed are
+	// always taken from the generator.
+	Params *watercolor.Params
+
+	// PaintWorkers is the number of layers painted concurrently within a tile once the land
+	// mask is known. 0 or 1 paints sequentially, which suits batch renders that already run
+	// tiles in parallel; higher values cut latency for single-tile (on-demand) renders.
+	// The output is identical for any value.
+	PaintWorkers int
+
+	// DebugStagesDir, when set, captures the intermediate stages of every tile rendered with
+	// Generate, GenerateWithData or GenerateTo and writes them as numbered PNGs
+	// (01_water_alpha.png, ...) to <DebugStagesDir>/<z>/<x>/<y><suffix>/. Metatile renders are
+	// not captured. Empty (the default) keeps the nil DebugContext fast path.
+	DebugStagesDir string
+
+	// EmitMetadata writes a JSON sidecar next to every tile rendered with Generate,
+	// GenerateWithData or GenerateTo, recording what produced it (see TileMetadata). Tiles
+	// written through a TileWriter and metatile renders get none.
+	EmitMetadata bool
+
+	// TMS names output files with TMS row numbers (y grows northward) instead of XYZ ones.
+	// Coordinates passed to the generator are always XYZ; only file and directory names are
+	// flipped. TileWriter backends still receive XYZ coordinates.
+	TMS bool
+
+	// Monochrome, when set, renders the map as tints of a single ink color: every painted
+	// layer (and the paper) is desaturated onto a value ramp from white to the ink before
+	// compositing. Layers further to the front of the composite order get darker values, so
+	// land stays light and water, roads and buildings read progressively darker.
+	Monochrome *color.RGBA
+
+	// Tone is a global brightness/contrast/saturation/gamma correction applied to the
+	// composited tile before it is cropped and encoded. The zero value leaves tiles unchanged.
+	Tone composite.ToneAdjust
+
+	// Dither, when > 0, adds blue noise of this peak-to-peak strength in 8-bit levels to the
+	// composited tile after the tone correction (see composite.Dither) to break up banding in
+	// smooth gradients. The pattern is anchored to global pixels, so tiles stay seamless.
+	// 0 (the default) keeps output byte-identical to undithered tiles.
+	Dither float64
+
+	// Vignette, when its Strength is > 0, darkens the composite where roads, buildings and
+	// urban areas are dense, leaving open land bright (see composite.ApplyVignette). The
+	// density is the union of those layers blurred across the metatile padding, so tiles stay
+	// seamless. It is applied before the tone correction. The zero value is off.
+	Vignette composite.Vignette
+
+	// LandTint, when its Strength is > 0, glazes the land wash green around parks and forests
+	// (see composite.ApplyLandTint), so land varies with the surrounding landcover instead of
+	// being one tan everywhere. Like the vignette, the vegetation density is blurred across the
+	// metatile padding, so neighboring tiles stay seamless. The zero value is off.
+	LandTint composite.LandTint
+
+	// Bridges carves roads and highways that lie over water out of the water and rivers
+	// layers, so bridges and causeways sit on paper like roads on land instead of over the
+	// water wash. Off by default.
+	Bridges bool
+
+	// Style selects a look preset applied on top of Params (or the default styles):
+	// watercolor.StyleWatercolor (the default) or watercolor.StyleFlat, which paints crisp
+	// solid fills over a plain paper color (see watercolor.Flatten).
+	Style string
+
+	// TileFormat selects the encoding of written tiles: "png" (the default) or "jpeg". JPEG has
+	// no alpha channel, so tiles are flattened onto FlattenColor first, which is required with
+	// TransparentBackground. There is no WebP encoder.
+	TileFormat string
+
+	// FlattenColor is the opaque background tiles are flattened onto when TileFormat has no
+	// alpha channel. The zero value means unset, which is fine for tiles painted over paper:
+	// they are opaque already.
+	FlattenColor color.RGBA
+
+	// EmptyTileTolerance, when > 0, checks every written tile with composite.ContentBounds and
+	// tags tiles without content beyond this per-channel tolerance (solid land, open ocean) as
+	// empty: they are logged, counted (see Generator.EmptyTiles) and stored once per background
+	// color. Later empty tiles of that color reuse the first one's bytes, hard-linked to its file
+	// in folder output and sharing its blob in MBTiles. The tolerance should cover the texture
+	// grain, e.g. 24. 0 disables it.
+	//
+	// It also skips rendering tiles whose data has no features at all (open sea away from the
+	// coastline, empty land): once the first of a zoom renders empty, later featureless tiles
+	// of that zoom are written as its bytes straight after the fetch (see
+	// Generator.FeaturelessTiles). Tiles with EmitMetadata or debug stages are always rendered.
+	EmptyTileTolerance uint8
+
+	// Paletted writes PNG tiles as 8-bit indexed images of at most PaletteColors colors (see
+	// composite.Quantize). Flat-style tiles have few colors, so they stay exact and shrink
+	// considerably; watercolor gradients and paper grain band, so it suits flat styles. JPEG
+	// tiles ignore it. Off by default.
+	Paletted bool
+
+	// PaletteColors is the largest palette of Paletted tiles, 2 to 256; 0 means 256.
+	PaletteColors int
+
+	// RerenderChanged checks existing tiles against their data instead of skipping them when
+	// rendering without force: the data is fetched and the tile is rendered again only when
+	// its features hash differently from those recorded in the tile's metadata sidecar (see
+	// TileMetadata.DataHash), or when the sidecar records none. It implies EmitMetadata so the
+	// hash is kept. Metatile renders and TileWriter output don't keep sidecars and ignore it.
+	RerenderChanged bool
+
+	// MaxTileAge, when > 0, renders existing tiles written longer ago than this again when
+	// rendering without force, whether or not their data changed.
+	MaxTileAge time.Duration
 }
 
 // TileWriter writes tile data to a storage backend.
@@ -88,6 +318,30 @@ type TileWriter interface {
 	WriteTile(z, x, y int, pngData []byte) error
 }
 
+// TileStreamWriter is an optional extension of TileWriter for backends that accept tile
+// bytes incrementally. When the configured TileWriter implements it, tiles are PNG-encoded
+// directly into the stream instead of being buffered in memory first.
+type TileStreamWriter interface {
+	TileWriter
+	TileStream(z, x, y int) (TileStream, error)
+}
+
+// TileChecker is an optional extension of TileWriter for backends that can tell whether they
+// already hold a tile. Without force, tiles the backend holds are skipped; without it, the
+// tile's path under the output directory is checked as for folder output. The backend keeps
+// no write time, so MaxTileAge does not apply to its tiles.
+type TileChecker interface {
+	TileWriter
+	HasTile(z, x, y int) (bool, error)
+}
+
+// TileStream receives the encoded bytes of one tile. Close commits the tile; Abort discards
+// what was written so far, e.g. after a failed encode, so no partial tile is stored.
+type TileStream interface {
+	io.WriteCloser
+	Abort() error
+}
+
 // DataSource fetches OSM features for a tile coordinate.
 type DataSource interface {
 	FetchTileData(context.Context, types.TileCoordinate) (*types.TileData, error)
@@ -108,18 +362,89 @@ type Generator struct {
 	tileSize   int
 	seed       int64
 	keepLayers bool
+	keptLayers layerStore         // deduplicates kept layer PNGs across tiles
+	params     *watercolor.Params // resolved GeneratorOptions.Params; nil = DefaultParams
+	emptyTiles atomic.Int64       // tiles tagged empty (GeneratorOptions.EmptyTileTolerance)
+	emptyStore emptyTileStore     // first encoded empty tile per background color
+
+	featurelessTiles atomic.Int64 // tiles written unrendered as their zoom's featureless tile
+
+	overzoomData tileDataCache // ancestor data of over-zoomed tiles (GeneratorOptions.MaxDataZoom)
 }
 
 // NewGenerator loads textures and prepares a generator.
+// If texturesDir is empty and opts.Textures is nil, the textures embedded in the binary are used.
 func NewGenerator(ds DataSource, stylesDir, texturesDir, outputDir string, tileSize int, seed int64, keepLayers bool, logger *slog.Logger, opts GeneratorOptions) (*Generator, error) {
 	if tileSize <= 0 {
 		return nil, fmt.Errorf("tile size must be positive")
 	}
-
-	textures, err := texture.LoadDefaultTextures(texturesDir)
+	format, err := normalizeTileFormat(opts)
 	if err != nil {
 		return nil, err
 	}
+	opts.TileFormat = format
+	style, err := watercolor.ParseStyle(opts.Style)
+	if err != nil {
+		return nil, err
+	}
+	opts.Style = style
+	if opts.RerenderChanged {
+		opts.EmitMetadata = true
+	}
+	if opts.PaletteColors < 0 || opts.PaletteColors == 1 || opts.PaletteColors > composite.MaxPaletteColors {
+		return nil, fmt.Errorf("palette colors must be 0 or between 2 and %d, got %d", composite.MaxPaletteColors, opts.PaletteColors)
+	}
+
+	textures := opts.Textures
+	if textures == nil {
+		if texturesDir == "" {
+			textures, err = texture.LoadEmbeddedDefaultTextures()
+		} else {
+			textures, err = texture.LoadDefaultTextures(texturesDir)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var params *watercolor.Params
+	if opts.Params != nil {
+		resolved, err := resolveParamsTextures(*opts.Params, textures, texturesDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve params textures: %w", err)
+		}
+		unknown, err := watercolor.CheckCompositeOrder(resolved.Order(), resolved.Styles)
+		if err != nil {
+			return nil, fmt.Errorf("invalid params: %w", err)
+		}
+		if len(unknown) > 0 {
+			log := logger
+			if log == nil {
+				log = slog.Default()
+			}
+			log.Warn("Composite order lists layers without a style; they are ignored", "layers", unknown)
+		}
+		params = &resolved
+	}
+	if params == nil {
+		if err := checkDefaultTextures(textures); err != nil {
+			return nil, err
+		}
+	}
+
+	if style == watercolor.StyleFlat {
+		flat := watercolor.FlatParams(tileSize, seed, textures)
+		if params != nil {
+			flat = watercolor.Flatten(*params)
+		}
+		params = &flat
+
+		// The paper shows as a plain color too
+		textures = maps.Clone(textures)
+		if paper := textures[geojson.LayerPaper]; paper != nil {
+			textures[geojson.LayerPaper] = texture.Flat(paper)
+		}
+	}
 
 	return &Generator{
 		ds:         ds,
@@ -129,6 +454,7 @@ func NewGenerator(ds DataSource, stylesDir, texturesDir, outputDir string, tileS
 		tileSize:   tileSize,
 		seed:       seed,
 		keepLayers: keepLayers,
+		params:     params,
 		logger:     logger,
 		options:    opts,
 	}, nil
@@ -150,59 +476,168 @@ func (g *Generator) GenerateWithData(ctx context.Context, coords tile.Coords, fo
 	if debugCtx != nil {
 		dc = debugCtx.(*DebugContext)
 	}
-	suffix := strings.TrimSpace(filenameSuffix)
+	return g.generate(ctx, coords, force, filenameSuffix, dc, prefetchedData, nil)
+}
 
-	// Compute final path based on folder structure setting
-	var finalPath string
-	var tileDir string
-	if g.options.FolderStructure == "nested" {
-		// Nested structure: {z}/{x}/{y}.png
-		z := fmt.Sprintf("%d", coords.Z)
-		x := fmt.Sprintf("%d", coords.X)
-		y := fmt.Sprintf("%d", coords.Y)
-		tileDir = filepath.Join(g.outputDir, z, x)
-		finalPath = filepath.Join(tileDir, y+suffix+".png")
-	} else {
-		// Flat structure (default): z{z}_x{x}_y{y}.png
-		finalPath = filepath.Join(g.outputDir, fmt.Sprintf("%s%s.png", coords.String(), suffix))
-		tileDir = g.outputDir
+// GenerateTo renders and writes a tile like GenerateWithData, and also encodes it straight
+// into w (for example an http.ResponseWriter) while it is written, so a caller serving the
+// tile needn't buffer it or read it back. Nothing is written to w when an existing tile is
+// kept. It returns the path of the tile.
+func (g *Generator) GenerateTo(ctx context.Context, coords tile.Coords, force bool, filenameSuffix string, w io.Writer, prefetchedData *types.TileData) (string, error) {
+	path, _, err := g.generate(ctx, coords, force, filenameSuffix, nil, prefetchedData, w)
+	return path, err
+}
+
+// generate implements GenerateWithData and GenerateTo; tee, when non-nil, receives a copy of
+// the encoded tile.
+func (g *Generator) generate(ctx context.Context, coords tile.Coords, force bool, filenameSuffix string, dc *DebugContext, prefetchedData *types.TileData, tee io.Writer) (string, string, error) {
+	writeStages := dc == nil && g.options.DebugStagesDir != ""
+	if writeStages {
+		dc = &DebugContext{}
 	}
+	finalPath, tileDir := g.tilePath(coords, filenameSuffix)
 
 	if !force {
-		if _, err := os.Stat(finalPath); err == nil {
+		current, data, err := g.existingTileCurrent(ctx, coords, finalPath, prefetchedData)
+		if err != nil {
+			return "", "", err
+		}
+		if current {
 			g.log().Info("Tile already exists; skipping", "coords", coords.String(), "path", finalPath)
 			return finalPath, "", nil
 		}
+		prefetchedData = data
 	}
 
 	if err := os.MkdirAll(tileDir, 0o755); err != nil {
 		return "", "", fmt.Errorf("failed to create output dir: %w", err)
 	}
 
-	// Phase 1: Setup and render all layers (optionally with pre-fetched data)
-	renderResult, err := g.renderLayersWithData(ctx, coords, dc, prefetchedData)
+	// Featureless tiles reuse the first one of their zoom; the data is needed to tell, and is
+	// passed on to the render otherwise
+	skippable := g.options.EmptyTileTolerance > 0 && !g.options.EmitMetadata && dc == nil
+	if _, ok := g.emptyStore.featurelessBackground(coords.Z); skippable && ok {
+		if prefetchedData == nil {
+			data, err := g.FetchOnly(ctx, coords)
+			if err != nil {
+				return "", "", err
+			}
+			prefetchedData = data
+		}
+		if featureless(prefetchedData) {
+			written, err := g.writeFeaturelessTile(coords, finalPath, tee)
+			if err != nil {
+				return "", "", err
+			}
+			if written {
+				return finalPath, "", nil
+			}
+		}
+	}
+
+	start := time.Now()
+	tm := g.newStageTimer()
+	renderResult, painted, err := g.renderAndPaint(ctx, coords, dc, tm, prefetchedData)
+	if err != nil {
+		return "", "", err
+	}
+
+	// Phase 4: Composite and write final tile
+	finalPath, layerDir, err := g.compositeAndWrite(painted, coords, finalPath, renderResult.params, renderResult.padPx, renderResult.layerDirReturn, skippable && featureless(renderResult.data), tee, dc, tm)
 	if err != nil {
 		return "", "", err
 	}
-	// Clean up temp layer directory unless keepLayers is set
+
+	if g.options.EmitMetadata {
+		renderTime := time.Since(start) - renderResult.fetchTime
+		if err := g.writeMetadata(coords, finalPath, renderResult, renderTime); err != nil {
+			return "", "", err
+		}
+	}
+
+	if writeStages {
+		stagesDir := g.debugStagesDir(coords, filenameSuffix)
+		if err := dc.WriteStages(stagesDir); err != nil {
+			// Diagnostics only; the tile itself was written
+			g.log().Warn("Failed to write debug stages", "coords", coords.String(), "dir", stagesDir, "error", err)
+		} else {
+			g.log().Debug("Wrote debug stages", "coords", coords.String(), "dir", stagesDir)
+		}
+	}
+	return finalPath, layerDir, nil
+}
+
+// fileCoords returns the coordinates used to name output files for coords.
+func (g *Generator) fileCoords(coords tile.Coords) tile.Coords {
+	if g.options.TMS {
+		return coords.FlipYForTMS()
+	}
+	return coords
+}
+
+// debugStagesDir returns the directory the debug stages of coords are written to.
+func (g *Generator) debugStagesDir(coords tile.Coords, filenameSuffix string) string {
+	coords = g.fileCoords(coords)
+	return filepath.Join(g.options.DebugStagesDir,
+		strconv.FormatUint(uint64(coords.Z), 10),
+		strconv.FormatUint(uint64(coords.X), 10),
+		strconv.FormatUint(uint64(coords.Y), 10)+filenameSuffix)
+}
+
+// renderAndPaint renders, masks, and paints all layers of a single tile (phases 1-3).
+func (g *Generator) renderAndPaint(ctx context.Context, coords tile.Coords, dc *DebugContext, tm *stageTimer, prefetchedData *types.TileData) (*renderLayersResult, map[geojson.LayerType]image.Image, error) {
+	// Phase 1: Setup and render all layers (optionally with pre-fetched data)
+	renderResult, err := g.renderLayersWithData(ctx, coords, 1, dc, tm, prefetchedData)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer renderResult.releaseNoise()
+	// Clean up temp layer directory unless keepLayers is set; layers are in memory by now
 	if !g.keepLayers {
 		defer os.RemoveAll(renderResult.layerDir) // nolint:errcheck
 	}
 
 	// Phase 2: Build masks from rendered layers
-	masks, err := buildMasks(renderResult.rawLayers, renderResult.params, dc)
+	masks, err := g.tileMasks(renderResult.rawLayers, renderResult.params, dc)
 	if err != nil {
-		return "", "", fmt.Errorf("failed to build masks: %w", err)
+		return nil, nil, fmt.Errorf("failed to build masks: %w", err)
 	}
+	tm.mark("masks")
 
 	// Phase 3: Paint all layers with watercolor effects
-	painted, err := paintAllLayers(renderResult.rawLayers, masks, renderResult.params, g.textures, dc)
+	painted, err := paintAllLayers(renderResult.rawLayers, masks, renderResult.params, g.textures, g.options.TransparentBackground, g.options.PaintWorkers, workerProcessorContext(ctx), dc, tm)
 	if err != nil {
-		return "", "", err
+		return nil, nil, err
 	}
 
-	// Phase 4: Composite and write final tile
-	return g.compositeAndWrite(painted, coords, finalPath, renderResult.params, renderResult.padPx, renderResult.layerDirReturn, dc)
+	return renderResult, painted, nil
+}
+
+// tilePath returns the output file path and its directory for a tile,
+// honoring the configured folder structure.
+func (g *Generator) tilePath(coords tile.Coords, filenameSuffix string) (string, string) {
+	return TilePath(g.outputDir, g.options.FolderStructure, g.fileCoords(coords), strings.TrimSpace(filenameSuffix), g.tileExt())
+}
+
+// TilePath returns the path of a tile file below dir in the given folder structure (see
+// GeneratorOptions.FolderStructure) and the directory holding it. Readers of a tile folder,
+// like the tile server, use it to find the files a Generator wrote.
+func TilePath(dir, folderStructure string, coords tile.Coords, suffix, ext string) (string, string) {
+	switch folderStructure {
+	case "nested":
+		// Nested structure: {z}/{x}/{y}.png
+		z := fmt.Sprintf("%d", coords.Z)
+		x := fmt.Sprintf("%d", coords.X)
+		y := fmt.Sprintf("%d", coords.Y)
+		tileDir := filepath.Join(dir, z, x)
+		return filepath.Join(tileDir, y+suffix+ext), tileDir
+	case "hashed":
+		// Hashed structure: {ab}/{cd}/z{z}_x{x}_y{y}.png
+		tileDir := filepath.Join(dir, coords.HashedDir())
+		return filepath.Join(tileDir, coords.String()+suffix+ext), tileDir
+	}
+	// Flat structure (default): z{z}_x{x}_y{y}.png
+	return filepath.Join(dir, coords.String()+suffix+ext), dir
 }
 
 func cropNRGBA(src image.Image, rect image.Rectangle) *image.NRGBA {
@@ -248,7 +683,7 @@ func (g *Generator) log() *slog.Logger {
 // This includes padding for metatile rendering to avoid edge artifacts.
 func (g *Generator) CalculateFetchBounds(coords tile.Coords) types.BoundingBox {
 	// Create watercolor parameters to calculate padding
-	params := watercolor.DefaultParams(g.tileSize, g.seed, g.textures)
+	params := g.baseParams()
 	params.BlurSigma = watercolor.ZoomAdjustedBlurSigma(params.BlurSigma, int(coords.Z))
 	params.AntialiasSigma = watercolor.ZoomAdjustedBlurSigma(params.AntialiasSigma, int(coords.Z))
 
@@ -263,7 +698,7 @@ func (g *Generator) CalculateFetchBounds(coords tile.Coords) types.BoundingBox {
 		Y:    int(coords.Y),
 	}
 
-	dataBounds := types.TileToBounds(tileCoord)
+	dataBounds := g.grid().TileToBounds(tileCoord)
 	if padPx > 0 {
 		padFrac := float64(padPx) / float64(g.tileSize)
 		dataBounds = dataBounds.ExpandByFraction(padFrac)
@@ -277,37 +712,43 @@ func (g *Generator) TileSize() int {
 	return g.tileSize
 }
 
-// renderLayersWithData handles setup, data fetching (if needed), and rendering of all map layers.
-// If prefetchedData is provided, it will be used instead of fetching from the datasource.
-func (g *Generator) renderLayersWithData(
-	ctx context.Context,
-	coords tile.Coords,
-	dc *DebugContext,
-	prefetchedData *types.TileData,
-) (*renderLayersResult, error) {
-	// Create watercolor parameters with zoom adjustments
-	params := watercolor.DefaultParams(g.tileSize, g.seed, g.textures)
-	params.BlurSigma = watercolor.ZoomAdjustedBlurSigma(params.BlurSigma, int(coords.Z))
-	params.AntialiasSigma = watercolor.ZoomAdjustedBlurSigma(params.AntialiasSigma, int(coords.Z))
+// FetchOnly fetches the data for a tile, including the padding around it that rendering
+// needs, without rendering anything. Together with RenderFromData it splits Generate into its
+// fetch and render halves, e.g. to inspect, cache or dump the fetched features.
+func (g *Generator) FetchOnly(ctx context.Context, coords tile.Coords) (*types.TileData, error) {
+	_, padPx := g.tileParams(coords, 1)
+	return g.fetchTileData(ctx, coords, 1, padPx)
+}
 
-	// Calculate padding for metatile to avoid edge artifacts
-	padPx := watercolor.RequiredPaddingPx(params)
-	if padPx > g.tileSize {
-		padPx = g.tileSize
+// RenderFromData renders a tile from data returned by FetchOnly (or an equivalent source) and
+// writes it like Generate, replacing an existing tile. It returns the path of the written tile.
+func (g *Generator) RenderFromData(ctx context.Context, coords tile.Coords, data *types.TileData) (string, error) {
+	if data == nil {
+		return "", fmt.Errorf("no tile data to render %s", coords.String())
 	}
+	path, _, err := g.GenerateWithData(ctx, coords, true, "", nil, data)
+	return path, err
+}
 
-	// Switch the pipeline to operate on a padded metatile
-	metatileSize := g.tileSize + 2*padPx
-	params.TileSize = metatileSize
-	params.OffsetX = int(coords.X)*g.tileSize - padPx
-	params.OffsetY = int(coords.Y)*g.tileSize - padPx
-
-	// Generate Perlin noise once for all layers to avoid redundant allocations
-	params.PerlinNoise = mask.GeneratePerlinNoiseWithOffset(
-		params.TileSize, params.TileSize,
-		params.NoiseScale, params.Seed,
-		params.OffsetX, params.OffsetY,
-	)
+// fetchTileData fetches the data of the n×n block (span) whose top-left tile is coords, expanded
+// by padPx on every side. Single tiles deeper than GeneratorOptions.MaxDataZoom get the cached
+// or freshly fetched data of their ancestor instead (see DataTile).
+func (g *Generator) fetchTileData(ctx context.Context, coords tile.Coords, span, padPx int) (*types.TileData, error) {
+	if dataCoords := g.DataTile(coords); span == 1 && dataCoords != coords {
+		if data := g.CachedData(dataCoords); data != nil {
+			g.log().Info("Using cached ancestor data", "coords", coords.String(), "data_tile", dataCoords.String())
+			return data, nil
+		}
+		// The ancestor's padding covers the tile's: padding doesn't grow with zoom, and the
+		// ancestor's pixels are larger
+		_, ancestorPadPx := g.tileParams(dataCoords, 1)
+		data, err := g.fetchTileData(ctx, dataCoords, 1, ancestorPadPx)
+		if err != nil {
+			return nil, err
+		}
+		g.StoreData(dataCoords, data)
+		return data, nil
+	}
 
 	tileCoord := types.TileCoordinate{
 		Zoom: int(coords.Z),
@@ -315,28 +756,76 @@ func (g *Generator) renderLayersWithData(
 		Y:    int(coords.Y),
 	}
 
-	dataBounds := types.TileToBounds(tileCoord)
+	dataBounds := g.grid().TileToBounds(tileCoord)
+	if span > 1 {
+		last := g.grid().TileToBounds(types.TileCoordinate{
+			Zoom: tileCoord.Zoom,
+			X:    tileCoord.X + span - 1,
+			Y:    tileCoord.Y + span - 1,
+		})
+		dataBounds.MaxLon = last.MaxLon
+		dataBounds.MinLat = last.MinLat
+	}
 	if padPx > 0 {
-		padFrac := float64(padPx) / float64(g.tileSize)
+		padFrac := float64(padPx) / float64(span*g.tileSize)
 		dataBounds = dataBounds.ExpandByFraction(padFrac)
 	}
 
-	// Use prefetched data if available, otherwise fetch from datasource
+	g.log().Info("Fetching tile data", "coords", coords.String(), "padPx", padPx)
 	var data *types.TileData
 	var err error
-	if prefetchedData != nil {
-		g.log().Info("Using pre-fetched tile data", "coords", coords.String())
-		data = prefetchedData
+	if dsb, ok := g.ds.(dataSourceWithBounds); ok {
+		data, err = dsb.FetchTileDataWithBounds(ctx, tileCoord, dataBounds)
+	} else if span > 1 {
+		return nil, fmt.Errorf("data source does not support bounded fetches required for metatiles")
 	} else {
-		g.log().Info("Fetching tile data", "coords", coords.String(), "padPx", padPx)
-		if dsb, ok := g.ds.(dataSourceWithBounds); ok {
-			data, err = dsb.FetchTileDataWithBounds(ctx, tileCoord, dataBounds)
-		} else {
-			data, err = g.ds.FetchTileData(ctx, tileCoord)
+		data, err = g.ds.FetchTileData(ctx, tileCoord)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch tile data: %w", err)
+	}
+	return data, nil
+}
+
+// renderLayersWithData handles setup, data fetching (if needed), and rendering of all map layers.
+// span is the number of tiles per side rendered in one pass (1 for a single tile); coords is the
+// top-left tile of the block.
+// If prefetchedData is provided, it will be used instead of fetching from the datasource.
+func (g *Generator) renderLayersWithData(
+	ctx context.Context,
+	coords tile.Coords,
+	span int,
+	dc *DebugContext,
+	tm *stageTimer,
+	prefetchedData *types.TileData,
+) (result *renderLayersResult, err error) {
+	params, padPx := g.tileParams(coords, span)
+	spanPx := span * g.tileSize
+
+	// Generate Perlin noise once for all layers, into a buffer of an earlier tile of the same
+	// size; it goes back to the pool once the layers are painted (see releaseNoise)
+	params.PerlinNoise = noiseBuffers.get(params.Size())
+	watercolor.FillNoise(params.PerlinNoise, params, int(coords.Z), int(coords.X), int(coords.Y))
+	defer func() {
+		if err != nil {
+			noiseBuffers.put(params.PerlinNoise)
 		}
+	}()
+	tm.mark("noise")
+
+	// Use prefetched data if available, otherwise fetch from datasource
+	data := prefetchedData
+	var fetchTime time.Duration
+	if data != nil {
+		g.log().Info("Using pre-fetched tile data", "coords", coords.String())
+	} else {
+		fetchStart := time.Now()
+		data, err = g.fetchTileData(ctx, coords, span, padPx)
 		if err != nil {
-			return nil, fmt.Errorf("failed to fetch tile data: %w", err)
+			return nil, err
 		}
+		fetchTime = time.Since(fetchStart)
+		tm.mark("fetch")
 	}
 
 	// Create temp directory for rendered layer PNGs
@@ -353,44 +842,134 @@ func (g *Generator) renderLayersWithData(
 
 	// Render all layers via Mapnik
 	g.log().Info("Rendering layers", "coords", coords.String())
-	mpRenderer, err := renderer.NewMultiPassRenderer(g.stylesDir, layerDir, g.tileSize, padPx)
+	mpRenderer, err := renderer.NewMultiPassRenderer(g.stylesDir, layerDir, spanPx, padPx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create multipass renderer: %w", err)
 	}
 	defer mpRenderer.Close() // nolint:errcheck
+	mpRenderer.SetOverrides(g.options.LayerOverrides)
+	mpRenderer.SetGrid(g.grid())
+	if g.options.MapnikBufferPx > 0 {
+		mpRenderer.SetBufferSize(g.options.MapnikBufferPx)
+	}
 
-	renderResult, err := mpRenderer.RenderTile(coords, data)
+	var renderResult *renderer.TileRenderResult
+	if span > 1 {
+		renderResult, err = mpRenderer.RenderMetatile(coords, span, data)
+	} else {
+		renderResult, err = mpRenderer.RenderTile(coords, data)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to render layers: %w", err)
 	}
 
 	// Read rendered PNG files into memory
+	rawLayers, err := g.readLayers(renderResult, coords)
+	if err != nil {
+		return nil, err
+	}
+	tm.mark("render")
+
+	return &renderLayersResult{
+		rawLayers:      rawLayers,
+		params:         params,
+		padPx:          padPx,
+		layerDir:       layerDir,
+		layerDirReturn: layerDirReturn,
+		data:           data,
+		fetchTime:      fetchTime,
+	}, nil
+}
+
+// readLayers loads the rendered layer PNGs into memory, skipping layers without features.
+// A layer whose render failed fails the tile, unless GeneratorOptions.SkipFailedLayers drops it.
+func (g *Generator) readLayers(renderResult *renderer.TileRenderResult, coords tile.Coords) (map[geojson.LayerType]image.Image, error) {
 	rawLayers := make(map[geojson.LayerType]image.Image)
 	for layer, res := range renderResult.Layers {
+		if res != nil && res.Error != nil {
+			if !g.options.SkipFailedLayers || layer == geojson.LayerLand {
+				return nil, fmt.Errorf("failed to render layer %s: %w", layer, res.Error)
+			}
+			g.log().Warn("Skipping failed layer", "layer", layer, "coords", coords.String(), "error", res.Error)
+			continue
+		}
 		if res == nil || res.OutputPath == "" {
 			g.log().Debug("Skipping empty layer", "layer", layer, "coords", coords.String())
 			continue
 		}
-		if res.Error != nil {
-			return nil, fmt.Errorf("failed to render layer %s: %w", layer, res.Error)
-		}
 
 		g.log().Debug("Painting layer", "layer", layer, "coords", coords.String())
 		img, err := readPNG(res.OutputPath)
 		if err != nil {
 			return nil, fmt.Errorf("failed to read layer %s: %w", layer, err)
 		}
+		if g.keepLayers {
+			if err := g.keptLayers.dedup(res.OutputPath); err != nil {
+				g.log().Warn("Failed to deduplicate kept layer", "layer", layer, "coords", coords.String(), "error", err)
+			}
+		}
 
 		rawLayers[layer] = img
 	}
+	return rawLayers, nil
+}
 
-	return &renderLayersResult{
-		rawLayers:      rawLayers,
-		params:         params,
-		padPx:          padPx,
-		layerDir:       layerDir,
-		layerDirReturn: layerDirReturn,
-	}, nil
+// tileParams returns the watercolor parameters for the padded n×n block whose top-left
+// tile is coords, along with the padding in pixels.
+func (g *Generator) tileParams(coords tile.Coords, span int) (watercolor.Params, int) {
+	params := g.zoomParams(int(coords.Z), float64(g.pixelRatio()))
+
+	// Calculate padding for metatile to avoid edge artifacts
+	padPx := watercolor.RequiredPaddingPx(params)
+	if padPx > g.tileSize {
+		padPx = g.tileSize
+	}
+
+	// Switch the pipeline to operate on a padded metatile
+	params.TileSize = span*g.tileSize + 2*padPx
+	params.OffsetX = int(coords.X)*g.tileSize - padPx
+	params.OffsetY = int(coords.Y)*g.tileSize - padPx
+	return params, padPx
+}
+
+// zoomParams returns the generator's watercolor parameters adjusted for zoom, before any
+// canvas size or offsets are set. noiseRatio scales the noise period (see tileParams).
+func (g *Generator) zoomParams(zoom int, noiseRatio float64) watercolor.Params {
+	// Create watercolor parameters with zoom adjustments
+	params := g.baseParams()
+	params.BlurSigma = watercolor.ZoomAdjustedBlurSigma(params.BlurSigma, zoom)
+	params.AntialiasSigma = watercolor.ZoomAdjustedBlurSigma(params.AntialiasSigma, zoom)
+	baseWidth := mask.DefaultAntialiasWidth
+	if params.AntialiasWidth != nil {
+		baseWidth = *params.AntialiasWidth
+	}
+	aaWidth := watercolor.ZoomAdjustedAntialiasWidth(baseWidth, zoom)
+	params.AntialiasWidth = &aaWidth
+
+	// Noise is sampled in device pixels; scaling its period by the pixel ratio keeps @2x tiles
+	// a higher-resolution render of the same field as @1x tiles, since offsets scale too.
+	params.NoiseScale *= noiseRatio
+
+	params.NoiseSeedMode = g.options.NoiseSeedMode
+	params.NoiseDownscale = g.options.NoiseDownscale
+	params.NoiseCache = g.options.NoiseCache
+	return params
+}
+
+// grid returns the tile grid of GeneratorOptions.Grid, or tile.WebMercator when it is unset.
+func (g *Generator) grid() tile.Grid {
+	if g.options.Grid.TileSize <= 0 {
+		return tile.WebMercator
+	}
+	return g.options.Grid
+}
+
+// pixelRatio returns the device pixel ratio of the tiles this generator renders.
+func (g *Generator) pixelRatio() int {
+	if g.options.PixelRatio < 1 {
+		return 1
+	}
+	return g.options.PixelRatio
 }
 
 // renderLayersResult holds the output from the rendering phase.
@@ -400,6 +979,15 @@ type renderLayersResult struct {
 	padPx          int
 	layerDir       string
 	layerDirReturn string
+	data           *types.TileData
+	fetchTime      time.Duration // 0 for prefetched data
+}
+
+// releaseNoise returns the noise field to noiseBuffers once painting is done; compositing
+// doesn't use it.
+func (r *renderLayersResult) releaseNoise() {
+	noiseBuffers.put(r.params.PerlinNoise)
+	r.params.PerlinNoise = nil
 }
 
 // maskSet holds all extracted alpha masks for a tile.
@@ -409,6 +997,11 @@ type maskSet struct {
 	roadsMask     *image.Gray
 	highwaysAlpha *image.Gray
 	nonLandUnion  *image.Gray // Union of water + rivers + roads (used as base for land inversion)
+	bridgeMask    *image.Gray // Roads over water carved out of water and rivers (nil = none; see carveBridges)
+
+	// Coverage of the layers above each layer that own the edges they share with it (nil =
+	// none; see shareEdges)
+	edgeNeighbors map[geojson.LayerType]*image.Gray
 }
 
 // buildMasks extracts alpha masks from rendered layers and creates the non-land union.
@@ -424,7 +1017,8 @@ func buildMasks(
 	roadsImg := rawLayers[geojson.LayerRoads]
 	highwaysImg := rawLayers[geojson.LayerHighways]
 
-	baseBounds := image.Rect(0, 0, params.TileSize, params.TileSize)
+	width, height := params.Size()
+	baseBounds := image.Rect(0, 0, width, height)
 
 	// Extract alpha masks from each layer
 	waterMask := mask.NewEmptyMask(baseBounds)
@@ -466,118 +1060,193 @@ func buildMasks(
 }
 
 // paintAllLayers applies watercolor effects to all layers.
+// With transparentBackground the land layer is not painted (see GeneratorOptions.TransparentBackground).
+// The land mask is processed first; the remaining layers are independent of each other and are
+// painted by up to workers goroutines (see GeneratorOptions.PaintWorkers). Results are collected
+// in a fixed order, so the output does not depend on the number of workers. With
+// params.ShoreBlendPx, land and water cross-fade across the shoreline (see shoreBlend). pc, when non-nil,
+// provides the watercolor buffers for everything painted on the calling goroutine.
 func paintAllLayers(
 	rawLayers map[geojson.LayerType]image.Image,
 	masks *maskSet,
 	params watercolor.Params,
 	textures map[geojson.LayerType]image.Image,
+	transparentBackground bool,
+	workers int,
+	pc *watercolor.ProcessorContext,
 	dc *DebugContext,
+	tm *stageTimer,
 ) (map[geojson.LayerType]image.Image, error) {
 	painted := make(map[geojson.LayerType]image.Image)
 
-	// Paint water from its own alpha mask (not the combined non-land mask)
-	if waterImg := rawLayers[geojson.LayerWater]; waterImg != nil {
-		waterPainted, err := watercolor.PaintLayer(waterImg, geojson.LayerWater, params)
+	// Paint land from non-land union mask (will be inverted during processing due to InvertMask=true)
+	// The watercolor processor handles blur/noise/threshold/invert/edges uniformly
+	var landMask *image.Gray
+	var shore *shoreBlend
+	if transparentBackground {
+		// Land is not painted, but its mask still constrains parks/urban/buildings
+		var err error
+		landMask, err = watercolor.ProcessLayerMask(masks.nonLandUnion, geojson.LayerLand, params)
 		if err != nil {
-			return nil, fmt.Errorf("failed to paint water: %w", err)
+			return nil, fmt.Errorf("failed to process land mask: %w", err)
 		}
-		painted[geojson.LayerWater] = waterPainted
-		dc.Capture("12_painted_water", "Watercolor-painted water layer", waterPainted, 12)
-	}
-
-	// Paint rivers from their own alpha mask
-	if riversImg := rawLayers[geojson.LayerRivers]; riversImg != nil {
-		riversPainted, err := watercolor.PaintLayer(riversImg, geojson.LayerRivers, params)
+		tm.mark("land_mask")
+	} else if params.ShoreBlendPx > 0 && rawLayers[geojson.LayerWater] != nil {
+		// Land continues under the water near the shore, where the water fades out over it
+		var err error
+		landMask, err = watercolor.ProcessLayerMask(masks.nonLandUnion, geojson.LayerLand, params)
 		if err != nil {
-			return nil, fmt.Errorf("failed to paint rivers: %w", err)
+			return nil, fmt.Errorf("failed to process land mask: %w", err)
 		}
-		painted[geojson.LayerRivers] = riversPainted
-		dc.Capture("13_painted_rivers", "Watercolor-painted rivers layer", riversPainted, 18)
+		others := mask.MaxMasks(masks.riversMask, masks.roadsMask, masks.highwaysAlpha)
+		shore, err = newShoreBlend(masks.waterMask, landMask, others, params)
+		if err != nil {
+			return nil, err
+		}
+		dc.Capture("12_shore_blend", "Water opacity across the shore blend band", shore.weight, 12)
+		paintedLand, err := watercolor.PaintLayerFromFinalMaskWithContext(shore.landMask, geojson.LayerLand, params, pc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to paint land: %w", err)
+		}
+		painted[geojson.LayerLand] = paintedLand
+		tm.mark("paint_land")
+		dc.Capture("10_painted_land", "Watercolor-painted land layer", paintedLand, 10)
+	} else {
+		paintedLand, processedLand, err := watercolor.PaintLayerFromMaskWithMaskContext(masks.nonLandUnion, geojson.LayerLand, params, pc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to paint land: %w", err)
+		}
+		landMask = processedLand
+		painted[geojson.LayerLand] = paintedLand
+		tm.mark("paint_land")
+		dc.Capture("10_painted_land", "Watercolor-painted land layer", paintedLand, 10)
+
+		// Create composite of land on white canvas for debugging
+		width, height := params.Size()
+		landOnCanvas := texture.TileTextureRect(textures[geojson.LayerPaper], width, height, params.OffsetX, params.OffsetY)
+		if landOnCanvas == nil {
+			landOnCanvas = image.NewNRGBA(image.Rect(0, 0, width, height))
+		}
+		if err := composite.CompositeLayersInto(
+			landOnCanvas,
+			map[geojson.LayerType]image.Image{geojson.LayerLand: paintedLand},
+			[]geojson.LayerType{geojson.LayerLand},
+		); err != nil {
+			return nil, fmt.Errorf("failed to composite land on canvas: %w", err)
+		}
+		dc.Capture("11_painted_land_on_canvas", "Land layer composited on white canvas", landOnCanvas, 11)
 	}
 
-	// Paint land from non-land union mask (will be inverted during processing due to InvertMask=true)
-	// The watercolor processor handles blur/noise/threshold/invert/edges uniformly
-	paintedLand, landMask, err := watercolor.PaintLayerFromMaskWithMask(masks.nonLandUnion, geojson.LayerLand, params)
-	if err != nil {
-		return nil, fmt.Errorf("failed to paint land: %w", err)
-	}
-	painted[geojson.LayerLand] = paintedLand
-	dc.Capture("10_painted_land", "Watercolor-painted land layer", paintedLand, 10)
-
-	// Create composite of land on white canvas for debugging
-	whiteCanvas := texture.TileTexture(textures[geojson.LayerPaper], params.TileSize, params.OffsetX, params.OffsetY)
-	landOnCanvas, err := composite.CompositeLayersOverBase(
-		whiteCanvas,
-		map[geojson.LayerType]image.Image{geojson.LayerLand: paintedLand},
-		[]geojson.LayerType{geojson.LayerLand},
-		params.TileSize,
-	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to composite land on canvas: %w", err)
+	var jobs []paintJob
+
+	// Paint water and rivers from their own alpha masks (not the combined non-land mask),
+	// minus any bridges carved out of them
+	if waterImg := rawLayers[geojson.LayerWater]; waterImg != nil {
+		paint := paintLayerFunc(waterImg, geojson.LayerWater, params)
+		if masks.bridgeMask != nil {
+			paint = paintMaskFunc(masks.waterMask, geojson.LayerWater, params)
+		}
+		if shore != nil {
+			paint = shore.paintWater(params)
+		}
+		jobs = append(jobs, paintJob{
+			layer: geojson.LayerWater, what: "water",
+			capture: "12_painted_water", description: "Watercolor-painted water layer", zorder: 12,
+			paint: paint,
+		})
+	}
+	if riversImg := rawLayers[geojson.LayerRivers]; riversImg != nil {
+		paint := paintLayerFunc(riversImg, geojson.LayerRivers, params)
+		if masks.bridgeMask != nil {
+			paint = paintMaskFunc(masks.riversMask, geojson.LayerRivers, params)
+		}
+		jobs = append(jobs, paintJob{
+			layer: geojson.LayerRivers, what: "rivers",
+			capture: "13_painted_rivers", description: "Watercolor-painted rivers layer", zorder: 18,
+			paint: paint,
+		})
 	}
-	dc.Capture("11_painted_land_on_canvas", "Land layer composited on white canvas", landOnCanvas, 11)
 
 	// Paint roads from their own alpha mask
 	// NOTE: Roads are also part of the derived non-land union mask, so they carve holes
 	// into land. Painting roads fills those holes with the intended style (instead of
 	// leaving paper showing through).
 	if roadsImg := rawLayers[geojson.LayerRoads]; roadsImg != nil {
-		roadsPainted, err := watercolor.PaintLayer(roadsImg, geojson.LayerRoads, params)
-		if err != nil {
-			return nil, fmt.Errorf("failed to paint roads: %w", err)
+		paint := paintLayerFunc(roadsImg, geojson.LayerRoads, params)
+		if masks.edgeNeighbors[geojson.LayerRoads] != nil {
+			paint = masks.paintSharingFunc(masks.roadsMask, geojson.LayerRoads, params)
 		}
-		painted[geojson.LayerRoads] = roadsPainted
-		dc.Capture("15_painted_roads", "Watercolor-painted roads layer", roadsPainted, 15)
+		jobs = append(jobs, paintJob{
+			layer: geojson.LayerRoads, what: "roads",
+			capture: "15_painted_roads", description: "Watercolor-painted roads layer", zorder: 15,
+			paint: paint,
+		})
 	}
 
 	// Paint highways/major roads on top
 	if highwaysImg := rawLayers[geojson.LayerHighways]; highwaysImg != nil {
-		highwaysPainted, err := watercolor.PaintLayer(highwaysImg, geojson.LayerHighways, params)
-		if err != nil {
-			return nil, fmt.Errorf("failed to paint highways: %w", err)
+		paint := paintLayerFunc(highwaysImg, geojson.LayerHighways, params)
+		if masks.edgeNeighbors[geojson.LayerHighways] != nil {
+			paint = masks.paintSharingFunc(masks.highwaysAlpha, geojson.LayerHighways, params)
 		}
-		painted[geojson.LayerHighways] = highwaysPainted
-		dc.Capture("19_painted_highways", "Watercolor-painted highways layer", highwaysPainted, 19)
+		jobs = append(jobs, paintJob{
+			layer: geojson.LayerHighways, what: "highways",
+			capture: "19_painted_highways", description: "Watercolor-painted highways layer", zorder: 19,
+			paint: paint,
+		})
 	}
 
 	// Constrain parks/urban/buildings to land, then paint
 	if parksImg := rawLayers[geojson.LayerParks]; parksImg != nil {
 		parksMask := mask.MinMask(mask.ExtractAlphaMask(parksImg), landMask)
 		dc.Capture("14_parks_on_land", "Parks constrained to land", parksMask, 14)
-		parksPainted, err := watercolor.PaintLayerFromMask(parksMask, geojson.LayerParks, params)
-		if err != nil {
-			return nil, fmt.Errorf("failed to paint parks constrained to land: %w", err)
-		}
-		painted[geojson.LayerParks] = parksPainted
-		dc.Capture("16_painted_parks", "Watercolor-painted parks layer", parksPainted, 16)
+		jobs = append(jobs, paintJob{
+			layer: geojson.LayerParks, what: "parks constrained to land",
+			capture: "16_painted_parks", description: "Watercolor-painted parks layer", zorder: 16,
+			paint: masks.paintSharingFunc(parksMask, geojson.LayerParks, params),
+		})
+	}
+
+	// Forests are painted over parks only when styled; otherwise parks cover them already
+	if forestImg := rawLayers[geojson.LayerForest]; forestImg != nil && params.Styles[geojson.LayerForest].Texture != nil {
+		forestMask := mask.MinMask(mask.ExtractAlphaMask(forestImg), landMask)
+		dc.Capture("14_forest_on_land", "Forest constrained to land", forestMask, 14)
+		jobs = append(jobs, paintJob{
+			layer: geojson.LayerForest, what: "forest constrained to land",
+			capture: "16_painted_forest", description: "Watercolor-painted forest layer", zorder: 16,
+			paint: masks.paintSharingFunc(forestMask, geojson.LayerForest, params),
+		})
 	}
 
 	if urbanImg := rawLayers[geojson.LayerUrban]; urbanImg != nil {
 		urbanMask := mask.MinMask(mask.ExtractAlphaMask(urbanImg), landMask)
 		dc.Capture("10_civic_on_land", "Civic constrained to land", urbanMask, 10)
-		urbanPainted, err := watercolor.PaintLayerFromMask(urbanMask, geojson.LayerUrban, params)
-		if err != nil {
-			return nil, fmt.Errorf("failed to paint urban constrained to land: %w", err)
-		}
-		painted[geojson.LayerUrban] = urbanPainted
-		dc.Capture("17_painted_civic", "Watercolor-painted urban layer", urbanPainted, 17)
+		jobs = append(jobs, paintJob{
+			layer: geojson.LayerUrban, what: "urban constrained to land",
+			capture: "17_painted_civic", description: "Watercolor-painted urban layer", zorder: 17,
+			paint: masks.paintSharingFunc(urbanMask, geojson.LayerUrban, params),
+		})
 	}
 
 	if buildingsImg := rawLayers[geojson.LayerBuildings]; buildingsImg != nil {
 		buildingsMask := mask.MinMask(mask.ExtractAlphaMask(buildingsImg), landMask)
 		dc.Capture("11_buildings_on_land", "Buildings constrained to land", buildingsMask, 11)
-		buildingsPainted, err := watercolor.PaintLayerFromMask(buildingsMask, geojson.LayerBuildings, params)
-		if err != nil {
-			return nil, fmt.Errorf("failed to paint buildings constrained to land: %w", err)
-		}
-		painted[geojson.LayerBuildings] = buildingsPainted
-		dc.Capture("18_painted_buildings", "Watercolor-painted buildings layer", buildingsPainted, 18)
+		jobs = append(jobs, paintJob{
+			layer: geojson.LayerBuildings, what: "buildings constrained to land",
+			capture: "18_painted_buildings", description: "Watercolor-painted buildings layer", zorder: 18,
+			paint: masks.paintSharingFunc(buildingsMask, geojson.LayerBuildings, params),
+		})
 	}
 
+	if err := runPaintJobs(jobs, workers, pc, painted, dc, tm); err != nil {
+		return nil, err
+	}
 	return painted, nil
 }
 
-// compositeAndWrite composites all painted layers, crops to tile size, and writes the final PNG.
+// compositeAndWrite composites all painted layers, crops to tile size, and writes the final PNG
+// (copied into tee when non-nil). featureless marks a tile rendered from data without features,
+// which is stored for its zoom when it turns out empty.
 func (g *Generator) compositeAndWrite(
 	painted map[geojson.LayerType]image.Image,
 	coords tile.Coords,
@@ -585,32 +1254,221 @@ func (g *Generator) compositeAndWrite(
 	params watercolor.Params,
 	padPx int,
 	layerDirReturn string,
+	featureless bool,
+	tee io.Writer,
 	dc *DebugContext,
+	tm *stageTimer,
 ) (string, string, error) {
-	// Paper base: fill the entire tile with a white texture so road cutouts show through
-	base := texture.TileTexture(g.textures[geojson.LayerPaper], params.TileSize, params.OffsetX, params.OffsetY)
+	composited, err := g.compositeLayers(painted, params, dc)
+	if err != nil {
+		return "", "", err
+	}
+	tm.mark("composite")
+	// Debug captures keep references to the composite, so only recycle it without them
+	if dc == nil {
+		defer metatileBuffers.put(composited)
+	}
+
+	// Crop back to the requested tile size
+	final := g.cropTile(composited, padPx, 0, 0)
+	if dc != nil {
+		// The crop is a view into the padded composite; capture a copy at the origin like the
+		// other stages
+		dc.Capture("21_combined_final", "Final tile (after crop)", cropNRGBA(final, final.Bounds()), 21)
+	}
+
+	if err := g.writeTileTo(final, coords, finalPath, tee, featureless); err != nil {
+		return "", "", err
+	}
+	tm.mark("encode")
+	tm.log(g.log(), coords)
+	return finalPath, layerDirReturn, nil
+}
+
+// cropTile returns the tile at block position (dx, dy) of a padded composite as a view
+// that shares pixels with composited, so no tile-sized copy is made before encoding.
+func (g *Generator) cropTile(composited *image.NRGBA, padPx, dx, dy int) *image.NRGBA {
+	minX := padPx + dx*g.tileSize
+	minY := padPx + dy*g.tileSize
+	rect := image.Rect(minX, minY, minX+g.tileSize, minY+g.tileSize)
+	if rect == composited.Bounds() {
+		return composited
+	}
+	return composited.SubImage(rect).(*image.NRGBA)
+}
+
+// compositeLayers stacks all painted layers over the paper texture at the (padded) metatile size.
+// The result comes from metatileBuffers; callers should return it with metatileBuffers.put
+// once the tile has been written.
+func (g *Generator) compositeLayers(
+	painted map[geojson.LayerType]image.Image,
+	params watercolor.Params,
+	dc *DebugContext,
+) (*image.NRGBA, error) {
+	if params.TileSize <= 0 {
+		return nil, fmt.Errorf("tile size must be positive")
+	}
+
+	// Paper base: fill the entire tile with a white texture so road cutouts show through.
+	// Transparent tiles start from an empty canvas instead.
+	width, height := params.Size()
+	composited := metatileBuffers.getRect(width, height)
+	if paper := g.textures[geojson.LayerPaper]; paper != nil && !g.options.TransparentBackground {
+		texture.TileTextureRectInto(paper, width, height, params.OffsetX, params.OffsetY, composited)
+		if ink := g.options.Monochrome; ink != nil {
+			toInkInto(composited, composited, *ink, monochromePaperDarkness)
+		}
+	} else {
+		clear(composited.Pix)
+	}
+	if g.options.LandTint.Strength > 0 {
+		if land, ok := painted[geojson.LayerLand].(*image.NRGBA); ok {
+			composite.ApplyLandTint(land, g.coverageDensity(painted, landTintLayers, params), g.options.LandTint)
+		}
+	}
+	if ink := g.options.Monochrome; ink != nil {
+		painted = monochromeLayers(painted, *ink)
+	}
+
+	// Layers with a paper bleed fade toward the paper texture instead of covering it fully
+	var paperImg image.Image
+	var bleed map[geojson.LayerType]float64
+	for layer, style := range params.Styles {
+		if style.PaperBleed > 0 {
+			if bleed == nil {
+				bleed = make(map[geojson.LayerType]float64)
+			}
+			bleed[layer] = style.PaperBleed
+		}
+	}
+	if paper := g.textures[geojson.LayerPaper]; paper != nil && bleed != nil && !g.options.TransparentBackground {
+		tiled := texture.TileTextureRect(paper, width, height, params.OffsetX, params.OffsetY)
+		if ink := g.options.Monochrome; ink != nil {
+			toInkInto(tiled, tiled, *ink, monochromePaperDarkness)
+		}
+		paperImg = tiled
+	}
 
-	// Layer order matches OSM standard: land (back) → parks → rivers → water → roads → highways → buildings → urban (front)
-	composited, err := composite.CompositeLayersOverBase(
-		base,
+	if err := composite.CompositeLayersOverPaperInto(
+		composited,
 		painted,
-		[]geojson.LayerType{geojson.LayerLand, geojson.LayerParks, geojson.LayerRivers, geojson.LayerWater, geojson.LayerRoads, geojson.LayerHighways, geojson.LayerBuildings, geojson.LayerUrban},
-		params.TileSize,
-	)
-	if err != nil {
-		return "", "", fmt.Errorf("failed to composite layers: %w", err)
+		params.Order(),
+		paperImg,
+		bleed,
+	); err != nil {
+		metatileBuffers.put(composited)
+		return nil, fmt.Errorf("failed to composite layers: %w", err)
+	}
+	if g.options.Vignette.Strength > 0 {
+		composite.ApplyVignette(composited, g.coverageDensity(painted, vignetteLayers, params), g.options.Vignette)
 	}
+	composite.ApplyToneCurve(composited, g.options.Tone)
+	composite.DitherAt(composited, g.options.Dither, params.OffsetX, params.OffsetY)
 	dc.Capture("20_combined_metatile", "Composited layers (before crop)", composited, 20)
 
-	// Crop back to the requested tile size
-	final := composited
-	if padPx > 0 {
-		cropRect := image.Rect(padPx, padPx, padPx+g.tileSize, padPx+g.tileSize)
-		final = cropNRGBA(composited, cropRect)
+	return composited, nil
+}
+
+// vignetteLayers are the layers whose coverage makes up the feature density of the vignette.
+var vignetteLayers = []geojson.LayerType{
+	geojson.LayerRoads,
+	geojson.LayerHighways,
+	geojson.LayerBuildings,
+	geojson.LayerUrban,
+}
+
+// landTintLayers are the layers whose coverage makes up the vegetation density of the land tint.
+var landTintLayers = []geojson.LayerType{
+	geojson.LayerParks,
+	geojson.LayerForest,
+}
+
+// coverageDensity returns the union of the painted layers' alpha, blurred as widely as the
+// metatile padding allows: the 3-pass box blur reaches about 6σ, and within the padding
+// every tile pixel sees the same neighborhood as in any other tile's render.
+func (g *Generator) coverageDensity(painted map[geojson.LayerType]image.Image, layers []geojson.LayerType, params watercolor.Params) *image.Gray {
+	width, height := params.Size()
+	alphas := make([]*image.Gray, 0, len(layers))
+	for _, layer := range layers {
+		if img := painted[layer]; img != nil {
+			alphas = append(alphas, mask.ExtractAlphaMask(img))
+		}
+	}
+	coverage := mask.MaxMasks(alphas...)
+	if coverage == nil {
+		return mask.NewEmptyMask(image.Rect(0, 0, width, height))
 	}
-	dc.Capture("21_combined_final", "Final tile (after crop)", final, 21)
 
-	// Configure PNG encoder
+	padPx := min(watercolor.RequiredPaddingPx(params), g.tileSize)
+	sigma := float32(max(padPx-1, 6)) / 6
+	return mask.BoxBlurSigma(coverage, sigma)
+}
+
+// Tile encodings supported by GeneratorOptions.TileFormat.
+const (
+	TileFormatPNG  = "png"
+	TileFormatJPEG = "jpeg"
+)
+
+// jpegQuality is the quality of JPEG tiles; high enough to keep the paper grain.
+const jpegQuality = 90
+
+// normalizeTileFormat returns the canonical TileFormat of opts and checks that it can encode
+// the tiles opts produce.
+func normalizeTileFormat(opts GeneratorOptions) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(opts.TileFormat)) {
+	case "", TileFormatPNG:
+		return TileFormatPNG, nil
+	case TileFormatJPEG, "jpg":
+		if opts.FlattenColor.A == 0 && opts.TransparentBackground {
+			return "", fmt.Errorf("tile format jpeg has no alpha channel: set a flatten color for transparent backgrounds")
+		}
+		if opts.FlattenColor.A != 0 && opts.FlattenColor.A != 255 {
+			return "", fmt.Errorf("flatten color must be opaque, got alpha %d", opts.FlattenColor.A)
+		}
+		return TileFormatJPEG, nil
+	case "webp":
+		return "", fmt.Errorf("tile format webp is not supported: no WebP encoder is available")
+	default:
+		return "", fmt.Errorf("unsupported tile format %q (png, jpeg)", opts.TileFormat)
+	}
+}
+
+// tileExt returns the file extension of written tiles.
+func (g *Generator) tileExt() string {
+	if g.options.TileFormat == TileFormatJPEG {
+		return ".jpg"
+	}
+	return ".png"
+}
+
+// encodeTile encodes a final tile in the configured TileFormat. JPEG tiles are flattened onto
+// FlattenColor in place first; Paletted PNG tiles are quantized.
+func (g *Generator) encodeTile(w io.Writer, final image.Image) error {
+	if g.options.TileFormat != TileFormatJPEG {
+		if g.options.Paletted {
+			img, ok := final.(*image.NRGBA)
+			if !ok {
+				img = cropNRGBA(final, final.Bounds())
+			}
+			final = composite.Quantize(img, g.options.PaletteColors)
+		}
+		enc := g.pngEncoder()
+		return enc.Encode(w, final)
+	}
+	if g.options.FlattenColor.A != 0 {
+		img, ok := final.(*image.NRGBA)
+		if !ok {
+			img = cropNRGBA(final, final.Bounds())
+		}
+		composite.Flatten(img, g.options.FlattenColor)
+		final = img
+	}
+	return jpeg.Encode(w, final, &jpeg.Options{Quality: jpegQuality})
+}
+
+// pngEncoder returns a PNG encoder configured from the generator options.
+func (g *Generator) pngEncoder() png.Encoder {
 	enc := png.Encoder{CompressionLevel: png.DefaultCompression}
 	switch strings.ToLower(strings.TrimSpace(g.options.PNGCompression)) {
 	case "", "default":
@@ -624,35 +1482,119 @@ func (g *Generator) compositeAndWrite(
 	default:
 		enc.CompressionLevel = png.DefaultCompression
 	}
+	return enc
+}
+
+// EmptyTiles returns the number of tiles written so far that had no content besides their
+// background (see GeneratorOptions.EmptyTileTolerance).
+func (g *Generator) EmptyTiles() int64 {
+	return g.emptyTiles.Load()
+}
+
+// FeaturelessTiles returns the number of tiles written so far as the stored featureless tile
+// of their zoom, without rendering (see GeneratorOptions.EmptyTileTolerance). They are
+// included in EmptyTiles.
+func (g *Generator) FeaturelessTiles() int64 {
+	return g.featurelessTiles.Load()
+}
+
+// tagEmptyTile counts and logs final when it has no content beyond the background, and
+// reports whether it is empty along with its background color.
+func (g *Generator) tagEmptyTile(final image.Image, coords tile.Coords) (color.NRGBA, bool) {
+	if g.options.EmptyTileTolerance == 0 {
+		return color.NRGBA{}, false
+	}
+	if !composite.ContentBounds(final, g.options.EmptyTileTolerance).Empty() {
+		return color.NRGBA{}, false
+	}
+	g.emptyTiles.Add(1)
+	g.log().Debug("Tile is empty", "coords", coords.String())
+	b := final.Bounds()
+	return color.NRGBAModel.Convert(final.At(b.Min.X, b.Min.Y)).(color.NRGBA), true
+}
+
+// writeTile encodes a final tile image and writes it via the TileWriter or to finalPath.
+func (g *Generator) writeTile(final image.Image, coords tile.Coords, finalPath string) error {
+	return g.writeTileTo(final, coords, finalPath, nil, false)
+}
+
+// writeTileTo is writeTile that also copies the encoded tile into tee when it is non-nil. An
+// empty featureless tile (see compositeAndWrite) becomes the featureless tile of its zoom.
+func (g *Generator) writeTileTo(final image.Image, coords tile.Coords, finalPath string, tee io.Writer, featureless bool) error {
+	if bg, empty := g.tagEmptyTile(final, coords); empty {
+		if err := g.writeEmptyTile(final, bg, coords, finalPath, tee); err != nil {
+			return err
+		}
+		if featureless {
+			g.emptyStore.putFeatureless(coords.Z, bg)
+		}
+		return nil
+	}
+
+	// Stream straight into backends that support it, avoiding an encoded copy in memory
+	if sw, ok := g.options.TileWriter.(TileStreamWriter); ok {
+		g.log().Info("Streaming tile via TileWriter", "coords", coords.String())
+		stream, err := sw.TileStream(int(coords.Z), int(coords.X), int(coords.Y))
+		if err != nil {
+			return fmt.Errorf("failed to open tile stream: %w", err)
+		}
+		if err := g.encodeTile(teeWriter(stream, tee), final); err != nil {
+			if abortErr := stream.Abort(); abortErr != nil {
+				g.log().Warn("Failed to discard partial tile", "coords", coords.String(), "error", abortErr)
+			}
+			return fmt.Errorf("failed to encode tile: %w", err)
+		}
+		if err := stream.Close(); err != nil {
+			return fmt.Errorf("failed to write tile: %w", err)
+		}
+		return nil
+	}
 
 	// Use TileWriter if provided, otherwise write to disk
 	if g.options.TileWriter != nil {
 		// Encode to bytes buffer
 		var buf bytes.Buffer
-		if err := enc.Encode(&buf, final); err != nil {
-			return "", "", fmt.Errorf("failed to encode tile: %w", err)
+		if err := g.encodeTile(&buf, final); err != nil {
+			return fmt.Errorf("failed to encode tile: %w", err)
 		}
 
 		// Write through TileWriter interface
 		g.log().Info("Writing tile via TileWriter", "coords", coords.String())
 		if err := g.options.TileWriter.WriteTile(int(coords.Z), int(coords.X), int(coords.Y), buf.Bytes()); err != nil {
-			return "", "", fmt.Errorf("failed to write tile: %w", err)
+			return fmt.Errorf("failed to write tile: %w", err)
+		}
+		if tee != nil {
+			if _, err := tee.Write(buf.Bytes()); err != nil {
+				return fmt.Errorf("failed to copy tile: %w", err)
+			}
 		}
 
-		return finalPath, layerDirReturn, nil
+		return nil
 	}
 
-	// Traditional file output
+	// Traditional file output. The old file may be a link shared with other empty tiles, so it
+	// is removed rather than truncated.
 	g.log().Info("Writing final tile", "coords", coords.String(), "path", finalPath)
+	if err := os.Remove(finalPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to replace tile file: %w", err)
+	}
 	outFile, err := os.Create(finalPath)
 	if err != nil {
-		return "", "", fmt.Errorf("failed to create tile file: %w", err)
+		return fmt.Errorf("failed to create tile file: %w", err)
 	}
 	defer outFile.Close() // nolint:errcheck
 
-	if err := enc.Encode(outFile, final); err != nil {
-		return "", "", fmt.Errorf("failed to encode final tile: %w", err)
+	if err := g.encodeTile(teeWriter(outFile, tee), final); err != nil {
+		return fmt.Errorf("failed to encode final tile: %w", err)
 	}
 
-	return finalPath, layerDirReturn, nil
+	return nil
+}
+
+// teeWriter returns w, or a writer copying into both w and tee when tee is non-nil.
+func teeWriter(w, tee io.Writer) io.Writer {
+	if tee == nil {
+		return w
+	}
+	return io.MultiWriter(w, tee)
 }
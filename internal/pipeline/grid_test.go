@@ -0,0 +1,50 @@
+package pipeline
+
+import (
+	"context"
+	"math"
+	"path/filepath"
+	"testing"
+
+	"github.com/MeKo-Tech/watercolormap/internal/tile"
+	"github.com/MeKo-Tech/watercolormap/internal/types"
+)
+
+func TestGeneratorGrid(t *testing.T) {
+	coords := tile.NewCoords(2, 1, 3)
+	tc := types.TileCoordinate{Zoom: 2, X: 1, Y: 3}
+	custom := tile.Grid{TileSize: 256, OriginX: -1e6, OriginY: 1e6, BaseResolution: 100}
+
+	tests := []struct {
+		name string
+		grid tile.Grid
+		want types.BoundingBox
+	}{
+		{name: "default", want: types.TileToBounds(tc)},
+		{name: "custom", grid: custom, want: custom.TileToBounds(tc)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ds := &recordingDataSource{}
+			gen, err := NewGenerator(ds, "", filepath.Join("..", "..", "assets", "textures"), t.TempDir(), 256, 1, false, nil, GeneratorOptions{Grid: tt.grid})
+			if err != nil {
+				t.Fatalf("failed to create generator: %v", err)
+			}
+			if _, err := gen.fetchTileData(context.Background(), coords, 1, 0); err != nil {
+				t.Fatalf("fetchTileData failed: %v", err)
+			}
+			if len(ds.bounds) != 1 || !boundsClose(ds.bounds[0], tt.want) {
+				t.Errorf("fetched %v, want %v", ds.bounds, tt.want)
+			}
+			if got := gen.CalculateFetchBounds(coords); !got.ContainsBox(tt.want) {
+				t.Errorf("CalculateFetchBounds = %v, want it around %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func boundsClose(a, b types.BoundingBox) bool {
+	const eps = 1e-9
+	return math.Abs(a.MinLon-b.MinLon) < eps && math.Abs(a.MinLat-b.MinLat) < eps &&
+		math.Abs(a.MaxLon-b.MaxLon) < eps && math.Abs(a.MaxLat-b.MaxLat) < eps
+}
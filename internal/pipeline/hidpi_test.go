@@ -0,0 +1,37 @@
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/MeKo-Tech/watercolormap/internal/tile"
+	"github.com/MeKo-Tech/watercolormap/internal/watercolor"
+)
+
+// TestHiDPINoiseMatchesStandard verifies that an @2x generator samples the same noise field as
+// the @1x generator: the @2x pixel covering a given ground position has the @1x noise value.
+func TestHiDPINoiseMatchesStandard(t *testing.T) {
+	gen1x := newCompositeTestGenerator(t, 256, GeneratorOptions{})
+	gen2x := newCompositeTestGenerator(t, 512, GeneratorOptions{PixelRatio: 2})
+
+	coords := tile.NewCoords(13, 4317, 2692)
+	params1x, pad1x := gen1x.tileParams(coords, 1)
+	params2x, pad2x := gen2x.tileParams(coords, 1)
+
+	if params2x.NoiseScale != 2*params1x.NoiseScale {
+		t.Fatalf("expected @2x noise scale %.1f, got %.1f", 2*params1x.NoiseScale, params2x.NoiseScale)
+	}
+
+	noise1x := watercolor.GenerateNoise(params1x, int(coords.Z), int(coords.X), int(coords.Y))
+	noise2x := watercolor.GenerateNoise(params2x, int(coords.Z), int(coords.X), int(coords.Y))
+
+	// Compare at @1x tile pixels; (x, y) in the @1x tile is (2x, 2y) in the @2x tile
+	for y := 0; y < 256; y += 7 {
+		for x := 0; x < 256; x += 7 {
+			a := noise1x.GrayAt(pad1x+x, pad1x+y).Y
+			b := noise2x.GrayAt(pad2x+2*x, pad2x+2*y).Y
+			if a != b {
+				t.Fatalf("pixel (%d,%d): @1x noise %d != @2x noise %d", x, y, a, b)
+			}
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package pipeline
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/MeKo-Tech/watercolormap/internal/composite"
+	"github.com/MeKo-Tech/watercolormap/internal/geojson"
+)
+
+// TestLandTintNearParks composites land with a park in its left quarter and checks that the
+// land around the park turns greener while land far from it keeps its color.
+func TestLandTintNearParks(t *testing.T) {
+	composited := func(strength float64) *image.NRGBA {
+		gen := newCompositeTestGenerator(t, 256, GeneratorOptions{LandTint: composite.LandTint{Strength: strength}})
+		params := testParams(gen)
+		w, h := params.Size()
+		land := image.NewNRGBA(image.Rect(0, 0, w, h))
+		parks := image.NewNRGBA(land.Bounds())
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				land.SetNRGBA(x, y, color.NRGBA{R: 214, G: 200, B: 160, A: 255})
+				if x < w/4 {
+					parks.SetNRGBA(x, y, color.NRGBA{R: 120, G: 170, B: 100, A: 200})
+				}
+			}
+		}
+		painted := map[geojson.LayerType]image.Image{geojson.LayerLand: land, geojson.LayerParks: parks}
+
+		img, err := gen.compositeLayers(painted, params, nil)
+		if err != nil {
+			t.Fatalf("compositeLayers failed: %v", err)
+		}
+		t.Cleanup(func() { metatileBuffers.put(img) })
+		return img
+	}
+	plain := composited(0)
+	tinted := composited(0.5)
+
+	// Just outside the park, land is glazed toward green (red drops more than green)
+	w := plain.Bounds().Dx()
+	p, q := plain.NRGBAAt(w/4+4, 100), tinted.NRGBAAt(w/4+4, 100)
+	if int(p.R)-int(q.R) <= int(p.G)-int(q.G) || q.R >= p.R {
+		t.Errorf("land next to the park = %v, want greener than %v", q, p)
+	}
+	if far := w - 1; plain.NRGBAAt(far, 100) != tinted.NRGBAAt(far, 100) {
+		t.Errorf("land far from the park changed: %v -> %v", plain.NRGBAAt(far, 100), tinted.NRGBAAt(far, 100))
+	}
+}
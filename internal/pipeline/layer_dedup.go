@@ -0,0 +1,67 @@
+package pipeline
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// layerStore deduplicates the layer PNGs kept with keepLayers: a layer file whose content
+// matches one kept earlier is replaced by a hard link to it. Large batches render the same
+// empty or fully covered layer for thousands of tiles, so this keeps the layer directories
+// from filling the disk. It is safe for concurrent use by the workers of a batch.
+type layerStore struct {
+	mu    sync.Mutex
+	files map[[sha256.Size]byte]string // content hash -> first kept file with that content
+}
+
+// dedup replaces path with a hard link to an earlier kept file of identical content, or
+// records it as the file for its content. Files on filesystems without hard links are kept
+// as they are.
+func (s *layerStore) dedup(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read layer file: %w", err)
+	}
+	sum := sha256.Sum256(data)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.files == nil {
+		s.files = make(map[[sha256.Size]byte]string)
+	}
+	first, ok := s.files[sum]
+	if !ok || first == path {
+		s.files[sum] = path
+		return nil
+	}
+
+	linked, err := linkOver(first, path)
+	if err != nil {
+		return fmt.Errorf("failed to replace layer file with link: %w", err)
+	}
+	if !linked {
+		// The first copy is gone (e.g. its directory was cleaned up) or the filesystem has
+		// no hard links; keep this copy and use it for later duplicates
+		s.files[sum] = path
+	}
+	return nil
+}
+
+// linkOver replaces path with a hard link to first. It links next to path and renames over
+// it, so path always holds a complete file. It reports false, leaving path untouched, when
+// the link cannot be created.
+func linkOver(first, path string) (bool, error) {
+	tmp := filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".link")
+	os.Remove(tmp) // nolint:errcheck
+	if err := os.Link(first, tmp); err != nil {
+		return false, nil
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp) // nolint:errcheck
+		return false, err
+	}
+	return true, nil
+}
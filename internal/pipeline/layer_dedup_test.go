@@ -0,0 +1,119 @@
+package pipeline
+
+import (
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MeKo-Tech/watercolormap/internal/geojson"
+	"github.com/MeKo-Tech/watercolormap/internal/renderer"
+	"github.com/MeKo-Tech/watercolormap/internal/tile"
+)
+
+func TestKeepLayersDeduplicatesIdenticalLayers(t *testing.T) {
+	gen := newCompositeTestGenerator(t, 64, GeneratorOptions{})
+	gen.keepLayers = true
+
+	// Each tile renders into its own layer directory, like renderLayersWithData
+	renderTile := func(landAlpha uint8) *renderer.TileRenderResult {
+		dir := t.TempDir()
+		write := func(layer geojson.LayerType, a uint8) *renderer.LayerRenderResult {
+			img := image.NewNRGBA(image.Rect(0, 0, 64, 64))
+			for i := 3; i < len(img.Pix); i += 4 {
+				img.Pix[i] = a
+			}
+			path := filepath.Join(dir, string(layer)+".png")
+			f, err := os.Create(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close() // nolint:errcheck
+			if err := png.Encode(f, img); err != nil {
+				t.Fatal(err)
+			}
+			return &renderer.LayerRenderResult{Layer: layer, OutputPath: path}
+		}
+		return &renderer.TileRenderResult{Layers: map[geojson.LayerType]*renderer.LayerRenderResult{
+			geojson.LayerLand:  write(geojson.LayerLand, landAlpha),
+			geojson.LayerWater: write(geojson.LayerWater, 0),
+		}}
+	}
+
+	first := renderTile(255)
+	second := renderTile(128)
+	for i, res := range []*renderer.TileRenderResult{first, second} {
+		if _, err := gen.readLayers(res, tile.NewCoords(13, uint32(100+i), 200)); err != nil {
+			t.Fatalf("readLayers failed: %v", err)
+		}
+	}
+
+	sameFile := func(layer geojson.LayerType) bool {
+		a, err := os.Stat(first.Layers[layer].OutputPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := os.Stat(second.Layers[layer].OutputPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return os.SameFile(a, b)
+	}
+	if !sameFile(geojson.LayerWater) {
+		t.Error("expected the identical empty water layers to share one file")
+	}
+	if sameFile(geojson.LayerLand) {
+		t.Error("expected the differing land layers to stay separate files")
+	}
+
+	// The deduplicated file still decodes as the layer
+	img, err := readPNG(second.Layers[geojson.LayerWater].OutputPath)
+	if err != nil {
+		t.Fatalf("failed to read deduplicated layer: %v", err)
+	}
+	if _, _, _, a := img.At(10, 10).RGBA(); a != 0 {
+		t.Errorf("deduplicated water layer alpha = %d, want 0", a)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(second.Layers[geojson.LayerWater].OutputPath)); len(entries) != 2 {
+		t.Errorf("expected 2 files in the second layer directory, got %d", len(entries))
+	}
+}
+
+// TestLayerStoreConcurrent checks that concurrent workers deduplicating the same content
+// end up with all copies linked to one file.
+func TestLayerStoreConcurrent(t *testing.T) {
+	var store layerStore
+	dir := t.TempDir()
+	paths := make([]string, 16)
+	for i := range paths {
+		paths[i] = filepath.Join(dir, "layer"+string(rune('a'+i))+".png")
+		if err := os.WriteFile(paths[i], []byte("same"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	done := make(chan error)
+	for _, p := range paths {
+		go func() { done <- store.dedup(p) }()
+	}
+	for range paths {
+		if err := <-done; err != nil {
+			t.Fatalf("dedup failed: %v", err)
+		}
+	}
+
+	want, err := os.Stat(paths[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range paths[1:] {
+		got, err := os.Stat(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !os.SameFile(want, got) {
+			t.Errorf("%s not linked to the shared copy", filepath.Base(p))
+		}
+	}
+}
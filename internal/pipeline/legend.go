@@ -0,0 +1,125 @@
+package pipeline
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+
+	"github.com/MeKo-Tech/watercolormap/internal/composite"
+	"github.com/MeKo-Tech/watercolormap/internal/geojson"
+	"github.com/MeKo-Tech/watercolormap/internal/texture"
+	"github.com/MeKo-Tech/watercolormap/internal/watercolor"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	legendGap  = 12
+	legendZoom = 13 // Zoom the swatches are painted at; 13 is the reference of the zoom adjustments
+)
+
+// Legend paints a swatch of every styled layer with its name beside it, laid out in a grid of
+// the given number of columns over the paper. Swatches are patchSize×patchSize squares
+// painted like real tiles: the layer's texture, tint and edges from the generator's styles
+// (including GeneratorOptions.Params and Style), composited over the paper with the
+// generator's finishing (tone, monochrome). Layers are listed back to front.
+func (g *Generator) Legend(patchSize, columns int) (*image.NRGBA, error) {
+	if patchSize <= 0 {
+		return nil, fmt.Errorf("patch size must be positive")
+	}
+	if columns <= 0 {
+		return nil, fmt.Errorf("columns must be positive")
+	}
+
+	base := g.zoomParams(legendZoom, 1)
+	var layers []geojson.LayerType
+	for _, layer := range base.Order() {
+		if layer != geojson.LayerPaper && base.Styles[layer].Texture != nil {
+			layers = append(layers, layer)
+		}
+	}
+	if len(layers) == 0 {
+		return nil, fmt.Errorf("no styled layers to show")
+	}
+
+	face := basicfont.Face7x13
+	labelWidth := 0
+	for _, layer := range layers {
+		labelWidth = max(labelWidth, font.MeasureString(face, string(layer)).Ceil())
+	}
+	cellW := patchSize + legendGap + labelWidth + legendGap
+	cellH := patchSize + legendGap
+	rows := (len(layers) + columns - 1) / columns
+	width := legendGap + min(columns, len(layers))*cellW
+	height := legendGap + rows*cellH
+
+	// The paper continues under the swatches, which are composited over the same texture
+	sheet := image.NewNRGBA(image.Rect(0, 0, width, height))
+	if paper := g.textures[geojson.LayerPaper]; paper != nil && !g.options.TransparentBackground {
+		texture.TileTextureRectInto(paper, width, height, 0, 0, sheet)
+		if ink := g.options.Monochrome; ink != nil {
+			toInkInto(sheet, sheet, *ink, monochromePaperDarkness)
+		}
+		composite.ApplyToneCurve(sheet, g.options.Tone)
+	} else {
+		draw.Draw(sheet, sheet.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	}
+
+	drawer := &font.Drawer{
+		Dst:  sheet,
+		Src:  image.NewUniform(color.NRGBA{R: 40, G: 40, B: 40, A: 255}),
+		Face: face,
+	}
+	for i, layer := range layers {
+		x := legendGap + (i%columns)*cellW
+		y := legendGap + (i/columns)*cellH
+		swatch, err := g.legendSwatch(base, layer, x, y, patchSize)
+		if err != nil {
+			return nil, err
+		}
+		draw.Draw(sheet, image.Rect(x, y, x+patchSize, y+patchSize), swatch, image.Point{}, draw.Over)
+
+		drawer.Dot = fixed.P(x+patchSize+legendGap, y+(patchSize+face.Ascent-face.Descent)/2)
+		drawer.DrawString(string(layer))
+	}
+	return sheet, nil
+}
+
+// legendSwatch paints layer over a patchSize square inset from its edges, so the layer's
+// edge darkening shows, and composites it over the paper at sheet position (x, y).
+func (g *Generator) legendSwatch(base watercolor.Params, layer geojson.LayerType, x, y, patchSize int) (*image.NRGBA, error) {
+	params := base
+	padPx := min(watercolor.RequiredPaddingPx(params), patchSize)
+	params.TileSize = patchSize + 2*padPx
+	params.OffsetX = x - padPx
+	params.OffsetY = y - padPx
+	params.PerlinNoise = watercolor.GenerateNoise(params, legendZoom, 0, 0)
+
+	inset := patchSize / 8
+	shape := image.NewGray(image.Rect(0, 0, params.TileSize, params.TileSize))
+	for py := padPx + inset; py < padPx+patchSize-inset; py++ {
+		row := shape.Pix[shape.PixOffset(padPx+inset, py):shape.PixOffset(padPx+patchSize-inset, py)]
+		for i := range row {
+			row[i] = 255
+		}
+	}
+	if params.Styles[layer].InvertMask {
+		// Inverted layers (land) paint where their rendered mask is empty
+		for i := range shape.Pix {
+			shape.Pix[i] = 255 - shape.Pix[i]
+		}
+	}
+
+	painted, err := watercolor.PaintLayerFromMask(shape, layer, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to paint %s swatch: %w", layer, err)
+	}
+	composited, err := g.compositeLayers(map[geojson.LayerType]image.Image{layer: painted}, params, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer metatileBuffers.put(composited)
+	return cropNRGBA(composited, image.Rect(padPx, padPx, padPx+patchSize, padPx+patchSize)), nil
+}
@@ -0,0 +1,88 @@
+package pipeline
+
+import (
+	"image"
+	"image/color"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/MeKo-Tech/watercolormap/internal/geojson"
+	"github.com/MeKo-Tech/watercolormap/internal/texture"
+	"github.com/MeKo-Tech/watercolormap/internal/watercolor"
+)
+
+func TestLegend(t *testing.T) {
+	const patchSize, columns = 48, 2
+
+	// waterSwatch returns the mean color of the middle of the water swatch
+	waterSwatch := func(t *testing.T, gen *Generator, legend *image.NRGBA) color.NRGBA {
+		t.Helper()
+		var layers []geojson.LayerType
+		for _, layer := range gen.baseParams().Order() {
+			if layer != geojson.LayerPaper && gen.baseParams().Styles[layer].Texture != nil {
+				layers = append(layers, layer)
+			}
+		}
+		i := slices.Index(layers, geojson.LayerWater)
+		if i < 0 {
+			t.Fatal("legend lists no water layer")
+		}
+		rows := (len(layers) + columns - 1) / columns
+		if got := legend.Bounds().Dy(); got != legendGap+rows*(patchSize+legendGap) {
+			t.Errorf("legend height = %d, want %d rows of swatches", got, rows)
+		}
+
+		cellW := (legend.Bounds().Dx() - legendGap) / columns
+		x0 := legendGap + (i%columns)*cellW + patchSize/2
+		y0 := legendGap + (i/columns)*(patchSize+legendGap) + patchSize/2
+		var r, g, b, n int
+		for y := y0 - 4; y < y0+4; y++ {
+			for x := x0 - 4; x < x0+4; x++ {
+				c := legend.NRGBAAt(x, y)
+				r, g, b, n = r+int(c.R), g+int(c.G), b+int(c.B), n+1
+			}
+		}
+		return color.NRGBA{R: uint8(r / n), G: uint8(g / n), B: uint8(b / n), A: 255}
+	}
+
+	t.Run("default styles", func(t *testing.T) {
+		gen := newCompositeTestGenerator(t, patchSize, GeneratorOptions{})
+		legend, err := gen.Legend(patchSize, columns)
+		if err != nil {
+			t.Fatalf("Legend failed: %v", err)
+		}
+		if water := waterSwatch(t, gen, legend); water.B <= water.R {
+			t.Errorf("water swatch %v, want a blue wash", water)
+		}
+		writePNG(t, filepath.Join("..", "..", "testdata", "output", "legend", "default.png"), legend)
+	})
+
+	t.Run("custom palette", func(t *testing.T) {
+		textures, err := texture.LoadDefaultTextures(filepath.Join("..", "..", "assets", "textures"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		params := watercolor.DefaultParams(patchSize, 123, textures)
+		style := params.Styles[geojson.LayerWater]
+		style.Tint = &color.NRGBA{R: 255, G: 40, B: 40, A: 255}
+		params.Styles[geojson.LayerWater] = style
+
+		gen := newCompositeTestGenerator(t, patchSize, GeneratorOptions{Params: &params})
+		legend, err := gen.Legend(patchSize, columns)
+		if err != nil {
+			t.Fatalf("Legend failed: %v", err)
+		}
+		if water := waterSwatch(t, gen, legend); water.R <= water.B {
+			t.Errorf("water swatch %v, want the red tint of the custom styles", water)
+		}
+		writePNG(t, filepath.Join("..", "..", "testdata", "output", "legend", "custom.png"), legend)
+	})
+
+	t.Run("invalid size", func(t *testing.T) {
+		gen := newCompositeTestGenerator(t, patchSize, GeneratorOptions{})
+		if _, err := gen.Legend(0, columns); err == nil {
+			t.Error("expected an error for a zero patch size")
+		}
+	})
+}
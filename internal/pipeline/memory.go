@@ -0,0 +1,30 @@
+package pipeline
+
+// RenderLayers is the number of layers a tile render rasterizes and paints at most: land,
+// water, rivers, parks, forest, urban, buildings, roads and highways.
+const RenderLayers = 9
+
+// Bytes per pixel of the padded canvas, measured over the masks, paint and composite stages
+// (see TestEstimateRenderMemoryMatchesMeasured).
+const (
+	// renderFixedBytesPerPx covers buffers allocated once per render: the noise field, the
+	// tiled paper, the composite, the union masks and Mapnik's image of the layer being
+	// rendered.
+	renderFixedBytesPerPx = 48
+	// renderLayerBytesPerPx covers the buffers of each layer: the decoded Mapnik layer, its
+	// masks and blur/noise/threshold intermediates, the tiled texture and the painted layer.
+	renderLayerBytesPerPx = 36
+)
+
+// EstimateRenderMemory returns the approximate peak memory in bytes of rendering one tile of
+// tileSize pixels with padPx padding on each side and the given number of layers (at most
+// RenderLayers), for sizing the number of concurrent renders to the memory available.
+//
+// The estimate assumes nothing allocated during the render is collected before it ends, so
+// it is rather an upper bound of the Go heap a render needs; pooled buffers make steady-state
+// use lower. It excludes the fetched OSM data and the encoded tile, which depend on the area
+// rather than the tile size, and scales with (tileSize+2·padPx)² and linearly with layers.
+func EstimateRenderMemory(tileSize, padPx int, layers int) int64 {
+	size := int64(tileSize + 2*padPx)
+	return size * size * int64(renderFixedBytesPerPx+renderLayerBytesPerPx*max(layers, 0))
+}
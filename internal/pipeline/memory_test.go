@@ -0,0 +1,85 @@
+package pipeline
+
+import (
+	"image"
+	"image/color"
+	"runtime"
+	"testing"
+
+	"github.com/MeKo-Tech/watercolormap/internal/geojson"
+	"github.com/MeKo-Tech/watercolormap/internal/watercolor"
+)
+
+func TestEstimateRenderMemoryScaling(t *testing.T) {
+	base := EstimateRenderMemory(256, 0, RenderLayers)
+	if got := EstimateRenderMemory(512, 0, RenderLayers); got != 4*base {
+		t.Errorf("doubling the tile size: got %d bytes, want 4×%d", got, base)
+	}
+	if got := EstimateRenderMemory(192, 32, RenderLayers); got != base {
+		t.Errorf("padding counts like tile pixels: got %d bytes, want %d", got, base)
+	}
+	fewer := EstimateRenderMemory(256, 0, 3)
+	if fewer >= base {
+		t.Errorf("expected fewer layers to need less memory: %d vs %d", fewer, base)
+	}
+	perLayer := (base - fewer) / int64(RenderLayers-3)
+	if got := EstimateRenderMemory(256, 0, 4) - fewer; got != perLayer {
+		t.Errorf("expected memory linear in layers: %d bytes for one more layer, want %d", got, perLayer)
+	}
+}
+
+// TestEstimateRenderMemoryMatchesMeasured compares the estimate against the bytes allocated
+// by masking, painting and compositing synthetic layers.
+func TestEstimateRenderMemoryMatchesMeasured(t *testing.T) {
+	if raceEnabled {
+		t.Skip("the race detector inflates the measured allocations")
+	}
+	featureLayers := []geojson.LayerType{
+		geojson.LayerWater, geojson.LayerRivers, geojson.LayerParks, geojson.LayerForest,
+		geojson.LayerUrban, geojson.LayerBuildings, geojson.LayerRoads, geojson.LayerHighways,
+	}
+	gen := newCompositeTestGenerator(t, 256, GeneratorOptions{PaintWorkers: 1})
+
+	for _, n := range []int{2, len(featureLayers)} {
+		params := testParams(gen)
+		size := params.TileSize
+
+		var before, after runtime.MemStats
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+
+		raw := make(map[geojson.LayerType]image.Image, n)
+		for i, layer := range featureLayers[:n] {
+			img := image.NewNRGBA(image.Rect(0, 0, size, size))
+			for y := 0; y < size; y++ {
+				for x := 0; x < size/2+8*i; x++ {
+					img.SetNRGBA(x, y, color.NRGBA{R: 255, A: 255})
+				}
+			}
+			raw[layer] = img
+		}
+		params.PerlinNoise = watercolor.GenerateNoise(params, 9, 100, 200)
+		masks, err := gen.tileMasks(raw, params, nil)
+		if err != nil {
+			t.Fatalf("tileMasks failed: %v", err)
+		}
+		painted, err := paintAllLayers(raw, masks, params, gen.textures, false, 1, nil, nil, nil)
+		if err != nil {
+			t.Fatalf("paintAllLayers failed: %v", err)
+		}
+		composited, err := gen.compositeLayers(painted, params, nil)
+		if err != nil {
+			t.Fatalf("compositeLayers failed: %v", err)
+		}
+
+		runtime.ReadMemStats(&after)
+		metatileBuffers.put(composited)
+
+		measured := int64(after.TotalAlloc - before.TotalAlloc)
+		// The land layer is painted from the masks besides the feature layers
+		estimate := EstimateRenderMemory(gen.tileSize, testPadPx, n+1)
+		if ratio := float64(estimate) / float64(measured); ratio < 0.67 || ratio > 1.5 {
+			t.Errorf("%d layers: estimated %d bytes, measured %d (ratio %.2f)", n+1, estimate, measured, ratio)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/MeKo-Tech/watercolormap/internal/tile"
+	"github.com/MeKo-Tech/watercolormap/internal/types"
+)
+
+// TileMetadata records what produced a tile: it is written next to the tile as a JSON sidecar
+// when GeneratorOptions.EmitMetadata is set, answering which data and styling a tile shows.
+type TileMetadata struct {
+	Tile string `json:"tile"`
+	Seed int64  `json:"seed"`
+	// FeatureCounts are the features the tile was rendered from, by type (see
+	// types.FeatureCollection.FeatureCounts); over-zoomed tiles count their ancestor's
+	FeatureCounts map[string]int `json:"feature_counts"`
+	// FetchMs is the time spent fetching the data, 0 for prefetched or cached data
+	FetchMs int64 `json:"fetch_ms"`
+	// RenderMs is the time from the start of the render to the written tile, without the fetch
+	RenderMs   int64  `json:"render_ms"`
+	DataSource string `json:"data_source"`
+	// OSMTimestamp is the OSM database state the data reflects, when the source reports it
+	OSMTimestamp *time.Time `json:"osm_timestamp,omitempty"`
+	// DataHash fingerprints the features the tile was rendered from (see
+	// types.FeatureCollection.Hash); GeneratorOptions.RerenderChanged compares it
+	DataHash string `json:"data_hash,omitempty"`
+	// ParamsHash fingerprints the styling the tile was painted with (see watercolor.Params.Hash)
+	ParamsHash string `json:"params_hash"`
+}
+
+// MetadataPath returns the path of the metadata sidecar of the tile at tilePath: the tile
+// path with its extension replaced by .json.
+func MetadataPath(tilePath string) string {
+	return strings.TrimSuffix(tilePath, filepath.Ext(tilePath)) + ".json"
+}
+
+// tileMetadata collects the metadata of a tile rendered from renderResult.
+func (g *Generator) tileMetadata(coords tile.Coords, renderResult *renderLayersResult, renderTime time.Duration) TileMetadata {
+	md := TileMetadata{
+		Tile:       coords.String(),
+		Seed:       g.seed,
+		FetchMs:    renderResult.fetchTime.Milliseconds(),
+		RenderMs:   renderTime.Milliseconds(),
+		ParamsHash: g.baseParams().Hash(),
+	}
+	if data := renderResult.data; data != nil {
+		md.FeatureCounts = data.Features.FeatureCounts()
+		md.DataSource = data.Source
+		md.DataHash = data.Features.Hash()
+		if ts, ok := osmTimestamp(data); ok {
+			md.OSMTimestamp = &ts
+		}
+	}
+	return md
+}
+
+// writeMetadata writes the metadata sidecar of a tile written to finalPath. Tiles written
+// through a TileWriter have no file to sit next to and are skipped.
+func (g *Generator) writeMetadata(coords tile.Coords, finalPath string, renderResult *renderLayersResult, renderTime time.Duration) error {
+	if g.options.TileWriter != nil {
+		g.log().Debug("Skipping tile metadata for TileWriter output", "coords", coords.String())
+		return nil
+	}
+
+	data, err := json.MarshalIndent(g.tileMetadata(coords, renderResult, renderTime), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode tile metadata: %w", err)
+	}
+	path := MetadataPath(finalPath)
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write tile metadata: %w", err)
+	}
+	return nil
+}
+
+// osmTimestamp returns the OSM database state data reflects, if its source reports one.
+func osmTimestamp(data *types.TileData) (time.Time, bool) {
+	if data == nil || data.OverpassResult == nil || data.OverpassResult.Timestamp.IsZero() {
+		return time.Time{}, false
+	}
+	return data.OverpassResult.Timestamp.UTC(), true
+}
+
+// readMetadata reads the metadata sidecar of the tile at tilePath.
+func readMetadata(tilePath string) (TileMetadata, error) {
+	var md TileMetadata
+	data, err := os.ReadFile(MetadataPath(tilePath))
+	if err != nil {
+		return md, err
+	}
+	if err := json.Unmarshal(data, &md); err != nil {
+		return md, fmt.Errorf("failed to decode tile metadata: %w", err)
+	}
+	return md, nil
+}
+
+// existingTileCurrent reports whether the tile already written to finalPath can be kept when
+// rendering without force: it exists, is no older than GeneratorOptions.MaxTileAge and, with
+// GeneratorOptions.RerenderChanged, was rendered from the same features the data source
+// returns for it now (see TileMetadata.DataHash). Comparing the tile's own features rather
+// than the database timestamp keeps tiles whose area did not change, and works for sources
+// that report no timestamp at all. The data fetched for the check is returned for the render, as is data
+// when no fetch was needed.
+func (g *Generator) existingTileCurrent(ctx context.Context, coords tile.Coords, finalPath string, data *types.TileData) (bool, *types.TileData, error) {
+	if tc, ok := g.options.TileWriter.(TileChecker); ok {
+		exists, err := tc.HasTile(int(coords.Z), int(coords.X), int(coords.Y))
+		if err != nil {
+			return false, data, fmt.Errorf("failed to check for existing tile: %w", err)
+		}
+		return exists, data, nil
+	}
+
+	info, err := os.Stat(finalPath)
+	if err != nil {
+		return false, data, nil
+	}
+	if age := time.Since(info.ModTime()); g.options.MaxTileAge > 0 && age > g.options.MaxTileAge {
+		g.log().Info("Tile is stale; rendering again", "coords", coords.String(), "age", age.Round(time.Second))
+		return false, data, nil
+	}
+	if !g.options.RerenderChanged || g.options.TileWriter != nil {
+		return true, data, nil
+	}
+
+	md, err := readMetadata(finalPath)
+	if err != nil || md.DataHash == "" {
+		g.log().Info("Tile has no recorded data hash; rendering again", "coords", coords.String())
+		return false, data, nil
+	}
+	if data == nil {
+		if data, err = g.FetchOnly(ctx, coords); err != nil {
+			return false, nil, err
+		}
+	}
+	current := data.Features.Hash()
+	if current == md.DataHash {
+		return true, data, nil
+	}
+	g.log().Info("Tile data changed; rendering again", "coords", coords.String(), "rendered", md.DataHash, "current", current)
+	return false, data, nil
+}
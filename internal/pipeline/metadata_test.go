@@ -0,0 +1,194 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/MeKo-Christian/go-overpass"
+	"github.com/MeKo-Tech/watercolormap/internal/datasource"
+	"github.com/MeKo-Tech/watercolormap/internal/tile"
+	"github.com/MeKo-Tech/watercolormap/internal/types"
+)
+
+func TestWriteMetadata(t *testing.T) {
+	gen := newCompositeTestGenerator(t, 32, GeneratorOptions{EmitMetadata: true})
+	osmBase := time.Date(2025, 12, 1, 8, 30, 0, 0, time.UTC)
+	renderResult := &renderLayersResult{
+		data: &types.TileData{
+			Source:         "overpass-api",
+			OverpassResult: &overpass.Result{Timestamp: osmBase},
+			Features: types.FeatureCollection{
+				Water: make([]types.Feature, 2),
+				Roads: make([]types.Feature, 3),
+			},
+		},
+		fetchTime: 1500 * time.Millisecond,
+	}
+	coords := tile.NewCoords(13, 4317, 2692)
+	tilePath := filepath.Join(t.TempDir(), "z13_x4317_y2692.png")
+
+	if err := gen.writeMetadata(coords, tilePath, renderResult, 250*time.Millisecond); err != nil {
+		t.Fatalf("writeMetadata failed: %v", err)
+	}
+
+	path := MetadataPath(tilePath)
+	if filepath.Base(path) != "z13_x4317_y2692.json" {
+		t.Errorf("MetadataPath = %s, want z13_x4317_y2692.json next to the tile", path)
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("sidecar not written: %v", err)
+	}
+
+	var fields map[string]any
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		t.Fatalf("sidecar is not JSON: %v", err)
+	}
+	for _, key := range []string{"tile", "seed", "feature_counts", "fetch_ms", "render_ms", "data_source", "osm_timestamp", "data_hash", "params_hash"} {
+		if _, ok := fields[key]; !ok {
+			t.Errorf("sidecar lacks %q: %s", key, raw)
+		}
+	}
+
+	var md TileMetadata
+	if err := json.Unmarshal(raw, &md); err != nil {
+		t.Fatalf("failed to decode sidecar: %v", err)
+	}
+	if md.Tile != coords.String() || md.Seed != gen.seed {
+		t.Errorf("tile %q seed %d, want %q %d", md.Tile, md.Seed, coords.String(), gen.seed)
+	}
+	if md.FeatureCounts["water"] != 2 || md.FeatureCounts["roads"] != 3 || md.FeatureCounts["total"] != 5 {
+		t.Errorf("feature counts = %v", md.FeatureCounts)
+	}
+	if md.FetchMs != 1500 || md.RenderMs != 250 {
+		t.Errorf("fetch %d ms, render %d ms, want 1500 and 250", md.FetchMs, md.RenderMs)
+	}
+	if md.DataSource != "overpass-api" {
+		t.Errorf("data source = %q", md.DataSource)
+	}
+	if md.OSMTimestamp == nil || !md.OSMTimestamp.Equal(osmBase) {
+		t.Errorf("OSM timestamp = %v, want %v", md.OSMTimestamp, osmBase)
+	}
+	if md.DataHash != renderResult.data.Features.Hash() {
+		t.Errorf("data hash = %q, want %q", md.DataHash, renderResult.data.Features.Hash())
+	}
+	if md.ParamsHash != gen.baseParams().Hash() || md.ParamsHash == "" {
+		t.Errorf("params hash = %q, want %q", md.ParamsHash, gen.baseParams().Hash())
+	}
+}
+
+func TestWriteMetadataSkipsTileWriter(t *testing.T) {
+	gen := newCompositeTestGenerator(t, 32, GeneratorOptions{EmitMetadata: true, TileWriter: &recordingStreamWriter{}})
+	tilePath := filepath.Join(t.TempDir(), "z1_x0_y0.png")
+	if err := gen.writeMetadata(tile.NewCoords(1, 0, 0), tilePath, &renderLayersResult{}, 0); err != nil {
+		t.Fatalf("writeMetadata failed: %v", err)
+	}
+	if _, err := os.Stat(MetadataPath(tilePath)); !os.IsNotExist(err) {
+		t.Errorf("sidecar written for TileWriter output: %v", err)
+	}
+}
+
+// countingDataSource counts the fetches of the data source it wraps.
+type countingDataSource struct {
+	*datasource.FileOverpassDataSource
+	calls int
+}
+
+func (s *countingDataSource) FetchTileDataWithBounds(ctx context.Context, coord types.TileCoordinate, bounds types.BoundingBox) (*types.TileData, error) {
+	s.calls++
+	return s.FileOverpassDataSource.FetchTileDataWithBounds(ctx, coord, bounds)
+}
+
+func TestRerenderChanged(t *testing.T) {
+	file, err := datasource.NewFileOverpassDataSource(filepath.Join("..", "..", "testdata", "overpass", "z16_x34540_y21540.json"))
+	if err != nil {
+		t.Fatalf("failed to load fixture: %v", err)
+	}
+	coords := tile.NewCoords(16, 34540, 21540)
+
+	// The file source reports no OSM timestamp for the tile; the sidecar records its features
+	probe := newCompositeTestGenerator(t, 32, GeneratorOptions{})
+	probe.ds = file
+	fetched, err := probe.FetchOnly(context.Background(), coords)
+	if err != nil {
+		t.Fatalf("FetchOnly failed: %v", err)
+	}
+	if fetched.OverpassResult != nil {
+		t.Fatal("expected the file source to leave OverpassResult unset")
+	}
+	unchanged := fetched.Features
+	changed := unchanged
+	changed.Buildings = unchanged.Buildings[1:]
+
+	// writeTile stands in for an earlier render of features: the tile and its sidecar
+	writeTile := func(t *testing.T, gen *Generator, features types.FeatureCollection) string {
+		t.Helper()
+		path, dir := gen.tilePath(coords, "")
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("tile"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		renderResult := &renderLayersResult{data: &types.TileData{Features: features}}
+		if err := gen.writeMetadata(coords, path, renderResult, 0); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	tests := []struct {
+		name        string
+		opts        GeneratorOptions
+		rendered    types.FeatureCollection
+		age         time.Duration
+		wantCurrent bool
+		wantFetches int
+	}{
+		{name: "unchanged", opts: GeneratorOptions{RerenderChanged: true}, rendered: unchanged, wantCurrent: true, wantFetches: 1},
+		{name: "changed", opts: GeneratorOptions{RerenderChanged: true}, rendered: changed, wantFetches: 1},
+		{name: "existing tiles kept without check", opts: GeneratorOptions{}, rendered: changed, wantCurrent: true},
+		{name: "stale", opts: GeneratorOptions{RerenderChanged: true, MaxTileAge: 24 * time.Hour}, rendered: unchanged, age: 48 * time.Hour},
+		{name: "within max age", opts: GeneratorOptions{MaxTileAge: 24 * time.Hour}, rendered: unchanged, age: time.Hour, wantCurrent: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ds := &countingDataSource{FileOverpassDataSource: file}
+			gen := newCompositeTestGenerator(t, 32, tt.opts)
+			gen.ds = ds
+			path := writeTile(t, gen, tt.rendered)
+			if tt.age > 0 {
+				old := time.Now().Add(-tt.age)
+				if err := os.Chtimes(path, old, old); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			current, data, err := gen.existingTileCurrent(context.Background(), coords, path, nil)
+			if err != nil {
+				t.Fatalf("existingTileCurrent failed: %v", err)
+			}
+			if current != tt.wantCurrent {
+				t.Errorf("current = %v, want %v", current, tt.wantCurrent)
+			}
+			if ds.calls != tt.wantFetches {
+				t.Errorf("fetched %d times, want %d", ds.calls, tt.wantFetches)
+			}
+			if tt.wantFetches > 0 && data == nil {
+				t.Error("expected the fetched data to be handed on to the render")
+			}
+
+			// A current tile is skipped before any rendering
+			if tt.wantCurrent {
+				got, _, err := gen.Generate(context.Background(), coords, false, "", nil)
+				if err != nil || got != path {
+					t.Errorf("Generate = %q, %v; want the existing tile skipped", got, err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/MeKo-Tech/watercolormap/internal/tile"
+)
+
+// GenerateMetatile renders an n×n block of tiles whose top-left tile is originCoords in a
+// single pass and writes each child tile. Compared to n² calls to Generate this fetches the
+// data once, runs Mapnik once per layer, and runs the mask pipeline once for the whole block.
+//
+// Noise and texture offsets are global (derived from the origin tile position), so the
+// sliced tiles are pixel-identical in alignment to tiles generated one at a time.
+// Child tiles that fall outside the world at the origin's zoom are skipped.
+// Existing tiles are always overwritten; callers decide whether a block needs rendering.
+// Returns the paths of the written tiles in row-major order.
+func (g *Generator) GenerateMetatile(ctx context.Context, originCoords tile.Coords, n int) ([]string, error) {
+	return g.generateMetatile(ctx, originCoords, n, "")
+}
+
+func (g *Generator) generateMetatile(ctx context.Context, originCoords tile.Coords, n int, filenameSuffix string) ([]string, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("metatile size must be positive, got %d", n)
+	}
+
+	tm := g.newStageTimer()
+	renderResult, err := g.renderLayersWithData(ctx, originCoords, n, nil, tm, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer renderResult.releaseNoise()
+	if !g.keepLayers {
+		defer os.RemoveAll(renderResult.layerDir) // nolint:errcheck
+	}
+
+	masks, err := g.tileMasks(renderResult.rawLayers, renderResult.params, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build masks: %w", err)
+	}
+	tm.mark("masks")
+
+	painted, err := paintAllLayers(renderResult.rawLayers, masks, renderResult.params, g.textures, g.options.TransparentBackground, g.options.PaintWorkers, workerProcessorContext(ctx), nil, tm)
+	if err != nil {
+		return nil, err
+	}
+
+	composited, err := g.compositeLayers(painted, renderResult.params, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer metatileBuffers.put(composited)
+	tm.mark("composite")
+
+	worldTiles := uint32(1) << originCoords.Z
+	padPx := renderResult.padPx
+	paths := make([]string, 0, n*n)
+	for dy := 0; dy < n; dy++ {
+		for dx := 0; dx < n; dx++ {
+			child := tile.NewCoords(originCoords.Z, originCoords.X+uint32(dx), originCoords.Y+uint32(dy))
+			if child.X >= worldTiles || child.Y >= worldTiles {
+				continue
+			}
+
+			finalPath, tileDir := g.tilePath(child, filenameSuffix)
+			if err := os.MkdirAll(tileDir, 0o755); err != nil {
+				return paths, fmt.Errorf("failed to create output dir: %w", err)
+			}
+
+			final := g.cropTile(composited, padPx, dx, dy)
+
+			if err := g.writeTile(final, child, finalPath); err != nil {
+				return paths, fmt.Errorf("failed to write tile %s: %w", child.String(), err)
+			}
+			paths = append(paths, finalPath)
+		}
+	}
+	tm.mark("encode")
+	tm.log(g.log(), originCoords)
+
+	return paths, nil
+}
+
+// MetatileOrigin returns the top-left tile of the n×n metatile block containing coords.
+// Blocks are aligned to multiples of n so every tile belongs to exactly one block.
+func MetatileOrigin(coords tile.Coords, n int) tile.Coords {
+	if n <= 1 {
+		return coords
+	}
+	size := uint32(n)
+	return tile.NewCoords(coords.Z, coords.X-coords.X%size, coords.Y-coords.Y%size)
+}
+
+// metatileExists reports whether every in-world child tile of the block already exists, in
+// the TileWriter when it is a TileChecker and on disk otherwise, and returns the first
+// child's path.
+func (g *Generator) metatileExists(originCoords tile.Coords, n int, filenameSuffix string) (string, bool, error) {
+	tc, checkWriter := g.options.TileWriter.(TileChecker)
+	worldTiles := uint32(1) << originCoords.Z
+	first := ""
+	for dy := 0; dy < n; dy++ {
+		for dx := 0; dx < n; dx++ {
+			child := tile.NewCoords(originCoords.Z, originCoords.X+uint32(dx), originCoords.Y+uint32(dy))
+			if child.X >= worldTiles || child.Y >= worldTiles {
+				continue
+			}
+			path, _ := g.tilePath(child, filenameSuffix)
+			if checkWriter {
+				exists, err := tc.HasTile(int(child.Z), int(child.X), int(child.Y))
+				if err != nil {
+					return "", false, fmt.Errorf("failed to check for existing tile %s: %w", child.String(), err)
+				}
+				if !exists {
+					return "", false, nil
+				}
+			} else if _, err := os.Stat(path); err != nil {
+				return "", false, nil
+			}
+			if first == "" {
+				first = path
+			}
+		}
+	}
+	return first, true, nil
+}
+
+// MetatileGenerator adapts a Generator so that each Generate call renders the whole n×n
+// block containing the requested tile. It satisfies worker.Generator, so batch generation
+// can feed it one task per block.
+type MetatileGenerator struct {
+	gen *Generator
+	n   int
+}
+
+// NewMetatileGenerator wraps gen to render n×n metatiles.
+func NewMetatileGenerator(gen *Generator, n int) *MetatileGenerator {
+	return &MetatileGenerator{gen: gen, n: n}
+}
+
+// WorkerContext gives each worker its own watercolor buffers (see Generator.WorkerContext).
+func (m *MetatileGenerator) WorkerContext(ctx context.Context) context.Context {
+	return m.gen.WorkerContext(ctx)
+}
+
+// Generate renders the block containing coords and returns the path of its first tile.
+// Unless force is set, the block is skipped when all of its tiles already exist.
+func (m *MetatileGenerator) Generate(ctx context.Context, coords tile.Coords, force bool, filenameSuffix string, debugCtx interface{}) (string, string, error) {
+	origin := MetatileOrigin(coords, m.n)
+	if !force {
+		path, ok, err := m.gen.metatileExists(origin, m.n, filenameSuffix)
+		if err != nil {
+			return "", "", err
+		}
+		if ok {
+			m.gen.log().Info("Metatile already exists; skipping", "origin", origin.String(), "n", m.n)
+			return path, "", nil
+		}
+	}
+
+	paths, err := m.gen.generateMetatile(ctx, origin, m.n, filenameSuffix)
+	if err != nil {
+		return "", "", err
+	}
+	if len(paths) == 0 {
+		return "", "", nil
+	}
+	return paths[0], "", nil
+}
@@ -0,0 +1,62 @@
+package pipeline
+
+import (
+	"image"
+	"testing"
+
+	"github.com/MeKo-Tech/watercolormap/internal/tile"
+)
+
+func TestMetatileOrigin(t *testing.T) {
+	tests := []struct {
+		name   string
+		coords tile.Coords
+		n      int
+		want   tile.Coords
+	}{
+		{"n=1 is identity", tile.NewCoords(13, 4317, 2693), 1, tile.NewCoords(13, 4317, 2693)},
+		{"aligned origin", tile.NewCoords(13, 4316, 2692), 4, tile.NewCoords(13, 4316, 2692)},
+		{"inside block", tile.NewCoords(13, 4319, 2695), 4, tile.NewCoords(13, 4316, 2692)},
+		{"n=2", tile.NewCoords(5, 3, 4), 2, tile.NewCoords(5, 2, 4)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MetatileOrigin(tt.coords, tt.n); got != tt.want {
+				t.Errorf("MetatileOrigin(%v, %d) = %v, want %v", tt.coords, tt.n, got, tt.want)
+			}
+		})
+	}
+}
+
+// checkingTileWriter is a memTileWriter that reports the tiles it holds.
+type checkingTileWriter struct {
+	memTileWriter
+}
+
+func (w *checkingTileWriter) HasTile(z, x, y int) (bool, error) {
+	_, ok := w.tiles[[3]int{z, x, y}]
+	return ok, nil
+}
+
+func TestMetatileExistsChecksTileWriter(t *testing.T) {
+	w := &checkingTileWriter{memTileWriter{tiles: map[[3]int][]byte{}}}
+	gen := newCompositeTestGenerator(t, 16, GeneratorOptions{TileWriter: w})
+	origin := tile.NewCoords(1, 0, 0)
+
+	// The 2×2 block covers the whole z1 world; nothing is on disk
+	img := image.NewNRGBA(image.Rect(0, 0, 16, 16))
+	for i, coords := range []tile.Coords{
+		tile.NewCoords(1, 0, 0), tile.NewCoords(1, 1, 0), tile.NewCoords(1, 0, 1), tile.NewCoords(1, 1, 1),
+	} {
+		if _, ok, err := gen.metatileExists(origin, 2, ""); err != nil || ok {
+			t.Fatalf("block with %d of 4 tiles written: exists=%v, err=%v", i, ok, err)
+		}
+		if err := gen.writeTile(img, coords, ""); err != nil {
+			t.Fatalf("writeTile failed: %v", err)
+		}
+	}
+	if _, ok, err := gen.metatileExists(origin, 2, ""); err != nil || !ok {
+		t.Errorf("block with all tiles written: exists=%v, err=%v", ok, err)
+	}
+}
@@ -0,0 +1,82 @@
+package pipeline
+
+import (
+	"image"
+	"image/color"
+	"slices"
+
+	"github.com/MeKo-Tech/watercolormap/internal/geojson"
+	"github.com/MeKo-Tech/watercolormap/internal/watercolor"
+)
+
+const (
+	// Ink coverage of the paper and of the back- and front-most layers in monochrome mode;
+	// layers in between are spread evenly by their default composite order, so a configured
+	// watercolor.Params.CompositeOrder restacks layers without changing their ink.
+	monochromePaperDarkness = 0.04
+	monochromeMinDarkness   = 0.15
+	monochromeMaxDarkness   = 0.65
+	// monochromeTextureContrast is how much a pixel's own lightness varies its layer value,
+	// keeping the texture grain and edge darkening visible.
+	monochromeTextureContrast = 0.5
+)
+
+// monochromeDarkness returns the base ink coverage of a layer in monochrome mode.
+func monochromeDarkness(layer geojson.LayerType) float64 {
+	order := watercolor.DefaultCompositeOrder
+	i := slices.Index(order, layer)
+	if i < 0 {
+		i = len(order) - 1
+	}
+	return monochromeMinDarkness + (monochromeMaxDarkness-monochromeMinDarkness)*float64(i)/float64(len(order)-1)
+}
+
+// monochromeLayers returns copies of the painted layers desaturated onto a value ramp of ink.
+func monochromeLayers(painted map[geojson.LayerType]image.Image, ink color.RGBA) map[geojson.LayerType]image.Image {
+	out := make(map[geojson.LayerType]image.Image, len(painted))
+	for layer, img := range painted {
+		if img == nil {
+			continue
+		}
+		dst := image.NewNRGBA(img.Bounds())
+		toInkInto(dst, img, ink, monochromeDarkness(layer))
+		out[layer] = dst
+	}
+	return out
+}
+
+// toInkInto desaturates src and writes it to dst as a tint of ink: white paper at coverage 0,
+// the ink itself at coverage 1. darkness is the coverage of a mid-gray pixel; lighter and
+// darker pixels get proportionally less or more. Alpha is preserved. dst may be src.
+func toInkInto(dst *image.NRGBA, src image.Image, ink color.RGBA, darkness float64) {
+	b := src.Bounds()
+	nrgba, _ := src.(*image.NRGBA)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			var c color.NRGBA
+			if nrgba != nil {
+				c = nrgba.NRGBAAt(x, y)
+			} else {
+				c = color.NRGBAModel.Convert(src.At(x, y)).(color.NRGBA)
+			}
+			if c.A == 0 {
+				dst.SetNRGBA(x, y, color.NRGBA{})
+				continue
+			}
+
+			lightness := (0.299*float64(c.R) + 0.587*float64(c.G) + 0.114*float64(c.B)) / 255
+			coverage := darkness * (1 + monochromeTextureContrast*(0.5-lightness)*2)
+			coverage = min(max(coverage, 0), 1)
+			dst.SetNRGBA(x, y, color.NRGBA{
+				R: inkChannel(ink.R, coverage),
+				G: inkChannel(ink.G, coverage),
+				B: inkChannel(ink.B, coverage),
+				A: c.A,
+			})
+		}
+	}
+}
+
+func inkChannel(ink uint8, coverage float64) uint8 {
+	return uint8(255 - (255-float64(ink))*coverage + 0.5)
+}
@@ -0,0 +1,67 @@
+package pipeline
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func TestMonochromeRendersGray(t *testing.T) {
+	ink := color.RGBA{R: 40, G: 40, B: 40, A: 255}
+	gen := newCompositeTestGenerator(t, 256, GeneratorOptions{Monochrome: &ink})
+	params := testParams(gen)
+	_, painted := renderSyntheticLake(t, GeneratorOptions{})
+
+	var buf bytes.Buffer
+	pooledTile(t, gen, painted, params, &buf)
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatalf("failed to decode tile: %v", err)
+	}
+
+	const tolerance = 2
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			lo := min(c.R, c.G, c.B)
+			hi := max(c.R, c.G, c.B)
+			if hi-lo > tolerance {
+				t.Fatalf("pixel (%d,%d) = %v is not gray", x, y, c)
+			}
+		}
+	}
+
+	// Water sits in front of land in the composite order, so the lake reads darker
+	land := gray(img, 0, 0)
+	water := gray(img, 128, 128)
+	if water >= land {
+		t.Errorf("expected water (%d) to be darker than land (%d)", water, land)
+	}
+}
+
+func TestToInkIntoKeepsAlpha(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	src.SetNRGBA(0, 0, color.NRGBA{R: 200, G: 60, B: 60, A: 120})
+	dst := image.NewNRGBA(src.Bounds())
+
+	ink := color.RGBA{R: 0, G: 0, B: 128, A: 255}
+	toInkInto(dst, src, ink, 0.5)
+
+	got := dst.NRGBAAt(0, 0)
+	if got.A != 120 {
+		t.Errorf("alpha = %d, want 120", got.A)
+	}
+	if got.R != got.G || got.B <= got.R {
+		t.Errorf("expected a tint of the blue ink, got %v", got)
+	}
+	if dst.NRGBAAt(1, 0) != (color.NRGBA{}) {
+		t.Errorf("transparent pixels should stay transparent, got %v", dst.NRGBAAt(1, 0))
+	}
+}
+
+func gray(img image.Image, x, y int) uint8 {
+	return color.GrayModel.Convert(img.At(x, y)).(color.Gray).Y
+}
@@ -0,0 +1,67 @@
+package pipeline
+
+import (
+	"sync"
+
+	"github.com/MeKo-Tech/watercolormap/internal/tile"
+	"github.com/MeKo-Tech/watercolormap/internal/types"
+)
+
+// overzoomCacheSize is the number of ancestor tiles whose data an over-zooming Generator
+// keeps. A browser at deep zoom requests tiles around a handful of ancestors at a time, and
+// one z16 ancestor covers 16 z18 tiles.
+const overzoomCacheSize = 32
+
+// DataTile returns the tile whose data coords renders from: its ancestor at
+// GeneratorOptions.MaxDataZoom when coords lies deeper, otherwise coords itself.
+func (g *Generator) DataTile(coords tile.Coords) tile.Coords {
+	maxZoom := g.options.MaxDataZoom
+	if maxZoom <= 0 || int(coords.Z) <= maxZoom {
+		return coords
+	}
+	shift := coords.Z - uint32(maxZoom)
+	return tile.NewCoords(uint32(maxZoom), coords.X>>shift, coords.Y>>shift)
+}
+
+// CachedData returns the cached data of an ancestor tile (see DataTile), or nil.
+func (g *Generator) CachedData(dataCoords tile.Coords) *types.TileData {
+	return g.overzoomData.get(dataCoords)
+}
+
+// StoreData caches the data fetched for an ancestor tile (see DataTile), so the other tiles
+// over-zoomed from it render without fetching it again. Data of other tiles isn't cached.
+func (g *Generator) StoreData(dataCoords tile.Coords, data *types.TileData) {
+	if g.options.MaxDataZoom <= 0 || int(dataCoords.Z) != g.options.MaxDataZoom || data == nil {
+		return
+	}
+	g.overzoomData.put(dataCoords, data)
+}
+
+// tileDataCache keeps the data of the most recently fetched tiles, evicting the oldest.
+type tileDataCache struct {
+	mu      sync.Mutex
+	entries map[tile.Coords]*types.TileData
+	order   []tile.Coords
+}
+
+func (c *tileDataCache) get(coords tile.Coords) *types.TileData {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.entries[coords]
+}
+
+func (c *tileDataCache) put(coords tile.Coords, data *types.TileData) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = make(map[tile.Coords]*types.TileData, overzoomCacheSize)
+	}
+	if _, ok := c.entries[coords]; !ok {
+		if len(c.order) == overzoomCacheSize {
+			delete(c.entries, c.order[0])
+			c.order = c.order[1:]
+		}
+		c.order = append(c.order, coords)
+	}
+	c.entries[coords] = data
+}
@@ -0,0 +1,148 @@
+package pipeline
+
+import (
+	"context"
+	"image"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/MeKo-Tech/watercolormap/internal/datasource"
+	"github.com/MeKo-Tech/watercolormap/internal/tile"
+	"github.com/MeKo-Tech/watercolormap/internal/types"
+)
+
+func TestDataTile(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxDataZoom int
+		coords      tile.Coords
+		want        tile.Coords
+	}{
+		{"off", 0, tile.NewCoords(18, 138161, 86161), tile.NewCoords(18, 138161, 86161)},
+		{"at max data zoom", 16, tile.NewCoords(16, 34540, 21540), tile.NewCoords(16, 34540, 21540)},
+		{"below max data zoom", 16, tile.NewCoords(13, 4317, 2692), tile.NewCoords(13, 4317, 2692)},
+		{"one level deeper", 16, tile.NewCoords(17, 69081, 43080), tile.NewCoords(16, 34540, 21540)},
+		{"two levels deeper", 16, tile.NewCoords(18, 138163, 86160), tile.NewCoords(16, 34540, 21540)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &Generator{options: GeneratorOptions{MaxDataZoom: tt.maxDataZoom}}
+			if got := g.DataTile(tt.coords); got != tt.want {
+				t.Errorf("DataTile(%s) = %s, want %s", tt.coords.String(), got.String(), tt.want.String())
+			}
+		})
+	}
+}
+
+// recordingDataSource returns empty data and records the fetches made.
+type recordingDataSource struct {
+	mu      sync.Mutex
+	fetches []types.TileCoordinate
+	bounds  []types.BoundingBox
+}
+
+func (ds *recordingDataSource) FetchTileData(ctx context.Context, coord types.TileCoordinate) (*types.TileData, error) {
+	return ds.FetchTileDataWithBounds(ctx, coord, types.TileToBounds(coord))
+}
+
+func (ds *recordingDataSource) FetchTileDataWithBounds(_ context.Context, coord types.TileCoordinate, bounds types.BoundingBox) (*types.TileData, error) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	ds.fetches = append(ds.fetches, coord)
+	ds.bounds = append(ds.bounds, bounds)
+	return &types.TileData{Coordinate: coord, Bounds: bounds}, nil
+}
+
+func TestOverzoomFetchesAncestorOnce(t *testing.T) {
+	ds := &recordingDataSource{}
+	gen, err := NewGenerator(ds, "", filepath.Join("..", "..", "assets", "textures"), t.TempDir(), 256, 1, false, nil, GeneratorOptions{MaxDataZoom: 16})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Siblings in the corner of their z16 ancestor, whose padding reaches into its neighbors
+	siblings := []tile.Coords{tile.NewCoords(18, 138160, 86160), tile.NewCoords(18, 138161, 86160)}
+	var first *types.TileData
+	for i, coords := range siblings {
+		data, err := gen.FetchOnly(context.Background(), coords)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i == 0 {
+			first = data
+		} else if data != first {
+			t.Error("sibling did not reuse its ancestor's data")
+		}
+	}
+
+	if len(ds.fetches) != 1 {
+		t.Fatalf("got %d fetches, want 1 for both siblings", len(ds.fetches))
+	}
+	if got := ds.fetches[0]; got != (types.TileCoordinate{Zoom: 16, X: 34540, Y: 21540}) {
+		t.Errorf("fetched %+v, want the z16 ancestor", got)
+	}
+	for _, coords := range siblings {
+		if need := gen.CalculateFetchBounds(coords); !ds.bounds[0].ContainsBox(need) {
+			t.Errorf("ancestor bounds %s don't cover the padded bounds %s of %s", ds.bounds[0], need, coords.String())
+		}
+	}
+}
+
+// TestOverzoomRenderSeamless renders two neighboring z18 tiles from the data of their z16
+// ancestor and checks that they are painted and agree along their shared edge.
+func TestOverzoomRenderSeamless(t *testing.T) {
+	requireIntegration(t)
+	ds, err := datasource.NewFileOverpassDataSource(filepath.Join("..", "..", "testdata", "overpass", "z16_x34540_y21540.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gen, err := NewGenerator(ds, filepath.Join("..", "..", "assets", "styles"), filepath.Join("..", "..", "assets", "textures"), t.TempDir(), 256, 123, false, nil, GeneratorOptions{MaxDataZoom: 16})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	var tiles [2]*image.NRGBA
+	var padPx int
+	for i, coords := range []tile.Coords{tile.NewCoords(18, 138161, 86161), tile.NewCoords(18, 138162, 86161)} {
+		data, err := gen.FetchOnly(ctx, coords)
+		if err != nil {
+			t.Fatal(err)
+		}
+		renderResult, painted, err := gen.renderAndPaint(ctx, coords, nil, nil, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		composited, err := gen.compositeLayers(painted, renderResult.params, nil)
+		renderResult.releaseNoise()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer metatileBuffers.put(composited)
+		tiles[i], padPx = composited, renderResult.padPx
+	}
+
+	report := analyzeTile(tiles[0], nil, image.Rect(padPx, padPx, padPx+256, padPx+256))
+	if report.OpaqueFraction < 0.99 {
+		t.Errorf("over-zoomed tile is %.1f%% painted, want a full tile", 100*report.OpaqueFraction)
+	}
+
+	// The left tile's padding extends into the right tile: its first column must match
+	diff := 0
+	for y := padPx; y < padPx+256; y++ {
+		l := tiles[0].NRGBAAt(padPx+256, y)
+		r := tiles[1].NRGBAAt(padPx, y)
+		diff += absDiff(l.R, r.R) + absDiff(l.G, r.G) + absDiff(l.B, r.B)
+	}
+	if mean := float64(diff) / (3 * 256); mean > 2 {
+		t.Errorf("mean difference %.2f along the shared edge, want the siblings to line up", mean)
+	}
+}
+
+func absDiff(a, b uint8) int {
+	if a > b {
+		return int(a - b)
+	}
+	return int(b - a)
+}
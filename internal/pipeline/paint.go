@@ -0,0 +1,96 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"sync"
+
+	"github.com/MeKo-Tech/watercolormap/internal/geojson"
+	"github.com/MeKo-Tech/watercolormap/internal/watercolor"
+)
+
+// paintJob paints a single layer that no other layer depends on.
+type paintJob struct {
+	paint       func(pc *watercolor.ProcessorContext) (image.Image, error)
+	layer       geojson.LayerType
+	what        string // used in error messages ("failed to paint <what>")
+	capture     string // debug stage name of the painted result
+	description string
+	zorder      int
+}
+
+// runPaintJobs runs jobs on up to workers goroutines and stores the results in painted.
+// Sequential jobs paint with the buffers of pc (nil = their own); concurrent jobs each paint
+// with their own watercolor buffers, so jobs never share mutable state. Results, debug
+// captures and errors are handled in job order, which keeps the output and the reported
+// error independent of scheduling.
+func runPaintJobs(jobs []paintJob, workers int, pc *watercolor.ProcessorContext, painted map[geojson.LayerType]image.Image, dc *DebugContext, tm *stageTimer) error {
+	results := make([]image.Image, len(jobs))
+	errs := make([]error, len(jobs))
+
+	if workers <= 1 || len(jobs) <= 1 {
+		for i, job := range jobs {
+			results[i], errs[i] = job.paint(pc)
+			if errs[i] != nil {
+				break
+			}
+			tm.mark("paint_" + string(job.layer))
+		}
+	} else {
+		sem := make(chan struct{}, workers)
+		var wg sync.WaitGroup
+		for i, job := range jobs {
+			wg.Add(1)
+			sem <- struct{}{}
+			go func() {
+				defer wg.Done()
+				defer func() { <-sem }()
+				results[i], errs[i] = job.paint(nil)
+			}()
+		}
+		wg.Wait()
+		tm.mark("paint_layers")
+	}
+
+	for i, job := range jobs {
+		if errs[i] != nil {
+			return fmt.Errorf("failed to paint %s: %w", job.what, errs[i])
+		}
+		painted[job.layer] = results[i]
+		dc.Capture(job.capture, job.description, results[i], job.zorder)
+	}
+	return nil
+}
+
+// paintLayerFunc paints a rendered layer from its own alpha mask.
+func paintLayerFunc(img image.Image, layer geojson.LayerType, params watercolor.Params) func(*watercolor.ProcessorContext) (image.Image, error) {
+	return func(pc *watercolor.ProcessorContext) (image.Image, error) {
+		return watercolor.PaintLayerWithContext(img, layer, params, pc)
+	}
+}
+
+// paintMaskFunc paints a layer from a precomputed base mask (e.g. one constrained to land).
+func paintMaskFunc(m *image.Gray, layer geojson.LayerType, params watercolor.Params) func(*watercolor.ProcessorContext) (image.Image, error) {
+	return func(pc *watercolor.ProcessorContext) (image.Image, error) {
+		return watercolor.PaintLayerFromMaskWithContext(m, layer, params, pc)
+	}
+}
+
+// processorContextKey is the context key of a worker's watercolor buffers (see
+// Generator.WorkerContext).
+type processorContextKey struct{}
+
+// WorkerContext returns ctx carrying watercolor buffers for one worker goroutine, which
+// paints its tiles one after another with the same buffers instead of allocating them for
+// every layer (see worker.WorkerContexter). The returned context must only be used by that
+// goroutine; layers painted concurrently (GeneratorOptions.PaintWorkers) still get their own.
+func (g *Generator) WorkerContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, processorContextKey{}, watercolor.NewProcessorContext(g.tileSize))
+}
+
+// workerProcessorContext returns the watercolor buffers of the worker running ctx, or nil.
+func workerProcessorContext(ctx context.Context) *watercolor.ProcessorContext {
+	pc, _ := ctx.Value(processorContextKey{}).(*watercolor.ProcessorContext)
+	return pc
+}
@@ -0,0 +1,137 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/MeKo-Tech/watercolormap/internal/geojson"
+	"github.com/MeKo-Tech/watercolormap/internal/watercolor"
+)
+
+// TestPaintAllLayersDeterministicAcrossWorkers paints the same synthetic layers sequentially
+// and concurrently and requires byte-identical results.
+func TestPaintAllLayersDeterministicAcrossWorkers(t *testing.T) {
+	gen := newCompositeTestGenerator(t, 256, GeneratorOptions{})
+	params := testParams(gen)
+	params.PerlinNoise = watercolor.GenerateNoise(params, 13, 100, 200)
+
+	rect := func(x0, y0, x1, y1 int) *image.NRGBA {
+		img := image.NewNRGBA(image.Rect(0, 0, params.TileSize, params.TileSize))
+		for y := y0; y < y1; y++ {
+			for x := x0; x < x1; x++ {
+				img.SetNRGBA(x, y, color.NRGBA{A: 255})
+			}
+		}
+		return img
+	}
+	c := params.TileSize / 2
+	raw := map[geojson.LayerType]image.Image{
+		geojson.LayerWater:     rect(c-60, c-60, c, c),
+		geojson.LayerRivers:    rect(c-70, c-4, c+70, c+4),
+		geojson.LayerRoads:     rect(c+10, c-80, c+16, c+80),
+		geojson.LayerHighways:  rect(c-80, c+30, c+80, c+38),
+		geojson.LayerParks:     rect(c+20, c-60, c+70, c-10),
+		geojson.LayerForest:    rect(c+30, c-50, c+60, c-20),
+		geojson.LayerUrban:     rect(c-60, c+40, c-10, c+70),
+		geojson.LayerBuildings: rect(c+30, c+45, c+50, c+60),
+	}
+
+	masks, err := buildMasks(raw, params, nil)
+	if err != nil {
+		t.Fatalf("buildMasks failed: %v", err)
+	}
+	sequential, err := paintAllLayers(raw, masks, params, gen.textures, false, 0, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("sequential paintAllLayers failed: %v", err)
+	}
+	concurrent, err := paintAllLayers(raw, masks, params, gen.textures, false, 4, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("concurrent paintAllLayers failed: %v", err)
+	}
+
+	if len(concurrent) != len(sequential) {
+		t.Fatalf("painted %d layers concurrently, %d sequentially", len(concurrent), len(sequential))
+	}
+	for layer, want := range sequential {
+		got, ok := concurrent[layer]
+		if !ok {
+			t.Errorf("layer %s missing from concurrent result", layer)
+			continue
+		}
+		if !bytes.Equal(got.(*image.NRGBA).Pix, want.(*image.NRGBA).Pix) {
+			t.Errorf("layer %s differs between sequential and concurrent painting", layer)
+		}
+	}
+}
+
+// TestPaintAllLayersReusedProcessorContext paints two tiles with one worker's buffers and
+// requires the same layers as painting with fresh buffers, with the first tile's layers left
+// intact by the second.
+func TestPaintAllLayersReusedProcessorContext(t *testing.T) {
+	gen := newCompositeTestGenerator(t, 128, GeneratorOptions{})
+	pc := workerProcessorContext(gen.WorkerContext(context.Background()))
+	if pc == nil {
+		t.Fatal("WorkerContext carries no processor context")
+	}
+
+	paintTile := func(x, y int, pc *watercolor.ProcessorContext) map[geojson.LayerType]image.Image {
+		t.Helper()
+		params := testParams(gen)
+		params.OffsetX, params.OffsetY = x*128-testPadPx, y*128-testPadPx
+		params.PerlinNoise = watercolor.GenerateNoise(params, 13, x, y)
+		raw := syntheticPainted(params.TileSize)
+		masks, err := buildMasks(raw, params, nil)
+		if err != nil {
+			t.Fatalf("buildMasks failed: %v", err)
+		}
+		painted, err := paintAllLayers(raw, masks, params, gen.textures, false, 0, pc, nil, nil)
+		if err != nil {
+			t.Fatalf("paintAllLayers failed: %v", err)
+		}
+		return painted
+	}
+
+	first := paintTile(100, 200, pc)
+	firstPix := make(map[geojson.LayerType][]byte, len(first))
+	for layer, img := range first {
+		firstPix[layer] = bytes.Clone(img.(*image.NRGBA).Pix)
+	}
+	second := paintTile(101, 200, pc)
+
+	for i, got := range []map[geojson.LayerType]image.Image{first, second} {
+		want := paintTile(100+i, 200, nil)
+		for layer, img := range want {
+			if !bytes.Equal(got[layer].(*image.NRGBA).Pix, img.(*image.NRGBA).Pix) {
+				t.Errorf("tile %d: layer %s differs from painting with fresh buffers", i, layer)
+			}
+		}
+	}
+	for layer, pix := range firstPix {
+		if !bytes.Equal(first[layer].(*image.NRGBA).Pix, pix) {
+			t.Errorf("layer %s of the first tile changed when the buffers were reused", layer)
+		}
+	}
+}
+
+func TestRunPaintJobsReportsFirstErrorInOrder(t *testing.T) {
+	ok := func(*watercolor.ProcessorContext) (image.Image, error) {
+		return image.NewNRGBA(image.Rect(0, 0, 1, 1)), nil
+	}
+	jobs := []paintJob{
+		{layer: geojson.LayerWater, what: "water", paint: ok},
+		{layer: geojson.LayerRoads, what: "roads", paint: func(*watercolor.ProcessorContext) (image.Image, error) { return nil, errors.New("boom") }},
+		{layer: geojson.LayerParks, what: "parks", paint: func(*watercolor.ProcessorContext) (image.Image, error) { return nil, errors.New("bang") }},
+	}
+
+	for _, workers := range []int{1, 3} {
+		err := runPaintJobs(jobs, workers, nil, map[geojson.LayerType]image.Image{}, nil, nil)
+		if err == nil || !strings.Contains(err.Error(), "failed to paint roads") {
+			t.Errorf("workers=%d: expected roads error, got %v", workers, err)
+		}
+	}
+}
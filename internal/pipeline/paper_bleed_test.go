@@ -0,0 +1,57 @@
+package pipeline
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MeKo-Tech/watercolormap/internal/geojson"
+)
+
+// TestPaperBleedGolden composites the synthetic lake tile with paper bleeding through the land
+// wash and compares it against a golden (set UPDATE_GOLDEN=1 to regenerate).
+func TestPaperBleedGolden(t *testing.T) {
+	goldenPath := filepath.Join("..", "..", "testdata", "golden", "pipeline-paper-bleed", "land_bleed.png")
+	debugDir := filepath.Join("..", "..", "testdata", "output", "pipeline-paper-bleed")
+
+	gen := newCompositeTestGenerator(t, 256, GeneratorOptions{})
+	params := testParams(gen)
+	_, painted := renderSyntheticLake(t, GeneratorOptions{})
+
+	encode := func(bleed float64) image.Image {
+		style := params.Styles[geojson.LayerLand]
+		style.PaperBleed = bleed
+		params.Styles[geojson.LayerLand] = style
+
+		var buf bytes.Buffer
+		pooledTile(t, gen, painted, params, &buf)
+		img, err := png.Decode(&buf)
+		if err != nil {
+			t.Fatalf("failed to decode tile: %v", err)
+		}
+		return img
+	}
+	plain := encode(0)
+	bled := encode(0.35)
+
+	// Land (corner) moves toward the lighter paper; the lake is untouched
+	_, plainG, _, _ := plain.At(0, 0).RGBA()
+	_, bledG, _, _ := bled.At(0, 0).RGBA()
+	if bledG <= plainG {
+		t.Errorf("expected land to lighten toward paper, green %d -> %d", plainG>>8, bledG>>8)
+	}
+	if plain.At(128, 128) != bled.At(128, 128) {
+		t.Errorf("expected lake center to be unaffected, got %v vs %v", plain.At(128, 128), bled.At(128, 128))
+	}
+
+	writePNG(t, filepath.Join(debugDir, "land_plain.png"), plain)
+	writePNG(t, filepath.Join(debugDir, "land_bleed.png"), bled)
+	if os.Getenv("UPDATE_GOLDEN") == "1" {
+		writePNG(t, goldenPath, bled)
+		return
+	}
+	assertImagesEqual(t, goldenPath, bled, "land_bleed")
+}
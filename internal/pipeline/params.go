@@ -0,0 +1,77 @@
+package pipeline
+
+import (
+	"fmt"
+	"image"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/MeKo-Tech/watercolormap/internal/geojson"
+	"github.com/MeKo-Tech/watercolormap/internal/texture"
+	"github.com/MeKo-Tech/watercolormap/internal/watercolor"
+)
+
+// resolveParamsTextures returns a copy of params whose styles have their Texture set from
+// TextureFile. Default texture file names map to the loaded textures; other names are
+// loaded from texturesDir.
+func resolveParamsTextures(params watercolor.Params, textures map[geojson.LayerType]image.Image, texturesDir string) (watercolor.Params, error) {
+	byFile := make(map[string]image.Image, len(texture.DefaultLayerTextures))
+	for layer, name := range texture.DefaultLayerTextures {
+		if img := textures[layer]; img != nil {
+			byFile[name] = img
+		}
+	}
+
+	params.Styles = maps.Clone(params.Styles)
+	for layer, style := range params.Styles {
+		if style.Texture != nil {
+			continue
+		}
+		img := byFile[style.TextureFile]
+		if img == nil {
+			if texturesDir == "" {
+				return watercolor.Params{}, fmt.Errorf("style %s: unknown texture %q (set a textures directory to load custom textures)", layer, style.TextureFile)
+			}
+			var err error
+			if img, err = texture.LoadTexture(filepath.Join(texturesDir, style.TextureFile)); err != nil {
+				return watercolor.Params{}, fmt.Errorf("style %s: %w", layer, err)
+			}
+			byFile[style.TextureFile] = img
+		}
+		style.Texture = img
+		params.Styles[layer] = style
+	}
+	return params, nil
+}
+
+// checkDefaultTextures reports the layers the default styles would paint without a texture,
+// e.g. when GeneratorOptions.Textures covers only some layers. Without the check they only
+// fail mid-render with a "texture is nil" paint error.
+func checkDefaultTextures(textures map[geojson.LayerType]image.Image) error {
+	var missing []string
+	for layer, style := range watercolor.DefaultParams(1, 0, textures).Styles {
+		if style.Texture == nil {
+			missing = append(missing, fmt.Sprintf("%s (%s)", layer, style.TextureFile))
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	slices.Sort(missing)
+	return fmt.Errorf("missing textures for layers %s", strings.Join(missing, ", "))
+}
+
+// baseParams returns the generator's watercolor parameters before zoom adjustments: the
+// configured GeneratorOptions.Params, or watercolor.DefaultParams.
+func (g *Generator) baseParams() watercolor.Params {
+	if g.params == nil {
+		return watercolor.DefaultParams(g.tileSize, g.seed, g.textures)
+	}
+	params := *g.params
+	params.Styles = maps.Clone(g.params.Styles)
+	params.TileSize = g.tileSize
+	params.Seed = g.seed
+	return params
+}
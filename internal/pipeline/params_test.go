@@ -0,0 +1,143 @@
+package pipeline
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MeKo-Tech/watercolormap/internal/geojson"
+	"github.com/MeKo-Tech/watercolormap/internal/texture"
+	"github.com/MeKo-Tech/watercolormap/internal/watercolor"
+)
+
+func TestResolveParamsTextures(t *testing.T) {
+	water := image.NewNRGBA(image.Rect(0, 0, 2, 2))
+	textures := map[geojson.LayerType]image.Image{geojson.LayerWater: water}
+
+	dir := t.TempDir()
+	custom := image.NewNRGBA(image.Rect(0, 0, 3, 3))
+	custom.Set(0, 0, color.NRGBA{R: 200, A: 255})
+	f, err := os.Create(filepath.Join(dir, "custom.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := png.Encode(f, custom); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	params := watercolor.Params{Styles: map[geojson.LayerType]watercolor.LayerStyle{
+		geojson.LayerWater:  {Layer: geojson.LayerWater, TextureFile: "water.png"},
+		geojson.LayerRivers: {Layer: geojson.LayerRivers, TextureFile: "custom.png"},
+	}}
+
+	got, err := resolveParamsTextures(params, textures, dir)
+	if err != nil {
+		t.Fatalf("resolveParamsTextures: %v", err)
+	}
+	if got.Styles[geojson.LayerWater].Texture != image.Image(water) {
+		t.Error("water.png should resolve to the loaded water texture")
+	}
+	if tex := got.Styles[geojson.LayerRivers].Texture; tex == nil || tex.Bounds().Dx() != 3 {
+		t.Errorf("custom.png should be loaded from the textures dir, got %v", tex)
+	}
+	if params.Styles[geojson.LayerWater].Texture != nil {
+		t.Error("input params should not be modified")
+	}
+
+	if _, err := resolveParamsTextures(params, textures, ""); err == nil {
+		t.Error("expected an error for a custom texture without a textures dir")
+	}
+}
+
+func TestNewGeneratorTextureErrors(t *testing.T) {
+	// A textures directory missing the water texture fails at construction, naming the layer
+	dir := t.TempDir()
+	for layer, name := range texture.DefaultLayerTextures {
+		if layer == geojson.LayerWater {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join("..", "..", "assets", "textures", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err := NewGenerator(nil, "", dir, t.TempDir(), 256, 1, false, nil, GeneratorOptions{})
+	var loadErr *texture.TextureLoadError
+	if !errors.As(err, &loadErr) {
+		t.Fatalf("expected a *texture.TextureLoadError, got %v", err)
+	}
+	if _, ok := loadErr.Failed[geojson.LayerWater]; !ok || len(loadErr.Failed) != 1 {
+		t.Errorf("Failed = %v, want only water", loadErr.Failed)
+	}
+
+	// Supplied textures must cover every layer the default styles paint
+	partial := map[geojson.LayerType]image.Image{geojson.LayerLand: image.NewNRGBA(image.Rect(0, 0, 2, 2))}
+	_, err = NewGenerator(nil, "", "", t.TempDir(), 256, 1, false, nil, GeneratorOptions{Textures: partial})
+	if err == nil || !strings.Contains(err.Error(), "water (water.png)") || strings.Contains(err.Error(), "land") {
+		t.Errorf("error = %v, want the missing layers without land", err)
+	}
+}
+
+func TestCompositeOrderFromParams(t *testing.T) {
+	const size = 64
+	opaque := func(c color.NRGBA) *image.NRGBA {
+		img := image.NewNRGBA(image.Rect(0, 0, size, size))
+		for i := 0; i < len(img.Pix); i += 4 {
+			img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = c.R, c.G, c.B, c.A
+		}
+		return img
+	}
+	waterColor := color.NRGBA{R: 80, G: 140, B: 200, A: 255}
+	parksColor := color.NRGBA{R: 120, G: 180, B: 90, A: 255}
+
+	tests := []struct {
+		name  string
+		order []geojson.LayerType
+		want  color.NRGBA
+	}{
+		{"default", nil, waterColor},
+		{"parks over water", []geojson.LayerType{
+			geojson.LayerLand, geojson.LayerForest, geojson.LayerRivers, geojson.LayerWater, geojson.LayerParks,
+			geojson.LayerRoads, geojson.LayerHighways, geojson.LayerBuildings, geojson.LayerUrban,
+		}, parksColor},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := watercolor.DefaultParams(0, 0, nil)
+			if tt.order != nil {
+				params.CompositeOrder = tt.order
+			}
+			gen := newCompositeTestGenerator(t, size, GeneratorOptions{Params: &params})
+			painted := map[geojson.LayerType]image.Image{
+				geojson.LayerWater: opaque(waterColor),
+				geojson.LayerParks: opaque(parksColor),
+			}
+
+			composited, err := gen.compositeLayers(painted, gen.baseParams(), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer metatileBuffers.put(composited)
+			if got := composited.NRGBAAt(size/2, size/2); got != tt.want {
+				t.Errorf("top pixel = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// Every styled layer must be composited
+	params := watercolor.DefaultParams(0, 0, nil)
+	params.CompositeOrder = []geojson.LayerType{geojson.LayerLand, geojson.LayerWater}
+	_, err := NewGenerator(nil, "", filepath.Join("..", "..", "assets", "textures"), t.TempDir(), size, 1, false, nil, GeneratorOptions{Params: &params})
+	if err == nil || !strings.Contains(err.Error(), "missing layers") {
+		t.Errorf("error = %v, want missing layers", err)
+	}
+}
@@ -17,7 +17,7 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
-// Test function with three subtests
+// Test function with four subtests
 func TestPipelineStages(t *testing.T) {
 	t.Run("Synthetic", func(t *testing.T) {
 		ds := &syntheticDataSource{}
@@ -25,6 +25,14 @@ func TestPipelineStages(t *testing.T) {
 		runPipelineStagesTest(t, "synthetic", ds, coords)
 	})
 
+	t.Run("Fixture_z16", func(t *testing.T) {
+		// Checked-in Overpass fixture: the full pipeline on OSM-like data, without network
+		ds, err := datasource.NewFileOverpassDataSource(filepath.Join("..", "..", "testdata", "overpass", "z16_x34540_y21540.json"))
+		require.NoError(t, err)
+		coords := tile.NewCoords(16, 34540, 21540)
+		runPipelineStagesTest(t, "fixture_z16_x34540_y21540", ds, coords)
+	})
+
 	t.Run("Hannover_z13", func(t *testing.T) {
 		requireIntegration(t)
 		// Use real Overpass data source (requires network)
@@ -86,7 +94,7 @@ func runPipelineStagesTest(t *testing.T, caseName string, ds DataSource, coords
 		if update {
 			writePNG(t, goldenPath, stage.Image)
 		} else {
-			require.FileExists(t, goldenPath, "golden file missing: %s", stage.Name)
+			require.FileExists(t, goldenPath, "golden file missing: %s (run with UPDATE_GOLDEN=1 to record it)", stage.Name)
 			assertImagesEqual(t, goldenPath, stage.Image, stage.Name)
 		}
 	}
@@ -0,0 +1,95 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"os"
+
+	"github.com/MeKo-Tech/watercolormap/internal/tile"
+)
+
+// PyramidGenerator adapts a Generator so that each Generate call builds the tile from its
+// four already written children one zoom deeper (see tile.Downsample) instead of rendering
+// it. Building a pyramid bottom-up from rendered base tiles is much faster than rendering
+// every zoom, but the parents differ from native renders: the watercolor texture, noise and
+// edge widths are those of the base zoom, shrunk, and features the zoom filter drops at the
+// parent's zoom (e.g. buildings, minor roads) stay visible. It satisfies worker.Generator.
+//
+// The children are read back from the output folder, so it requires a generator without a
+// TileWriter.
+type PyramidGenerator struct {
+	gen *Generator
+}
+
+// NewPyramidGenerator wraps gen to derive tiles from their children.
+func NewPyramidGenerator(gen *Generator) *PyramidGenerator {
+	return &PyramidGenerator{gen: gen}
+}
+
+// Generate builds the tile at coords from its children and returns its path. Children that
+// don't exist count as transparent. Unless force is set, existing tiles are skipped.
+func (p *PyramidGenerator) Generate(ctx context.Context, coords tile.Coords, force bool, filenameSuffix string, debugCtx interface{}) (string, string, error) {
+	g := p.gen
+	if g.options.TileWriter != nil {
+		return "", "", fmt.Errorf("building tiles from their children requires folder output")
+	}
+	if err := ctx.Err(); err != nil {
+		return "", "", err
+	}
+
+	finalPath, tileDir := g.tilePath(coords, filenameSuffix)
+	if !force {
+		if _, err := os.Stat(finalPath); err == nil {
+			g.log().Info("Tile already exists; skipping", "coords", coords.String(), "path", finalPath)
+			return finalPath, "", nil
+		}
+	}
+
+	var children [4]*image.NRGBA
+	for i := range children {
+		child := tile.NewCoords(coords.Z+1, 2*coords.X+uint32(i%2), 2*coords.Y+uint32(i/2))
+		img, err := p.readTile(child, filenameSuffix)
+		if err != nil {
+			return "", "", err
+		}
+		children[i] = img
+	}
+
+	final := tile.Downsample(children)
+	if final == nil {
+		g.log().Warn("No child tiles to build tile from; skipping", "coords", coords.String())
+		return "", "", nil
+	}
+
+	if err := os.MkdirAll(tileDir, 0o755); err != nil {
+		return "", "", fmt.Errorf("failed to create output dir: %w", err)
+	}
+	if err := g.writeTile(final, coords, finalPath); err != nil {
+		return "", "", err
+	}
+	return finalPath, "", nil
+}
+
+// readTile decodes a written tile, returning nil if it doesn't exist.
+func (p *PyramidGenerator) readTile(coords tile.Coords, filenameSuffix string) (*image.NRGBA, error) {
+	path, _ := p.gen.tilePath(coords, filenameSuffix)
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open child tile: %w", err)
+	}
+	defer f.Close() // nolint:errcheck
+
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode child tile %s: %w", path, err)
+	}
+	if nrgba, ok := img.(*image.NRGBA); ok {
+		return nrgba, nil
+	}
+	return cropNRGBA(img, img.Bounds()), nil
+}
@@ -0,0 +1,81 @@
+package pipeline
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"os"
+	"testing"
+
+	"github.com/MeKo-Tech/watercolormap/internal/tile"
+)
+
+func TestPyramidGenerator(t *testing.T) {
+	gen := newCompositeTestGenerator(t, 16, GeneratorOptions{})
+	parent := tile.NewCoords(10, 4, 6)
+
+	// Write three of the four children; the bottom-right one is missing
+	colors := []color.NRGBA{{R: 200, A: 255}, {G: 200, A: 255}, {B: 200, A: 255}}
+	for i, c := range colors {
+		img := image.NewNRGBA(image.Rect(0, 0, 16, 16))
+		for p := 0; p < len(img.Pix); p += 4 {
+			img.Pix[p], img.Pix[p+1], img.Pix[p+2], img.Pix[p+3] = c.R, c.G, c.B, c.A
+		}
+		child := tile.NewCoords(11, 8+uint32(i%2), 12+uint32(i/2))
+		path, _ := gen.tilePath(child, "")
+		if err := gen.writeTile(img, child, path); err != nil {
+			t.Fatalf("failed to write child: %v", err)
+		}
+	}
+
+	pyramid := NewPyramidGenerator(gen)
+	path, _, err := pyramid.Generate(context.Background(), parent, false, "", nil)
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	img, err := readPNG(path)
+	if err != nil {
+		t.Fatalf("failed to read parent: %v", err)
+	}
+	if img.Bounds() != image.Rect(0, 0, 16, 16) {
+		t.Fatalf("parent bounds = %v, want the tile size", img.Bounds())
+	}
+
+	quadrants := []struct {
+		x, y int
+		want color.NRGBA
+	}{
+		{2, 2, colors[0]},
+		{12, 2, colors[1]},
+		{2, 12, colors[2]},
+		{12, 12, color.NRGBA{}}, // Missing child
+	}
+	for _, q := range quadrants {
+		if got := color.NRGBAModel.Convert(img.At(q.x, q.y)).(color.NRGBA); got != q.want {
+			t.Errorf("pixel (%d,%d) = %+v, want %+v", q.x, q.y, got, q.want)
+		}
+	}
+
+	// Existing parents are kept unless forced
+	if err := os.WriteFile(path, []byte("stale"), 0o644); err != nil {
+		t.Fatalf("failed to overwrite parent: %v", err)
+	}
+	if _, _, err := pyramid.Generate(context.Background(), parent, false, "", nil); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if b, _ := os.ReadFile(path); string(b) != "stale" {
+		t.Error("existing parent was rebuilt without force")
+	}
+	if _, _, err := pyramid.Generate(context.Background(), parent, true, "", nil); err != nil {
+		t.Fatalf("forced Generate failed: %v", err)
+	}
+	if _, err := readPNG(path); err != nil {
+		t.Errorf("forced Generate did not rebuild the parent: %v", err)
+	}
+
+	// No children: nothing to write
+	path, _, err = pyramid.Generate(context.Background(), tile.NewCoords(10, 0, 0), false, "", nil)
+	if err != nil || path != "" {
+		t.Errorf("Generate without children = %q, %v; want no tile", path, err)
+	}
+}
@@ -0,0 +1,7 @@
+//go:build race
+
+package pipeline
+
+// raceEnabled reports whether the tests run under the race detector, whose instrumentation
+// inflates allocations.
+const raceEnabled = true
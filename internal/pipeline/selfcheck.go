@@ -0,0 +1,55 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/MeKo-Tech/watercolormap/internal/tile"
+	"github.com/MeKo-Tech/watercolormap/internal/types"
+)
+
+// selfCheckCoords is an arbitrary tile used for the synthetic readiness render.
+var selfCheckCoords = tile.Coords{Z: 13, X: 4317, Y: 2692}
+
+// SelfCheck renders, paints, and composites a synthetic tile with no features.
+// It exercises Mapnik, the layer styles, and the loaded textures without touching
+// the datasource or writing any tile to disk, so it is safe to use as a readiness probe.
+func (g *Generator) SelfCheck(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	data := &types.TileData{
+		Source: "selfcheck",
+		Coordinate: types.TileCoordinate{
+			Zoom: int(selfCheckCoords.Z),
+			X:    int(selfCheckCoords.X),
+			Y:    int(selfCheckCoords.Y),
+		},
+	}
+
+	renderResult, err := g.renderLayersWithData(ctx, selfCheckCoords, 1, nil, nil, data)
+	if err != nil {
+		return err
+	}
+	defer renderResult.releaseNoise()
+	defer os.RemoveAll(renderResult.layerDir) // nolint:errcheck
+
+	masks, err := g.tileMasks(renderResult.rawLayers, renderResult.params, nil)
+	if err != nil {
+		return fmt.Errorf("failed to build masks: %w", err)
+	}
+
+	painted, err := paintAllLayers(renderResult.rawLayers, masks, renderResult.params, g.textures, g.options.TransparentBackground, g.options.PaintWorkers, nil, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	composited, err := g.compositeLayers(painted, renderResult.params, nil)
+	if err != nil {
+		return err
+	}
+	metatileBuffers.put(composited)
+	return nil
+}
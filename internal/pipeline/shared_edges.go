@@ -0,0 +1,67 @@
+package pipeline
+
+import (
+	"image"
+	"slices"
+
+	"github.com/MeKo-Tech/watercolormap/internal/geojson"
+	"github.com/MeKo-Tech/watercolormap/internal/mask"
+	"github.com/MeKo-Tech/watercolormap/internal/watercolor"
+)
+
+// sharedEdgeLayers are the layers whose common boundaries are darkened once with
+// GeneratorOptions.SharedEdges. Water and land keep their shores on both sides.
+var sharedEdgeLayers = []geojson.LayerType{
+	geojson.LayerParks,
+	geojson.LayerForest,
+	geojson.LayerUrban,
+	geojson.LayerBuildings,
+	geojson.LayerRoads,
+	geojson.LayerHighways,
+}
+
+// sharedEdgeReachPx widens the neighbors of a layer so the thin strip of paper the mask
+// pipeline leaves between abutting layers (e.g. parks cut back from a road with the land)
+// still counts as a shared boundary.
+const sharedEdgeReachPx = 3
+
+// shareEdges records, for each layer of sharedEdgeLayers, the coverage of the shared-edge
+// layers composited above it (see watercolor.PaintLayerFromMaskSharingEdges). Layers without
+// a painted neighbor above get none.
+func (m *maskSet) shareEdges(rawLayers map[geojson.LayerType]image.Image, params watercolor.Params, dc *DebugContext) {
+	var above *image.Gray
+	order := params.Order()
+	for i := len(order) - 1; i >= 0; i-- {
+		layer := order[i]
+		img := rawLayers[layer]
+		if img == nil || !slices.Contains(sharedEdgeLayers, layer) || params.Styles[layer].Texture == nil {
+			continue
+		}
+		if above != nil {
+			if m.edgeNeighbors == nil {
+				m.edgeNeighbors = make(map[geojson.LayerType]*image.Gray)
+			}
+			m.edgeNeighbors[layer] = above
+			dc.Capture("05_"+string(layer)+"_edge_neighbors", "Layers above "+string(layer)+" that own their shared edges", above, 5)
+		}
+
+		alpha := mask.Dilate(mask.ExtractAlphaMask(img), sharedEdgeReachPx)
+		if above == nil {
+			above = alpha
+		} else {
+			above = mask.MaxMasks(above, alpha)
+		}
+	}
+}
+
+// paintSharingFunc paints a layer from a base mask, leaving the edges it shares with the
+// layers above it to them when GeneratorOptions.SharedEdges recorded any.
+func (m *maskSet) paintSharingFunc(base *image.Gray, layer geojson.LayerType, params watercolor.Params) func(*watercolor.ProcessorContext) (image.Image, error) {
+	neighbors := m.edgeNeighbors[layer]
+	if neighbors == nil {
+		return paintMaskFunc(base, layer, params)
+	}
+	return func(pc *watercolor.ProcessorContext) (image.Image, error) {
+		return watercolor.PaintLayerFromMaskSharingEdgesWithContext(base, neighbors, layer, params, pc)
+	}
+}
@@ -0,0 +1,106 @@
+package pipeline
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MeKo-Tech/watercolormap/internal/geojson"
+	"github.com/MeKo-Tech/watercolormap/internal/watercolor"
+)
+
+// renderParkAlongRoad renders a tile with a park (tile x 40-128) whose right side abuts a
+// vertical road (tile x 128-140).
+func renderParkAlongRoad(t *testing.T, opts GeneratorOptions) image.Image {
+	t.Helper()
+	gen := newCompositeTestGenerator(t, 256, opts)
+	params := testParams(gen)
+	params.PerlinNoise = watercolor.GenerateNoise(params, 13, 100, 200)
+
+	size := params.TileSize
+	parks := image.NewNRGBA(image.Rect(0, 0, size, size))
+	roads := image.NewNRGBA(image.Rect(0, 0, size, size))
+	seam := testPadPx + 128
+	for y := 0; y < size; y++ {
+		for x := testPadPx + 40; x < seam; x++ {
+			parks.SetNRGBA(x, y, color.NRGBA{G: 255, A: 255})
+		}
+		for x := seam; x < seam+12; x++ {
+			roads.SetNRGBA(x, y, color.NRGBA{R: 255, G: 255, B: 255, A: 255})
+		}
+	}
+	raw := map[geojson.LayerType]image.Image{geojson.LayerParks: parks, geojson.LayerRoads: roads}
+
+	masks, err := gen.tileMasks(raw, params, nil)
+	if err != nil {
+		t.Fatalf("tileMasks failed: %v", err)
+	}
+	painted, err := paintAllLayers(raw, masks, params, gen.textures, false, 0, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("paintAllLayers failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	pooledTile(t, gen, painted, params, &buf)
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatalf("failed to decode tile: %v", err)
+	}
+	return img
+}
+
+// TestSharedEdgesGolden checks that where a park abuts a road, the seam is no darker than the
+// park's and the road's own edges, and compares the tile against a golden (set
+// UPDATE_GOLDEN=1 to regenerate).
+func TestSharedEdgesGolden(t *testing.T) {
+	goldenPath := filepath.Join("..", "..", "testdata", "golden", "pipeline-shared-edges", "park_road.png")
+	debugDir := filepath.Join("..", "..", "testdata", "output", "pipeline-shared-edges")
+
+	plain := renderParkAlongRoad(t, GeneratorOptions{})
+	shared := renderParkAlongRoad(t, GeneratorOptions{SharedEdges: true})
+
+	// Mean luma of a tile column; the synthetic features are vertical stripes
+	column := func(img image.Image, x int) int {
+		sum := 0
+		for y := 0; y < 256; y++ {
+			r, g, b, _ := img.At(x, y).RGBA()
+			sum += int(299*(r>>8)+587*(g>>8)+114*(b>>8)) / 1000
+		}
+		return sum / 256
+	}
+	darkest := func(img image.Image, from, to int) int {
+		d := 255
+		for x := from; x < to; x++ {
+			d = min(d, column(img, x))
+		}
+		return d
+	}
+
+	// The park's left edge and the road's right edge border paper, not another layer
+	edge := min(darkest(plain, 36, 50), darkest(plain, 139, 146))
+	if got := darkest(plain, 114, 134); got >= edge-2 {
+		t.Errorf("expected the seam to stack darker than the layers' own edges without SharedEdges: %d vs %d", got, edge)
+	}
+	if got := darkest(shared, 114, 134); got < edge-2 {
+		t.Errorf("seam darker than the layers' own edges: %d vs %d", got, edge)
+	}
+
+	// Only the park side of the seam changes; free edges and the road keep their darkening
+	for _, x := range []int{40, 80, 130, 142, 200} {
+		if p, s := column(plain, x), column(shared, x); p != s {
+			t.Errorf("column %d changed away from the seam: %d -> %d", x, p, s)
+		}
+	}
+
+	writePNG(t, filepath.Join(debugDir, "park_road_plain.png"), plain)
+	writePNG(t, filepath.Join(debugDir, "park_road.png"), shared)
+	if os.Getenv("UPDATE_GOLDEN") == "1" {
+		writePNG(t, goldenPath, shared)
+		return
+	}
+	assertImagesEqual(t, goldenPath, shared, "park_road")
+}
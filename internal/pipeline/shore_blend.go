@@ -0,0 +1,89 @@
+package pipeline
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/MeKo-Tech/watercolormap/internal/geojson"
+	"github.com/MeKo-Tech/watercolormap/internal/mask"
+	"github.com/MeKo-Tech/watercolormap/internal/watercolor"
+)
+
+// shoreBlend holds the masks that cross-fade water into land over a band of
+// watercolor.Params.ShoreBlendPx pixels on either side of the shoreline. Land is painted on
+// under the water side of the band and water over the land side, and the painted water then
+// fades out by weight, so the shore is a soft bleed instead of two darkened edges meeting.
+type shoreBlend struct {
+	landMask  *image.Gray // Final land mask, extended under the water side of the band
+	waterMask *image.Gray // Final water mask, extended over the land side of the band
+	weight    *image.Gray // Water opacity: 255 from ShoreBlendPx inside the water, 0 from ShoreBlendPx outside
+}
+
+// newShoreBlend derives the blend from the signed distance to the processed water outline.
+// landMask is the processed land mask; others is the rest of the non-land union (rivers and
+// roads), whose holes in the land stay open inside the band.
+func newShoreBlend(waterBase, landMask, others *image.Gray, params watercolor.Params) (*shoreBlend, error) {
+	waterFinal, err := watercolor.ProcessLayerMask(waterBase, geojson.LayerWater, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to process water mask: %w", err)
+	}
+
+	width := params.ShoreBlendPx
+	inside := image.NewGray(waterFinal.Bounds())
+	for i, v := range waterFinal.Pix {
+		if v >= 128 {
+			inside.Pix[i] = 255
+		}
+	}
+	// Both distance fields are 0 on their side of the outline and 255 at width or more
+	toShore := mask.EuclideanDistanceTransform(inside, width)
+	fromShore := mask.EuclideanDistanceTransform(mask.InvertMask(inside), width)
+
+	b := &shoreBlend{
+		landMask:  image.NewGray(waterFinal.Bounds()),
+		waterMask: image.NewGray(waterFinal.Bounds()),
+		weight:    image.NewGray(waterFinal.Bounds()),
+	}
+	for i := range inside.Pix {
+		// Signed distance to the shoreline in pixels, negative in the water; the outline
+		// lies between the two edge pixels
+		var d float64
+		if inside.Pix[i] != 0 {
+			d = -(float64(toShore.Pix[i])*width/255 + 0.5)
+		} else {
+			d = float64(fromShore.Pix[i])*width/255 + 0.5
+		}
+
+		t := min(max((width-d)/(2*width), 0), 1)
+		b.weight.Pix[i] = uint8(255*t*t*(3-2*t) + 0.5)
+
+		b.waterMask.Pix[i] = waterFinal.Pix[i]
+		if d < width {
+			b.waterMask.Pix[i] = 255
+		}
+		b.landMask.Pix[i] = landMask.Pix[i]
+		if d > -width && others.Pix[i] == 0 {
+			b.landMask.Pix[i] = 255
+		}
+	}
+	return b, nil
+}
+
+// paintWater paints water over the extended mask and fades it out across the band.
+func (b *shoreBlend) paintWater(params watercolor.Params) func(*watercolor.ProcessorContext) (image.Image, error) {
+	return func(pc *watercolor.ProcessorContext) (image.Image, error) {
+		painted, err := watercolor.PaintLayerFromFinalMaskWithContext(b.waterMask, geojson.LayerWater, params, pc)
+		if err != nil {
+			return nil, err
+		}
+		bounds := painted.Bounds()
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			row := painted.Pix[painted.PixOffset(bounds.Min.X, y):painted.PixOffset(bounds.Max.X, y)]
+			weights := b.weight.Pix[b.weight.PixOffset(bounds.Min.X, y):]
+			for x := 0; x < len(row)/4; x++ {
+				row[x*4+3] = uint8((int(row[x*4+3])*int(weights[x]) + 127) / 255)
+			}
+		}
+		return painted, nil
+	}
+}
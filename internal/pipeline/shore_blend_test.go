@@ -0,0 +1,113 @@
+package pipeline
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MeKo-Tech/watercolormap/internal/geojson"
+	"github.com/MeKo-Tech/watercolormap/internal/watercolor"
+)
+
+// renderCoast renders a tile with water on its left half (tile x 0-128) and land on the
+// right.
+func renderCoast(t *testing.T, shoreBlendPx float64) image.Image {
+	t.Helper()
+	gen := newCompositeTestGenerator(t, 256, GeneratorOptions{})
+	params := testParams(gen)
+	params.ShoreBlendPx = shoreBlendPx
+	params.PerlinNoise = watercolor.GenerateNoise(params, 13, 100, 200)
+
+	size := params.TileSize
+	water := image.NewNRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		for x := 0; x < testPadPx+128; x++ {
+			water.SetNRGBA(x, y, color.NRGBA{B: 255, A: 255})
+		}
+	}
+	raw := map[geojson.LayerType]image.Image{geojson.LayerWater: water}
+
+	masks, err := gen.tileMasks(raw, params, nil)
+	if err != nil {
+		t.Fatalf("tileMasks failed: %v", err)
+	}
+	painted, err := paintAllLayers(raw, masks, params, gen.textures, false, 0, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("paintAllLayers failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	pooledTile(t, gen, painted, params, &buf)
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatalf("failed to decode tile: %v", err)
+	}
+	return img
+}
+
+// TestShoreBlendGolden checks that ShoreBlendPx turns the shoreline into a gradual band
+// without the darkened seam of the hard edges, and compares the tile against a golden (set
+// UPDATE_GOLDEN=1 to regenerate).
+func TestShoreBlendGolden(t *testing.T) {
+	goldenPath := filepath.Join("..", "..", "testdata", "golden", "pipeline-shore-blend", "coast.png")
+	debugDir := filepath.Join("..", "..", "testdata", "output", "pipeline-shore-blend")
+
+	const blendPx = 12
+	plain := renderCoast(t, 0)
+	blended := renderCoast(t, blendPx)
+
+	// Mean luma of a tile column; the coastline is vertical
+	column := func(img image.Image, x int) int {
+		sum := 0
+		for y := 0; y < 256; y++ {
+			r, g, b, _ := img.At(x, y).RGBA()
+			sum += int(299*(r>>8)+587*(g>>8)+114*(b>>8)) / 1000
+		}
+		return sum / 256
+	}
+	// Darkest column at the shore relative to the darker of the open water and the land
+	seam := func(img image.Image) int {
+		d := 255
+		for x := 128 - 2*blendPx; x < 128+2*blendPx; x++ {
+			d = min(d, column(img, x))
+		}
+		return min(column(img, 60), column(img, 200)) - d
+	}
+	if got := seam(plain); got < 8 {
+		t.Errorf("expected the hard shoreline to darken by its edges: %d", got)
+	}
+	if got := seam(blended); got > 4 {
+		t.Errorf("shore blend left a seam %d darker than water and land", got)
+	}
+
+	// The band changes gradually instead of stepping at the shoreline
+	steepest := func(img image.Image) int {
+		s := 0
+		for x := 128 - 2*blendPx; x < 128+2*blendPx; x++ {
+			s = max(s, abs(column(img, x+1)-column(img, x)))
+		}
+		return s
+	}
+	if p, b := steepest(plain), steepest(blended); b*2 > p {
+		t.Errorf("expected a softer transition with ShoreBlendPx: steepest step %d vs %d", b, p)
+	}
+
+	// Away from the band the tile is unchanged
+	for _, x := range []int{20, 200} {
+		if p, b := column(plain, x), column(blended, x); p != b {
+			t.Errorf("column %d changed away from the shore: %d -> %d", x, p, b)
+		}
+	}
+
+	writePNG(t, filepath.Join(debugDir, "coast_plain.png"), plain)
+	writePNG(t, filepath.Join(debugDir, "coast.png"), blended)
+	if os.Getenv("UPDATE_GOLDEN") == "1" {
+		writePNG(t, goldenPath, blended)
+		return
+	}
+	assertImagesEqual(t, goldenPath, blended, "coast")
+}
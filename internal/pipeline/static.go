@@ -0,0 +1,100 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/draw"
+	"math"
+
+	"github.com/MeKo-Tech/watercolormap/internal/tile"
+	"github.com/MeKo-Tech/watercolormap/internal/types"
+)
+
+// RenderStatic renders bounds at the given zoom as a single image (a static map) by rendering
+// every tile that covers it and stitching them together. The image is cropped to the exact
+// bounds rather than whole tiles, so its size is that of the bounds in pixels at zoom.
+//
+// Unlike RenderArea, which runs the pipeline once over a free-form canvas, the pixels are
+// those of regular tiles, so a static map matches the tiles served for the same zoom.
+func (g *Generator) RenderStatic(ctx context.Context, bounds types.BoundingBox, zoom int) (*image.NRGBA, error) {
+	if zoom < 0 || zoom > 30 {
+		return nil, fmt.Errorf("invalid zoom level %d", zoom)
+	}
+	if bounds.MinLon >= bounds.MaxLon || bounds.MinLat >= bounds.MaxLat {
+		return nil, fmt.Errorf("invalid bounds %s", bounds.String())
+	}
+
+	rect := staticPixelRect(bounds, zoom, g.tileSize)
+	if rect.Empty() {
+		return nil, fmt.Errorf("bounds %s are smaller than a pixel at zoom %d", bounds.String(), zoom)
+	}
+	g.log().Info("Rendering static map", "bounds", bounds.String(), "zoom", zoom, "width", rect.Dx(), "height", rect.Dy())
+
+	return stitchTiles(ctx, rect, zoom, g.tileSize, g.renderTileImage)
+}
+
+// staticPixelRect returns bounds in global pixel coordinates at zoom for tiles of tileSize
+// pixels, rounded to whole pixels and clipped to the world.
+func staticPixelRect(bounds types.BoundingBox, zoom, tileSize int) image.Rectangle {
+	worldPx := math.Exp2(float64(zoom)) * float64(tileSize)
+	minX, minY := tile.LonLatToMercator(bounds.MinLon, bounds.MinLat)
+	maxX, maxY := tile.LonLatToMercator(bounds.MaxLon, bounds.MaxLat)
+
+	// Global pixel coordinates grow east and south from the top-left corner of the world
+	px := func(x float64) int { return int(math.Round((x + webMercatorExtent/2) / webMercatorExtent * worldPx)) }
+	py := func(y float64) int { return int(math.Round((webMercatorExtent/2 - y) / webMercatorExtent * worldPx)) }
+	rect := image.Rect(px(minX), py(maxY), px(maxX), py(minY))
+	return rect.Intersect(image.Rect(0, 0, int(worldPx), int(worldPx)))
+}
+
+// stitchTiles renders the tiles covering rect (in global pixel coordinates) with renderTile
+// and copies them into an image of rect's size, cropping the tiles along the edges.
+func stitchTiles(
+	ctx context.Context,
+	rect image.Rectangle,
+	zoom, tileSize int,
+	renderTile func(context.Context, tile.Coords) (*image.NRGBA, error),
+) (*image.NRGBA, error) {
+	out := image.NewNRGBA(image.Rect(0, 0, rect.Dx(), rect.Dy()))
+	for ty := rect.Min.Y / tileSize; ty*tileSize < rect.Max.Y; ty++ {
+		for tx := rect.Min.X / tileSize; tx*tileSize < rect.Max.X; tx++ {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			coords := tile.NewCoords(uint32(zoom), uint32(tx), uint32(ty))
+			img, err := renderTile(ctx, coords)
+			if err != nil {
+				return nil, fmt.Errorf("failed to render tile %s: %w", coords.String(), err)
+			}
+
+			// draw.Draw clips the tile to the output, which crops it at the edges
+			origin := image.Pt(tx*tileSize, ty*tileSize).Sub(rect.Min)
+			draw.Draw(out, image.Rectangle{Min: origin, Max: origin.Add(img.Bounds().Size())}, img, img.Bounds().Min, draw.Src)
+		}
+	}
+	return out, nil
+}
+
+// renderTileImage renders a single tile like Generate and returns it as an image instead of
+// writing it.
+func (g *Generator) renderTileImage(ctx context.Context, coords tile.Coords) (*image.NRGBA, error) {
+	tm := g.newStageTimer()
+	renderResult, painted, err := g.renderAndPaint(ctx, coords, nil, tm, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	composited, err := g.compositeLayers(painted, renderResult.params, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer metatileBuffers.put(composited)
+	tm.mark("composite")
+
+	// Copy the tile out so the pooled composite can be recycled
+	padPx := renderResult.padPx
+	final := cropNRGBA(composited, image.Rect(padPx, padPx, padPx+g.tileSize, padPx+g.tileSize))
+	tm.log(g.log(), coords)
+	return final, nil
+}
@@ -0,0 +1,84 @@
+package pipeline
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/MeKo-Tech/watercolormap/internal/tile"
+	"github.com/MeKo-Tech/watercolormap/internal/types"
+)
+
+func TestStaticPixelRect(t *testing.T) {
+	const tileSize = 256
+	rect := staticPixelRect(tileBBox(13, 4317, 2692), 13, tileSize)
+	want := image.Rect(4317*tileSize, 2692*tileSize, 4318*tileSize, 2693*tileSize)
+	if rect != want {
+		t.Errorf("tile bounds map to %v, want %v", rect, want)
+	}
+}
+
+// TestStitchTilesCropsToBounds stitches a static map whose bounds start and end inside the
+// tiles of a 2×2 block and checks that every pixel comes from the right place of its tile.
+func TestStitchTilesCropsToBounds(t *testing.T) {
+	const zoom, tileSize = 13, 64
+	const x0, y0 = 4317, 2692
+
+	// Each tile encodes its column and row in red and blue and the pixel position in green and
+	// alpha, so any misplaced or missing tile shows up
+	var rendered []tile.Coords
+	renderTile := func(_ context.Context, c tile.Coords) (*image.NRGBA, error) {
+		rendered = append(rendered, c)
+		img := image.NewNRGBA(image.Rect(0, 0, tileSize, tileSize))
+		for y := 0; y < tileSize; y++ {
+			for x := 0; x < tileSize; x++ {
+				img.SetNRGBA(x, y, color.NRGBA{R: uint8(c.X - x0), G: uint8(x), B: uint8(c.Y - y0), A: uint8(y)})
+			}
+		}
+		return img, nil
+	}
+
+	// From a quarter into the top-left tile to three quarters into the bottom-right one
+	nw := tile.NewCoords(zoom, x0, y0).Bounds()
+	se := tile.NewCoords(zoom, x0+1, y0+1).Bounds()
+	bounds := types.BoundingBox{
+		MinLon: nw[0] + (nw[2]-nw[0])/4,
+		MaxLat: nw[3] - (nw[3]-nw[1])/4,
+		MaxLon: se[0] + (se[2]-se[0])*3/4,
+		MinLat: se[3] - (se[3]-se[1])*3/4,
+	}
+	rect := staticPixelRect(bounds, zoom, tileSize)
+
+	out, err := stitchTiles(context.Background(), rect, zoom, tileSize, renderTile)
+	if err != nil {
+		t.Fatalf("stitchTiles: %v", err)
+	}
+
+	if len(rendered) != 4 {
+		t.Fatalf("rendered %d tiles, want 4: %v", len(rendered), rendered)
+	}
+	// Latitude quarters aren't pixel quarters in Mercator, so allow a pixel of rounding
+	const want = tileSize * 3 / 2
+	if w, h := out.Bounds().Dx(), out.Bounds().Dy(); w != want || h < want-1 || h > want+1 {
+		t.Fatalf("static map is %dx%d, want about %dx%d", w, h, want, want)
+	}
+	if rect.Min.X != x0*tileSize+tileSize/4 {
+		t.Errorf("left edge at global pixel %d, want %d", rect.Min.X, x0*tileSize+tileSize/4)
+	}
+
+	for y := 0; y < out.Bounds().Dy(); y++ {
+		for x := 0; x < out.Bounds().Dx(); x++ {
+			gx, gy := rect.Min.X+x, rect.Min.Y+y
+			want := color.NRGBA{
+				R: uint8(gx/tileSize - x0),
+				G: uint8(gx % tileSize),
+				B: uint8(gy/tileSize - y0),
+				A: uint8(gy % tileSize),
+			}
+			if got := out.NRGBAAt(x, y); got != want {
+				t.Fatalf("pixel (%d, %d) = %v, want %v", x, y, got, want)
+			}
+		}
+	}
+}
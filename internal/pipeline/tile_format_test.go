@@ -0,0 +1,149 @@
+package pipeline
+
+import (
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MeKo-Tech/watercolormap/internal/tile"
+)
+
+func TestNewGeneratorTileFormat(t *testing.T) {
+	white := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+
+	tests := []struct {
+		name    string
+		opts    GeneratorOptions
+		want    string
+		wantErr string
+	}{
+		{name: "default", want: TileFormatPNG},
+		{name: "jpg alias", opts: GeneratorOptions{TileFormat: "JPG"}, want: TileFormatJPEG},
+		{name: "jpeg over paper", opts: GeneratorOptions{TileFormat: "jpeg"}, want: TileFormatJPEG},
+		{name: "jpeg transparent with flatten color", opts: GeneratorOptions{TileFormat: "jpeg", TransparentBackground: true, FlattenColor: white}, want: TileFormatJPEG},
+		{name: "jpeg transparent without flatten color", opts: GeneratorOptions{TileFormat: "jpeg", TransparentBackground: true}, wantErr: "flatten color"},
+		{name: "translucent flatten color", opts: GeneratorOptions{TileFormat: "jpeg", FlattenColor: color.RGBA{A: 128}}, wantErr: "opaque"},
+		{name: "webp", opts: GeneratorOptions{TileFormat: "webp"}, wantErr: "webp"},
+		{name: "unknown", opts: GeneratorOptions{TileFormat: "gif"}, wantErr: "unsupported"},
+		{name: "paletted", opts: GeneratorOptions{Paletted: true, PaletteColors: 16}, want: TileFormatPNG},
+		{name: "palette too large", opts: GeneratorOptions{Paletted: true, PaletteColors: 1024}, wantErr: "palette colors"},
+		{name: "palette of one color", opts: GeneratorOptions{Paletted: true, PaletteColors: 1}, wantErr: "palette colors"},
+	}
+
+	texturesDir := filepath.Join("..", "..", "assets", "textures")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen, err := NewGenerator(nil, "", texturesDir, t.TempDir(), 256, 1, false, nil, tt.opts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewGenerator failed: %v", err)
+			}
+			if gen.options.TileFormat != tt.want {
+				t.Errorf("tile format = %q, want %q", gen.options.TileFormat, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteTileJPEGFlattensOntoColor(t *testing.T) {
+	gen := newCompositeTestGenerator(t, 16, GeneratorOptions{
+		TileFormat:            TileFormatJPEG,
+		TransparentBackground: true,
+		FlattenColor:          color.RGBA{R: 255, G: 255, B: 255, A: 255},
+	})
+
+	// Half-transparent black: flattens to mid-gray
+	final := image.NewNRGBA(image.Rect(0, 0, 16, 16))
+	for i := 3; i < len(final.Pix); i += 4 {
+		final.Pix[i] = 128
+	}
+
+	coords := tile.NewCoords(10, 1, 2)
+	path, _ := gen.tilePath(coords, "")
+	if filepath.Ext(path) != ".jpg" {
+		t.Fatalf("tile path %s, want a .jpg file", path)
+	}
+	if err := gen.writeTile(final, coords, path); err != nil {
+		t.Fatalf("writeTile failed: %v", err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	img, err := jpeg.Decode(f)
+	if err != nil {
+		t.Fatalf("tile is not a JPEG: %v", err)
+	}
+	r, g, b, _ := img.At(8, 8).RGBA()
+	for _, v := range []uint32{r >> 8, g >> 8, b >> 8} {
+		if v < 124 || v > 130 {
+			t.Errorf("center = (%d, %d, %d), want ~127 gray", r>>8, g>>8, b>>8)
+			break
+		}
+	}
+}
+
+func TestWriteTilePaletted(t *testing.T) {
+	gen := newCompositeTestGenerator(t, 32, GeneratorOptions{Paletted: true, PaletteColors: 8})
+
+	// Flat fills with a few blended edge pixels: more colors than the palette holds
+	final := image.NewNRGBA(image.Rect(0, 0, 32, 32))
+	for y := 0; y < 32; y++ {
+		for x := 0; x < 32; x++ {
+			c := color.NRGBA{R: 240, G: 235, B: 220, A: 255}
+			switch {
+			case x < 10:
+				c = color.NRGBA{R: 90, G: 140, B: 200, A: 255}
+			case x == 10:
+				c = color.NRGBA{R: 165 + uint8(y%4), G: 187, B: 210, A: 255}
+			case y > 20:
+				c = color.NRGBA{R: 120, G: 170, B: 90, A: 255}
+			}
+			final.SetNRGBA(x, y, c)
+		}
+	}
+
+	coords := tile.NewCoords(10, 1, 2)
+	path, _ := gen.tilePath(coords, "")
+	if err := gen.writeTile(final, coords, path); err != nil {
+		t.Fatalf("writeTile failed: %v", err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	img, err := png.Decode(f)
+	if err != nil {
+		t.Fatalf("failed to decode tile: %v", err)
+	}
+	p, ok := img.(*image.Paletted)
+	if !ok {
+		t.Fatalf("decoded %T, want *image.Paletted", img)
+	}
+	if len(p.Palette) > 8 {
+		t.Errorf("palette has %d colors, want at most 8", len(p.Palette))
+	}
+	for y := 0; y < 32; y++ {
+		for x := 0; x < 32; x++ {
+			want := final.NRGBAAt(x, y)
+			got := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			if absDiff(got.R, want.R) > 4 || absDiff(got.G, want.G) > 4 || absDiff(got.B, want.B) > 4 || got.A != want.A {
+				t.Fatalf("pixel (%d,%d) = %v, want within 4 of %v", x, y, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package pipeline
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/MeKo-Tech/watercolormap/internal/tile"
+)
+
+// stageTimer records per-stage durations for one tile when GeneratorOptions.LogTiming is set.
+// Like *DebugContext, a nil *stageTimer is valid and does nothing, so the production path
+// only pays for a nil check per stage.
+type stageTimer struct {
+	start time.Time
+	last  time.Time
+	attrs []any
+}
+
+// newStageTimer returns a running timer, or nil when timing is disabled.
+func (g *Generator) newStageTimer() *stageTimer {
+	if !g.options.LogTiming {
+		return nil
+	}
+	now := time.Now()
+	return &stageTimer{start: now, last: now}
+}
+
+// mark records the time elapsed since the previous mark under the given stage name.
+func (t *stageTimer) mark(stage string) {
+	if t == nil {
+		return
+	}
+	now := time.Now()
+	t.attrs = append(t.attrs, stage, now.Sub(t.last))
+	t.last = now
+}
+
+// log emits all recorded stage durations plus the total as a single structured log line.
+func (t *stageTimer) log(logger *slog.Logger, coords tile.Coords) {
+	if t == nil {
+		return
+	}
+	args := make([]any, 0, len(t.attrs)+4)
+	args = append(args, "coords", coords.String(), "total", time.Since(t.start))
+	args = append(args, t.attrs...)
+	logger.Info("Tile stage timings", args...)
+}
@@ -0,0 +1,43 @@
+package pipeline
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/MeKo-Tech/watercolormap/internal/tile"
+)
+
+func TestStageTimer(t *testing.T) {
+	coords := tile.NewCoords(13, 4317, 2692)
+
+	t.Run("disabled", func(t *testing.T) {
+		g := &Generator{}
+		tm := g.newStageTimer()
+		if tm != nil {
+			t.Fatal("expected nil timer when LogTiming is off")
+		}
+		// A nil timer must be safe to use
+		tm.mark("fetch")
+		tm.log(slog.Default(), coords)
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		g := &Generator{options: GeneratorOptions{LogTiming: true}}
+		tm := g.newStageTimer()
+		for _, stage := range []string{"fetch", "render", "paint_water", "composite", "encode"} {
+			tm.mark(stage)
+		}
+
+		var buf bytes.Buffer
+		tm.log(slog.New(slog.NewTextHandler(&buf, nil)), coords)
+
+		out := buf.String()
+		for _, want := range []string{"Tile stage timings", "coords=" + coords.String(), "total=", "fetch=", "render=", "paint_water=", "composite=", "encode="} {
+			if !strings.Contains(out, want) {
+				t.Errorf("expected %q in log output, got %q", want, out)
+			}
+		}
+	})
+}
@@ -0,0 +1,39 @@
+package pipeline
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"testing"
+
+	"github.com/MeKo-Tech/watercolormap/internal/composite"
+)
+
+func TestToneAdjustsCompositedTile(t *testing.T) {
+	_, painted := renderSyntheticLake(t, GeneratorOptions{})
+	render := func(opts GeneratorOptions) ([]byte, image.Image) {
+		t.Helper()
+		gen := newCompositeTestGenerator(t, 256, opts)
+		var buf bytes.Buffer
+		pooledTile(t, gen, painted, testParams(gen), &buf)
+		data := bytes.Clone(buf.Bytes())
+		img, err := png.Decode(&buf)
+		if err != nil {
+			t.Fatalf("failed to decode tile: %v", err)
+		}
+		return data, img
+	}
+
+	plainPNG, plain := render(GeneratorOptions{})
+	identityPNG, _ := render(GeneratorOptions{Tone: composite.ToneAdjust{Gamma: 1}})
+	if !bytes.Equal(plainPNG, identityPNG) {
+		t.Error("expected an identity tone adjustment to leave the tile unchanged")
+	}
+
+	_, darker := render(GeneratorOptions{Tone: composite.ToneAdjust{Brightness: -0.2}})
+	for _, p := range []image.Point{{0, 0}, {128, 128}} {
+		if got, was := gray(darker, p.X, p.Y), gray(plain, p.X, p.Y); got >= was {
+			t.Errorf("pixel %v: expected darker tile, got %d vs %d", p, got, was)
+		}
+	}
+}
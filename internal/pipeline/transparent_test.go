@@ -0,0 +1,80 @@
+package pipeline
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/MeKo-Tech/watercolormap/internal/geojson"
+	"github.com/MeKo-Tech/watercolormap/internal/watercolor"
+)
+
+// renderSyntheticLake runs masks, painting, compositing and encoding for a raw water layer
+// holding a lake in the middle of the tile, and returns the decoded PNG.
+func renderSyntheticLake(t *testing.T, opts GeneratorOptions) (image.Image, map[geojson.LayerType]image.Image) {
+	t.Helper()
+	gen := newCompositeTestGenerator(t, 256, opts)
+	params := testParams(gen)
+	params.PerlinNoise = watercolor.GenerateNoise(params, 13, 100, 200)
+
+	water := image.NewNRGBA(image.Rect(0, 0, params.TileSize, params.TileSize))
+	c := params.TileSize / 2
+	for y := c - 60; y < c+60; y++ {
+		for x := c - 60; x < c+60; x++ {
+			water.SetNRGBA(x, y, color.NRGBA{R: 0, G: 0, B: 255, A: 255})
+		}
+	}
+	raw := map[geojson.LayerType]image.Image{geojson.LayerWater: water}
+
+	masks, err := buildMasks(raw, params, nil)
+	if err != nil {
+		t.Fatalf("buildMasks failed: %v", err)
+	}
+	painted, err := paintAllLayers(raw, masks, params, gen.textures, opts.TransparentBackground, 0, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("paintAllLayers failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	pooledTile(t, gen, painted, params, &buf)
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatalf("failed to decode tile: %v", err)
+	}
+	return img, painted
+}
+
+func TestTransparentBackground(t *testing.T) {
+	img, painted := renderSyntheticLake(t, GeneratorOptions{TransparentBackground: true})
+
+	if _, ok := painted[geojson.LayerLand]; ok {
+		t.Error("land layer should not be painted with a transparent background")
+	}
+
+	b := img.Bounds()
+	corners := []image.Point{
+		{b.Min.X, b.Min.Y},
+		{b.Max.X - 1, b.Min.Y},
+		{b.Min.X, b.Max.Y - 1},
+		{b.Max.X - 1, b.Max.Y - 1},
+	}
+	for _, p := range corners {
+		if _, _, _, a := img.At(p.X, p.Y).RGBA(); a != 0 {
+			t.Errorf("corner %v: expected fully transparent, got alpha %d", p, a>>8)
+		}
+	}
+
+	if _, _, _, a := img.At(b.Dx()/2, b.Dy()/2).RGBA(); a == 0 {
+		t.Error("expected painted water in the tile center")
+	}
+}
+
+func TestOpaqueBackgroundByDefault(t *testing.T) {
+	img, _ := renderSyntheticLake(t, GeneratorOptions{})
+
+	if _, _, _, a := img.At(0, 0).RGBA(); a>>8 != 255 {
+		t.Errorf("expected an opaque paper/land corner by default, got alpha %d", a>>8)
+	}
+}
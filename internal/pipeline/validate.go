@@ -0,0 +1,221 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"sort"
+
+	"github.com/MeKo-Tech/watercolormap/internal/geojson"
+	"github.com/MeKo-Tech/watercolormap/internal/mask"
+	"github.com/MeKo-Tech/watercolormap/internal/tile"
+)
+
+// blackBlockSize is the side of the blocks ValidateTile checks for black regions.
+const blackBlockSize = 16
+
+// blackLevel is the channel value below which an opaque pixel counts as black. Painted
+// layers and the paper never get this dark, so black pixels are rendering failures.
+const blackLevel = 16
+
+// TileReport holds quality metrics of a rendered tile (see ValidateTile).
+type TileReport struct {
+	Coords tile.Coords
+	// FeatureCounts are the fetched features by type (see types.FeatureCollection.FeatureCounts)
+	FeatureCounts map[string]int
+	// OpaqueFraction is the fraction of tile pixels that aren't fully transparent
+	OpaqueFraction float64
+	// BlackBlocks is the number of 16×16 blocks of the tile that are entirely black
+	BlackBlocks int
+	// LayerCoverage is the fraction of tile pixels each painted layer covers (alpha >= 128)
+	LayerCoverage map[geojson.LayerType]float64
+	// WaterFraction is the fraction of tile pixels covered by water or rivers
+	WaterFraction float64
+	// MaskStats are the gray-value statistics of each layer's rendered alpha over the tile,
+	// before any blur, noise or threshold
+	MaskStats map[geojson.LayerType]mask.Stats
+}
+
+// TileThresholds are the limits a TileReport is checked against (see TileReport.Check).
+type TileThresholds struct {
+	MinFeatures       int     // Minimum number of fetched features (0 = any)
+	MinOpaqueFraction float64 // Minimum fraction of non-transparent pixels
+	MaxBlackBlocks    int     // Maximum number of entirely black 16×16 blocks
+	MinWaterFraction  float64 // Minimum water coverage, e.g. for tiles known to show a lake
+	MaxWaterFraction  float64 // Maximum water coverage (0 = no limit)
+}
+
+// DefaultTileThresholds returns thresholds that any successfully rendered tile over mapped
+// land passes: some features, a fully painted tile and no black regions.
+func DefaultTileThresholds() TileThresholds {
+	return TileThresholds{
+		MinFeatures:       1,
+		MinOpaqueFraction: 0.99,
+	}
+}
+
+// Check returns a description of every threshold r violates, or nil if it passes.
+func (r *TileReport) Check(th TileThresholds) []string {
+	var failures []string
+	if total := r.FeatureCounts["total"]; total < th.MinFeatures {
+		failures = append(failures, fmt.Sprintf("%d features, want at least %d", total, th.MinFeatures))
+	}
+	if r.OpaqueFraction < th.MinOpaqueFraction {
+		failures = append(failures, fmt.Sprintf("%.1f%% of pixels painted, want at least %.1f%%", 100*r.OpaqueFraction, 100*th.MinOpaqueFraction))
+	}
+	if r.BlackBlocks > th.MaxBlackBlocks {
+		failures = append(failures, fmt.Sprintf("%d black %dx%d blocks, want at most %d", r.BlackBlocks, blackBlockSize, blackBlockSize, th.MaxBlackBlocks))
+	}
+	if r.WaterFraction < th.MinWaterFraction {
+		failures = append(failures, fmt.Sprintf("%.1f%% water, want at least %.1f%%", 100*r.WaterFraction, 100*th.MinWaterFraction))
+	}
+	if th.MaxWaterFraction > 0 && r.WaterFraction > th.MaxWaterFraction {
+		failures = append(failures, fmt.Sprintf("%.1f%% water, want at most %.1f%%", 100*r.WaterFraction, 100*th.MaxWaterFraction))
+	}
+	return failures
+}
+
+// String formats r as a human-readable report, one metric per line.
+func (r *TileReport) String() string {
+	s := fmt.Sprintf("tile %s\n", r.Coords.String())
+	s += fmt.Sprintf("  features:  %d (water %d, parks %d, roads %d, buildings %d, urban %d)\n",
+		r.FeatureCounts["total"], r.FeatureCounts["water"], r.FeatureCounts["parks"],
+		r.FeatureCounts["roads"], r.FeatureCounts["buildings"], r.FeatureCounts["urban"])
+	s += fmt.Sprintf("  painted:   %.1f%%\n", 100*r.OpaqueFraction)
+	s += fmt.Sprintf("  black:     %d blocks\n", r.BlackBlocks)
+	s += fmt.Sprintf("  water:     %.1f%%\n", 100*r.WaterFraction)
+
+	layers := make([]string, 0, len(r.LayerCoverage))
+	for layer := range r.LayerCoverage {
+		layers = append(layers, string(layer))
+	}
+	sort.Strings(layers)
+	for _, layer := range layers {
+		s += fmt.Sprintf("  %-10s %.1f%%\n", layer+":", 100*r.LayerCoverage[geojson.LayerType(layer)])
+	}
+
+	if len(r.MaskStats) > 0 {
+		s += "  rendered masks:\n"
+		layers = layers[:0]
+		for layer := range r.MaskStats {
+			layers = append(layers, string(layer))
+		}
+		sort.Strings(layers)
+		for _, layer := range layers {
+			s += fmt.Sprintf("    %-10s %s\n", layer+":", r.MaskStats[geojson.LayerType(layer)])
+		}
+	}
+	return s
+}
+
+// ValidateTile fetches and renders a tile like Generate, without writing it, and returns its
+// quality metrics for checking against TileThresholds.
+func (g *Generator) ValidateTile(ctx context.Context, coords tile.Coords) (*TileReport, error) {
+	data, err := g.FetchOnly(ctx, coords)
+	if err != nil {
+		return nil, err
+	}
+
+	renderResult, painted, err := g.renderAndPaint(ctx, coords, nil, nil, data)
+	if err != nil {
+		return nil, err
+	}
+	composited, err := g.compositeLayers(painted, renderResult.params, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer metatileBuffers.put(composited)
+
+	padPx := renderResult.padPx
+	rect := image.Rect(padPx, padPx, padPx+g.tileSize, padPx+g.tileSize)
+	report := analyzeTile(composited, painted, rect)
+	report.Coords = coords
+	report.FeatureCounts = data.Features.FeatureCounts()
+	report.MaskStats = make(map[geojson.LayerType]mask.Stats, len(renderResult.rawLayers))
+	for layer, img := range renderResult.rawLayers {
+		if img != nil {
+			alpha := mask.ExtractAlphaMask(img).SubImage(rect).(*image.Gray)
+			report.MaskStats[layer] = mask.ComputeStats(alpha)
+		}
+	}
+	return report, nil
+}
+
+// analyzeTile computes the image metrics of a TileReport over rect of the composite and the
+// painted layers, which share its bounds.
+func analyzeTile(composited *image.NRGBA, painted map[geojson.LayerType]image.Image, rect image.Rectangle) *TileReport {
+	report := &TileReport{LayerCoverage: make(map[geojson.LayerType]float64, len(painted))}
+	total := float64(rect.Dx() * rect.Dy())
+
+	opaque := 0
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			if composited.Pix[composited.PixOffset(x, y)+3] > 0 {
+				opaque++
+			}
+		}
+	}
+	report.OpaqueFraction = float64(opaque) / total
+
+	for by := rect.Min.Y; by+blackBlockSize <= rect.Max.Y; by += blackBlockSize {
+		for bx := rect.Min.X; bx+blackBlockSize <= rect.Max.X; bx += blackBlockSize {
+			if isBlackBlock(composited, image.Rect(bx, by, bx+blackBlockSize, by+blackBlockSize)) {
+				report.BlackBlocks++
+			}
+		}
+	}
+
+	water := 0
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			if layerCovers(painted[geojson.LayerWater], x, y) || layerCovers(painted[geojson.LayerRivers], x, y) {
+				water++
+			}
+		}
+	}
+	report.WaterFraction = float64(water) / total
+
+	for layer, img := range painted {
+		if img == nil {
+			continue
+		}
+		covered := 0
+		for y := rect.Min.Y; y < rect.Max.Y; y++ {
+			for x := rect.Min.X; x < rect.Max.X; x++ {
+				if layerCovers(img, x, y) {
+					covered++
+				}
+			}
+		}
+		report.LayerCoverage[layer] = float64(covered) / total
+	}
+	return report
+}
+
+// isBlackBlock reports whether every pixel of r is opaque and black.
+func isBlackBlock(img *image.NRGBA, r image.Rectangle) bool {
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		row := img.Pix[img.PixOffset(r.Min.X, y):img.PixOffset(r.Max.X, y)]
+		for i := 0; i < len(row); i += 4 {
+			if row[i+3] == 0 || row[i] >= blackLevel || row[i+1] >= blackLevel || row[i+2] >= blackLevel {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// layerCovers reports whether a painted layer covers pixel (x, y) with alpha >= 128.
+func layerCovers(img image.Image, x, y int) bool {
+	if img == nil {
+		return false
+	}
+	if n, ok := img.(*image.NRGBA); ok {
+		if !(image.Point{X: x, Y: y}).In(n.Rect) {
+			return false
+		}
+		return n.Pix[n.PixOffset(x, y)+3] >= 128
+	}
+	_, _, _, a := img.At(x, y).RGBA()
+	return a >= 128<<8
+}
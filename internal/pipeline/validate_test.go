@@ -0,0 +1,69 @@
+package pipeline
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/MeKo-Tech/watercolormap/internal/geojson"
+)
+
+func TestAnalyzeTile(t *testing.T) {
+	const padPx, size = 8, 64
+	bounds := image.Rect(0, 0, size+2*padPx, size+2*padPx)
+	rect := image.Rect(padPx, padPx, padPx+size, padPx+size)
+
+	// Paper everywhere, a black 32×16 hole in the top-left of the tile and water in its
+	// bottom half; the padding is water too, but doesn't count
+	composited := image.NewNRGBA(bounds)
+	water := image.NewNRGBA(bounds)
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			c := color.NRGBA{R: 240, G: 235, B: 220, A: 255}
+			if x >= padPx && x < padPx+32 && y >= padPx && y < padPx+16 {
+				c = color.NRGBA{A: 255}
+			}
+			composited.SetNRGBA(x, y, c)
+			if y >= padPx+size/2 || x < padPx {
+				water.SetNRGBA(x, y, color.NRGBA{R: 90, G: 140, B: 200, A: 255})
+			}
+		}
+	}
+	painted := map[geojson.LayerType]image.Image{geojson.LayerWater: water}
+
+	report := analyzeTile(composited, painted, rect)
+	report.FeatureCounts = map[string]int{"total": 12, "water": 1}
+
+	if report.OpaqueFraction != 1 {
+		t.Errorf("OpaqueFraction = %v, want 1", report.OpaqueFraction)
+	}
+	if report.BlackBlocks != 2 {
+		t.Errorf("BlackBlocks = %d, want 2", report.BlackBlocks)
+	}
+	// Only the bottom half of the water lies within the tile
+	if report.WaterFraction != 0.5 {
+		t.Errorf("WaterFraction = %v, want 0.5", report.WaterFraction)
+	}
+	if got := report.LayerCoverage[geojson.LayerWater]; got != 0.5 {
+		t.Errorf("water coverage = %v, want 0.5", got)
+	}
+
+	tests := []struct {
+		name         string
+		th           TileThresholds
+		wantFailures int
+	}{
+		{"black blocks fail the defaults", DefaultTileThresholds(), 1},
+		{"black blocks allowed", TileThresholds{MaxBlackBlocks: 2}, 0},
+		{"too few features", TileThresholds{MinFeatures: 20, MaxBlackBlocks: 2}, 1},
+		{"too much water", TileThresholds{MaxBlackBlocks: 2, MaxWaterFraction: 0.3}, 1},
+		{"too little water", TileThresholds{MaxBlackBlocks: 2, MinWaterFraction: 0.8}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if failures := report.Check(tt.th); len(failures) != tt.wantFailures {
+				t.Errorf("Check = %q, want %d failures", failures, tt.wantFailures)
+			}
+		})
+	}
+}
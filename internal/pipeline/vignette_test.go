@@ -0,0 +1,131 @@
+package pipeline
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MeKo-Tech/watercolormap/internal/composite"
+	"github.com/MeKo-Tech/watercolormap/internal/geojson"
+	"github.com/MeKo-Tech/watercolormap/internal/watercolor"
+)
+
+// syntheticUrban returns painted layers of a street grid with building blocks in the left
+// half of a w×h area and open land everywhere.
+func syntheticUrban(w, h int) map[geojson.LayerType]image.Image {
+	rect := image.Rect(0, 0, w, h)
+	land := image.NewNRGBA(rect)
+	roads := image.NewNRGBA(rect)
+	buildings := image.NewNRGBA(rect)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			land.SetNRGBA(x, y, color.NRGBA{R: 214, G: 200, B: 160, A: 255})
+			if x >= w/2 {
+				continue
+			}
+			switch {
+			case x%24 < 4 || y%24 < 4:
+				roads.SetNRGBA(x, y, color.NRGBA{R: 250, G: 245, B: 235, A: 255})
+			case x%24 >= 7 && x%24 < 21 && y%24 >= 7 && y%24 < 21:
+				buildings.SetNRGBA(x, y, color.NRGBA{R: 170, G: 150, B: 140, A: 230})
+			}
+		}
+	}
+	return map[geojson.LayerType]image.Image{
+		geojson.LayerLand:      land,
+		geojson.LayerRoads:     roads,
+		geojson.LayerBuildings: buildings,
+	}
+}
+
+// TestVignetteGolden composites a synthetic urban tile with the vignette and compares it
+// against a golden (set UPDATE_GOLDEN=1 to regenerate).
+func TestVignetteGolden(t *testing.T) {
+	goldenPath := filepath.Join("..", "..", "testdata", "golden", "pipeline-vignette", "urban.png")
+	debugDir := filepath.Join("..", "..", "testdata", "output", "pipeline-vignette")
+
+	encode := func(strength float64) image.Image {
+		gen := newCompositeTestGenerator(t, 256, GeneratorOptions{Vignette: composite.Vignette{Strength: strength}})
+		params := testParams(gen)
+		painted := syntheticUrban(params.Size())
+
+		var buf bytes.Buffer
+		pooledTile(t, gen, painted, params, &buf)
+		img, err := png.Decode(&buf)
+		if err != nil {
+			t.Fatalf("failed to decode tile: %v", err)
+		}
+		return img
+	}
+	plain := encode(0)
+	vignetted := encode(0.3)
+
+	// A building in the dense half darkens; land far from it is untouched
+	_, plainG, _, _ := plain.At(8, 8).RGBA()
+	_, darkG, _, _ := vignetted.At(8, 8).RGBA()
+	if darkG >= plainG {
+		t.Errorf("expected the dense area to darken, green %d -> %d", plainG>>8, darkG>>8)
+	}
+	if plain.At(250, 128) != vignetted.At(250, 128) {
+		t.Errorf("expected open land to be unaffected, got %v vs %v", plain.At(250, 128), vignetted.At(250, 128))
+	}
+
+	writePNG(t, filepath.Join(debugDir, "urban_plain.png"), plain)
+	writePNG(t, filepath.Join(debugDir, "urban.png"), vignetted)
+	if os.Getenv("UPDATE_GOLDEN") == "1" {
+		writePNG(t, goldenPath, vignetted)
+		return
+	}
+	assertImagesEqual(t, goldenPath, vignetted, "urban")
+}
+
+// TestVignetteDensitySeamless computes the density of two neighboring padded metatiles cut
+// from one wide render and checks that it agrees along their shared edge.
+func TestVignetteDensitySeamless(t *testing.T) {
+	const tileSize = 64
+	gen := newCompositeTestGenerator(t, tileSize, GeneratorOptions{Vignette: composite.Vignette{Strength: 0.3}})
+	params := watercolor.DefaultParams(tileSize, gen.seed, gen.textures)
+	padPx := min(watercolor.RequiredPaddingPx(params), tileSize)
+	params.TileSize = tileSize + 2*padPx
+
+	// Two tiles side by side, straddling the edge of the dense area
+	full := syntheticUrban(2*tileSize+2*padPx, params.TileSize)
+	for layer, img := range full {
+		full[layer] = shiftImage(img.(*image.NRGBA), tileSize/2)
+	}
+
+	var crops [2]*image.Gray
+	for i := range crops {
+		window := image.Rect(i*tileSize, 0, i*tileSize+params.TileSize, params.TileSize)
+		painted := make(map[geojson.LayerType]image.Image, len(full))
+		for layer, img := range full {
+			painted[layer] = cropNRGBA(img, window)
+		}
+		crops[i] = gen.coverageDensity(painted, vignetteLayers, params)
+	}
+
+	for y := padPx; y < padPx+tileSize; y++ {
+		left := crops[0].GrayAt(padPx+tileSize, y).Y // First column of the right tile
+		right := crops[1].GrayAt(padPx, y).Y
+		if left != right {
+			t.Fatalf("row %d: density %d in the left metatile, %d in the right one", y, left, right)
+		}
+	}
+	if crops[0].GrayAt(padPx, padPx).Y == 0 {
+		t.Error("expected density within the street grid")
+	}
+}
+
+// shiftImage moves img right by dx pixels, filling the left with transparency.
+func shiftImage(img *image.NRGBA, dx int) *image.NRGBA {
+	out := image.NewNRGBA(img.Bounds())
+	w := img.Bounds().Dx()
+	for y := 0; y < img.Bounds().Dy(); y++ {
+		copy(out.Pix[out.PixOffset(dx, y):out.PixOffset(w, y)], img.Pix[img.PixOffset(0, y):img.PixOffset(w-dx, y)])
+	}
+	return out
+}
@@ -0,0 +1,73 @@
+package pipeline
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"os"
+	"strings"
+
+	"github.com/MeKo-Tech/watercolormap/internal/types"
+)
+
+// WorldFile is the affine transform of an ESRI world file (.pgw for PNGs), which georeferences
+// an image for GIS tools such as QGIS. Coordinates are Web Mercator (EPSG:3857) meters; world
+// files don't name their CRS, so it has to be set when loading the image.
+type WorldFile struct {
+	// PixelSizeX and PixelSizeY are the size of a pixel in meters; PixelSizeY is negative
+	// because rows grow southward
+	PixelSizeX, PixelSizeY float64
+	// RotationY and RotationX are the rotation terms, always zero for north-up maps
+	RotationY, RotationX float64
+	// X and Y are the center of the top-left pixel
+	X, Y float64
+}
+
+// StaticWorldFile returns the world file of the image RenderStatic renders for bounds at
+// zoom. It describes the image's whole pixels, which RenderStatic rounds bounds to.
+func (g *Generator) StaticWorldFile(bounds types.BoundingBox, zoom int) WorldFile {
+	return pixelRectWorldFile(staticPixelRect(bounds, zoom, g.tileSize), zoom, g.tileSize)
+}
+
+// AreaWorldFile returns the world file of the widthPx×heightPx image RenderArea renders for
+// bounds at zoom, covering bounds grown to the image's aspect ratio.
+func (g *Generator) AreaWorldFile(bounds types.BoundingBox, widthPx, heightPx, zoom int) WorldFile {
+	geom := newAreaGeometry(bounds, widthPx, heightPx, zoom, g.tileSize)
+	sizeX := (geom.mercator[2] - geom.mercator[0]) / float64(widthPx)
+	sizeY := (geom.mercator[3] - geom.mercator[1]) / float64(heightPx)
+	return WorldFile{
+		PixelSizeX: sizeX,
+		PixelSizeY: -sizeY,
+		X:          geom.mercator[0] + sizeX/2,
+		Y:          geom.mercator[3] - sizeY/2,
+	}
+}
+
+// pixelRectWorldFile returns the world file of an image covering rect, in global pixel
+// coordinates at zoom for tiles of tileSize pixels.
+func pixelRectWorldFile(rect image.Rectangle, zoom, tileSize int) WorldFile {
+	metersPerPx := webMercatorExtent / (math.Exp2(float64(zoom)) * float64(tileSize))
+	return WorldFile{
+		PixelSizeX: metersPerPx,
+		PixelSizeY: -metersPerPx,
+		X:          -webMercatorExtent/2 + (float64(rect.Min.X)+0.5)*metersPerPx,
+		Y:          webMercatorExtent/2 - (float64(rect.Min.Y)+0.5)*metersPerPx,
+	}
+}
+
+// String formats w as the six lines of a world file.
+func (w WorldFile) String() string {
+	var sb strings.Builder
+	for _, v := range []float64{w.PixelSizeX, w.RotationY, w.RotationX, w.PixelSizeY, w.X, w.Y} {
+		fmt.Fprintf(&sb, "%.10f\n", v)
+	}
+	return sb.String()
+}
+
+// Write writes w to path.
+func (w WorldFile) Write(path string) error {
+	if err := os.WriteFile(path, []byte(w.String()), 0o644); err != nil {
+		return fmt.Errorf("failed to write world file: %w", err)
+	}
+	return nil
+}
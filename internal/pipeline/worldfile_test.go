@@ -0,0 +1,56 @@
+package pipeline
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/MeKo-Tech/watercolormap/internal/tile"
+)
+
+func TestStaticWorldFile(t *testing.T) {
+	const zoom, tileSize = 13, 256
+	coords := tile.NewCoords(zoom, 4317, 2692)
+	g := &Generator{tileSize: tileSize}
+
+	wf := g.StaticWorldFile(tileBBox(zoom, 4317, 2692), zoom)
+
+	// A z13 pixel of a 256 px tile is 2π·6378137 / 2^21 meters
+	wantRes := 2 * math.Pi * 6378137 / (1 << 21)
+	if math.Abs(wf.PixelSizeX-wantRes) > 1e-9 || math.Abs(wf.PixelSizeY+wantRes) > 1e-9 {
+		t.Errorf("pixel size = %v, %v; want %v, %v", wf.PixelSizeX, wf.PixelSizeY, wantRes, -wantRes)
+	}
+	if wf.RotationX != 0 || wf.RotationY != 0 {
+		t.Errorf("rotation = %v, %v; want 0", wf.RotationX, wf.RotationY)
+	}
+
+	// The top-left pixel center is half a pixel inside the tile's north-west corner
+	b := coords.Bounds()
+	west, north := tile.LonLatToMercator(b[0], b[3])
+	if math.Abs(wf.X-(west+wantRes/2)) > 1e-6 || math.Abs(wf.Y-(north-wantRes/2)) > 1e-6 {
+		t.Errorf("top-left pixel center = (%v, %v), want (%v, %v)", wf.X, wf.Y, west+wantRes/2, north-wantRes/2)
+	}
+
+	lines := strings.Split(strings.TrimSpace(wf.String()), "\n")
+	if len(lines) != 6 {
+		t.Fatalf("world file has %d lines, want 6:\n%s", len(lines), wf.String())
+	}
+}
+
+func TestAreaWorldFile(t *testing.T) {
+	const zoom, tileSize = 13, 256
+	g := &Generator{tileSize: tileSize}
+
+	// One tile rendered at twice its native resolution
+	wf := g.AreaWorldFile(tileBBox(zoom, 4317, 2692), 2*tileSize, 2*tileSize, zoom)
+
+	wantRes := 2 * math.Pi * 6378137 / (1 << 21) / 2
+	if math.Abs(wf.PixelSizeX-wantRes) > 1e-6 || math.Abs(wf.PixelSizeY+wantRes) > 1e-6 {
+		t.Errorf("pixel size = %v, %v; want %v, %v", wf.PixelSizeX, wf.PixelSizeY, wantRes, -wantRes)
+	}
+	b := tile.NewCoords(zoom, 4317, 2692).Bounds()
+	west, north := tile.LonLatToMercator(b[0], b[3])
+	if math.Abs(wf.X-(west+wantRes/2)) > 1e-3 || math.Abs(wf.Y-(north-wantRes/2)) > 1e-3 {
+		t.Errorf("top-left pixel center = (%v, %v), want (%v, %v)", wf.X, wf.Y, west+wantRes/2, north-wantRes/2)
+	}
+}
@@ -0,0 +1,78 @@
+package renderer
+
+import (
+	"testing"
+
+	"github.com/MeKo-Tech/watercolormap/internal/geojson"
+	"github.com/MeKo-Tech/watercolormap/internal/tile"
+	"github.com/MeKo-Tech/watercolormap/internal/types"
+	"github.com/paulmach/orb"
+)
+
+// TestBufferSizeKeepsCasingsAtTileEdges renders a wide road running just east of the border
+// between two tiles. Its stroke reaches into the western tile, which only draws it when
+// Mapnik's buffer covers the road.
+func TestBufferSizeKeepsCasingsAtTileEdges(t *testing.T) {
+	requireIntegration(t)
+
+	west := tile.NewCoords(16, 34540, 21537)
+	east := tile.NewCoords(16, 34541, 21537)
+
+	// 3 px east of the border at z16 with 256 px tiles
+	b := east.Bounds()
+	lon := b[0] + 3*(b[2]-b[0])/256
+	data := &types.TileData{
+		Features: types.FeatureCollection{
+			Roads: []types.Feature{{
+				ID:         "test-border-road",
+				Type:       types.FeatureTypeRoad,
+				Geometry:   orb.LineString{{lon, b[1] - 0.01}, {lon, b[3] + 0.01}},
+				Properties: map[string]interface{}{"highway": "primary"},
+			}},
+		},
+	}
+
+	render := func(t *testing.T, bufferPx int) map[tile.Coords]map[geojson.LayerType]string {
+		t.Helper()
+		renderer, err := NewMultiPassRenderer("../../assets/styles", t.TempDir(), 256, 0)
+		if err != nil {
+			t.Fatalf("failed to create renderer: %v", err)
+		}
+		defer renderer.Close() // nolint:errcheck
+		renderer.SetOverrides(map[geojson.LayerType]LayerRenderOverride{geojson.LayerRoads: {LineWidthScale: 3}})
+		renderer.SetBufferSize(bufferPx)
+
+		rendered := make(map[tile.Coords]map[geojson.LayerType]string)
+		for _, coords := range []tile.Coords{west, east} {
+			result, err := renderer.RenderTile(coords, data)
+			if err != nil {
+				t.Fatalf("failed to render %s: %v", coords.String(), err)
+			}
+			roads := result.Layers[geojson.LayerRoads]
+			if roads == nil || roads.OutputPath == "" {
+				t.Fatalf("no roads layer output for %s", coords.String())
+			}
+			rendered[coords] = map[geojson.LayerType]string{geojson.LayerRoads: roads.OutputPath}
+		}
+		return rendered
+	}
+
+	// westEdgeCovered reports whether the road reaches the last column of the western tile.
+	westEdgeCovered := func(t *testing.T, rendered map[tile.Coords]map[geojson.LayerType]string) bool {
+		img := loadPNG(t, rendered[west][geojson.LayerRoads])
+		x := img.Bounds().Max.X - 1
+		_, _, _, a := img.At(x, img.Bounds().Dy()/2).RGBA()
+		return a >= 128<<8
+	}
+
+	// Without a buffer Mapnik skips the road for the western tile
+	if westEdgeCovered(t, render(t, 0)) {
+		t.Error("expected the road to be clipped from the western tile without a buffer")
+	}
+
+	rendered := render(t, DefaultBufferPx)
+	if !westEdgeCovered(t, rendered) {
+		t.Fatal("expected the road's stroke to reach into the western tile with the default buffer")
+	}
+	checkEdgeAlignment(t, rendered, []tile.Coords{west, east})
+}
@@ -0,0 +1,19 @@
+package renderer
+
+import "github.com/MeKo-Tech/watercolormap/internal/geojson"
+
+// RenderError describes a Mapnik rendering failure. Rendering is deterministic for a given
+// input, so render errors are never worth retrying. Layer is empty when the failure is not
+// specific to one layer (e.g. renderer setup).
+type RenderError struct {
+	Layer geojson.LayerType
+	Err   error
+}
+
+func (e *RenderError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RenderError) Unwrap() error {
+	return e.Err
+}
@@ -18,25 +18,31 @@ import (
 // MapnikRenderer wraps Mapnik for tile rendering
 type MapnikRenderer struct {
 	mapObject *mapnik.Map
-	tileSize  int
+	width     int
+	height    int
 }
 
 func (r *MapnikRenderer) resetMapObject() {
 	if r.mapObject != nil {
 		r.mapObject.Free()
 	}
-	r.mapObject = mapnik.NewSized(r.tileSize, r.tileSize)
+	r.mapObject = mapnik.NewSized(r.width, r.height)
 }
 
 // NewMapnikRenderer creates a new Mapnik renderer
 func NewMapnikRenderer(styleFile string, tileSize int) (*MapnikRenderer, error) {
+	return NewMapnikRendererSize(styleFile, tileSize, tileSize)
+}
+
+// NewMapnikRendererSize creates a new Mapnik renderer producing width×height images.
+func NewMapnikRendererSize(styleFile string, width, height int) (*MapnikRenderer, error) {
 	// Initialize Mapnik (must be called once)
 	if err := mapnik.RegisterDatasources("/usr/lib/mapnik/3.1/input"); err != nil {
 		return nil, fmt.Errorf("failed to register datasources: %w", err)
 	}
 
 	// Create map object with specified size
-	m := mapnik.NewSized(tileSize, tileSize)
+	m := mapnik.NewSized(width, height)
 
 	// Load style from XML file
 	if styleFile != "" {
@@ -47,7 +53,8 @@ func NewMapnikRenderer(styleFile string, tileSize int) (*MapnikRenderer, error)
 
 	return &MapnikRenderer{
 		mapObject: m,
-		tileSize:  tileSize,
+		width:     width,
+		height:    height,
 	}, nil
 }
 
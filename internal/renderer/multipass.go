@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/MeKo-Tech/watercolormap/internal/geojson"
@@ -11,14 +13,28 @@ import (
 	"github.com/MeKo-Tech/watercolormap/internal/types"
 )
 
+// DefaultBufferPx is the Mapnik buffer size, in pixels, a MultiPassRenderer renders with
+// unless the padding is larger or SetBufferSize is called.
+const DefaultBufferPx = 128
+
 // MultiPassRenderer renders tiles in multiple passes, one per layer
 type MultiPassRenderer struct {
 	mapnikRenderer *MapnikRenderer
 	stylesDir      string
 	outputDir      string
 	tempDir        string
-	baseTileSize   int
+	baseWidth      int
+	baseHeight     int
 	padPx          int
+	overrides      map[geojson.LayerType]LayerRenderOverride
+	grid           tile.Grid
+}
+
+// LayerRenderOverride adjusts how a layer's Mapnik style renders without editing its XML,
+// e.g. to iterate on road widths.
+type LayerRenderOverride struct {
+	// LineWidthScale multiplies every stroke-width in the layer's style (0 or 1 = unchanged)
+	LineWidthScale float64
 }
 
 // LayerRenderResult contains the result of rendering a single layer
@@ -41,27 +57,28 @@ type TileRenderResult struct {
 // This provides real pixels outside the final tile area, which is important for
 // post-processing blurs (watercolor masks, edge halos) to avoid seams.
 func NewMultiPassRenderer(stylesDir, outputDir string, tileSize int, padPx int) (*MultiPassRenderer, error) {
-	if tileSize <= 0 {
+	return NewMultiPassRendererSize(stylesDir, outputDir, tileSize, tileSize, padPx)
+}
+
+// NewMultiPassRendererSize is like NewMultiPassRenderer but renders a width×height
+// (before padding) image, for non-square areas rendered with RenderBounds.
+func NewMultiPassRendererSize(stylesDir, outputDir string, width, height int, padPx int) (*MultiPassRenderer, error) {
+	if width <= 0 || height <= 0 {
 		return nil, fmt.Errorf("tile size must be positive")
 	}
 	if padPx < 0 {
 		padPx = 0
 	}
-	renderSize := tileSize + 2*padPx
 
-	// Create Mapnik renderer (empty style file, requested tile size)
-	mapnikRenderer, err := NewMapnikRenderer("", renderSize)
+	// Create Mapnik renderer (empty style file, requested padded size)
+	mapnikRenderer, err := NewMapnikRendererSize("", width+2*padPx, height+2*padPx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create Mapnik renderer: %w", err)
+		return nil, &RenderError{Err: fmt.Errorf("failed to create Mapnik renderer: %w", err)}
 	}
 
 	// Set buffer size to ensure features near the render bounds aren't clipped.
 	// When padPx is used we keep the buffer at least as large as the pad.
-	buf := 128
-	if padPx > buf {
-		buf = padPx
-	}
-	mapnikRenderer.SetBufferSize(buf)
+	mapnikRenderer.SetBufferSize(max(DefaultBufferPx, padPx))
 
 	// Create temp directory for GeoJSON files
 	tempDir := filepath.Join(os.TempDir(), "watercolormap")
@@ -79,11 +96,33 @@ func NewMultiPassRenderer(stylesDir, outputDir string, tileSize int, padPx int)
 		stylesDir:      stylesDir,
 		outputDir:      outputDir,
 		tempDir:        tempDir,
-		baseTileSize:   tileSize,
+		baseWidth:      width,
+		baseHeight:     height,
 		padPx:          padPx,
+		grid:           tile.WebMercator,
 	}, nil
 }
 
+// SetOverrides sets per-layer style adjustments applied when each layer's style is loaded.
+// Layers without an entry render their style unchanged.
+func (r *MultiPassRenderer) SetOverrides(overrides map[geojson.LayerType]LayerRenderOverride) {
+	r.overrides = overrides
+}
+
+// SetGrid sets the tile grid RenderTile and RenderMetatile place tiles in (default
+// tile.WebMercator).
+func (r *MultiPassRenderer) SetGrid(g tile.Grid) {
+	r.grid = g
+}
+
+// SetBufferSize sets the Mapnik buffer size in pixels (default DefaultBufferPx): Mapnik
+// queries and draws features within this margin around the render bounds, so strokes and
+// casings of features just outside aren't cut off at the image edge. Unlike padPx the
+// margin isn't part of the output image. It is kept at least as large as padPx.
+func (r *MultiPassRenderer) SetBufferSize(px int) {
+	r.mapnikRenderer.SetBufferSize(max(px, r.padPx))
+}
+
 // Close cleans up resources
 func (r *MultiPassRenderer) Close() error {
 	return r.mapnikRenderer.Close()
@@ -91,6 +130,32 @@ func (r *MultiPassRenderer) Close() error {
 
 // RenderTile renders all layers for a single tile
 func (r *MultiPassRenderer) RenderTile(coords tile.Coords, data *types.TileData) (*TileRenderResult, error) {
+	return r.renderArea(coords, r.grid.BoundsMercator(coords), data)
+}
+
+// RenderMetatile renders all layers for an n×n block of tiles whose top-left tile is origin.
+// The renderer must have been created with a tile size of n times the per-tile size so that
+// the render size and padding cover the whole block.
+func (r *MultiPassRenderer) RenderMetatile(origin tile.Coords, n int, data *types.TileData) (*TileRenderResult, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("metatile size must be positive")
+	}
+	last := tile.NewCoords(origin.Z, origin.X+uint32(n-1), origin.Y+uint32(n-1))
+	first := r.grid.BoundsMercator(origin)
+	end := r.grid.BoundsMercator(last)
+	bounds := [4]float64{first[0], end[1], end[2], first[3]}
+	return r.renderArea(origin, bounds, data)
+}
+
+// RenderBounds renders all layers for arbitrary Web Mercator bounds (minX, minY, maxX, maxY).
+// The bounds should have the same aspect ratio as the renderer's width×height, otherwise Mapnik
+// grows them to fit. coords only names the temporary and output files.
+func (r *MultiPassRenderer) RenderBounds(coords tile.Coords, bounds [4]float64, data *types.TileData) (*TileRenderResult, error) {
+	return r.renderArea(coords, bounds, data)
+}
+
+// renderArea renders all layers for the given Web Mercator bounds (before padding).
+func (r *MultiPassRenderer) renderArea(coords tile.Coords, bounds [4]float64, data *types.TileData) (*TileRenderResult, error) {
 	result := &TileRenderResult{
 		TileCoords: coords,
 		Layers:     make(map[geojson.LayerType]*LayerRenderResult),
@@ -102,19 +167,19 @@ func (r *MultiPassRenderer) RenderTile(coords tile.Coords, data *types.TileData)
 		geojson.LayerWater,     // Water bodies
 		geojson.LayerRivers,    // Rivers and streams (linear waterways)
 		geojson.LayerParks,     // Parks and green spaces
+		geojson.LayerForest,    // Forests and woods (subset of parks)
 		geojson.LayerUrban,     // Civic buildings and areas
 		geojson.LayerBuildings, // Buildings (darker lavender)
 		geojson.LayerRoads,     // All roads (white mask; used for cutouts)
 		geojson.LayerHighways,  // Major roads/highways (yellow)
 	}
 
-	// Get bounds for the tile and expand when rendering a metatile.
-	bounds := coords.BoundsMercator()
+	// Expand bounds when rendering a padded metatile.
 	if r.padPx > 0 {
 		w := bounds[2] - bounds[0]
 		h := bounds[3] - bounds[1]
-		padX := w * float64(r.padPx) / float64(r.baseTileSize)
-		padY := h * float64(r.padPx) / float64(r.baseTileSize)
+		padX := w * float64(r.padPx) / float64(r.baseWidth)
+		padY := h * float64(r.padPx) / float64(r.baseHeight)
 		bounds = [4]float64{bounds[0] - padX, bounds[1] - padY, bounds[2] + padX, bounds[3] + padY}
 	}
 
@@ -124,6 +189,7 @@ func (r *MultiPassRenderer) RenderTile(coords tile.Coords, data *types.TileData)
 		result.Layers[layer] = layerResult
 
 		if layerResult.Error != nil {
+			layerResult.Error = &RenderError{Layer: layer, Err: layerResult.Error}
 			// Log error but continue with other layers
 			fmt.Printf("Warning: Failed to render layer %s for tile %s: %v\n",
 				layer, coords.String(), layerResult.Error)
@@ -133,6 +199,12 @@ func (r *MultiPassRenderer) RenderTile(coords tile.Coords, data *types.TileData)
 	return result, nil
 }
 
+// optionalLayers may be missing from a styles directory without failing the render. Forests
+// are painted over parks, so without their style the parks layer alone shows all greens.
+var optionalLayers = map[geojson.LayerType]bool{
+	geojson.LayerForest: true,
+}
+
 // renderLayer renders a single layer
 func (r *MultiPassRenderer) renderLayer(
 	coords tile.Coords,
@@ -147,6 +219,10 @@ func (r *MultiPassRenderer) renderLayer(
 	// Get style file path
 	stylePath := filepath.Join(r.stylesDir, "layers", fmt.Sprintf("%s.xml", layer))
 	if _, err := os.Stat(stylePath); err != nil {
+		if optionalLayers[layer] {
+			// Older style directories predate the layer; render it as empty
+			return result
+		}
 		result.Error = fmt.Errorf("style file not found: %s", stylePath)
 		return result
 	}
@@ -192,6 +268,7 @@ func (r *MultiPassRenderer) renderLayer(
 	modifiedStyleXML := strings.ReplaceAll(string(styleXML), "DATASOURCE_PLACEHOLDER", geoJSONPath)
 	geoJSONLayerName := strings.TrimSuffix(filepath.Base(geoJSONPath), filepath.Ext(geoJSONPath))
 	modifiedStyleXML = strings.ReplaceAll(modifiedStyleXML, "LAYER_PLACEHOLDER", geoJSONLayerName)
+	modifiedStyleXML = scaleStrokeWidths(modifiedStyleXML, r.overrides[layer].LineWidthScale)
 
 	// Load style into Mapnik
 	if err := r.mapnikRenderer.LoadXML(modifiedStyleXML); err != nil {
@@ -267,3 +344,21 @@ func LayerExists(outputDir string, coords tile.Coords, layer geojson.LayerType)
 	_, err := os.Stat(path)
 	return err == nil
 }
+
+// strokeWidthAttr matches the stroke-width attribute of a Mapnik symbolizer.
+var strokeWidthAttr = regexp.MustCompile(`stroke-width="([0-9]*\.?[0-9]+)"`)
+
+// scaleStrokeWidths multiplies every stroke-width in a style XML by scale. A scale of 0 or 1
+// returns the style unchanged.
+func scaleStrokeWidths(styleXML string, scale float64) string {
+	if scale <= 0 || scale == 1 {
+		return styleXML
+	}
+	return strokeWidthAttr.ReplaceAllStringFunc(styleXML, func(attr string) string {
+		width, err := strconv.ParseFloat(strokeWidthAttr.FindStringSubmatch(attr)[1], 64)
+		if err != nil {
+			return attr
+		}
+		return `stroke-width="` + strconv.FormatFloat(width*scale, 'f', -1, 64) + `"`
+	})
+}
@@ -0,0 +1,94 @@
+package renderer
+
+import (
+	"image/png"
+	"os"
+	"testing"
+
+	"github.com/MeKo-Christian/go-overpass"
+	"github.com/MeKo-Tech/watercolormap/internal/datasource"
+	"github.com/MeKo-Tech/watercolormap/internal/geojson"
+	"github.com/MeKo-Tech/watercolormap/internal/tile"
+	"github.com/MeKo-Tech/watercolormap/internal/types"
+)
+
+// TestRenderMultipolygonHole renders a lake relation with an island and checks that the
+// island is cut out of the water layer's alpha mask.
+func TestRenderMultipolygonHole(t *testing.T) {
+	requireIntegration(t)
+
+	renderer, err := NewMultiPassRenderer("../../assets/styles", t.TempDir(), 256, 0)
+	if err != nil {
+		t.Fatalf("failed to create renderer: %v", err)
+	}
+	defer renderer.Close()
+
+	coords := tile.NewCoords(13, 4317, 2692)
+	bounds := coords.Bounds()
+	minLon, minLat := bounds[0], bounds[1]
+	w := bounds[2] - bounds[0]
+	h := bounds[3] - bounds[1]
+
+	// ring builds a closed ring covering the given fraction of the tile (0..1 on both axes).
+	ring := func(id int64, x0, y0, x1, y1 float64) *overpass.Way {
+		return &overpass.Way{
+			Meta: overpass.Meta{ID: id},
+			Geometry: []overpass.Point{
+				{Lat: minLat + y0*h, Lon: minLon + x0*w},
+				{Lat: minLat + y0*h, Lon: minLon + x1*w},
+				{Lat: minLat + y1*h, Lon: minLon + x1*w},
+				{Lat: minLat + y1*h, Lon: minLon + x0*w},
+				{Lat: minLat + y0*h, Lon: minLon + x0*w},
+			},
+		}
+	}
+
+	result := &overpass.Result{
+		Relations: map[int64]*overpass.Relation{
+			1: {
+				Meta: overpass.Meta{
+					ID:   1,
+					Tags: map[string]string{"type": "multipolygon", "natural": "water"},
+				},
+				Members: []overpass.RelationMember{
+					{Type: "way", Way: ring(10, -0.1, -0.1, 1.1, 1.1), Role: "outer"},
+					{Type: "way", Way: ring(11, 0.3, 0.3, 0.7, 0.7), Role: "inner"},
+				},
+			},
+		},
+	}
+
+	data := &types.TileData{Features: datasource.ExtractFeaturesFromOverpassResult(result)}
+	if len(data.Features.Water) != 1 {
+		t.Fatalf("expected 1 water feature, got %d", len(data.Features.Water))
+	}
+
+	rendered, err := renderer.RenderTile(coords, data)
+	if err != nil {
+		t.Fatalf("failed to render tile: %v", err)
+	}
+
+	waterLayer := rendered.Layers[geojson.LayerWater]
+	if waterLayer == nil || waterLayer.OutputPath == "" {
+		t.Fatal("no water layer output")
+	}
+
+	f, err := os.Open(waterLayer.OutputPath)
+	if err != nil {
+		t.Fatalf("failed to open water layer: %v", err)
+	}
+	defer f.Close()
+
+	img, err := png.Decode(f)
+	if err != nil {
+		t.Fatalf("failed to decode water layer: %v", err)
+	}
+
+	b := img.Bounds()
+	if _, _, _, a := img.At(b.Min.X+b.Dx()/2, b.Min.Y+b.Dy()/2).RGBA(); a != 0 {
+		t.Errorf("hole centre should be transparent, got alpha %d", a>>8)
+	}
+	if _, _, _, a := img.At(b.Min.X+b.Dx()/10, b.Min.Y+b.Dy()/10).RGBA(); a == 0 {
+		t.Error("expected water outside the island")
+	}
+}
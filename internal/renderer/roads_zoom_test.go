@@ -3,6 +3,7 @@ package renderer
 import (
 	"image/png"
 	"os"
+	"strconv"
 	"testing"
 
 	"github.com/MeKo-Tech/watercolormap/internal/geojson"
@@ -121,3 +122,74 @@ func TestRoadStrokeScalesWithZoom(t *testing.T) {
 		t.Fatalf("expected thicker primary roads at higher zoom: z11=%dpx, z14=%dpx", widths[11], widths[14])
 	}
 }
+
+func TestScaleStrokeWidths(t *testing.T) {
+	styleXML, err := os.ReadFile("../../assets/styles/layers/roads.xml")
+	if err != nil {
+		t.Fatalf("failed to read roads style: %v", err)
+	}
+	style := string(styleXML)
+
+	// The defaults reproduce the style exactly
+	for _, scale := range []float64{0, 1} {
+		if got := scaleStrokeWidths(style, scale); got != style {
+			t.Errorf("scale %v changed the style", scale)
+		}
+	}
+
+	scaled := scaleStrokeWidths(style, 2)
+	before := strokeWidthAttr.FindAllStringSubmatch(style, -1)
+	after := strokeWidthAttr.FindAllStringSubmatch(scaled, -1)
+	if len(before) == 0 || len(after) != len(before) {
+		t.Fatalf("found %d stroke widths after scaling, want %d", len(after), len(before))
+	}
+	for i := range before {
+		w, _ := strconv.ParseFloat(before[i][1], 64)
+		got, _ := strconv.ParseFloat(after[i][1], 64)
+		if got != 2*w {
+			t.Errorf("stroke-width %v scaled to %v, want %v", w, got, 2*w)
+		}
+	}
+}
+
+func TestLineWidthScaleOverride(t *testing.T) {
+	requireIntegration(t)
+
+	// A north-south primary road: a row crosses it once, so the run is its stroke width
+	center := tile.NewCoords(14, 8634, 5384)
+	lon, lat := center.Center()
+	data := &types.TileData{
+		Features: types.FeatureCollection{
+			Roads: []types.Feature{{
+				ID:         "test-primary",
+				Type:       types.FeatureTypeRoad,
+				Geometry:   orb.LineString{{lon, lat - 0.02}, {lon, lat + 0.02}},
+				Properties: map[string]interface{}{"highway": "secondary"},
+			}},
+		},
+	}
+
+	widths := make(map[float64]int)
+	for _, scale := range []float64{1, 2.5} {
+		renderer, err := NewMultiPassRenderer("../../assets/styles", t.TempDir(), 256, 0)
+		if err != nil {
+			t.Fatalf("failed to create renderer: %v", err)
+		}
+		renderer.SetOverrides(map[geojson.LayerType]LayerRenderOverride{geojson.LayerRoads: {LineWidthScale: scale}})
+
+		result, err := renderer.RenderTile(center, data)
+		if err != nil {
+			t.Fatalf("failed to render roads at scale %v: %v", scale, err)
+		}
+		roadsLayer := result.Layers[geojson.LayerRoads]
+		if roadsLayer == nil || roadsLayer.OutputPath == "" {
+			t.Fatalf("no roads layer output at scale %v", scale)
+		}
+		widths[scale] = measureRoadWidth(t, roadsLayer.OutputPath)
+		renderer.Close() // nolint:errcheck
+	}
+
+	if widths[2.5] < 2*widths[1] {
+		t.Errorf("expected the override to widen the road: %dpx at scale 1, %dpx at 2.5", widths[1], widths[2.5])
+	}
+}
@@ -0,0 +1,176 @@
+package server
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", ""},
+		{"identity", ""},
+		{"gzip", "gzip"},
+		{"deflate", "deflate"},
+		{"deflate, gzip;q=0.5", "gzip"},
+		{"gzip;q=0, deflate", "deflate"},
+		{"br, *", "gzip"},
+		{"*;q=0", ""},
+		{"GZIP", "gzip"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			if got := negotiateEncoding(tt.header); got != tt.want {
+				t.Errorf("negotiateEncoding(%q) = %q, want %q", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompressJSON(t *testing.T) {
+	body := strings.Repeat(`{"queued":0,"generating":1}`, 50)
+	h := Compress(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, body)
+	}))
+
+	tests := []struct {
+		name     string
+		accept   string
+		encoding string
+		decode   func(io.Reader) (io.Reader, error)
+	}{
+		{"identity", "", "", func(r io.Reader) (io.Reader, error) { return r, nil }},
+		{"gzip", "gzip", "gzip", func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) }},
+		{"deflate", "deflate", "deflate", func(r io.Reader) (io.Reader, error) { return flate.NewReader(r), nil }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/tiles/status", nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept-Encoding", tt.accept)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if got := rec.Header().Get("Content-Encoding"); got != tt.encoding {
+				t.Fatalf("Content-Encoding = %q, want %q", got, tt.encoding)
+			}
+			if got := rec.Header().Get("Vary"); got != "Accept-Encoding" {
+				t.Errorf("Vary = %q, want Accept-Encoding", got)
+			}
+			r, err := tt.decode(rec.Body)
+			if err != nil {
+				t.Fatalf("failed to open decoder: %v", err)
+			}
+			got, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatalf("failed to decode body: %v", err)
+			}
+			if string(got) != body {
+				t.Errorf("decoded body mismatch: got %d bytes, want %d", len(got), len(body))
+			}
+		})
+	}
+}
+
+func TestCompressLeavesBodilessResponsesAlone(t *testing.T) {
+	h := Compress(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("Content-Encoding"); got != "" {
+		t.Errorf("expected no Content-Encoding on 204, got %q", got)
+	}
+	if rec.Body.Len() != 0 {
+		t.Errorf("expected empty body, got %d bytes", rec.Body.Len())
+	}
+}
+
+// TestCompressSSEFlushes checks that each flushed event is readable by the client
+// while the stream is still open, i.e. compression does not hold events back.
+func TestCompressSSEFlushes(t *testing.T) {
+	release := make(chan struct{})
+	h := Compress(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "SSE not supported", http.StatusInternalServerError)
+			return
+		}
+		for i := 0; i < 2; i++ {
+			fmt.Fprintf(w, "data: %d\n\n", i)
+			flusher.Flush()
+		}
+		<-release
+	}))
+
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+	defer close(release)
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	if err != nil {
+		t.Fatalf("failed to build request: %v", err)
+	}
+	// Setting the header explicitly disables the transport's transparent decompression.
+	req.Header.Set("Accept-Encoding", "gzip")
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if got := resp.Header.Get("Content-Encoding"); got != "gzip" {
+		t.Fatalf("Content-Encoding = %q, want gzip", got)
+	}
+
+	lines := make(chan string)
+	go func() {
+		zr, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			close(lines)
+			return
+		}
+		sc := bufio.NewScanner(zr)
+		for sc.Scan() {
+			if sc.Text() != "" {
+				lines <- sc.Text()
+			}
+		}
+		close(lines)
+	}()
+
+	for i := 0; i < 2; i++ {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				t.Fatalf("stream ended before event %d", i)
+			}
+			if want := fmt.Sprintf("data: %d", i); line != want {
+				t.Errorf("event %d = %q, want %q", i, line, want)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("event %d was not flushed through the compressor", i)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// CORS is a middleware adding Cross-Origin Resource Sharing headers for an allowlist of
+// origins. Browser-based playgrounds (including GitHub Pages) need them to request tiles and
+// status; note that HTTPS pages cannot fetch from HTTP backends due to mixed-content rules.
+//
+// With the wildcard "*" every origin is allowed with "Access-Control-Allow-Origin: *".
+// Otherwise the request's Origin is echoed back only if it is on the list, and responses
+// carry "Vary: Origin" so shared caches keep the answers per origin apart. A nil *CORS
+// allows every origin.
+type CORS struct {
+	any     bool
+	origins map[string]bool
+}
+
+// NewCORS parses a comma-separated list of origins (e.g. "https://a.example,
+// http://localhost:8080") or "*". An empty list allows every origin, like "*".
+func NewCORS(list string) (*CORS, error) {
+	c := &CORS{origins: make(map[string]bool)}
+	for _, origin := range strings.Split(list, ",") {
+		origin = strings.TrimSpace(origin)
+		switch {
+		case origin == "":
+		case origin == "*":
+			c.any = true
+		default:
+			u, err := url.Parse(origin)
+			if err != nil || u.Scheme == "" || u.Host == "" || (u.Path != "" && u.Path != "/") || u.RawQuery != "" {
+				return nil, fmt.Errorf("invalid CORS origin %q: want scheme://host[:port]", origin)
+			}
+			// Browsers send origins without a trailing slash
+			c.origins[strings.ToLower(u.Scheme+"://"+u.Host)] = true
+		}
+	}
+	if len(c.origins) == 0 {
+		c.any = true
+	}
+	return c, nil
+}
+
+// allowOrigin returns the Access-Control-Allow-Origin value for a request from origin, or ""
+// if it isn't allowed.
+func (c *CORS) allowOrigin(origin string) string {
+	if c == nil || c.any {
+		return "*"
+	}
+	if origin != "" && c.origins[strings.ToLower(origin)] {
+		return origin
+	}
+	return ""
+}
+
+// Middleware adds the CORS headers to next's responses and answers preflight (OPTIONS)
+// requests with 204 No Content itself.
+func (c *CORS) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h := w.Header()
+		if c != nil && !c.any {
+			h.Add("Vary", "Origin")
+		}
+		if allowed := c.allowOrigin(r.Header.Get("Origin")); allowed != "" {
+			h.Set("Access-Control-Allow-Origin", allowed)
+		}
+		h.Set("Access-Control-Allow-Methods", "GET, HEAD, OPTIONS")
+		h.Set("Access-Control-Allow-Headers", "Content-Type")
+
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCORSMiddleware(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("tile"))
+	})
+
+	tests := []struct {
+		name       string
+		origins    string
+		method     string
+		origin     string
+		wantStatus int
+		wantAllow  string
+		wantVary   bool
+	}{
+		{name: "wildcard", origins: "*", method: http.MethodGet, origin: "https://any.example", wantStatus: http.StatusOK, wantAllow: "*"},
+		{name: "wildcard without origin", origins: "*", method: http.MethodGet, wantStatus: http.StatusOK, wantAllow: "*"},
+		{name: "empty list is wildcard", origins: "", method: http.MethodGet, origin: "https://any.example", wantStatus: http.StatusOK, wantAllow: "*"},
+		{name: "allowed", origins: "https://maps.example, http://localhost:8080", method: http.MethodGet, origin: "http://localhost:8080", wantStatus: http.StatusOK, wantAllow: "http://localhost:8080", wantVary: true},
+		{name: "allowed case-insensitive", origins: "https://Maps.example/", method: http.MethodGet, origin: "https://maps.example", wantStatus: http.StatusOK, wantAllow: "https://maps.example", wantVary: true},
+		{name: "disallowed", origins: "https://maps.example", method: http.MethodGet, origin: "https://evil.example", wantStatus: http.StatusOK, wantVary: true},
+		{name: "missing origin", origins: "https://maps.example", method: http.MethodGet, wantStatus: http.StatusOK, wantVary: true},
+		{name: "preflight allowed", origins: "https://maps.example", method: http.MethodOptions, origin: "https://maps.example", wantStatus: http.StatusNoContent, wantAllow: "https://maps.example", wantVary: true},
+		{name: "preflight disallowed", origins: "https://maps.example", method: http.MethodOptions, origin: "https://evil.example", wantStatus: http.StatusNoContent, wantVary: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cors, err := NewCORS(tt.origins)
+			if err != nil {
+				t.Fatalf("NewCORS(%q): %v", tt.origins, err)
+			}
+			req := httptest.NewRequest(tt.method, "/tiles/z1_x0_y0.png", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			rec := httptest.NewRecorder()
+			cors.Middleware(next).ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := rec.Header().Get("Access-Control-Allow-Origin"); got != tt.wantAllow {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.wantAllow)
+			}
+			if got := rec.Header().Get("Vary") == "Origin"; got != tt.wantVary {
+				t.Errorf("Vary: Origin = %v, want %v", got, tt.wantVary)
+			}
+			if got := rec.Header().Get("Access-Control-Allow-Methods"); got != "GET, HEAD, OPTIONS" {
+				t.Errorf("Access-Control-Allow-Methods = %q", got)
+			}
+			if tt.method == http.MethodOptions && rec.Body.Len() != 0 {
+				t.Errorf("preflight reached the handler: body %q", rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestNewCORSInvalid(t *testing.T) {
+	for _, origins := range []string{"maps.example", "https://maps.example/tiles", "https://"} {
+		if _, err := NewCORS(origins); err == nil {
+			t.Errorf("NewCORS(%q): expected an error", origins)
+		}
+	}
+}
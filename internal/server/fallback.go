@@ -0,0 +1,151 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MeKo-Tech/watercolormap/internal/tile"
+)
+
+// maxFallbackTileBytes bounds the size of an upstream tile, so a misconfigured upstream
+// can't make the server buffer arbitrary responses.
+const maxFallbackTileBytes = 16 << 20
+
+// validateFallbackURL checks that an upstream URL template addresses tiles by {z}, {x} and {y}.
+func validateFallbackURL(template string) error {
+	for _, p := range []string{"{z}", "{x}", "{y}"} {
+		if !strings.Contains(template, p) {
+			return fmt.Errorf("fallback URL %q lacks the %s placeholder", template, p)
+		}
+	}
+	return nil
+}
+
+// fallbackURL returns the upstream URL of a tile, or false if the request can't be proxied:
+// outside the fallback zoom range, a seed override (upstream tiles have one fixed look) or
+// an @2x request when the template has no {r} placeholder for the retina suffix.
+func (t *OnDemandTiles) fallbackURL(coords tile.Coords, suffix string) (string, bool) {
+	template := t.cfg.FallbackURL
+	if template == "" || int(coords.Z) < t.cfg.FallbackMinZoom || int(coords.Z) > t.cfg.FallbackMaxZoom {
+		return "", false
+	}
+	retina := ""
+	switch suffix {
+	case "":
+	case "@2x":
+		if !strings.Contains(template, "{r}") {
+			return "", false
+		}
+		retina = "@2x"
+	default:
+		return "", false
+	}
+	return strings.NewReplacer(
+		"{z}", strconv.FormatUint(uint64(coords.Z), 10),
+		"{x}", strconv.FormatUint(uint64(coords.X), 10),
+		"{y}", strconv.FormatUint(uint64(coords.Y), 10),
+		"{r}", retina,
+	).Replace(template), true
+}
+
+// serveFallback fetches a tile from the upstream tile source and serves it, reporting
+// whether it did. Upstream PNGs are cached at fullPath like generated tiles; other image
+// types are passed through with their content type but not cached, since cached tiles are
+// named .png. Any failure (timeout, error status, non-image response) is logged and leaves
+// the response untouched so the caller can generate the tile locally.
+func (t *OnDemandTiles) serveFallback(w http.ResponseWriter, r *http.Request, url, fullPath string) bool {
+	ctx, cancel := context.WithTimeout(r.Context(), t.cfg.FallbackTimeout)
+	defer cancel()
+
+	start := time.Now()
+	body, contentType, err := fetchFallback(ctx, url)
+	if err != nil {
+		t.log().Warn("fallback tile fetch failed; generating locally", "url", url, "error", err)
+		return false
+	}
+	t.log().Info("tile served from fallback", "url", url, "bytes", len(body), "ms", time.Since(start).Milliseconds())
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType == "image/png" {
+		if err := writeFileAtomic(fullPath, body); err != nil {
+			t.log().Warn("failed to cache fallback tile", "path", fullPath, "error", err)
+		} else {
+			http.ServeFile(w, r, fullPath)
+			return true
+		}
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	_, _ = w.Write(body)
+	return true
+}
+
+// fetchFallback downloads an upstream tile and returns its body and content type.
+func fetchFallback(ctx context.Context, url string) ([]byte, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close() // nolint:errcheck
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("upstream returned %s", resp.Status)
+	}
+	contentType := resp.Header.Get("Content-Type")
+	if !strings.HasPrefix(contentType, "image/") {
+		return nil, "", fmt.Errorf("upstream returned content type %q, want an image", contentType)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxFallbackTileBytes+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read upstream tile: %w", err)
+	}
+	if len(body) > maxFallbackTileBytes {
+		return nil, "", fmt.Errorf("upstream tile exceeds %d bytes", maxFallbackTileBytes)
+	}
+	return body, contentType, nil
+}
+
+// writeFileAtomic writes data to path through a temporary file, so concurrent readers never
+// see a partial tile.
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create tile dir: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, ".fallback-*.png")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()           // nolint:errcheck
+		os.Remove(tmp.Name()) // nolint:errcheck
+		return fmt.Errorf("failed to set tile permissions: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()           // nolint:errcheck
+		os.Remove(tmp.Name()) // nolint:errcheck
+		return fmt.Errorf("failed to write tile: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name()) // nolint:errcheck
+		return fmt.Errorf("failed to write tile: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name()) // nolint:errcheck
+		return fmt.Errorf("failed to move tile into place: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package server
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestServeTileFallback(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\nupstream")
+	jpeg := []byte("\xff\xd8\xffupstream")
+
+	var requests atomic.Int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		switch r.URL.Path {
+		case "/2/1/1.png", "/2/1/1@2x.png":
+			w.Header().Set("Content-Type", "image/png")
+			_, _ = w.Write(png)
+		case "/2/2/1.png":
+			w.Header().Set("Content-Type", "image/jpeg")
+			_, _ = w.Write(jpeg)
+		case "/2/3/1.png":
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = w.Write([]byte("<html>maintenance</html>"))
+		case "/2/0/0.png":
+			time.Sleep(200 * time.Millisecond)
+			w.Header().Set("Content-Type", "image/png")
+			_, _ = w.Write(png)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer upstream.Close()
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+		wantType   string
+		wantBody   []byte
+		wantCached bool
+		wantFetch  bool
+	}{
+		{name: "png is cached", path: "/tiles/z2_x1_y1.png", wantStatus: http.StatusOK, wantType: "image/png", wantBody: png, wantCached: true, wantFetch: true},
+		{name: "retina", path: "/tiles/z2_x1_y1@2x.png", wantStatus: http.StatusOK, wantType: "image/png", wantBody: png, wantCached: true, wantFetch: true},
+		{name: "jpeg passes through", path: "/tiles/z2_x2_y1.png", wantStatus: http.StatusOK, wantType: "image/jpeg", wantBody: jpeg, wantFetch: true},
+		{name: "non-image", path: "/tiles/z2_x3_y1.png", wantStatus: http.StatusNotFound, wantFetch: true},
+		{name: "upstream 404", path: "/tiles/z2_x2_y2.png", wantStatus: http.StatusNotFound, wantFetch: true},
+		{name: "timeout", path: "/tiles/z2_x0_y0.png", wantStatus: http.StatusNotFound, wantFetch: true},
+		{name: "seed override", path: "/tiles/z2_x1_y1_s42.png", wantStatus: http.StatusNotFound},
+		{name: "above zoom range", path: "/tiles/z9_x1_y1.png", wantStatus: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tilesDir := t.TempDir()
+			// Local generation is off, so a failed fallback answers 404 instead of rendering
+			od, err := NewOnDemandTiles(nil, OnDemandTilesConfig{
+				TilesDir:        tilesDir,
+				FallbackURL:     upstream.URL + "/{z}/{x}/{y}{r}.png",
+				FallbackMinZoom: 0,
+				FallbackMaxZoom: 8,
+				FallbackTimeout: 50 * time.Millisecond,
+			}, nil)
+			if err != nil {
+				t.Fatalf("NewOnDemandTiles: %v", err)
+			}
+			defer od.Stop()
+
+			before := requests.Load()
+			rec := httptest.NewRecorder()
+			od.serveTile(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("GET %s: status = %d, want %d", tt.path, rec.Code, tt.wantStatus)
+			}
+			if fetched := requests.Load() > before; fetched != tt.wantFetch {
+				t.Errorf("upstream fetched = %v, want %v", fetched, tt.wantFetch)
+			}
+			if tt.wantStatus == http.StatusOK {
+				if got := rec.Header().Get("Content-Type"); got != tt.wantType {
+					t.Errorf("Content-Type = %q, want %q", got, tt.wantType)
+				}
+				if !bytes.Equal(rec.Body.Bytes(), tt.wantBody) {
+					t.Errorf("body = %q, want %q", rec.Body.Bytes(), tt.wantBody)
+				}
+			}
+
+			entries, err := os.ReadDir(tilesDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cached := len(entries) > 0; cached != tt.wantCached {
+				t.Fatalf("cached = %v (%d files), want %v", cached, len(entries), tt.wantCached)
+			}
+			if !tt.wantCached {
+				return
+			}
+			if entries[0].Name() != filepath.Base(tt.path) {
+				t.Errorf("cached %s, want %s", entries[0].Name(), filepath.Base(tt.path))
+			}
+
+			// The second request is served from the cache
+			before = requests.Load()
+			rec = httptest.NewRecorder()
+			od.serveTile(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rec.Code != http.StatusOK || requests.Load() != before {
+				t.Errorf("second GET: status %d, upstream fetched %v; want a cache hit", rec.Code, requests.Load() != before)
+			}
+		})
+	}
+}
+
+func TestValidateFallbackURL(t *testing.T) {
+	if err := validateFallbackURL("https://tiles.example.com/{z}/{x}/{y}{r}.png"); err != nil {
+		t.Errorf("expected a valid template, got %v", err)
+	}
+	if err := validateFallbackURL("https://tiles.example.com/{z}/{x}.png"); err == nil {
+		t.Error("expected an error for a template without {y}")
+	}
+	if _, err := NewOnDemandTiles(nil, OnDemandTilesConfig{TilesDir: t.TempDir(), FallbackURL: "https://tiles.example.com/"}, nil); err == nil {
+		t.Error("expected NewOnDemandTiles to reject an invalid fallback URL")
+	}
+}
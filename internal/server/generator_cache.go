@@ -0,0 +1,85 @@
+package server
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/MeKo-Tech/watercolormap/internal/pipeline"
+)
+
+// maxSeedGenerators bounds the generators kept for seed overrides. Each one holds its own
+// textures and data caches, so clients cycling through seeds must not accumulate them.
+const maxSeedGenerators = 4
+
+// generatorKey identifies a cached generator.
+type generatorKey struct {
+	tileSize int
+	seed     int64
+}
+
+type generatorEntry struct {
+	key generatorKey
+	gen *pipeline.Generator
+}
+
+// generatorCache keeps the most recently used generators up to a size limit. It is safe for
+// concurrent use.
+type generatorCache struct {
+	mu      sync.Mutex
+	maxGens int
+	gens    map[generatorKey]*list.Element // Values are *generatorEntry
+	lru     *list.List                     // Most recently used at the front
+}
+
+func newGeneratorCache(maxGens int) *generatorCache {
+	return &generatorCache{
+		maxGens: max(maxGens, 1),
+		gens:    make(map[generatorKey]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// get returns the generator for key, creating it with create on a miss. Concurrent misses
+// may both create one; the first stored is kept. Generators evicted while in use stay valid
+// for their callers.
+func (c *generatorCache) get(key generatorKey, create func() (*pipeline.Generator, error)) (*pipeline.Generator, error) {
+	if g, ok := c.lookup(key); ok {
+		return g, nil
+	}
+	g, err := create()
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.gens[key]; ok {
+		c.lru.MoveToFront(e)
+		return e.Value.(*generatorEntry).gen, nil
+	}
+	c.gens[key] = c.lru.PushFront(&generatorEntry{key: key, gen: g})
+	for c.lru.Len() > c.maxGens {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.gens, oldest.Value.(*generatorEntry).key)
+	}
+	return g, nil
+}
+
+func (c *generatorCache) lookup(key generatorKey) (*pipeline.Generator, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.gens[key]
+	if !ok {
+		return nil, false
+	}
+	c.lru.MoveToFront(e)
+	return e.Value.(*generatorEntry).gen, true
+}
+
+// Len returns the number of cached generators.
+func (c *generatorCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
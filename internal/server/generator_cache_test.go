@@ -0,0 +1,68 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/MeKo-Tech/watercolormap/internal/pipeline"
+	"github.com/MeKo-Tech/watercolormap/internal/texture"
+	"github.com/MeKo-Tech/watercolormap/internal/tile"
+)
+
+func TestSeedGeneratorsAreBounded(t *testing.T) {
+	textures, err := texture.LoadEmbeddedDefaultTextures()
+	if err != nil {
+		t.Fatalf("failed to load textures: %v", err)
+	}
+	od := &OnDemandTiles{
+		cfg: OnDemandTilesConfig{
+			TilesDir:      t.TempDir(),
+			BaseTileSize:  256,
+			Seed:          1337,
+			SeedOverrides: true,
+			Generator:     pipeline.GeneratorOptions{Textures: textures},
+		},
+		seedGens: newGeneratorCache(maxSeedGenerators),
+	}
+
+	base, err := od.getGenerator(256, 1337)
+	if err != nil {
+		t.Fatalf("getGenerator failed: %v", err)
+	}
+	for seed := int64(0); seed < 3*maxSeedGenerators; seed++ {
+		if _, err := od.getGenerator(256, seed); err != nil {
+			t.Fatalf("getGenerator(seed %d) failed: %v", seed, err)
+		}
+		if n := od.seedGens.Len(); n > maxSeedGenerators {
+			t.Fatalf("after seed %d: %d seed generators cached, want at most %d", seed, n, maxSeedGenerators)
+		}
+	}
+
+	// The most recent seeds are reused; the configured seed's generator is never evicted
+	last := int64(3*maxSeedGenerators - 1)
+	a, _ := od.getGenerator(256, last)
+	b, _ := od.getGenerator(256, last)
+	if a != b {
+		t.Error("expected the generator of a recent seed to be reused")
+	}
+	if again, _ := od.getGenerator(256, 1337); again != base {
+		t.Error("expected the configured seed's generator to be kept")
+	}
+}
+
+func TestServeTileSeedOverridesOff(t *testing.T) {
+	od := &OnDemandTiles{cfg: OnDemandTilesConfig{TilesDir: t.TempDir(), BaseTileSize: 256, GenerateMissing: true}}
+	od.render = func(ctx context.Context, coords tile.Coords, suffix string, w io.Writer) error {
+		t.Errorf("unexpected render of %s%s", coords, suffix)
+		return nil
+	}
+
+	rec := httptest.NewRecorder()
+	od.serveTile(rec, httptest.NewRequest(http.MethodGet, "/tiles/z13_x4317_y2692_s42.png", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
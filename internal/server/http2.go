@@ -0,0 +1,21 @@
+package server
+
+import "net/http"
+
+// Protocols returns the protocols a tile server accepts. HTTP/1.1 is always served; with
+// http2 set, HTTP/2 is served too, so one connection can multiplex many tile requests instead
+// of queueing behind the browser's per-host HTTP/1.1 connection limit. Over TLS, HTTP/2 is
+// negotiated via ALPN; without TLS it is cleartext HTTP/2 (h2c) with prior knowledge, as used
+// by reverse proxies and gRPC-style clients (browsers only speak HTTP/2 over TLS).
+func Protocols(http2, tls bool) *http.Protocols {
+	p := new(http.Protocols)
+	p.SetHTTP1(true)
+	if http2 {
+		if tls {
+			p.SetHTTP2(true)
+		} else {
+			p.SetUnencryptedHTTP2(true)
+		}
+	}
+	return p
+}
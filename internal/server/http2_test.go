@@ -0,0 +1,86 @@
+package server
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestH2CStatusStream checks that the compressed SSE status stream works over cleartext
+// HTTP/2 and drops the HTTP/1-only Connection header.
+func TestH2CStatusStream(t *testing.T) {
+	od := &OnDemandTiles{cfg: OnDemandTilesConfig{Seed: 1337, BaseTileSize: 256}}
+	srv := httptest.NewUnstartedServer(Compress(od.StatusStreamHandler()))
+	srv.Config.Protocols = Protocols(true, false)
+	srv.Start()
+	defer srv.Close()
+
+	client := &http.Client{Transport: &http.Transport{Protocols: h2cOnly()}}
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.ProtoMajor != 2 {
+		t.Fatalf("protocol = %s, want HTTP/2", resp.Proto)
+	}
+	if got := resp.Header.Get("Connection"); got != "" {
+		t.Errorf("Connection = %q, want no header over HTTP/2", got)
+	}
+
+	lines := make(chan string)
+	go func() {
+		sc := bufio.NewScanner(resp.Body)
+		for sc.Scan() {
+			if sc.Text() != "" {
+				lines <- sc.Text()
+			}
+		}
+		close(lines)
+	}()
+
+	select {
+	case line, ok := <-lines:
+		if !ok {
+			t.Fatal("stream ended before the first event")
+		}
+		if !strings.HasPrefix(line, "data: ") {
+			t.Errorf("first line = %q, want an SSE data event", line)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("first status event was not flushed")
+	}
+}
+
+func TestProtocolsHTTP1Only(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srv.Config.Protocols = Protocols(false, false)
+	srv.Start()
+	defer srv.Close()
+
+	client := &http.Client{Transport: &http.Transport{Protocols: h2cOnly()}}
+	if resp, err := client.Get(srv.URL); err == nil {
+		resp.Body.Close()
+		t.Fatalf("expected an h2c request to fail without http2, got %s", resp.Proto)
+	}
+
+	resp, err := srv.Client().Get(srv.URL)
+	if err != nil {
+		t.Fatalf("HTTP/1.1 request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.ProtoMajor != 1 {
+		t.Errorf("protocol = %s, want HTTP/1.1", resp.Proto)
+	}
+}
+
+// h2cOnly returns client protocols that speak cleartext HTTP/2 with prior knowledge.
+func h2cOnly() *http.Protocols {
+	p := new(http.Protocols)
+	p.SetUnencryptedHTTP2(true)
+	return p
+}
@@ -5,6 +5,7 @@ import (
 	"log/slog"
 	"net/http"
 	"path"
+	"strconv"
 	"strings"
 
 	"github.com/MeKo-Tech/watercolormap/internal/mbtiles"
@@ -16,12 +17,15 @@ type MBTilesHandler struct {
 	reader       *mbtiles.Reader
 	logger       *slog.Logger
 	cacheControl string
+	tms          bool
 }
 
 // MBTilesConfig configures the MBTiles handler.
 type MBTilesConfig struct {
 	MBTilesPath  string
 	CacheControl string
+	// TMS interprets request rows as TMS (y grows northward) instead of XYZ
+	TMS bool
 }
 
 // NewMBTilesHandler creates a new MBTiles handler.
@@ -35,22 +39,14 @@ func NewMBTilesHandler(cfg MBTilesConfig, logger *slog.Logger) (*MBTilesHandler,
 		reader:       reader,
 		logger:       logger,
 		cacheControl: cfg.CacheControl,
+		tms:          cfg.TMS,
 	}, nil
 }
 
-// Handler returns the HTTP handler function.
+// Handler returns the HTTP handler function. Browser clients on other origins need it
+// wrapped in CORS.Middleware.
 func (h *MBTilesHandler) Handler() http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-		if r.Method == http.MethodOptions {
-			w.WriteHeader(http.StatusNoContent)
-			return
-		}
-
-		h.serveTile(w, r)
-	}
+	return h.serveTile
 }
 
 // serveTile serves a single tile from the MBTiles database.
@@ -61,9 +57,21 @@ func (h *MBTilesHandler) serveTile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Note: suffix (@2x) is ignored for MBTiles serving
+	// Note: the @2x ratio is ignored for MBTiles serving
 	// Separate MBTiles files should be used for different tile sizes
-	_ = suffix
+	if _, seeded := seedFromSuffix(suffix); seeded {
+		// An archive is generated with a single seed, so per-request seeds can't be honoured
+		http.NotFound(w, r)
+		return
+	}
+
+	if h.tms {
+		if !coords.InRange() {
+			http.NotFound(w, r)
+			return
+		}
+		coords = coords.FlipYForTMS()
+	}
 
 	w.Header().Set("Cache-Control", h.cacheControl)
 	w.Header().Set("Content-Type", "image/png")
@@ -76,6 +84,11 @@ func (h *MBTilesHandler) serveTile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	if r.Method == http.MethodHead {
+		return
+	}
+
 	// Write PNG data
 	if _, err := w.Write(data); err != nil {
 		h.log().Error("Failed to write response", "error", err)
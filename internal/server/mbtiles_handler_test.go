@@ -0,0 +1,58 @@
+package server
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/MeKo-Tech/watercolormap/internal/mbtiles"
+)
+
+// TestMBTilesHandlerTMS checks that a TMS request resolves to the same tile as the
+// equivalent XYZ request.
+func TestMBTilesHandlerTMS(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tiles.mbtiles")
+	w, err := mbtiles.New(path, mbtiles.Metadata{Name: "test", Format: "png"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []byte("\x89PNG\r\n\x1a\ntile")
+	if err := w.WriteTile(13, 4317, 2692, want); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		tms        bool
+		path       string
+		wantStatus int
+	}{
+		{"xyz", false, "/tiles/z13_x4317_y2692.png", http.StatusOK},
+		{"tms", true, "/tiles/z13_x4317_y5499.png", http.StatusOK},
+		{"tms with xyz row", true, "/tiles/z13_x4317_y2692.png", http.StatusNotFound},
+		{"tms row out of range", true, "/tiles/z13_x4317_y8192.png", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, err := NewMBTilesHandler(MBTilesConfig{MBTilesPath: path, TMS: tt.tms}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer h.Close()
+
+			rec := httptest.NewRecorder()
+			h.serveTile(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("GET %s: status = %d, want %d", tt.path, rec.Code, tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusOK && !bytes.Equal(rec.Body.Bytes(), want) {
+				t.Errorf("GET %s: body = %q, want %q", tt.path, rec.Body.Bytes(), want)
+			}
+		})
+	}
+}
@@ -3,12 +3,16 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
+	"math/rand/v2"
 	"net/http"
 	"os"
 	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -16,6 +20,7 @@ import (
 
 	"github.com/MeKo-Tech/watercolormap/internal/datasource"
 	"github.com/MeKo-Tech/watercolormap/internal/pipeline"
+	"github.com/MeKo-Tech/watercolormap/internal/renderer"
 	"github.com/MeKo-Tech/watercolormap/internal/tile"
 	"github.com/MeKo-Tech/watercolormap/internal/types"
 )
@@ -24,7 +29,6 @@ type OnDemandTilesConfig struct {
 	TilesDir                 string
 	StylesDir                string
 	TexturesDir              string
-	PNGCompression           string
 	CacheControl             string
 	BaseTileSize             int
 	Seed                     int64
@@ -33,10 +37,38 @@ type OnDemandTilesConfig struct {
 	KeepLayers               bool
 	GenerateMissing          bool
 	DisableCache             bool
+	// SeedOverrides serves tiles named with a seed (z13_x4317_y2692_s42.png), rendered with
+	// that seed instead of Seed, to compare seeds without a restart. Every seed renders and
+	// caches its own tiles, so it is off by default and such tiles are not found.
+	SeedOverrides bool
+	// Generator is the template for the generators rendering tiles: styling, tone, output
+	// format and render settings as for the generate command (see pipeline.GeneratorOptions).
+	// Its FolderStructure ("" = flat, or "hashed") and TMS also name the cached files in
+	// TilesDir. PixelRatio is set per tile size.
+	Generator pipeline.GeneratorOptions
+	// HeadTriggersGenerate makes HEAD requests for missing tiles generate them (when
+	// GenerateMissing is set) instead of only reporting whether they are on disk (default: false)
+	HeadTriggersGenerate bool
 	// FetchWorkers is the number of concurrent Overpass API fetch workers (default: 2)
 	FetchWorkers int
 	// DataSizeWarningMB logs a warning when tile data exceeds this size (default: 10)
 	DataSizeWarningMB int64
+	// MaxDataSizeMB fails fetches whose tile data exceeds this size instead of rendering
+	// them (default: 0 = unlimited). Applies to Overpass data sources.
+	MaxDataSizeMB int64
+	// FallbackURL is an upstream XYZ tile URL template with {z}, {x}, {y} and optionally {r}
+	// (replaced by "@2x" for retina requests). Missing tiles within the fallback zoom range are
+	// fetched from it and cached before falling back to local generation (default: "" = off)
+	FallbackURL string
+	// FallbackMinZoom and FallbackMaxZoom bound the zooms served from FallbackURL (inclusive)
+	FallbackMinZoom int
+	FallbackMaxZoom int
+	// FallbackTimeout bounds a single upstream tile fetch (default: 10s)
+	FallbackTimeout time.Duration
+	// ReadyCacheTTL is how long a successful readiness check is reused (default: 30s)
+	ReadyCacheTTL time.Duration
+	// ReadyTimeout bounds a single readiness check render (default: 30s)
+	ReadyTimeout time.Duration
 }
 
 type OnDemandTiles struct {
@@ -44,12 +76,18 @@ type OnDemandTiles struct {
 	fetchQueue  *datasource.FetchQueue
 	logger      *slog.Logger
 	sem         chan struct{}
-	locks       sync.Map
-	gens        sync.Map
+	locks       sync.Map // Per-file locks for fallback fetches
+	flights     flightGroup
+	gens        sync.Map        // Generators for the configured seed by tile size
+	seedGens    *generatorCache // Generators for seed overrides
 	cfg         OnDemandTilesConfig
 	retryQueue  chan retryJob
 	retryCtx    context.Context
 	retryCancel context.CancelFunc
+	ready       *readiness
+	// render renders a tile to disk, copying the encoded tile into w when it is non-nil;
+	// generateTile, or a stub in tests
+	render func(ctx context.Context, coords tile.Coords, suffix string, w io.Writer) error
 
 	// Status tracking for renders
 	activeRenders  atomic.Int32
@@ -93,10 +131,17 @@ type RetryStatus struct {
 }
 
 type retryJob struct {
-	coords  tile.Coords
-	suffix  string
-	attempt int
-	data    *types.TileData // Pre-fetched data for retry
+	coords     tile.Coords
+	suffix     string
+	attempt    int
+	data       *types.TileData // Pre-fetched data for retry
+	retryAfter time.Duration   // Delay requested by the data source (Retry-After), if any
+}
+
+// delay returns how long to wait before running the job: the zoom-based backoff (see
+// retryDelay), but never less than the data source asked for.
+func (j retryJob) delay() time.Duration {
+	return max(retryDelay(j.coords.Z, j.attempt), j.retryAfter)
 }
 
 func NewOnDemandTiles(ds pipeline.DataSource, cfg OnDemandTilesConfig, logger *slog.Logger) (*OnDemandTiles, error) {
@@ -127,6 +172,33 @@ func NewOnDemandTiles(ds pipeline.DataSource, cfg OnDemandTilesConfig, logger *s
 	if cfg.DataSizeWarningMB <= 0 {
 		cfg.DataSizeWarningMB = 10
 	}
+	if cfg.ReadyCacheTTL <= 0 {
+		cfg.ReadyCacheTTL = 30 * time.Second
+	}
+	if cfg.ReadyTimeout <= 0 {
+		cfg.ReadyTimeout = 30 * time.Second
+	}
+	if cfg.FallbackTimeout <= 0 {
+		cfg.FallbackTimeout = 10 * time.Second
+	}
+	if fs := cfg.Generator.FolderStructure; fs != "" && fs != "flat" && fs != "hashed" {
+		return nil, fmt.Errorf("invalid folder structure %q: must be 'flat' or 'hashed'", fs)
+	}
+	if cfg.FallbackURL != "" {
+		if err := validateFallbackURL(cfg.FallbackURL); err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.MaxDataSizeMB > 0 {
+		maxBytes := cfg.MaxDataSizeMB * 1024 * 1024
+		switch ods := ds.(type) {
+		case *datasource.OverpassDataSource:
+			ods.WithMaxDataSize(maxBytes)
+		case *datasource.MultiOverpassDataSource:
+			ods.WithMaxDataSize(maxBytes)
+		}
+	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 
@@ -149,10 +221,13 @@ func NewOnDemandTiles(ds pipeline.DataSource, cfg OnDemandTilesConfig, logger *s
 		cfg:         cfg,
 		logger:      logger,
 		sem:         make(chan struct{}, cfg.MaxConcurrentGenerations),
+		seedGens:    newGeneratorCache(maxSeedGenerators),
 		retryQueue:  make(chan retryJob, 1000),
 		retryCtx:    ctx,
 		retryCancel: cancel,
 	}
+	t.ready = newReadiness(t.checkReady, cfg.ReadyCacheTTL, cfg.ReadyTimeout)
+	t.render = t.generateTile
 
 	// Start retry worker
 	go t.retryWorker()
@@ -210,7 +285,6 @@ func (t *OnDemandTiles) Status() TileStatus {
 func (t *OnDemandTiles) StatusHandler() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
-		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Cache-Control", "no-store")
 
 		status := t.Status()
@@ -230,8 +304,10 @@ func (t *OnDemandTiles) StatusStreamHandler() http.Handler {
 		// Set SSE headers
 		w.Header().Set("Content-Type", "text/event-stream")
 		w.Header().Set("Cache-Control", "no-cache")
-		w.Header().Set("Connection", "keep-alive")
-		w.Header().Set("Access-Control-Allow-Origin", "*")
+		if r.ProtoMajor == 1 {
+			// Connection-specific headers are not allowed over HTTP/2
+			w.Header().Set("Connection", "keep-alive")
+		}
 
 		flusher, ok := w.(http.Flusher)
 		if !ok {
@@ -267,29 +343,52 @@ func (t *OnDemandTiles) sendStatusEvent(w http.ResponseWriter, flusher http.Flus
 	flusher.Flush()
 }
 
+// ReadyHandler returns an HTTP handler for the deep readiness check.
+// It responds 200 once the generator can load textures and styles and render a
+// synthetic tile through Mapnik, and 503 with the failure details otherwise.
+func (t *OnDemandTiles) ReadyHandler() http.Handler {
+	return t.ready.handler()
+}
+
+// checkReady instantiates the base-size generator and renders a synthetic tile.
+func (t *OnDemandTiles) checkReady(ctx context.Context) error {
+	gen, err := t.getGenerator(t.cfg.BaseTileSize, t.cfg.Seed)
+	if err != nil {
+		return fmt.Errorf("failed to init generator: %w", err)
+	}
+	if err := gen.SelfCheck(ctx); err != nil {
+		return fmt.Errorf("self-check render failed: %w", err)
+	}
+	return nil
+}
+
+// Handler returns the tile handler. Browser clients on other origins need it wrapped in
+// CORS.Middleware.
 func (t *OnDemandTiles) Handler() http.Handler {
 	return http.HandlerFunc(t.serveTile)
 }
 
 func (t *OnDemandTiles) serveTile(w http.ResponseWriter, r *http.Request) {
-	// Allow browser-based playgrounds (including GitHub Pages) to request tiles.
-	// Note: HTTPS pages cannot fetch from HTTP backends due to mixed-content rules.
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-	if r.Method == http.MethodOptions {
-		w.WriteHeader(http.StatusNoContent)
-		return
-	}
-
 	coords, suffix, ok := parseTilePath(r.URL.Path)
 	if !ok {
 		http.NotFound(w, r)
 		return
 	}
+	if _, seeded := seedFromSuffix(suffix); seeded && !t.cfg.SeedOverrides {
+		http.NotFound(w, r)
+		return
+	}
 
+	// Files on disk are named like the request (TMS rows with --tms); see GeneratorOptions.TMS
 	filename := coords.String() + suffix + ".png"
-	fullPath := filepath.Join(t.cfg.TilesDir, filename)
+	fullPath, _ := pipeline.TilePath(t.cfg.TilesDir, t.cfg.Generator.FolderStructure, coords, suffix, ".png")
+	if t.cfg.Generator.TMS {
+		if !coords.InRange() {
+			http.NotFound(w, r)
+			return
+		}
+		coords = coords.FlipYForTMS()
+	}
 
 	w.Header().Set("Cache-Control", t.cfg.CacheControl)
 
@@ -300,23 +399,140 @@ func (t *OnDemandTiles) serveTile(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	if !t.cfg.GenerateMissing {
-		http.Error(w, fmt.Sprintf("tile not found: %s", filename), http.StatusNotFound)
+	// HEAD only reports cache state unless configured to generate; http.ServeFile
+	// writes the headers (including Content-Length) without a body for HEAD requests.
+	if r.Method == http.MethodHead && !t.cfg.HeadTriggersGenerate {
+		if fileExists(fullPath) {
+			http.ServeFile(w, r, fullPath)
+			return
+		}
+		http.NotFound(w, r)
 		return
 	}
 
-	lockKey := filename
-	mu := t.getLock(lockKey)
-	mu.Lock()
-	defer mu.Unlock()
+	fallbackURL, useFallback := t.fallbackURL(coords, suffix)
+	if !t.cfg.GenerateMissing && !useFallback {
+		http.Error(w, fmt.Sprintf("tile not found: %s", filename), http.StatusNotFound)
+		return
+	}
 
-	if !t.cfg.DisableCache {
-		if fileExists(fullPath) {
+	// Fallbacks are cheap to proxy, but still fetched once per tile
+	if useFallback {
+		mu := t.getLock(filename)
+		mu.Lock()
+		served := !t.cfg.DisableCache && fileExists(fullPath)
+		if served {
 			http.ServeFile(w, r, fullPath)
+		} else {
+			// Cache -> upstream -> local generation: low zooms are expensive to render but cheap to proxy
+			served = t.serveFallback(w, r, fallbackURL, fullPath)
+		}
+		mu.Unlock()
+		if served {
+			return
+		}
+		if !t.cfg.GenerateMissing {
+			http.Error(w, fmt.Sprintf("tile not found: %s", filename), http.StatusNotFound)
 			return
 		}
 	}
 
+	// Concurrent requests for the same tile share one render and all serve its result. The
+	// request that starts the render receives the tile as it is encoded; the others read it
+	// from disk once it is written.
+	var stream *tileStream
+	if r.Method == http.MethodGet {
+		stream = &tileStream{w: w}
+	}
+	err := t.flights.Do(r.Context(), filename, func(ctx context.Context) error {
+		if !t.cfg.DisableCache && fileExists(fullPath) {
+			return nil // Rendered by a flight that finished after our cache check
+		}
+		if stream == nil {
+			return t.render(ctx, coords, suffix, nil)
+		}
+		return t.render(ctx, coords, suffix, stream)
+	})
+	streamed := stream.detach()
+	if streamed {
+		// The response is under way; a failure can't change its status anymore
+		if err != nil {
+			t.log().Warn("tile stream interrupted", "coords", coords.String(), "suffix", suffix, "error", err)
+		}
+		return
+	}
+	if err != nil {
+		var te *tileError
+		switch {
+		case errors.As(err, &te):
+			http.Error(w, te.msg, te.status)
+		case r.Context().Err() != nil:
+			http.Error(w, "request cancelled", http.StatusRequestTimeout)
+		default:
+			http.Error(w, fmt.Sprintf("failed to generate tile %s: %v", coords.String()+suffix, err), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if !fileExists(fullPath) {
+		http.Error(w, "tile generation completed but file missing on disk", http.StatusInternalServerError)
+		return
+	}
+
+	http.ServeFile(w, r, fullPath)
+}
+
+// tileStream forwards an encoded tile to the response of the request that started its
+// render while the render writes it to disk. Writes never fail: once the response fails or
+// the request has stopped waiting (see detach), the render carries on writing the tile file
+// alone.
+type tileStream struct {
+	mu      sync.Mutex
+	w       http.ResponseWriter // nil once detached
+	started bool                // bytes reached the response
+	failed  bool
+}
+
+func (s *tileStream) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.w == nil || s.failed {
+		return len(p), nil
+	}
+	if !s.started {
+		s.w.Header().Set("Content-Type", "image/png")
+		s.started = true
+	}
+	if _, err := s.w.Write(p); err != nil {
+		s.failed = true
+	}
+	return len(p), nil
+}
+
+// detach stops forwarding, so the render no longer touches the response once the handler
+// returns, and reports whether the response already received part of the tile. It is safe
+// to call on a nil stream.
+func (s *tileStream) detach() bool {
+	if s == nil {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.w = nil
+	return s.started
+}
+
+// tileError is a failed on-demand render together with the HTTP status to answer it with.
+type tileError struct {
+	status int
+	msg    string
+}
+
+func (e *tileError) Error() string { return e.msg }
+
+// generateTile renders a tile to disk, waiting for a free generation slot first, and copies
+// the encoded tile into w when it is non-nil. Failures are *tileError values.
+func (t *OnDemandTiles) generateTile(ctx context.Context, coords tile.Coords, suffix string, w io.Writer) error {
 	// Track tile as queued (waiting for semaphore)
 	queueKey := coords.String() + suffix
 	t.queuedRenders.Add(1)
@@ -328,58 +544,55 @@ func (t *OnDemandTiles) serveTile(w http.ResponseWriter, r *http.Request) {
 		t.queuedRenders.Add(-1)
 		t.queuedTiles.Delete(queueKey)
 		defer func() { <-t.sem }()
-	case <-r.Context().Done():
-		// Request cancelled - remove from queue
+	case <-ctx.Done():
+		// Every waiting request was cancelled - remove from queue
 		t.queuedRenders.Add(-1)
 		t.queuedTiles.Delete(queueKey)
-		http.Error(w, "request cancelled", http.StatusRequestTimeout)
-		return
+		return &tileError{status: http.StatusRequestTimeout, msg: "request cancelled"}
 	}
 
-	ctx, cancel := context.WithTimeout(r.Context(), t.cfg.GenerationTimeout)
+	ctx, cancel := context.WithTimeout(ctx, t.cfg.GenerationTimeout)
 	defer cancel()
 
 	force := t.cfg.DisableCache
 	tileSize := tileSizeForSuffix(t.cfg.BaseTileSize, suffix)
-	gen, err := t.getGenerator(tileSize)
+	gen, err := t.getGenerator(tileSize, t.seedForSuffix(suffix))
 	if err != nil {
 		t.log().Error("failed to init generator", "error", err)
-		http.Error(w, "failed to init generator", http.StatusInternalServerError)
-		return
+		return &tileError{status: http.StatusInternalServerError, msg: "failed to init generator"}
 	}
 
 	start := time.Now()
 
 	// Phase 1: Fetch data (decoupled from rendering)
-	// The go-overpass library handles retries internally with exponential backoff
-	var tileData *types.TileData
-	if t.fetchQueue != nil {
-		tileCoord := types.TileCoordinate{
-			Zoom: int(coords.Z),
-			X:    int(coords.X),
-			Y:    int(coords.Y),
-		}
-		bounds := gen.CalculateFetchBounds(coords)
-
+	// The go-overpass library handles retries internally with exponential backoff.
+	// Over-zoomed tiles fetch their ancestor's data, which their siblings then reuse.
+	dataCoords := gen.DataTile(coords)
+	tileData := gen.CachedData(dataCoords)
+	if tileData == nil && t.fetchQueue != nil {
+		tileCoord, bounds := fetchArea(gen, dataCoords)
 		fetchResult, fetchErr := t.fetchQueue.SubmitAndWait(ctx, tileCoord, bounds)
+		if errors.Is(fetchErr, datasource.ErrCircuitOpen) || errors.Is(fetchResult.Error, datasource.ErrCircuitOpen) {
+			// Overpass keeps failing; answer quickly instead of piling up retries
+			return &tileError{status: http.StatusServiceUnavailable, msg: "tile data source temporarily unavailable"}
+		}
 		if fetchErr != nil {
 			t.log().Error("fetch queue error", "coords", coords.String(), "error", fetchErr)
-			http.Error(w, fmt.Sprintf("failed to fetch tile data: %v", fetchErr), http.StatusBadGateway)
-			return
+			return &tileError{status: http.StatusBadGateway, msg: fmt.Sprintf("failed to fetch tile data: %v", fetchErr)}
 		}
 		if fetchResult.Error != nil {
 			// Fetch failed - queue for retry if transient
 			if isTransientError(fetchResult.Error) {
 				t.log().Warn("transient fetch error, queuing retry", "coords", coords.String(), "suffix", suffix, "error", fetchResult.Error)
-				t.queueRetry(coords, suffix, 0, nil)
+				t.queueRetry(coords, suffix, 0, nil, fetchResult.Error)
 			} else {
 				t.log().Error("failed to fetch tile data", "coords", coords.String(), "suffix", suffix, "error", fetchResult.Error)
 			}
-			http.Error(w, fmt.Sprintf("failed to fetch tile data: %v", fetchResult.Error), http.StatusBadGateway)
-			return
+			return &tileError{status: http.StatusBadGateway, msg: fmt.Sprintf("failed to fetch tile data: %v", fetchResult.Error)}
 		}
 		tileData = fetchResult.Data
-		t.log().Info("fetch completed", "coords", coords.String(), "data_size_mb", fmt.Sprintf("%.2f", float64(fetchResult.DataSize)/(1024*1024)))
+		gen.StoreData(dataCoords, tileData)
+		t.log().Info("fetch completed", "coords", dataCoords.String(), "data_size_mb", fmt.Sprintf("%.2f", float64(fetchResult.DataSize)/(1024*1024)))
 	}
 
 	// Phase 2: Render with pre-fetched data (or fetch during render if no queue)
@@ -387,7 +600,7 @@ func (t *OnDemandTiles) serveTile(w http.ResponseWriter, r *http.Request) {
 	t.activeRenders.Add(1)
 	t.currentRenders.Store(tileKey, time.Now())
 
-	_, _, err = gen.GenerateWithData(ctx, coords, force, suffix, nil, tileData)
+	_, err = gen.GenerateTo(ctx, coords, force, suffix, w, tileData)
 
 	t.activeRenders.Add(-1)
 	t.currentRenders.Delete(tileKey)
@@ -398,49 +611,48 @@ func (t *OnDemandTiles) serveTile(w http.ResponseWriter, r *http.Request) {
 		// and we didn't already have pre-fetched data
 		if tileData == nil && isTransientError(err) {
 			t.log().Warn("transient error during generation, queuing retry", "coords", coords.String(), "suffix", suffix, "error", err)
-			t.queueRetry(coords, suffix, 0, nil)
+			t.queueRetry(coords, suffix, 0, nil, err)
 		} else {
 			t.log().Error("failed to generate tile", "coords", coords.String(), "suffix", suffix, "error", err)
 		}
 
-		http.Error(w, fmt.Sprintf("failed to generate tile %s: %v", coords.String()+suffix, err), http.StatusBadGateway)
-		return
+		return &tileError{status: http.StatusBadGateway, msg: fmt.Sprintf("failed to generate tile %s: %v", coords.String()+suffix, err)}
 	}
 	t.totalRendered.Add(1)
 	t.log().Info("tile generated on-demand", "coords", coords.String(), "suffix", suffix, "ms", time.Since(start).Milliseconds())
+	return nil
+}
 
-	if !fileExists(fullPath) {
-		http.Error(w, "tile generation completed but file missing on disk", http.StatusInternalServerError)
-		return
+// getGenerator returns the generator rendering tiles of tileSize with seed. Generators for
+// the configured seed are kept for good; those for seed overrides share a small cache.
+func (t *OnDemandTiles) getGenerator(tileSize int, seed int64) (*pipeline.Generator, error) {
+	if seed != t.cfg.Seed {
+		return t.seedGens.get(generatorKey{tileSize: tileSize, seed: seed}, func() (*pipeline.Generator, error) {
+			return t.newGenerator(tileSize, seed)
+		})
 	}
 
-	http.ServeFile(w, r, fullPath)
-}
-
-func (t *OnDemandTiles) getGenerator(tileSize int) (*pipeline.Generator, error) {
 	if v, ok := t.gens.Load(tileSize); ok {
 		return v.(*pipeline.Generator), nil
 	}
-
-	g, err := pipeline.NewGenerator(
-		t.ds,
-		t.cfg.StylesDir,
-		t.cfg.TexturesDir,
-		t.cfg.TilesDir,
-		tileSize,
-		t.cfg.Seed,
-		t.cfg.KeepLayers,
-		t.logger,
-		pipeline.GeneratorOptions{PNGCompression: t.cfg.PNGCompression},
-	)
+	g, err := t.newGenerator(tileSize, seed)
 	if err != nil {
 		return nil, err
 	}
-
 	actual, _ := t.gens.LoadOrStore(tileSize, g)
 	return actual.(*pipeline.Generator), nil
 }
 
+// newGenerator creates a generator from the configured template.
+func (t *OnDemandTiles) newGenerator(tileSize int, seed int64) (*pipeline.Generator, error) {
+	opts := t.cfg.Generator
+	opts.PixelRatio = 1
+	if t.cfg.BaseTileSize > 0 {
+		opts.PixelRatio = tileSize / t.cfg.BaseTileSize
+	}
+	return pipeline.NewGenerator(t.ds, t.cfg.StylesDir, t.cfg.TexturesDir, t.cfg.TilesDir, tileSize, seed, t.cfg.KeepLayers, t.logger, opts)
+}
+
 func (t *OnDemandTiles) getLock(key string) *sync.Mutex {
 	if v, ok := t.locks.Load(key); ok {
 		return v.(*sync.Mutex)
@@ -457,8 +669,11 @@ func (t *OnDemandTiles) log() *slog.Logger {
 	return slog.Default()
 }
 
+// parseTilePath parses an on-demand tile path. The returned suffix is appended to the
+// tile name on disk and encodes the optional seed override and pixel ratio, e.g. "_s42@2x".
 func parseTilePath(requestPath string) (tile.Coords, string, bool) {
-	// Expect: /tiles/z13_x4317_y2692.png or /tiles/z13_x4317_y2692@2x.png
+	// Expect: /tiles/z13_x4317_y2692.png or /tiles/z13_x4317_y2692@2x.png,
+	// optionally with a seed before the ratio: /tiles/z13_x4317_y2692_s42@2x.png
 	if !strings.HasPrefix(requestPath, "/tiles/") {
 		return tile.Coords{}, "", false
 	}
@@ -472,16 +687,56 @@ func parseTilePath(requestPath string) (tile.Coords, string, bool) {
 		suffix = "@2x"
 		name = strings.TrimSuffix(name, "@2x")
 	}
+	if i := strings.LastIndex(name, "_s"); i >= 0 {
+		seed, ok := parseSeed(name[i+2:])
+		if !ok {
+			return tile.Coords{}, "", false
+		}
+		// Canonical form, so e.g. _s042 and _s42 share one file on disk
+		suffix = "_s" + strconv.FormatInt(seed, 10) + suffix
+		name = name[:i]
+	}
 
 	coords, err := tile.ParseCoords(name)
-	if err != nil {
+	if err != nil || coords.String() != name {
+		// ParseCoords ignores trailing input, so also reject anything that doesn't round-trip
 		return tile.Coords{}, "", false
 	}
 	return coords, suffix, true
 }
 
+// parseSeed parses a non-negative decimal seed.
+func parseSeed(s string) (int64, bool) {
+	if s == "" || s[0] < '0' || s[0] > '9' {
+		return 0, false // Rejects signs as well as empty strings
+	}
+	seed, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return seed, true
+}
+
+// seedFromSuffix returns the seed override encoded in a tile suffix, if any.
+func seedFromSuffix(suffix string) (int64, bool) {
+	seedPart, ok := strings.CutPrefix(strings.TrimSuffix(suffix, "@2x"), "_s")
+	if !ok {
+		return 0, false
+	}
+	return parseSeed(seedPart)
+}
+
+// seedForSuffix returns the seed to render a tile with: the override from its suffix,
+// or the configured seed.
+func (t *OnDemandTiles) seedForSuffix(suffix string) int64 {
+	if seed, ok := seedFromSuffix(suffix); ok {
+		return seed
+	}
+	return t.cfg.Seed
+}
+
 func tileSizeForSuffix(base int, suffix string) int {
-	if suffix == "@2x" {
+	if strings.HasSuffix(suffix, "@2x") {
 		return base * 2
 	}
 	return base
@@ -495,23 +750,53 @@ func fileExists(p string) bool {
 	return !st.IsDir()
 }
 
-// isTransientError checks if an error is likely transient and worth retrying
+// retryDelay returns how long to wait before retry attempt (0-based) of a tile at zoom z.
+// The base delay depends on zoom level, since low zoom tiles hit rate limits harder:
+// z0-7: 30s (huge tiles, heavy queries), z8-10: 15s (large tiles), z11+: 5s (normal tiles).
+// It doubles with every attempt and is jittered by ±25% so tiles that failed together
+// don't retry in lockstep.
+func retryDelay(z uint32, attempt int) time.Duration {
+	var baseDelay time.Duration
+	switch {
+	case z <= 7:
+		baseDelay = 30 * time.Second
+	case z <= 10:
+		baseDelay = 15 * time.Second
+	default:
+		baseDelay = 5 * time.Second
+	}
+
+	// Exponential backoff from base delay
+	delay := baseDelay * time.Duration(1<<attempt)
+	jitter := delay / 4
+	return delay - jitter + rand.N(2*jitter+1)
+}
+
+// isTransientError checks if an error is likely transient and worth retrying.
+// Only fetch failures classified as transient by the datasource qualify; render
+// failures are deterministic and never retried.
 func isTransientError(err error) bool {
-	if err == nil {
+	var renderErr *renderer.RenderError
+	if errors.As(err, &renderErr) {
 		return false
 	}
-	errStr := err.Error()
-	return strings.Contains(errStr, "504") ||
-		strings.Contains(errStr, "Gateway Timeout") ||
-		strings.Contains(errStr, "timeout") ||
-		strings.Contains(errStr, "overpass") ||
-		strings.Contains(errStr, "empty response") ||
-		strings.Contains(errStr, "max retries exceeded")
+	var fetchErr *datasource.FetchError
+	if errors.As(err, &fetchErr) {
+		return fetchErr.Transient
+	}
+	return false
 }
 
-func (t *OnDemandTiles) queueRetry(coords tile.Coords, suffix string, attempt int, data *types.TileData) {
+// queueRetry queues a retry of a tile whose fetch or render failed with cause.
+func (t *OnDemandTiles) queueRetry(coords tile.Coords, suffix string, attempt int, data *types.TileData, cause error) {
+	job := retryJob{coords: coords, suffix: suffix, attempt: attempt, data: data}
+	var fetchErr *datasource.FetchError
+	if errors.As(cause, &fetchErr) {
+		job.retryAfter = fetchErr.RetryAfter
+	}
+
 	select {
-	case t.retryQueue <- retryJob{coords: coords, suffix: suffix, attempt: attempt, data: data}:
+	case t.retryQueue <- job:
 		t.pendingRetries.Add(1)
 		t.log().Info("queued tile for retry", "coords", coords.String(), "suffix", suffix, "attempt", attempt+1)
 	default:
@@ -528,22 +813,7 @@ func (t *OnDemandTiles) retryWorker() {
 			return
 		case job := <-t.retryQueue:
 			t.pendingRetries.Add(-1)
-			// Base delay depends on zoom level - low zoom tiles hit rate limits harder
-			// z0-7: 30s base (huge tiles, heavy queries)
-			// z8-10: 15s base (large tiles)
-			// z11+: 5s base (normal tiles)
-			var baseDelay time.Duration
-			switch {
-			case job.coords.Z <= 7:
-				baseDelay = 30 * time.Second
-			case job.coords.Z <= 10:
-				baseDelay = 15 * time.Second
-			default:
-				baseDelay = 5 * time.Second
-			}
-
-			// Exponential backoff from base delay
-			delay := baseDelay * time.Duration(1<<job.attempt)
+			delay := job.delay()
 			t.log().Info("waiting before retry", "coords", job.coords.String(), "suffix", job.suffix, "delay", delay)
 
 			select {
@@ -561,7 +831,7 @@ func (t *OnDemandTiles) retryWorker() {
 
 			ctx, cancel := context.WithTimeout(t.retryCtx, t.cfg.GenerationTimeout)
 			tileSize := tileSizeForSuffix(t.cfg.BaseTileSize, job.suffix)
-			gen, err := t.getGenerator(tileSize)
+			gen, err := t.getGenerator(tileSize, t.seedForSuffix(job.suffix))
 			if err != nil {
 				t.log().Error("retry: failed to init generator", "error", err)
 				<-t.sem
@@ -572,15 +842,13 @@ func (t *OnDemandTiles) retryWorker() {
 			start := time.Now()
 
 			// Use pre-fetched data if available, otherwise fetch first
+			dataCoords := gen.DataTile(job.coords)
 			tileData := job.data
+			if tileData == nil {
+				tileData = gen.CachedData(dataCoords)
+			}
 			if tileData == nil && t.fetchQueue != nil {
-				tileCoord := types.TileCoordinate{
-					Zoom: int(job.coords.Z),
-					X:    int(job.coords.X),
-					Y:    int(job.coords.Y),
-				}
-				bounds := gen.CalculateFetchBounds(job.coords)
-
+				tileCoord, bounds := fetchArea(gen, dataCoords)
 				fetchResult, fetchErr := t.fetchQueue.SubmitAndWait(ctx, tileCoord, bounds)
 				if fetchErr != nil || fetchResult.Error != nil {
 					fetchError := fetchErr
@@ -589,13 +857,14 @@ func (t *OnDemandTiles) retryWorker() {
 					}
 					t.log().Error("retry: failed to fetch tile data", "coords", job.coords.String(), "suffix", job.suffix, "attempt", job.attempt+1, "error", fetchError)
 					if isTransientError(fetchError) && job.attempt+1 < maxRetries {
-						t.queueRetry(job.coords, job.suffix, job.attempt+1, nil)
+						t.queueRetry(job.coords, job.suffix, job.attempt+1, nil, fetchError)
 					}
 					<-t.sem
 					cancel()
 					continue
 				}
 				tileData = fetchResult.Data
+				gen.StoreData(dataCoords, tileData)
 				t.log().Info("retry: fetch completed", "coords", job.coords.String(), "data_size_mb", fmt.Sprintf("%.2f", float64(fetchResult.DataSize)/(1024*1024)))
 			}
 
@@ -616,7 +885,7 @@ func (t *OnDemandTiles) retryWorker() {
 				t.log().Error("retry: failed to generate tile", "coords", job.coords.String(), "suffix", job.suffix, "attempt", job.attempt+1, "error", err)
 				// Only retry if we didn't have pre-fetched data (fetch-related error)
 				if tileData == nil && isTransientError(err) && job.attempt+1 < maxRetries {
-					t.queueRetry(job.coords, job.suffix, job.attempt+1, nil)
+					t.queueRetry(job.coords, job.suffix, job.attempt+1, nil, err)
 				}
 			} else {
 				t.totalRendered.Add(1)
@@ -1,6 +1,26 @@
 package server
 
-import "testing"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/MeKo-Tech/watercolormap/internal/datasource"
+	"github.com/MeKo-Tech/watercolormap/internal/geojson"
+	"github.com/MeKo-Tech/watercolormap/internal/pipeline"
+	"github.com/MeKo-Tech/watercolormap/internal/renderer"
+	"github.com/MeKo-Tech/watercolormap/internal/tile"
+)
 
 func TestParseTilePath(t *testing.T) {
 	t.Run("base tile", func(t *testing.T) {
@@ -42,4 +62,355 @@ func TestParseTilePath(t *testing.T) {
 			t.Fatalf("expected not ok")
 		}
 	})
+
+	t.Run("seeded tiles", func(t *testing.T) {
+		tests := []struct {
+			path       string
+			wantSuffix string
+		}{
+			{"/tiles/z13_x4317_y2692_s42.png", "_s42"},
+			{"/tiles/z13_x4317_y2692_s42@2x.png", "_s42@2x"},
+			{"/tiles/z13_x4317_y2692_s0.png", "_s0"},
+			{"/tiles/z13_x4317_y2692_s0042.png", "_s42"},
+		}
+		for _, tt := range tests {
+			coords, suffix, ok := parseTilePath(tt.path)
+			if !ok {
+				t.Fatalf("%s: expected ok", tt.path)
+			}
+			if suffix != tt.wantSuffix {
+				t.Fatalf("%s: expected suffix %q, got %q", tt.path, tt.wantSuffix, suffix)
+			}
+			if coords.String() != "z13_x4317_y2692" {
+				t.Fatalf("%s: unexpected coords: %s", tt.path, coords.String())
+			}
+		}
+	})
+
+	t.Run("reject invalid seeds", func(t *testing.T) {
+		for _, p := range []string{
+			"/tiles/z13_x4317_y2692_s-1.png",
+			"/tiles/z13_x4317_y2692_s+1.png",
+			"/tiles/z13_x4317_y2692_s.png",
+			"/tiles/z13_x4317_y2692_sabc.png",
+			"/tiles/z13_x4317_y2692_s99999999999999999999.png",
+			"/tiles/z13_x4317_y2692@2x_s42.png",
+		} {
+			if _, _, ok := parseTilePath(p); ok {
+				t.Fatalf("%s: expected not ok", p)
+			}
+		}
+	})
+}
+
+func TestSeedForSuffix(t *testing.T) {
+	od := &OnDemandTiles{cfg: OnDemandTilesConfig{Seed: 1337, BaseTileSize: 256}}
+
+	tests := []struct {
+		suffix   string
+		wantSeed int64
+		wantSize int
+	}{
+		{"", 1337, 256},
+		{"@2x", 1337, 512},
+		{"_s42", 42, 256},
+		{"_s42@2x", 42, 512},
+	}
+	for _, tt := range tests {
+		t.Run(tt.suffix, func(t *testing.T) {
+			if got := od.seedForSuffix(tt.suffix); got != tt.wantSeed {
+				t.Errorf("seedForSuffix(%q) = %d, want %d", tt.suffix, got, tt.wantSeed)
+			}
+			if got := tileSizeForSuffix(od.cfg.BaseTileSize, tt.suffix); got != tt.wantSize {
+				t.Errorf("tileSizeForSuffix(%q) = %d, want %d", tt.suffix, got, tt.wantSize)
+			}
+		})
+	}
+}
+
+func TestServeTileHead(t *testing.T) {
+	tilesDir := t.TempDir()
+	png := []byte("\x89PNG\r\n\x1a\nfake")
+	if err := os.WriteFile(filepath.Join(tilesDir, "z13_x4317_y2692.png"), png, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// GenerateMissing is on, but HEAD must only report cache state and never render
+	od := &OnDemandTiles{cfg: OnDemandTilesConfig{TilesDir: tilesDir, BaseTileSize: 256, GenerateMissing: true}}
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+	}{
+		{"cached tile", "/tiles/z13_x4317_y2692.png", http.StatusOK},
+		{"missing tile", "/tiles/z13_x4318_y2692.png", http.StatusNotFound},
+		{"invalid path", "/tiles/nope.png", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			od.serveTile(rec, httptest.NewRequest(http.MethodHead, tt.path, nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("HEAD %s: status = %d, want %d", tt.path, rec.Code, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			if got := rec.Header().Get("Content-Type"); got != "image/png" {
+				t.Errorf("Content-Type = %q, want image/png", got)
+			}
+			if got := rec.Header().Get("Content-Length"); got != strconv.Itoa(len(png)) {
+				t.Errorf("Content-Length = %q, want %d", got, len(png))
+			}
+			if rec.Body.Len() != 0 {
+				t.Errorf("expected empty body for HEAD, got %d bytes", rec.Body.Len())
+			}
+		})
+	}
+}
+
+func TestServeTileHashed(t *testing.T) {
+	tilesDir := t.TempDir()
+	png := []byte("\x89PNG\r\n\x1a\nfake")
+	// Where a Generator with FolderStructure "hashed" writes these tiles (see the pipeline's
+	// TestTilePathTMS)
+	for _, name := range []string{
+		filepath.Join("9a", "8a", "z13_x4317_y2692.png"),
+		filepath.Join("a5", "a5", "z13_x4317_y5499_s42@2x.png"),
+	} {
+		p := filepath.Join(tilesDir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, png, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		tms        bool
+		path       string
+		wantStatus int
+	}{
+		{"xyz", false, "/tiles/z13_x4317_y2692.png", http.StatusOK},
+		{"tms seed override", true, "/tiles/z13_x4317_y5499_s42@2x.png", http.StatusOK},
+		{"missing tile", false, "/tiles/z13_x4318_y2692.png", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			od := &OnDemandTiles{cfg: OnDemandTilesConfig{TilesDir: tilesDir, BaseTileSize: 256, SeedOverrides: true, Generator: pipeline.GeneratorOptions{FolderStructure: "hashed", TMS: tt.tms}}}
+			rec := httptest.NewRecorder()
+			od.serveTile(rec, httptest.NewRequest(http.MethodHead, tt.path, nil))
+			if rec.Code != tt.wantStatus {
+				t.Errorf("HEAD %s: status = %d, want %d", tt.path, rec.Code, tt.wantStatus)
+			}
+		})
+	}
+
+	if _, err := NewOnDemandTiles(nil, OnDemandTilesConfig{Generator: pipeline.GeneratorOptions{FolderStructure: "nested"}}, nil); err == nil {
+		t.Error("expected an error for an unsupported folder structure")
+	}
+}
+
+func TestIsTransientError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{
+			name: "gateway timeout",
+			err:  fmt.Errorf("failed to fetch tile data: %w", &datasource.FetchError{Transient: true, StatusCode: 504, Err: errors.New("overpass query failed: 504 Gateway Timeout")}),
+			want: true,
+		},
+		{
+			name: "empty response",
+			err:  fmt.Errorf("failed to fetch tile data: %w", &datasource.FetchError{Transient: true, Err: datasource.ErrEmptyOverpassResponse}),
+			want: true,
+		},
+		{
+			name: "bad request",
+			err:  &datasource.FetchError{StatusCode: 400, Err: errors.New("overpass query failed: 400 Bad Request")},
+			want: false,
+		},
+		{
+			name: "render failure",
+			err:  fmt.Errorf("failed to render layer water: %w", &renderer.RenderError{Layer: geojson.LayerWater, Err: errors.New("failed to render: overpass timeout 504")}),
+			want: false,
+		},
+		{
+			name: "untyped error mentioning 504",
+			err:  errors.New("504 Gateway Timeout"),
+			want: false,
+		},
+		{
+			name: "nil",
+			err:  nil,
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isTransientError(tt.err); got != tt.want {
+				t.Errorf("isTransientError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestQueueRetryHonorsRetryAfter checks that a retry never runs sooner than the data source
+// asked for, while the zoom-based backoff still applies when it asks for less.
+func TestQueueRetryHonorsRetryAfter(t *testing.T) {
+	tests := []struct {
+		name       string
+		retryAfter time.Duration
+		min, max   time.Duration
+	}{
+		{"no header", 0, 5 * time.Second * 3 / 4, 5 * time.Second * 5 / 4},
+		{"longer than backoff", 10 * time.Minute, 10 * time.Minute, 10 * time.Minute},
+		{"shorter than backoff", time.Second, 5 * time.Second * 3 / 4, 5 * time.Second * 5 / 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			od := &OnDemandTiles{retryQueue: make(chan retryJob, 1)}
+			cause := fmt.Errorf("fetch failed: %w", &datasource.FetchError{Transient: true, StatusCode: http.StatusTooManyRequests, RetryAfter: tt.retryAfter})
+			od.queueRetry(tile.NewCoords(14, 8634, 5384), "", 0, nil, cause)
+
+			job := <-od.retryQueue
+			if d := job.delay(); d < tt.min || d > tt.max {
+				t.Errorf("delay = %v, want between %v and %v", d, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestRetryDelayJitter(t *testing.T) {
+	tests := []struct {
+		z       uint32
+		attempt int
+		base    time.Duration
+	}{
+		{5, 0, 30 * time.Second},
+		{9, 1, 30 * time.Second},
+		{14, 2, 20 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("z%d_attempt%d", tt.z, tt.attempt), func(t *testing.T) {
+			seen := map[time.Duration]bool{}
+			for i := 0; i < 50; i++ {
+				d := retryDelay(tt.z, tt.attempt)
+				if d < tt.base*3/4 || d > tt.base*5/4 {
+					t.Fatalf("delay %v outside %v ±25%%", d, tt.base)
+				}
+				seen[d] = true
+			}
+			if len(seen) < 2 {
+				t.Errorf("expected jittered delays, got a constant %v", retryDelay(tt.z, tt.attempt))
+			}
+		})
+	}
+}
+
+// TestServeTileSharesRender fires concurrent requests for one missing tile and checks that
+// they share a single render and all receive the tile.
+func TestServeTileSharesRender(t *testing.T) {
+	const requests = 8
+	tilesDir := t.TempDir()
+	png := []byte("\x89PNG\r\n\x1a\nfake")
+
+	var renders atomic.Int32
+	release := make(chan struct{})
+	od := &OnDemandTiles{cfg: OnDemandTilesConfig{TilesDir: tilesDir, BaseTileSize: 256, GenerateMissing: true, DisableCache: true}}
+	od.render = func(ctx context.Context, coords tile.Coords, suffix string, w io.Writer) error {
+		renders.Add(1)
+		<-release
+		return os.WriteFile(filepath.Join(tilesDir, coords.String()+suffix+".png"), png, 0o644)
+	}
+
+	var wg sync.WaitGroup
+	recs := make([]*httptest.ResponseRecorder, requests)
+	for i := range recs {
+		recs[i] = httptest.NewRecorder()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			od.serveTile(recs[i], httptest.NewRequest(http.MethodGet, "/tiles/z13_x4317_y2692.png", nil))
+		}()
+	}
+
+	// Hold the render until every request has joined it
+	deadline := time.Now().Add(5 * time.Second)
+	for od.flights.waiters("z13_x4317_y2692.png") < requests {
+		if time.Now().After(deadline) {
+			t.Fatalf("only %d of %d requests joined the render", od.flights.waiters("z13_x4317_y2692.png"), requests)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+
+	if got := renders.Load(); got != 1 {
+		t.Errorf("rendered %d times, want 1", got)
+	}
+	for i, rec := range recs {
+		if rec.Code != http.StatusOK || rec.Body.String() != string(png) {
+			t.Errorf("request %d: status %d, body %q", i, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+// TestServeTileStreamsRender checks that the request starting a render receives the tile as
+// it is written, while the tile still lands on disk for later requests.
+func TestServeTileStreamsRender(t *testing.T) {
+	tilesDir := t.TempDir()
+	png := []byte("\x89PNG\r\n\x1a\nfake")
+
+	od := &OnDemandTiles{cfg: OnDemandTilesConfig{TilesDir: tilesDir, BaseTileSize: 256, GenerateMissing: true}}
+	od.render = func(ctx context.Context, coords tile.Coords, suffix string, w io.Writer) error {
+		if w == nil {
+			t.Error("render got no stream for a GET request")
+			return os.WriteFile(filepath.Join(tilesDir, coords.String()+suffix+".png"), png, 0o644)
+		}
+		if _, err := w.Write(png); err != nil {
+			return err
+		}
+		return os.WriteFile(filepath.Join(tilesDir, coords.String()+suffix+".png"), png, 0o644)
+	}
+
+	rec := httptest.NewRecorder()
+	od.serveTile(rec, httptest.NewRequest(http.MethodGet, "/tiles/z13_x4317_y2692.png", nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != string(png) {
+		t.Fatalf("status %d, body %q", rec.Code, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "image/png" {
+		t.Errorf("Content-Type = %q, want image/png", ct)
+	}
+
+	// The cached tile is served without rendering again
+	od.render = func(ctx context.Context, coords tile.Coords, suffix string, w io.Writer) error {
+		t.Error("cached tile rendered again")
+		return nil
+	}
+	rec = httptest.NewRecorder()
+	od.serveTile(rec, httptest.NewRequest(http.MethodGet, "/tiles/z13_x4317_y2692.png", nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != string(png) {
+		t.Errorf("cached: status %d, body %q", rec.Code, rec.Body.String())
+	}
+}
+
+func TestServeTileRenderError(t *testing.T) {
+	od := &OnDemandTiles{cfg: OnDemandTilesConfig{TilesDir: t.TempDir(), BaseTileSize: 256, GenerateMissing: true}}
+	od.render = func(ctx context.Context, coords tile.Coords, suffix string, w io.Writer) error {
+		return &tileError{status: http.StatusServiceUnavailable, msg: "tile data source temporarily unavailable"}
+	}
+
+	rec := httptest.NewRecorder()
+	od.serveTile(rec, httptest.NewRequest(http.MethodGet, "/tiles/z13_x4317_y2692.png", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
 }
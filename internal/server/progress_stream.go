@@ -0,0 +1,131 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/MeKo-Tech/watercolormap/internal/worker"
+)
+
+// progressStreamInterval is the minimum time between two events of a progress stream, so a
+// fast batch doesn't flood browsers with one event per tile.
+const progressStreamInterval = 100 * time.Millisecond
+
+// BatchProgress is the state of a batch generation as streamed by ProgressStream.
+type BatchProgress struct {
+	Phase     string `json:"phase"`                // Current phase, e.g. "base" or "@2x"
+	Completed int    `json:"completed"`            // Tasks of the phase completed, including failed ones
+	Total     int    `json:"total"`                // Tasks of the phase
+	Failed    int    `json:"failed"`               // Tasks of the phase failed so far
+	Current   string `json:"current,omitempty"`    // Tile that completed last
+	LastError string `json:"last_error,omitempty"` // Error of the last failed task
+	Done      bool   `json:"done"`                 // The batch has finished; the stream ends
+}
+
+// ProgressStream collects the worker.ProgressEvents of a batch run and serves them as
+// Server-Sent Events, so a long-running generate can be monitored from a browser. A nil
+// *ProgressStream is valid and records nothing.
+type ProgressStream struct {
+	mu      sync.Mutex
+	state   BatchProgress
+	changed chan struct{} // Closed and replaced on every update
+}
+
+// NewProgressStream creates an empty progress stream.
+func NewProgressStream() *ProgressStream {
+	return &ProgressStream{changed: make(chan struct{})}
+}
+
+// Track starts a phase of total tasks and returns a worker.Config.OnEvent func that records
+// each event and then passes it on to next (which may be nil).
+func (s *ProgressStream) Track(phase string, total int, next worker.ProgressEventFunc) worker.ProgressEventFunc {
+	if s == nil {
+		return next
+	}
+	s.update(func(p *BatchProgress) {
+		*p = BatchProgress{Phase: phase, Total: total}
+	})
+	return func(e worker.ProgressEvent) {
+		s.update(func(p *BatchProgress) {
+			p.Completed, p.Total, p.Failed = e.Completed, e.Total, e.Failed
+			p.Current = e.LastCoords.String()
+			if e.LastErr != nil {
+				p.LastError = fmt.Sprintf("%s: %v", e.LastCoords, e.LastErr)
+			}
+		})
+		if next != nil {
+			next(e)
+		}
+	}
+}
+
+// Finish marks the batch as done; open streams send a final event and end.
+func (s *ProgressStream) Finish() {
+	if s == nil {
+		return
+	}
+	s.update(func(p *BatchProgress) { p.Done = true })
+}
+
+func (s *ProgressStream) update(fn func(*BatchProgress)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn(&s.state)
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// snapshot returns the current state and a channel closed on the next update.
+func (s *ProgressStream) snapshot() (BatchProgress, <-chan struct{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.state, s.changed
+}
+
+// Handler returns an SSE handler streaming a BatchProgress event on connect and after every
+// update, at most every 100ms. The stream ends after the event with Done set.
+func (s *ProgressStream) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		if r.ProtoMajor == 1 {
+			// Connection-specific headers are not allowed over HTTP/2
+			w.Header().Set("Connection", "keep-alive")
+		}
+
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "SSE not supported", http.StatusInternalServerError)
+			return
+		}
+
+		for {
+			state, changed := s.snapshot()
+			data, err := json.Marshal(state)
+			if err != nil {
+				return
+			}
+			if _, err := fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+				return
+			}
+			flusher.Flush()
+			if state.Done {
+				return
+			}
+
+			select {
+			case <-r.Context().Done():
+				return
+			case <-changed:
+			}
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(progressStreamInterval):
+			}
+		}
+	})
+}
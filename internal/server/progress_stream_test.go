@@ -0,0 +1,83 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/MeKo-Tech/watercolormap/internal/tile"
+	"github.com/MeKo-Tech/watercolormap/internal/worker"
+)
+
+// readProgressEvents reads the SSE events of a progress stream until it ends.
+func readProgressEvents(t *testing.T, resp *http.Response) []BatchProgress {
+	t.Helper()
+	var events []BatchProgress
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		var p BatchProgress
+		if err := json.Unmarshal([]byte(data), &p); err != nil {
+			t.Fatalf("invalid event %q: %v", data, err)
+		}
+		events = append(events, p)
+	}
+	return events
+}
+
+func TestProgressStream(t *testing.T) {
+	stream := NewProgressStream()
+	srv := httptest.NewServer(stream.Handler())
+	defer srv.Close()
+
+	var forwarded int
+	onEvent := stream.Track("base", 3, func(worker.ProgressEvent) { forwarded++ })
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if got := resp.Header.Get("Content-Type"); got != "text/event-stream" {
+		t.Errorf("Content-Type = %q, want text/event-stream", got)
+	}
+
+	onEvent(worker.ProgressEvent{LastCoords: tile.NewCoords(5, 1, 2), Completed: 1, Total: 3})
+	onEvent(worker.ProgressEvent{LastCoords: tile.NewCoords(5, 1, 3), LastErr: errors.New("boom"), Completed: 2, Total: 3, Failed: 1})
+	stream.Finish()
+
+	events := readProgressEvents(t, resp)
+	if len(events) < 2 {
+		t.Fatalf("got %d events, want at least the initial and final one", len(events))
+	}
+	if first := events[0]; first.Phase != "base" || first.Total != 3 {
+		t.Errorf("first event = %+v, want the base phase with 3 tasks", first)
+	}
+	want := BatchProgress{Phase: "base", Completed: 2, Total: 3, Failed: 1, Current: "z5_x1_y3", LastError: "z5_x1_y3: boom", Done: true}
+	if last := events[len(events)-1]; last != want {
+		t.Errorf("last event = %+v, want %+v", last, want)
+	}
+	if forwarded != 2 {
+		t.Errorf("forwarded %d events to the next func, want 2", forwarded)
+	}
+}
+
+func TestProgressStreamNil(t *testing.T) {
+	var stream *ProgressStream
+	called := false
+	stream.Track("base", 1, func(worker.ProgressEvent) { called = true })(worker.ProgressEvent{})
+	stream.Finish()
+	if !called {
+		t.Error("expected a nil stream to pass events through")
+	}
+	if stream.Track("base", 1, nil) != nil {
+		t.Error("expected a nil stream without next func to return nil")
+	}
+}
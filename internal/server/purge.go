@@ -0,0 +1,150 @@
+package server
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/MeKo-Tech/watercolormap/internal/pipeline"
+	"github.com/MeKo-Tech/watercolormap/internal/tile"
+)
+
+// PurgeTokenHeader is the request header carrying the shared secret for PurgeHandler.
+const PurgeTokenHeader = "X-Purge-Token"
+
+// maxPurgeTiles bounds the tiles a single purge request may address, so a bbox with a deep
+// zoom range can't make the server stat millions of files.
+const maxPurgeTiles = 100_000
+
+// PurgeRequest is the body of a purge request: a bbox with a zoom range, a list of tiles, or both.
+type PurgeRequest struct {
+	BBox    *[4]float64 `json:"bbox,omitempty"` // [minLon, minLat, maxLon, maxLat] in WGS84
+	ZoomMin int         `json:"zoom_min,omitempty"`
+	ZoomMax int         `json:"zoom_max,omitempty"`
+	// Tiles are addressed like tile requests: TMS rows when the server runs with TMS
+	Tiles []PurgeTile `json:"tiles,omitempty"`
+}
+
+// PurgeTile is a single tile of a PurgeRequest.
+type PurgeTile struct {
+	Z uint32 `json:"z"`
+	X uint32 `json:"x"`
+	Y uint32 `json:"y"`
+}
+
+// PurgeResponse reports the outcome of a purge.
+type PurgeResponse struct {
+	Tiles  int `json:"tiles"`  // Tiles addressed by the request
+	Purged int `json:"purged"` // Cached files deleted (all seed and @2x variants count)
+}
+
+// PurgeHandler returns a handler for POST /tiles/purge that deletes cached tiles so the next
+// request regenerates them, e.g. after OSM data changed. Requests must carry token in the
+// X-Purge-Token header. Every cached variant of an addressed tile is removed: @1x, @2x and
+// seed overrides. Renders already in flight may still write their tile afterwards.
+func (t *OnDemandTiles) PurgeHandler(token string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		got := r.Header.Get(PurgeTokenHeader)
+		if token == "" || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			http.Error(w, "invalid purge token", http.StatusUnauthorized)
+			return
+		}
+
+		var req PurgeRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+			http.Error(w, fmt.Sprintf("invalid purge request: %v", err), http.StatusBadRequest)
+			return
+		}
+		tiles, err := t.purgeTiles(req)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		purged, err := t.purge(tiles)
+		if err != nil {
+			t.log().Error("failed to purge tiles", "error", err)
+			http.Error(w, "failed to purge tiles", http.StatusInternalServerError)
+			return
+		}
+		t.log().Info("purged cached tiles", "tiles", len(tiles), "files", purged)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		_ = json.NewEncoder(w).Encode(PurgeResponse{Tiles: len(tiles), Purged: purged})
+	})
+}
+
+// purgeTiles returns the tiles a purge request addresses, as they are named on disk.
+func (t *OnDemandTiles) purgeTiles(req PurgeRequest) ([]tile.Coords, error) {
+	if req.BBox == nil && len(req.Tiles) == 0 {
+		return nil, errors.New("purge request needs a bbox or tiles")
+	}
+
+	var tiles []tile.Coords
+	if req.BBox != nil {
+		b := *req.BBox
+		if b[0] >= b[2] || b[1] >= b[3] {
+			return nil, fmt.Errorf("invalid bbox %v: min must be below max", b)
+		}
+		if req.ZoomMin < 0 || req.ZoomMax > 30 || req.ZoomMin > req.ZoomMax {
+			return nil, fmt.Errorf("invalid zoom range %d-%d", req.ZoomMin, req.ZoomMax)
+		}
+		if n := tile.TileCount(b, req.ZoomMin, req.ZoomMax); n+len(req.Tiles) > maxPurgeTiles {
+			return nil, fmt.Errorf("purge request addresses %d tiles, more than the limit of %d", n+len(req.Tiles), maxPurgeTiles)
+		}
+		for _, coords := range tile.TilesInBBox(b, req.ZoomMin, req.ZoomMax) {
+			// Files on disk are named like requests (see serveTile)
+			if t.cfg.Generator.TMS {
+				coords = coords.FlipYForTMS()
+			}
+			tiles = append(tiles, coords)
+		}
+	}
+	if len(req.Tiles) > maxPurgeTiles {
+		return nil, fmt.Errorf("purge request addresses %d tiles, more than the limit of %d", len(req.Tiles), maxPurgeTiles)
+	}
+	for _, pt := range req.Tiles {
+		coords := tile.NewCoords(pt.Z, pt.X, pt.Y)
+		if !coords.InRange() {
+			return nil, fmt.Errorf("tile %s is out of range", coords.String())
+		}
+		tiles = append(tiles, coords)
+	}
+	return tiles, nil
+}
+
+// purge deletes every cached variant of tiles from TilesDir and returns the number of files
+// removed.
+func (t *OnDemandTiles) purge(tiles []tile.Coords) (int, error) {
+	purged := 0
+	for _, coords := range tiles {
+		name, _ := pipeline.TilePath(t.cfg.TilesDir, t.cfg.Generator.FolderStructure, coords, "", "")
+		// The glob matches seed overrides (_s42.png, _s42@2x.png) but no other tiles, whose
+		// names continue with a digit instead
+		variants, err := filepath.Glob(name + "_s*.png")
+		if err != nil {
+			return purged, fmt.Errorf("failed to list cached variants of %s: %w", coords.String(), err)
+		}
+		variants = append(variants, name+".png", name+"@2x.png")
+		for _, p := range variants {
+			err := os.Remove(p)
+			switch {
+			case err == nil:
+				purged++
+			case !errors.Is(err, os.ErrNotExist):
+				return purged, fmt.Errorf("failed to remove %s: %w", p, err)
+			}
+		}
+	}
+	return purged, nil
+}
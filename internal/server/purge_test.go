@@ -0,0 +1,109 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPurgeHandler(t *testing.T) {
+	// Hannover at z10 is x=539, y=336; the bbox covers only that tile
+	const body = `{"bbox":[9.70,52.35,9.75,52.40],"zoom_min":10,"zoom_max":10}`
+
+	tests := []struct {
+		name       string
+		method     string
+		token      string
+		body       string
+		wantStatus int
+		wantPurged int
+		removed    []string
+	}{
+		{
+			name:       "bbox",
+			method:     http.MethodPost,
+			token:      "secret",
+			body:       body,
+			wantStatus: http.StatusOK,
+			wantPurged: 3,
+			removed:    []string{"z10_x539_y336.png", "z10_x539_y336@2x.png", "z10_x539_y336_s42.png"},
+		},
+		{
+			name:       "tile list",
+			method:     http.MethodPost,
+			token:      "secret",
+			body:       `{"tiles":[{"z":12,"x":2158,"y":1345},{"z":12,"x":0,"y":0}]}`,
+			wantStatus: http.StatusOK,
+			wantPurged: 1,
+			removed:    []string{"z12_x2158_y1345.png"},
+		},
+		{name: "wrong token", method: http.MethodPost, token: "guess", body: body, wantStatus: http.StatusUnauthorized},
+		{name: "missing token", method: http.MethodPost, body: body, wantStatus: http.StatusUnauthorized},
+		{name: "GET", method: http.MethodGet, token: "secret", wantStatus: http.StatusMethodNotAllowed},
+		{name: "empty request", method: http.MethodPost, token: "secret", body: `{}`, wantStatus: http.StatusBadRequest},
+		{
+			name:       "too many tiles",
+			method:     http.MethodPost,
+			token:      "secret",
+			body:       `{"bbox":[-180,-85,180,85],"zoom_min":0,"zoom_max":12}`,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	files := []string{
+		"z10_x539_y336.png",
+		"z10_x539_y336@2x.png",
+		"z10_x539_y336_s42.png",
+		"z10_x539_y3360.png", // Shares the bbox tile's name as a prefix
+		"z12_x2158_y1345.png",
+		"z10_x540_y336.png",  // Outside the bbox
+		"z11_x1078_y672.png", // Outside the zoom range
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, name := range files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte("png"), 0o644); err != nil {
+					t.Fatalf("failed to write %s: %v", name, err)
+				}
+			}
+			od := &OnDemandTiles{cfg: OnDemandTilesConfig{TilesDir: dir}}
+
+			req := httptest.NewRequest(tt.method, "/tiles/purge", strings.NewReader(tt.body))
+			if tt.token != "" {
+				req.Header.Set(PurgeTokenHeader, tt.token)
+			}
+			rec := httptest.NewRecorder()
+			od.PurgeHandler("secret").ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus == http.StatusOK {
+				var resp PurgeResponse
+				if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+					t.Fatalf("failed to decode response: %v", err)
+				}
+				if resp.Purged != tt.wantPurged {
+					t.Errorf("purged = %d, want %d", resp.Purged, tt.wantPurged)
+				}
+			}
+
+			removed := make(map[string]bool)
+			for _, name := range tt.removed {
+				removed[name] = true
+			}
+			for _, name := range files {
+				_, err := os.Stat(filepath.Join(dir, name))
+				if exists := err == nil; exists == removed[name] {
+					t.Errorf("%s: exists = %v, want %v", name, exists, !removed[name])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/MeKo-Tech/watercolormap/internal/pipeline"
+	"github.com/MeKo-Tech/watercolormap/internal/tile"
+	"github.com/MeKo-Tech/watercolormap/internal/types"
+)
+
+// queryDebugger is implemented by data sources that can show the query they run for an area
+// (see datasource.OverpassDataSource.DebugQuery).
+type queryDebugger interface {
+	DebugQuery(bounds types.BoundingBox, zoom int) string
+}
+
+// fetchArea returns the tile and padded bounds the fetch queue fetches for the data of
+// dataCoords (see pipeline.Generator.DataTile).
+func fetchArea(gen *pipeline.Generator, dataCoords tile.Coords) (types.TileCoordinate, types.BoundingBox) {
+	tileCoord := types.TileCoordinate{
+		Zoom: int(dataCoords.Z),
+		X:    int(dataCoords.X),
+		Y:    int(dataCoords.Y),
+	}
+	return tileCoord, gen.CalculateFetchBounds(dataCoords)
+}
+
+// QueryHandler returns a debug handler for GET /tiles/query?z=&x=&y= that answers with the
+// Overpass QL query generating the tile would run, as plain text for pasting into Overpass
+// turbo. It covers the padding and, for over-zoomed tiles, the ancestor whose data is fetched
+// instead (named in the X-Data-Tile header). Rows are read like tile requests: TMS rows when
+// the server runs with TMS. Nothing is fetched or rendered.
+func (t *OnDemandTiles) QueryHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		qd, ok := t.ds.(queryDebugger)
+		if !ok {
+			http.Error(w, "data source has no query to show", http.StatusNotImplemented)
+			return
+		}
+
+		coords, err := parseQueryCoords(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if t.cfg.Generator.TMS {
+			coords = coords.FlipYForTMS()
+		}
+
+		gen, err := t.getGenerator(t.cfg.BaseTileSize, t.cfg.Seed)
+		if err != nil {
+			t.log().Error("failed to init generator", "error", err)
+			http.Error(w, "failed to init generator", http.StatusInternalServerError)
+			return
+		}
+		dataCoords := gen.DataTile(coords)
+		tileCoord, bounds := fetchArea(gen, dataCoords)
+		query := qd.DebugQuery(bounds, tileCoord.Zoom)
+		if query == "" {
+			http.Error(w, fmt.Sprintf("no data source covers tile %s", coords.String()), http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("X-Data-Tile", dataCoords.String())
+		_, _ = w.Write([]byte(query))
+	})
+}
+
+// parseQueryCoords reads the tile addressed by the z, x and y query parameters.
+func parseQueryCoords(r *http.Request) (tile.Coords, error) {
+	var zxy [3]uint32
+	for i, name := range []string{"z", "x", "y"} {
+		v, err := strconv.ParseUint(r.URL.Query().Get(name), 10, 32)
+		if err != nil {
+			return tile.Coords{}, fmt.Errorf("invalid or missing %s parameter", name)
+		}
+		zxy[i] = uint32(v)
+	}
+	coords := tile.NewCoords(zxy[0], zxy[1], zxy[2])
+	if !coords.InRange() {
+		return tile.Coords{}, fmt.Errorf("tile %s is out of range", coords.String())
+	}
+	return coords, nil
+}
@@ -0,0 +1,66 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/MeKo-Tech/watercolormap/internal/datasource"
+	"github.com/MeKo-Tech/watercolormap/internal/pipeline"
+	"github.com/MeKo-Tech/watercolormap/internal/tile"
+)
+
+func TestQueryHandler(t *testing.T) {
+	ds := datasource.NewOverpassDataSource("")
+	od := &OnDemandTiles{ds: ds, cfg: OnDemandTilesConfig{BaseTileSize: 256, Seed: 1337, Generator: pipeline.GeneratorOptions{MaxDataZoom: 16}}}
+	gen, err := od.getGenerator(256, 1337)
+	if err != nil {
+		t.Fatalf("getGenerator failed: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		url        string
+		wantStatus int
+		wantData   tile.Coords
+	}{
+		{name: "tile", url: "/tiles/query?z=13&x=4317&y=2692", wantStatus: http.StatusOK, wantData: tile.NewCoords(13, 4317, 2692)},
+		{name: "over-zoomed", url: "/tiles/query?z=18&x=138139&y=86158", wantStatus: http.StatusOK, wantData: tile.NewCoords(16, 34534, 21539)},
+		{name: "missing y", url: "/tiles/query?z=13&x=4317", wantStatus: http.StatusBadRequest},
+		{name: "out of range", url: "/tiles/query?z=2&x=4&y=0", wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			od.QueryHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.url, nil))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			if got := rec.Header().Get("X-Data-Tile"); got != tt.wantData.String() {
+				t.Errorf("X-Data-Tile = %q, want %q", got, tt.wantData.String())
+			}
+			if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+				t.Errorf("Content-Type = %q, want text/plain", ct)
+			}
+			// The query of the padded fetch the fetch queue would submit
+			want := ds.DebugQuery(gen.CalculateFetchBounds(tt.wantData), int(tt.wantData.Z))
+			if rec.Body.String() != want {
+				t.Errorf("query =\n%s\nwant\n%s", rec.Body.String(), want)
+			}
+		})
+	}
+
+	t.Run("no query", func(t *testing.T) {
+		od := &OnDemandTiles{cfg: OnDemandTilesConfig{BaseTileSize: 256}}
+		rec := httptest.NewRecorder()
+		od.QueryHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/tiles/query?z=1&x=0&y=0", nil))
+		if rec.Code != http.StatusNotImplemented {
+			t.Errorf("status = %d, want %d", rec.Code, http.StatusNotImplemented)
+		}
+	})
+}
@@ -0,0 +1,129 @@
+package server
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// rateLimitIdleTTL is the minimum time a client's bucket is kept after its last request.
+const rateLimitIdleTTL = 10 * time.Minute
+
+// RateLimitConfig configures RateLimiter.
+type RateLimitConfig struct {
+	RPS    float64 // Sustained requests per second per client; <= 0 disables limiting
+	Burst  int     // Requests a client may make at once before being throttled (minimum 1)
+	Global bool    // Share one bucket between all clients instead of one per client IP
+}
+
+// RateLimiter is a token-bucket rate limiter for HTTP handlers, keyed by client IP or
+// shared globally. Buckets of clients that went quiet are evicted as requests come in.
+// It is safe for concurrent use.
+type RateLimiter struct {
+	rps   float64
+	burst float64
+	perIP bool
+	// idleTTL is how long an idle bucket is kept: at least until it has refilled completely,
+	// so dropping it doesn't change any decision.
+	idleTTL time.Duration
+	now     func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter creates a limiter, or returns nil when cfg.RPS is not positive. A nil
+// *RateLimiter passes all requests through.
+func NewRateLimiter(cfg RateLimitConfig) *RateLimiter {
+	if cfg.RPS <= 0 {
+		return nil
+	}
+	burst := float64(max(cfg.Burst, 1))
+	refill := time.Duration(burst / cfg.RPS * float64(time.Second))
+	return &RateLimiter{
+		rps:     cfg.RPS,
+		burst:   burst,
+		perIP:   !cfg.Global,
+		idleTTL: max(rateLimitIdleTTL, refill),
+		now:     time.Now,
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// Middleware wraps next so that requests over the limit get 429 Too Many Requests with a
+// Retry-After header instead of reaching it.
+func (l *RateLimiter) Middleware(next http.Handler) http.Handler {
+	if l == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ok, retryAfter := l.allow(l.key(r))
+		if !ok {
+			seconds := int(math.Ceil(retryAfter.Seconds()))
+			w.Header().Set("Retry-After", strconv.Itoa(max(seconds, 1)))
+			http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// key returns the bucket key for r: the client IP, or "" for the global bucket.
+func (l *RateLimiter) key(r *http.Request) string {
+	if !l.perIP {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// allow takes a token from key's bucket. When none is left it returns false and how long
+// until the next token is available.
+func (l *RateLimiter) allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	} else {
+		b.tokens = min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rps)
+		b.last = now
+	}
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	wait := time.Duration((1 - b.tokens) / l.rps * float64(time.Second))
+	return false, wait
+}
+
+// sweep drops buckets idle for longer than idleTTL, at most once per TTL.
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.idleTTL {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if now.Sub(b.last) >= l.idleTTL {
+			delete(l.buckets, key)
+		}
+	}
+}
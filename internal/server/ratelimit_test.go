@@ -0,0 +1,164 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a manually advanced clock for rate limiter tests.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func newTestRateLimiter(cfg RateLimitConfig) (*RateLimiter, *fakeClock) {
+	l := NewRateLimiter(cfg)
+	clock := &fakeClock{now: time.Unix(1_700_000_000, 0)}
+	l.now = clock.Now
+	return l, clock
+}
+
+func rateLimitedRequest(h http.Handler, remoteAddr string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/tiles/1/0/0.png", nil)
+	req.RemoteAddr = remoteAddr
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestRateLimiterBurstThenThrottle(t *testing.T) {
+	l, clock := newTestRateLimiter(RateLimitConfig{RPS: 2, Burst: 3})
+	h := l.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for i := 0; i < 3; i++ {
+		if rec := rateLimitedRequest(h, "10.0.0.1:1234"); rec.Code != http.StatusOK {
+			t.Fatalf("request %d within burst: status %d, want 200", i, rec.Code)
+		}
+	}
+
+	rec := rateLimitedRequest(h, "10.0.0.1:1234")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("request over burst: status %d, want 429", rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "1" {
+		t.Errorf("Retry-After = %q, want %q", got, "1")
+	}
+
+	// Another client has its own bucket
+	if rec := rateLimitedRequest(h, "10.0.0.2:1234"); rec.Code != http.StatusOK {
+		t.Errorf("other client: status %d, want 200", rec.Code)
+	}
+
+	// At 2 rps one token comes back after half a second
+	clock.Advance(500 * time.Millisecond)
+	if rec := rateLimitedRequest(h, "10.0.0.1:5678"); rec.Code != http.StatusOK {
+		t.Errorf("after refill: status %d, want 200", rec.Code)
+	}
+	if rec := rateLimitedRequest(h, "10.0.0.1:5678"); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("after using the refilled token: status %d, want 429", rec.Code)
+	}
+}
+
+func TestRateLimiterGlobal(t *testing.T) {
+	l, _ := newTestRateLimiter(RateLimitConfig{RPS: 1, Burst: 2, Global: true})
+	h := l.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	codes := []int{
+		rateLimitedRequest(h, "10.0.0.1:1").Code,
+		rateLimitedRequest(h, "10.0.0.2:1").Code,
+		rateLimitedRequest(h, "10.0.0.3:1").Code,
+	}
+	want := []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests}
+	for i := range want {
+		if codes[i] != want[i] {
+			t.Errorf("request %d: status %d, want %d", i, codes[i], want[i])
+		}
+	}
+}
+
+func TestRateLimiterRetryAfterRoundsUp(t *testing.T) {
+	l, _ := newTestRateLimiter(RateLimitConfig{RPS: 0.25, Burst: 1})
+	h := l.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	rateLimitedRequest(h, "10.0.0.1:1")
+	rec := rateLimitedRequest(h, "10.0.0.1:1")
+	if got := rec.Header().Get("Retry-After"); got != "4" {
+		t.Errorf("Retry-After = %q, want %q", got, "4")
+	}
+}
+
+func TestRateLimiterEvictsIdleBuckets(t *testing.T) {
+	l, clock := newTestRateLimiter(RateLimitConfig{RPS: 10, Burst: 5})
+
+	l.allow("10.0.0.1")
+	l.allow("10.0.0.2")
+	clock.Advance(rateLimitIdleTTL)
+	l.allow("10.0.0.3")
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.buckets) != 1 {
+		t.Errorf("expected idle buckets to be evicted, have %d", len(l.buckets))
+	}
+	if _, ok := l.buckets["10.0.0.3"]; !ok {
+		t.Error("expected the active client's bucket to be kept")
+	}
+}
+
+func TestRateLimiterDisabled(t *testing.T) {
+	if l := NewRateLimiter(RateLimitConfig{RPS: 0, Burst: 10}); l != nil {
+		t.Fatalf("expected nil limiter for RPS 0, got %+v", l)
+	}
+
+	var l *RateLimiter
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	h := l.Middleware(next)
+	for i := 0; i < 100; i++ {
+		if rec := rateLimitedRequest(h, "10.0.0.1:1"); rec.Code != http.StatusOK {
+			t.Fatalf("disabled limiter throttled request %d", i)
+		}
+	}
+}
+
+func TestRateLimiterConcurrent(t *testing.T) {
+	l := NewRateLimiter(RateLimitConfig{RPS: 1, Burst: 50})
+	h := l.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	allowed := 0
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if rateLimitedRequest(h, "10.0.0.1:1").Code == http.StatusOK {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	// The test takes far less than a second, so at most one token is refilled
+	if allowed < 50 || allowed > 51 {
+		t.Errorf("allowed %d concurrent requests, want the burst of 50", allowed)
+	}
+}
@@ -0,0 +1,76 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ReadinessStatus is the JSON body returned by the readiness endpoint.
+type ReadinessStatus struct {
+	Ready     bool      `json:"ready"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+	Cached    bool      `json:"cached"`
+}
+
+// readiness runs a deep check and caches successful results for a short TTL,
+// so frequent probes don't render a tile on every request.
+type readiness struct {
+	check   func(context.Context) error
+	ttl     time.Duration
+	timeout time.Duration
+	now     func() time.Time
+
+	mu        sync.Mutex
+	lastOK    time.Time
+	hasLastOK bool
+}
+
+func newReadiness(check func(context.Context) error, ttl, timeout time.Duration) *readiness {
+	return &readiness{
+		check:   check,
+		ttl:     ttl,
+		timeout: timeout,
+		now:     time.Now,
+	}
+}
+
+// status returns the cached result if still fresh, otherwise runs the check.
+// Concurrent callers share one check; failures are never cached.
+func (r *readiness) status(ctx context.Context) ReadinessStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	if r.hasLastOK && now.Sub(r.lastOK) < r.ttl {
+		return ReadinessStatus{Ready: true, CheckedAt: r.lastOK, Cached: true}
+	}
+
+	checkCtx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	if err := r.check(checkCtx); err != nil {
+		r.hasLastOK = false
+		return ReadinessStatus{Ready: false, Error: err.Error(), CheckedAt: now}
+	}
+
+	r.lastOK = now
+	r.hasLastOK = true
+	return ReadinessStatus{Ready: true, CheckedAt: now}
+}
+
+func (r *readiness) handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+
+		status := r.status(req.Context())
+		if !status.Ready {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_ = json.NewEncoder(w).Encode(status)
+	})
+}
@@ -0,0 +1,67 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestReadinessHandler(t *testing.T) {
+	t.Run("caches success", func(t *testing.T) {
+		calls := 0
+		r := newReadiness(func(context.Context) error {
+			calls++
+			return nil
+		}, time.Minute, time.Second)
+		now := time.Unix(1000, 0)
+		r.now = func() time.Time { return now }
+
+		for i := 0; i < 3; i++ {
+			rec := httptest.NewRecorder()
+			r.handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d", rec.Code)
+			}
+		}
+		if calls != 1 {
+			t.Fatalf("expected 1 check within TTL, got %d", calls)
+		}
+
+		now = now.Add(2 * time.Minute)
+		r.status(context.Background())
+		if calls != 2 {
+			t.Fatalf("expected re-check after TTL, got %d calls", calls)
+		}
+	})
+
+	t.Run("failure returns 503 and is not cached", func(t *testing.T) {
+		calls := 0
+		r := newReadiness(func(context.Context) error {
+			calls++
+			return errors.New("mapnik unavailable")
+		}, time.Minute, time.Second)
+
+		rec := httptest.NewRecorder()
+		r.handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		if rec.Code != http.StatusServiceUnavailable {
+			t.Fatalf("expected 503, got %d", rec.Code)
+		}
+
+		var status ReadinessStatus
+		if err := json.NewDecoder(rec.Body).Decode(&status); err != nil {
+			t.Fatalf("failed to decode body: %v", err)
+		}
+		if status.Ready || status.Error != "mapnik unavailable" {
+			t.Fatalf("unexpected status: %+v", status)
+		}
+
+		r.status(context.Background())
+		if calls != 2 {
+			t.Fatalf("expected failures to be re-checked, got %d calls", calls)
+		}
+	})
+}
@@ -0,0 +1,90 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// flightGroup runs a function at most once at a time per key: callers arriving while a call
+// for their key is in flight wait for it and share its result instead of starting another.
+// It is a context-aware variant of golang.org/x/sync/singleflight.
+type flightGroup struct {
+	mu      sync.Mutex
+	flights map[string]*flight
+}
+
+type flight struct {
+	done    chan struct{}
+	err     error
+	waiters int
+	cancel  context.CancelFunc
+}
+
+// Do runs fn for key, or joins the call already in flight for it, and returns its error.
+//
+// fn's context isn't tied to any single caller, so one impatient client doesn't fail the
+// call for everyone else; it is cancelled once every caller's context is done. A caller whose
+// context ends first returns its context's error without waiting for fn.
+func (g *flightGroup) Do(ctx context.Context, key string, fn func(context.Context) error) error {
+	g.mu.Lock()
+	if g.flights == nil {
+		g.flights = make(map[string]*flight)
+	}
+	f, ok := g.flights[key]
+	if !ok {
+		fctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		f = &flight{done: make(chan struct{}), cancel: cancel}
+		g.flights[key] = f
+		go g.run(fctx, key, f, fn)
+	}
+	f.waiters++
+	g.mu.Unlock()
+
+	select {
+	case <-f.done:
+		return f.err
+	case <-ctx.Done():
+		g.mu.Lock()
+		f.waiters--
+		if f.waiters == 0 {
+			// Nobody is waiting anymore; later callers start over instead of joining a
+			// cancelled call
+			f.cancel()
+			g.forget(key, f)
+		}
+		g.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+func (g *flightGroup) run(ctx context.Context, key string, f *flight, fn func(context.Context) error) {
+	defer func() {
+		if p := recover(); p != nil {
+			f.err = fmt.Errorf("panic: %v", p)
+		}
+		g.mu.Lock()
+		g.forget(key, f)
+		g.mu.Unlock()
+		f.cancel()
+		close(f.done)
+	}()
+	f.err = fn(ctx)
+}
+
+// forget removes f from the group if it is still the call in flight for key. g.mu must be held.
+func (g *flightGroup) forget(key string, f *flight) {
+	if g.flights[key] == f {
+		delete(g.flights, key)
+	}
+}
+
+// waiters returns the number of callers waiting for the call in flight for key.
+func (g *flightGroup) waiters(key string) int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if f, ok := g.flights[key]; ok {
+		return f.waiters
+	}
+	return 0
+}
@@ -0,0 +1,59 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestFlightGroupCancel checks that a call outlives an impatient caller as long as another
+// one waits for it, and is cancelled once every caller has gone.
+func TestFlightGroupCancel(t *testing.T) {
+	var g flightGroup
+	started := make(chan struct{})
+	release := make(chan struct{})
+	cancelled := make(chan struct{})
+	fn := func(ctx context.Context) error {
+		close(started)
+		select {
+		case <-release:
+			return nil
+		case <-ctx.Done():
+			close(cancelled)
+			return ctx.Err()
+		}
+	}
+
+	ctx1, cancel1 := context.WithCancel(context.Background())
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	errs := make(chan error, 2)
+	go func() { errs <- g.Do(ctx1, "tile", fn) }()
+	<-started
+	go func() { errs <- g.Do(ctx2, "tile", fn) }()
+	for g.waiters("tile") < 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel1()
+	if err := <-errs; !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled caller got %v, want context.Canceled", err)
+	}
+	select {
+	case <-cancelled:
+		t.Fatal("call cancelled while a caller still waits for it")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	cancel2()
+	<-errs
+	select {
+	case <-cancelled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("call not cancelled after every caller left")
+	}
+	if n := g.waiters("tile"); n != 0 {
+		t.Errorf("%d waiters left after the call ended", n)
+	}
+	close(release)
+}
@@ -0,0 +1,98 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+const (
+	// tileJSONVersion is the TileJSON specification version of the served documents.
+	tileJSONVersion = "3.0.0"
+
+	// tileURLPath is the URL path template of served tiles (see parseTilePath).
+	tileURLPath = "/tiles/z{z}_x{x}_y{y}.png"
+
+	// onDemandMaxZoom is the deepest zoom advertised for on-demand tiles, as for OSM's
+	// standard tiles; deeper tiles render, but their data stops growing.
+	onDemandMaxZoom = 18
+
+	osmAttribution = "© OpenStreetMap contributors"
+)
+
+// TileJSON is a TileJSON document describing the served tiles to map clients (see
+// https://github.com/mapbox/tilejson-spec).
+type TileJSON struct {
+	TileJSON    string      `json:"tilejson"`
+	Name        string      `json:"name,omitempty"`
+	Description string      `json:"description,omitempty"`
+	Attribution string      `json:"attribution,omitempty"`
+	Scheme      string      `json:"scheme"`
+	Tiles       []string    `json:"tiles"`
+	MinZoom     int         `json:"minzoom"`
+	MaxZoom     int         `json:"maxzoom"`
+	Bounds      *[4]float64 `json:"bounds,omitempty"`
+	Center      *[3]float64 `json:"center,omitempty"`
+}
+
+// TileJSONHandler serves doc as tiles.json. The tile URL is built from the request's host,
+// so clients reaching the server under any name get working URLs; doc's TileJSON and Tiles
+// are filled in.
+func TileJSONHandler(doc TileJSON) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scheme := "http"
+		if r.TLS != nil {
+			scheme = "https"
+		}
+		d := doc
+		d.TileJSON = tileJSONVersion
+		d.Tiles = []string{scheme + "://" + r.Host + tileURLPath}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-cache")
+		if err := json.NewEncoder(w).Encode(d); err != nil {
+			http.Error(w, "failed to encode tiles.json", http.StatusInternalServerError)
+		}
+	})
+}
+
+// tileScheme returns the TileJSON scheme of tiles addressed with TMS or XYZ rows.
+func tileScheme(tms bool) string {
+	if tms {
+		return "tms"
+	}
+	return "xyz"
+}
+
+// TileJSON describes the on-demand tiles.
+func (t *OnDemandTiles) TileJSON() TileJSON {
+	return TileJSON{
+		Name:        "watercolormap",
+		Attribution: osmAttribution,
+		Scheme:      tileScheme(t.cfg.Generator.TMS),
+		MinZoom:     0,
+		MaxZoom:     onDemandMaxZoom,
+	}
+}
+
+// TileJSON describes the archive's tiles from its metadata.
+func (h *MBTilesHandler) TileJSON() (TileJSON, error) {
+	meta, err := h.reader.Metadata()
+	if err != nil {
+		return TileJSON{}, err
+	}
+	doc := TileJSON{
+		Name:        meta.Name,
+		Description: meta.Description,
+		Attribution: meta.Attribution,
+		Scheme:      tileScheme(h.tms),
+		MinZoom:     meta.MinZoom,
+		MaxZoom:     meta.MaxZoom,
+	}
+	if meta.Bounds != [4]float64{} {
+		doc.Bounds = &meta.Bounds
+	}
+	if meta.Center != [3]float64{} {
+		doc.Center = &meta.Center
+	}
+	return doc, nil
+}
@@ -0,0 +1,83 @@
+package server
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/MeKo-Tech/watercolormap/internal/mbtiles"
+	"github.com/MeKo-Tech/watercolormap/internal/pipeline"
+)
+
+func TestTileJSONHandler(t *testing.T) {
+	od := &OnDemandTiles{cfg: OnDemandTilesConfig{Generator: pipeline.GeneratorOptions{TMS: true}}}
+	h := Compress(TileJSONHandler(od.TileJSON()))
+
+	req := httptest.NewRequest(http.MethodGet, "http://maps.example.com:8080/tiles.json", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if got := rec.Header().Get("Content-Encoding"); got != "gzip" {
+		t.Fatalf("Content-Encoding = %q, want gzip", got)
+	}
+	zr, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc TileJSON
+	if err := json.NewDecoder(zr).Decode(&doc); err != nil {
+		t.Fatalf("failed to decode tiles.json: %v", err)
+	}
+
+	if doc.TileJSON != tileJSONVersion || doc.Scheme != "tms" || doc.MaxZoom != onDemandMaxZoom {
+		t.Errorf("unexpected document: %+v", doc)
+	}
+	if want := "http://maps.example.com:8080/tiles/z{z}_x{x}_y{y}.png"; len(doc.Tiles) != 1 || doc.Tiles[0] != want {
+		t.Errorf("tiles = %q, want [%q]", doc.Tiles, want)
+	}
+}
+
+func TestMBTilesHandlerTileJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tiles.mbtiles")
+	meta := mbtiles.Metadata{
+		Name:        "hannover",
+		Format:      "png",
+		Attribution: osmAttribution,
+		MinZoom:     10,
+		MaxZoom:     14,
+		Bounds:      [4]float64{9.6, 52.3, 9.9, 52.45},
+	}
+	w, err := mbtiles.New(path, meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	h, err := NewMBTilesHandler(MBTilesConfig{MBTilesPath: path}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+
+	doc, err := h.TileJSON()
+	if err != nil {
+		t.Fatalf("TileJSON failed: %v", err)
+	}
+	if doc.Name != meta.Name || doc.Scheme != "xyz" || doc.MinZoom != 10 || doc.MaxZoom != 14 {
+		t.Errorf("unexpected document: %+v", doc)
+	}
+	if doc.Bounds == nil || *doc.Bounds != meta.Bounds {
+		t.Errorf("bounds = %v, want %v", doc.Bounds, meta.Bounds)
+	}
+	if doc.Center != nil {
+		t.Errorf("center = %v, want none", *doc.Center)
+	}
+}
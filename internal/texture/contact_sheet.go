@@ -0,0 +1,82 @@
+package texture
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+
+	"github.com/MeKo-Tech/watercolormap/internal/geojson"
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	contactSheetGap         = 16
+	contactSheetLabelHeight = 24
+)
+
+// ContactSheet lays out the given layer textures side by side, each scaled to thumbSize
+// and labeled with its layer name, on a white background. Layers are placed in the default
+// texture order; layers missing from textures are skipped.
+// This is intended as a quick preview for theme authors tuning texture parameters.
+func ContactSheet(textures map[geojson.LayerType]image.Image, thumbSize int) (*image.NRGBA, error) {
+	if thumbSize <= 0 {
+		return nil, fmt.Errorf("thumbnail size must be positive")
+	}
+
+	layers := make([]geojson.LayerType, 0, len(defaultTextureOrder))
+	for _, layer := range defaultTextureOrder {
+		if textures[layer] != nil {
+			layers = append(layers, layer)
+		}
+	}
+	if len(layers) == 0 {
+		return nil, fmt.Errorf("no textures to preview")
+	}
+
+	width := len(layers)*(thumbSize+contactSheetGap) + contactSheetGap
+	height := thumbSize + contactSheetLabelHeight + 2*contactSheetGap
+	sheet := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(sheet, sheet.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+
+	drawer := &font.Drawer{
+		Dst:  sheet,
+		Src:  image.NewUniform(color.NRGBA{R: 40, G: 40, B: 40, A: 255}),
+		Face: basicfont.Face7x13,
+	}
+
+	for i, layer := range layers {
+		x := contactSheetGap + i*(thumbSize+contactSheetGap)
+		y := contactSheetGap
+		thumbRect := image.Rect(x, y, x+thumbSize, y+thumbSize)
+
+		src := textures[layer]
+		xdraw.CatmullRom.Scale(sheet, thumbRect, src, src.Bounds(), xdraw.Src, nil)
+
+		label := string(layer)
+		if filename, ok := DefaultLayerTextures[layer]; ok {
+			label = fmt.Sprintf("%s (%s)", layer, filename)
+		}
+		labelWidth := drawer.MeasureString(label).Ceil()
+		labelX := x + (thumbSize-labelWidth)/2
+		if labelX < x {
+			labelX = x
+		}
+		drawer.Dot = fixed.P(labelX, y+thumbSize+contactSheetLabelHeight-6)
+		drawer.DrawString(label)
+	}
+
+	return sheet, nil
+}
+
+// WriteContactSheet renders a contact sheet for textures and writes it as a PNG to path.
+func WriteContactSheet(path string, textures map[geojson.LayerType]image.Image, thumbSize int) error {
+	sheet, err := ContactSheet(textures, thumbSize)
+	if err != nil {
+		return err
+	}
+	return writePNG(path, sheet)
+}
@@ -0,0 +1,51 @@
+package texture
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/MeKo-Tech/watercolormap/internal/geojson"
+)
+
+func TestContactSheetLayout(t *testing.T) {
+	water := image.NewNRGBA(image.Rect(0, 0, 8, 8))
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			water.SetNRGBA(x, y, color.NRGBA{R: 255, A: 255})
+		}
+	}
+	textures := map[geojson.LayerType]image.Image{
+		geojson.LayerLand:  image.NewNRGBA(image.Rect(0, 0, 8, 8)),
+		geojson.LayerWater: water,
+	}
+
+	const thumb = 32
+	sheet, err := ContactSheet(textures, thumb)
+	if err != nil {
+		t.Fatalf("ContactSheet returned error: %v", err)
+	}
+
+	wantW := 2*(thumb+contactSheetGap) + contactSheetGap
+	wantH := thumb + contactSheetLabelHeight + 2*contactSheetGap
+	if sheet.Bounds().Dx() != wantW || sheet.Bounds().Dy() != wantH {
+		t.Fatalf("unexpected sheet size %v, want %dx%d", sheet.Bounds(), wantW, wantH)
+	}
+
+	// Land comes before water in the default order, so water is the second thumbnail.
+	cx := contactSheetGap + (thumb + contactSheetGap) + thumb/2
+	cy := contactSheetGap + thumb/2
+	if got := sheet.NRGBAAt(cx, cy); got.R != 255 || got.G != 0 {
+		t.Errorf("expected red water thumbnail at (%d,%d), got %v", cx, cy, got)
+	}
+}
+
+func TestContactSheetErrors(t *testing.T) {
+	if _, err := ContactSheet(nil, 32); err == nil {
+		t.Error("expected error for empty texture set")
+	}
+	textures := map[geojson.LayerType]image.Image{geojson.LayerLand: image.NewNRGBA(image.Rect(0, 0, 2, 2))}
+	if _, err := ContactSheet(textures, 0); err == nil {
+		t.Error("expected error for non-positive thumbnail size")
+	}
+}
@@ -0,0 +1,105 @@
+package texture
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// TileTextureScaledRectInto is like TileTextureRectInto, but magnifies the texture to scale
+// pixels per texel (values below 1 are treated as 1), e.g. to stretch a small texture over a
+// larger area. With bilinear set, every pixel blends the four texels around its center,
+// wrapping at the texture edges like the repeats do, so magnified textures are smooth and
+// stay seamless; otherwise each texel repeats as a block, which keeps large textures exact.
+// Sampling depends only on the global pixel position, so adjacent tiles match.
+func TileTextureScaledRectInto(src image.Image, width, height int, offsetX, offsetY int, scale float64, bilinear bool, dst *image.NRGBA) {
+	if src == nil || width <= 0 || height <= 0 || dst == nil {
+		return
+	}
+
+	bounds := src.Bounds()
+	srcW := bounds.Dx()
+	srcH := bounds.Dy()
+
+	if srcW == 0 || srcH == 0 {
+		return
+	}
+	if scale < 1 || math.IsNaN(scale) {
+		scale = 1
+	}
+
+	cols := textureTaps(offsetX, width, srcW, scale, bilinear)
+	rows := textureTaps(offsetY, height, srcH, scale, bilinear)
+
+	for y, ry := range rows {
+		sy0, sy1 := bounds.Min.Y+ry.i0, bounds.Min.Y+ry.i1
+		for x, cx := range cols {
+			sx0, sx1 := bounds.Min.X+cx.i0, bounds.Min.X+cx.i1
+			if !bilinear {
+				dst.SetNRGBA(x, y, getNRGBA(src, sx0, sy0))
+				continue
+			}
+
+			// Blend premultiplied so transparent texels don't darken their neighbors
+			var r, g, b, a float64
+			for _, tap := range [4]struct {
+				x, y int
+				w    float64
+			}{
+				{sx0, sy0, (1 - cx.t) * (1 - ry.t)},
+				{sx1, sy0, cx.t * (1 - ry.t)},
+				{sx0, sy1, (1 - cx.t) * ry.t},
+				{sx1, sy1, cx.t * ry.t},
+			} {
+				c := getNRGBA(src, tap.x, tap.y)
+				wa := tap.w * float64(c.A)
+				r += wa * float64(c.R)
+				g += wa * float64(c.G)
+				b += wa * float64(c.B)
+				a += wa
+			}
+			if a == 0 {
+				dst.SetNRGBA(x, y, color.NRGBA{})
+				continue
+			}
+			i := dst.PixOffset(x, y)
+			dst.Pix[i] = uint8(math.Round(r / a))
+			dst.Pix[i+1] = uint8(math.Round(g / a))
+			dst.Pix[i+2] = uint8(math.Round(b / a))
+			dst.Pix[i+3] = uint8(math.Round(a))
+		}
+	}
+}
+
+// textureTap is the sampling of one destination column or row: texel i0, blended toward
+// texel i1 by t for bilinear sampling.
+type textureTap struct {
+	i0, i1 int
+	t      float64
+}
+
+// textureTaps returns the taps of n destination pixels starting at global pixel offset
+// along a texture axis of size texels magnified by scale.
+func textureTaps(offset, n, size int, scale float64, bilinear bool) []textureTap {
+	wrap := func(i int) int {
+		r := i % size
+		if r < 0 {
+			r += size
+		}
+		return r
+	}
+
+	taps := make([]textureTap, n)
+	for i := range taps {
+		g := float64(offset + i)
+		if !bilinear {
+			taps[i].i0 = wrap(int(math.Floor((g + 0.5) / scale)))
+			continue
+		}
+		// Pixel centers in texel coordinates, with texel centers at integers
+		u := (g+0.5)/scale - 0.5
+		fu := math.Floor(u)
+		taps[i] = textureTap{i0: wrap(int(fu)), i1: wrap(int(fu) + 1), t: u - fu}
+	}
+	return taps
+}
@@ -0,0 +1,67 @@
+package texture
+
+import (
+	"image"
+	"math"
+	"testing"
+)
+
+func TestTileTextureScaledBilinear(t *testing.T) {
+	const size, scale = 8, 8
+	src := gradientTexture(size) // Red ramps 0, 30, ..., 210 and wraps back to 0
+
+	dst := image.NewNRGBA(image.Rect(0, 0, 64, 64))
+	TileTextureScaledRectInto(src, 64, 64, 0, 0, scale, true, dst)
+
+	// Between texel centers the red channel is the linear blend of the two texels
+	texel := func(i int) float64 { return float64(src.NRGBAAt((i+size)%size, 0).R) }
+	for x := 0; x < 64; x++ {
+		u := (float64(x)+0.5)/scale - 0.5
+		i := int(math.Floor(u))
+		want := texel(i) + (texel(i+1)-texel(i))*(u-float64(i))
+		if got := float64(dst.NRGBAAt(x, 10).R); math.Abs(got-want) > 1 {
+			t.Errorf("x=%d: red %v, want %.1f", x, got, want)
+		}
+	}
+
+	// No hard seams: the 210 -> 0 step where the texture wraps is spread over a texel too
+	maxStep := func(img *image.NRGBA) int {
+		steps := 0
+		for x := 0; x < 64; x++ {
+			a, b := int(img.NRGBAAt(x, 10).R), int(img.NRGBAAt((x+1)%64, 10).R)
+			steps = max(steps, max(a-b, b-a))
+		}
+		return steps
+	}
+	if got := maxStep(dst); got > 27 {
+		t.Errorf("largest step between neighboring pixels is %d, want at most 27", got)
+	}
+	blocky := image.NewNRGBA(image.Rect(0, 0, 64, 64))
+	TileTextureScaledRectInto(src, 64, 64, 0, 0, scale, false, blocky)
+	if got := maxStep(blocky); got != 210 {
+		t.Errorf("expected nearest sampling to keep the hard wrap step, got %d", got)
+	}
+}
+
+func TestTileTextureScaledSeamless(t *testing.T) {
+	src := gradientTexture(8)
+	for _, bilinear := range []bool{false, true} {
+		ref := image.NewNRGBA(image.Rect(0, 0, 64, 64))
+		TileTextureScaledRectInto(src, 64, 64, -20, 37, 5, bilinear, ref)
+		for _, off := range []image.Point{{0, 0}, {32, 0}, {0, 32}, {32, 32}} {
+			part := image.NewNRGBA(image.Rect(0, 0, 32, 32))
+			TileTextureScaledRectInto(src, 32, 32, -20+off.X, 37+off.Y, 5, bilinear, part)
+			assertMatchesSubregion(t, part, ref, off.X, off.Y)
+		}
+	}
+}
+
+func TestTileTextureScaledUnscaledMatchesTiling(t *testing.T) {
+	src := gradientTexture(8)
+	want := TileTextureRect(src, 40, 40, 13, -7)
+	for _, bilinear := range []bool{false, true} {
+		got := image.NewNRGBA(image.Rect(0, 0, 40, 40))
+		TileTextureScaledRectInto(src, 40, 40, 13, -7, 1, bilinear, got)
+		assertMatchesSubregion(t, got, want, 0, 0)
+	}
+}
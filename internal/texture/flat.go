@@ -0,0 +1,39 @@
+package texture
+
+import (
+	"image"
+	"image/color"
+)
+
+// Flat returns a 1×1 texture of the mean color of src, which tiles into a solid fill. The mean
+// is alpha-weighted, so transparent texels don't darken it. It returns nil for a nil or empty src.
+func Flat(src image.Image) *image.NRGBA {
+	if src == nil || src.Bounds().Empty() {
+		return nil
+	}
+
+	var r, g, b, a uint64
+	bounds := src.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := getNRGBA(src, x, y)
+			r += uint64(c.R) * uint64(c.A)
+			g += uint64(c.G) * uint64(c.A)
+			b += uint64(c.B) * uint64(c.A)
+			a += uint64(c.A)
+		}
+	}
+
+	out := image.NewNRGBA(image.Rect(0, 0, 1, 1))
+	if a == 0 {
+		return out
+	}
+	n := uint64(bounds.Dx() * bounds.Dy())
+	out.SetNRGBA(0, 0, color.NRGBA{
+		R: uint8((r + a/2) / a),
+		G: uint8((g + a/2) / a),
+		B: uint8((b + a/2) / a),
+		A: uint8((a + n/2) / n),
+	})
+	return out
+}
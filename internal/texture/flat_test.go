@@ -0,0 +1,28 @@
+package texture
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestFlat(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 2, 2))
+	src.SetNRGBA(0, 0, color.NRGBA{R: 200, G: 100, B: 0, A: 255})
+	src.SetNRGBA(1, 0, color.NRGBA{R: 100, G: 200, B: 50, A: 255})
+	src.SetNRGBA(0, 1, color.NRGBA{R: 150, G: 150, B: 25, A: 255})
+	src.SetNRGBA(1, 1, color.NRGBA{R: 255, G: 0, B: 255}) // Transparent; its color must not count
+
+	flat := Flat(src)
+	if flat.Bounds() != image.Rect(0, 0, 1, 1) {
+		t.Fatalf("bounds = %v, want 1x1", flat.Bounds())
+	}
+	want := color.NRGBA{R: 150, G: 150, B: 25, A: 191}
+	if got := flat.NRGBAAt(0, 0); got != want {
+		t.Errorf("flat color = %+v, want %+v", got, want)
+	}
+
+	if Flat(nil) != nil {
+		t.Error("expected nil for a nil texture")
+	}
+}
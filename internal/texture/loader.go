@@ -1,36 +1,187 @@
 package texture
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"image"
+	"io"
+	"io/fs"
+	"net/http"
 	"os"
-	"path/filepath"
+	"path"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/MeKo-Tech/watercolormap/assets"
 	"github.com/MeKo-Tech/watercolormap/internal/geojson"
 
 	_ "image/png" // Register PNG decoder
 )
 
-// LoadDefaultTextures loads the default textures for all watercolor layers from the given directory.
+// urlClient is used by LoadTexturesFromURLs. Textures are small, so a modest
+// timeout is enough to fail fast on an unreachable CDN.
+var urlClient = &http.Client{Timeout: 30 * time.Second}
+
+// TextureLoadError reports the layer textures that could not be loaded from a textures
+// directory, alongside the ones that could, so a broken or incomplete directory is
+// diagnosed at startup instead of as paint errors mid-render.
+type TextureLoadError struct {
+	Source string                      // Directory the textures were loaded from
+	Failed map[geojson.LayerType]error // Why each missing or unreadable texture failed
+	Loaded []geojson.LayerType         // Layers whose texture loaded, sorted
+}
+
+func (e *TextureLoadError) Error() string {
+	failed := make([]string, 0, len(e.Failed))
+	for _, layer := range sortedLayers(e.Failed) {
+		failed = append(failed, fmt.Sprintf("%s (%s): %v", layer, DefaultLayerTextures[layer], e.Failed[layer]))
+	}
+	loaded := "none"
+	if len(e.Loaded) > 0 {
+		names := make([]string, len(e.Loaded))
+		for i, layer := range e.Loaded {
+			names[i] = string(layer)
+		}
+		loaded = strings.Join(names, ", ")
+	}
+	return fmt.Sprintf("failed to load %d of %d textures from %s: %s; loaded: %s",
+		len(e.Failed), len(e.Failed)+len(e.Loaded), e.Source, strings.Join(failed, "; "), loaded)
+}
+
+// Unwrap returns the per-layer errors, so errors.Is(err, fs.ErrNotExist) reports a missing file.
+func (e *TextureLoadError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Failed))
+	for _, layer := range sortedLayers(e.Failed) {
+		errs = append(errs, e.Failed[layer])
+	}
+	return errs
+}
+
+// sortedLayers returns the keys of m in name order.
+func sortedLayers[V any](m map[geojson.LayerType]V) []geojson.LayerType {
+	layers := make([]geojson.LayerType, 0, len(m))
+	for layer := range m {
+		layers = append(layers, layer)
+	}
+	slices.Sort(layers)
+	return layers
+}
+
+// LoadDefaultTextures loads the default textures for all watercolor layers from the given
+// directory. If any texture is missing or unreadable, it returns a *TextureLoadError.
 func LoadDefaultTextures(dir string) (map[geojson.LayerType]image.Image, error) {
+	textures, err := LoadTexturesFromFS(os.DirFS(dir), ".")
+	var loadErr *TextureLoadError
+	if errors.As(err, &loadErr) {
+		loadErr.Source = dir
+		return nil, loadErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load textures from %s: %w", dir, err)
+	}
+	return textures, nil
+}
+
+// LoadEmbeddedDefaultTextures loads the default watercolor textures compiled into
+// the binary (see assets.TexturesFS). This works for native and js/wasm builds.
+func LoadEmbeddedDefaultTextures() (map[geojson.LayerType]image.Image, error) {
+	return LoadTexturesFromFS(assets.TexturesFS, "textures")
+}
+
+// LoadTexturesFromFS loads the default textures for all watercolor layers from dir
+// inside fsys. fsys may be an embed.FS, os.DirFS, or any other fs.FS implementation.
+// Every texture is attempted; if any is missing, unreadable or empty, it returns a
+// *TextureLoadError naming each failed layer and the layers that did load.
+func LoadTexturesFromFS(fsys fs.FS, dir string) (map[geojson.LayerType]image.Image, error) {
 	textures := make(map[geojson.LayerType]image.Image)
+	failed := make(map[geojson.LayerType]error)
 
 	for layer, filename := range DefaultLayerTextures {
-		path := filepath.Join(dir, filename)
-
-		file, err := os.Open(path)
+		img, err := decodeTexture(fsys, path.Join(dir, filename))
 		if err != nil {
-			return nil, fmt.Errorf("failed to open texture %s: %w", path, err)
+			failed[layer] = err
+			continue
 		}
+		textures[layer] = img
+	}
+
+	if len(failed) > 0 {
+		return nil, &TextureLoadError{Source: dir, Failed: failed, Loaded: sortedLayers(textures)}
+	}
+	return textures, nil
+}
 
-		img, _, err := image.Decode(file)
-		file.Close()
+// decodeTexture opens and decodes the texture name inside fsys.
+func decodeTexture(fsys fs.FS, name string) (image.Image, error) {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open texture %s: %w", name, err)
+	}
+	defer file.Close() // nolint:errcheck
+
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode texture %s: %w", name, err)
+	}
+	if img.Bounds().Empty() {
+		return nil, fmt.Errorf("texture %s is empty", name)
+	}
+	return img, nil
+}
+
+// LoadTexturesFromURLs downloads and decodes one texture per layer from the given URLs
+// (e.g. CDN-hosted textures). Only the layers present in urls are loaded.
+func LoadTexturesFromURLs(urls map[geojson.LayerType]string) (map[geojson.LayerType]image.Image, error) {
+	textures := make(map[geojson.LayerType]image.Image, len(urls))
+
+	for layer, url := range urls {
+		img, err := fetchTexture(url)
 		if err != nil {
-			return nil, fmt.Errorf("failed to decode texture %s: %w", path, err)
+			return nil, fmt.Errorf("failed to load texture for layer %s: %w", layer, err)
 		}
-
 		textures[layer] = img
 	}
 
 	return textures, nil
 }
+
+// fetchTexture downloads a single texture image from url.
+func fetchTexture(url string) (image.Image, error) {
+	resp, err := urlClient.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch %s: unexpected status %s", url, resp.Status)
+	}
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", url, err)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(b))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", url, err)
+	}
+	return img, nil
+}
+
+// LoadTexture loads and decodes a single texture image from a file.
+func LoadTexture(path string) (image.Image, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open texture %s: %w", path, err)
+	}
+	defer file.Close()
+
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode texture %s: %w", path, err)
+	}
+	return img, nil
+}
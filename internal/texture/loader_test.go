@@ -1,12 +1,20 @@
 package texture
 
 import (
+	"bytes"
+	"errors"
 	"image"
+	"image/png"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"testing/fstest"
 
-	_ "image/png" // Register PNG decoder
+	"github.com/MeKo-Tech/watercolormap/internal/geojson"
 )
 
 func TestLoadPNGTextures(t *testing.T) {
@@ -61,3 +69,130 @@ func TestLoadPNGTextures(t *testing.T) {
 		})
 	}
 }
+
+func encodeTestPNG(t *testing.T) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+	for i := range img.Pix {
+		img.Pix[i] = 200
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("Failed to encode PNG: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestLoadTexturesFromFS(t *testing.T) {
+	data := encodeTestPNG(t)
+	fsys := fstest.MapFS{}
+	for _, filename := range DefaultLayerTextures {
+		fsys["textures/"+filename] = &fstest.MapFile{Data: data}
+	}
+
+	textures, err := LoadTexturesFromFS(fsys, "textures")
+	if err != nil {
+		t.Fatalf("LoadTexturesFromFS returned error: %v", err)
+	}
+	if len(textures) != len(DefaultLayerTextures) {
+		t.Fatalf("Expected %d textures, got %d", len(DefaultLayerTextures), len(textures))
+	}
+	for layer := range DefaultLayerTextures {
+		if textures[layer] == nil {
+			t.Errorf("Missing texture for layer %s", layer)
+		}
+	}
+
+	if _, err := LoadTexturesFromFS(fstest.MapFS{}, "textures"); err == nil {
+		t.Error("Expected error for empty FS, got nil")
+	}
+}
+
+func TestLoadDefaultTexturesReportsFailedLayers(t *testing.T) {
+	data := encodeTestPNG(t)
+	dir := t.TempDir()
+	for layer, filename := range DefaultLayerTextures {
+		switch layer {
+		case geojson.LayerWater:
+			continue // Missing
+		case geojson.LayerRoads:
+			data := []byte("not a png")
+			if err := os.WriteFile(filepath.Join(dir, filename), data, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		if err := os.WriteFile(filepath.Join(dir, filename), data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	textures, err := LoadDefaultTextures(dir)
+	if textures != nil {
+		t.Error("expected no textures on failure")
+	}
+	var loadErr *TextureLoadError
+	if !errors.As(err, &loadErr) {
+		t.Fatalf("expected a *TextureLoadError, got %T: %v", err, err)
+	}
+	if loadErr.Source != dir {
+		t.Errorf("Source = %q, want %q", loadErr.Source, dir)
+	}
+	if len(loadErr.Failed) != 2 || loadErr.Failed[geojson.LayerWater] == nil || loadErr.Failed[geojson.LayerRoads] == nil {
+		t.Errorf("Failed = %v, want water and roads", loadErr.Failed)
+	}
+	if got, want := len(loadErr.Loaded), len(DefaultLayerTextures)-2; got != want {
+		t.Errorf("Loaded %d layers, want %d", got, want)
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Error("expected errors.Is(err, fs.ErrNotExist) for the missing water texture")
+	}
+	for _, want := range []string{"water (water.png)", "roads (gray.png)", "loaded: highways, land"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+}
+
+func TestLoadEmbeddedDefaultTextures(t *testing.T) {
+	textures, err := LoadEmbeddedDefaultTextures()
+	if err != nil {
+		t.Fatalf("LoadEmbeddedDefaultTextures returned error: %v", err)
+	}
+	if len(textures) != len(DefaultLayerTextures) {
+		t.Errorf("Expected %d textures, got %d", len(DefaultLayerTextures), len(textures))
+	}
+}
+
+func TestLoadTexturesFromURLs(t *testing.T) {
+	data := encodeTestPNG(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/water.png" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(data) //nolint:errcheck
+	}))
+	defer srv.Close()
+
+	textures, err := LoadTexturesFromURLs(map[geojson.LayerType]string{
+		geojson.LayerWater: srv.URL + "/water.png",
+	})
+	if err != nil {
+		t.Fatalf("LoadTexturesFromURLs returned error: %v", err)
+	}
+	if textures[geojson.LayerWater] == nil {
+		t.Fatal("Missing water texture")
+	}
+	if got := textures[geojson.LayerWater].Bounds().Dx(); got != 4 {
+		t.Errorf("Expected width 4, got %d", got)
+	}
+
+	_, err = LoadTexturesFromURLs(map[geojson.LayerType]string{
+		geojson.LayerLand: srv.URL + "/missing.png",
+	})
+	if err == nil {
+		t.Error("Expected error for missing texture, got nil")
+	}
+}
@@ -55,27 +55,37 @@ func TextureNameForLayer(layer geojson.LayerType) (string, bool) {
 // TileTexture tiles a source texture into a square tile of the given size.
 // Offsets align the sampling to a global texture grid to keep seams invisible across tiles.
 func TileTexture(src image.Image, tileSize int, offsetX, offsetY int) *image.NRGBA {
-	if src == nil || tileSize <= 0 {
+	return TileTextureRect(src, tileSize, tileSize, offsetX, offsetY)
+}
+
+// TileTextureRect is like TileTexture for a width×height destination.
+func TileTextureRect(src image.Image, width, height int, offsetX, offsetY int) *image.NRGBA {
+	if src == nil || width <= 0 || height <= 0 {
 		return nil
 	}
 
-	dst := image.NewNRGBA(image.Rect(0, 0, tileSize, tileSize))
-	TileTextureInto(src, tileSize, offsetX, offsetY, dst)
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	TileTextureRectInto(src, width, height, offsetX, offsetY, dst)
 	return dst
 }
 
 // TileTextureInto tiles a source texture into an existing destination buffer.
 // This avoids allocation when the caller can reuse a buffer.
 func TileTextureInto(src image.Image, tileSize int, offsetX, offsetY int, dst *image.NRGBA) {
-	if src == nil || tileSize <= 0 || dst == nil {
+	TileTextureRectInto(src, tileSize, tileSize, offsetX, offsetY, dst)
+}
+
+// TileTextureRectInto is like TileTextureInto for a width×height region of dst.
+func TileTextureRectInto(src image.Image, width, height int, offsetX, offsetY int, dst *image.NRGBA) {
+	if src == nil || width <= 0 || height <= 0 || dst == nil {
 		return
 	}
 
 	bounds := src.Bounds()
-	width := bounds.Dx()
-	height := bounds.Dy()
+	srcW := bounds.Dx()
+	srcH := bounds.Dy()
 
-	if width == 0 || height == 0 {
+	if srcW == 0 || srcH == 0 {
 		return
 	}
 
@@ -87,10 +97,10 @@ func TileTextureInto(src image.Image, tileSize int, offsetX, offsetY int, dst *i
 		return r
 	}
 
-	for y := 0; y < tileSize; y++ {
-		sy := bounds.Min.Y + mod(offsetY+y, height)
-		for x := 0; x < tileSize; x++ {
-			sx := bounds.Min.X + mod(offsetX+x, width)
+	for y := 0; y < height; y++ {
+		sy := bounds.Min.Y + mod(offsetY+y, srcH)
+		for x := 0; x < width; x++ {
+			sx := bounds.Min.X + mod(offsetX+x, srcW)
 			dst.SetNRGBA(x, y, getNRGBA(src, sx, sy))
 		}
 	}
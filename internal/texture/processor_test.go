@@ -53,6 +53,22 @@ func TestTileTextureWithOffsetsSeamless(t *testing.T) {
 	assertMatchesSubregion(t, bottom, ref, 0, 4)
 }
 
+func TestTileTextureRectMatchesSquare(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			src.SetNRGBA(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 7, A: 255})
+		}
+	}
+
+	ref := TileTexture(src, 8, 3, 5)
+	wide := TileTextureRect(src, 8, 3, 3, 5)
+	if wide.Bounds() != image.Rect(0, 0, 8, 3) {
+		t.Fatalf("expected 8x3 bounds, got %v", wide.Bounds())
+	}
+	assertMatchesSubregion(t, wide, ref, 0, 0)
+}
+
 func TestTileTextureUsesOffsets(t *testing.T) {
 	src := image.NewNRGBA(image.Rect(0, 0, 4, 4))
 	for y := 0; y < 4; y++ {
@@ -0,0 +1,99 @@
+package texture
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+)
+
+// SeamError describes a discontinuity CheckSeamless found where a texture wraps.
+type SeamError struct {
+	Edge          string      // "left/right" or "top/bottom"
+	At            image.Point // Worst pixel on the right or bottom edge, in image coordinates
+	Delta         uint8       // Largest channel difference across the wrap at At
+	NeighborDelta uint8       // Largest channel difference to the adjacent inner pixels at At
+	MeanExcess    float64     // How much larger the step across the wrap is than next to it, on average
+	Tolerance     uint8
+}
+
+func (e *SeamError) Error() string {
+	return fmt.Sprintf("texture is not seamless: %s seam steps %.1f more than its neighbors on average (tolerance %d); worst at (%d,%d) jumps by %d (neighbors differ by %d)",
+		e.Edge, e.MeanExcess, e.Tolerance, e.At.X, e.At.Y, e.Delta, e.NeighborDelta)
+}
+
+// CheckSeamless verifies that img tiles without visible seams. It compares the step from the
+// last to the first column (and from the last to the first row) with the steps to the
+// neighbouring pixels on either side of the wrap. Grainy textures vary from pixel to pixel, so
+// the steps are averaged along the edge: a seamless texture changes no more across the wrap
+// than it does next to it. It returns a *SeamError for the worse edge when the average excess
+// exceeds tolerance, or nil.
+func CheckSeamless(img image.Image, tolerance uint8) error {
+	if img == nil {
+		return fmt.Errorf("texture is nil")
+	}
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w < 3 || h < 3 {
+		return fmt.Errorf("texture too small to check for seams: %dx%d", w, h)
+	}
+
+	at := func(x, y int) color.NRGBA { return getNRGBA(img, b.Min.X+x, b.Min.Y+y) }
+
+	// checkEdge measures one wrap; pixel(i, k) returns the pixel k steps past the wrap
+	// (k = -2, -1 before it, 0, 1 after it) along line i.
+	checkEdge := func(edge string, lines int, pixel func(i, k int) (image.Point, color.NRGBA)) *SeamError {
+		var worst SeamError
+		worstExcess := math.MinInt
+		sum := 0
+		for i := 0; i < lines; i++ {
+			_, beforeLast := pixel(i, -2)
+			pos, last := pixel(i, -1)
+			_, first := pixel(i, 0)
+			_, afterFirst := pixel(i, 1)
+
+			across := channelDelta(last, first)
+			neighbors := (int(channelDelta(beforeLast, last)) + int(channelDelta(first, afterFirst))) / 2
+			excess := int(across) - neighbors
+			sum += excess
+			if excess > worstExcess {
+				worstExcess = excess
+				worst = SeamError{Edge: edge, At: b.Min.Add(pos), Delta: across, NeighborDelta: uint8(neighbors)}
+			}
+		}
+		worst.MeanExcess = float64(sum) / float64(lines)
+		worst.Tolerance = tolerance
+		if worst.MeanExcess <= float64(tolerance) {
+			return nil
+		}
+		return &worst
+	}
+
+	vertical := checkEdge("left/right", h, func(y, k int) (image.Point, color.NRGBA) {
+		x := (k + w) % w
+		return image.Pt(x, y), at(x, y)
+	})
+	horizontal := checkEdge("top/bottom", w, func(x, k int) (image.Point, color.NRGBA) {
+		y := (k + h) % h
+		return image.Pt(x, y), at(x, y)
+	})
+
+	switch {
+	case vertical != nil && (horizontal == nil || vertical.MeanExcess >= horizontal.MeanExcess):
+		return vertical
+	case horizontal != nil:
+		return horizontal
+	}
+	return nil
+}
+
+// channelDelta returns the largest absolute per-channel difference between a and b.
+func channelDelta(a, b color.NRGBA) uint8 {
+	d := func(x, y uint8) uint8 {
+		if x > y {
+			return x - y
+		}
+		return y - x
+	}
+	return max(d(a.R, b.R), d(a.G, b.G), d(a.B, b.B), d(a.A, b.A))
+}
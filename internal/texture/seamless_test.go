@@ -0,0 +1,78 @@
+package texture
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestCheckSeamlessGeneratedTextures(t *testing.T) {
+	params := TextureParams{Size: 128, BaseColor: color.RGBA{R: 120, G: 170, B: 210, A: 255}, Variation: 1, Brushness: 1, Seed: 1337}
+
+	seamless, err := GenerateSeamlessTexture(params)
+	if err != nil {
+		t.Fatalf("GenerateSeamlessTexture: %v", err)
+	}
+	paper, err := GeneratePaperTexture(params)
+	if err != nil {
+		t.Fatalf("GeneratePaperTexture: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		img  image.Image
+	}{
+		{"seamless", seamless},
+		{"paper", paper},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := CheckSeamless(tt.img, 4); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestCheckSeamlessEmbeddedTextures(t *testing.T) {
+	textures, err := LoadEmbeddedDefaultTextures()
+	if err != nil {
+		t.Fatalf("LoadEmbeddedDefaultTextures: %v", err)
+	}
+	for layer, img := range textures {
+		if err := CheckSeamless(img, 8); err != nil {
+			t.Errorf("%s: %v", layer, err)
+		}
+	}
+}
+
+func TestCheckSeamlessFindsSeam(t *testing.T) {
+	// A horizontal ramp is smooth inside but jumps from 15*8 back to 0 at the wrap
+	img := image.NewNRGBA(image.Rect(10, 20, 26, 36))
+	for y := 20; y < 36; y++ {
+		for x := 10; x < 26; x++ {
+			v := uint8((x - 10) * 8)
+			img.SetNRGBA(x, y, color.NRGBA{R: v, G: v, B: v, A: 255})
+		}
+	}
+
+	err := CheckSeamless(img, 4)
+	var seam *SeamError
+	if !errors.As(err, &seam) {
+		t.Fatalf("expected a *SeamError, got %v", err)
+	}
+	if seam.Edge != "left/right" || seam.At.X != 25 || seam.At.Y < 20 || seam.At.Y >= 36 {
+		t.Errorf("unexpected seam location: %+v", seam)
+	}
+	if seam.Delta != 120 || seam.NeighborDelta != 8 {
+		t.Errorf("delta = %d (neighbors %d), want 120 (8)", seam.Delta, seam.NeighborDelta)
+	}
+
+	if err := CheckSeamless(img, 200); err != nil {
+		t.Errorf("expected the seam to be within a large tolerance, got %v", err)
+	}
+	if err := CheckSeamless(image.NewNRGBA(image.Rect(0, 0, 2, 2)), 0); err == nil {
+		t.Error("expected an error for a texture too small to check")
+	}
+}
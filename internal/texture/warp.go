@@ -0,0 +1,90 @@
+package texture
+
+import (
+	"image"
+	"math"
+)
+
+const (
+	// warpPeriodCells is the wavelength of the texture warp in texture widths. Long enough that
+	// the texture is only gently stretched, short enough that neighbouring repeats differ.
+	warpPeriodCells = 4
+	// warpAmplitudeCells is the maximum displacement of texture lookups in texture widths.
+	warpAmplitudeCells = 0.5
+)
+
+// TileTextureWarpedRectInto is like TileTextureRectInto, but displaces every lookup by a
+// smooth, low-frequency domain warp so repeats of a small texture no longer line up on a
+// visible grid. The warp is a function of the global pixel position (offset + x/y) and seed
+// only, so adjacent tiles rendered with matching offsets stay seamless.
+func TileTextureWarpedRectInto(src image.Image, width, height int, offsetX, offsetY int, seed int64, dst *image.NRGBA) {
+	if src == nil || width <= 0 || height <= 0 || dst == nil {
+		return
+	}
+
+	bounds := src.Bounds()
+	srcW := bounds.Dx()
+	srcH := bounds.Dy()
+
+	if srcW == 0 || srcH == 0 {
+		return
+	}
+
+	mod := func(a, b int) int {
+		r := a % b
+		if r < 0 {
+			r += b
+		}
+		return r
+	}
+
+	cell := float64(max(srcW, srcH))
+	invPeriod := 1 / (warpPeriodCells * cell)
+	amplitude := warpAmplitudeCells * cell
+	seedX := uint64(seed)
+	seedY := seedX ^ 0x9e3779b97f4a7c15 // Independent field for the vertical displacement
+
+	for y := 0; y < height; y++ {
+		gy := offsetY + y
+		v := float64(gy) * invPeriod
+		for x := 0; x < width; x++ {
+			gx := offsetX + x
+			u := float64(gx) * invPeriod
+			dx := int(math.Round(amplitude * valueNoise(u, v, seedX)))
+			dy := int(math.Round(amplitude * valueNoise(u, v, seedY)))
+			sx := bounds.Min.X + mod(gx+dx, srcW)
+			sy := bounds.Min.Y + mod(gy+dy, srcH)
+			dst.SetNRGBA(x, y, getNRGBA(src, sx, sy))
+		}
+	}
+}
+
+// valueNoise returns smoothly interpolated lattice noise in [-1, 1] at (x, y).
+func valueNoise(x, y float64, seed uint64) float64 {
+	fx := math.Floor(x)
+	fy := math.Floor(y)
+	ix := int64(fx)
+	iy := int64(fy)
+	tx := smoothstep(x - fx)
+	ty := smoothstep(y - fy)
+
+	top := lerp(latticeValue(ix, iy, seed), latticeValue(ix+1, iy, seed), tx)
+	bottom := lerp(latticeValue(ix, iy+1, seed), latticeValue(ix+1, iy+1, seed), tx)
+	return lerp(top, bottom, ty)
+}
+
+// latticeValue hashes a lattice point to a value in [-1, 1].
+func latticeValue(ix, iy int64, seed uint64) float64 {
+	h := seed ^ uint64(ix)*0xbf58476d1ce4e5b9 ^ uint64(iy)*0x94d049bb133111eb
+	// splitmix64 finalizer
+	h ^= h >> 30
+	h *= 0xbf58476d1ce4e5b9
+	h ^= h >> 27
+	h *= 0x94d049bb133111eb
+	h ^= h >> 31
+	return float64(h>>11)/float64(1<<53)*2 - 1
+}
+
+func smoothstep(t float64) float64 {
+	return t * t * (3 - 2*t)
+}
@@ -0,0 +1,66 @@
+package texture
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func gradientTexture(size int) *image.NRGBA {
+	src := image.NewNRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			src.SetNRGBA(x, y, color.NRGBA{R: uint8(x * 30), G: uint8(y * 30), B: uint8(x + 2*y), A: 255})
+		}
+	}
+	return src
+}
+
+func TestTileTextureWarpedSeamless(t *testing.T) {
+	src := gradientTexture(8)
+
+	ref := image.NewNRGBA(image.Rect(0, 0, 64, 64))
+	TileTextureWarpedRectInto(src, 64, 64, 100, 200, 7, ref)
+
+	// Neighbouring tiles rendered with their own offsets must line up with the larger render
+	for _, off := range []image.Point{{0, 0}, {32, 0}, {0, 32}, {32, 32}} {
+		part := image.NewNRGBA(image.Rect(0, 0, 32, 32))
+		TileTextureWarpedRectInto(src, 32, 32, 100+off.X, 200+off.Y, 7, part)
+		assertMatchesSubregion(t, part, ref, off.X, off.Y)
+	}
+}
+
+func TestTileTextureWarpedBreaksRepetition(t *testing.T) {
+	const size = 8
+	src := gradientTexture(size)
+
+	plain := TileTextureRect(src, 128, 128, 0, 0)
+	warped := image.NewNRGBA(image.Rect(0, 0, 128, 128))
+	TileTextureWarpedRectInto(src, 128, 128, 0, 0, 1337, warped)
+
+	if got := repeatFraction(plain, size); got != 1 {
+		t.Fatalf("plain tiling should repeat exactly every %d px, got %.2f", size, got)
+	}
+	if got := repeatFraction(warped, size); got > 0.5 {
+		t.Errorf("warped tiling repeats every %d px for %.2f of pixels, want <= 0.5", size, got)
+	}
+
+	other := image.NewNRGBA(image.Rect(0, 0, 128, 128))
+	TileTextureWarpedRectInto(src, 128, 128, 0, 0, 1337, other)
+	assertMatchesSubregion(t, other, warped, 0, 0)
+}
+
+// repeatFraction returns the fraction of pixels equal to the pixel one period to the right.
+func repeatFraction(img *image.NRGBA, period int) float64 {
+	b := img.Bounds()
+	same, total := 0, 0
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x+period < b.Max.X; x++ {
+			total++
+			if img.NRGBAAt(x, y) == img.NRGBAAt(x+period, y) {
+				same++
+			}
+		}
+	}
+	return float64(same) / float64(total)
+}
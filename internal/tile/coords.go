@@ -1,8 +1,12 @@
 package tile
 
 import (
+	"crypto/sha1"
+	"encoding/hex"
 	"fmt"
+	"iter"
 	"math"
+	"path/filepath"
 
 	"github.com/paulmach/orb"
 	"github.com/paulmach/orb/maptile"
@@ -20,42 +24,53 @@ func (c Coords) String() string {
 	return fmt.Sprintf("z%d_x%d_y%d", c.Z, c.X, c.Y)
 }
 
+// HashedDir returns the two-level shard directory of the tile, "ab/cd" (with the OS path
+// separator), taken from the first two bytes of the SHA-1 of String. Tiles spread evenly
+// over 65536 directories, so no directory or object store prefix gets hot; the shard is
+// part of the on-disk format and must not change.
+func (c Coords) HashedDir() string {
+	sum := sha1.Sum([]byte(c.String()))
+	return filepath.Join(hex.EncodeToString(sum[:1]), hex.EncodeToString(sum[1:2]))
+}
+
 // Path returns the file path for this tile
 func (c Coords) Path(extension string) string {
 	return fmt.Sprintf("%s.%s", c.String(), extension)
 }
 
+// FlipYForTMS converts between XYZ addressing (y grows southward, as used internally) and
+// TMS addressing (y grows northward) of the same tile. The conversion is its own inverse;
+// Y must be valid for the zoom level (see InRange).
+func (c Coords) FlipYForTMS() Coords {
+	return Coords{Z: c.Z, X: c.X, Y: uint32(uint64(1)<<c.Z - 1 - uint64(c.Y))}
+}
+
+// InRange reports whether X and Y address an existing tile at zoom Z.
+func (c Coords) InRange() bool {
+	if c.Z > 31 {
+		return false
+	}
+	n := uint64(1) << c.Z
+	return uint64(c.X) < n && uint64(c.Y) < n
+}
+
 // Tile returns the maptile.Tile for this coordinate
 func (c Coords) Tile() maptile.Tile {
 	return maptile.New(c.X, c.Y, maptile.Zoom(c.Z))
 }
 
-// Bounds returns the geographic bounding box for this tile in WGS84 (EPSG:4326)
+// Bounds returns the geographic bounding box for this tile in WGS84 (EPSG:4326), in the
+// standard WebMercator grid (see Grid.Bounds for others)
 // Returns [minLon, minLat, maxLon, maxLat]
 func (c Coords) Bounds() [4]float64 {
-	tile := c.Tile()
-	bound := tile.Bound()
-
-	return [4]float64{
-		bound.Min.Lon(), // minLon
-		bound.Min.Lat(), // minLat
-		bound.Max.Lon(), // maxLon
-		bound.Max.Lat(), // maxLat
-	}
+	return WebMercator.Bounds(c)
 }
 
-// BoundsMercator returns the bounding box in Web Mercator projection (EPSG:3857)
+// BoundsMercator returns the bounding box in Web Mercator projection (EPSG:3857) in the
+// standard WebMercator grid
 // Returns [minX, minY, maxX, maxY] in meters
 func (c Coords) BoundsMercator() [4]float64 {
-	bounds := c.Bounds()
-	minLon, minLat := bounds[0], bounds[1]
-	maxLon, maxLat := bounds[2], bounds[3]
-
-	// Convert WGS84 to Web Mercator
-	minX, minY := lonLatToMercator(minLon, minLat)
-	maxX, maxY := lonLatToMercator(maxLon, maxLat)
-
-	return [4]float64{minX, minY, maxX, maxY}
+	return WebMercator.BoundsMercator(c)
 }
 
 // Center returns the center point of the tile in WGS84 (lon, lat)
@@ -69,11 +84,11 @@ func (c Coords) Center() (float64, float64) {
 // CenterMercator returns the center point in Web Mercator (x, y) in meters
 func (c Coords) CenterMercator() (float64, float64) {
 	lon, lat := c.Center()
-	return lonLatToMercator(lon, lat)
+	return LonLatToMercator(lon, lat)
 }
 
-// lonLatToMercator converts WGS84 coordinates to Web Mercator (EPSG:3857)
-func lonLatToMercator(lon, lat float64) (float64, float64) {
+// LonLatToMercator converts WGS84 coordinates to Web Mercator (EPSG:3857) meters
+func LonLatToMercator(lon, lat float64) (float64, float64) {
 	// Web Mercator constants
 	const earthRadius = 6378137.0 // meters
 
@@ -87,8 +102,8 @@ func lonLatToMercator(lon, lat float64) (float64, float64) {
 	return x, y
 }
 
-// mercatorToLonLat converts Web Mercator (EPSG:3857) to WGS84
-func mercatorToLonLat(x, y float64) (float64, float64) {
+// MercatorToLonLat converts Web Mercator (EPSG:3857) to WGS84
+func MercatorToLonLat(x, y float64) (float64, float64) {
 	const earthRadius = 6378137.0
 
 	lon := (x / earthRadius) * 180.0 / math.Pi
@@ -180,33 +195,14 @@ func TileRangeFromBounds(minZ, maxZ uint32, bounds [4]float64) TileRange {
 // TilesInBBox returns all tile coordinates within a bounding box across a zoom range.
 // bbox: [minLon, minLat, maxLon, maxLat] in WGS84
 // Calculates correct tile coordinates at each zoom level independently.
+// For large areas, TilesInBBoxIter yields the same tiles without holding them all in memory.
 func TilesInBBox(bbox [4]float64, zoomMin, zoomMax int) []Coords {
-	minLon, minLat, maxLon, maxLat := bbox[0], bbox[1], bbox[2], bbox[3]
-
 	// Pre-allocate with estimated capacity
 	estimatedCount := TileCount(bbox, zoomMin, zoomMax)
 	tiles := make([]Coords, 0, estimatedCount)
 
-	minPoint := orb.Point{minLon, minLat}
-	maxPoint := orb.Point{maxLon, maxLat}
-
 	for z := zoomMin; z <= zoomMax; z++ {
-		zoom := maptile.Zoom(z)
-
-		// Get tile coordinates at this zoom level
-		minTile := maptile.At(minPoint, zoom)
-		maxTile := maptile.At(maxPoint, zoom)
-
-		// Ensure min/max are correctly ordered (Y is inverted in TMS)
-		minX, maxX := minTile.X, maxTile.X
-		if minX > maxX {
-			minX, maxX = maxX, minX
-		}
-
-		minY, maxY := minTile.Y, maxTile.Y
-		if minY > maxY {
-			minY, maxY = maxY, minY
-		}
+		minX, maxX, minY, maxY := bboxTileRange(bbox, z)
 
 		// Generate all tiles at this zoom level
 		for x := minX; x <= maxX; x++ {
@@ -219,30 +215,30 @@ func TilesInBBox(bbox [4]float64, zoomMin, zoomMax int) []Coords {
 	return tiles
 }
 
+// TilesInBBoxIter yields the tiles of TilesInBBox lazily, in the same order (by zoom, then
+// column, then row), so a continent at a deep zoom can be streamed into a worker pool without
+// allocating millions of Coords up front. The sequence can be iterated more than once.
+func TilesInBBoxIter(bbox [4]float64, zoomMin, zoomMax int) iter.Seq[Coords] {
+	return func(yield func(Coords) bool) {
+		for z := zoomMin; z <= zoomMax; z++ {
+			minX, maxX, minY, maxY := bboxTileRange(bbox, z)
+			for x := minX; x <= maxX; x++ {
+				for y := minY; y <= maxY; y++ {
+					if !yield(NewCoords(uint32(z), x, y)) {
+						return
+					}
+				}
+			}
+		}
+	}
+}
+
 // TileCount returns the number of tiles in a bounding box across a zoom range.
 // This is useful for progress estimation without allocating the full tile list.
 func TileCount(bbox [4]float64, zoomMin, zoomMax int) int {
-	minLon, minLat, maxLon, maxLat := bbox[0], bbox[1], bbox[2], bbox[3]
-	minPoint := orb.Point{minLon, minLat}
-	maxPoint := orb.Point{maxLon, maxLat}
-
 	count := 0
 	for z := zoomMin; z <= zoomMax; z++ {
-		zoom := maptile.Zoom(z)
-
-		minTile := maptile.At(minPoint, zoom)
-		maxTile := maptile.At(maxPoint, zoom)
-
-		minX, maxX := minTile.X, maxTile.X
-		if minX > maxX {
-			minX, maxX = maxX, minX
-		}
-
-		minY, maxY := minTile.Y, maxTile.Y
-		if minY > maxY {
-			minY, maxY = maxY, minY
-		}
-
+		minX, maxX, minY, maxY := bboxTileRange(bbox, z)
 		xCount := int(maxX - minX + 1)
 		yCount := int(maxY - minY + 1)
 		count += xCount * yCount
@@ -250,3 +246,26 @@ func TileCount(bbox [4]float64, zoomMin, zoomMax int) int {
 
 	return count
 }
+
+// bboxTileRange returns the inclusive column and row range of the tiles covering bbox at
+// zoom z.
+func bboxTileRange(bbox [4]float64, z int) (minX, maxX, minY, maxY uint32) {
+	minLon, minLat, maxLon, maxLat := bbox[0], bbox[1], bbox[2], bbox[3]
+	zoom := maptile.Zoom(z)
+
+	// Get tile coordinates at this zoom level
+	minTile := maptile.At(orb.Point{minLon, minLat}, zoom)
+	maxTile := maptile.At(orb.Point{maxLon, maxLat}, zoom)
+
+	// Ensure min/max are correctly ordered (Y is inverted in TMS)
+	minX, maxX = minTile.X, maxTile.X
+	if minX > maxX {
+		minX, maxX = maxX, minX
+	}
+
+	minY, maxY = minTile.Y, maxTile.Y
+	if minY > maxY {
+		minY, maxY = maxY, minY
+	}
+	return minX, maxX, minY, maxY
+}
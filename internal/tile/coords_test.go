@@ -2,6 +2,8 @@ package tile
 
 import (
 	"math"
+	"path/filepath"
+	"slices"
 	"testing"
 )
 
@@ -47,6 +49,26 @@ func TestCoordsPath(t *testing.T) {
 	}
 }
 
+func TestCoordsHashedDir(t *testing.T) {
+	tests := []struct {
+		coords   Coords
+		expected string
+	}{
+		// First two bytes of sha1("z13_x4317_y2692") = 9a8aef93...
+		{Coords{Z: 13, X: 4317, Y: 2692}, filepath.Join("9a", "8a")},
+		{Coords{Z: 13, X: 4317, Y: 5499}, filepath.Join("a5", "a5")},
+		{Coords{Z: 0, X: 0, Y: 0}, filepath.Join("2f", "22")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.coords.String(), func(t *testing.T) {
+			if got := tt.coords.HashedDir(); got != tt.expected {
+				t.Errorf("HashedDir() = %s, want %s", got, tt.expected)
+			}
+		})
+	}
+}
+
 func TestCoordsBounds(t *testing.T) {
 	// Test tile covering Hanover (z13_x4297_y2754)
 	coords := Coords{Z: 13, X: 4297, Y: 2754}
@@ -160,8 +182,8 @@ func TestMercatorConversion(t *testing.T) {
 		lon, lat := point[0], point[1]
 
 		// Convert to Mercator and back
-		x, y := lonLatToMercator(lon, lat)
-		lon2, lat2 := mercatorToLonLat(x, y)
+		x, y := LonLatToMercator(lon, lat)
+		lon2, lat2 := MercatorToLonLat(x, y)
 
 		// Check round-trip accuracy (should be very close)
 		lonDiff := math.Abs(lon - lon2)
@@ -297,6 +319,20 @@ func TestTilesInBBox(t *testing.T) {
 	})
 }
 
+func TestTilesInBBoxIter(t *testing.T) {
+	bbox := [4]float64{9.7, 52.3, 9.8, 52.4}
+	want := TilesInBBox(bbox, 10, 12)
+
+	var got []Coords
+	for c := range TilesInBBoxIter(bbox, 10, 12) {
+		got = append(got, c)
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("TilesInBBoxIter yielded %d tiles %v, want TilesInBBox's %d tiles %v", len(got), got, len(want), want)
+	}
+
+}
+
 func TestTileCount(t *testing.T) {
 	bbox := [4]float64{9.7, 52.3, 9.8, 52.4}
 
@@ -308,3 +344,56 @@ func TestTileCount(t *testing.T) {
 		t.Errorf("TileCount() = %d, but TilesInBBox returned %d tiles", count, len(tiles))
 	}
 }
+
+func TestFlipYForTMS(t *testing.T) {
+	tests := []struct {
+		xyz Coords
+		tms Coords
+	}{
+		{xyz: Coords{Z: 0, X: 0, Y: 0}, tms: Coords{Z: 0, X: 0, Y: 0}},
+		{xyz: Coords{Z: 1, X: 1, Y: 0}, tms: Coords{Z: 1, X: 1, Y: 1}},
+		{xyz: Coords{Z: 13, X: 4297, Y: 2754}, tms: Coords{Z: 13, X: 4297, Y: 5437}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.xyz.String(), func(t *testing.T) {
+			if got := tt.xyz.FlipYForTMS(); got != tt.tms {
+				t.Errorf("FlipYForTMS() = %v, want %v", got, tt.tms)
+			}
+			if got := tt.tms.FlipYForTMS(); got != tt.xyz {
+				t.Errorf("flipping back = %v, want %v", got, tt.xyz)
+			}
+
+			// A TMS request must cover the same ground as the XYZ tile: the TMS y counts
+			// rows from the south, i.e. the tile's southern edge is tms.Y tiles north of -85°
+			xyzBounds := tt.xyz.Bounds()
+			n := math.Exp2(float64(tt.tms.Z))
+			southMercY := -math.Pi + 2*math.Pi*float64(tt.tms.Y)/n
+			southLat := math.Atan(math.Sinh(southMercY)) * 180 / math.Pi
+			if math.Abs(xyzBounds[1]-southLat) > 1e-9 {
+				t.Errorf("TMS tile south edge = %f, XYZ tile south edge = %f", southLat, xyzBounds[1])
+			}
+		})
+	}
+}
+
+func TestCoordsInRange(t *testing.T) {
+	tests := []struct {
+		coords Coords
+		want   bool
+	}{
+		{Coords{Z: 0, X: 0, Y: 0}, true},
+		{Coords{Z: 0, X: 0, Y: 1}, false},
+		{Coords{Z: 13, X: 8191, Y: 8191}, true},
+		{Coords{Z: 13, X: 8192, Y: 0}, false},
+		{Coords{Z: 40, X: 0, Y: 0}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.coords.String(), func(t *testing.T) {
+			if got := tt.coords.InRange(); got != tt.want {
+				t.Errorf("InRange() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package tile
+
+import (
+	"fmt"
+	"image"
+	"math"
+)
+
+// srgbToLinear maps 8-bit sRGB channel values to linear light in [0, 1].
+var srgbToLinear = func() (lut [256]float64) {
+	for i := range lut {
+		v := float64(i) / 255
+		if v <= 0.04045 {
+			lut[i] = v / 12.92
+		} else {
+			lut[i] = math.Pow((v+0.055)/1.055, 2.4)
+		}
+	}
+	return lut
+}()
+
+// linearToSRGB8 converts linear light in [0, 1] to an 8-bit sRGB channel value.
+func linearToSRGB8(l float64) uint8 {
+	var v float64
+	if l <= 0.0031308 {
+		v = l * 12.92
+	} else {
+		v = 1.055*math.Pow(l, 1/2.4) - 0.055
+	}
+	return uint8(math.Max(0, math.Min(255, math.Round(v*255))))
+}
+
+// Downsample builds a parent tile from its four children, given in the order top-left,
+// top-right, bottom-left, bottom-right (the children at 2x, 2x+1 and 2y, 2y+1 one zoom
+// deeper). Each parent pixel averages a 2×2 block of child pixels in linear light, weighted by
+// alpha, so transparent pixels don't darken their neighbors and the blend of light and dark
+// pixels keeps its perceived brightness. A nil child counts as fully transparent, e.g. a child
+// outside the rendered area. The parent has the size of the children, which must all have the
+// same size; it is nil if all children are nil.
+func Downsample(children [4]*image.NRGBA) *image.NRGBA {
+	var size image.Point
+	for i, c := range children {
+		if c == nil {
+			continue
+		}
+		if size == (image.Point{}) {
+			size = c.Bounds().Size()
+		} else if c.Bounds().Size() != size {
+			panic(fmt.Sprintf("tile: Downsample child %d is %v, want %v", i, c.Bounds().Size(), size))
+		}
+	}
+	if size == (image.Point{}) {
+		return nil
+	}
+
+	w, h := size.X, size.Y
+	out := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for oy := 0; oy < h; oy++ {
+		for ox := 0; ox < w; ox++ {
+			// The 2×2 block of the 2w×2h mosaic of the children
+			var sumA, sumR, sumG, sumB float64
+			for _, d := range [4][2]int{{0, 0}, {1, 0}, {0, 1}, {1, 1}} {
+				mx, my := 2*ox+d[0], 2*oy+d[1]
+				child := children[(my/h)*2+mx/w]
+				if child == nil {
+					continue
+				}
+				b := child.Bounds()
+				i := child.PixOffset(b.Min.X+mx%w, b.Min.Y+my%h)
+				px := child.Pix[i : i+4 : i+4]
+				if px[3] == 0 {
+					continue
+				}
+				a := float64(px[3]) / 255
+				sumA += a
+				sumR += srgbToLinear[px[0]] * a
+				sumG += srgbToLinear[px[1]] * a
+				sumB += srgbToLinear[px[2]] * a
+			}
+			if sumA == 0 {
+				continue
+			}
+			i := out.PixOffset(ox, oy)
+			out.Pix[i] = linearToSRGB8(sumR / sumA)
+			out.Pix[i+1] = linearToSRGB8(sumG / sumA)
+			out.Pix[i+2] = linearToSRGB8(sumB / sumA)
+			out.Pix[i+3] = uint8(math.Round(sumA / 4 * 255))
+		}
+	}
+	return out
+}
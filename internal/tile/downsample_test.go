@@ -0,0 +1,91 @@
+package tile
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func solidTile(size int, c color.NRGBA) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, size, size))
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = c.R, c.G, c.B, c.A
+	}
+	return img
+}
+
+func TestDownsampleQuadrants(t *testing.T) {
+	colors := [4]color.NRGBA{
+		{R: 200, G: 40, B: 40, A: 255},
+		{R: 40, G: 200, B: 40, A: 255},
+		{R: 40, G: 40, B: 200, A: 255},
+		{R: 230, G: 220, B: 190, A: 128},
+	}
+	var children [4]*image.NRGBA
+	for i, c := range colors {
+		children[i] = solidTile(8, c)
+	}
+
+	out := Downsample(children)
+	if out.Bounds() != image.Rect(0, 0, 8, 8) {
+		t.Fatalf("bounds = %v, want the child size", out.Bounds())
+	}
+	// Each 2×2 block lies within one child, so each quadrant keeps its child's color
+	for i, c := range colors {
+		x, y := (i%2)*4+1, (i/2)*4+2
+		if got := out.NRGBAAt(x, y); got != c {
+			t.Errorf("quadrant %d pixel (%d,%d) = %+v, want %+v", i, x, y, got, c)
+		}
+	}
+}
+
+func TestDownsampleBlend(t *testing.T) {
+	// One child is a checkerboard of black and white: its quarter of the parent blends them
+	checker := solidTile(4, color.NRGBA{A: 255})
+	for y := 0; y < 4; y++ {
+		for x := (y % 2); x < 4; x += 2 {
+			checker.SetNRGBA(x, y, color.NRGBA{R: 255, G: 255, B: 255, A: 255})
+		}
+	}
+	red := solidTile(4, color.NRGBA{R: 255, A: 255})
+	transparent := solidTile(4, color.NRGBA{G: 255}) // Transparent, with a color that must not leak
+
+	tests := []struct {
+		name     string
+		children [4]*image.NRGBA
+		x, y     int
+		want     color.NRGBA
+	}{
+		// Linear-light average of black and white is 0.5, sRGB 188 (not 128)
+		{name: "gamma-correct", children: [4]*image.NRGBA{checker, red, red, red}, x: 0, y: 0, want: color.NRGBA{R: 188, G: 188, B: 188, A: 255}},
+		// The mosaic column of child 1 begins at x=2 of the parent
+		{name: "solid child", children: [4]*image.NRGBA{checker, red, red, red}, x: 3, y: 0, want: color.NRGBA{R: 255, A: 255}},
+		{name: "transparent child", children: [4]*image.NRGBA{transparent, red, red, red}, x: 0, y: 0, want: color.NRGBA{}},
+		{name: "nil child", children: [4]*image.NRGBA{nil, red, red, red}, x: 1, y: 1, want: color.NRGBA{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := Downsample(tt.children)
+			if got := out.NRGBAAt(tt.x, tt.y); got != tt.want {
+				t.Errorf("pixel (%d,%d) = %+v, want %+v", tt.x, tt.y, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDownsampleAlphaWeighting(t *testing.T) {
+	// Half-covered red: the transparent pixels halve the alpha but don't tint the color
+	half := solidTile(2, color.NRGBA{R: 255, A: 255})
+	half.SetNRGBA(1, 0, color.NRGBA{B: 255})
+	half.SetNRGBA(1, 1, color.NRGBA{B: 255})
+
+	out := Downsample([4]*image.NRGBA{half, half, half, half})
+	if got, want := out.NRGBAAt(0, 0), (color.NRGBA{R: 255, A: 128}); got != want {
+		t.Errorf("pixel = %+v, want %+v", got, want)
+	}
+
+	if out := Downsample([4]*image.NRGBA{}); out != nil {
+		t.Error("expected nil for four nil children")
+	}
+}
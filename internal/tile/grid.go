@@ -0,0 +1,92 @@
+package tile
+
+import (
+	"math"
+
+	"github.com/MeKo-Tech/watercolormap/internal/types"
+)
+
+// webMercatorHalfExtent is half the width of the Web Mercator square in meters (π·R).
+const webMercatorHalfExtent = math.Pi * 6378137.0
+
+// Grid describes a tile grid over Web Mercator (EPSG:3857): where tile (0, 0) starts and how
+// many meters a pixel covers at each zoom. The package functions and Coords methods use the
+// standard WebMercator grid; a custom grid (e.g. a TMS offset from the standard origin, or
+// with its own resolutions) maps the same Coords to other areas.
+type Grid struct {
+	TileSize int // Pixels per tile side
+	// OriginX and OriginY are the top-left corner of tile (0, 0) in meters; rows grow
+	// southward from it
+	OriginX, OriginY float64
+	// Resolutions are the meters per pixel of each zoom. Zooms past the end halve the
+	// resolution of the previous one (nil = BaseResolution halved per zoom).
+	Resolutions []float64
+	// BaseResolution is the meters per pixel at zoom 0 when Resolutions is empty
+	BaseResolution float64
+}
+
+// WebMercator is the standard XYZ grid: 256-pixel tiles, one tile covering the world at
+// zoom 0, origin at the top-left corner of the Mercator square.
+var WebMercator = Grid{
+	TileSize:       256,
+	OriginX:        -webMercatorHalfExtent,
+	OriginY:        webMercatorHalfExtent,
+	BaseResolution: 2 * webMercatorHalfExtent / 256,
+}
+
+// Resolution returns the meters per pixel at zoom z.
+func (g Grid) Resolution(z uint32) float64 {
+	if len(g.Resolutions) == 0 {
+		return g.BaseResolution / math.Exp2(float64(z))
+	}
+	last := len(g.Resolutions) - 1
+	if int(z) <= last {
+		return g.Resolutions[z]
+	}
+	return g.Resolutions[last] / math.Exp2(float64(int(z)-last))
+}
+
+// tileSpan returns the side of a tile at zoom z in meters.
+func (g Grid) tileSpan(z uint32) float64 {
+	return g.Resolution(z) * float64(g.TileSize)
+}
+
+// BoundsMercator returns the bounding box of c in Web Mercator meters:
+// [minX, minY, maxX, maxY]. For WebMercator it matches Coords.BoundsMercator.
+func (g Grid) BoundsMercator(c Coords) [4]float64 {
+	span := g.tileSpan(c.Z)
+	minX := g.OriginX + float64(c.X)*span
+	maxY := g.OriginY - float64(c.Y)*span
+	return [4]float64{minX, maxY - span, minX + span, maxY}
+}
+
+// Bounds returns the bounding box of c in WGS84: [minLon, minLat, maxLon, maxLat]. For
+// WebMercator it matches Coords.Bounds.
+func (g Grid) Bounds(c Coords) [4]float64 {
+	m := g.BoundsMercator(c)
+	minLon, minLat := MercatorToLonLat(m[0], m[1])
+	maxLon, maxLat := MercatorToLonLat(m[2], m[3])
+	return [4]float64{minLon, minLat, maxLon, maxLat}
+}
+
+// Center returns the center of c in WGS84 (lon, lat), the midpoint of its Bounds like
+// Coords.Center.
+func (g Grid) Center(c Coords) (float64, float64) {
+	b := g.Bounds(c)
+	return (b[0] + b[2]) / 2, (b[1] + b[3]) / 2
+}
+
+// TileToBounds returns the WGS84 bounds of a tile like types.TileToBounds, in this grid.
+func (g Grid) TileToBounds(coord types.TileCoordinate) types.BoundingBox {
+	b := g.Bounds(NewCoords(uint32(coord.Zoom), uint32(coord.X), uint32(coord.Y)))
+	return types.BoundingBox{MinLon: b[0], MinLat: b[1], MaxLon: b[2], MaxLat: b[3]}
+}
+
+// TileAt returns the tile at zoom z containing the Web Mercator point (x, y). Points
+// outside the grid's first tile row or column are clamped to it.
+func (g Grid) TileAt(z uint32, x, y float64) Coords {
+	span := g.tileSpan(z)
+	col := math.Floor((x - g.OriginX) / span)
+	row := math.Floor((g.OriginY - y) / span)
+	return NewCoords(z, uint32(max(col, 0)), uint32(max(row, 0)))
+}
@@ -0,0 +1,75 @@
+package tile
+
+import (
+	"math"
+	"testing"
+
+	"github.com/MeKo-Tech/watercolormap/internal/types"
+)
+
+var gridTestTiles = []Coords{
+	{Z: 0, X: 0, Y: 0},
+	{Z: 13, X: 4297, Y: 2754},
+	{Z: 16, X: 34540, Y: 21540},
+	{Z: 18, X: 262143, Y: 0},
+}
+
+func TestWebMercatorGridMatchesCoords(t *testing.T) {
+	for _, c := range gridTestTiles {
+		t.Run(c.String(), func(t *testing.T) {
+			// Coords goes through WebMercator, so check it against maptile's independent math
+			b := c.Tile().Bound()
+			bounds := [4]float64{b.Min.Lon(), b.Min.Lat(), b.Max.Lon(), b.Max.Lat()}
+			assertClose(t, "Bounds", WebMercator.Bounds(c), bounds, 1e-9)
+			minX, minY := LonLatToMercator(bounds[0], bounds[1])
+			maxX, maxY := LonLatToMercator(bounds[2], bounds[3])
+			assertClose(t, "BoundsMercator", WebMercator.BoundsMercator(c), [4]float64{minX, minY, maxX, maxY}, 1e-6)
+
+			lon, lat := WebMercator.Center(c)
+			assertClose(t, "Center", [4]float64{lon, lat}, [4]float64{(bounds[0] + bounds[2]) / 2, (bounds[1] + bounds[3]) / 2}, 1e-9)
+
+			tc := types.TileCoordinate{Zoom: int(c.Z), X: int(c.X), Y: int(c.Y)}
+			got, want := WebMercator.TileToBounds(tc), types.TileToBounds(tc)
+			assertClose(t, "TileToBounds",
+				[4]float64{got.MinLon, got.MinLat, got.MaxLon, got.MaxLat},
+				[4]float64{want.MinLon, want.MinLat, want.MaxLon, want.MaxLat}, 1e-9)
+
+			// The tile's center maps back to the tile
+			m := c.BoundsMercator()
+			if got := WebMercator.TileAt(c.Z, (m[0]+m[2])/2, (m[1]+m[3])/2); got != c {
+				t.Errorf("TileAt(center) = %s, want %s", got.String(), c.String())
+			}
+		})
+	}
+}
+
+func TestCustomGrid(t *testing.T) {
+	// A local grid: 512-pixel tiles, origin at (1000, 9000) meters, 2 m/px at zoom 0 and
+	// 0.5 m/px at zoom 1; zoom 2 halves the last resolution
+	g := Grid{TileSize: 512, OriginX: 1000, OriginY: 9000, Resolutions: []float64{2, 0.5}}
+
+	tests := []struct {
+		c    Coords
+		want [4]float64
+	}{
+		{Coords{Z: 0, X: 0, Y: 0}, [4]float64{1000, 7976, 2024, 9000}},
+		{Coords{Z: 1, X: 2, Y: 1}, [4]float64{1512, 8488, 1768, 8744}},
+		{Coords{Z: 2, X: 1, Y: 0}, [4]float64{1128, 8872, 1256, 9000}},
+	}
+	for _, tt := range tests {
+		got := g.BoundsMercator(tt.c)
+		assertClose(t, "BoundsMercator "+tt.c.String(), got, tt.want, 1e-9)
+		if back := g.TileAt(tt.c.Z, got[0]+1, got[3]-1); back != tt.c {
+			t.Errorf("TileAt(%s corner) = %s", tt.c.String(), back.String())
+		}
+	}
+}
+
+func assertClose(t *testing.T, name string, got, want [4]float64, tol float64) {
+	t.Helper()
+	for i := range want {
+		if math.Abs(got[i]-want[i]) > tol {
+			t.Errorf("%s[%d] = %.12f, want %.12f", name, i, got[i], want[i])
+		}
+	}
+}
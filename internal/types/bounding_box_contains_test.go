@@ -0,0 +1,53 @@
+package types
+
+import "testing"
+
+func TestBoundingBoxContains(t *testing.T) {
+	b := BoundingBox{MinLon: 10, MinLat: 20, MaxLon: 30, MaxLat: 40}
+
+	tests := []struct {
+		name     string
+		lon, lat float64
+		want     bool
+	}{
+		{"center", 20, 30, true},
+		{"on edge", 10, 25, true},
+		{"on corner", 30, 40, true},
+		{"west of box", 9.99, 30, false},
+		{"north of box", 20, 40.01, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := b.Contains(tt.lon, tt.lat); got != tt.want {
+				t.Errorf("Contains(%v, %v) = %v, want %v", tt.lon, tt.lat, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBoundingBoxContainsBoxAndIntersects(t *testing.T) {
+	b := BoundingBox{MinLon: 10, MinLat: 20, MaxLon: 30, MaxLat: 40}
+
+	tests := []struct {
+		name           string
+		other          BoundingBox
+		wantContains   bool
+		wantIntersects bool
+	}{
+		{"itself", b, true, true},
+		{"inner", BoundingBox{MinLon: 12, MinLat: 22, MaxLon: 28, MaxLat: 38}, true, true},
+		{"overlapping", BoundingBox{MinLon: 25, MinLat: 35, MaxLon: 35, MaxLat: 45}, false, true},
+		{"touching", BoundingBox{MinLon: 30, MinLat: 20, MaxLon: 40, MaxLat: 40}, false, true},
+		{"disjoint", BoundingBox{MinLon: 31, MinLat: 20, MaxLon: 40, MaxLat: 40}, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := b.ContainsBox(tt.other); got != tt.wantContains {
+				t.Errorf("ContainsBox(%+v) = %v, want %v", tt.other, got, tt.wantContains)
+			}
+			if got := b.Intersects(tt.other); got != tt.wantIntersects {
+				t.Errorf("Intersects(%+v) = %v, want %v", tt.other, got, tt.wantIntersects)
+			}
+		})
+	}
+}
@@ -1,6 +1,10 @@
 package types
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
 	"time"
 
 	"github.com/MeKo-Christian/go-overpass"
@@ -67,3 +71,29 @@ func (fc FeatureCollection) FeatureCounts() map[string]int {
 		"total":     fc.Count(),
 	}
 }
+
+// Hash returns a short fingerprint of the features: their IDs, types, names, geometries and
+// properties, independent of their order within a layer. Data sources extract features in
+// map order, so equal data fetched twice hashes alike, and any edit to a feature changes it.
+func (fc FeatureCollection) Hash() string {
+	h := sha256.New()
+	for _, layer := range [][]Feature{fc.Water, fc.Rivers, fc.Parks, fc.Roads, fc.Buildings, fc.Urban, fc.Land} {
+		sums := make([]string, 0, len(layer))
+		for _, f := range layer {
+			fh := sha256.New()
+			kind := ""
+			if f.Geometry != nil {
+				kind = f.Geometry.GeoJSONType()
+			}
+			// %v prints map keys sorted and floats in their shortest exact form
+			fmt.Fprintf(fh, "%s\x00%s\x00%s\x00%s\x00%v\x00%v", f.ID, f.Type, f.Name, kind, f.Geometry, f.Properties)
+			sums = append(sums, string(fh.Sum(nil)))
+		}
+		slices.Sort(sums)
+		fmt.Fprintf(h, "%d\n", len(sums))
+		for _, sum := range sums {
+			h.Write([]byte(sum))
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)[:8])
+}
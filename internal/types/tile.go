@@ -57,6 +57,21 @@ func (b BoundingBox) ExpandByFraction(f float64) BoundingBox {
 	return b.Expand(b.Width()*f, b.Height()*f)
 }
 
+// Contains reports whether the point lon/lat lies within the bounding box (edges inclusive).
+func (b BoundingBox) Contains(lon, lat float64) bool {
+	return lon >= b.MinLon && lon <= b.MaxLon && lat >= b.MinLat && lat <= b.MaxLat
+}
+
+// ContainsBox reports whether other lies entirely within the bounding box (edges inclusive).
+func (b BoundingBox) ContainsBox(other BoundingBox) bool {
+	return other.MinLon >= b.MinLon && other.MaxLon <= b.MaxLon && other.MinLat >= b.MinLat && other.MaxLat <= b.MaxLat
+}
+
+// Intersects reports whether the two bounding boxes share any area (touching edges count).
+func (b BoundingBox) Intersects(other BoundingBox) bool {
+	return b.MinLon <= other.MaxLon && b.MaxLon >= other.MinLon && b.MinLat <= other.MaxLat && b.MaxLat >= other.MinLat
+}
+
 // TileToBounds converts tile coordinates to geographic bounding box
 func TileToBounds(coord TileCoordinate) BoundingBox {
 	n := math.Pow(2, float64(coord.Zoom))
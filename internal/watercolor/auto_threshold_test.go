@@ -0,0 +1,54 @@
+package watercolor
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/MeKo-Tech/watercolormap/internal/geojson"
+	"github.com/MeKo-Tech/watercolormap/internal/mask"
+)
+
+// TestAutoThresholdFaintMask checks that AutoThreshold adapts to a faint rendering whose
+// coverage never reaches the fixed threshold.
+func TestAutoThresholdFaintMask(t *testing.T) {
+	const tileSize = 64
+
+	// A faint square (alpha 100) on an empty background
+	baseMask := image.NewGray(image.Rect(0, 0, tileSize, tileSize))
+	for y := 16; y < 48; y++ {
+		for x := 16; x < 48; x++ {
+			baseMask.SetGray(x, y, color.Gray{Y: 100})
+		}
+	}
+
+	textures := map[geojson.LayerType]image.Image{
+		geojson.LayerWater: solidTexture(4, 4, color.NRGBA{R: 150, G: 190, B: 225, A: 255}),
+	}
+	params := DefaultParams(tileSize, 1337, textures)
+	params.PerlinNoise = mask.GeneratePerlinNoiseWithOffset(tileSize, tileSize, params.NoiseScale, params.Seed, 0, 0)
+	style := params.Styles[geojson.LayerWater]
+	style.MaskThreshold = ptr(144)
+	params.Styles[geojson.LayerWater] = style
+
+	_, fixed, err := PaintLayerFromMaskWithMask(baseMask, geojson.LayerWater, params)
+	if err != nil {
+		t.Fatalf("PaintLayerFromMaskWithMask (fixed) failed: %v", err)
+	}
+	if v := fixed.GrayAt(32, 32).Y; v != 0 {
+		t.Fatalf("expected the fixed threshold to drop the faint square, got coverage %d", v)
+	}
+
+	style.AutoThreshold = true
+	params.Styles[geojson.LayerWater] = style
+	_, auto, err := PaintLayerFromMaskWithMask(baseMask, geojson.LayerWater, params)
+	if err != nil {
+		t.Fatalf("PaintLayerFromMaskWithMask (auto) failed: %v", err)
+	}
+	if v := auto.GrayAt(32, 32).Y; v != 255 {
+		t.Errorf("expected the auto threshold to keep the square's center, got coverage %d", v)
+	}
+	if v := auto.GrayAt(2, 2).Y; v != 0 {
+		t.Errorf("expected the background to stay empty, got coverage %d", v)
+	}
+}
@@ -1,6 +1,7 @@
 package watercolor
 
 import (
+	"fmt"
 	"image"
 	"image/color"
 	"testing"
@@ -205,10 +206,47 @@ func BenchmarkPaintFromMask(b *testing.B) {
 	b.ReportAllocs()
 
 	for i := 0; i < b.N; i++ {
-		_, _ = paintFromFinalMask(finalMask, geojson.LayerWater, params)
+		_, _ = paintFromFinalMask(finalMask, nil, geojson.LayerWater, params, nil)
 	}
 }
 
+// BenchmarkPaintTilesProcessorContext paints the layers of a run of tiles the way a batch
+// worker does, allocating watercolor buffers per layer versus reusing one ProcessorContext
+// for all of them; compare allocs/op and B/op.
+func BenchmarkPaintTilesProcessorContext(b *testing.B) {
+	const tileSize, tiles = 256, 16
+	layers := []geojson.LayerType{geojson.LayerLand, geojson.LayerWater, geojson.LayerParks, geojson.LayerRoads, geojson.LayerHighways}
+
+	textures := make(map[geojson.LayerType]image.Image, len(layers))
+	for _, layer := range layers {
+		textures[layer] = benchSolidTexture(8, 8, color.NRGBA{R: 180, G: 170, B: 150, A: 255})
+	}
+	params := DefaultParams(tileSize, 42, textures)
+	params.PerlinNoise = mask.GeneratePerlinNoiseWithOffset(tileSize, tileSize, params.NoiseScale, params.Seed, 0, 0)
+	baseMask := createAlphaMask(tileSize)
+
+	paintTiles := func(b *testing.B, ctx func() *ProcessorContext) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			pc := ctx()
+			for range tiles {
+				for _, layer := range layers {
+					if _, err := PaintLayerFromMaskWithContext(baseMask, layer, params, pc); err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+		}
+	}
+
+	b.Run("per-call", func(b *testing.B) {
+		paintTiles(b, func() *ProcessorContext { return nil })
+	})
+	b.Run("per-worker", func(b *testing.B) {
+		paintTiles(b, func() *ProcessorContext { return NewProcessorContext(tileSize) })
+	})
+}
+
 // BenchmarkEdgeDarkening benchmarks edge darkening operation using ApplySoftEdgeMask
 func BenchmarkEdgeDarkening(b *testing.B) {
 	tileSize := 256
@@ -312,3 +350,17 @@ func formatSigma(sigma float32) string {
 	}
 	return s
 }
+
+// BenchmarkPerlinNoiseDownscale compares full-resolution noise with downscaled generation
+// for a padded @2x metatile.
+func BenchmarkPerlinNoiseDownscale(b *testing.B) {
+	const size = 512 + 2*64
+	for _, downscale := range []int{1, 2, 4} {
+		b.Run(fmt.Sprintf("downscale%d", downscale), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_ = mask.GeneratePerlinNoiseDownscaled(size, size, 30.0, 42, mask.PerlinOctaves{}, -64, -64, downscale)
+			}
+		})
+	}
+}
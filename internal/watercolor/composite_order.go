@@ -0,0 +1,62 @@
+package watercolor
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/MeKo-Tech/watercolormap/internal/geojson"
+)
+
+// DefaultCompositeOrder is the back-to-front order layers are composited in, matching OSM
+// conventions: land (back) → parks → forest → rivers → water → roads → highways → buildings →
+// urban (front).
+var DefaultCompositeOrder = []geojson.LayerType{
+	geojson.LayerLand,
+	geojson.LayerParks,
+	geojson.LayerForest,
+	geojson.LayerRivers,
+	geojson.LayerWater,
+	geojson.LayerRoads,
+	geojson.LayerHighways,
+	geojson.LayerBuildings,
+	geojson.LayerUrban,
+}
+
+// Order returns the back-to-front composite order of the painted layers: CompositeOrder, or
+// DefaultCompositeOrder when it is unset.
+func (p Params) Order() []geojson.LayerType {
+	if len(p.CompositeOrder) == 0 {
+		return DefaultCompositeOrder
+	}
+	return p.CompositeOrder
+}
+
+// CheckCompositeOrder checks that order lists every styled layer exactly once; a layer left
+// out would be painted but never composited. It returns the layers of order that have no
+// style, which composite nothing (typically misspelled layer names) and are worth a warning.
+func CheckCompositeOrder(order []geojson.LayerType, styles map[geojson.LayerType]LayerStyle) ([]geojson.LayerType, error) {
+	var unknown []geojson.LayerType
+	seen := make(map[geojson.LayerType]bool, len(order))
+	for _, layer := range order {
+		if seen[layer] {
+			return nil, fmt.Errorf("composite order lists layer %q twice", layer)
+		}
+		seen[layer] = true
+		if _, ok := styles[layer]; !ok {
+			unknown = append(unknown, layer)
+		}
+	}
+
+	var missing []string
+	for layer := range styles {
+		if !seen[layer] {
+			missing = append(missing, string(layer))
+		}
+	}
+	if len(missing) > 0 {
+		slices.Sort(missing)
+		return nil, fmt.Errorf("composite order is missing layers %s", strings.Join(missing, ", "))
+	}
+	return unknown, nil
+}
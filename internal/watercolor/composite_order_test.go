@@ -0,0 +1,43 @@
+package watercolor
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/MeKo-Tech/watercolormap/internal/geojson"
+)
+
+func TestCheckCompositeOrder(t *testing.T) {
+	styles := DefaultParams(0, 0, nil).Styles
+
+	unknown, err := CheckCompositeOrder(DefaultCompositeOrder, styles)
+	if err != nil || len(unknown) != 0 {
+		t.Fatalf("default order: unknown %v, error %v; want every styled layer", unknown, err)
+	}
+
+	withTypo := append(slices.Clone(DefaultCompositeOrder), "raods")
+	unknown, err = CheckCompositeOrder(withTypo, styles)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(unknown, []geojson.LayerType{"raods"}) {
+		t.Errorf("unknown = %v, want [raods]", unknown)
+	}
+
+	withoutUrban := slices.DeleteFunc(slices.Clone(DefaultCompositeOrder), func(l geojson.LayerType) bool {
+		return l == geojson.LayerUrban
+	})
+	if _, err := CheckCompositeOrder(withoutUrban, styles); err == nil {
+		t.Error("expected an error for an order without urban")
+	}
+}
+
+func TestParamsOrder(t *testing.T) {
+	if got := (Params{}).Order(); !slices.Equal(got, DefaultCompositeOrder) {
+		t.Errorf("unset Order() = %v, want DefaultCompositeOrder", got)
+	}
+	custom := []geojson.LayerType{geojson.LayerWater, geojson.LayerLand}
+	if got := (Params{CompositeOrder: custom}).Order(); !slices.Equal(got, custom) {
+		t.Errorf("Order() = %v, want %v", got, custom)
+	}
+}
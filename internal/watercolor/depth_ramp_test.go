@@ -0,0 +1,88 @@
+package watercolor
+
+import (
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MeKo-Tech/watercolormap/internal/geojson"
+	"github.com/MeKo-Tech/watercolormap/internal/mask"
+)
+
+// TestDepthRampGolden paints a large synthetic lake with a depth ramp and compares it against
+// a golden (set UPDATE_GOLDEN=1 to regenerate).
+func TestDepthRampGolden(t *testing.T) {
+	const tileSize = 256
+	goldenDir := filepath.Join("..", "..", "testdata", "golden", "watercolor-depth-ramp")
+	debugDir := filepath.Join("..", "..", "testdata", "output", "watercolor-depth-ramp")
+	update := os.Getenv("UPDATE_GOLDEN") == "1"
+
+	layerImg := image.NewRGBA(image.Rect(0, 0, tileSize, tileSize))
+	for y := 16; y < 240; y++ {
+		for x := 16; x < 240; x++ {
+			layerImg.Set(x, y, color.RGBA{B: 255, A: 255})
+		}
+	}
+	textures := map[geojson.LayerType]image.Image{
+		geojson.LayerWater: solidTexture(4, 4, color.NRGBA{R: 150, G: 190, B: 225, A: 255}),
+	}
+
+	params := DefaultParams(tileSize, 1337, textures)
+	params.PerlinNoise = mask.GeneratePerlinNoiseWithOffset(tileSize, tileSize, params.NoiseScale, params.Seed, 0, 0)
+
+	plain, err := PaintLayer(layerImg, geojson.LayerWater, params)
+	if err != nil {
+		t.Fatalf("PaintLayer (plain) failed: %v", err)
+	}
+
+	style := params.Styles[geojson.LayerWater]
+	style.DepthRamp = &WaterDepthRamp{
+		Shallow:   color.NRGBA{R: 255, G: 255, B: 255, A: 255},
+		Deep:      color.NRGBA{R: 70, G: 110, B: 190, A: 255},
+		MaxDistPx: 80,
+	}
+	params.Styles[geojson.LayerWater] = style
+	shaded, err := PaintLayer(layerImg, geojson.LayerWater, params)
+	if err != nil {
+		t.Fatalf("PaintLayer (depth ramp) failed: %v", err)
+	}
+
+	// A white shallow color leaves the shore as is; the middle of the lake is deeper
+	center := shaded.NRGBAAt(128, 128)
+	plainCenter := plain.NRGBAAt(128, 128)
+	if center.R >= plainCenter.R || center.G >= plainCenter.G {
+		t.Errorf("expected the lake center to be darker than the plain wash, got %v vs %v", center, plainCenter)
+	}
+	if center.A != plainCenter.A {
+		t.Errorf("expected alpha to be unchanged, got %d vs %d", center.A, plainCenter.A)
+	}
+	nearShore := shaded.NRGBAAt(128, 22)
+	if nearShore.R <= center.R {
+		t.Errorf("expected the water near the shore to be lighter than the center, got %v vs %v", nearShore, center)
+	}
+	if shaded.NRGBAAt(4, 4).A != 0 {
+		t.Error("expected land outside the lake to stay transparent")
+	}
+
+	writeTestPNG(t, filepath.Join(debugDir, "lake_plain.png"), plain)
+	writeTestPNG(t, filepath.Join(debugDir, "lake_depth.png"), shaded)
+	goldenPath := filepath.Join(goldenDir, "lake_depth.png")
+	if update {
+		writeTestPNG(t, goldenPath, shaded)
+		return
+	}
+	assertMatchesGolden(t, goldenPath, shaded)
+}
+
+func TestRequiredPaddingCoversDepthRamp(t *testing.T) {
+	params := DefaultParams(256, 1, nil)
+	water := params.Styles[geojson.LayerWater]
+	water.DepthRamp = &WaterDepthRamp{MaxDistPx: 100}
+	params.Styles[geojson.LayerWater] = water
+
+	if got := RequiredPaddingPx(params); got < 100 {
+		t.Errorf("RequiredPaddingPx = %d, want at least the ramp distance 100", got)
+	}
+}
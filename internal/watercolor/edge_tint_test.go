@@ -0,0 +1,124 @@
+package watercolor
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MeKo-Tech/watercolormap/internal/geojson"
+	"github.com/MeKo-Tech/watercolormap/internal/mask"
+)
+
+// TestEdgeTintGolden paints the same synthetic water body with and without an edge tint
+// and compares both against goldens (set UPDATE_GOLDEN=1 to regenerate).
+func TestEdgeTintGolden(t *testing.T) {
+	const tileSize = 128
+	goldenDir := filepath.Join("..", "..", "testdata", "golden", "watercolor-edge-tint")
+	debugDir := filepath.Join("..", "..", "testdata", "output", "watercolor-edge-tint")
+	update := os.Getenv("UPDATE_GOLDEN") == "1"
+
+	layerImg := image.NewRGBA(image.Rect(0, 0, tileSize, tileSize))
+	for y := 24; y < 104; y++ {
+		for x := 24; x < 104; x++ {
+			layerImg.Set(x, y, color.RGBA{B: 255, A: 255})
+		}
+	}
+	textures := map[geojson.LayerType]image.Image{
+		geojson.LayerWater: solidTexture(4, 4, color.NRGBA{R: 105, G: 160, B: 210, A: 255}),
+	}
+
+	params := DefaultParams(tileSize, 1337, textures)
+	params.PerlinNoise = mask.GeneratePerlinNoiseWithOffset(tileSize, tileSize, params.NoiseScale, params.Seed, 0, 0)
+
+	style := params.Styles[geojson.LayerWater]
+	style.EdgeStrength = 0.5 // exaggerate so the tint is clearly visible
+	tinted := style
+	untinted := style
+	untinted.EdgeTint = nil
+
+	params.Styles[geojson.LayerWater] = untinted
+	plain, err := PaintLayer(layerImg, geojson.LayerWater, params)
+	if err != nil {
+		t.Fatalf("PaintLayer (untinted) failed: %v", err)
+	}
+
+	params.Styles[geojson.LayerWater] = tinted
+	blue, err := PaintLayer(layerImg, geojson.LayerWater, params)
+	if err != nil {
+		t.Fatalf("PaintLayer (tinted) failed: %v", err)
+	}
+
+	// Edges must differ from the neutral version, and the tint should pull the edge color
+	// toward blue: red drops proportionally more than blue relative to the untouched interior.
+	edge, center := blue.NRGBAAt(64, 26), blue.NRGBAAt(64, 64)
+	if edge == plain.NRGBAAt(64, 26) {
+		t.Errorf("expected tinted edge to differ from untinted edge, both %v", edge)
+	}
+	redKeep := float64(edge.R) / float64(center.R)
+	blueKeep := float64(edge.B) / float64(center.B)
+	if blueKeep <= redKeep {
+		t.Errorf("expected edge to shift toward blue: kept %.2f of blue vs %.2f of red (edge %v, center %v)",
+			blueKeep, redKeep, edge, center)
+	}
+
+	for name, img := range map[string]*image.NRGBA{"water_untinted": plain, "water_tinted": blue} {
+		writeTestPNG(t, filepath.Join(debugDir, name+".png"), img)
+		goldenPath := filepath.Join(goldenDir, name+".png")
+		if update {
+			writeTestPNG(t, goldenPath, img)
+			continue
+		}
+		assertMatchesGolden(t, goldenPath, img)
+	}
+}
+
+func writeTestPNG(t *testing.T, path string, img image.Image) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("failed to create dir for %s: %v", path, err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("failed to create %s: %v", path, err)
+	}
+	defer f.Close()
+	if err := png.Encode(f, img); err != nil {
+		t.Fatalf("failed to encode %s: %v", path, err)
+	}
+}
+
+func assertMatchesGolden(t *testing.T, goldenPath string, actual *image.NRGBA) {
+	t.Helper()
+	f, err := os.Open(goldenPath)
+	if err != nil {
+		t.Fatalf("golden file missing (run with UPDATE_GOLDEN=1): %v", err)
+	}
+	defer f.Close()
+
+	expected, err := png.Decode(f)
+	if err != nil {
+		t.Fatalf("failed to decode golden %s: %v", goldenPath, err)
+	}
+	if expected.Bounds() != actual.Bounds() {
+		t.Fatalf("%s: bounds mismatch: golden %v, got %v", goldenPath, expected.Bounds(), actual.Bounds())
+	}
+
+	diff := 0
+	b := expected.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			e := color.NRGBAModel.Convert(expected.At(x, y)).(color.NRGBA)
+			a := actual.NRGBAAt(x, y)
+			if absDiff(int(e.R), int(a.R)) > 1 || absDiff(int(e.G), int(a.G)) > 1 ||
+				absDiff(int(e.B), int(a.B)) > 1 || absDiff(int(e.A), int(a.A)) > 1 {
+				diff++
+			}
+		}
+	}
+	if diff > 0 {
+		t.Fatalf("%s: %d pixels differ from golden", goldenPath, diff)
+	}
+}
@@ -0,0 +1,117 @@
+package watercolor
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/MeKo-Tech/watercolormap/internal/geojson"
+	"github.com/MeKo-Tech/watercolormap/internal/mask"
+)
+
+// emptyMaskParams returns the default parameters with a texture for every layer.
+func emptyMaskParams(tileSize int) Params {
+	params := DefaultParams(tileSize, 1337, nil)
+	params.PerlinNoise = mask.GeneratePerlinNoiseWithOffset(tileSize, tileSize, params.NoiseScale, params.Seed, 0, 0)
+	for layer, style := range params.Styles {
+		style.Texture = solidTexture(4, 4, color.NRGBA{R: 180, G: 160, B: 120, A: 255})
+		params.Styles[layer] = style
+	}
+	return params
+}
+
+func TestEmptyMaskShortcutMatchesFullPipeline(t *testing.T) {
+	const tileSize = 64
+	empty := image.NewGray(image.Rect(0, 0, tileSize, tileSize))
+
+	noisy := emptyMaskParams(tileSize)
+	for layer, style := range noisy.Styles {
+		// Noise strong enough to reach the threshold: the shortcut must not apply
+		style.MaskNoiseStrength = 3
+		noisy.Styles[layer] = style
+	}
+
+	for name, params := range map[string]Params{"default": emptyMaskParams(tileSize), "strong noise": noisy} {
+		for layer := range params.Styles {
+			t.Run(name+"/"+string(layer), func(t *testing.T) {
+				want, err := runMaskPipeline(empty, layer, params, false)
+				if err != nil {
+					t.Fatalf("full pipeline failed: %v", err)
+				}
+				got, err := processMask(empty, layer, params)
+				if err != nil {
+					t.Fatalf("processMask failed: %v", err)
+				}
+				if !bytes.Equal(got.Pix, want.Pix) || got.Bounds() != want.Bounds() {
+					t.Fatal("shortcut differs from the full pipeline")
+				}
+			})
+		}
+	}
+}
+
+func TestEmptyMaskShortcutApplies(t *testing.T) {
+	const tileSize = 16
+	bounds := image.Rect(0, 0, tileSize, tileSize)
+
+	tests := []struct {
+		name      string
+		noise     float64
+		invert    bool
+		wantOK    bool
+		wantValue uint8
+	}{
+		{name: "no noise", wantOK: true},
+		{name: "default noise", noise: 0.28, wantOK: true},
+		{name: "inverted", noise: 0.28, invert: true, wantOK: true, wantValue: 255},
+		{name: "noise reaches threshold", noise: 2, wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := emptyFinalMask(bounds, tt.noise, 120, mask.DefaultAntialiasWidth, tt.invert)
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			for _, v := range got.Pix {
+				if v != tt.wantValue {
+					t.Fatalf("mask value %d, want %d", v, tt.wantValue)
+				}
+			}
+		})
+	}
+}
+
+func TestPaintEmptyMaskMatchesFullPath(t *testing.T) {
+	const tileSize = 64
+	params := emptyMaskParams(tileSize)
+	empty := image.NewGray(image.Rect(0, 0, tileSize, tileSize))
+
+	for _, layer := range []geojson.LayerType{geojson.LayerWater, geojson.LayerParks, geojson.LayerRoads} {
+		t.Run(string(layer), func(t *testing.T) {
+			got, err := paintFromFinalMask(empty, nil, layer, params, nil)
+			if err != nil {
+				t.Fatalf("paintFromFinalMask failed: %v", err)
+			}
+			want, err := paintFromFinalMaskWithContext(empty, nil, layer, params, newProcessorContextSize(params.Size()))
+			if err != nil {
+				t.Fatalf("full path failed: %v", err)
+			}
+			if got.Bounds() != want.Bounds() {
+				t.Fatalf("bounds = %v, want %v", got.Bounds(), want.Bounds())
+			}
+			// Transparent pixels composite identically whatever their color channels hold
+			for y := 0; y < tileSize; y++ {
+				for x := 0; x < tileSize; x++ {
+					if g, w := color.RGBAModel.Convert(got.At(x, y)), color.RGBAModel.Convert(want.At(x, y)); g != w {
+						t.Fatalf("pixel (%d,%d) = %v, want %v", x, y, g, w)
+					}
+				}
+			}
+		})
+	}
+}
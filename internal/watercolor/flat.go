@@ -0,0 +1,77 @@
+package watercolor
+
+import (
+	"fmt"
+	"image"
+	"maps"
+	"strings"
+
+	"github.com/MeKo-Tech/watercolormap/internal/geojson"
+	"github.com/MeKo-Tech/watercolormap/internal/texture"
+)
+
+// Style presets selectable with ParseStyle.
+const (
+	StyleWatercolor = "watercolor" // The painted look of DefaultParams
+	StyleFlat       = "flat"       // Crisp solid fills, see Flatten
+)
+
+// ParseStyle returns the canonical name of a style preset; "" is StyleWatercolor.
+func ParseStyle(s string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", StyleWatercolor:
+		return StyleWatercolor, nil
+	case StyleFlat:
+		return StyleFlat, nil
+	default:
+		return "", fmt.Errorf("unknown style %q (watercolor, flat)", s)
+	}
+}
+
+// flatAntialiasWidth is the threshold transition of flat layers: wide enough around the
+// half-coverage threshold to keep Mapnik's own antialiasing of the edges.
+const flatAntialiasWidth = 127
+
+// FlatParams returns the flat style preset: DefaultParams with Flatten applied.
+func FlatParams(tileSize int, seed int64, textures map[geojson.LayerType]image.Image) Params {
+	return Flatten(DefaultParams(tileSize, seed, textures))
+}
+
+// Flatten returns a copy of p that paints crisp, vector-like layers: no blur or noise, so
+// edges follow the rendered geometry exactly, a fixed threshold at half coverage, no edge
+// darkening, shading, outlines, paper bleed or shore blend, and every texture replaced by its mean color
+// (see texture.Flat). Layer glazes (Tint) still apply, so e.g. forests stay darker than parks.
+func Flatten(p Params) Params {
+	p.BlurSigma = 0
+	p.NoiseStrength = 0
+	p.Threshold = 128
+	p.AntialiasWidth = ptr(flatAntialiasWidth)
+	p.ShoreBlendPx = 0
+
+	p.Styles = maps.Clone(p.Styles)
+	flat := make(map[image.Image]image.Image)
+	for layer, style := range p.Styles {
+		style.MaskBlurSigma = 0
+		style.MaskNoiseStrength = 0
+		style.AdaptiveNoise = false
+		style.AnisotropicBlur = false
+		style.MaskThreshold = nil
+		style.AutoThreshold = false
+		style.AntialiasWidth = nil
+		style.EdgeStrength = 0
+		style.ShadeStrength = 0
+		style.Outline = nil
+		style.PaperBleed = 0
+		style.TextureJitter = false
+		style.DepthRamp = nil
+		if style.Texture != nil {
+			// Layers sharing a texture share its flat version too
+			if _, ok := flat[style.Texture]; !ok {
+				flat[style.Texture] = texture.Flat(style.Texture)
+			}
+			style.Texture = flat[style.Texture]
+		}
+		p.Styles[layer] = style
+	}
+	return p
+}
@@ -0,0 +1,80 @@
+package watercolor
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/MeKo-Tech/watercolormap/internal/geojson"
+)
+
+func TestParseStyle(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: "", want: StyleWatercolor},
+		{in: "watercolor", want: StyleWatercolor},
+		{in: " Flat ", want: StyleFlat},
+		{in: "pencil", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseStyle(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseStyle(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseStyle(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+// TestFlatParamsHardEdges paints a square with the flat preset: the fill is one solid color
+// and the edge follows the geometry to the pixel.
+func TestFlatParamsHardEdges(t *testing.T) {
+	const tileSize = 64
+	layerImg := image.NewRGBA(image.Rect(0, 0, tileSize, tileSize))
+	for y := 16; y < 48; y++ {
+		for x := 16; x < 48; x++ {
+			layerImg.Set(x, y, color.RGBA{B: 255, A: 255})
+		}
+	}
+	textures := map[geojson.LayerType]image.Image{
+		geojson.LayerWater: solidTexture(4, 4, color.NRGBA{R: 105, G: 160, B: 210, A: 255}),
+	}
+	defaults := DefaultParams(tileSize, 1337, textures)
+	params := FlatParams(tileSize, 1337, textures)
+
+	if defaults.Styles[geojson.LayerWater].EdgeStrength == 0 {
+		t.Fatal("expected the default water style to darken edges")
+	}
+	if params.Styles[geojson.LayerWater].EdgeStrength != 0 {
+		t.Error("expected FlatParams to disable edge darkening")
+	}
+	blended := defaults
+	blended.ShoreBlendPx = 8
+	if got := Flatten(blended).ShoreBlendPx; got != 0 {
+		t.Errorf("Flatten kept ShoreBlendPx = %v, want 0", got)
+	}
+
+	painted, err := PaintLayer(layerImg, geojson.LayerWater, params)
+	if err != nil {
+		t.Fatalf("PaintLayer failed: %v", err)
+	}
+	fill := painted.NRGBAAt(32, 32)
+	if fill.A != 255 {
+		t.Fatalf("expected an opaque fill, got %v", fill)
+	}
+	for _, p := range []image.Point{{16, 16}, {47, 32}, {32, 47}} {
+		if got := painted.NRGBAAt(p.X, p.Y); got != fill {
+			t.Errorf("pixel %v = %v, want the solid fill %v", p, got, fill)
+		}
+	}
+	for _, p := range []image.Point{{15, 32}, {48, 32}, {32, 15}} {
+		if got := painted.NRGBAAt(p.X, p.Y); got.A != 0 {
+			t.Errorf("pixel %v outside the square = %v, want transparent", p, got)
+		}
+	}
+}
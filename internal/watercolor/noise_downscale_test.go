@@ -0,0 +1,138 @@
+package watercolor
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MeKo-Tech/watercolormap/internal/geojson"
+)
+
+// TestNoiseDownscaleGolden captures full-resolution and downscaled noise plus the water layer
+// painted with each, and checks that downscaling changes the painted edges but stays visually
+// close to full resolution (set UPDATE_GOLDEN=1 to regenerate).
+func TestNoiseDownscaleGolden(t *testing.T) {
+	const tileSize = 256
+	goldenDir := filepath.Join("..", "..", "testdata", "golden", "watercolor-noise-downscale")
+	debugDir := filepath.Join("..", "..", "testdata", "output", "watercolor-noise-downscale")
+	update := os.Getenv("UPDATE_GOLDEN") == "1"
+
+	layerImg := image.NewRGBA(image.Rect(0, 0, tileSize, tileSize))
+	for y := 0; y < tileSize; y++ {
+		for x := 0; x < tileSize; x++ {
+			// Diagonal shoreline plus a small island exercise noisy mask edges
+			if x+y < tileSize || (x-190)*(x-190)+(y-190)*(y-190) < 900 {
+				layerImg.Set(x, y, color.RGBA{B: 255, A: 255})
+			}
+		}
+	}
+	textures := map[geojson.LayerType]image.Image{
+		geojson.LayerWater: solidTexture(4, 4, color.NRGBA{R: 105, G: 160, B: 210, A: 255}),
+	}
+
+	render := func(downscale int) (*image.Gray, *image.NRGBA) {
+		params := DefaultParams(tileSize, 1337, textures)
+		params.OffsetX = 4317*tileSize - 37 // unaligned, as with metatile padding
+		params.OffsetY = 2692*tileSize - 37
+		params.NoiseDownscale = downscale
+		params.PerlinNoise = GenerateNoise(params, 13, 4317, 2692)
+
+		// The default water style keeps noise away from its edges (AdaptiveNoise), so its mask
+		// never crosses the threshold differently. Noise the whole soft edge instead.
+		style := params.Styles[geojson.LayerWater]
+		style.AdaptiveNoise = false
+		style.MaskBlurSigma = 3
+		style.MaskNoiseStrength = 0.35
+		params.Styles[geojson.LayerWater] = style
+
+		painted, err := PaintLayer(layerImg, geojson.LayerWater, params)
+		if err != nil {
+			t.Fatalf("PaintLayer (downscale %d) failed: %v", downscale, err)
+		}
+		return params.PerlinNoise, painted
+	}
+
+	fullNoise, fullPainted := render(1)
+	for _, downscale := range []int{1, 2, 4} {
+		noise, painted := render(downscale)
+
+		if downscale > 1 {
+			// The finest Perlin octave is only ~7px per cycle at the default scale, so allow
+			// slightly more smoothing at quarter resolution (~1% of the 0-255 range).
+			maxDiff := float64(downscale)
+			if diff := meanAbsGrayDiff(fullNoise, noise); diff > maxDiff {
+				t.Errorf("downscale %d: mean noise difference %.2f exceeds %.0f levels", downscale, diff, maxDiff)
+			}
+			if bytes.Equal(fullPainted.Pix, painted.Pix) {
+				t.Errorf("downscale %d: painted layer is identical to full resolution, the mask ignores the noise", downscale)
+			}
+			if diff := meanAbsAlphaDiff(fullPainted, painted); diff > 0.5 {
+				t.Errorf("downscale %d: mean alpha difference %.2f exceeds 0.5 levels", downscale, diff)
+			}
+			if frac := alphaMismatchFraction(fullPainted, painted); frac > 0.01 {
+				t.Errorf("downscale %d: %.2f%% of mask pixels differ (want <= 1%%)", downscale, frac*100)
+			}
+		}
+
+		images := map[string]image.Image{
+			fmt.Sprintf("noise_downscale%d", downscale): noise,
+			fmt.Sprintf("water_downscale%d", downscale): painted,
+		}
+		for name, img := range images {
+			writeTestPNG(t, filepath.Join(debugDir, name+".png"), img)
+			goldenPath := filepath.Join(goldenDir, name+".png")
+			if update {
+				writeTestPNG(t, goldenPath, img)
+				continue
+			}
+			assertMatchesGolden(t, goldenPath, toNRGBA(img))
+		}
+	}
+}
+
+func meanAbsGrayDiff(a, b *image.Gray) float64 {
+	var sum int
+	for i := range a.Pix {
+		sum += absDiff(int(a.Pix[i]), int(b.Pix[i]))
+	}
+	return float64(sum) / float64(len(a.Pix))
+}
+
+// meanAbsAlphaDiff returns the mean absolute alpha difference in 8-bit levels.
+func meanAbsAlphaDiff(a, b *image.NRGBA) float64 {
+	var sum int
+	for i := 3; i < len(a.Pix); i += 4 {
+		sum += absDiff(int(a.Pix[i]), int(b.Pix[i]))
+	}
+	return float64(sum) / float64(len(a.Pix)/4)
+}
+
+// alphaMismatchFraction returns the fraction of pixels whose coverage (alpha > 127) differs.
+func alphaMismatchFraction(a, b *image.NRGBA) float64 {
+	mismatched := 0
+	total := a.Bounds().Dx() * a.Bounds().Dy()
+	for i := 3; i < len(a.Pix); i += 4 {
+		if (a.Pix[i] > 127) != (b.Pix[i] > 127) {
+			mismatched++
+		}
+	}
+	return float64(mismatched) / float64(total)
+}
+
+func toNRGBA(img image.Image) *image.NRGBA {
+	if n, ok := img.(*image.NRGBA); ok {
+		return n
+	}
+	b := img.Bounds()
+	dst := image.NewNRGBA(b)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			dst.Set(x, y, img.At(x, y))
+		}
+	}
+	return dst
+}
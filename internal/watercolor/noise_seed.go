@@ -0,0 +1,94 @@
+package watercolor
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"image"
+
+	"github.com/MeKo-Tech/watercolormap/internal/mask"
+)
+
+// Noise seed modes for Params.NoiseSeedMode.
+//
+// NoiseSeedGlobal (the default) samples one continuous Perlin field for the whole map using
+// the global seed and the tile's pixel offsets. Neighboring tiles line up exactly, so there are
+// no seams, but at low zooms the single field can show as large-scale banding across a region.
+//
+// NoiseSeedPerTile derives an independent seed from (seed, z, x, y) and samples the field from
+// the origin. This removes macro patterns at the cost of small visible seams where the noisy
+// mask edges of adjacent tiles no longer match. Texture alignment is unaffected.
+//
+// NoiseSeedRegion sits between the two: the seed is derived from (seed, RegionSeedZoom parent)
+// while the field is still sampled at global pixel offsets. Tiles sharing a z8 parent are
+// seamless and distant regions look distinct, but seams appear along z8 tile boundaries.
+// Below RegionSeedZoom a tile spans several regions, so the global seed is used instead.
+// Metatiles take their region from the block's origin tile; power-of-two block sizes keep
+// every block inside a single region.
+const (
+	NoiseSeedGlobal  = "global"
+	NoiseSeedPerTile = "per-tile"
+	NoiseSeedRegion  = "region"
+)
+
+// RegionSeedZoom is the zoom level whose tiles define the regions for NoiseSeedRegion.
+const RegionSeedZoom = 8
+
+// PerTileSeed deterministically derives a seed for a single tile from the global seed.
+func PerTileSeed(seed int64, z, x, y int) int64 {
+	h := fnv.New64a()
+	var buf [8]byte
+	for _, v := range []int64{seed, int64(z), int64(x), int64(y)} {
+		binary.LittleEndian.PutUint64(buf[:], uint64(v))
+		h.Write(buf[:]) // nolint:errcheck // hash.Hash never returns an error
+	}
+	return int64(h.Sum64())
+}
+
+// RegionSeed derives the seed for tile z/x/y from the global seed and its RegionSeedZoom
+// parent tile. Tiles below RegionSeedZoom use the global seed unchanged.
+func RegionSeed(seed int64, z, x, y int) int64 {
+	if z < RegionSeedZoom {
+		return seed
+	}
+	shift := uint(z - RegionSeedZoom)
+	return PerTileSeed(seed, RegionSeedZoom, x>>shift, y>>shift)
+}
+
+// NoiseSeedAndOffset returns the seed and pixel offsets to use when generating the Perlin
+// noise field for tile z/x/y, according to params.NoiseSeedMode.
+func NoiseSeedAndOffset(params Params, z, x, y int) (int64, int, int) {
+	if params.NoiseSeedMode == NoiseSeedPerTile {
+		return PerTileSeed(params.Seed, z, x, y), 0, 0
+	}
+	if params.NoiseSeedMode == NoiseSeedRegion {
+		return RegionSeed(params.Seed, z, x, y), params.OffsetX, params.OffsetY
+	}
+	return params.Seed, params.OffsetX, params.OffsetY
+}
+
+// GenerateNoise generates the Perlin noise field covering the params.Size() canvas for tile z/x/y, honoring
+// params.NoiseSeedMode, params.NoiseOctaves, params.NoisePersistence and params.NoiseDownscale. The field is assembled from params.NoiseCache
+// when set, except in per-tile mode where no two tiles share a seed.
+func GenerateNoise(params Params, z, x, y int) *image.Gray {
+	width, height := params.Size()
+	noise := image.NewGray(image.Rect(0, 0, width, height))
+	FillNoise(noise, params, z, x, y)
+	return noise
+}
+
+// FillNoise is GenerateNoise writing into noise, which must be params.Size(), instead of a
+// new image. Every pixel is overwritten, so a buffer can be reused from tile to tile.
+func FillNoise(noise *image.Gray, params Params, z, x, y int) {
+	seed, offX, offY := NoiseSeedAndOffset(params, z, x, y)
+	cache := params.NoiseCache
+	if params.NoiseSeedMode == NoiseSeedPerTile {
+		cache = nil
+	}
+	cache.FillPerlinNoiseDownscaled(
+		noise,
+		params.NoiseScale, seed,
+		mask.PerlinOctaves{Octaves: params.NoiseOctaves, Persistence: params.NoisePersistence},
+		offX, offY,
+		params.NoiseDownscale,
+	)
+}
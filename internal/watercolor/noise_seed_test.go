@@ -0,0 +1,85 @@
+package watercolor
+
+import "testing"
+
+func TestPerTileSeedDeterministic(t *testing.T) {
+	a := PerTileSeed(1337, 13, 4317, 2692)
+	b := PerTileSeed(1337, 13, 4317, 2692)
+	if a != b {
+		t.Fatalf("expected identical seeds for identical inputs, got %d and %d", a, b)
+	}
+
+	neighbors := []int64{
+		PerTileSeed(1337, 13, 4318, 2692),
+		PerTileSeed(1337, 13, 4317, 2693),
+		PerTileSeed(1337, 14, 4317, 2692),
+		PerTileSeed(1338, 13, 4317, 2692),
+	}
+	for i, n := range neighbors {
+		if n == a {
+			t.Errorf("neighbor %d: expected a different seed, got %d", i, n)
+		}
+	}
+}
+
+func TestNoiseSeedAndOffset(t *testing.T) {
+	params := Params{Seed: 42, OffsetX: 1000, OffsetY: 2000}
+
+	seed, offX, offY := NoiseSeedAndOffset(params, 13, 10, 20)
+	if seed != 42 || offX != 1000 || offY != 2000 {
+		t.Errorf("global mode: got (%d, %d, %d), want (42, 1000, 2000)", seed, offX, offY)
+	}
+
+	params.NoiseSeedMode = NoiseSeedPerTile
+	seed, offX, offY = NoiseSeedAndOffset(params, 13, 10, 20)
+	if seed != PerTileSeed(42, 13, 10, 20) {
+		t.Errorf("per-tile mode: got seed %d, want %d", seed, PerTileSeed(42, 13, 10, 20))
+	}
+	if offX != 0 || offY != 0 {
+		t.Errorf("per-tile mode: expected zero offsets, got (%d, %d)", offX, offY)
+	}
+}
+
+func TestRegionSeed(t *testing.T) {
+	// z13 tiles 4317/2692 and 4318/2693 share the z8 parent 134/84.
+	a := RegionSeed(1337, 13, 4317, 2692)
+	if b := RegionSeed(1337, 13, 4318, 2693); a != b {
+		t.Errorf("tiles in the same z8 parent: got seeds %d and %d, want equal", a, b)
+	}
+	if a != PerTileSeed(1337, RegionSeedZoom, 134, 84) {
+		t.Errorf("expected seed of z8 parent 134/84, got %d", a)
+	}
+	if b := RegionSeed(1337, 13, 4320, 2692); a == b {
+		t.Errorf("tiles in different z8 parents: expected different seeds, got %d", a)
+	}
+	if s := RegionSeed(1337, 7, 10, 20); s != 1337 {
+		t.Errorf("below region zoom: got seed %d, want global seed 1337", s)
+	}
+}
+
+func TestRegionNoiseSeamlessWithinRegion(t *testing.T) {
+	const size = 64
+	params := Params{Seed: 42, NoiseScale: 30, TileSize: size, NoiseSeedMode: NoiseSeedRegion}
+
+	// Two horizontally adjacent z10 tiles in the same z8 parent (x 0..3 share parent 0).
+	left := params
+	left.OffsetX, left.OffsetY = 0, 0
+	right := params
+	right.OffsetX, right.OffsetY = size, 0
+
+	a := GenerateNoise(left, 10, 0, 0)
+	b := GenerateNoise(right, 10, 1, 0)
+
+	// Sampling one extra column on the left tile must reproduce the right tile's first column.
+	wide := params
+	wide.TileSize = size + 1
+	w := GenerateNoise(wide, 10, 0, 0)
+	for y := 0; y < size; y++ {
+		if w.GrayAt(size, y) != b.GrayAt(0, y) {
+			t.Fatalf("row %d: noise not continuous across the shared boundary", y)
+		}
+		if w.GrayAt(0, y) != a.GrayAt(0, y) {
+			t.Fatalf("row %d: widened field diverges from the tile field", y)
+		}
+	}
+}
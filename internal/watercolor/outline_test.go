@@ -0,0 +1,70 @@
+package watercolor
+
+import (
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MeKo-Tech/watercolormap/internal/geojson"
+	"github.com/MeKo-Tech/watercolormap/internal/mask"
+)
+
+// TestOutlineGolden paints a synthetic coastline with and without an ink outline and
+// compares the outlined version against a golden (set UPDATE_GOLDEN=1 to regenerate).
+func TestOutlineGolden(t *testing.T) {
+	const tileSize = 128
+	goldenDir := filepath.Join("..", "..", "testdata", "golden", "watercolor-outline")
+	debugDir := filepath.Join("..", "..", "testdata", "output", "watercolor-outline")
+	update := os.Getenv("UPDATE_GOLDEN") == "1"
+
+	layerImg := image.NewRGBA(image.Rect(0, 0, tileSize, tileSize))
+	for y := 24; y < 104; y++ {
+		for x := 24; x < 104; x++ {
+			layerImg.Set(x, y, color.RGBA{B: 255, A: 255})
+		}
+	}
+	textures := map[geojson.LayerType]image.Image{
+		geojson.LayerWater: solidTexture(4, 4, color.NRGBA{R: 105, G: 160, B: 210, A: 255}),
+	}
+
+	params := DefaultParams(tileSize, 1337, textures)
+	params.PerlinNoise = mask.GeneratePerlinNoiseWithOffset(tileSize, tileSize, params.NoiseScale, params.Seed, 0, 0)
+
+	plain, err := PaintLayer(layerImg, geojson.LayerWater, params)
+	if err != nil {
+		t.Fatalf("PaintLayer (plain) failed: %v", err)
+	}
+
+	style := params.Styles[geojson.LayerWater]
+	style.Outline = &Outline{Color: color.NRGBA{R: 40, G: 45, B: 60, A: 255}, WidthPx: 2, Strength: 0.5}
+	params.Styles[geojson.LayerWater] = style
+	outlined, err := PaintLayer(layerImg, geojson.LayerWater, params)
+	if err != nil {
+		t.Fatalf("PaintLayer (outlined) failed: %v", err)
+	}
+
+	// The interior is untouched; the edge band is darker than the plain wash
+	if outlined.NRGBAAt(64, 64) != plain.NRGBAAt(64, 64) {
+		t.Errorf("expected interior to be unchanged, got %v vs %v", outlined.NRGBAAt(64, 64), plain.NRGBAAt(64, 64))
+	}
+	changed := 0
+	for i := 0; i < len(outlined.Pix); i += 4 {
+		if outlined.Pix[i+3] > plain.Pix[i+3] || (outlined.Pix[i+3] > 0 && outlined.Pix[i] < plain.Pix[i]) {
+			changed++
+		}
+	}
+	if changed == 0 {
+		t.Error("expected the outline to ink some edge pixels")
+	}
+
+	writeTestPNG(t, filepath.Join(debugDir, "water_plain.png"), plain)
+	writeTestPNG(t, filepath.Join(debugDir, "water_outlined.png"), outlined)
+	goldenPath := filepath.Join(goldenDir, "water_outlined.png")
+	if update {
+		writeTestPNG(t, goldenPath, outlined)
+		return
+	}
+	assertMatchesGolden(t, goldenPath, outlined)
+}
@@ -31,10 +31,18 @@ func RequiredPaddingPx(params Params) int {
 	consider(params.BlurSigma)
 	consider(params.AntialiasSigma)
 
+	outlinePx := 0
+	rampPx := 0
 	for _, style := range params.Styles {
 		consider(style.MaskBlurSigma)
 		consider(style.ShadeSigma)
 		consider(style.EdgeSigma)
+		if style.Outline != nil && style.Outline.WidthPx > outlinePx {
+			outlinePx = style.Outline.WidthPx
+		}
+		if style.DepthRamp != nil && style.DepthRamp.MaxDistPx > 0 {
+			rampPx = max(rampPx, int(math.Ceil(style.DepthRamp.MaxDistPx)))
+		}
 	}
 
 	// 3*sigma captures the vast majority of the kernel energy.
@@ -42,6 +50,12 @@ func RequiredPaddingPx(params Params) int {
 	if blurPad < 1 {
 		blurPad = 1
 	}
+	// Outlines reach at most their width past the (blurred) edge
+	blurPad += outlinePx
+	// Shore blending extends land and water up to its width past the shoreline
+	blurPad += int(math.Ceil(params.ShoreBlendPx))
+	// Depth ramps need to see the shore up to their max distance away
+	blurPad = max(blurPad, rampPx)
 
 	// Use the larger of blur padding and geometry padding
 	if blurPad < MinGeometryPaddingPx {
@@ -37,3 +37,17 @@ func TestRequiredPaddingPx(t *testing.T) {
 		t.Fatalf("expected pad %d (MinGeometryPaddingPx) when all sigmas are 0, got %d", MinGeometryPaddingPx, got)
 	}
 }
+
+func TestRequiredPaddingPxIncludesOutline(t *testing.T) {
+	params := DefaultParams(256, 123, nil)
+	params.BlurSigma = 30 // large enough that blur padding exceeds MinGeometryPaddingPx
+	base := RequiredPaddingPx(params)
+
+	style := params.Styles[geojson.LayerWater]
+	style.Outline = &Outline{WidthPx: 3, Strength: 0.4}
+	params.Styles[geojson.LayerWater] = style
+
+	if got := RequiredPaddingPx(params); got != base+3 {
+		t.Fatalf("expected outline width to be added to padding %d, got %d", base, got)
+	}
+}
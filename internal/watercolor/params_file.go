@@ -0,0 +1,376 @@
+package watercolor
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image/color"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/MeKo-Tech/watercolormap/internal/geojson"
+	"github.com/MeKo-Tech/watercolormap/internal/mask"
+	"github.com/pelletier/go-toml/v2"
+	"go.yaml.in/yaml/v3"
+)
+
+// maxNoiseOctaves bounds noise_octaves: every octave costs a full sampling pass, and past a
+// dozen they are finer than a pixel at the default noise scale.
+const maxNoiseOctaves = 12
+
+// paramsFile is the on-disk form of Params. Runtime fields (tile size, seed, offsets, the
+// noise field) are not part of it; they are set by the generator for every tile.
+type paramsFile struct {
+	BlurSigma        float32                         `yaml:"blur_sigma" toml:"blur_sigma"`
+	AntialiasSigma   float32                         `yaml:"antialias_sigma" toml:"antialias_sigma"`
+	AntialiasWidth   *uint8                          `yaml:"antialias_width,omitempty" toml:"antialias_width,omitempty"`
+	DisableAntialias bool                            `yaml:"disable_antialias,omitempty" toml:"disable_antialias,omitempty"`
+	NoiseScale       float64                         `yaml:"noise_scale" toml:"noise_scale"`
+	NoiseStrength    float64                         `yaml:"noise_strength" toml:"noise_strength"`
+	NoiseOctaves     int                             `yaml:"noise_octaves" toml:"noise_octaves"`
+	NoisePersistence float64                         `yaml:"noise_persistence" toml:"noise_persistence"`
+	ShoreBlendPx     float64                         `yaml:"shore_blend_px,omitempty" toml:"shore_blend_px,omitempty"`
+	Threshold        uint8                           `yaml:"threshold" toml:"threshold"`
+	CompositeOrder   []geojson.LayerType             `yaml:"composite_order,omitempty" toml:"composite_order,omitempty"`
+	Styles           map[geojson.LayerType]styleFile `yaml:"styles" toml:"styles"`
+}
+
+// styleFile is the on-disk form of LayerStyle. Optional values stay pointers so that an
+// omitted key keeps the default (e.g. the global threshold for mask_threshold).
+type styleFile struct {
+	Texture           string         `yaml:"texture,omitempty" toml:"texture,omitempty"`
+	MaskBlurSigma     float32        `yaml:"mask_blur_sigma" toml:"mask_blur_sigma"`
+	MaskNoiseStrength float64        `yaml:"mask_noise_strength" toml:"mask_noise_strength"`
+	MaskThreshold     *uint8         `yaml:"mask_threshold,omitempty" toml:"mask_threshold,omitempty"`
+	AutoThreshold     bool           `yaml:"auto_threshold,omitempty" toml:"auto_threshold,omitempty"`
+	AntialiasWidth    *uint8         `yaml:"antialias_width,omitempty" toml:"antialias_width,omitempty"`
+	MinFeatureAreaPx  int            `yaml:"min_feature_area_px,omitempty" toml:"min_feature_area_px,omitempty"`
+	InvertMask        bool           `yaml:"invert_mask" toml:"invert_mask"`
+	AdaptiveNoise     bool           `yaml:"adaptive_noise" toml:"adaptive_noise"`
+	NoiseMinDist      float64        `yaml:"noise_min_dist" toml:"noise_min_dist"`
+	NoiseMaxDist      float64        `yaml:"noise_max_dist" toml:"noise_max_dist"`
+	NoiseFalloff      string         `yaml:"noise_falloff,omitempty" toml:"noise_falloff,omitempty"`
+	NoiseFalloffExp   float64        `yaml:"noise_falloff_exponent,omitempty" toml:"noise_falloff_exponent,omitempty"`
+	ShadeSigma        float32        `yaml:"shade_sigma" toml:"shade_sigma"`
+	ShadeStrength     float64        `yaml:"shade_strength" toml:"shade_strength"`
+	EdgeSigma         float32        `yaml:"edge_sigma" toml:"edge_sigma"`
+	EdgeStrength      float64        `yaml:"edge_strength" toml:"edge_strength"`
+	EdgeGamma         float64        `yaml:"edge_gamma" toml:"edge_gamma"`
+	Tint              string         `yaml:"tint,omitempty" toml:"tint,omitempty"`
+	EdgeTint          string         `yaml:"edge_tint,omitempty" toml:"edge_tint,omitempty"`
+	Outline           *outlineFile   `yaml:"outline,omitempty" toml:"outline,omitempty"`
+	PaperBleed        float64        `yaml:"paper_bleed,omitempty" toml:"paper_bleed,omitempty"`
+	TextureJitter     bool           `yaml:"texture_jitter,omitempty" toml:"texture_jitter,omitempty"`
+	AnisotropicBlur   bool           `yaml:"anisotropic_blur,omitempty" toml:"anisotropic_blur,omitempty"`
+	TextureScale      float64        `yaml:"texture_scale,omitempty" toml:"texture_scale,omitempty"`
+	TextureSmooth     bool           `yaml:"texture_smooth,omitempty" toml:"texture_smooth,omitempty"`
+	DepthRamp         *depthRampFile `yaml:"depth_ramp,omitempty" toml:"depth_ramp,omitempty"`
+}
+
+type depthRampFile struct {
+	Shallow   string  `yaml:"shallow" toml:"shallow"`
+	Deep      string  `yaml:"deep" toml:"deep"`
+	MaxDistPx float64 `yaml:"max_dist_px" toml:"max_dist_px"`
+}
+
+type outlineFile struct {
+	Color    string  `yaml:"color" toml:"color"`
+	WidthPx  int     `yaml:"width_px" toml:"width_px"`
+	Strength float64 `yaml:"strength" toml:"strength"`
+}
+
+// LoadParams reads watercolor parameters from a YAML or TOML file (chosen by the .toml
+// extension; anything else is parsed as YAML, which includes JSON). Values are applied on top
+// of DefaultParams, so a file only needs the knobs it changes:
+//
+//	noise_strength: 0.3
+//	composite_order: [land, parks, forest, roads, highways, rivers, water, buildings, urban]
+//	styles:
+//	  water:
+//	    texture: water.png
+//	    mask_threshold: 150
+//	    edge_tint: "#1e4682"
+//
+// Textures are referenced by file name and left unloaded (Texture is nil); the generator
+// resolves TextureFile against its textures. Tile size and seed are left at zero.
+func LoadParams(path string) (Params, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Params{}, fmt.Errorf("failed to read params file: %w", err)
+	}
+
+	if isTOML(path) {
+		// Go through a generic map so TOML and YAML share the override logic below
+		var generic map[string]any
+		if err := toml.Unmarshal(data, &generic); err != nil {
+			return Params{}, fmt.Errorf("failed to parse params file %s: %w", path, err)
+		}
+		if data, err = yaml.Marshal(generic); err != nil {
+			return Params{}, fmt.Errorf("failed to convert params file %s: %w", path, err)
+		}
+	}
+
+	f := toParamsFile(DefaultParams(0, 0, nil))
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return Params{}, fmt.Errorf("failed to parse params file %s: %w", path, err)
+	}
+
+	// Decoding replaces whole map entries, so decode each style again over its defaults
+	// (new layers start from a zero style)
+	var raw struct {
+		Styles map[geojson.LayerType]yaml.Node `yaml:"styles"`
+	}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return Params{}, fmt.Errorf("failed to parse params file %s: %w", path, err)
+	}
+	defaults := toParamsFile(DefaultParams(0, 0, nil)).Styles
+	for layer, node := range raw.Styles {
+		sf := defaults[layer]
+		if err := node.Decode(&sf); err != nil {
+			return Params{}, fmt.Errorf("failed to parse style %q in %s: %w", layer, path, err)
+		}
+		f.Styles[layer] = sf
+	}
+
+	params, err := f.toParams()
+	if err != nil {
+		return Params{}, fmt.Errorf("invalid params file %s: %w", path, err)
+	}
+	return params, nil
+}
+
+// SaveParams writes the styling knobs of params to a YAML or TOML file (chosen by the .toml
+// extension), in the format read by LoadParams. Textures are written as their TextureFile.
+func SaveParams(path string, params Params) error {
+	f := toParamsFile(params)
+
+	var data []byte
+	var err error
+	if isTOML(path) {
+		data, err = toml.Marshal(f)
+	} else {
+		data, err = yaml.Marshal(f)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to encode params: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write params file: %w", err)
+	}
+	return nil
+}
+
+// Hash returns a short fingerprint of the styling knobs of p: the values SaveParams writes,
+// so runtime fields (tile size, seed, offsets, noise) and loaded textures don't change it.
+// Tiles rendered with equal hashes were styled alike.
+func (p Params) Hash() string {
+	data, err := yaml.Marshal(toParamsFile(p))
+	if err != nil {
+		// paramsFile only holds plain values, which always encode
+		panic(fmt.Sprintf("failed to encode params: %v", err))
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+func isTOML(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".toml")
+}
+
+func toParamsFile(p Params) paramsFile {
+	f := paramsFile{
+		BlurSigma:        p.BlurSigma,
+		AntialiasSigma:   p.AntialiasSigma,
+		AntialiasWidth:   p.AntialiasWidth,
+		DisableAntialias: p.DisableAntialias,
+		NoiseScale:       p.NoiseScale,
+		NoiseStrength:    p.NoiseStrength,
+		NoiseOctaves:     p.NoiseOctaves,
+		NoisePersistence: p.NoisePersistence,
+		ShoreBlendPx:     p.ShoreBlendPx,
+		Threshold:        p.Threshold,
+		CompositeOrder:   p.CompositeOrder,
+		Styles:           make(map[geojson.LayerType]styleFile, len(p.Styles)),
+	}
+	for layer, s := range p.Styles {
+		sf := styleFile{
+			Texture:           s.TextureFile,
+			MaskBlurSigma:     s.MaskBlurSigma,
+			MaskNoiseStrength: s.MaskNoiseStrength,
+			MaskThreshold:     s.MaskThreshold,
+			AutoThreshold:     s.AutoThreshold,
+			AntialiasWidth:    s.AntialiasWidth,
+			MinFeatureAreaPx:  s.MinFeatureAreaPx,
+			InvertMask:        s.InvertMask,
+			AdaptiveNoise:     s.AdaptiveNoise,
+			NoiseMinDist:      s.NoiseMinDist,
+			NoiseMaxDist:      s.NoiseMaxDist,
+			NoiseFalloff:      s.NoiseFalloff,
+			NoiseFalloffExp:   s.NoiseFalloffExp,
+			ShadeSigma:        s.ShadeSigma,
+			ShadeStrength:     s.ShadeStrength,
+			EdgeSigma:         s.EdgeSigma,
+			EdgeStrength:      s.EdgeStrength,
+			EdgeGamma:         s.EdgeGamma,
+			PaperBleed:        s.PaperBleed,
+			TextureJitter:     s.TextureJitter,
+			AnisotropicBlur:   s.AnisotropicBlur,
+			TextureScale:      s.TextureScale,
+			TextureSmooth:     s.TextureSmooth,
+		}
+		if s.Tint != nil {
+			sf.Tint = formatHexColor(*s.Tint)
+		}
+		if s.EdgeTint != nil {
+			sf.EdgeTint = formatHexColor(*s.EdgeTint)
+		}
+		if s.Outline != nil {
+			sf.Outline = &outlineFile{Color: formatHexColor(s.Outline.Color), WidthPx: s.Outline.WidthPx, Strength: s.Outline.Strength}
+		}
+		if s.DepthRamp != nil {
+			sf.DepthRamp = &depthRampFile{
+				Shallow:   formatHexColor(s.DepthRamp.Shallow),
+				Deep:      formatHexColor(s.DepthRamp.Deep),
+				MaxDistPx: s.DepthRamp.MaxDistPx,
+			}
+		}
+		f.Styles[layer] = sf
+	}
+	return f
+}
+
+func (f paramsFile) toParams() (Params, error) {
+	p := Params{
+		BlurSigma:        f.BlurSigma,
+		AntialiasSigma:   f.AntialiasSigma,
+		AntialiasWidth:   f.AntialiasWidth,
+		DisableAntialias: f.DisableAntialias,
+		NoiseScale:       f.NoiseScale,
+		NoiseStrength:    f.NoiseStrength,
+		NoiseOctaves:     f.NoiseOctaves,
+		NoisePersistence: f.NoisePersistence,
+		ShoreBlendPx:     f.ShoreBlendPx,
+		Threshold:        f.Threshold,
+		CompositeOrder:   f.CompositeOrder,
+		Styles:           make(map[geojson.LayerType]LayerStyle, len(f.Styles)),
+	}
+	if p.NoiseScale <= 0 {
+		return Params{}, fmt.Errorf("noise_scale must be positive, got %v", p.NoiseScale)
+	}
+	// 0 keeps the default for both
+	if p.NoiseOctaves < 0 || p.NoiseOctaves > maxNoiseOctaves {
+		return Params{}, fmt.Errorf("noise_octaves must be between 1 and %d (0 keeps the default), got %d", maxNoiseOctaves, p.NoiseOctaves)
+	}
+	if p.NoisePersistence < 0 || p.NoisePersistence > 1 {
+		return Params{}, fmt.Errorf("noise_persistence must be in (0, 1] (0 keeps the default), got %v", p.NoisePersistence)
+	}
+	if p.ShoreBlendPx < 0 {
+		return Params{}, fmt.Errorf("shore_blend_px must not be negative, got %v", p.ShoreBlendPx)
+	}
+
+	for layer, sf := range f.Styles {
+		s := LayerStyle{
+			Layer:             layer,
+			TextureFile:       sf.Texture,
+			MaskBlurSigma:     sf.MaskBlurSigma,
+			MaskNoiseStrength: sf.MaskNoiseStrength,
+			MaskThreshold:     sf.MaskThreshold,
+			AutoThreshold:     sf.AutoThreshold,
+			AntialiasWidth:    sf.AntialiasWidth,
+			MinFeatureAreaPx:  sf.MinFeatureAreaPx,
+			InvertMask:        sf.InvertMask,
+			AdaptiveNoise:     sf.AdaptiveNoise,
+			NoiseMinDist:      sf.NoiseMinDist,
+			NoiseMaxDist:      sf.NoiseMaxDist,
+			NoiseFalloff:      sf.NoiseFalloff,
+			NoiseFalloffExp:   sf.NoiseFalloffExp,
+			ShadeSigma:        sf.ShadeSigma,
+			ShadeStrength:     sf.ShadeStrength,
+			EdgeSigma:         sf.EdgeSigma,
+			EdgeStrength:      sf.EdgeStrength,
+			EdgeGamma:         sf.EdgeGamma,
+			PaperBleed:        sf.PaperBleed,
+			TextureJitter:     sf.TextureJitter,
+			AnisotropicBlur:   sf.AnisotropicBlur,
+			TextureScale:      sf.TextureScale,
+			TextureSmooth:     sf.TextureSmooth,
+		}
+		if s.TextureFile == "" {
+			return Params{}, fmt.Errorf("style %q: missing texture", layer)
+		}
+		if err := (mask.NoiseFalloff{Curve: s.NoiseFalloff, Exponent: s.NoiseFalloffExp}).Validate(); err != nil {
+			return Params{}, fmt.Errorf("style %q: %w", layer, err)
+		}
+		if s.TextureScale < 0 {
+			return Params{}, fmt.Errorf("style %q: texture_scale must not be negative, got %v", layer, s.TextureScale)
+		}
+		if sf.Tint != "" {
+			c, err := parseHexColor(sf.Tint)
+			if err != nil {
+				return Params{}, fmt.Errorf("style %q: tint: %w", layer, err)
+			}
+			s.Tint = &c
+		}
+		if sf.EdgeTint != "" {
+			c, err := parseHexColor(sf.EdgeTint)
+			if err != nil {
+				return Params{}, fmt.Errorf("style %q: edge_tint: %w", layer, err)
+			}
+			s.EdgeTint = &c
+		}
+		if sf.Outline != nil {
+			c, err := parseHexColor(sf.Outline.Color)
+			if err != nil {
+				return Params{}, fmt.Errorf("style %q: outline color: %w", layer, err)
+			}
+			s.Outline = &Outline{Color: c, WidthPx: sf.Outline.WidthPx, Strength: sf.Outline.Strength}
+		}
+		if sf.DepthRamp != nil {
+			shallow, err := parseHexColor(sf.DepthRamp.Shallow)
+			if err != nil {
+				return Params{}, fmt.Errorf("style %q: depth_ramp shallow: %w", layer, err)
+			}
+			deep, err := parseHexColor(sf.DepthRamp.Deep)
+			if err != nil {
+				return Params{}, fmt.Errorf("style %q: depth_ramp deep: %w", layer, err)
+			}
+			s.DepthRamp = &WaterDepthRamp{Shallow: shallow, Deep: deep, MaxDistPx: sf.DepthRamp.MaxDistPx}
+		}
+		p.Styles[layer] = s
+	}
+	if len(p.CompositeOrder) > 0 {
+		if _, err := CheckCompositeOrder(p.CompositeOrder, p.Styles); err != nil {
+			return Params{}, err
+		}
+	}
+	return p, nil
+}
+
+// formatHexColor formats c as #rrggbb, or #rrggbbaa when it is not opaque.
+func formatHexColor(c color.NRGBA) string {
+	if c.A == 255 {
+		return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+	}
+	return fmt.Sprintf("#%02x%02x%02x%02x", c.R, c.G, c.B, c.A)
+}
+
+// parseHexColor parses #rrggbb or #rrggbbaa.
+func parseHexColor(s string) (color.NRGBA, error) {
+	hex := strings.TrimPrefix(strings.TrimSpace(s), "#")
+	c := color.NRGBA{A: 255}
+	var err error
+	switch len(hex) {
+	case 6:
+		_, err = fmt.Sscanf(hex, "%02x%02x%02x", &c.R, &c.G, &c.B)
+	case 8:
+		_, err = fmt.Sscanf(hex, "%02x%02x%02x%02x", &c.R, &c.G, &c.B, &c.A)
+	default:
+		err = fmt.Errorf("expected #rrggbb or #rrggbbaa")
+	}
+	if err != nil {
+		return color.NRGBA{}, fmt.Errorf("invalid color %q: %w", s, err)
+	}
+	return c, nil
+}
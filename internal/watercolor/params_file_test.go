@@ -0,0 +1,137 @@
+package watercolor
+
+import (
+	"image/color"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/MeKo-Tech/watercolormap/internal/geojson"
+)
+
+func TestSaveLoadParamsRoundTrip(t *testing.T) {
+	want := DefaultParams(0, 0, nil)
+	want.NoiseOctaves = 5
+	want.NoisePersistence = 0.6
+	want.DisableAntialias = true
+	want.ShoreBlendPx = 6
+	water := want.Styles[geojson.LayerWater]
+	water.Outline = &Outline{Color: color.NRGBA{R: 20, G: 30, B: 60, A: 200}, WidthPx: 2, Strength: 0.6}
+	water.AutoThreshold = true
+	water.MinFeatureAreaPx = 6
+	water.TextureScale = 4
+	water.TextureSmooth = true
+	water.AnisotropicBlur = true
+	water.DepthRamp = &WaterDepthRamp{Shallow: color.NRGBA{R: 240, G: 250, B: 255, A: 255}, Deep: color.NRGBA{R: 90, G: 130, B: 200, A: 255}, MaxDistPx: 40}
+	want.Styles[geojson.LayerWater] = water
+	want.CompositeOrder = []geojson.LayerType{
+		geojson.LayerLand, geojson.LayerRoads, geojson.LayerHighways, geojson.LayerParks, geojson.LayerForest,
+		geojson.LayerRivers, geojson.LayerWater, geojson.LayerBuildings, geojson.LayerUrban,
+	}
+
+	for _, name := range []string{"params.yaml", "params.toml"} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			if err := SaveParams(path, want); err != nil {
+				t.Fatalf("SaveParams: %v", err)
+			}
+
+			got, err := LoadParams(path)
+			if err != nil {
+				t.Fatalf("LoadParams: %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("round trip mismatch:\n got  %+v\n want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestLoadParamsPartialOverride(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "params.yaml")
+	data := `noise_strength: 0.5
+styles:
+  water:
+    mask_threshold: 150
+    edge_tint: "#102030"
+`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := LoadParams(path)
+	if err != nil {
+		t.Fatalf("LoadParams: %v", err)
+	}
+	defaults := DefaultParams(0, 0, nil)
+
+	if got.NoiseStrength != 0.5 {
+		t.Errorf("NoiseStrength = %v, want 0.5", got.NoiseStrength)
+	}
+	if got.BlurSigma != defaults.BlurSigma || got.Threshold != defaults.Threshold {
+		t.Errorf("global defaults not kept: blur %v threshold %d", got.BlurSigma, got.Threshold)
+	}
+
+	water := got.Styles[geojson.LayerWater]
+	if water.MaskThreshold == nil || *water.MaskThreshold != 150 {
+		t.Errorf("water mask threshold = %v, want 150", water.MaskThreshold)
+	}
+	if water.EdgeTint == nil || *water.EdgeTint != (color.NRGBA{R: 0x10, G: 0x20, B: 0x30, A: 255}) {
+		t.Errorf("water edge tint = %v, want #102030", water.EdgeTint)
+	}
+	defWater := defaults.Styles[geojson.LayerWater]
+	if water.TextureFile != defWater.TextureFile || water.EdgeStrength != defWater.EdgeStrength {
+		t.Errorf("water defaults not kept: texture %q edge strength %v", water.TextureFile, water.EdgeStrength)
+	}
+
+	if !reflect.DeepEqual(got.Styles[geojson.LayerLand], defaults.Styles[geojson.LayerLand]) {
+		t.Errorf("untouched land style changed: %+v", got.Styles[geojson.LayerLand])
+	}
+}
+
+func TestLoadParamsInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{"bad color", "styles:\n  water:\n    edge_tint: blue\n", "edge_tint"},
+		{"bad tint", "styles:\n  forest:\n    tint: \"#12\"\n", "tint"},
+		{"new layer without texture", "styles:\n  glaciers:\n    edge_strength: 0.2\n", "missing texture"},
+		{"zero noise scale", "noise_scale: 0\n", "noise_scale"},
+		{"too many noise octaves", "noise_octaves: 20\n", "noise_octaves"},
+		{"noise persistence above 1", "noise_persistence: 1.5\n", "noise_persistence"},
+		{"unknown noise falloff", "styles:\n  roads:\n    noise_falloff: cubic\n", "noise falloff"},
+		{"negative texture scale", "styles:\n  land:\n    texture_scale: -2\n", "texture_scale"},
+		{"incomplete composite order", "composite_order: [land, water]\n", "missing layers buildings, forest"},
+		{"duplicate composite layer", "composite_order: [land, land]\n", "twice"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "params.yaml")
+			if err := os.WriteFile(path, []byte(tt.data), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := LoadParams(path)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("LoadParams error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParamsHash(t *testing.T) {
+	base := DefaultParams(256, 1337, nil)
+	if base.Hash() != DefaultParams(512, 42, nil).Hash() {
+		t.Error("hash depends on tile size or seed")
+	}
+
+	changed := DefaultParams(256, 1337, nil)
+	changed.NoiseStrength += 0.1
+	if base.Hash() == changed.Hash() {
+		t.Error("hash ignores noise_strength")
+	}
+}
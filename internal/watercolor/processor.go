@@ -4,6 +4,9 @@ import (
 	"errors"
 	"fmt"
 	"image"
+	"image/color"
+	"math"
+	"slices"
 
 	"github.com/MeKo-Tech/watercolormap/internal/geojson"
 	"github.com/MeKo-Tech/watercolormap/internal/mask"
@@ -11,46 +14,66 @@ import (
 )
 
 // ProcessorContext holds reusable buffers for watercolor processing.
-// Reusing these buffers across multiple calls significantly reduces allocations.
+// Reusing these buffers across multiple calls significantly reduces allocations. Painted
+// results are copied out of the buffers, so they stay valid after the context is reused,
+// but a context must not be used by more than one goroutine at a time: give each worker
+// its own (see the WithContext variants of the Paint functions).
 type ProcessorContext struct {
 	distCtx   *mask.DistanceContext
 	tiledTex  *image.NRGBA // buffer for tiled texture
 	painted   *image.NRGBA // buffer for painted result
 	tempNRGBA *image.NRGBA // temporary NRGBA buffer for edge operations
 	tempGray  *image.Gray  // temporary Gray buffer for inverted mask
-	tileSize  int          // current buffer size
+	width     int          // current buffer width
+	height    int          // current buffer height
 }
 
 // NewProcessorContext creates a context sized for the given tile size.
 func NewProcessorContext(tileSize int) *ProcessorContext {
-	bounds := image.Rect(0, 0, tileSize, tileSize)
+	return newProcessorContextSize(tileSize, tileSize)
+}
+
+func newProcessorContextSize(width, height int) *ProcessorContext {
+	bounds := image.Rect(0, 0, width, height)
 	return &ProcessorContext{
-		distCtx:   mask.NewDistanceContext(tileSize),
+		distCtx:   mask.NewDistanceContext(max(width, height)),
 		tiledTex:  image.NewNRGBA(bounds),
 		painted:   image.NewNRGBA(bounds),
 		tempNRGBA: image.NewNRGBA(bounds),
 		tempGray:  image.NewGray(bounds),
-		tileSize:  tileSize,
+		width:     width,
+		height:    height,
 	}
 }
 
 // EnsureCapacity grows buffers if needed for the given tile size.
 func (c *ProcessorContext) EnsureCapacity(tileSize int) {
-	if tileSize <= c.tileSize {
+	if tileSize <= c.width && tileSize <= c.height {
+		return
+	}
+	c.ensureSize(tileSize, tileSize)
+}
+
+// ensureSize resizes the buffers to exactly width×height, since painted results
+// take their bounds from them.
+func (c *ProcessorContext) ensureSize(width, height int) {
+	if width == c.width && height == c.height {
 		return
 	}
-	bounds := image.Rect(0, 0, tileSize, tileSize)
-	c.distCtx.EnsureCapacity(tileSize, tileSize)
+	bounds := image.Rect(0, 0, width, height)
+	c.distCtx.EnsureCapacity(width, height)
 	c.tiledTex = image.NewNRGBA(bounds)
 	c.painted = image.NewNRGBA(bounds)
 	c.tempNRGBA = image.NewNRGBA(bounds)
 	c.tempGray = image.NewGray(bounds)
-	c.tileSize = tileSize
+	c.width = width
+	c.height = height
 }
 
 // LayerStyle defines per-layer watercolor styling parameters.
 type LayerStyle struct {
 	Texture           image.Image
+	TextureFile       string // Texture file name (e.g. "water.png") that Texture was loaded from; references it in params files
 	Layer             geojson.LayerType
 	EdgeStrength      float64
 	MaskNoiseStrength float64
@@ -58,27 +81,76 @@ type LayerStyle struct {
 	EdgeGamma         float64
 	NoiseMinDist      float64 // Distance below which noise is minimal (for adaptive noise)
 	NoiseMaxDist      float64 // Distance above which noise is at full strength (for adaptive noise)
+	NoiseFalloff      string  // Adaptive noise ramp between NoiseMinDist and NoiseMaxDist: "smoothstep" (default), "linear" or "power"
+	NoiseFalloffExp   float64 // Exponent of the "power" falloff (0 = 2)
 	MaskBlurSigma     float32
 	ShadeSigma        float32
 	EdgeSigma         float32
-	MaskThreshold     *uint8 // Optional per-layer threshold override (if nil, uses global Params.Threshold)
-	InvertMask        bool   // If true, invert the mask after threshold (used for land = invert of non-land)
-	AdaptiveNoise     bool   // If true, scale noise based on feature distance (protects thin structures)
+	MaskThreshold     *uint8          // Optional per-layer threshold override (if nil, uses global Params.Threshold)
+	AutoThreshold     bool            // If true, pick the threshold from the blurred mask's histogram (Otsu), falling back to MaskThreshold/Threshold
+	InvertMask        bool            // If true, invert the mask after threshold (used for land = invert of non-land)
+	AdaptiveNoise     bool            // If true, scale noise based on feature distance (protects thin structures)
+	AnisotropicBlur   bool            // If true, blur along linear features and barely across them so thin rivers/roads stay connected (see mask.DirectionalBlur)
+	Tint              *color.NRGBA    // Optional glaze multiplied onto the texture, e.g. to derive a deeper green from the park texture (nil = off)
+	EdgeTint          *color.NRGBA    // Optional pigment color edges darken toward (nil = neutral HSL darkening)
+	AntialiasWidth    *uint8          // Optional per-layer threshold transition width override (0 = hard edge)
+	MinFeatureAreaPx  int             // Drop isolated mask specks smaller than this many pixels after thresholding (0 = off)
+	Outline           *Outline        // Optional ink outline traced along the layer's edges (nil = off)
+	PaperBleed        float64         // Fraction (0.0-1.0) the wash fades toward the paper texture when composited (thin pigment; 0 = off)
+	TextureJitter     bool            // If true, domain-warp texture lookups so small textures don't repeat on a visible grid
+	TextureScale      float64         // Magnification of the texture in pixels per texel, e.g. to stretch a small texture (0 or 1 = one texel per pixel; ignored with TextureJitter)
+	TextureSmooth     bool            // If true, sample the magnified texture bilinearly instead of repeating each texel as a block (see TextureScale)
+	DepthRamp         *WaterDepthRamp // Optional shore-to-interior color ramp, e.g. for water depth (nil = off)
+}
+
+// Outline describes a thin ink stroke painted over a layer's edges for a hand-drawn look,
+// e.g. along coastlines or roads.
+type Outline struct {
+	Color    color.NRGBA // Ink color
+	WidthPx  int         // Stroke width in pixels, centered on the edge
+	Strength float64     // Ink opacity (0.0-1.0); the stroke is meant to be subtle, e.g. 0.3
+}
+
+// WaterDepthRamp shades a layer by distance from its edge, e.g. lighter water at the shore
+// and deeper blue toward the middle of a lake. Both colors are multiplied onto the wash like
+// glazes, so white leaves it unchanged.
+type WaterDepthRamp struct {
+	Shallow   color.NRGBA // Glaze at the edge
+	Deep      color.NRGBA // Glaze at MaxDistPx from the edge and beyond
+	MaxDistPx float64     // Distance in pixels over which the ramp runs from Shallow to Deep
 }
 
 // Params define the common watercolor processing knobs.
 type Params struct {
-	Styles         map[geojson.LayerType]LayerStyle
-	TileSize       int
-	NoiseScale     float64
-	NoiseStrength  float64
-	Seed           int64
-	OffsetX        int
-	OffsetY        int
-	BlurSigma      float32
-	AntialiasSigma float32
-	Threshold      uint8
-	PerlinNoise    *image.Gray // Pre-generated noise texture, reused across all layers to avoid redundant allocations
+	Styles           map[geojson.LayerType]LayerStyle
+	TileSize         int
+	TileHeight       int // Canvas height when it differs from TileSize (the width); 0 = square
+	NoiseScale       float64
+	NoiseStrength    float64
+	NoiseOctaves     int     // Perlin octaves of the noise field: more give finer detail, fewer a smoother wobble (0 = mask.DefaultNoiseOctaves)
+	NoisePersistence float64 // Amplitude of each noise octave relative to the previous one (0 = mask.DefaultNoisePersistence)
+	Seed             int64
+	OffsetX          int
+	OffsetY          int
+	BlurSigma        float32
+	AntialiasSigma   float32
+	Threshold        uint8
+	PerlinNoise      *image.Gray         // Pre-generated noise texture, reused across all layers to avoid redundant allocations
+	NoiseSeedMode    string              // NoiseSeedGlobal (default when empty), NoiseSeedPerTile or NoiseSeedRegion
+	NoiseDownscale   int                 // If >1, generate noise at 1/NoiseDownscale resolution and upscale bilinearly
+	NoiseCache       *mask.NoiseCache    // Optional noise slab cache shared across tiles (nil = generate every field)
+	AntialiasWidth   *uint8              // Threshold transition width in gray levels (nil = mask.DefaultAntialiasWidth)
+	DisableAntialias bool                // Hard threshold for every layer, overriding all transition widths: binary masks for debugging the mask pipeline
+	ShoreBlendPx     float64             // Half-width in pixels of the band where water fades into the land painted beneath it (0 = hard shoreline)
+	CompositeOrder   []geojson.LayerType // Back-to-front layer order (nil = DefaultCompositeOrder, see Order)
+}
+
+// Size returns the canvas width and height in pixels.
+func (p Params) Size() (int, int) {
+	if p.TileHeight > 0 {
+		return p.TileSize, p.TileHeight
+	}
+	return p.TileSize, p.TileSize
 }
 
 // ZoomAdjustedBlurSigma returns blur sigma adjusted for zoom level.
@@ -96,24 +168,52 @@ func ZoomAdjustedBlurSigma(baseBlurSigma float32, zoom int) float32 {
 	return baseBlurSigma
 }
 
+// ZoomAdjustedAntialiasWidth returns the threshold transition width adjusted for zoom level,
+// following the same bands as ZoomAdjustedBlurSigma: softer coastlines at overview zooms,
+// crisper ones at detail zooms.
+func ZoomAdjustedAntialiasWidth(baseWidth uint8, zoom int) uint8 {
+	if zoom <= 11 {
+		return uint8(min(255, (int(baseWidth)*14+5)/10))
+	} else if zoom >= 14 {
+		return uint8((int(baseWidth)*7 + 5) / 10)
+	}
+	return baseWidth
+}
+
 // ptr is a helper to create uint8 pointers for optional threshold values.
 func ptr(v uint8) *uint8 { return &v }
 
+// colorPtr is a helper to create color pointers for optional edge tints.
+func colorPtr(c color.NRGBA) *color.NRGBA { return &c }
+
+// deepWaterTint is the pigment water edges darken toward (instead of neutral gray).
+var deepWaterTint = color.NRGBA{R: 30, G: 70, B: 130, A: 255}
+
+// forestTint glazes the park texture into the deeper, cooler green of forests and woods.
+var forestTint = color.NRGBA{R: 160, G: 190, B: 180, A: 255}
+
+// forestEdgeTint is the pigment forest edges darken toward.
+var forestEdgeTint = color.NRGBA{R: 30, G: 70, B: 55, A: 255}
+
 // DefaultParams returns sensible defaults for the watercolor pipeline.
 // textures provides base textures per layer; caller may omit entries for layers they won't process.
 func DefaultParams(tileSize int, seed int64, textures map[geojson.LayerType]image.Image) Params {
 	return Params{
-		TileSize:       tileSize,
-		BlurSigma:      1.2,
-		NoiseScale:     30.0,
-		NoiseStrength:  0.28,
-		Threshold:      50,
-		AntialiasSigma: 0.5,
-		Seed:           seed,
+		TileSize:         tileSize,
+		BlurSigma:        1.2,
+		NoiseScale:       30.0,
+		NoiseStrength:    0.28,
+		NoiseOctaves:     mask.DefaultNoiseOctaves,
+		NoisePersistence: mask.DefaultNoisePersistence,
+		Threshold:        50,
+		AntialiasSigma:   0.5,
+		Seed:             seed,
+		CompositeOrder:   slices.Clone(DefaultCompositeOrder),
 		Styles: map[geojson.LayerType]LayerStyle{
 			geojson.LayerLand: {
 				Layer:         geojson.LayerLand,
 				Texture:       textures[geojson.LayerLand],
+				TextureFile:   texture.DefaultLayerTextures[geojson.LayerLand],
 				ShadeSigma:    3.5,
 				ShadeStrength: 0.12,
 				EdgeStrength:  0.3,  // Match old generator shadow strength
@@ -124,6 +224,7 @@ func DefaultParams(tileSize int, seed int64, textures map[geojson.LayerType]imag
 			geojson.LayerWater: {
 				Layer:             geojson.LayerWater,
 				Texture:           textures[geojson.LayerWater],
+				TextureFile:       texture.DefaultLayerTextures[geojson.LayerWater],
 				MaskBlurSigma:     0.9,  // Moderate blur for subtle softening
 				MaskNoiseStrength: 0.18, // Moderate noise for organic edges
 				AdaptiveNoise:     true, // Protect thin roads from fragmentation
@@ -134,26 +235,30 @@ func DefaultParams(tileSize int, seed int64, textures map[geojson.LayerType]imag
 				EdgeStrength:      0.2,
 				EdgeSigma:         3.5,
 				EdgeGamma:         9.3,
+				EdgeTint:          colorPtr(deepWaterTint), // Darken toward deep blue, not gray
 				MaskThreshold:     ptr(144),
 			},
 			geojson.LayerRivers: {
 				Layer:             geojson.LayerRivers,
 				Texture:           textures[geojson.LayerWater], // Use same texture as water
-				MaskThreshold:     ptr(98),                      // Balanced threshold for rivers
-				MaskBlurSigma:     0.7,                          // Light blur for natural edges
-				MaskNoiseStrength: 0.15,                         // Subtle noise for organic feel
-				AdaptiveNoise:     true,                         // Protect narrow streams from fragmentation
-				NoiseMinDist:      2.0,                          // Minimal noise below 2px from edge
-				NoiseMaxDist:      10.0,                         // Full noise above 10px from edge
+				TextureFile:       texture.DefaultLayerTextures[geojson.LayerWater],
+				MaskThreshold:     ptr(98), // Balanced threshold for rivers
+				MaskBlurSigma:     0.7,     // Light blur for natural edges
+				MaskNoiseStrength: 0.15,    // Subtle noise for organic feel
+				AdaptiveNoise:     true,    // Protect narrow streams from fragmentation
+				NoiseMinDist:      2.0,     // Minimal noise below 2px from edge
+				NoiseMaxDist:      10.0,    // Full noise above 10px from edge
 				ShadeSigma:        0,
 				ShadeStrength:     0,
 				EdgeStrength:      0.2,
 				EdgeSigma:         2.5,
 				EdgeGamma:         9.3,
+				EdgeTint:          colorPtr(deepWaterTint),
 			},
 			geojson.LayerParks: {
 				Layer:         geojson.LayerParks,
 				Texture:       textures[geojson.LayerParks],
+				TextureFile:   texture.DefaultLayerTextures[geojson.LayerParks],
 				MaskThreshold: ptr(120), // Higher threshold for layers after land
 				ShadeSigma:    0,
 				ShadeStrength: 0,
@@ -161,9 +266,25 @@ func DefaultParams(tileSize int, seed int64, textures map[geojson.LayerType]imag
 				EdgeSigma:     3.0,
 				EdgeGamma:     8.6,
 			},
+			// Forests are painted over parks with the same texture, glazed deeper and cooler.
+			// Without this style, parks alone show all greens in one tint.
+			geojson.LayerForest: {
+				Layer:         geojson.LayerForest,
+				Texture:       textures[geojson.LayerParks], // Use same texture as parks
+				TextureFile:   texture.DefaultLayerTextures[geojson.LayerParks],
+				Tint:          colorPtr(forestTint),
+				MaskThreshold: ptr(120), // Same as parks so shared edges line up
+				ShadeSigma:    0,
+				ShadeStrength: 0,
+				EdgeStrength:  0.25,
+				EdgeSigma:     3.0,
+				EdgeGamma:     8.6,
+				EdgeTint:      colorPtr(forestEdgeTint),
+			},
 			geojson.LayerRoads: {
 				Layer:             geojson.LayerRoads,
 				Texture:           textures[geojson.LayerRoads],
+				TextureFile:       texture.DefaultLayerTextures[geojson.LayerRoads],
 				MaskThreshold:     ptr(100), // Balanced threshold for roads
 				MaskBlurSigma:     0.9,      // Moderate blur for subtle softening
 				MaskNoiseStrength: 0.18,     // Moderate noise for organic edges
@@ -179,6 +300,7 @@ func DefaultParams(tileSize int, seed int64, textures map[geojson.LayerType]imag
 			geojson.LayerHighways: {
 				Layer:             geojson.LayerHighways,
 				Texture:           textures[geojson.LayerHighways],
+				TextureFile:       texture.DefaultLayerTextures[geojson.LayerHighways],
 				MaskThreshold:     ptr(120), // Higher threshold for layers after land
 				MaskBlurSigma:     1.1,
 				MaskNoiseStrength: 0.18,
@@ -194,6 +316,7 @@ func DefaultParams(tileSize int, seed int64, textures map[geojson.LayerType]imag
 			geojson.LayerUrban: {
 				Layer:         geojson.LayerUrban,
 				Texture:       textures[geojson.LayerUrban],
+				TextureFile:   texture.DefaultLayerTextures[geojson.LayerUrban],
 				MaskThreshold: ptr(160),
 				ShadeSigma:    0,
 				ShadeStrength: 0,
@@ -204,7 +327,8 @@ func DefaultParams(tileSize int, seed int64, textures map[geojson.LayerType]imag
 			geojson.LayerBuildings: {
 				Layer:         geojson.LayerBuildings,
 				Texture:       textures[geojson.LayerUrban], // Use same texture as urban
-				MaskThreshold: ptr(150),                     // Higher threshold for layers after land
+				TextureFile:   texture.DefaultLayerTextures[geojson.LayerUrban],
+				MaskThreshold: ptr(150), // Higher threshold for layers after land
 				ShadeSigma:    0,
 				ShadeStrength: 0,
 				EdgeStrength:  0.2,
@@ -215,7 +339,18 @@ func DefaultParams(tileSize int, seed int64, textures map[geojson.LayerType]imag
 	}
 }
 
+// anisotropicAcrossRatio is the blur across linear features relative to the blur along them
+// for LayerStyle.AnisotropicBlur: enough to soften the antialiased outline, too little to
+// fade a line a few pixels wide below the threshold.
+const anisotropicAcrossRatio = 0.25
+
 func processMask(baseMask *image.Gray, layer geojson.LayerType, params Params) (*image.Gray, error) {
+	return runMaskPipeline(baseMask, layer, params, true)
+}
+
+// runMaskPipeline is processMask; skipEmpty enables the shortcut for empty base masks, which
+// tests disable to compare it with the full pipeline.
+func runMaskPipeline(baseMask *image.Gray, layer geojson.LayerType, params Params, skipEmpty bool) (*image.Gray, error) {
 	if baseMask == nil {
 		return nil, errors.New("base mask is nil")
 	}
@@ -240,7 +375,39 @@ func processMask(baseMask *image.Gray, layer geojson.LayerType, params Params) (
 		threshold = *style.MaskThreshold
 	}
 
-	blurred := mask.BoxBlurSigma(baseMask, layerBlur)
+	// Use per-layer transition width if specified, then the zoom-derived one, then the default
+	aaWidth := mask.DefaultAntialiasWidth
+	if style.AntialiasWidth != nil {
+		aaWidth = *style.AntialiasWidth
+	} else if params.AntialiasWidth != nil {
+		aaWidth = *params.AntialiasWidth
+	}
+	if params.DisableAntialias {
+		// A zero width is the plain (inverted) hard threshold
+		aaWidth = 0
+	}
+
+	// Layers absent from the tile (e.g. water inland) skip the blur and noise passes
+	if skipEmpty && mask.IsEmpty(baseMask) {
+		if finalMask, ok := emptyFinalMask(baseMask.Bounds(), layerNoiseStrength, threshold, aaWidth, style.InvertMask); ok {
+			return finalMask, nil
+		}
+	}
+
+	var blurred *image.Gray
+	if style.AnisotropicBlur && layerBlur > 0 {
+		angles := mask.OrientationField(baseMask, layerBlur)
+		blurred = mask.DirectionalBlur(baseMask, angles, layerBlur, layerBlur*anisotropicAcrossRatio)
+	} else {
+		blurred = mask.BoxBlurSigma(baseMask, layerBlur)
+	}
+	if style.AutoThreshold {
+		// Split at the valley between the feature and background peaks of the blurred mask;
+		// masks with a single gray level (empty or fully covered) keep the fixed threshold
+		if t, ok := mask.OtsuThreshold(blurred); ok {
+			threshold = t
+		}
+	}
 	noisy := blurred
 	if layerNoiseStrength != 0 {
 		if style.AdaptiveNoise && style.NoiseMaxDist > 0 {
@@ -249,7 +416,8 @@ func processMask(baseMask *image.Gray, layer geojson.LayerType, params Params) (
 			binaryMask := mask.ApplyThreshold(blurred, threshold)
 			distMap := mask.EuclideanDistanceTransform(binaryMask, style.NoiseMaxDist)
 			noisy = mask.ApplyNoiseToMaskAdaptive(blurred, params.PerlinNoise, distMap,
-				layerNoiseStrength, style.NoiseMinDist, style.NoiseMaxDist)
+				layerNoiseStrength, style.NoiseMinDist, style.NoiseMaxDist,
+				mask.NoiseFalloff{Curve: style.NoiseFalloff, Exponent: style.NoiseFalloffExp})
 		} else {
 			noisy = mask.ApplyNoiseToMask(blurred, params.PerlinNoise, layerNoiseStrength)
 		}
@@ -258,21 +426,66 @@ func processMask(baseMask *image.Gray, layer geojson.LayerType, params Params) (
 	// Apply threshold with antialiasing, optionally inverting (for land = invert of non-land)
 	var finalMask *image.Gray
 	if style.InvertMask {
-		finalMask = mask.ApplyThresholdWithAntialiasAndInvert(noisy, threshold)
+		finalMask = mask.ApplyThresholdWithAntialiasAndInvertWidth(noisy, threshold, aaWidth)
 	} else {
-		finalMask = mask.ApplyThresholdWithAntialias(noisy, threshold)
+		finalMask = mask.ApplyThresholdWithAntialiasWidth(noisy, threshold, aaWidth)
+	}
+	if style.MinFeatureAreaPx > 0 {
+		finalMask = mask.RemoveSmallComponents(finalMask, style.MinFeatureAreaPx)
 	}
 
 	return finalMask, nil
 }
 
-func paintFromFinalMask(finalMask *image.Gray, layer geojson.LayerType, params Params) (*image.NRGBA, error) {
-	// Create a temporary context for this call
-	ctx := NewProcessorContext(params.TileSize)
-	return paintFromFinalMaskWithContext(finalMask, layer, params, ctx)
+// emptyFinalMask returns the final mask processMask produces for an empty base mask, if it is
+// uniform. Blurring keeps the mask at 0 and the noise lifts it by at most 128·|strength| gray
+// levels; when the antialiased threshold maps both 0 and that bound to the same value, it maps
+// every level in between to it too, and the whole mask is that value. Otherwise ok is false and
+// the full pipeline has to run.
+func emptyFinalMask(bounds image.Rectangle, noiseStrength float64, threshold, aaWidth uint8, invert bool) (*image.Gray, bool) {
+	maxNoise := uint8(math.Min(255, math.Ceil(128*math.Abs(noiseStrength))))
+	probe := &image.Gray{Pix: []uint8{0, maxNoise}, Stride: 2, Rect: image.Rect(0, 0, 2, 1)}
+	var levels *image.Gray
+	if invert {
+		levels = mask.ApplyThresholdWithAntialiasAndInvertWidth(probe, threshold, aaWidth)
+	} else {
+		levels = mask.ApplyThresholdWithAntialiasWidth(probe, threshold, aaWidth)
+	}
+	if levels.Pix[0] != levels.Pix[1] {
+		return nil, false
+	}
+
+	out := image.NewGray(bounds)
+	if v := levels.Pix[0]; v != 0 {
+		for i := range out.Pix {
+			out.Pix[i] = v
+		}
+	}
+	return out, true
+}
+
+// paintFromFinalMask paints a layer from its final mask with the buffers of ctx, or of a
+// temporary context when ctx is nil. neighbors, when non-nil, is the coverage of adjacent
+// layers that own the edges they share with this one: the layer isn't edge-darkened where it
+// borders them (see PaintLayerFromMaskSharingEdges).
+func paintFromFinalMask(finalMask, neighbors *image.Gray, layer geojson.LayerType, params Params, ctx *ProcessorContext) (*image.NRGBA, error) {
+	// A layer without coverage paints nothing: skip the texture, shading and edge passes. The
+	// full path leaves every pixel at alpha 0 as well.
+	if finalMask != nil && params.TileSize > 0 && mask.IsEmpty(finalMask) {
+		if style, ok := params.Styles[layer]; ok && style.Texture != nil {
+			width, height := params.Size()
+			return image.NewNRGBA(image.Rect(0, 0, width, height)), nil
+		}
+	}
+
+	if ctx == nil {
+		// Create a temporary context for this call
+		ctx = newProcessorContextSize(params.Size())
+	}
+	return paintFromFinalMaskWithContext(finalMask, neighbors, layer, params, ctx)
 }
 
-func paintFromFinalMaskWithContext(finalMask *image.Gray, layer geojson.LayerType, params Params, ctx *ProcessorContext) (*image.NRGBA, error) {
+func paintFromFinalMaskWithContext(finalMask, neighbors *image.Gray, layer geojson.LayerType, params Params, ctx *ProcessorContext) (*image.NRGBA, error) {
 	style, ok := params.Styles[layer]
 	if !ok {
 		return nil, fmt.Errorf("missing style for layer %s", layer)
@@ -287,16 +500,34 @@ func paintFromFinalMaskWithContext(finalMask *image.Gray, layer geojson.LayerTyp
 		return nil, errors.New("final mask is nil")
 	}
 
-	// Ensure context has enough capacity
-	ctx.EnsureCapacity(params.TileSize)
+	// Ensure context buffers match the canvas
+	width, height := params.Size()
+	ctx.ensureSize(width, height)
 
 	// Texture + mask using pooled buffers
-	texture.TileTextureInto(style.Texture, params.TileSize, params.OffsetX, params.OffsetY, ctx.tiledTex)
+	if style.TextureJitter {
+		texture.TileTextureWarpedRectInto(style.Texture, width, height, params.OffsetX, params.OffsetY, params.Seed, ctx.tiledTex)
+	} else if style.TextureScale > 1 {
+		texture.TileTextureScaledRectInto(style.Texture, width, height, params.OffsetX, params.OffsetY, style.TextureScale, style.TextureSmooth, ctx.tiledTex)
+	} else {
+		texture.TileTextureRectInto(style.Texture, width, height, params.OffsetX, params.OffsetY, ctx.tiledTex)
+	}
 	texture.ApplyMaskToTextureInto(ctx.tiledTex, finalMask, ctx.painted)
 
 	// result points to the current result buffer; we'll swap between painted and tempNRGBA
 	result := ctx.painted
 
+	// Optional uniform glaze, e.g. forests painted with a darker version of the park texture
+	if style.Tint != nil {
+		mask.ApplyTintInto(result, *style.Tint, result)
+	}
+
+	// Optional depth shading: glaze from the shallow color at the edge to the deep one inside.
+	if r := style.DepthRamp; r != nil && r.MaxDistPx > 0 {
+		dist := mask.EuclideanDistanceTransformWithContext(finalMask, r.MaxDistPx, ctx.distCtx)
+		mask.ApplyDepthRampInto(result, dist, r.Shallow, r.Deep, result)
+	}
+
 	// Optional additional shading: blur the final mask further and apply a subtle darkening.
 	if style.ShadeSigma > 0 && style.ShadeStrength > 0 {
 		shade := mask.BoxBlurSigma(finalMask, style.ShadeSigma)
@@ -316,24 +547,49 @@ func paintFromFinalMaskWithContext(finalMask *image.Gray, layer geojson.LayerTyp
 		gamma = 1.0
 	}
 
-	edgeMask := mask.CreateDistanceEdgeMaskWithContext(finalMask, radius, gamma, ctx.distCtx)
+	// Edges shared with neighbors are left to them: measure the distance to the edge of the
+	// combined coverage, so pixels bordering a neighbor count as interior
+	edgeSource := finalMask
+	if neighbors != nil {
+		edgeSource = mask.MaxMasks(finalMask, neighbors)
+		if edgeSource == nil {
+			return nil, errors.New("neighbors mask bounds do not match the final mask")
+		}
+	}
+	edgeMask := mask.CreateDistanceEdgeMaskWithContext(edgeSource, radius, gamma, ctx.distCtx)
 	if edgeMask == nil {
 		return nil, errors.New("failed to create edge mask")
 	}
 	// ApplySoftEdgeMask expects: 255=no change, 0=maximum effect
 	// CreateDistanceEdgeMask produces: 255=no effect (center), 0=max effect (edges)
-	mask.ApplySoftEdgeMaskInto(result, edgeMask, style.EdgeStrength, ctx.tempNRGBA)
+	if style.EdgeTint != nil {
+		mask.ApplySoftEdgeMaskTintedInto(result, edgeMask, style.EdgeStrength, *style.EdgeTint, ctx.tempNRGBA)
+	} else {
+		mask.ApplySoftEdgeMaskInto(result, edgeMask, style.EdgeStrength, ctx.tempNRGBA)
+	}
 
 	// Return a copy since ctx.tempNRGBA will be reused
 	bounds := ctx.tempNRGBA.Bounds()
 	output := image.NewNRGBA(bounds)
 	copy(output.Pix, ctx.tempNRGBA.Pix)
 
+	// Optional ink outline on top of the finished wash
+	if o := style.Outline; o != nil && o.WidthPx > 0 && o.Strength > 0 {
+		band := mask.EdgeBand(finalMask, o.WidthPx)
+		mask.ApplyOutlineInto(output, band, o.Color, o.Strength, output)
+	}
+
 	return output, nil
 }
 
 // PaintLayer applies the watercolor pipeline to a single rendered layer image.
 func PaintLayer(layerImage image.Image, layer geojson.LayerType, params Params) (*image.NRGBA, error) {
+	return PaintLayerWithContext(layerImage, layer, params, nil)
+}
+
+// PaintLayerWithContext is like PaintLayer but paints with the buffers of ctx (nil = a
+// temporary context), e.g. one context per worker reused across its tiles.
+func PaintLayerWithContext(layerImage image.Image, layer geojson.LayerType, params Params, ctx *ProcessorContext) (*image.NRGBA, error) {
 	style, ok := params.Styles[layer]
 	if !ok {
 		return nil, fmt.Errorf("missing style for layer %s", layer)
@@ -354,19 +610,31 @@ func PaintLayer(layerImage image.Image, layer geojson.LayerType, params Params)
 	if err != nil {
 		return nil, err
 	}
-	return paintFromFinalMask(finalMask, layer, params)
+	return paintFromFinalMask(finalMask, nil, layer, params, ctx)
 }
 
 // PaintLayerFromMask runs the mask pipeline (blur/noise/threshold/AA) on a provided alpha mask,
 // then applies texture/tinting and edge/shading. This is used for cross-layer workflows.
 func PaintLayerFromMask(baseMask *image.Gray, layer geojson.LayerType, params Params) (*image.NRGBA, error) {
-	painted, _, err := PaintLayerFromMaskWithMask(baseMask, layer, params)
+	return PaintLayerFromMaskWithContext(baseMask, layer, params, nil)
+}
+
+// PaintLayerFromMaskWithContext is like PaintLayerFromMask but paints with the buffers of ctx
+// (nil = a temporary context).
+func PaintLayerFromMaskWithContext(baseMask *image.Gray, layer geojson.LayerType, params Params, ctx *ProcessorContext) (*image.NRGBA, error) {
+	painted, _, err := PaintLayerFromMaskWithMaskContext(baseMask, layer, params, ctx)
 	return painted, err
 }
 
 // PaintLayerFromMaskWithMask is like PaintLayerFromMask but also returns the processed final mask.
 // This is useful when the caller needs the mask for constraining other layers (e.g., land mask for parks).
 func PaintLayerFromMaskWithMask(baseMask *image.Gray, layer geojson.LayerType, params Params) (*image.NRGBA, *image.Gray, error) {
+	return PaintLayerFromMaskWithMaskContext(baseMask, layer, params, nil)
+}
+
+// PaintLayerFromMaskWithMaskContext is like PaintLayerFromMaskWithMask but paints with the
+// buffers of ctx (nil = a temporary context).
+func PaintLayerFromMaskWithMaskContext(baseMask *image.Gray, layer geojson.LayerType, params Params, ctx *ProcessorContext) (*image.NRGBA, *image.Gray, error) {
 	if params.NoiseScale <= 0 {
 		return nil, nil, errors.New("noise scale must be positive")
 	}
@@ -374,15 +642,49 @@ func PaintLayerFromMaskWithMask(baseMask *image.Gray, layer geojson.LayerType, p
 	if err != nil {
 		return nil, nil, err
 	}
-	painted, err := paintFromFinalMask(finalMask, layer, params)
+	painted, err := paintFromFinalMask(finalMask, nil, layer, params, ctx)
 	if err != nil {
 		return nil, nil, err
 	}
 	return painted, finalMask, nil
 }
 
+// PaintLayerFromMaskSharingEdges is like PaintLayerFromMask, but leaves the edges the layer
+// shares with its neighbors undarkened: neighbors is the coverage of adjacent layers painted
+// over this one, whose own edge darkening marks the boundary. Without it, both layers darken
+// their side of the boundary and the seam comes out twice as dark as either edge. A nil
+// neighbors mask paints like PaintLayerFromMask.
+func PaintLayerFromMaskSharingEdges(baseMask, neighbors *image.Gray, layer geojson.LayerType, params Params) (*image.NRGBA, error) {
+	return PaintLayerFromMaskSharingEdgesWithContext(baseMask, neighbors, layer, params, nil)
+}
+
+// PaintLayerFromMaskSharingEdgesWithContext is like PaintLayerFromMaskSharingEdges but paints
+// with the buffers of ctx (nil = a temporary context).
+func PaintLayerFromMaskSharingEdgesWithContext(baseMask, neighbors *image.Gray, layer geojson.LayerType, params Params, ctx *ProcessorContext) (*image.NRGBA, error) {
+	if params.NoiseScale <= 0 {
+		return nil, errors.New("noise scale must be positive")
+	}
+	finalMask, err := processMask(baseMask, layer, params)
+	if err != nil {
+		return nil, err
+	}
+	return paintFromFinalMask(finalMask, neighbors, layer, params, ctx)
+}
+
+// ProcessLayerMask runs the mask pipeline (blur/noise/threshold/AA) on a provided alpha mask
+// without painting it. This is used when a layer only serves to constrain other layers.
+func ProcessLayerMask(baseMask *image.Gray, layer geojson.LayerType, params Params) (*image.Gray, error) {
+	return processMask(baseMask, layer, params)
+}
+
 // PaintLayerFromFinalMask skips the blur/noise/threshold steps and paints directly from a final mask.
 // Useful when the final mask is derived from other layers (e.g. landMask = invert(nonLandMask)).
 func PaintLayerFromFinalMask(finalMask *image.Gray, layer geojson.LayerType, params Params) (*image.NRGBA, error) {
-	return paintFromFinalMask(finalMask, layer, params)
+	return PaintLayerFromFinalMaskWithContext(finalMask, layer, params, nil)
+}
+
+// PaintLayerFromFinalMaskWithContext is like PaintLayerFromFinalMask but paints with the
+// buffers of ctx (nil = a temporary context).
+func PaintLayerFromFinalMaskWithContext(finalMask *image.Gray, layer geojson.LayerType, params Params, ctx *ProcessorContext) (*image.NRGBA, error) {
+	return paintFromFinalMask(finalMask, nil, layer, params, ctx)
 }
@@ -96,3 +96,160 @@ func TestPaintLayerMissingStyle(t *testing.T) {
 		t.Fatal("expected error for missing style")
 	}
 }
+
+func TestZoomAdjustedAntialiasWidth(t *testing.T) {
+	tests := []struct {
+		zoom int
+		want uint8
+	}{
+		{zoom: 10, want: 28},
+		{zoom: 12, want: 20},
+		{zoom: 13, want: 20},
+		{zoom: 15, want: 14},
+	}
+	for _, tt := range tests {
+		if got := ZoomAdjustedAntialiasWidth(20, tt.zoom); got != tt.want {
+			t.Errorf("zoom %d: got %d, want %d", tt.zoom, got, tt.want)
+		}
+	}
+	if got := ZoomAdjustedAntialiasWidth(0, 10); got != 0 {
+		t.Errorf("zero width must stay hard at any zoom, got %d", got)
+	}
+}
+
+func TestProcessMaskAntialiasWidth(t *testing.T) {
+	base := image.NewGray(image.Rect(0, 0, 32, 32))
+	for y := 0; y < 32; y++ {
+		for x := 0; x < 16; x++ {
+			base.SetGray(x, y, color.Gray{Y: 255})
+		}
+	}
+
+	params := Params{
+		TileSize:  32,
+		BlurSigma: 2,
+		Threshold: 128,
+		Styles:    map[geojson.LayerType]LayerStyle{geojson.LayerWater: {Layer: geojson.LayerWater}},
+	}
+
+	countPartial := func(m *image.Gray) int {
+		n := 0
+		for _, v := range m.Pix {
+			if v != 0 && v != 255 {
+				n++
+			}
+		}
+		return n
+	}
+
+	soft, err := processMask(base, geojson.LayerWater, params)
+	if err != nil {
+		t.Fatalf("processMask failed: %v", err)
+	}
+	if countPartial(soft) == 0 {
+		t.Fatal("expected antialiased pixels with the default width")
+	}
+
+	hard := ptr(0)
+	params.Styles[geojson.LayerWater] = LayerStyle{Layer: geojson.LayerWater, AntialiasWidth: hard}
+	params.AntialiasWidth = ptr(40)
+	sharp, err := processMask(base, geojson.LayerWater, params)
+	if err != nil {
+		t.Fatalf("processMask failed: %v", err)
+	}
+	if n := countPartial(sharp); n != 0 {
+		t.Errorf("per-layer width 0 should override params and yield a hard edge, got %d partial pixels", n)
+	}
+}
+
+func TestProcessMaskDisableAntialias(t *testing.T) {
+	base := image.NewGray(image.Rect(0, 0, 32, 32))
+	for y := 0; y < 32; y++ {
+		for x := 0; x < 16; x++ {
+			base.SetGray(x, y, color.Gray{Y: 255})
+		}
+	}
+
+	params := Params{
+		TileSize:         32,
+		BlurSigma:        2,
+		Threshold:        128,
+		AntialiasWidth:   ptr(40),
+		DisableAntialias: true,
+		Styles: map[geojson.LayerType]LayerStyle{
+			geojson.LayerWater: {Layer: geojson.LayerWater, AntialiasWidth: ptr(60)},
+			geojson.LayerLand:  {Layer: geojson.LayerLand, InvertMask: true},
+		},
+	}
+
+	water, err := processMask(base, geojson.LayerWater, params)
+	if err != nil {
+		t.Fatalf("processMask failed: %v", err)
+	}
+	land, err := processMask(base, geojson.LayerLand, params)
+	if err != nil {
+		t.Fatalf("processMask failed: %v", err)
+	}
+
+	covered := 0
+	for i, v := range water.Pix {
+		if v != 0 && v != 255 {
+			t.Fatalf("pixel %d of the mask is %d, want 0 or 255", i, v)
+		}
+		// The inverted mask is the exact complement of the plain one
+		if land.Pix[i] != 255-v {
+			t.Fatalf("pixel %d: inverted mask %d, want %d", i, land.Pix[i], 255-v)
+		}
+		if v == 255 {
+			covered++
+		}
+	}
+	if covered == 0 || covered == len(water.Pix) {
+		t.Errorf("expected the mask to split the tile, %d of %d pixels covered", covered, len(water.Pix))
+	}
+}
+
+func TestAnisotropicBlurKeepsThinRiver(t *testing.T) {
+	// A stream about 1.4 px wide running diagonally
+	base := image.NewGray(image.Rect(0, 0, 96, 96))
+	for i := 8; i < 88; i++ {
+		base.Pix[i*base.Stride+i] = 255
+		base.Pix[i*base.Stride+i+1] = 255
+	}
+	params := Params{
+		TileSize:  96,
+		Threshold: 128,
+		Styles: map[geojson.LayerType]LayerStyle{
+			geojson.LayerRivers: {Layer: geojson.LayerRivers, MaskBlurSigma: 3, AntialiasWidth: ptr(0)},
+		},
+	}
+
+	covered := func(m *image.Gray) int {
+		n := 0
+		for i := 20; i < 76; i++ {
+			if m.Pix[i*m.Stride+i] == 255 {
+				n++
+			}
+		}
+		return n
+	}
+
+	isotropic, err := processMask(base, geojson.LayerRivers, params)
+	if err != nil {
+		t.Fatalf("processMask failed: %v", err)
+	}
+	style := params.Styles[geojson.LayerRivers]
+	style.AnisotropicBlur = true
+	params.Styles[geojson.LayerRivers] = style
+	anisotropic, err := processMask(base, geojson.LayerRivers, params)
+	if err != nil {
+		t.Fatalf("processMask failed: %v", err)
+	}
+
+	if n := covered(isotropic); n != 0 {
+		t.Errorf("expected the isotropic blur to wash the stream out, %d of 56 pixels survived", n)
+	}
+	if n := covered(anisotropic); n != 56 {
+		t.Errorf("expected the anisotropic blur to keep the stream, %d of 56 pixels survived", n)
+	}
+}
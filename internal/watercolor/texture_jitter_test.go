@@ -0,0 +1,78 @@
+package watercolor
+
+import (
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MeKo-Tech/watercolormap/internal/geojson"
+	"github.com/MeKo-Tech/watercolormap/internal/mask"
+)
+
+// TestTextureJitterGolden paints a large, featureless land area with a small texture, with
+// and without TextureJitter, and compares the jittered version against a golden (set
+// UPDATE_GOLDEN=1 to regenerate). Without jitter the 8px texture repeats on an exact grid.
+func TestTextureJitterGolden(t *testing.T) {
+	const (
+		tileSize = 256
+		texSize  = 8
+	)
+	goldenDir := filepath.Join("..", "..", "testdata", "golden", "watercolor-texture-jitter")
+	debugDir := filepath.Join("..", "..", "testdata", "output", "watercolor-texture-jitter")
+	update := os.Getenv("UPDATE_GOLDEN") == "1"
+
+	tex := image.NewNRGBA(image.Rect(0, 0, texSize, texSize))
+	for y := 0; y < texSize; y++ {
+		for x := 0; x < texSize; x++ {
+			v := uint8(200 + (x*7+y*13)%40)
+			tex.SetNRGBA(x, y, color.NRGBA{R: v, G: v - 20, B: v - 60, A: 255})
+		}
+	}
+
+	// Land is the inverse of the non-land layer, so an empty layer paints land everywhere
+	layerImg := image.NewRGBA(image.Rect(0, 0, tileSize, tileSize))
+	params := DefaultParams(tileSize, 1337, map[geojson.LayerType]image.Image{geojson.LayerLand: tex})
+	params.PerlinNoise = mask.GeneratePerlinNoiseWithOffset(tileSize, tileSize, params.NoiseScale, params.Seed, 0, 0)
+
+	plain, err := PaintLayer(layerImg, geojson.LayerLand, params)
+	if err != nil {
+		t.Fatalf("PaintLayer (plain) failed: %v", err)
+	}
+
+	style := params.Styles[geojson.LayerLand]
+	style.TextureJitter = true
+	params.Styles[geojson.LayerLand] = style
+	jittered, err := PaintLayer(layerImg, geojson.LayerLand, params)
+	if err != nil {
+		t.Fatalf("PaintLayer (jittered) failed: %v", err)
+	}
+
+	// Compare how often a pixel equals the one a texture width to its right, away from the edges
+	repeats := func(img *image.NRGBA) float64 {
+		same, total := 0, 0
+		for y := 32; y < tileSize-32; y++ {
+			for x := 32; x < tileSize-32-texSize; x++ {
+				total++
+				if img.NRGBAAt(x, y) == img.NRGBAAt(x+texSize, y) {
+					same++
+				}
+			}
+		}
+		return float64(same) / float64(total)
+	}
+	plainRepeats, jitteredRepeats := repeats(plain), repeats(jittered)
+	if jitteredRepeats >= plainRepeats/2 {
+		t.Errorf("expected jitter to at least halve exact repeats, got %.2f (plain %.2f)", jitteredRepeats, plainRepeats)
+	}
+
+	writeTestPNG(t, filepath.Join(debugDir, "land_plain.png"), plain)
+	writeTestPNG(t, filepath.Join(debugDir, "land_jittered.png"), jittered)
+	goldenPath := filepath.Join(goldenDir, "land_jittered.png")
+	if update {
+		writeTestPNG(t, goldenPath, jittered)
+		return
+	}
+	assertMatchesGolden(t, goldenPath, jittered)
+}
@@ -3,6 +3,8 @@ package worker
 
 import (
 	"context"
+	"iter"
+	"slices"
 	"sync"
 	"time"
 
@@ -15,11 +17,28 @@ type Generator interface {
 	Generate(ctx context.Context, coords tile.Coords, force bool, suffix string, debugCtx interface{}) (path string, layersDir string, err error)
 }
 
+// WorkerContexter is an optional extension of Generator for generators that keep state per
+// worker, such as reusable buffers. Each worker goroutine calls WorkerContext once and runs
+// all of its tasks with the returned context, so that state is never shared between
+// goroutines.
+type WorkerContexter interface {
+	WorkerContext(ctx context.Context) context.Context
+}
+
 // Task represents a single tile generation task.
 type Task struct {
 	Suffix string
 	Coords tile.Coords
 	Force  bool
+
+	// Tiles is the number of tiles the task renders, e.g. the tiles of a metatile block, so
+	// progress counts tiles rather than tasks. 0 counts as one tile.
+	Tiles int
+}
+
+// tiles returns the number of tiles t counts for in progress.
+func (t Task) tiles() int {
+	return max(t.Tiles, 1)
 }
 
 // Result represents the outcome of a tile generation task.
@@ -33,18 +52,44 @@ type Result struct {
 // ProgressFunc is called after each task completes.
 type ProgressFunc func(completed, total, failed int)
 
+// ProgressEvent reports the state of a Run after one of its tasks completed.
+type ProgressEvent struct {
+	LastErr    error       // Error of the task that just completed (nil on success)
+	LastCoords tile.Coords // Coordinates of the task that just completed
+	Completed  int         // Tiles completed so far, including failed ones (see Task.Tiles)
+	Total      int         // Tiles in the Run
+	Failed     int         // Tiles of failed tasks so far
+}
+
+// ProgressEventFunc receives the ProgressEvents of a Run, e.g. to drive a custom UI.
+type ProgressEventFunc func(ProgressEvent)
+
 // Config configures the worker pool.
 type Config struct {
-	Generator  Generator
+	Generator Generator
+
+	// OnEvent is called with a ProgressEvent after each task completes. Calls never overlap
+	// and arrive in completion order, so Completed counts up by the task's tiles per event.
+	OnEvent ProgressEventFunc
+
+	// OnProgress is a simpler alternative to OnEvent, called after it with the counts only.
 	OnProgress ProgressFunc
-	Workers    int
+
+	Workers int
+
+	// WorkersPerZoom optionally sets the worker count by zoom level. Each entry applies from
+	// its zoom up to the next configured zoom, e.g. {5: 1, 10: 4, 14: 8} runs z5-z9 with one
+	// worker, z10-z13 with four and z14+ with eight; zooms below the lowest entry use Workers.
+	// When set, tasks are grouped by zoom and each zoom runs to completion before the next.
+	WorkersPerZoom map[uint32]int
 }
 
 // Pool manages parallel tile generation.
 type Pool struct {
-	generator  Generator
-	onProgress ProgressFunc
-	workers    int
+	generator      Generator
+	onEvent        ProgressEventFunc
+	workersPerZoom map[uint32]int
+	workers        int
 }
 
 // New creates a new worker pool.
@@ -54,75 +99,174 @@ func New(cfg Config) *Pool {
 		workers = 1
 	}
 
+	onEvent := cfg.OnEvent
+	if onProgress := cfg.OnProgress; onProgress != nil {
+		next := onEvent
+		onEvent = func(e ProgressEvent) {
+			if next != nil {
+				next(e)
+			}
+			onProgress(e.Completed, e.Total, e.Failed)
+		}
+	}
+
 	return &Pool{
-		workers:    workers,
-		generator:  cfg.Generator,
-		onProgress: cfg.OnProgress,
+		workers:        workers,
+		workersPerZoom: cfg.WorkersPerZoom,
+		generator:      cfg.Generator,
+		onEvent:        onEvent,
+	}
+}
+
+// WorkersForZoom returns the number of workers used for tasks at zoom z.
+func (p *Pool) WorkersForZoom(z uint32) int {
+	workers := p.workers
+	best := -1
+	for zoom, n := range p.workersPerZoom {
+		if zoom <= z && int(zoom) > best && n > 0 {
+			best = int(zoom)
+			workers = n
+		}
+	}
+	return workers
+}
+
+// progressTracker counts completed tasks across all groups of a Run. Results are added from
+// one collector goroutine at a time, so events are delivered in order.
+type progressTracker struct {
+	onEvent   ProgressEventFunc
+	total     int
+	completed int
+	failed    int
+}
+
+func (pt *progressTracker) add(result Result) {
+	pt.completed += result.Task.tiles()
+	if result.Err != nil {
+		pt.failed += result.Task.tiles()
+	}
+
+	if pt.onEvent != nil {
+		pt.onEvent(ProgressEvent{
+			LastErr:    result.Err,
+			LastCoords: result.Task.Coords,
+			Completed:  pt.completed,
+			Total:      pt.total,
+			Failed:     pt.failed,
+		})
 	}
 }
 
 // Run executes all tasks and returns results.
-// Tasks are processed in parallel by the configured number of workers.
+// Tasks are processed in parallel by the configured number of workers (per zoom level when
+// WorkersPerZoom is set, in ascending zoom order).
 // The function blocks until all tasks complete or the context is cancelled.
 func (p *Pool) Run(ctx context.Context, tasks []Task) []Result {
 	if len(tasks) == 0 {
 		return nil
 	}
 
-	// Create channels
-	taskCh := make(chan Task, len(tasks))
-	resultCh := make(chan Result, len(tasks))
+	results := make([]Result, 0, len(tasks))
+	collect := func(r Result) { results = append(results, r) }
+	total := 0
+	for _, task := range tasks {
+		total += task.tiles()
+	}
+	tracker := &progressTracker{onEvent: p.onEvent, total: total}
+	if len(p.workersPerZoom) == 0 {
+		p.run(ctx, slices.Values(tasks), p.workers, tracker, collect)
+		return results
+	}
+
+	byZoom := make(map[uint32][]Task)
+	for _, task := range tasks {
+		byZoom[task.Coords.Z] = append(byZoom[task.Coords.Z], task)
+	}
+	zooms := make([]uint32, 0, len(byZoom))
+	for z := range byZoom {
+		zooms = append(zooms, z)
+	}
+	slices.Sort(zooms)
+
+	for _, z := range zooms {
+		p.run(ctx, slices.Values(byZoom[z]), p.WorkersForZoom(z), tracker, collect)
+	}
+	return results
+}
+
+// RunSeq is like Run, but pulls the tasks from a sequence as workers free up instead of
+// taking them as a slice, and hands each result to onResult instead of returning them all,
+// so a huge run (e.g. tile.TilesInBBoxIter over a continent) never holds all of its tasks or
+// results at once. onResult calls never overlap and arrive in completion order. total is the
+// number of tiles the sequence's tasks cover (see Task.Tiles), reported in
+// ProgressEvent.Total.
+//
+// With WorkersPerZoom, each run of consecutive tasks at the same zoom gets that zoom's worker
+// count; sequences sorted by zoom, like tile.TilesInBBoxIter, match Run.
+func (p *Pool) RunSeq(ctx context.Context, tasks iter.Seq[Task], total int, onResult func(Result)) {
+	tracker := &progressTracker{onEvent: p.onEvent, total: total}
+	if len(p.workersPerZoom) == 0 {
+		p.run(ctx, tasks, p.workers, tracker, onResult)
+		return
+	}
+
+	next, stop := iter.Pull(tasks)
+	defer stop()
 
-	// Track progress
-	var (
-		completed int
-		failed    int
-		mu        sync.Mutex
-	)
+	task, ok := next()
+	for ok {
+		z := task.Coords.Z
+		group := func(yield func(Task) bool) {
+			for ok && task.Coords.Z == z {
+				if !yield(task) {
+					return
+				}
+				task, ok = next()
+			}
+		}
+		p.run(ctx, group, p.WorkersForZoom(z), tracker, onResult)
+	}
+}
+
+// run processes tasks with the given number of workers and passes each result to onResult
+// (which may be nil) from a single collector goroutine.
+func (p *Pool) run(ctx context.Context, tasks iter.Seq[Task], workers int, tracker *progressTracker, onResult func(Result)) {
+	// Create channels. Tasks are fed as workers take them, so the sequence is consumed lazily.
+	taskCh := make(chan Task, workers)
+	resultCh := make(chan Result, workers)
 
 	// Start workers
 	var wg sync.WaitGroup
-	for i := 0; i < p.workers; i++ {
+	for i := 0; i < workers; i++ {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			p.worker(ctx, taskCh, resultCh)
+			workerCtx := ctx
+			if wc, ok := p.generator.(WorkerContexter); ok {
+				workerCtx = wc.WorkerContext(ctx)
+			}
+			p.worker(workerCtx, taskCh, resultCh)
 		}()
 	}
 
-	// Feed tasks
+	// Feed tasks. Once the context is cancelled, workers answer the remaining tasks with
+	// its error instead of generating them, so every task still gets a result.
 	go func() {
-		for _, task := range tasks {
-			select {
-			case taskCh <- task:
-			case <-ctx.Done():
-				// Context cancelled, stop feeding
-				break
-			}
+		for task := range tasks {
+			taskCh <- task
 		}
 		close(taskCh)
 	}()
 
 	// Collect results in a separate goroutine
-	results := make([]Result, 0, len(tasks))
 	done := make(chan struct{})
 
 	go func() {
 		for result := range resultCh {
-			results = append(results, result)
-
-			// Update progress
-			mu.Lock()
-			completed++
-			if result.Err != nil {
-				failed++
-			}
-			c, f := completed, failed
-			mu.Unlock()
-
-			if p.onProgress != nil {
-				p.onProgress(c, len(tasks), f)
+			if onResult != nil {
+				onResult(result)
 			}
+			tracker.add(result)
 		}
 		close(done)
 	}()
@@ -133,8 +277,6 @@ func (p *Pool) Run(ctx context.Context, tasks []Task) []Result {
 
 	// Wait for result collection to finish
 	<-done
-
-	return results
 }
 
 // worker processes tasks from the task channel and sends results to the result channel.
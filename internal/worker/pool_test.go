@@ -3,6 +3,7 @@ package worker
 import (
 	"context"
 	"errors"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -224,6 +225,92 @@ func TestPool_ProgressCallback(t *testing.T) {
 	}
 }
 
+func TestPool_ProgressEvents(t *testing.T) {
+	gen := &mockGenerator{
+		delay:     time.Millisecond,
+		failTiles: map[string]bool{"z13_x4297_y2755": true, "z14_x8596_y5510": true},
+	}
+
+	var events []ProgressEvent
+	var counted int
+	pool := New(Config{
+		Workers:        3,
+		WorkersPerZoom: map[uint32]int{14: 2},
+		Generator:      gen,
+		OnEvent:        func(e ProgressEvent) { events = append(events, e) },
+		OnProgress:     func(completed, total, failed int) { counted = completed },
+	})
+
+	tasks := []Task{
+		{Coords: tile.NewCoords(13, 4297, 2754)},
+		{Coords: tile.NewCoords(13, 4297, 2755)},
+		{Coords: tile.NewCoords(13, 4298, 2754)},
+		{Coords: tile.NewCoords(14, 8596, 5510)},
+		{Coords: tile.NewCoords(14, 8596, 5511)},
+	}
+	pool.Run(context.Background(), tasks)
+
+	if len(events) != len(tasks) {
+		t.Fatalf("got %d events, want one per task (%d)", len(events), len(tasks))
+	}
+	seen := make(map[tile.Coords]bool)
+	failed := 0
+	for i, e := range events {
+		if e.Completed != i+1 || e.Total != len(tasks) {
+			t.Errorf("event %d: completed %d/%d, want %d/%d", i, e.Completed, e.Total, i+1, len(tasks))
+		}
+		if e.LastErr != nil {
+			failed++
+			if !gen.failTiles[e.LastCoords.String()] {
+				t.Errorf("event %d: unexpected error for %s: %v", i, e.LastCoords.String(), e.LastErr)
+			}
+		}
+		if e.Failed != failed {
+			t.Errorf("event %d: failed = %d, want %d", i, e.Failed, failed)
+		}
+		seen[e.LastCoords] = true
+	}
+	if len(seen) != len(tasks) {
+		t.Errorf("events reported %d distinct tiles, want %d", len(seen), len(tasks))
+	}
+	if last := events[len(events)-1]; last.Failed != 2 {
+		t.Errorf("final failed = %d, want 2", last.Failed)
+	}
+	if counted != len(tasks) {
+		t.Errorf("OnProgress saw %d completed, want %d alongside OnEvent", counted, len(tasks))
+	}
+}
+
+func TestPool_ProgressCountsTiles(t *testing.T) {
+	gen := &mockGenerator{failTiles: map[string]bool{"z13_x4300_y2756": true}}
+
+	var events []ProgressEvent
+	pool := New(Config{
+		Workers:   1,
+		Generator: gen,
+		OnEvent:   func(e ProgressEvent) { events = append(events, e) },
+	})
+
+	// Metatile blocks of 16 tiles, one clipped to 4 tiles by the requested area
+	tasks := []Task{
+		{Coords: tile.NewCoords(13, 4296, 2752), Tiles: 16},
+		{Coords: tile.NewCoords(13, 4300, 2756), Tiles: 4},
+		{Coords: tile.NewCoords(13, 4304, 2760)},
+	}
+	pool.Run(context.Background(), tasks)
+
+	if len(events) != len(tasks) {
+		t.Fatalf("got %d events, want %d", len(events), len(tasks))
+	}
+	last := events[len(events)-1]
+	if last.Completed != 21 || last.Total != 21 {
+		t.Errorf("final progress %d/%d, want 21/21 tiles", last.Completed, last.Total)
+	}
+	if last.Failed != 4 {
+		t.Errorf("final failed = %d, want the 4 tiles of the failed block", last.Failed)
+	}
+}
+
 func TestPool_EmptyTasks(t *testing.T) {
 	gen := &mockGenerator{}
 
@@ -266,3 +353,211 @@ func TestPool_WithSuffix(t *testing.T) {
 		t.Errorf("Expected path with @2x suffix, got %s", results[0].Path)
 	}
 }
+
+func TestPool_WorkersForZoom(t *testing.T) {
+	pool := New(Config{
+		Workers:        3,
+		WorkersPerZoom: map[uint32]int{5: 1, 10: 4, 14: 8},
+	})
+
+	tests := []struct {
+		zoom uint32
+		want int
+	}{
+		{zoom: 2, want: 3},
+		{zoom: 5, want: 1},
+		{zoom: 9, want: 1},
+		{zoom: 10, want: 4},
+		{zoom: 13, want: 4},
+		{zoom: 14, want: 8},
+		{zoom: 18, want: 8},
+	}
+	for _, tt := range tests {
+		if got := pool.WorkersForZoom(tt.zoom); got != tt.want {
+			t.Errorf("WorkersForZoom(%d) = %d, want %d", tt.zoom, got, tt.want)
+		}
+	}
+}
+
+// concurrencyGenerator records the peak number of concurrent Generate calls per zoom.
+type concurrencyGenerator struct {
+	mu      sync.Mutex
+	active  int
+	zooms   map[uint32]bool
+	peak    map[uint32]int
+	overlap bool
+}
+
+func (g *concurrencyGenerator) Generate(ctx context.Context, coords tile.Coords, force bool, suffix string, debugCtx interface{}) (string, string, error) {
+	g.mu.Lock()
+	g.active++
+	g.zooms[coords.Z] = true
+	if len(g.zooms) > 1 {
+		g.overlap = true // Tasks of two zoom levels ran at the same time
+	}
+	g.peak[coords.Z] = max(g.peak[coords.Z], g.active)
+	g.mu.Unlock()
+
+	time.Sleep(10 * time.Millisecond)
+
+	g.mu.Lock()
+	g.active--
+	if g.active == 0 {
+		clear(g.zooms)
+	}
+	g.mu.Unlock()
+	return coords.String(), "", nil
+}
+
+func TestPool_WorkersPerZoom(t *testing.T) {
+	gen := &concurrencyGenerator{zooms: map[uint32]bool{}, peak: map[uint32]int{}}
+
+	var lastCompleted, lastTotal int
+	pool := New(Config{
+		Workers:        1,
+		WorkersPerZoom: map[uint32]int{10: 4},
+		Generator:      gen,
+		OnProgress: func(completed, total, failed int) {
+			lastCompleted, lastTotal = completed, total
+		},
+	})
+
+	var tasks []Task
+	for i := uint32(0); i < 8; i++ {
+		tasks = append(tasks, Task{Coords: tile.NewCoords(12, 100+i, 100)})
+		tasks = append(tasks, Task{Coords: tile.NewCoords(6, i, 0)})
+	}
+
+	results := pool.Run(context.Background(), tasks)
+	if len(results) != len(tasks) {
+		t.Fatalf("Expected %d results, got %d", len(tasks), len(results))
+	}
+	if gen.peak[6] != 1 {
+		t.Errorf("Expected z6 to run with 1 worker, peak concurrency was %d", gen.peak[6])
+	}
+	if gen.peak[12] < 2 || gen.peak[12] > 4 {
+		t.Errorf("Expected z12 to run with up to 4 workers, peak concurrency was %d", gen.peak[12])
+	}
+	if gen.overlap {
+		t.Error("Expected zoom levels to run one after another")
+	}
+	if results[0].Task.Coords.Z != 6 {
+		t.Errorf("Expected the lowest zoom to run first, got z%d", results[0].Task.Coords.Z)
+	}
+	if lastCompleted != len(tasks) || lastTotal != len(tasks) {
+		t.Errorf("Expected progress to reach %d/%d across zooms, got %d/%d", len(tasks), len(tasks), lastCompleted, lastTotal)
+	}
+}
+
+func TestPool_RunSeq(t *testing.T) {
+	gen := &concurrencyGenerator{zooms: map[uint32]bool{}, peak: map[uint32]int{}}
+
+	var lastCompleted, lastTotal int
+	pool := New(Config{
+		Workers:        1,
+		WorkersPerZoom: map[uint32]int{12: 4},
+		Generator:      gen,
+		OnProgress: func(completed, total, failed int) {
+			lastCompleted, lastTotal = completed, total
+		},
+	})
+
+	bbox := [4]float64{9.7, 52.3, 9.8, 52.4}
+	total := tile.TileCount(bbox, 10, 12)
+	tasks := func(yield func(Task) bool) {
+		for coords := range tile.TilesInBBoxIter(bbox, 10, 12) {
+			if !yield(Task{Coords: coords}) {
+				return
+			}
+		}
+	}
+
+	var results []Result
+	pool.RunSeq(context.Background(), tasks, total, func(r Result) {
+		results = append(results, r)
+	})
+	want := tile.TilesInBBox(bbox, 10, 12)
+	if len(results) != len(want) {
+		t.Fatalf("Expected %d results, got %d", len(want), len(results))
+	}
+	seen := make(map[tile.Coords]bool, len(results))
+	for _, r := range results {
+		seen[r.Task.Coords] = true
+	}
+	for _, c := range want {
+		if !seen[c] {
+			t.Errorf("Missing result for %s", c.String())
+		}
+	}
+	if gen.peak[10] != 1 || gen.peak[11] != 1 {
+		t.Errorf("Expected z10 and z11 to run with 1 worker, peak concurrency was %d and %d", gen.peak[10], gen.peak[11])
+	}
+	if gen.peak[12] < 2 || gen.peak[12] > 4 {
+		t.Errorf("Expected z12 to run with up to 4 workers, peak concurrency was %d", gen.peak[12])
+	}
+	if gen.overlap {
+		t.Error("Expected zoom levels to run one after another")
+	}
+	if lastCompleted != total || lastTotal != total {
+		t.Errorf("Expected progress to reach %d/%d, got %d/%d", total, total, lastCompleted, lastTotal)
+	}
+}
+
+// workerStateKey is the context key of workerStateGenerator's per-worker state.
+type workerStateKey struct{}
+
+// workerState is mutable state that must not be shared between goroutines.
+type workerState struct {
+	busy atomic.Bool
+}
+
+// workerStateGenerator hands every worker its own state and checks that no two tasks use
+// the same state at once.
+type workerStateGenerator struct {
+	mu     sync.Mutex
+	states map[*workerState]bool
+	shared atomic.Bool
+}
+
+func (g *workerStateGenerator) WorkerContext(ctx context.Context) context.Context {
+	s := &workerState{}
+	g.mu.Lock()
+	g.states[s] = true
+	g.mu.Unlock()
+	return context.WithValue(ctx, workerStateKey{}, s)
+}
+
+func (g *workerStateGenerator) Generate(ctx context.Context, coords tile.Coords, force bool, suffix string, debugCtx interface{}) (string, string, error) {
+	s, ok := ctx.Value(workerStateKey{}).(*workerState)
+	if !ok {
+		return "", "", errors.New("task ran without worker state")
+	}
+	if !s.busy.CompareAndSwap(false, true) {
+		g.shared.Store(true)
+	}
+	time.Sleep(time.Millisecond)
+	s.busy.Store(false)
+	return coords.String(), "", nil
+}
+
+func TestPool_WorkerContext(t *testing.T) {
+	gen := &workerStateGenerator{states: make(map[*workerState]bool)}
+	pool := New(Config{Workers: 3, Generator: gen})
+
+	var tasks []Task
+	for x := uint32(0); x < 30; x++ {
+		tasks = append(tasks, Task{Coords: tile.NewCoords(10, x, 0)})
+	}
+	for _, r := range pool.Run(context.Background(), tasks) {
+		if r.Err != nil {
+			t.Fatalf("task %s failed: %v", r.Task.Coords.String(), r.Err)
+		}
+	}
+
+	if len(gen.states) != 3 {
+		t.Errorf("created %d worker states, want one per worker (3)", len(gen.states))
+	}
+	if gen.shared.Load() {
+		t.Error("a worker state was used by two tasks at once")
+	}
+}
@@ -48,6 +48,11 @@ func (p *Progress) Callback() ProgressFunc {
 	return p.Update
 }
 
+// Handle records a ProgressEvent; pass it as Config.OnEvent.
+func (p *Progress) Handle(e ProgressEvent) {
+	p.Update(e.Completed, e.Total, e.Failed)
+}
+
 // Print displays the current progress to output.
 func (p *Progress) Print() {
 	p.mu.RLock()
@@ -128,6 +128,16 @@ func TestProgress_Callback(t *testing.T) {
 	}
 }
 
+func TestProgress_Handle(t *testing.T) {
+	p := NewProgress(10, false)
+
+	p.Handle(ProgressEvent{Completed: 4, Total: 10, Failed: 2})
+
+	if p.completed != 4 || p.failed != 2 || p.total != 10 {
+		t.Errorf("Expected 4/10 with 2 failed, got %d/%d with %d failed", p.completed, p.total, p.failed)
+	}
+}
+
 func TestFormatDuration(t *testing.T) {
 	tests := []struct {
 		expected string